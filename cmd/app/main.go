@@ -12,7 +12,9 @@ import (
 	"ecommerce_clean/pkgs/validation"
 	"sync"
 
+	addressEntity "ecommerce_clean/internals/address/entity"
 	cartEntity "ecommerce_clean/internals/cart/entity"
+	discountEntity "ecommerce_clean/internals/discount/entity"
 	orderEntity "ecommerce_clean/internals/order/entity"
 	productEntity "ecommerce_clean/internals/product/entity"
 	httpServer "ecommerce_clean/internals/server/http"
@@ -35,13 +37,26 @@ func main() {
 		logger.Fatal(err)
 	}
 
-	if err := database.AutoMigrate(
+	if err := database.Migrate(db.Migrations,
 		&userEntity.User{},
 		&productEntity.Product{},
+		&productEntity.Category{},
+		&productEntity.StockReservation{},
+		&productEntity.PriceHistory{},
+		&productEntity.ScheduledPriceChange{},
+		&productEntity.Review{},
+		&productEntity.Supplier{},
+		&productEntity.ProductTemplate{},
 		&orderEntity.Order{},
 		&orderEntity.OrderLine{},
+		&orderEntity.OrderStatusHistory{},
+		&orderEntity.OrderNote{},
+		&orderEntity.Refund{},
+		&addressEntity.Address{},
+		&discountEntity.Discount{},
 		&cartEntity.Cart{},
-		&cartEntity.CartLine{}); err != nil {
+		&cartEntity.CartLine{},
+		&cartEntity.GiftCard{}); err != nil {
 		logger.Fatal("Database migration fail", err)
 	}
 
@@ -13,6 +13,10 @@ const (
 	ProductionEnv      = "production" //production or development
 	DatabaseTimeout    = time.Second * 5
 	ProductCachingTime = time.Minute * 1
+	TaxRate            = 0.10
+	ShippingBaseCost   = 5.0
+	ShippingCostPerKg  = 1.5
+	CartTTL            = time.Hour * 24 * 30
 )
 
 type Config struct {
@@ -4,6 +4,8 @@ import (
 	"context"
 	"time"
 
+	"ecommerce_clean/pkgs/database"
+
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 	gormLogger "gorm.io/gorm/logger"
@@ -16,7 +18,8 @@ const (
 type IDatabase interface {
 	GetDB() *gorm.DB
 	AutoMigrate(models ...any) error
-	WithTransaction(function func() error) error
+	WithTransaction(ctx context.Context, function func(ctx context.Context) error) error
+	Conn(ctx context.Context) *gorm.DB
 	Create(ctx context.Context, doc any) error
 	CreateInBatches(ctx context.Context, docs any, batchSize int) error
 	Update(ctx context.Context, doc any) error
@@ -44,7 +47,13 @@ type Database struct {
 }
 
 func NewDatabase(uri string) (*Database, error) {
-	database, err := gorm.Open(postgres.Open(uri), &gorm.Config{
+	return Open(postgres.Open(uri))
+}
+
+// Open connects using the given GORM dialector, e.g. an in-memory SQLite
+// database in tests.
+func Open(dialector gorm.Dialector) (*Database, error) {
+	database, err := gorm.Open(dialector, &gorm.Config{
 		Logger: gormLogger.Default.LogMode(gormLogger.Warn),
 	})
 	if err != nil {
@@ -68,22 +77,22 @@ func (d *Database) AutoMigrate(models ...any) error {
 	return d.db.AutoMigrate(models...)
 }
 
-func (d *Database) WithTransaction(function func() error) error {
-	callback := func(db *gorm.DB) error {
-		return function()
-	}
-
-	tx := d.db.Begin()
-	if err := callback(tx); err != nil {
-		tx.Rollback()
-		return err
-	}
+// WithTransaction runs function in a transaction. Every call made with the ctx
+// passed to function, on this Database or through Conn, joins that transaction.
+func (d *Database) WithTransaction(ctx context.Context, function func(ctx context.Context) error) error {
+	return database.WithTransaction(ctx, d.db, func(tx *gorm.DB) error {
+		return function(tx.Statement.Context)
+	})
+}
 
-	if err := tx.Commit().Error; err != nil {
-		return err
+// Conn returns the transaction carried by ctx, if any, or the plain connection,
+// bound to ctx.
+func (d *Database) Conn(ctx context.Context) *gorm.DB {
+	if tx, ok := database.TxFromContext(ctx); ok {
+		return tx.WithContext(ctx)
 	}
 
-	return nil
+	return d.db.WithContext(ctx)
 }
 
 func (d *Database) Preload(query string, args ...interface{}) IDatabase {
@@ -95,28 +104,28 @@ func (d *Database) Create(ctx context.Context, doc any) error {
 	ctx, cancel := context.WithTimeout(ctx, DatabaseTimeout)
 	defer cancel()
 
-	return d.db.Create(doc).Error
+	return d.Conn(ctx).Create(doc).Error
 }
 
 func (d *Database) CreateInBatches(ctx context.Context, docs any, batchSize int) error {
 	ctx, cancel := context.WithTimeout(ctx, DatabaseTimeout)
 	defer cancel()
 
-	return d.db.CreateInBatches(docs, batchSize).Error
+	return d.Conn(ctx).CreateInBatches(docs, batchSize).Error
 }
 
 func (d *Database) Update(ctx context.Context, doc any) error {
 	ctx, cancel := context.WithTimeout(ctx, DatabaseTimeout)
 	defer cancel()
 
-	return d.db.Save(doc).Error
+	return d.Conn(ctx).Save(doc).Error
 }
 
 func (d *Database) Delete(ctx context.Context, value any, opts ...FindOption) error {
 	ctx, cancel := context.WithTimeout(ctx, DatabaseTimeout)
 	defer cancel()
 
-	query := d.applyOptions(opts...)
+	query := d.applyOptions(ctx, opts...)
 	return query.Delete(value).Error
 }
 
@@ -124,7 +133,7 @@ func (d *Database) FindById(ctx context.Context, id string, result any) error {
 	ctx, cancel := context.WithTimeout(ctx, DatabaseTimeout)
 	defer cancel()
 
-	if err := d.db.Where("id = ? ", id).First(result).Error; err != nil {
+	if err := d.Conn(ctx).Where("id = ? ", id).First(result).Error; err != nil {
 		return err
 	}
 
@@ -135,7 +144,7 @@ func (d *Database) FindOne(ctx context.Context, result any, opts ...FindOption)
 	ctx, cancel := context.WithTimeout(ctx, DatabaseTimeout)
 	defer cancel()
 
-	query := d.applyOptions(opts...)
+	query := d.applyOptions(ctx, opts...)
 	if err := query.First(result).Error; err != nil {
 		return err
 	}
@@ -147,7 +156,7 @@ func (d *Database) Find(ctx context.Context, result any, opts ...FindOption) err
 	ctx, cancel := context.WithTimeout(ctx, DatabaseTimeout)
 	defer cancel()
 
-	query := d.applyOptions(opts...)
+	query := d.applyOptions(ctx, opts...)
 	if err := query.Find(result).Error; err != nil {
 		return err
 	}
@@ -159,7 +168,7 @@ func (d *Database) Count(ctx context.Context, model any, total *int64, opts ...F
 	ctx, cancel := context.WithTimeout(ctx, DatabaseTimeout)
 	defer cancel()
 
-	query := d.applyOptions(opts...)
+	query := d.applyOptions(ctx, opts...)
 	if err := query.Model(model).Count(total).Error; err != nil {
 		return err
 	}
@@ -171,8 +180,8 @@ func (d *Database) GetDB() *gorm.DB {
 	return d.db
 }
 
-func (d *Database) applyOptions(opts ...FindOption) *gorm.DB {
-	query := d.db
+func (d *Database) applyOptions(ctx context.Context, opts ...FindOption) *gorm.DB {
+	query := d.Conn(ctx)
 
 	opt := getOption(opts...)
 
@@ -184,7 +193,7 @@ func (d *Database) applyOptions(opts ...FindOption) *gorm.DB {
 
 	if opt.query != nil {
 		for _, q := range opt.query {
-			query = query.Where(q.Query, q.Args)
+			query = query.Where(q.Query, q.Args...)
 		}
 	}
 
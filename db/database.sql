@@ -16,7 +16,7 @@ CREATE TABLE "Products"(
     "id" VARCHAR PRIMARY KEY DEFAULT gen_random_uuid() NOT NULL,
     "code" VARCHAR(255) NOT NULL,
     "name" VARCHAR(255) NOT NULL,
-    "image_url" VARCHAR(255) NOT NULL,
+    "images" TEXT NULL,
     "description" VARCHAR(255) NOT NULL,
     "created_at" DATE NOT NULL,
     "updated_at" DATE NOT NULL,
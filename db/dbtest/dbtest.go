@@ -0,0 +1,31 @@
+// Package dbtest opens throwaway databases for repository tests.
+package dbtest
+
+import (
+	"testing"
+
+	"ecommerce_clean/db"
+
+	"github.com/glebarez/sqlite"
+	"github.com/stretchr/testify/require"
+)
+
+// NewDatabase opens an in-memory SQLite database with the tables of models
+// and closes it when the test ends. It keeps a single connection, since each
+// connection to "file::memory:" would otherwise get a database of its own.
+func NewDatabase(t testing.TB, models ...any) *db.Database {
+	t.Helper()
+
+	database, err := db.Open(sqlite.Open("file::memory:"))
+	require.NoError(t, err)
+
+	sqlDB, err := database.GetDB().DB()
+	require.NoError(t, err)
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { _ = sqlDB.Close() })
+
+	if len(models) > 0 {
+		require.NoError(t, database.AutoMigrate(models...))
+	}
+	return database
+}
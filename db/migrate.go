@@ -0,0 +1,77 @@
+package db
+
+import (
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Migration is a schema change AutoMigrate cannot make on its own, such as
+// dropping a constraint or moving data out of a column before it goes. Each
+// migration runs once; the names of the applied ones are kept in the
+// schema_migrations table.
+type Migration struct {
+	Name string
+	// BeforeAutoMigrate runs the migration ahead of AutoMigrate, for changes
+	// AutoMigrate would otherwise fail on.
+	BeforeAutoMigrate bool
+	Up                func(tx *gorm.DB) error
+}
+
+type schemaMigration struct {
+	Name      string `gorm:"primaryKey;size:100"`
+	AppliedAt time.Time
+}
+
+func (schemaMigration) TableName() string {
+	return "schema_migrations"
+}
+
+// Migrate auto migrates models and runs the migrations not applied yet, in
+// order: those marked BeforeAutoMigrate first, the rest afterwards. Each
+// migration runs in its own transaction.
+func (d *Database) Migrate(migrations []Migration, models ...any) error {
+	if err := d.db.AutoMigrate(&schemaMigration{}); err != nil {
+		return err
+	}
+
+	if err := d.runMigrations(migrations, true); err != nil {
+		return err
+	}
+
+	if err := d.db.AutoMigrate(models...); err != nil {
+		return err
+	}
+
+	return d.runMigrations(migrations, false)
+}
+
+func (d *Database) runMigrations(migrations []Migration, beforeAutoMigrate bool) error {
+	for _, migration := range migrations {
+		if migration.BeforeAutoMigrate != beforeAutoMigrate {
+			continue
+		}
+
+		err := d.db.Transaction(func(tx *gorm.DB) error {
+			var applied int64
+			if err := tx.Model(&schemaMigration{}).Where("name = ?", migration.Name).Count(&applied).Error; err != nil {
+				return err
+			}
+			if applied > 0 {
+				return nil
+			}
+
+			if err := migration.Up(tx); err != nil {
+				return err
+			}
+
+			return tx.Create(&schemaMigration{Name: migration.Name, AppliedAt: time.Now()}).Error
+		})
+		if err != nil {
+			return fmt.Errorf("migration %s: %w", migration.Name, err)
+		}
+	}
+
+	return nil
+}
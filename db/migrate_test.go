@@ -0,0 +1,49 @@
+package db_test
+
+import (
+	"testing"
+
+	"ecommerce_clean/db"
+	"ecommerce_clean/db/dbtest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+type migratedItem struct {
+	ID   uint
+	Name string
+}
+
+// TestMigrate verifica que cada migración se aplica una sola vez y que las
+// marcadas BeforeAutoMigrate corren antes de AutoMigrate y el resto después.
+func TestMigrate(t *testing.T) {
+	database := dbtest.NewDatabase(t)
+
+	var runs []string
+	migrations := []db.Migration{
+		{
+			Name: "after",
+			Up: func(tx *gorm.DB) error {
+				assert.True(t, tx.Migrator().HasTable(&migratedItem{}))
+				runs = append(runs, "after")
+				return nil
+			},
+		},
+		{
+			Name:              "before",
+			BeforeAutoMigrate: true,
+			Up: func(tx *gorm.DB) error {
+				assert.False(t, tx.Migrator().HasTable(&migratedItem{}))
+				runs = append(runs, "before")
+				return nil
+			},
+		},
+	}
+
+	require.NoError(t, database.Migrate(migrations, &migratedItem{}))
+	require.NoError(t, database.Migrate(migrations, &migratedItem{}))
+
+	assert.Equal(t, []string{"before", "after"}, runs)
+}
@@ -0,0 +1,78 @@
+package db
+
+import (
+	"encoding/json"
+
+	"gorm.io/gorm"
+)
+
+// Migrations are the schema changes made on top of AutoMigrate, oldest
+// first. Append new ones; never edit or reorder applied ones.
+var Migrations = []Migration{
+	{
+		// Carts were unique per user; now only the active cart is, through
+		// the unique_active_cart_user index, so checked out carts are kept.
+		// The constraint name depends on the GORM version that created it.
+		Name:              "20261016_carts_user_id_not_unique",
+		BeforeAutoMigrate: true,
+		Up: func(tx *gorm.DB) error {
+			for _, constraint := range []string{"uni_carts_user_id", "carts_user_id_key"} {
+				if err := tx.Exec("ALTER TABLE IF EXISTS carts DROP CONSTRAINT IF EXISTS " + constraint).Error; err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	},
+	{
+		// Products had a single image_url; they now keep a list of images.
+		// Each image_url becomes the only entry of images, unless images was
+		// already filled in, and the column goes with its unique constraint.
+		Name: "20261016_products_image_url_to_images",
+		Up: func(tx *gorm.DB) error {
+			if !tx.Migrator().HasColumn(&legacyProductImage{}, "image_url") {
+				return nil
+			}
+
+			var products []legacyProductImage
+			err := tx.Model(&legacyProductImage{}).
+				Select("id", "image_url").
+				Where("image_url <> ''").
+				Where("images IS NULL OR images IN ('', 'null', '[]')").
+				Find(&products).Error
+			if err != nil {
+				return err
+			}
+
+			for _, product := range products {
+				images, err := json.Marshal([]string{product.ImageURL})
+				if err != nil {
+					return err
+				}
+				if err := tx.Model(&legacyProductImage{}).Where("id = ?", product.ID).Update("images", string(images)).Error; err != nil {
+					return err
+				}
+			}
+
+			const constraint = "uni_products_image_url"
+			if tx.Migrator().HasConstraint(&legacyProductImage{}, constraint) {
+				if err := tx.Migrator().DropConstraint(&legacyProductImage{}, constraint); err != nil {
+					return err
+				}
+			}
+
+			return tx.Migrator().DropColumn(&legacyProductImage{}, "image_url")
+		},
+	},
+}
+
+// legacyProductImage is the part of a product row the image_url migration
+// reads.
+type legacyProductImage struct {
+	ID       string
+	ImageURL string `gorm:"column:image_url"`
+}
+
+func (legacyProductImage) TableName() string {
+	return "products"
+}
@@ -0,0 +1,68 @@
+package db_test
+
+import (
+	"testing"
+
+	"ecommerce_clean/db"
+	"ecommerce_clean/db/dbtest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func migrationByName(t *testing.T, name string) db.Migration {
+	for _, migration := range db.Migrations {
+		if migration.Name == name {
+			return migration
+		}
+	}
+	t.Fatalf("migration %s not found", name)
+	return db.Migration{}
+}
+
+// productBeforeImages is the products table as it was before images.
+type productBeforeImages struct {
+	ID       string `gorm:"primaryKey"`
+	ImageURL string `gorm:"unique;not null"`
+	Images   *string
+}
+
+func (productBeforeImages) TableName() string {
+	return "products"
+}
+
+// TestMigration_ProductsImageURLToImages verifica que cada image_url pasa a
+// ser la única imagen del producto, sin pisar las imágenes ya guardadas, y
+// que la columna desaparece.
+func TestMigration_ProductsImageURLToImages(t *testing.T) {
+	database := dbtest.NewDatabase(t)
+	conn := database.GetDB()
+
+	require.NoError(t, conn.AutoMigrate(&productBeforeImages{}))
+	require.NoError(t, conn.Exec(`INSERT INTO products (id, image_url, images) VALUES
+		('p1', 'https://img/1.png', NULL),
+		('p2', 'https://img/2.png', '["https://img/2a.png"]'),
+		('p3', '', NULL)`).Error)
+
+	migration := migrationByName(t, "20261016_products_image_url_to_images")
+	require.NoError(t, database.Migrate([]db.Migration{migration}))
+
+	assert.False(t, conn.Migrator().HasColumn("products", "image_url"))
+
+	images := map[string]*string{}
+	rows, err := conn.Table("products").Select("id", "images").Rows()
+	require.NoError(t, err)
+	defer rows.Close()
+	for rows.Next() {
+		var id string
+		var value *string
+		require.NoError(t, rows.Scan(&id, &value))
+		images[id] = value
+	}
+
+	require.NotNil(t, images["p1"])
+	assert.Equal(t, `["https://img/1.png"]`, *images["p1"])
+	require.NotNil(t, images["p2"])
+	assert.Equal(t, `["https://img/2a.png"]`, *images["p2"])
+	assert.Nil(t, images["p3"])
+}
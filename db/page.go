@@ -0,0 +1,36 @@
+package db
+
+import (
+	"context"
+
+	"ecommerce_clean/pkgs/paging"
+)
+
+// FindPage counts the rows of model matching the query of opts and loads the
+// page req asks for into result. A nil req gives the first page of the default
+// size. opts should not set a limit or offset, the page decides them.
+func FindPage(ctx context.Context, d IDatabase, model, result any, req *paging.Pagination, opts ...FindOption) (*paging.Pagination, error) {
+	opt := getOption(opts...)
+
+	var total int64
+	if err := d.Count(ctx, model, &total, WithQuery(opt.query...)); err != nil {
+		return nil, err
+	}
+
+	var page, size int64
+	if req != nil {
+		page, size = req.Page, req.Size
+	}
+	pagination := paging.NewPagination(page, size, total)
+
+	findOpts := append([]FindOption{}, opts...)
+	findOpts = append(findOpts,
+		WithLimit(int(pagination.Size)),
+		WithOffset(int(pagination.Skip)),
+	)
+	if err := d.Find(ctx, result, findOpts...); err != nil {
+		return nil, err
+	}
+
+	return pagination, nil
+}
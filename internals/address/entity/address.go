@@ -0,0 +1,31 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type Address struct {
+	ID         string          `json:"id" gorm:"unique;not null;index;primary_key"`
+	UserID     string          `json:"user_id" gorm:"not null;index"`
+	Street     string          `json:"street"`
+	City       string          `json:"city"`
+	State      string          `json:"state"`
+	PostalCode string          `json:"postal_code"`
+	Country    string          `json:"country"`
+	CreatedAt  time.Time       `json:"created_at"`
+	UpdatedAt  time.Time       `json:"updated_at"`
+	DeletedAt  *gorm.DeletedAt `json:"deleted_at" gorm:"index"`
+}
+
+func (address *Address) BeforeCreate(tx *gorm.DB) error {
+	address.ID = uuid.New().String()
+
+	return nil
+}
+
+func (address *Address) TableName() string {
+	return "addresses"
+}
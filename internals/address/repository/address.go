@@ -0,0 +1,28 @@
+package repository
+
+import (
+	"context"
+	"ecommerce_clean/db"
+	"ecommerce_clean/internals/address/entity"
+)
+
+type IAddressRepository interface {
+	GetAddressByID(ctx context.Context, id string) (*entity.Address, error)
+}
+
+type AddressRepository struct {
+	db db.IDatabase
+}
+
+func NewAddressRepository(db db.IDatabase) *AddressRepository {
+	return &AddressRepository{db: db}
+}
+
+func (r *AddressRepository) GetAddressByID(ctx context.Context, id string) (*entity.Address, error) {
+	var address entity.Address
+	if err := r.db.FindById(ctx, id, &address); err != nil {
+		return nil, err
+	}
+
+	return &address, nil
+}
@@ -1,9 +1,11 @@
 package dto
 
 type Cart struct {
-	ID    string      `json:"id"`
-	User  *User       `json:"user"`
-	Lines []*CartLine `json:"lines"`
+	ID             string      `json:"id"`
+	User           *User       `json:"user"`
+	Lines          []*CartLine `json:"lines"`
+	DiscountID     *string     `json:"discount_id,omitempty"`
+	DiscountAmount float64     `json:"discount_amount"`
 }
 
 type CartLine struct {
@@ -19,14 +21,29 @@ type AddProductRequest struct {
 	Quantity  int    `json:"quantity" validate:"required"`
 }
 
+// BulkAddProductsRequest adds several products to one cart. The CartID of
+// each line is ignored in favour of the request's CartID.
+type BulkAddProductsRequest struct {
+	CartID string              `json:"cart_id" validate:"required"`
+	Lines  []AddProductRequest `json:"lines" validate:"required,min=1,max=100"`
+}
+
 type UpdateCartLineRequest struct {
 	ID        string `json:"id" validate:"required"`
 	CartID    string `json:"cart_id" validate:"required"`
 	ProductID string `json:"product_id" validate:"required"`
-	Quantity  int    `json:"quantity" validate:"required"`
+	Quantity  int    `json:"quantity" validate:"min=0"`
 }
 
 type RemoveProductRequest struct {
 	CartID    string `json:"cart_id" validate:"required"`
 	ProductID string `json:"product_id" validate:"required"`
 }
+
+// PriceDriftItem is a cart line whose unit price no longer matches the
+// product's current price. Both prices are per unit.
+type PriceDriftItem struct {
+	ProductID    string  `json:"product_id"`
+	CartPrice    float64 `json:"cart_price"`
+	CurrentPrice float64 `json:"current_price"`
+}
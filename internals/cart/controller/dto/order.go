@@ -0,0 +1,18 @@
+package dto
+
+import "time"
+
+type OrderResponse struct {
+	ID         string               `json:"id"`
+	Code       string               `json:"code"`
+	Lines      []*OrderLineResponse `json:"lines"`
+	TotalPrice float64              `json:"total_price"`
+	Status     string               `json:"status"`
+	CreatedAt  time.Time            `json:"created_at"`
+}
+
+type OrderLineResponse struct {
+	ProductID string  `json:"product_id"`
+	Quantity  uint    `json:"quantity"`
+	Price     float64 `json:"price"`
+}
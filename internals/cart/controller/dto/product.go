@@ -1,10 +1,10 @@
 package dto
 
 type Product struct {
-	ID          string  `json:"id"`
-	Code        string  `json:"code"`
-	Name        string  `json:"name"`
-	ImageUrl    string  `json:"image_url"`
-	Description string  `json:"description"`
-	Price       float64 `json:"price"`
+	ID          string   `json:"id"`
+	Code        string   `json:"code"`
+	Name        string   `json:"name"`
+	Images      []string `json:"images"`
+	Description string   `json:"description"`
+	Price       float64  `json:"price"`
 }
@@ -12,6 +12,9 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
+// maxIdempotencyKeyLength is the size of the orders.idempotency_key column.
+const maxIdempotencyKeyLength = 100
+
 type CartHandler struct {
 	usecase usecase.ICartUseCase
 }
@@ -94,7 +97,7 @@ func (h *CartHandler) AddProductToCart(c *gin.Context) {
 }
 
 // @Summary			Update a cart line item
-// @Description		Updates the quantity or details of a specific product in the authenticated user's shopping cart.
+// @Description		Updates the quantity of a specific product in the authenticated user's shopping cart. A quantity of 0 removes the product.
 // @Tags			Carts
 // @Accept			json
 // @Produce			json
@@ -168,3 +171,58 @@ func (h *CartHandler) RemoveProductToCart(c *gin.Context) {
 
 	response.JSON(c, http.StatusOK, "Remove product from cart successfully")
 }
+
+// @Summary			Check out the user's cart
+// @Description		Creates an order from the authenticated user's cart and empties the cart.
+// @Tags			Carts
+// @Produce			json
+// @Param			country			query	string	true	"ISO 3166-1 alpha-2 code of the country the order is taxed in"
+// @Param			Idempotency-Key	header	string	false	"Key that makes retries return the order already placed (max 100 characters)"
+// @Success			201	{object}	dto.OrderResponse	"Order created from cart"
+// @Failure			400	{object}	response.Response	"Bad Request - Cart is empty, the country is not supported or the idempotency key is too long"
+// @Failure			401	{object}	response.Response	"Unauthorized - Authentication failed"
+// @Failure			409	{object}	response.Response	"Conflict - Insufficient stock, a cart product changed price, or the idempotency key belongs to another user"
+// @Failure			422	{object}	response.Response	"Unprocessable Entity - A cart product is no longer available"
+// @Failure			500	{object}	response.Response	"Internal Server Error - An error occurred while processing the request"
+// @Router			/cart/checkout [post]
+// @Security		ApiKeyAuth
+func (h *CartHandler) Checkout(c *gin.Context) {
+	userID := c.GetString("userId")
+	if userID == "" {
+		response.Error(c, http.StatusUnauthorized, errors.New("unauthorized"), "Unauthorized")
+		return
+	}
+
+	idempotencyKey := c.GetHeader("Idempotency-Key")
+	if len(idempotencyKey) > maxIdempotencyKeyLength {
+		response.Error(c, http.StatusBadRequest, errors.New("idempotency key too long"), "Invalid idempotency key")
+		return
+	}
+
+	order, err := h.usecase.Checkout(c, userID, c.Query("country"), idempotencyKey)
+	if err != nil {
+		logger.Errorf("Failed to check out cart, user: %s, error: %s", userID, err)
+		var drift usecase.ErrPriceDriftDetected
+		switch {
+		case errors.Is(err, usecase.ErrEmptyCart):
+			response.Error(c, http.StatusBadRequest, err, "Cart is empty")
+		case errors.Is(err, usecase.ErrUnsupportedCountry):
+			response.Error(c, http.StatusBadRequest, err, "Unsupported country")
+		case errors.Is(err, usecase.ErrInsufficientStock):
+			response.Error(c, http.StatusConflict, err, "Insufficient stock")
+		case errors.As(err, &drift):
+			response.Error(c, http.StatusConflict, err, "Price changed for some cart items")
+		case errors.Is(err, usecase.ErrProductInactive):
+			response.Error(c, http.StatusUnprocessableEntity, err, "Product is not available")
+		case errors.Is(err, usecase.ErrIdempotencyKeyConflict):
+			response.Error(c, http.StatusConflict, err, "Idempotency key already used")
+		default:
+			response.Error(c, http.StatusInternalServerError, err, "Failed to check out")
+		}
+		return
+	}
+
+	var res dto.OrderResponse
+	utils.MapStruct(&res, order)
+	response.JSON(c, http.StatusCreated, res)
+}
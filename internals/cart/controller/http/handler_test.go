@@ -0,0 +1,174 @@
+package http_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	cartDto "ecommerce_clean/internals/cart/controller/dto"
+	cartHttp "ecommerce_clean/internals/cart/controller/http"
+	"ecommerce_clean/internals/cart/usecase"
+	orderEntity "ecommerce_clean/internals/order/entity"
+	"ecommerce_clean/pkgs/logger"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// -------------------
+// Mocks
+// -------------------
+
+// MockCartUseCase solo implementa los métodos usados en estos tests; el resto
+// queda cubierto por la interfaz embebida.
+type MockCartUseCase struct {
+	usecase.ICartUseCase
+	mock.Mock
+}
+
+func (m *MockCartUseCase) Checkout(ctx context.Context, userID, countryCode, idempotencyKey string) (*orderEntity.Order, error) {
+	args := m.Called(userID, countryCode, idempotencyKey)
+	if v := args.Get(0); v != nil {
+		return v.(*orderEntity.Order), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func TestMain(m *testing.M) {
+	logger.Initialize("test")
+	gin.SetMode(gin.TestMode)
+	os.Exit(m.Run())
+}
+
+func performCheckoutRequest(uc usecase.ICartUseCase, userID, idempotencyKey string) *httptest.ResponseRecorder {
+	handler := cartHttp.NewCartHandler(uc)
+	router := gin.New()
+	router.POST("/cart/checkout", func(c *gin.Context) {
+		c.Set("userId", userID)
+		handler.Checkout(c)
+	})
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/cart/checkout?country=ES", nil)
+	if idempotencyKey != "" {
+		req.Header.Set("Idempotency-Key", idempotencyKey)
+	}
+	router.ServeHTTP(w, req)
+	return w
+}
+
+// -------------------------------------
+// Tests de Checkout
+// -------------------------------------
+
+// TestCheckout_Created verifica que se devuelve 201 con el pedido creado.
+func TestCheckout_Created(t *testing.T) {
+	uc := new(MockCartUseCase)
+	uc.On("Checkout", "u1", "ES", "").Return(&orderEntity.Order{
+		ID:         "o1",
+		UserID:     "u1",
+		TotalPrice: 30,
+		Lines: []*orderEntity.OrderLine{
+			{ProductID: "p1", Quantity: 3, Price: 30},
+		},
+	}, nil)
+
+	w := performCheckoutRequest(uc, "u1", "")
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+
+	var body struct {
+		Data struct {
+			ID         string  `json:"id"`
+			TotalPrice float64 `json:"total_price"`
+			Lines      []struct {
+				ProductID string  `json:"product_id"`
+				Quantity  uint    `json:"quantity"`
+				Price     float64 `json:"price"`
+			} `json:"lines"`
+		} `json:"data"`
+	}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	assert.Equal(t, "o1", body.Data.ID)
+	assert.Equal(t, 30.0, body.Data.TotalPrice)
+	assert.Len(t, body.Data.Lines, 1)
+	assert.Equal(t, "p1", body.Data.Lines[0].ProductID)
+	assert.Equal(t, uint(3), body.Data.Lines[0].Quantity)
+	uc.AssertExpectations(t)
+}
+
+// TestCheckout_ErrorStatus verifica el código HTTP para cada error del caso de uso.
+func TestCheckout_ErrorStatus(t *testing.T) {
+	cases := []struct {
+		name   string
+		err    error
+		status int
+	}{
+		{"empty cart", usecase.ErrEmptyCart, http.StatusBadRequest},
+		{"unsupported country", usecase.ErrUnsupportedCountry, http.StatusBadRequest},
+		{"insufficient stock", usecase.ErrInsufficientStock, http.StatusConflict},
+		{"price drift", usecase.ErrPriceDriftDetected{Items: []*cartDto.PriceDriftItem{{ProductID: "p1"}}}, http.StatusConflict},
+		{"product inactive", usecase.ErrProductInactive, http.StatusUnprocessableEntity},
+		{"idempotency key of another user", usecase.ErrIdempotencyKeyConflict, http.StatusConflict},
+		{"unexpected", errors.New("db down"), http.StatusInternalServerError},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			uc := new(MockCartUseCase)
+			uc.On("Checkout", "u1", "ES", "").Return(nil, tc.err)
+
+			w := performCheckoutRequest(uc, "u1", "")
+
+			assert.Equal(t, tc.status, w.Code)
+
+			var body struct {
+				Data struct {
+					Message string `json:"message"`
+				} `json:"data"`
+			}
+			assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+			assert.NotEmpty(t, body.Data.Message)
+		})
+	}
+}
+
+// TestCheckout_Unauthorized verifica que sin usuario en el contexto no se
+// llama al caso de uso.
+func TestCheckout_Unauthorized(t *testing.T) {
+	uc := new(MockCartUseCase)
+
+	w := performCheckoutRequest(uc, "", "")
+
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	uc.AssertNotCalled(t, "Checkout", mock.Anything, mock.Anything, mock.Anything)
+}
+
+// TestCheckout_IdempotencyKeyHeader verifica que la cabecera Idempotency-Key
+// llega al caso de uso.
+func TestCheckout_IdempotencyKeyHeader(t *testing.T) {
+	uc := new(MockCartUseCase)
+	uc.On("Checkout", "u1", "ES", "k1").Return(&orderEntity.Order{ID: "o1", UserID: "u1"}, nil)
+
+	w := performCheckoutRequest(uc, "u1", "k1")
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+	uc.AssertExpectations(t)
+}
+
+// TestCheckout_IdempotencyKeyTooLong verifica que una clave más larga que la
+// columna se rechaza sin llamar al caso de uso.
+func TestCheckout_IdempotencyKeyTooLong(t *testing.T) {
+	uc := new(MockCartUseCase)
+
+	w := performCheckoutRequest(uc, "u1", strings.Repeat("k", 101))
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	uc.AssertNotCalled(t, "Checkout", mock.Anything, mock.Anything, mock.Anything)
+}
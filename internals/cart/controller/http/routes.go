@@ -1,6 +1,7 @@
 package http
 
 import (
+	"ecommerce_clean/configs"
 	"ecommerce_clean/db"
 	"ecommerce_clean/internals/cart/usecase"
 	"ecommerce_clean/pkgs/middlewares"
@@ -11,6 +12,8 @@ import (
 	"github.com/gin-gonic/gin"
 
 	cartRepo "ecommerce_clean/internals/cart/repository"
+	orderRepo "ecommerce_clean/internals/order/repository"
+	orderUseCase "ecommerce_clean/internals/order/usecase"
 	productRepo "ecommerce_clean/internals/product/repository"
 )
 
@@ -24,7 +27,11 @@ func Routes(
 
 	cartRepository := cartRepo.NewCartRepository(sqlDB)
 	productRepository := productRepo.NewProductRepository(sqlDB)
-	cartUseCase := usecase.NewCartUseCase(validator, cartRepository, productRepository)
+	orderRepository := orderRepo.NewOrderRepository(sqlDB)
+	giftCardRepository := cartRepo.NewGiftCardRepository(sqlDB)
+	shippingCalculator := orderUseCase.NewFlatRateShippingCalculator(configs.ShippingBaseCost, configs.ShippingCostPerKg)
+	taxProvider := usecase.NewCountryTaxProvider(configs.TaxRate)
+	cartUseCase := usecase.NewCartUseCase(validator, cartRepository, productRepository, orderRepository, giftCardRepository, shippingCalculator, taxProvider)
 	cartHandler := NewCartHandler(cartUseCase)
 
 	authMiddleware := middlewares.NewAuthMiddleware(token, cache).TokenAuth()
@@ -36,4 +43,9 @@ func Routes(
 		cartRoute.PUT("/cart-line/:userID", cartHandler.UpdateCartLine)
 		cartRoute.DELETE("/:userID", cartHandler.RemoveProductToCart)
 	}
+
+	checkoutRoute := r.Group("/cart", authMiddleware)
+	{
+		checkoutRoute.POST("/checkout", cartHandler.Checkout)
+	}
 }
@@ -1,28 +1,128 @@
 package entity
 
 import (
+	"errors"
 	"time"
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
 
+var (
+	ErrCartUserRequired     = errors.New("cart user id is required")
+	ErrDuplicateCartProduct = errors.New("cart has duplicate product lines")
+	ErrInvalidLineQuantity  = errors.New("cart line quantity must be positive")
+	ErrNegativeLinePrice    = errors.New("cart line price must not be negative")
+)
+
+type CartStatus string
+
+// A cart is active until checked out. Checked out carts are kept with their
+// lines and the user gets a new active cart on next use.
+const (
+	CartStatusActive     CartStatus = "active"
+	CartStatusCheckedOut CartStatus = "checked_out"
+)
+
 type Cart struct {
-	ID        string      `json:"id" gorm:"unique;not null;index;primary_key"`
-	UserID    string      `json:"user_id" gorm:"unique;not null;index"`
-	Lines     []*CartLine `json:"lines"`
-	User      *User
-	CreatedAt time.Time       `json:"created_at"`
-	UpdatedAt time.Time       `json:"updated_at"`
-	DeletedAt *gorm.DeletedAt `json:"deleted_at" gorm:"index"`
+	ID             string      `json:"id" gorm:"unique;not null;index;primary_key"`
+	UserID         string      `json:"user_id" gorm:"not null;index;uniqueIndex:unique_active_cart_user,where:status = 'active' AND deleted_at IS NULL"`
+	Lines          []*CartLine `json:"lines"`
+	User           *User
+	GiftCardID     *string         `json:"gift_card_id"`
+	GiftCardAmount float64         `json:"gift_card_amount" gorm:"default:0"`
+	DiscountID     *string         `json:"discount_id"`
+	DiscountAmount float64         `json:"discount_amount" gorm:"default:0"`
+	ExpiresAt      *time.Time      `json:"expires_at" gorm:"index"`
+	Status         CartStatus      `json:"status" gorm:"default:active"`
+	CreatedAt      time.Time       `json:"created_at"`
+	UpdatedAt      time.Time       `json:"updated_at"`
+	DeletedAt      *gorm.DeletedAt `json:"deleted_at" gorm:"index"`
+}
+
+// NewCart returns a cart for the user that expires ttl from now. A ttl of zero
+// or less gives a cart that never expires.
+func NewCart(userID string, ttl time.Duration) *Cart {
+	cart := &Cart{
+		ID:     uuid.New().String(),
+		UserID: userID,
+		Status: CartStatusActive,
+	}
+	cart.Touch(ttl)
+
+	return cart
+}
+
+// Touch starts a new expiry period of ttl from now, called whenever the cart
+// changes. A ttl of zero or less leaves the cart without expiry.
+func (cart *Cart) Touch(ttl time.Duration) {
+	if ttl <= 0 {
+		cart.ExpiresAt = nil
+		return
+	}
+
+	expiresAt := time.Now().Add(ttl)
+	cart.ExpiresAt = &expiresAt
+}
+
+// Reset empties the cart so the user can start over with it: lines, discount
+// and gift card are dropped. An empty cart has nothing to expire, so its
+// expiry time is cleared until the next change.
+func (cart *Cart) Reset() {
+	cart.Lines = nil
+	cart.GiftCardID = nil
+	cart.GiftCardAmount = 0
+	cart.DiscountID = nil
+	cart.DiscountAmount = 0
+	cart.ExpiresAt = nil
+}
+
+// IsExpired reports whether the cart's expiry time has passed at now.
+func (cart *Cart) IsExpired(now time.Time) bool {
+	return cart.ExpiresAt != nil && cart.ExpiresAt.Before(now)
+}
+
+// IsActive reports whether the cart can still be changed. Carts saved before
+// the status was tracked have no status and count as active.
+func (cart *Cart) IsActive() bool {
+	return cart.Status == "" || cart.Status == CartStatusActive
 }
 
 func (cart *Cart) BeforeCreate(tx *gorm.DB) error {
 	cart.ID = uuid.New().String()
 
+	if cart.Status == "" {
+		cart.Status = CartStatusActive
+	}
+
 	return nil
 }
 
 func (cart *Cart) TableName() string {
 	return "carts"
 }
+
+// Validate checks the cart invariants that must hold before it is persisted.
+func (cart *Cart) Validate() error {
+	if cart.UserID == "" {
+		return ErrCartUserRequired
+	}
+
+	seen := make(map[string]struct{}, len(cart.Lines))
+	for _, line := range cart.Lines {
+		if _, ok := seen[line.ProductID]; ok {
+			return ErrDuplicateCartProduct
+		}
+		seen[line.ProductID] = struct{}{}
+
+		if line.Quantity <= 0 {
+			return ErrInvalidLineQuantity
+		}
+
+		if line.Price < 0 {
+			return ErrNegativeLinePrice
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,100 @@
+package entity_test
+
+import (
+	"testing"
+	"time"
+
+	cartEntity "ecommerce_clean/internals/cart/entity"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func validCart() *cartEntity.Cart {
+	return &cartEntity.Cart{
+		ID:     "c1",
+		UserID: "u1",
+		Lines: []*cartEntity.CartLine{
+			{ProductID: "p1", Quantity: 2, Price: 20},
+			{ProductID: "p2", Quantity: 1, Price: 0},
+		},
+	}
+}
+
+// TestCartValidate_Valid verifica que un carrito correcto no devuelve error.
+func TestCartValidate_Valid(t *testing.T) {
+	assert.NoError(t, validCart().Validate())
+}
+
+// TestCartValidate_MissingUser verifica que el carrito necesita un usuario.
+func TestCartValidate_MissingUser(t *testing.T) {
+	cart := validCart()
+	cart.UserID = ""
+
+	assert.ErrorIs(t, cart.Validate(), cartEntity.ErrCartUserRequired)
+}
+
+// TestCartValidate_DuplicateProduct verifica que un producto no puede aparecer
+// en dos líneas.
+func TestCartValidate_DuplicateProduct(t *testing.T) {
+	cart := validCart()
+	cart.Lines = append(cart.Lines, &cartEntity.CartLine{ProductID: "p1", Quantity: 1, Price: 10})
+
+	assert.ErrorIs(t, cart.Validate(), cartEntity.ErrDuplicateCartProduct)
+}
+
+// TestCartValidate_ZeroQuantity verifica que una línea con cantidad cero es inválida.
+func TestCartValidate_ZeroQuantity(t *testing.T) {
+	cart := validCart()
+	cart.Lines[1].Quantity = 0
+
+	assert.ErrorIs(t, cart.Validate(), cartEntity.ErrInvalidLineQuantity)
+}
+
+// TestCartValidate_NegativePrice verifica que una línea con precio negativo es inválida.
+func TestCartValidate_NegativePrice(t *testing.T) {
+	cart := validCart()
+	cart.Lines[0].Price = -1
+
+	assert.ErrorIs(t, cart.Validate(), cartEntity.ErrNegativeLinePrice)
+}
+
+// TestCartTouch verifica que Touch renueva el vencimiento y que un ttl nulo lo
+// quita.
+func TestCartTouch(t *testing.T) {
+	cart := validCart()
+	past := time.Now().Add(-time.Hour)
+	cart.ExpiresAt = &past
+
+	cart.Touch(time.Hour)
+	assert.False(t, cart.IsExpired(time.Now()))
+	assert.WithinDuration(t, time.Now().Add(time.Hour), *cart.ExpiresAt, time.Minute)
+
+	cart.Touch(0)
+	assert.Nil(t, cart.ExpiresAt)
+}
+
+// TestCartReset verifica que Reset vacía el carrito y lo deja sin
+// vencimiento, sin cambiar su estado.
+func TestCartReset(t *testing.T) {
+	cart := validCart()
+	giftCardID, discountID := "g1", "d1"
+	expiresAt := time.Now()
+	cart.GiftCardID = &giftCardID
+	cart.GiftCardAmount = 10
+	cart.DiscountID = &discountID
+	cart.DiscountAmount = 5
+	cart.ExpiresAt = &expiresAt
+	cart.Status = cartEntity.CartStatusActive
+
+	cart.Reset()
+
+	assert.Equal(t, "c1", cart.ID)
+	assert.Equal(t, "u1", cart.UserID)
+	assert.Empty(t, cart.Lines)
+	assert.Nil(t, cart.GiftCardID)
+	assert.Zero(t, cart.GiftCardAmount)
+	assert.Nil(t, cart.DiscountID)
+	assert.Zero(t, cart.DiscountAmount)
+	assert.Nil(t, cart.ExpiresAt)
+	assert.Equal(t, cartEntity.CartStatusActive, cart.Status)
+}
@@ -0,0 +1,14 @@
+package entity
+
+import "time"
+
+type CheckoutSummary struct {
+	Subtotal          float64   `json:"subtotal"`
+	ShippingCost      float64   `json:"shipping_cost"`
+	TaxAmount         float64   `json:"tax_amount"`
+	DiscountAmount    float64   `json:"discount_amount"`
+	GiftCardAmount    float64   `json:"gift_card_amount"`
+	GrandTotal        float64   `json:"grand_total"`
+	EstimatedDelivery time.Time `json:"estimated_delivery"`
+	ItemCount         int       `json:"item_count"`
+}
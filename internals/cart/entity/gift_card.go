@@ -0,0 +1,29 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type GiftCard struct {
+	ID        string          `json:"id" gorm:"unique;not null;index;primary_key"`
+	Code      string          `json:"code" gorm:"uniqueIndex:unique_gift_card_code;not null"`
+	Balance   float64         `json:"balance"`
+	ExpiresAt time.Time       `json:"expires_at"`
+	IsUsed    bool            `json:"is_used" gorm:"default:false"`
+	CreatedAt time.Time       `json:"created_at"`
+	UpdatedAt time.Time       `json:"updated_at"`
+	DeletedAt *gorm.DeletedAt `json:"deleted_at" gorm:"index"`
+}
+
+func (giftCard *GiftCard) BeforeCreate(tx *gorm.DB) error {
+	giftCard.ID = uuid.New().String()
+
+	return nil
+}
+
+func (giftCard *GiftCard) TableName() string {
+	return "gift_cards"
+}
@@ -0,0 +1,10 @@
+package entity
+
+// PriceChangedLine is a cart line whose product unit price is no longer the one
+// it was added at. NewPrice is 0 when the product is inactive or gone.
+type PriceChangedLine struct {
+	*CartLine
+	OldPrice      float64 `json:"old_price"`
+	NewPrice      float64 `json:"new_price"`
+	PercentChange float64 `json:"percent_change"`
+}
@@ -0,0 +1,18 @@
+package entity
+
+const (
+	CartIssueOutOfStock      = "out_of_stock"
+	CartIssueProductInactive = "product_inactive"
+	CartIssuePriceChanged    = "price_changed"
+	CartIssueProductNotFound = "product_not_found"
+)
+
+type CartIssue struct {
+	ProductID string `json:"product_id"`
+	IssueType string `json:"issue_type"`
+}
+
+type ValidationReport struct {
+	IsValid bool        `json:"is_valid"`
+	Issues  []CartIssue `json:"issues"`
+}
@@ -2,16 +2,40 @@ package repository
 
 import (
 	"context"
+	"ecommerce_clean/configs"
 	"ecommerce_clean/db"
 	"ecommerce_clean/internals/cart/entity"
+	"ecommerce_clean/pkgs/paging"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+const abandonedCartsLimit = 1000
+
 type ICartRepository interface {
 	GetCartByUserID(ctx context.Context, userID string) (*entity.Cart, error)
+	GetCartByID(ctx context.Context, cartID string) (*entity.Cart, error)
 	GetCartLineByProductIDAndCartID(ctx context.Context, cartID string, productID string) (*entity.CartLine, error)
+	CreateCart(ctx context.Context, cart *entity.Cart) error
 	CreateCartLine(ctx context.Context, cartLine *entity.CartLine) error
 	UpdateCartLine(ctx context.Context, cartLine *entity.CartLine) error
 	RemoveCartLine(ctx context.Context, cartLine *entity.CartLine) error
+	DeleteAllCartLines(ctx context.Context, cartID string) error
+	UpdateCartStatus(ctx context.Context, cartID string, status entity.CartStatus) error
+	UpdateCart(ctx context.Context, cart *entity.Cart) error
+	TouchCart(ctx context.Context, cart *entity.Cart) error
+	UpdateCartGiftCard(ctx context.Context, cart *entity.Cart) error
+	ResetCart(ctx context.Context, cart *entity.Cart) error
+	WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error
+	GetAbandonedCarts(ctx context.Context, updatedBefore time.Time) ([]*entity.Cart, error)
+	GetExpiredCarts(ctx context.Context, before time.Time) ([]*entity.Cart, error)
+	GetCartIDByUserID(ctx context.Context, userID string) (string, error)
+	SumCartLinesPrices(ctx context.Context, cartID string) (float64, error)
+	GetCartLineByID(ctx context.Context, lineID string) (*entity.CartLine, error)
+	UpdateLastViewed(ctx context.Context, cartID, productID string, viewedAt time.Time) error
+	GetCartsByProductID(ctx context.Context, productID string, req *paging.Pagination) ([]*entity.Cart, *paging.Pagination, error)
 }
 
 type CartRepository struct {
@@ -22,10 +46,15 @@ func NewCartRepository(db db.IDatabase) *CartRepository {
 	return &CartRepository{db: db}
 }
 
+// GetCartByUserID returns the user's active cart. Checked out carts are
+// kept but not returned.
 func (cr *CartRepository) GetCartByUserID(ctx context.Context, userID string) (*entity.Cart, error) {
 	var cart entity.Cart
 	opts := []db.FindOption{
-		db.WithQuery(db.NewQuery("user_id = ?", userID)),
+		db.WithQuery(
+			db.NewQuery("user_id = ?", userID),
+			db.NewQuery("status = ?", entity.CartStatusActive),
+		),
 	}
 	opts = append(opts, db.WithPreload([]string{"User", "Lines.Product"}))
 
@@ -36,11 +65,27 @@ func (cr *CartRepository) GetCartByUserID(ctx context.Context, userID string) (*
 	return &cart, nil
 }
 
+func (cr *CartRepository) GetCartByID(ctx context.Context, cartID string) (*entity.Cart, error) {
+	var cart entity.Cart
+	opts := []db.FindOption{
+		db.WithQuery(db.NewQuery("id = ?", cartID)),
+	}
+	opts = append(opts, db.WithPreload([]string{"Lines.Product"}))
+
+	if err := cr.db.FindOne(ctx, &cart, opts...); err != nil {
+		return nil, err
+	}
+
+	return &cart, nil
+}
+
 func (cr *CartRepository) GetCartLineByProductIDAndCartID(ctx context.Context, cartID string, productID string) (*entity.CartLine, error) {
 	var cartLine entity.CartLine
 	opts := []db.FindOption{
-		db.WithQuery(db.NewQuery("cart_id = ?", cartID)),
-		db.WithQuery(db.NewQuery("product_id = ?", productID)),
+		db.WithQuery(
+			db.NewQuery("cart_id = ?", cartID),
+			db.NewQuery("product_id = ?", productID),
+		),
 	}
 
 	if err := cr.db.FindOne(ctx, &cartLine, opts...); err != nil {
@@ -50,6 +95,10 @@ func (cr *CartRepository) GetCartLineByProductIDAndCartID(ctx context.Context, c
 	return &cartLine, nil
 }
 
+func (cr *CartRepository) CreateCart(ctx context.Context, cart *entity.Cart) error {
+	return cr.db.Create(ctx, cart)
+}
+
 func (cr *CartRepository) CreateCartLine(ctx context.Context, cartLine *entity.CartLine) error {
 	return cr.db.Create(ctx, cartLine)
 }
@@ -61,3 +110,206 @@ func (cr *CartRepository) UpdateCartLine(ctx context.Context, cartLine *entity.C
 func (cr *CartRepository) RemoveCartLine(ctx context.Context, cartLine *entity.CartLine) error {
 	return cr.db.Delete(ctx, cartLine)
 }
+
+// DeleteAllCartLines soft deletes every line of the cart in one statement.
+func (cr *CartRepository) DeleteAllCartLines(ctx context.Context, cartID string) error {
+	ctx, cancel := context.WithTimeout(ctx, configs.DatabaseTimeout)
+	defer cancel()
+
+	return cr.db.Conn(ctx).
+		Where("cart_id = ?", cartID).
+		Delete(&entity.CartLine{}).Error
+}
+
+// UpdateCart saves the cart's own columns. Loaded lines and user are not
+// written back.
+func (cr *CartRepository) UpdateCart(ctx context.Context, cart *entity.Cart) error {
+	ctx, cancel := context.WithTimeout(ctx, configs.DatabaseTimeout)
+	defer cancel()
+
+	return cr.db.Conn(ctx).Omit(clause.Associations).Save(cart).Error
+}
+
+// TouchCart saves the cart columns that follow a change to its lines: the
+// expiry time and the applied discount. UpdatedAt moves as well, so it tracks
+// every change to the cart.
+func (cr *CartRepository) TouchCart(ctx context.Context, cart *entity.Cart) error {
+	ctx, cancel := context.WithTimeout(ctx, configs.DatabaseTimeout)
+	defer cancel()
+
+	return cr.db.Conn(ctx).
+		Model(cart).
+		Select("expires_at", "discount_id", "discount_amount", "updated_at").
+		Updates(cart).Error
+}
+
+// UpdateCartGiftCard saves only the cart's gift card and the amount it
+// covers.
+func (cr *CartRepository) UpdateCartGiftCard(ctx context.Context, cart *entity.Cart) error {
+	ctx, cancel := context.WithTimeout(ctx, configs.DatabaseTimeout)
+	defer cancel()
+
+	return cr.db.Conn(ctx).
+		Model(cart).
+		Select("gift_card_id", "gift_card_amount").
+		Updates(cart).Error
+}
+
+// ResetCart deletes every line of the cart and saves its own columns, as set
+// by Cart.Reset, in one transaction.
+func (cr *CartRepository) ResetCart(ctx context.Context, cart *entity.Cart) error {
+	return cr.db.WithTransaction(ctx, func(ctx context.Context) error {
+		if err := cr.DeleteAllCartLines(ctx, cart.ID); err != nil {
+			return err
+		}
+
+		return cr.UpdateCart(ctx, cart)
+	})
+}
+
+// WithinTransaction runs fn in a database transaction. Repository calls made
+// with the ctx passed to fn join that transaction.
+func (cr *CartRepository) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	return cr.db.WithTransaction(ctx, fn)
+}
+
+func (cr *CartRepository) UpdateCartStatus(ctx context.Context, cartID string, status entity.CartStatus) error {
+	ctx, cancel := context.WithTimeout(ctx, configs.DatabaseTimeout)
+	defer cancel()
+
+	return cr.db.Conn(ctx).
+		Model(&entity.Cart{}).
+		Where("id = ?", cartID).
+		Update("status", status).Error
+}
+
+func (cr *CartRepository) GetAbandonedCarts(ctx context.Context, updatedBefore time.Time) ([]*entity.Cart, error) {
+	var carts []*entity.Cart
+	opts := []db.FindOption{
+		db.WithQuery(
+			db.NewQuery("updated_at < ?", updatedBefore),
+			db.NewQuery("user_id IS NOT NULL"),
+			db.NewQuery("status = ?", entity.CartStatusActive),
+		),
+		db.WithOrder("updated_at ASC"),
+		db.WithLimit(abandonedCartsLimit),
+	}
+
+	if err := cr.db.Find(ctx, &carts, opts...); err != nil {
+		return nil, err
+	}
+
+	return carts, nil
+}
+
+// GetExpiredCarts returns the active carts whose expiry time is before the
+// given time, oldest first, with their lines. Carts without an expiry time
+// never expire.
+func (cr *CartRepository) GetExpiredCarts(ctx context.Context, before time.Time) ([]*entity.Cart, error) {
+	var carts []*entity.Cart
+	opts := []db.FindOption{
+		db.WithQuery(
+			db.NewQuery("expires_at IS NOT NULL"),
+			db.NewQuery("expires_at < ?", before),
+			db.NewQuery("status = ?", entity.CartStatusActive),
+		),
+		db.WithPreload([]string{"Lines"}),
+		db.WithOrder("expires_at ASC"),
+		db.WithLimit(abandonedCartsLimit),
+	}
+
+	if err := cr.db.Find(ctx, &carts, opts...); err != nil {
+		return nil, err
+	}
+
+	return carts, nil
+}
+
+func (cr *CartRepository) GetCartIDByUserID(ctx context.Context, userID string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, configs.DatabaseTimeout)
+	defer cancel()
+
+	var cart entity.Cart
+	err := cr.db.Conn(ctx).
+		Select("id").
+		Where("user_id = ? AND status = ?", userID, entity.CartStatusActive).
+		First(&cart).Error
+	if err != nil {
+		return "", err
+	}
+
+	return cart.ID, nil
+}
+
+func (cr *CartRepository) SumCartLinesPrices(ctx context.Context, cartID string) (float64, error) {
+	ctx, cancel := context.WithTimeout(ctx, configs.DatabaseTimeout)
+	defer cancel()
+
+	var total float64
+	err := cr.db.Conn(ctx).
+		Model(&entity.CartLine{}).
+		Select("COALESCE(SUM(price), 0)").
+		Where("cart_id = ?", cartID).
+		Scan(&total).Error
+	if err != nil {
+		return 0, err
+	}
+
+	return total, nil
+}
+
+func (cr *CartRepository) GetCartLineByID(ctx context.Context, lineID string) (*entity.CartLine, error) {
+	var cartLine entity.CartLine
+	if err := cr.db.FindById(ctx, lineID, &cartLine); err != nil {
+		return nil, err
+	}
+
+	return &cartLine, nil
+}
+
+// UpdateLastViewed stamps when the user last viewed the product of a cart
+// line. It returns gorm.ErrRecordNotFound when the cart has no such line.
+func (cr *CartRepository) UpdateLastViewed(ctx context.Context, cartID, productID string, viewedAt time.Time) error {
+	ctx, cancel := context.WithTimeout(ctx, configs.DatabaseTimeout)
+	defer cancel()
+
+	result := cr.db.Conn(ctx).
+		Model(&entity.CartLine{}).
+		Where("cart_id = ? AND product_id = ?", cartID, productID).
+		Update("last_viewed_at", viewedAt)
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+
+	return nil
+}
+
+// GetCartsByProductID pages through the active carts holding a line for the
+// product, most recently updated first, with their lines.
+func (cr *CartRepository) GetCartsByProductID(ctx context.Context, productID string, req *paging.Pagination) ([]*entity.Cart, *paging.Pagination, error) {
+	query := db.NewQuery(
+		"id IN (SELECT cart_id FROM cart_lines WHERE product_id = ? AND deleted_at IS NULL)",
+		productID,
+	)
+
+	var carts []*entity.Cart
+	pagination, err := db.FindPage(
+		ctx,
+		cr.db,
+		&entity.Cart{},
+		&carts,
+		req,
+		db.WithPreload([]string{"Lines"}),
+		db.WithQuery(query, db.NewQuery("status = ?", entity.CartStatusActive)),
+		db.WithOrder("updated_at DESC"),
+	)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return carts, pagination, nil
+}
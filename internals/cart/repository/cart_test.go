@@ -0,0 +1,236 @@
+package repository_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"ecommerce_clean/db"
+	"ecommerce_clean/db/dbtest"
+	cartEntity "ecommerce_clean/internals/cart/entity"
+	"ecommerce_clean/internals/cart/repository"
+	"ecommerce_clean/pkgs/paging"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func newTestDatabase(t *testing.T) *db.Database {
+	return dbtest.NewDatabase(t, &cartEntity.Cart{}, &cartEntity.CartLine{})
+}
+
+// TestGetExpiredCarts verifica que solo se devuelven los carritos vencidos,
+// con sus líneas, y que no se borra ninguno.
+func TestGetExpiredCarts(t *testing.T) {
+	database := newTestDatabase(t)
+	repo := repository.NewCartRepository(database)
+	ctx := context.Background()
+
+	past := time.Now().Add(-time.Hour)
+	expired := &cartEntity.Cart{UserID: "u1", ExpiresAt: &past}
+	require.NoError(t, database.Create(ctx, expired))
+	require.NoError(t, database.Create(ctx, &cartEntity.CartLine{CartID: expired.ID, ProductID: "p1", Quantity: 1, Price: 10}))
+	require.NoError(t, database.Create(ctx, &cartEntity.Cart{UserID: "u2", ExpiresAt: &past}))
+	require.NoError(t, database.Create(ctx, cartEntity.NewCart("u3", time.Hour)))
+	require.NoError(t, database.Create(ctx, cartEntity.NewCart("u4", 0)))
+
+	carts, err := repo.GetExpiredCarts(ctx, time.Now())
+	require.NoError(t, err)
+	require.Len(t, carts, 2)
+
+	byUser := map[string]*cartEntity.Cart{}
+	for _, cart := range carts {
+		byUser[cart.UserID] = cart
+	}
+	require.Contains(t, byUser, "u1")
+	require.Contains(t, byUser, "u2")
+	assert.Len(t, byUser["u1"].Lines, 1)
+
+	_, err = repo.GetCartByUserID(ctx, "u1")
+	assert.NoError(t, err)
+}
+
+// TestResetCart verifica que ResetCart borra las líneas y guarda el carrito
+// vacío, de modo que el mismo usuario puede seguir usándolo.
+func TestResetCart(t *testing.T) {
+	database := newTestDatabase(t)
+	repo := repository.NewCartRepository(database)
+	ctx := context.Background()
+
+	past := time.Now().Add(-time.Hour)
+	discountID := "d1"
+	cart := &cartEntity.Cart{UserID: "u1", ExpiresAt: &past, DiscountID: &discountID, DiscountAmount: 5}
+	require.NoError(t, database.Create(ctx, cart))
+	require.NoError(t, database.Create(ctx, &cartEntity.CartLine{CartID: cart.ID, ProductID: "p1", Quantity: 1, Price: 10}))
+
+	cart.Reset()
+	require.NoError(t, repo.ResetCart(ctx, cart))
+
+	got, err := repo.GetCartByID(ctx, cart.ID)
+	require.NoError(t, err)
+	assert.Empty(t, got.Lines)
+	assert.Nil(t, got.DiscountID)
+	assert.Zero(t, got.DiscountAmount)
+	assert.Nil(t, got.ExpiresAt)
+	assert.Equal(t, cartEntity.CartStatusActive, got.Status)
+
+	require.NoError(t, database.Create(ctx, &cartEntity.CartLine{CartID: cart.ID, ProductID: "p2", Quantity: 1, Price: 10}))
+	got, err = repo.GetCartByUserID(ctx, "u1")
+	require.NoError(t, err)
+	assert.Len(t, got.Lines, 1)
+}
+
+// TestUpdateCart_DiscountFields verifica que el descuento guardado con
+// UpdateCart vuelve en GetCartByUserID.
+func TestUpdateCart_DiscountFields(t *testing.T) {
+	database := newTestDatabase(t)
+	repo := repository.NewCartRepository(database)
+	ctx := context.Background()
+
+	cart := cartEntity.NewCart("u1", 0)
+	require.NoError(t, database.Create(ctx, cart))
+
+	discountID := "d1"
+	cart.DiscountID = &discountID
+	cart.DiscountAmount = 4.5
+	require.NoError(t, repo.UpdateCart(ctx, cart))
+
+	got, err := repo.GetCartByUserID(ctx, "u1")
+	require.NoError(t, err)
+	if assert.NotNil(t, got.DiscountID) {
+		assert.Equal(t, "d1", *got.DiscountID)
+	}
+	assert.Equal(t, 4.5, got.DiscountAmount)
+}
+
+// TestTouchCart verifica que TouchCart guarda el vencimiento y el descuento,
+// también cuando se quitan, y mueve UpdatedAt sin tocar la tarjeta regalo.
+func TestTouchCart(t *testing.T) {
+	database := newTestDatabase(t)
+	repo := repository.NewCartRepository(database)
+	ctx := context.Background()
+
+	discountID, giftCardID := "d1", "g1"
+	cart := cartEntity.NewCart("u1", time.Hour)
+	cart.DiscountID = &discountID
+	cart.DiscountAmount = 5
+	require.NoError(t, database.Create(ctx, cart))
+
+	created, err := repo.GetCartByID(ctx, cart.ID)
+	require.NoError(t, err)
+	time.Sleep(time.Millisecond)
+
+	stale := *cart
+	stale.GiftCardID = &giftCardID
+	stale.GiftCardAmount = 20
+	stale.Touch(0)
+	stale.DiscountID = nil
+	stale.DiscountAmount = 0
+	require.NoError(t, repo.TouchCart(ctx, &stale))
+
+	got, err := repo.GetCartByID(ctx, cart.ID)
+	require.NoError(t, err)
+	assert.Nil(t, got.ExpiresAt)
+	assert.Nil(t, got.DiscountID)
+	assert.Zero(t, got.DiscountAmount)
+	assert.Nil(t, got.GiftCardID)
+	assert.Zero(t, got.GiftCardAmount)
+	assert.True(t, got.UpdatedAt.After(created.UpdatedAt))
+}
+
+// TestGetCartLineByProductIDAndCartID_ScopedToCart verifica que, con dos
+// carritos que tienen el mismo producto, se devuelve la línea del carrito
+// pedido y no la del otro.
+func TestGetCartLineByProductIDAndCartID_ScopedToCart(t *testing.T) {
+	database := newTestDatabase(t)
+	repo := repository.NewCartRepository(database)
+	ctx := context.Background()
+
+	cartA := cartEntity.NewCart("u1", 0)
+	cartB := cartEntity.NewCart("u2", 0)
+	require.NoError(t, database.Create(ctx, cartA))
+	require.NoError(t, database.Create(ctx, cartB))
+
+	lineB := &cartEntity.CartLine{CartID: cartB.ID, ProductID: "p1", Quantity: 5, Price: 50}
+	require.NoError(t, database.Create(ctx, lineB))
+	lineA := &cartEntity.CartLine{CartID: cartA.ID, ProductID: "p1", Quantity: 1, Price: 10}
+	require.NoError(t, database.Create(ctx, lineA))
+
+	got, err := repo.GetCartLineByProductIDAndCartID(ctx, cartA.ID, "p1")
+	require.NoError(t, err)
+	assert.Equal(t, lineA.ID, got.ID)
+
+	got, err = repo.GetCartLineByProductIDAndCartID(ctx, cartB.ID, "p1")
+	require.NoError(t, err)
+	assert.Equal(t, lineB.ID, got.ID)
+
+	_, err = repo.GetCartLineByProductIDAndCartID(ctx, cartA.ID, "p2")
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+}
+
+// TestGetCartsByProductID verifica que solo se devuelven los carritos con una
+// línea viva del producto, los más recientes primero y paginados.
+func TestGetCartsByProductID(t *testing.T) {
+	database := newTestDatabase(t)
+	repo := repository.NewCartRepository(database)
+	ctx := context.Background()
+
+	var carts []*cartEntity.Cart
+	for i, userID := range []string{"u1", "u2", "u3", "u4"} {
+		cart := cartEntity.NewCart(userID, 0)
+		require.NoError(t, database.Create(ctx, cart))
+		require.NoError(t, database.GetDB().Model(cart).UpdateColumn("updated_at", time.Now().Add(time.Duration(i)*time.Minute)).Error)
+		carts = append(carts, cart)
+	}
+	require.NoError(t, database.Create(ctx, &cartEntity.CartLine{CartID: carts[0].ID, ProductID: "p1", Quantity: 1, Price: 10}))
+	require.NoError(t, database.Create(ctx, &cartEntity.CartLine{CartID: carts[1].ID, ProductID: "p1", Quantity: 1, Price: 10}))
+	require.NoError(t, database.Create(ctx, &cartEntity.CartLine{CartID: carts[2].ID, ProductID: "p1", Quantity: 1, Price: 10}))
+	require.NoError(t, database.Create(ctx, &cartEntity.CartLine{CartID: carts[3].ID, ProductID: "p2", Quantity: 1, Price: 10}))
+
+	removed := &cartEntity.CartLine{CartID: carts[3].ID, ProductID: "p1", Quantity: 1, Price: 10}
+	require.NoError(t, database.Create(ctx, removed))
+	require.NoError(t, database.Delete(ctx, removed))
+
+	got, pagination, err := repo.GetCartsByProductID(ctx, "p1", &paging.Pagination{Page: 1, Size: 2})
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), pagination.TotalCount)
+	require.Len(t, got, 2)
+	assert.Equal(t, carts[2].ID, got[0].ID)
+	assert.Equal(t, carts[1].ID, got[1].ID)
+	assert.Len(t, got[0].Lines, 1)
+
+	got, _, err = repo.GetCartsByProductID(ctx, "p1", &paging.Pagination{Page: 2, Size: 2})
+	require.NoError(t, err)
+	require.Len(t, got, 1)
+	assert.Equal(t, carts[0].ID, got[0].ID)
+}
+
+// TestGetCartByUserID_SkipsCheckedOut verifica que un carrito pagado se
+// conserva pero ya no es el del usuario, que puede empezar otro, y que solo
+// puede tener un carrito activo a la vez.
+func TestGetCartByUserID_SkipsCheckedOut(t *testing.T) {
+	database := newTestDatabase(t)
+	repo := repository.NewCartRepository(database)
+	ctx := context.Background()
+
+	checkedOut := cartEntity.NewCart("u1", 0)
+	require.NoError(t, repo.CreateCart(ctx, checkedOut))
+	require.NoError(t, repo.UpdateCartStatus(ctx, checkedOut.ID, cartEntity.CartStatusCheckedOut))
+
+	_, err := repo.GetCartByUserID(ctx, "u1")
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+
+	active := cartEntity.NewCart("u1", 0)
+	require.NoError(t, repo.CreateCart(ctx, active))
+
+	got, err := repo.GetCartByUserID(ctx, "u1")
+	require.NoError(t, err)
+	assert.Equal(t, active.ID, got.ID)
+
+	old, err := repo.GetCartByID(ctx, checkedOut.ID)
+	require.NoError(t, err)
+	assert.Equal(t, cartEntity.CartStatusCheckedOut, old.Status)
+
+	assert.Error(t, repo.CreateCart(ctx, cartEntity.NewCart("u1", 0)))
+}
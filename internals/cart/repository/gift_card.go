@@ -0,0 +1,93 @@
+package repository
+
+import (
+	"context"
+	"ecommerce_clean/configs"
+	"ecommerce_clean/db"
+	"ecommerce_clean/internals/cart/entity"
+	"errors"
+
+	"gorm.io/gorm"
+)
+
+// ErrGiftCardBalanceTooLow is returned by ApplyGiftCard when the card no
+// longer has the amount the cart wants to take from it.
+var ErrGiftCardBalanceTooLow = errors.New("gift card balance too low")
+
+type IGiftCardRepository interface {
+	GetGiftCardByCode(ctx context.Context, code string) (*entity.GiftCard, error)
+	ApplyGiftCard(ctx context.Context, cart *entity.Cart) error
+	ReleaseGiftCard(ctx context.Context, giftCardID string, amount float64) error
+}
+
+type GiftCardRepository struct {
+	db db.IDatabase
+}
+
+func NewGiftCardRepository(db db.IDatabase) *GiftCardRepository {
+	return &GiftCardRepository{db: db}
+}
+
+func (r *GiftCardRepository) GetGiftCardByCode(ctx context.Context, code string) (*entity.GiftCard, error) {
+	var giftCard entity.GiftCard
+	if err := r.db.FindOne(ctx, &giftCard, db.WithQuery(db.NewQuery("code = ?", code))); err != nil {
+		return nil, err
+	}
+
+	return &giftCard, nil
+}
+
+// ApplyGiftCard saves the cart's gift card fields, including its renewed
+// expiry time, and takes cart.GiftCardAmount off the card's balance in one
+// transaction. The balance is decremented in the database, so two carts
+// cannot spend the same credit. Nothing is saved when the cart already holds a
+// gift card, which returns gorm.ErrRecordNotFound, or when the card's balance
+// is below the amount, which returns ErrGiftCardBalanceTooLow.
+func (r *GiftCardRepository) ApplyGiftCard(ctx context.Context, cart *entity.Cart) error {
+	return r.db.WithTransaction(ctx, func(ctx context.Context) error {
+		result := r.db.Conn(ctx).
+			Model(cart).
+			Where("gift_card_id IS NULL").
+			Select("gift_card_id", "gift_card_amount", "expires_at", "updated_at").
+			Updates(cart)
+		if result.Error != nil {
+			return result.Error
+		}
+
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+
+		result = r.db.Conn(ctx).
+			Model(&entity.GiftCard{}).
+			Where("id = ? AND is_used = ? AND balance >= ?", *cart.GiftCardID, false, cart.GiftCardAmount).
+			Updates(map[string]interface{}{
+				"balance": gorm.Expr("balance - ?", cart.GiftCardAmount),
+				"is_used": gorm.Expr("balance - ? <= 0", cart.GiftCardAmount),
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+
+		if result.RowsAffected == 0 {
+			return ErrGiftCardBalanceTooLow
+		}
+
+		return nil
+	})
+}
+
+// ReleaseGiftCard gives amount back to the card's balance, for a cart that
+// gave up the card without checking out.
+func (r *GiftCardRepository) ReleaseGiftCard(ctx context.Context, giftCardID string, amount float64) error {
+	ctx, cancel := context.WithTimeout(ctx, configs.DatabaseTimeout)
+	defer cancel()
+
+	return r.db.Conn(ctx).
+		Model(&entity.GiftCard{}).
+		Where("id = ?", giftCardID).
+		Updates(map[string]interface{}{
+			"balance": gorm.Expr("balance + ?", amount),
+			"is_used": false,
+		}).Error
+}
@@ -0,0 +1,104 @@
+package repository_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	cartEntity "ecommerce_clean/internals/cart/entity"
+	"ecommerce_clean/internals/cart/repository"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// TestApplyGiftCard_SecondCardRejected verifica que un carrito con tarjeta
+// regalo no acepta otra y que el saldo de la segunda tarjeta no se toca.
+func TestApplyGiftCard_SecondCardRejected(t *testing.T) {
+	database := newTestDatabase(t)
+	require.NoError(t, database.AutoMigrate(&cartEntity.GiftCard{}))
+	repo := repository.NewGiftCardRepository(database)
+	ctx := context.Background()
+
+	cart := cartEntity.NewCart("u1", time.Hour)
+	require.NoError(t, database.Create(ctx, cart))
+	first := &cartEntity.GiftCard{Code: "FIRST", Balance: 50, ExpiresAt: time.Now().Add(time.Hour)}
+	second := &cartEntity.GiftCard{Code: "SECOND", Balance: 50, ExpiresAt: time.Now().Add(time.Hour)}
+	require.NoError(t, database.Create(ctx, first))
+	require.NoError(t, database.Create(ctx, second))
+
+	cart.GiftCardID = &first.ID
+	cart.GiftCardAmount = 30
+	require.NoError(t, repo.ApplyGiftCard(ctx, cart))
+
+	stale := *cart
+	stale.GiftCardID = &second.ID
+	stale.GiftCardAmount = 30
+	err := repo.ApplyGiftCard(ctx, &stale)
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+
+	got, err := repo.GetGiftCardByCode(ctx, "SECOND")
+	require.NoError(t, err)
+	assert.Equal(t, 50.0, got.Balance)
+
+	got, err = repo.GetGiftCardByCode(ctx, "FIRST")
+	require.NoError(t, err)
+	assert.Equal(t, 20.0, got.Balance)
+	assert.False(t, got.IsUsed)
+}
+
+// TestApplyGiftCard_NoDoubleSpend verifica que dos carritos que leyeron el
+// mismo saldo no pueden gastarlo dos veces: el segundo recibe
+// ErrGiftCardBalanceTooLow y su carrito no se modifica.
+func TestApplyGiftCard_NoDoubleSpend(t *testing.T) {
+	database := newTestDatabase(t)
+	require.NoError(t, database.AutoMigrate(&cartEntity.GiftCard{}))
+	repo := repository.NewGiftCardRepository(database)
+	cartRepo := repository.NewCartRepository(database)
+	ctx := context.Background()
+
+	card := &cartEntity.GiftCard{Code: "GIFT", Balance: 50, ExpiresAt: time.Now().Add(time.Hour)}
+	require.NoError(t, database.Create(ctx, card))
+	cartA := cartEntity.NewCart("u1", time.Hour)
+	cartB := cartEntity.NewCart("u2", time.Hour)
+	require.NoError(t, database.Create(ctx, cartA))
+	require.NoError(t, database.Create(ctx, cartB))
+
+	cartA.GiftCardID = &card.ID
+	cartA.GiftCardAmount = 50
+	require.NoError(t, repo.ApplyGiftCard(ctx, cartA))
+
+	cartB.GiftCardID = &card.ID
+	cartB.GiftCardAmount = 50
+	assert.ErrorIs(t, repo.ApplyGiftCard(ctx, cartB), repository.ErrGiftCardBalanceTooLow)
+
+	got, err := repo.GetGiftCardByCode(ctx, "GIFT")
+	require.NoError(t, err)
+	assert.Zero(t, got.Balance)
+	assert.True(t, got.IsUsed)
+
+	stored, err := cartRepo.GetCartByID(ctx, cartB.ID)
+	require.NoError(t, err)
+	assert.Nil(t, stored.GiftCardID)
+	assert.Zero(t, stored.GiftCardAmount)
+}
+
+// TestReleaseGiftCard verifica que el importe liberado vuelve al saldo de la
+// tarjeta y que deja de estar marcada como usada.
+func TestReleaseGiftCard(t *testing.T) {
+	database := newTestDatabase(t)
+	require.NoError(t, database.AutoMigrate(&cartEntity.GiftCard{}))
+	repo := repository.NewGiftCardRepository(database)
+	ctx := context.Background()
+
+	card := &cartEntity.GiftCard{Code: "GIFT", Balance: 0, IsUsed: true, ExpiresAt: time.Now().Add(time.Hour)}
+	require.NoError(t, database.Create(ctx, card))
+
+	require.NoError(t, repo.ReleaseGiftCard(ctx, card.ID, 25))
+
+	got, err := repo.GetGiftCardByCode(ctx, "GIFT")
+	require.NoError(t, err)
+	assert.Equal(t, 25.0, got.Balance)
+	assert.False(t, got.IsUsed)
+}
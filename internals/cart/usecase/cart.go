@@ -2,63 +2,311 @@ package usecase
 
 import (
 	"context"
+	"ecommerce_clean/configs"
 	"ecommerce_clean/utils"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+	"gorm.io/gorm"
 
 	"ecommerce_clean/pkgs/logger"
+	"ecommerce_clean/pkgs/paging"
 	"ecommerce_clean/pkgs/validation"
 
+	addressEntity "ecommerce_clean/internals/address/entity"
 	"ecommerce_clean/internals/cart/controller/dto"
 	"ecommerce_clean/internals/cart/entity"
 	"ecommerce_clean/internals/cart/repository"
+	orderEntity "ecommerce_clean/internals/order/entity"
+	orderRepo "ecommerce_clean/internals/order/repository"
+	orderUseCase "ecommerce_clean/internals/order/usecase"
+	productEntity "ecommerce_clean/internals/product/entity"
 	productRepo "ecommerce_clean/internals/product/repository"
 )
 
+const maxCrossSellSuggestions = 20
+
+// activeCartConstraint is the unique index that keeps one active cart per
+// user.
+const activeCartConstraint = "unique_active_cart_user"
+
 type ICartUseCase interface {
 	GetCartByUserID(ctx context.Context, userID string) (*entity.Cart, error)
 	AddProduct(ctx context.Context, req *dto.AddProductRequest) error
+	BulkAddProducts(ctx context.Context, req *dto.BulkAddProductsRequest) error
 	UpdateCartLine(ctx context.Context, req *dto.UpdateCartLineRequest) error
 	RemoveProduct(ctx context.Context, req *dto.RemoveProductRequest) error
+	ClearCart(ctx context.Context, cartID string) error
+	MergeCarts(ctx context.Context, sourceCartID, sourceUserID, targetUserID string) error
+	GetAbandonedCarts(ctx context.Context, idleSince time.Duration, role string) ([]*entity.Cart, error)
+	PurgeExpiredCarts(ctx context.Context) (int64, error)
+	GetCartItemCount(ctx context.Context, userID string) (int, error)
+	GetCartValueByUserID(ctx context.Context, userID string) (float64, error)
+	ValidateCartBeforeCheckout(ctx context.Context, userID string) (*entity.ValidationReport, error)
+	Checkout(ctx context.Context, userID, countryCode, idempotencyKey string) (*orderEntity.Order, error)
+	ApplyGiftCard(ctx context.Context, cartID, userID, giftCardCode string) error
+	ApplyCoupon(ctx context.Context, cartID, userID, code string) error
+	GetCartLineByID(ctx context.Context, lineID, userID string) (*entity.CartLine, error)
+	GetCrossSellSuggestions(ctx context.Context, userID string, limit int) ([]*productEntity.Product, error)
+	ComputeCartCheckoutSummary(ctx context.Context, userID, countryCode string) (*entity.CheckoutSummary, error)
+	GetCartLinePriceChange(ctx context.Context, userID string) ([]*entity.PriceChangedLine, error)
+	DetectPriceDrift(ctx context.Context, cartID string) ([]*dto.PriceDriftItem, error)
+	EstimateCartTax(ctx context.Context, userID, countryCode string) (float64, error)
+	GetCartItemLastViewedAt(ctx context.Context, userID, productID string) (*time.Time, error)
+	GetCartsByProductID(ctx context.Context, productID string, role string, req *paging.Pagination) ([]*entity.Cart, *paging.Pagination, error)
 }
 
 type CartUseCase struct {
-	validator   validation.Validation
-	cartRepo    repository.ICartRepository
-	productRepo productRepo.IProductRepository
+	validator    validation.Validation
+	cartRepo     repository.ICartRepository
+	productRepo  productRepo.IProductRepository
+	orderRepo    orderRepo.IOrderRepository
+	giftCardRepo repository.IGiftCardRepository
+	shipping     orderUseCase.ShippingCalculator
+	tax          TaxProvider
 }
 
 func NewCartUseCase(
 	validator validation.Validation,
 	cartRepo repository.ICartRepository,
 	productRepo productRepo.IProductRepository,
+	orderRepo orderRepo.IOrderRepository,
+	giftCardRepo repository.IGiftCardRepository,
+	shipping orderUseCase.ShippingCalculator,
+	tax TaxProvider,
 ) *CartUseCase {
 	return &CartUseCase{
-		validator:   validator,
-		cartRepo:    cartRepo,
-		productRepo: productRepo,
+		validator:    validator,
+		cartRepo:     cartRepo,
+		productRepo:  productRepo,
+		orderRepo:    orderRepo,
+		giftCardRepo: giftCardRepo,
+		shipping:     shipping,
+		tax:          tax,
 	}
 }
 
+// GetCartByUserID returns the user's active cart, starting a new one if the
+// last was checked out. A cart whose expiry time has passed is reset and
+// returned empty.
 func (cu *CartUseCase) GetCartByUserID(ctx context.Context, userID string) (*entity.Cart, error) {
+	return cu.cartByUserID(ctx, userID)
+}
+
+// GetCartItemCount returns the total quantity across the user's cart lines.
+func (cu *CartUseCase) GetCartItemCount(ctx context.Context, userID string) (int, error) {
+	cart, err := cu.cartByUserID(ctx, userID)
+	if err != nil {
+		return 0, err
+	}
+
+	count := 0
+	for _, line := range cart.Lines {
+		count += int(line.Quantity)
+	}
+
+	return count, nil
+}
+
+// PurgeExpiredCarts resets every active cart whose expiry time has passed and
+// returns how many were reset. Carts are kept, not deleted, since each user
+// has exactly one active cart.
+func (cu *CartUseCase) PurgeExpiredCarts(ctx context.Context) (int64, error) {
+	carts, err := cu.cartRepo.GetExpiredCarts(ctx, time.Now())
+	if err != nil {
+		return 0, err
+	}
+
+	var purged int64
+	for _, cart := range carts {
+		if err := cu.resetExpiredCart(ctx, cart); err != nil {
+			return purged, err
+		}
+		purged++
+	}
+
+	return purged, nil
+}
+
+// cartByID loads a cart, resetting it first if it has expired.
+func (cu *CartUseCase) cartByID(ctx context.Context, cartID string) (*entity.Cart, error) {
+	cart, err := cu.cartRepo.GetCartByID(ctx, cartID)
+	if err != nil {
+		return nil, err
+	}
+
+	if cart.IsExpired(time.Now()) {
+		if err := cu.resetExpiredCart(ctx, cart); err != nil {
+			return nil, err
+		}
+	}
+
+	return cart, nil
+}
+
+// cartByUserID loads the user's active cart, resetting it first if it has
+// expired. A user whose last cart was checked out gets a new one.
+func (cu *CartUseCase) cartByUserID(ctx context.Context, userID string) (*entity.Cart, error) {
 	cart, err := cu.cartRepo.GetCartByUserID(ctx, userID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return cu.newCart(ctx, userID)
+	}
 	if err != nil {
 		return nil, err
 	}
 
+	if cart.IsExpired(time.Now()) {
+		if err := cu.resetExpiredCart(ctx, cart); err != nil {
+			return nil, err
+		}
+	}
+
+	return cart, nil
+}
+
+// newCart starts a new active cart for the user. If a concurrent request
+// started one first, that cart is returned instead.
+func (cu *CartUseCase) newCart(ctx context.Context, userID string) (*entity.Cart, error) {
+	cart := entity.NewCart(userID, configs.CartTTL)
+	if err := cu.cartRepo.CreateCart(ctx, cart); err != nil {
+		if utils.ExtractConstraintName(err) == activeCartConstraint {
+			return cu.cartRepo.GetCartByUserID(ctx, userID)
+		}
+		return nil, err
+	}
+
 	return cart, nil
 }
 
+// resetExpiredCart empties an expired cart so its user can keep using it. The
+// gift card amount it was holding goes back to the card.
+func (cu *CartUseCase) resetExpiredCart(ctx context.Context, cart *entity.Cart) error {
+	return cu.cartRepo.WithinTransaction(ctx, func(ctx context.Context) error {
+		if cart.GiftCardID != nil && cart.GiftCardAmount > 0 {
+			if err := cu.giftCardRepo.ReleaseGiftCard(ctx, *cart.GiftCardID, cart.GiftCardAmount); err != nil {
+				return err
+			}
+		}
+
+		cart.Reset()
+		return cu.cartRepo.ResetCart(ctx, cart)
+	})
+}
+
+// touchCart runs after every change to the cart's lines. It starts a new
+// expiry period, reprices the applied discount against the new subtotal and
+// gives back to the gift card whatever it covers beyond that subtotal.
+func (cu *CartUseCase) touchCart(ctx context.Context, cart *entity.Cart) error {
+	cart.Touch(configs.CartTTL)
+
+	if cart.DiscountID != nil {
+		if err := cu.repriceDiscount(ctx, cart); err != nil {
+			return err
+		}
+	}
+
+	if cart.GiftCardID != nil {
+		if err := cu.clampGiftCard(ctx, cart); err != nil {
+			return err
+		}
+	}
+
+	return cu.cartRepo.TouchCart(ctx, cart)
+}
+
+// repriceDiscount sets the cart's discount amount for its current subtotal. A
+// discount that has been removed or has expired is dropped from the cart.
+func (cu *CartUseCase) repriceDiscount(ctx context.Context, cart *entity.Cart) error {
+	discount, err := cu.orderRepo.GetDiscount(ctx, *cart.DiscountID)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+
+	if discount == nil || discount.IsExpired(time.Now()) {
+		cart.DiscountID = nil
+		cart.DiscountAmount = 0
+		return nil
+	}
+
+	subtotal, err := cu.cartRepo.SumCartLinesPrices(ctx, cart.ID)
+	if err != nil {
+		return err
+	}
+
+	cart.DiscountAmount = roundMoney(discount.DiscountFor(subtotal))
+	return nil
+}
+
+// clampGiftCard gives back to the gift card the part of the cart's gift card
+// amount above its current subtotal. A card left covering nothing is removed
+// from the cart, so it can be applied again later.
+func (cu *CartUseCase) clampGiftCard(ctx context.Context, cart *entity.Cart) error {
+	subtotal, err := cu.cartRepo.SumCartLinesPrices(ctx, cart.ID)
+	if err != nil {
+		return err
+	}
+
+	if cart.GiftCardAmount <= subtotal {
+		return nil
+	}
+
+	excess := roundMoney(cart.GiftCardAmount - subtotal)
+	giftCardID := *cart.GiftCardID
+	cart.GiftCardAmount = subtotal
+	if cart.GiftCardAmount <= 0 {
+		cart.GiftCardID = nil
+		cart.GiftCardAmount = 0
+	}
+
+	return cu.cartRepo.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := cu.giftCardRepo.ReleaseGiftCard(ctx, giftCardID, excess); err != nil {
+			return err
+		}
+
+		return cu.cartRepo.UpdateCartGiftCard(ctx, cart)
+	})
+}
+
 func (cu *CartUseCase) AddProduct(ctx context.Context, req *dto.AddProductRequest) error {
 	if err := cu.validator.ValidateStruct(req); err != nil {
 		return err
 	}
 
+	cart, err := cu.cartByID(ctx, req.CartID)
+	if err != nil {
+		return err
+	}
+
+	if !cart.IsActive() {
+		return ErrCartNotActive
+	}
+
 	product, err := cu.productRepo.GetProductById(ctx, req.ProductID)
 	if err != nil {
 		return err
 	}
 
+	existing, err := cu.cartRepo.GetCartLineByProductIDAndCartID(ctx, cart.ID, req.ProductID)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+
+	if existing != nil {
+		existing.Quantity += uint(req.Quantity)
+		existing.Price = float64(existing.Quantity) * product.Price
+		if err := cu.cartRepo.UpdateCartLine(ctx, existing); err != nil {
+			logger.Errorf("Update fail, error: %s", err)
+			return err
+		}
+		return cu.touchCart(ctx, cart)
+	}
+
 	var cartLine entity.CartLine
 	utils.MapStruct(&cartLine, &req)
+	cartLine.CartID = cart.ID
 	cartLine.Price = float64(cartLine.Quantity) * product.Price
 
 	err = cu.cartRepo.CreateCartLine(ctx, &cartLine)
@@ -66,20 +314,64 @@ func (cu *CartUseCase) AddProduct(ctx context.Context, req *dto.AddProductReques
 		logger.Errorf("Create fail, error: %s", err)
 		return err
 	}
-	return nil
+	return cu.touchCart(ctx, cart)
+}
+
+// BulkAddProducts adds each line through AddProduct. It is best effort: a
+// failing line does not stop the others, and lines already added stay in the
+// cart. Every failure is returned as a BulkAddError, joined when there are
+// several.
+func (cu *CartUseCase) BulkAddProducts(ctx context.Context, req *dto.BulkAddProductsRequest) error {
+	if err := cu.validator.ValidateStruct(req); err != nil {
+		return err
+	}
+
+	var failures []error
+	for i, line := range req.Lines {
+		line.CartID = req.CartID
+		if err := cu.AddProduct(ctx, &line); err != nil {
+			failures = append(failures, BulkAddError{Index: i, Err: err})
+		}
+	}
+
+	return errors.Join(failures...)
 }
 
+// UpdateCartLine sets the quantity of a cart line and reprices it. A quantity
+// of zero removes the line.
 func (cu *CartUseCase) UpdateCartLine(ctx context.Context, req *dto.UpdateCartLineRequest) error {
 	if err := cu.validator.ValidateStruct(req); err != nil {
 		return err
 	}
 
+	cart, err := cu.cartByID(ctx, req.CartID)
+	if err != nil {
+		return err
+	}
+
+	if !cart.IsActive() {
+		return ErrCartNotActive
+	}
+
+	if req.Quantity == 0 {
+		cartLine, err := cu.cartRepo.GetCartLineByProductIDAndCartID(ctx, cart.ID, req.ProductID)
+		if err != nil {
+			return err
+		}
+
+		if err := cu.cartRepo.RemoveCartLine(ctx, cartLine); err != nil {
+			return err
+		}
+
+		return cu.touchCart(ctx, cart)
+	}
+
 	product, err := cu.productRepo.GetProductById(ctx, req.ProductID)
 	if err != nil {
 		return err
 	}
 
-	cartLine, err := cu.cartRepo.GetCartLineByProductIDAndCartID(ctx, req.CartID, req.ProductID)
+	cartLine, err := cu.cartRepo.GetCartLineByProductIDAndCartID(ctx, cart.ID, req.ProductID)
 	if err != nil {
 		return err
 	}
@@ -91,11 +383,20 @@ func (cu *CartUseCase) UpdateCartLine(ctx context.Context, req *dto.UpdateCartLi
 		return err
 	}
 
-	return nil
+	return cu.touchCart(ctx, cart)
 }
 
 func (cu *CartUseCase) RemoveProduct(ctx context.Context, req *dto.RemoveProductRequest) error {
-	cartLine, err := cu.cartRepo.GetCartLineByProductIDAndCartID(ctx, req.CartID, req.ProductID)
+	cart, err := cu.cartByID(ctx, req.CartID)
+	if err != nil {
+		return err
+	}
+
+	if !cart.IsActive() {
+		return ErrCartNotActive
+	}
+
+	cartLine, err := cu.cartRepo.GetCartLineByProductIDAndCartID(ctx, cart.ID, req.ProductID)
 	if err != nil {
 		return err
 	}
@@ -104,5 +405,726 @@ func (cu *CartUseCase) RemoveProduct(ctx context.Context, req *dto.RemoveProduct
 		return err
 	}
 
+	return cu.touchCart(ctx, cart)
+}
+
+// ClearCart removes every line from the cart, then reprices its discount and
+// gives its gift card amount back to the card. An already empty cart is left
+// untouched.
+func (cu *CartUseCase) ClearCart(ctx context.Context, cartID string) error {
+	cart, err := cu.cartRepo.GetCartByID(ctx, cartID)
+	if err != nil {
+		return err
+	}
+
+	if len(cart.Lines) == 0 {
+		return nil
+	}
+
+	return cu.cartRepo.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := cu.cartRepo.DeleteAllCartLines(ctx, cart.ID); err != nil {
+			return err
+		}
+
+		return cu.touchCart(ctx, cart)
+	})
+}
+
+// MergeCarts adds every line of the source cart, typically a guest cart owned
+// by sourceUserID, to the target user's cart through AddProduct, then empties
+// the source. The merge runs in one transaction: if a line cannot be added the
+// error is returned and only what the merge changed is undone, leaving both
+// carts as they were.
+func (cu *CartUseCase) MergeCarts(ctx context.Context, sourceCartID, sourceUserID, targetUserID string) error {
+	source, err := cu.cartByID(ctx, sourceCartID)
+	if err != nil {
+		return err
+	}
+
+	if source.UserID != sourceUserID {
+		return ErrCartNotOwned
+	}
+
+	if len(source.Lines) == 0 {
+		return nil
+	}
+
+	target, err := cu.cartByUserID(ctx, targetUserID)
+	if err != nil {
+		return err
+	}
+
+	if target.ID == source.ID {
+		return nil
+	}
+
+	return cu.cartRepo.WithinTransaction(ctx, func(ctx context.Context) error {
+		for _, line := range source.Lines {
+			err := cu.AddProduct(ctx, &dto.AddProductRequest{
+				CartID:    target.ID,
+				ProductID: line.ProductID,
+				Quantity:  int(line.Quantity),
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		return cu.ClearCart(ctx, source.ID)
+	})
+}
+
+func (cu *CartUseCase) GetAbandonedCarts(ctx context.Context, idleSince time.Duration, role string) ([]*entity.Cart, error) {
+	if role != utils.RoleAdmin {
+		return nil, ErrForbidden
+	}
+
+	if idleSince < time.Hour {
+		return nil, ErrInvalidIdleDuration
+	}
+
+	carts, err := cu.cartRepo.GetAbandonedCarts(ctx, time.Now().Add(-idleSince))
+	if err != nil {
+		return nil, err
+	}
+
+	return carts, nil
+}
+
+// GetCartsByProductID lists the carts that still hold the product, for
+// cleaning up after it is removed from the catalog.
+func (cu *CartUseCase) GetCartsByProductID(ctx context.Context, productID string, role string, req *paging.Pagination) ([]*entity.Cart, *paging.Pagination, error) {
+	if role != utils.RoleAdmin {
+		return nil, nil, ErrForbidden
+	}
+
+	return cu.cartRepo.GetCartsByProductID(ctx, productID, req)
+}
+
+func (cu *CartUseCase) GetCartValueByUserID(ctx context.Context, userID string) (float64, error) {
+	cartID, err := cu.cartRepo.GetCartIDByUserID(ctx, userID)
+	if err != nil {
+		return 0, err
+	}
+
+	return cu.cartRepo.SumCartLinesPrices(ctx, cartID)
+}
+
+// ValidateCartBeforeCheckout checks every cart line against the current product
+// data. Problems are reported in the returned report, not as an error.
+func (cu *CartUseCase) ValidateCartBeforeCheckout(ctx context.Context, userID string) (*entity.ValidationReport, error) {
+	cart, err := cu.cartByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &entity.ValidationReport{Issues: []entity.CartIssue{}}
+	if len(cart.Lines) == 0 {
+		report.IsValid = true
+		return report, nil
+	}
+
+	ids := make([]string, 0, len(cart.Lines))
+	for _, line := range cart.Lines {
+		ids = append(ids, line.ProductID)
+	}
+
+	products, err := cu.productRepo.GetProductsByIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	productMap := make(map[string]*productEntity.Product, len(products))
+	for _, product := range products {
+		productMap[product.ID] = product
+	}
+
+	addIssue := func(productID, issueType string) {
+		report.Issues = append(report.Issues, entity.CartIssue{ProductID: productID, IssueType: issueType})
+	}
+
+	for _, line := range cart.Lines {
+		product, ok := productMap[line.ProductID]
+		if !ok {
+			addIssue(line.ProductID, entity.CartIssueProductNotFound)
+			continue
+		}
+		if !product.Active {
+			addIssue(line.ProductID, entity.CartIssueProductInactive)
+		}
+		if product.Stock < int(line.Quantity) {
+			addIssue(line.ProductID, entity.CartIssueOutOfStock)
+		}
+		if math.Abs(product.Price*float64(line.Quantity)-line.Price) > 0.005 {
+			addIssue(line.ProductID, entity.CartIssuePriceChanged)
+		}
+	}
+
+	report.IsValid = len(report.Issues) == 0
+	return report, nil
+}
+
+// Checkout turns the user's cart into an order at current product prices. Every
+// line must still be in stock and priced as the product is now; otherwise
+// ErrPriceDriftDetected lists the changed lines. The cart's discount and gift
+// card carry over to the order, which uses up the card, so the cart is marked
+// checked out without giving its balance back; the user's next use of the
+// cart starts a new one. Taking the stock, creating the order and marking the
+// cart happen in one transaction, so a failure leaves stock and cart as they
+// were.
+//
+// A retry with the idempotencyKey of an order the user already placed returns
+// that order, even once the cart was checked out. Without a key, the cart's own key
+// still keeps two concurrent checkouts of the same cart to one order.
+//
+// The order stores the discount and the tax, at the rate of countryCode, it
+// was charged, so its receipt does not depend on later changes to either.
+//
+// Checkout does not go through PlaceOrder: it carries the cart's discount and
+// gift card over to the order and closes the cart in the same transaction, and
+// a cart is not held to PlaceOrder's five line limit.
+func (cu *CartUseCase) Checkout(ctx context.Context, userID, countryCode, idempotencyKey string) (*orderEntity.Order, error) {
+	countryCode, err := normalizeCountry(countryCode)
+	if err != nil {
+		return nil, err
+	}
+
+	if idempotencyKey != "" {
+		existing, err := orderUseCase.FindIdempotentOrder(ctx, cu.orderRepo, idempotencyKey, userID)
+		if err != nil || existing != nil {
+			return existing, err
+		}
+	}
+
+	cart, err := cu.cartByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !cart.IsActive() {
+		return nil, ErrCartNotActive
+	}
+
+	if len(cart.Lines) == 0 {
+		return nil, ErrEmptyCart
+	}
+
+	if err := cart.Validate(); err != nil {
+		return nil, err
+	}
+
+	ids := make([]string, 0, len(cart.Lines))
+	for _, line := range cart.Lines {
+		ids = append(ids, line.ProductID)
+	}
+
+	products, err := cu.productRepo.GetProductsByIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	productMap := make(map[string]*productEntity.Product, len(products))
+	for _, product := range products {
+		productMap[product.ID] = product
+	}
+
+	lines := make([]*orderEntity.OrderLine, 0, len(cart.Lines))
+	var drifted []*dto.PriceDriftItem
+	for _, line := range cart.Lines {
+		product, ok := productMap[line.ProductID]
+		if !ok || !product.Active {
+			return nil, ErrProductInactive
+		}
+		if product.Stock < int(line.Quantity) {
+			return nil, ErrInsufficientStock
+		}
+		if item := priceDrift(line, product); item != nil {
+			drifted = append(drifted, item)
+		}
+
+		lines = append(lines, &orderEntity.OrderLine{
+			ProductID: line.ProductID,
+			Quantity:  line.Quantity,
+			Price:     product.Price * float64(line.Quantity),
+		})
+	}
+
+	if len(drifted) > 0 {
+		return nil, ErrPriceDriftDetected{Items: drifted}
+	}
+
+	taxRate, err := cu.tax.GetTaxRate(ctx, countryCode)
+	if err != nil {
+		return nil, err
+	}
+
+	var subtotal float64
+	for _, line := range lines {
+		subtotal += line.Price
+	}
+	taxAmount := roundMoney((subtotal - cart.DiscountAmount) * taxRate)
+
+	key := idempotencyKey
+	if key == "" {
+		key = checkoutKey(cart)
+	}
+
+	var order *orderEntity.Order
+	err = cu.productRepo.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := orderUseCase.TakeStock(ctx, cu.productRepo, lines); err != nil {
+			return err
+		}
+
+		created, err := cu.orderRepo.CreateOrder(ctx, &orderEntity.Order{
+			UserID:         userID,
+			DiscountID:     cart.DiscountID,
+			GiftCardID:     cart.GiftCardID,
+			GiftCardAmount: cart.GiftCardAmount,
+			DiscountAmount: cart.DiscountAmount,
+			TaxAmount:      taxAmount,
+			IdempotencyKey: &key,
+		}, lines)
+		if err != nil {
+			return err
+		}
+		order = created
+
+		cart.Status = entity.CartStatusCheckedOut
+		return cu.cartRepo.UpdateCartStatus(ctx, cart.ID, cart.Status)
+	})
+	if err != nil {
+		if !orderUseCase.IsIdempotencyKeyTaken(err) {
+			return nil, err
+		}
+		existing, findErr := orderUseCase.FindIdempotentOrder(ctx, cu.orderRepo, key, userID)
+		switch {
+		case findErr != nil:
+			return nil, findErr
+		case existing == nil:
+			return nil, err
+		}
+		return existing, nil
+	}
+
+	for _, line := range order.Lines {
+		line.Product = productMap[line.ProductID]
+	}
+
+	return order, nil
+}
+
+// checkoutKey is the idempotency key of an order placed from the cart when the
+// client sends none. Every change to the cart moves its UpdatedAt, so only
+// checkouts of the unchanged cart get the same key.
+func checkoutKey(cart *entity.Cart) string {
+	return fmt.Sprintf("cart:%s:%d", cart.ID, cart.UpdatedAt.UnixMicro())
+}
+
+// ApplyGiftCard offsets the user's cart total with the gift card balance. At
+// most the cart total is taken from the card; any remainder stays on the card.
+// A cart holds one gift card at a time, and an empty cart cannot take one.
+func (cu *CartUseCase) ApplyGiftCard(ctx context.Context, cartID, userID, giftCardCode string) error {
+	cart, err := cu.cartByID(ctx, cartID)
+	if err != nil {
+		return err
+	}
+
+	if cart.UserID != userID {
+		return ErrCartNotOwned
+	}
+
+	if !cart.IsActive() {
+		return ErrCartNotActive
+	}
+
+	if cart.GiftCardID != nil {
+		return ErrGiftCardAlreadyApplied
+	}
+
+	giftCard, err := cu.giftCardRepo.GetGiftCardByCode(ctx, giftCardCode)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrGiftCardNotFound
+		}
+		return err
+	}
+
+	if !giftCard.ExpiresAt.After(time.Now()) {
+		return ErrGiftCardExpired
+	}
+
+	if giftCard.IsUsed || giftCard.Balance <= 0 {
+		return ErrGiftCardEmpty
+	}
+
+	var total float64
+	for _, line := range cart.Lines {
+		total += line.Price
+	}
+
+	if total <= 0 {
+		return ErrEmptyCart
+	}
+
+	cart.GiftCardID = &giftCard.ID
+	cart.GiftCardAmount = math.Min(giftCard.Balance, total)
+	cart.Touch(configs.CartTTL)
+
+	if err := cu.giftCardRepo.ApplyGiftCard(ctx, cart); err != nil {
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			return ErrGiftCardAlreadyApplied
+		case errors.Is(err, repository.ErrGiftCardBalanceTooLow):
+			return ErrGiftCardEmpty
+		}
+		return err
+	}
+
 	return nil
 }
+
+// ApplyCoupon applies the discount with the given code to the cart and stores
+// what it takes off the current subtotal. The amount is repriced whenever the
+// cart's lines change. An empty code removes the discount.
+func (cu *CartUseCase) ApplyCoupon(ctx context.Context, cartID, userID, code string) error {
+	cart, err := cu.cartByID(ctx, cartID)
+	if err != nil {
+		return err
+	}
+
+	if cart.UserID != userID {
+		return ErrCartNotOwned
+	}
+
+	if !cart.IsActive() {
+		return ErrCartNotActive
+	}
+
+	if code == "" {
+		cart.DiscountID = nil
+		cart.DiscountAmount = 0
+		cart.Touch(configs.CartTTL)
+		return cu.cartRepo.UpdateCart(ctx, cart)
+	}
+
+	discount, err := cu.orderRepo.GetDiscountByCode(ctx, code)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrCouponNotFound
+		}
+		return err
+	}
+
+	if discount.IsExpired(time.Now()) {
+		return ErrCouponExpired
+	}
+
+	var subtotal float64
+	for _, line := range cart.Lines {
+		subtotal += line.Price
+	}
+
+	cart.DiscountID = &discount.ID
+	cart.DiscountAmount = roundMoney(discount.DiscountFor(subtotal))
+	cart.Touch(configs.CartTTL)
+
+	return cu.cartRepo.UpdateCart(ctx, cart)
+}
+
+func (cu *CartUseCase) GetCartLineByID(ctx context.Context, lineID, userID string) (*entity.CartLine, error) {
+	cartLine, err := cu.cartRepo.GetCartLineByID(ctx, lineID)
+	if err != nil {
+		return nil, err
+	}
+
+	cart, err := cu.cartRepo.GetCartByID(ctx, cartLine.CartID)
+	if err != nil {
+		return nil, err
+	}
+
+	if cart.UserID != userID {
+		return nil, ErrLineNotOwned
+	}
+
+	return cartLine, nil
+}
+
+// GetCartItemLastViewedAt returns when the user last viewed a product in their
+// cart, or nil if they have not viewed it since adding it.
+func (cu *CartUseCase) GetCartItemLastViewedAt(ctx context.Context, userID, productID string) (*time.Time, error) {
+	cartID, err := cu.cartRepo.GetCartIDByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	cartLine, err := cu.cartRepo.GetCartLineByProductIDAndCartID(ctx, cartID, productID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrLineNotInCart
+		}
+		return nil, err
+	}
+
+	return cartLine.LastViewedAt, nil
+}
+
+// GetCrossSellSuggestions merges the products frequently bought with each cart
+// item, skipping anything already in the cart, and keeps the first limit.
+func (cu *CartUseCase) GetCrossSellSuggestions(ctx context.Context, userID string, limit int) ([]*productEntity.Product, error) {
+	if limit < 1 || limit > maxCrossSellSuggestions {
+		return nil, ErrInvalidSuggestionLimit
+	}
+
+	cart, err := cu.cartByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool, len(cart.Lines))
+	for _, line := range cart.Lines {
+		seen[line.ProductID] = true
+	}
+
+	suggestions := make([]*productEntity.Product, 0, limit)
+	for _, line := range cart.Lines {
+		// Ask for enough extra rows to cover products already in the cart.
+		products, err := cu.productRepo.GetFrequentlyBoughtTogether(ctx, line.ProductID, limit+len(cart.Lines))
+		if err != nil {
+			return nil, err
+		}
+
+		for _, product := range products {
+			if seen[product.ID] {
+				continue
+			}
+			seen[product.ID] = true
+
+			suggestions = append(suggestions, product)
+			if len(suggestions) == limit {
+				return suggestions, nil
+			}
+		}
+	}
+
+	return suggestions, nil
+}
+
+// ComputeCartCheckoutSummary prices the cart the way checkout would for
+// delivery to the given country. Shipping, tax and discount are looked up
+// concurrently. Shipping and discount are best effort and count as 0 when
+// unavailable; tax is required.
+func (cu *CartUseCase) ComputeCartCheckoutSummary(ctx context.Context, userID, countryCode string) (*entity.CheckoutSummary, error) {
+	countryCode, err := normalizeCountry(countryCode)
+	if err != nil {
+		return nil, err
+	}
+
+	cart, err := cu.cartByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(cart.Lines) == 0 {
+		return nil, ErrEmptyCart
+	}
+
+	summary := &entity.CheckoutSummary{}
+	var weight float64
+	for _, line := range cart.Lines {
+		summary.Subtotal += line.Price
+		summary.ItemCount += int(line.Quantity)
+		if line.Product != nil {
+			weight += line.Product.Weight * float64(line.Quantity)
+		}
+	}
+	summary.Subtotal = roundMoney(summary.Subtotal)
+
+	var taxRate float64
+
+	g, gCtx := errgroup.WithContext(ctx)
+
+	g.Go(func() error {
+		if cu.shipping == nil {
+			return nil
+		}
+		// Only the country is known before checkout; carriers quote on it and
+		// the weight.
+		quote, err := cu.shipping.Calculate(gCtx, weight, addressEntity.Address{Country: countryCode})
+		if err != nil {
+			logger.Errorf("Failed to quote shipping, user id: %s, error: %s", userID, err)
+			return nil
+		}
+		summary.ShippingCost = quote.Cost
+		summary.EstimatedDelivery = time.Now().AddDate(0, 0, quote.EstimatedDays)
+		return nil
+	})
+
+	g.Go(func() error {
+		rate, err := cu.tax.GetTaxRate(gCtx, countryCode)
+		if err != nil {
+			return err
+		}
+		taxRate = rate
+		return nil
+	})
+
+	if cart.DiscountID != nil {
+		g.Go(func() error {
+			discount, err := cu.orderRepo.GetDiscount(gCtx, *cart.DiscountID)
+			if err != nil {
+				logger.Errorf("Failed to get discount, user id: %s, error: %s", userID, err)
+				return nil
+			}
+			summary.DiscountAmount = roundMoney(discount.DiscountFor(summary.Subtotal))
+			return nil
+		})
+	}
+
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+
+	taxable := summary.Subtotal - summary.DiscountAmount
+	summary.TaxAmount = roundMoney(taxable * taxRate)
+
+	total := taxable + summary.TaxAmount + summary.ShippingCost
+	summary.GiftCardAmount = roundMoney(math.Min(cart.GiftCardAmount, total))
+	summary.GrandTotal = roundMoney(total - summary.GiftCardAmount)
+
+	return summary, nil
+}
+
+// GetCartLinePriceChange returns the user's cart lines whose unit price differs
+// from the product's current price. Inactive or missing products count as a
+// change to 0.
+func (cu *CartUseCase) GetCartLinePriceChange(ctx context.Context, userID string) ([]*entity.PriceChangedLine, error) {
+	cart, err := cu.cartByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	changed := make([]*entity.PriceChangedLine, 0)
+	if len(cart.Lines) == 0 {
+		return changed, nil
+	}
+
+	ids := make([]string, 0, len(cart.Lines))
+	for _, line := range cart.Lines {
+		ids = append(ids, line.ProductID)
+	}
+
+	products, err := cu.productRepo.GetProductsByIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	productMap := make(map[string]*productEntity.Product, len(products))
+	for _, product := range products {
+		productMap[product.ID] = product
+	}
+
+	for _, line := range cart.Lines {
+		if line.Quantity == 0 {
+			continue
+		}
+
+		oldPrice := roundMoney(line.Price / float64(line.Quantity))
+		newPrice := 0.0
+		if product, ok := productMap[line.ProductID]; ok && product.Active {
+			newPrice = product.Price
+		}
+
+		if math.Abs(newPrice-oldPrice) <= 0.005 {
+			continue
+		}
+
+		var percent float64
+		if oldPrice > 0 {
+			percent = roundMoney((newPrice - oldPrice) / oldPrice * 100)
+		}
+
+		changed = append(changed, &entity.PriceChangedLine{
+			CartLine:      line,
+			OldPrice:      oldPrice,
+			NewPrice:      newPrice,
+			PercentChange: percent,
+		})
+	}
+
+	return changed, nil
+}
+
+// EstimateCartTax returns the tax the user's cart would pay in the given
+// country at current line prices.
+func (cu *CartUseCase) EstimateCartTax(ctx context.Context, userID, countryCode string) (float64, error) {
+	countryCode, err := normalizeCountry(countryCode)
+	if err != nil {
+		return 0, err
+	}
+
+	cart, err := cu.cartByUserID(ctx, userID)
+	if err != nil {
+		return 0, err
+	}
+
+	var total float64
+	for _, line := range cart.Lines {
+		total += line.Price
+	}
+	if total == 0 {
+		return 0, nil
+	}
+
+	rate, err := cu.tax.GetTaxRate(ctx, countryCode)
+	if err != nil {
+		return 0, err
+	}
+
+	return roundMoney(total * rate), nil
+}
+
+// DetectPriceDrift returns the cart lines whose unit price differs from the
+// product's current price.
+func (cu *CartUseCase) DetectPriceDrift(ctx context.Context, cartID string) ([]*dto.PriceDriftItem, error) {
+	cart, err := cu.cartByID(ctx, cartID)
+	if err != nil {
+		return nil, err
+	}
+
+	drifted := make([]*dto.PriceDriftItem, 0)
+	for _, line := range cart.Lines {
+		product, err := cu.productRepo.GetProductById(ctx, line.ProductID)
+		if err != nil {
+			return nil, err
+		}
+
+		if item := priceDrift(line, product); item != nil {
+			drifted = append(drifted, item)
+		}
+	}
+
+	return drifted, nil
+}
+
+// priceDrift compares the line's unit price with the product's current price
+// and returns nil when they match to the cent.
+func priceDrift(line *entity.CartLine, product *productEntity.Product) *dto.PriceDriftItem {
+	if line.Quantity == 0 {
+		return nil
+	}
+
+	cartPrice := roundMoney(line.Price / float64(line.Quantity))
+	if math.Abs(product.Price-cartPrice) <= 0.005 {
+		return nil
+	}
+
+	return &dto.PriceDriftItem{
+		ProductID:    line.ProductID,
+		CartPrice:    cartPrice,
+		CurrentPrice: product.Price,
+	}
+}
+
+func roundMoney(v float64) float64 {
+	return math.Round(v*100) / 100
+}
@@ -0,0 +1,56 @@
+package usecase
+
+import (
+	"errors"
+	"fmt"
+
+	"ecommerce_clean/internals/cart/controller/dto"
+	orderUseCase "ecommerce_clean/internals/order/usecase"
+)
+
+var (
+	ErrForbidden              = errors.New("forbidden")
+	ErrInvalidIdleDuration    = errors.New("idle duration must be at least 1 hour")
+	ErrCartNotOwned           = errors.New("cart does not belong to user")
+	ErrLineNotInCart          = errors.New("cart line not found in cart")
+	ErrEmptyCart              = errors.New("cart is empty")
+	ErrInsufficientStock      = orderUseCase.ErrInsufficientStock
+	ErrIdempotencyKeyConflict = orderUseCase.ErrIdempotencyKeyConflict
+	ErrProductInactive        = errors.New("product is not available")
+	ErrGiftCardNotFound       = errors.New("gift card not found")
+	ErrGiftCardExpired        = errors.New("gift card expired")
+	ErrGiftCardEmpty          = errors.New("gift card has no balance")
+	ErrGiftCardAlreadyApplied = errors.New("cart already has a gift card")
+	ErrLineNotOwned           = errors.New("cart line does not belong to user")
+	ErrInvalidSuggestionLimit = errors.New("limit must be between 1 and 20")
+	ErrUnsupportedCountry     = errors.New("unsupported country code")
+	ErrCartNotActive          = errors.New("cart is not active")
+	ErrCouponNotFound         = errors.New("coupon not found")
+	ErrCouponExpired          = errors.New("coupon expired")
+)
+
+// ErrPriceDriftDetected is returned by checkout when some cart lines were
+// added at a price other than the current one. Items lists those lines so the
+// client can ask the user to confirm.
+type ErrPriceDriftDetected struct {
+	Items []*dto.PriceDriftItem
+}
+
+func (e ErrPriceDriftDetected) Error() string {
+	return fmt.Sprintf("price changed for %d cart items", len(e.Items))
+}
+
+// BulkAddError reports a line of a bulk add that could not be added. Index is
+// the line's position in the request.
+type BulkAddError struct {
+	Index int
+	Err   error
+}
+
+func (e BulkAddError) Error() string {
+	return fmt.Sprintf("line %d: %s", e.Index, e.Err)
+}
+
+func (e BulkAddError) Unwrap() error {
+	return e.Err
+}
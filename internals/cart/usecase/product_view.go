@@ -0,0 +1,42 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+
+	"ecommerce_clean/internals/cart/repository"
+)
+
+// ProductViewRecorder stamps the user's cart line when they view its product,
+// which GetCartItemLastViewedAt later reports. The product detail handler
+// calls it on every view.
+type ProductViewRecorder struct {
+	cartRepo repository.ICartRepository
+}
+
+func NewProductViewRecorder(cartRepo repository.ICartRepository) *ProductViewRecorder {
+	return &ProductViewRecorder{cartRepo: cartRepo}
+}
+
+// RecordProductView records that the user viewed the product now. Views of
+// products that are not in the user's cart, or by users without a cart, are
+// not tracked and return nil.
+func (r *ProductViewRecorder) RecordProductView(ctx context.Context, userID, productID string) error {
+	cartID, err := r.cartRepo.GetCartIDByUserID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+
+	err = r.cartRepo.UpdateLastViewed(ctx, cartID, productID, time.Now())
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,60 @@
+package usecase
+
+import (
+	"context"
+	"strings"
+)
+
+// supportedCountries holds the ISO 3166-1 alpha-2 codes taxes can be estimated
+// for.
+var supportedCountries = map[string]struct{}{
+	"AR": {}, "AT": {}, "AU": {}, "BE": {}, "BR": {}, "CA": {}, "CH": {}, "CL": {},
+	"CN": {}, "CO": {}, "CZ": {}, "DE": {}, "DK": {}, "ES": {}, "FI": {}, "FR": {},
+	"GB": {}, "GR": {}, "IE": {}, "IN": {}, "IT": {}, "JP": {}, "KR": {}, "MX": {},
+	"NL": {}, "NO": {}, "NZ": {}, "PE": {}, "PL": {}, "PT": {}, "SE": {}, "SG": {},
+	"US": {}, "UY": {}, "VN": {},
+}
+
+// normalizeCountry upper-cases countryCode and checks taxes can be estimated
+// for it.
+func normalizeCountry(countryCode string) (string, error) {
+	countryCode = strings.ToUpper(strings.TrimSpace(countryCode))
+	if _, ok := supportedCountries[countryCode]; !ok {
+		return "", ErrUnsupportedCountry
+	}
+	return countryCode, nil
+}
+
+// countryTaxRates holds the standard VAT, GST or sales tax rate of the
+// supported countries. US sales tax is set by each state, so the US is left out
+// and gets the default rate.
+var countryTaxRates = map[string]float64{
+	"AR": 0.21, "AT": 0.20, "AU": 0.10, "BE": 0.21, "BR": 0.17, "CA": 0.05, "CH": 0.081, "CL": 0.19,
+	"CN": 0.13, "CO": 0.19, "CZ": 0.21, "DE": 0.19, "DK": 0.25, "ES": 0.21, "FI": 0.255, "FR": 0.20,
+	"GB": 0.20, "GR": 0.24, "IE": 0.23, "IN": 0.18, "IT": 0.22, "JP": 0.10, "KR": 0.10, "MX": 0.16,
+	"NL": 0.21, "NO": 0.25, "NZ": 0.15, "PE": 0.18, "PL": 0.23, "PT": 0.23, "SE": 0.25, "SG": 0.09,
+	"UY": 0.22, "VN": 0.10,
+}
+
+// TaxProvider looks up the tax rate that applies in a country.
+type TaxProvider interface {
+	GetTaxRate(ctx context.Context, countryCode string) (float64, error)
+}
+
+// CountryTaxProvider looks the rate up in countryTaxRates and falls back to a
+// default rate for countries it has none for.
+type CountryTaxProvider struct {
+	rates       map[string]float64
+	defaultRate float64
+}
+
+func NewCountryTaxProvider(defaultRate float64) *CountryTaxProvider {
+	return &CountryTaxProvider{rates: countryTaxRates, defaultRate: defaultRate}
+}
+
+func (p *CountryTaxProvider) GetTaxRate(ctx context.Context, countryCode string) (float64, error) {
+	if rate, ok := p.rates[countryCode]; ok {
+		return rate, nil
+	}
+	return p.defaultRate, nil
+}
@@ -3,17 +3,29 @@ package usecase_test
 import (
 	"context"
 	"errors"
+	"os"
 	"testing"
+	"time"
 
+	"ecommerce_clean/configs"
+	addressEntity "ecommerce_clean/internals/address/entity"
 	cartDto "ecommerce_clean/internals/cart/controller/dto"
 	cartEntity "ecommerce_clean/internals/cart/entity"
+	cartRepo "ecommerce_clean/internals/cart/repository"
 	"ecommerce_clean/internals/cart/usecase"
-	prodDto "ecommerce_clean/internals/product/controller/dto"
+	discountEntity "ecommerce_clean/internals/discount/entity"
+	orderEntity "ecommerce_clean/internals/order/entity"
+	orderRepo "ecommerce_clean/internals/order/repository"
 	productEntity "ecommerce_clean/internals/product/entity"
+	"ecommerce_clean/internals/product/repository/mocks"
+	"ecommerce_clean/pkgs/logger"
 	"ecommerce_clean/pkgs/paging"
+	"ecommerce_clean/pkgs/validation"
+	"ecommerce_clean/utils"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"gorm.io/gorm"
 )
 
 // --- Mocks ---
@@ -27,9 +39,20 @@ func (m *MockCartRepository) GetCartByUserID(ctx context.Context, userID string)
 	return args.Get(0).(*cartEntity.Cart), args.Error(1)
 }
 
+func (m *MockCartRepository) GetCartByID(ctx context.Context, cartID string) (*cartEntity.Cart, error) {
+	args := m.Called(ctx, cartID)
+	if v := args.Get(0); v != nil {
+		return v.(*cartEntity.Cart), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
 func (m *MockCartRepository) GetCartLineByProductIDAndCartID(ctx context.Context, cartID, productID string) (*cartEntity.CartLine, error) {
 	args := m.Called(ctx, cartID, productID)
-	return args.Get(0).(*cartEntity.CartLine), args.Error(1)
+	if v := args.Get(0); v != nil {
+		return v.(*cartEntity.CartLine), args.Error(1)
+	}
+	return nil, args.Error(1)
 }
 
 func (m *MockCartRepository) CreateCartLine(ctx context.Context, cl *cartEntity.CartLine) error {
@@ -37,6 +60,11 @@ func (m *MockCartRepository) CreateCartLine(ctx context.Context, cl *cartEntity.
 	return args.Error(0)
 }
 
+func (m *MockCartRepository) CreateCart(ctx context.Context, cart *cartEntity.Cart) error {
+	args := m.Called(ctx, cart)
+	return args.Error(0)
+}
+
 func (m *MockCartRepository) UpdateCartLine(ctx context.Context, cl *cartEntity.CartLine) error {
 	args := m.Called(ctx, cl)
 	return args.Error(0)
@@ -47,29 +75,179 @@ func (m *MockCartRepository) RemoveCartLine(ctx context.Context, cl *cartEntity.
 	return args.Error(0)
 }
 
-type MockProductRepository struct {
+func (m *MockCartRepository) UpdateCart(ctx context.Context, cart *cartEntity.Cart) error {
+	args := m.Called(ctx, cart)
+	return args.Error(0)
+}
+
+func (m *MockCartRepository) UpdateCartStatus(ctx context.Context, cartID string, status cartEntity.CartStatus) error {
+	args := m.Called(ctx, cartID, status)
+	return args.Error(0)
+}
+
+func (m *MockCartRepository) DeleteAllCartLines(ctx context.Context, cartID string) error {
+	args := m.Called(ctx, cartID)
+	return args.Error(0)
+}
+
+func (m *MockCartRepository) TouchCart(ctx context.Context, cart *cartEntity.Cart) error {
+	args := m.Called(ctx, cart)
+	return args.Error(0)
+}
+
+func (m *MockCartRepository) UpdateCartGiftCard(ctx context.Context, cart *cartEntity.Cart) error {
+	args := m.Called(ctx, cart)
+	return args.Error(0)
+}
+
+func (m *MockCartRepository) ResetCart(ctx context.Context, cart *cartEntity.Cart) error {
+	args := m.Called(ctx, cart)
+	return args.Error(0)
+}
+
+func (m *MockCartRepository) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+func (m *MockCartRepository) GetExpiredCarts(ctx context.Context, before time.Time) ([]*cartEntity.Cart, error) {
+	args := m.Called(ctx, before)
+	var carts []*cartEntity.Cart
+	if v := args.Get(0); v != nil {
+		carts = v.([]*cartEntity.Cart)
+	}
+	return carts, args.Error(1)
+}
+
+func (m *MockCartRepository) GetAbandonedCarts(ctx context.Context, updatedBefore time.Time) ([]*cartEntity.Cart, error) {
+	args := m.Called(ctx, updatedBefore)
+	var carts []*cartEntity.Cart
+	if v := args.Get(0); v != nil {
+		carts = v.([]*cartEntity.Cart)
+	}
+	return carts, args.Error(1)
+}
+
+func (m *MockCartRepository) GetCartIDByUserID(ctx context.Context, userID string) (string, error) {
+	args := m.Called(ctx, userID)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockCartRepository) SumCartLinesPrices(ctx context.Context, cartID string) (float64, error) {
+	args := m.Called(ctx, cartID)
+	return args.Get(0).(float64), args.Error(1)
+}
+
+func (m *MockCartRepository) GetCartLineByID(ctx context.Context, lineID string) (*cartEntity.CartLine, error) {
+	args := m.Called(ctx, lineID)
+	if v := args.Get(0); v != nil {
+		return v.(*cartEntity.CartLine), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockCartRepository) UpdateLastViewed(ctx context.Context, cartID, productID string, viewedAt time.Time) error {
+	args := m.Called(ctx, cartID, productID, viewedAt)
+	return args.Error(0)
+}
+
+func (m *MockCartRepository) GetCartsByProductID(ctx context.Context, productID string, req *paging.Pagination) ([]*cartEntity.Cart, *paging.Pagination, error) {
+	args := m.Called(ctx, productID, req)
+	var carts []*cartEntity.Cart
+	if v := args.Get(0); v != nil {
+		carts = v.([]*cartEntity.Cart)
+	}
+	var pagination *paging.Pagination
+	if v := args.Get(1); v != nil {
+		pagination = v.(*paging.Pagination)
+	}
+	return carts, pagination, args.Error(2)
+}
+
+type MockGiftCardRepository struct {
+	mock.Mock
+}
+
+func (m *MockGiftCardRepository) GetGiftCardByCode(ctx context.Context, code string) (*cartEntity.GiftCard, error) {
+	args := m.Called(ctx, code)
+	if v := args.Get(0); v != nil {
+		return v.(*cartEntity.GiftCard), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockGiftCardRepository) ApplyGiftCard(ctx context.Context, cart *cartEntity.Cart) error {
+	args := m.Called(ctx, cart)
+	return args.Error(0)
+}
+
+func (m *MockGiftCardRepository) ReleaseGiftCard(ctx context.Context, giftCardID string, amount float64) error {
+	args := m.Called(ctx, giftCardID, amount)
+	return args.Error(0)
+}
+
+// MockOrderRepository solo implementa lo que usa el carrito: las búsquedas de
+// descuentos y la creación de la orden en el checkout. El resto queda cubierto
+// por la interfaz embebida.
+type MockOrderRepository struct {
+	orderRepo.IOrderRepository
 	mock.Mock
 }
 
-func (m *MockProductRepository) ListProducts(ctx context.Context, req *prodDto.ListProductRequest) ([]*productEntity.Product, *paging.Pagination, error) {
-	return nil, nil, nil
+func (m *MockOrderRepository) GetDiscount(ctx context.Context, discountID string) (*discountEntity.Discount, error) {
+	args := m.Called(ctx, discountID)
+	if v := args.Get(0); v != nil {
+		return v.(*discountEntity.Discount), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockOrderRepository) GetDiscountByCode(ctx context.Context, code string) (*discountEntity.Discount, error) {
+	args := m.Called(ctx, code)
+	if v := args.Get(0); v != nil {
+		return v.(*discountEntity.Discount), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockOrderRepository) FindOrderByIdempotencyKey(ctx context.Context, key string) (*orderEntity.Order, error) {
+	args := m.Called(ctx, key)
+	if v := args.Get(0); v != nil {
+		return v.(*orderEntity.Order), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+// CreateOrder devuelve el pedido recibido con sus líneas, como hace el
+// repositorio real.
+func (m *MockOrderRepository) CreateOrder(ctx context.Context, order *orderEntity.Order, lines []*orderEntity.OrderLine) (*orderEntity.Order, error) {
+	args := m.Called(ctx, order, lines)
+	if err := args.Error(0); err != nil {
+		return nil, err
+	}
+	order.ID = "o1"
+	order.Lines = lines
+	return order, nil
 }
 
-func (m *MockProductRepository) GetProductById(ctx context.Context, id string) (*productEntity.Product, error) {
-	args := m.Called(ctx, id)
-	return args.Get(0).(*productEntity.Product), args.Error(1)
+type MockShippingCalculator struct {
+	mock.Mock
 }
 
-func (m *MockProductRepository) CreatedProduct(ctx context.Context, p *productEntity.Product) error {
-	return nil
+func (m *MockShippingCalculator) Calculate(ctx context.Context, weight float64, destination addressEntity.Address) (*orderEntity.ShippingQuote, error) {
+	args := m.Called(ctx, weight, destination)
+	if v := args.Get(0); v != nil {
+		return v.(*orderEntity.ShippingQuote), args.Error(1)
+	}
+	return nil, args.Error(1)
 }
 
-func (m *MockProductRepository) UpdateProduct(ctx context.Context, p *productEntity.Product) error {
-	return nil
+type MockTaxProvider struct {
+	mock.Mock
 }
 
-func (m *MockProductRepository) DeleteProduct(ctx context.Context, p *productEntity.Product) error {
-	return nil
+func (m *MockTaxProvider) GetTaxRate(ctx context.Context, countryCode string) (float64, error) {
+	args := m.Called(ctx, countryCode)
+	return args.Get(0).(float64), args.Error(1)
 }
 
 type MockValidator struct {
@@ -81,6 +259,13 @@ func (m *MockValidator) ValidateStruct(i interface{}) error {
 	return args.Error(0)
 }
 
+// TestMain inicializa el logger global, necesario para los casos de uso
+// que registran errores no críticos.
+func TestMain(m *testing.M) {
+	logger.Initialize("test")
+	os.Exit(m.Run())
+}
+
 // --- Tests ---
 
 // -------------------------------------
@@ -91,13 +276,14 @@ func (m *MockValidator) ValidateStruct(i interface{}) error {
 // 1) Valida correctamente la petición.
 // 2) Recupera el producto existente.
 // 3) Crea la línea de carrito con el precio calculado.
-// 4) No devuelve error.
+// 4) Renueva el vencimiento del carrito.
+// 5) No devuelve error.
 func TestAddProduct_Success(t *testing.T) {
 	mockCartRepo := new(MockCartRepository)
-	mockProductRepo := new(MockProductRepository)
+	mockProductRepo := new(mocks.ProductRepository)
 	mockValidator := new(MockValidator)
 
-	uc := usecase.NewCartUseCase(mockValidator, mockCartRepo, mockProductRepo)
+	uc := usecase.NewCartUseCase(mockValidator, mockCartRepo, mockProductRepo, nil, nil, nil, nil)
 
 	req := &cartDto.AddProductRequest{
 		CartID:    "cart123",
@@ -107,8 +293,13 @@ func TestAddProduct_Success(t *testing.T) {
 	product := &productEntity.Product{ID: "prod456", Price: 10.0}
 
 	mockValidator.On("ValidateStruct", req).Return(nil)
+	mockCartRepo.On("GetCartByID", mock.Anything, "cart123").Return(&cartEntity.Cart{ID: "cart123"}, nil)
 	mockProductRepo.On("GetProductById", mock.Anything, "prod456").Return(product, nil)
+	mockCartRepo.On("GetCartLineByProductIDAndCartID", mock.Anything, "cart123", "prod456").Return(nil, gorm.ErrRecordNotFound)
 	mockCartRepo.On("CreateCartLine", mock.Anything, mock.Anything).Return(nil)
+	mockCartRepo.On("TouchCart", mock.Anything, mock.MatchedBy(func(cart *cartEntity.Cart) bool {
+		return cart.ID == "cart123" && cart.ExpiresAt != nil && cart.ExpiresAt.After(time.Now().Add(configs.CartTTL-time.Minute))
+	})).Return(nil)
 
 	err := uc.AddProduct(context.Background(), req)
 
@@ -118,14 +309,100 @@ func TestAddProduct_Success(t *testing.T) {
 	mockCartRepo.AssertExpectations(t)
 }
 
+// TestAddProduct_ExpiredCart verifica que añadir a un carrito vencido lo vacía
+// primero y después crea la línea nueva, sin sumarla a las líneas viejas.
+func TestAddProduct_ExpiredCart(t *testing.T) {
+	mockCartRepo := new(MockCartRepository)
+	mockProductRepo := new(mocks.ProductRepository)
+	mockValidator := new(MockValidator)
+	uc := usecase.NewCartUseCase(mockValidator, mockCartRepo, mockProductRepo, nil, nil, nil, nil)
+
+	req := &cartDto.AddProductRequest{CartID: "cart123", ProductID: "prod456", Quantity: 1}
+	expiredAt := time.Now().Add(-time.Hour)
+	cart := &cartEntity.Cart{
+		ID:        "cart123",
+		Lines:     []*cartEntity.CartLine{{ID: "old", CartID: "cart123", ProductID: "prod456", Quantity: 3, Price: 30}},
+		ExpiresAt: &expiredAt,
+	}
+
+	mockValidator.On("ValidateStruct", req).Return(nil)
+	mockCartRepo.On("GetCartByID", mock.Anything, "cart123").Return(cart, nil)
+	mockCartRepo.On("ResetCart", mock.Anything, cart).Return(nil)
+	mockProductRepo.On("GetProductById", mock.Anything, "prod456").Return(&productEntity.Product{ID: "prod456", Price: 10}, nil)
+	mockCartRepo.On("GetCartLineByProductIDAndCartID", mock.Anything, "cart123", "prod456").Return(nil, gorm.ErrRecordNotFound)
+	mockCartRepo.On("CreateCartLine", mock.Anything, mock.MatchedBy(func(cl *cartEntity.CartLine) bool {
+		return cl.Quantity == 1 && cl.Price == 10
+	})).Return(nil)
+	mockCartRepo.On("TouchCart", mock.Anything, cart).Return(nil)
+
+	err := uc.AddProduct(context.Background(), req)
+
+	assert.NoError(t, err)
+	assert.False(t, cart.IsExpired(time.Now()))
+	mockCartRepo.AssertExpectations(t)
+}
+
+// TestAddProduct_ExistingLine_IncrementsQuantity verifica que si el producto
+// ya está en el carrito se suma la cantidad a la línea existente en lugar de
+// crear otra.
+func TestAddProduct_ExistingLine_IncrementsQuantity(t *testing.T) {
+	mockCartRepo := new(MockCartRepository)
+	mockProductRepo := new(mocks.ProductRepository)
+	mockValidator := new(MockValidator)
+
+	uc := usecase.NewCartUseCase(mockValidator, mockCartRepo, mockProductRepo, nil, nil, nil, nil)
+
+	req := &cartDto.AddProductRequest{CartID: "cart123", ProductID: "prod456", Quantity: 2}
+	existing := &cartEntity.CartLine{ID: "l1", CartID: "cart123", ProductID: "prod456", Quantity: 3, Price: 30}
+
+	mockValidator.On("ValidateStruct", req).Return(nil)
+	mockCartRepo.On("GetCartByID", mock.Anything, "cart123").Return(&cartEntity.Cart{ID: "cart123"}, nil)
+	mockProductRepo.On("GetProductById", mock.Anything, "prod456").Return(&productEntity.Product{ID: "prod456", Price: 10.0}, nil)
+	mockCartRepo.On("GetCartLineByProductIDAndCartID", mock.Anything, "cart123", "prod456").Return(existing, nil)
+	mockCartRepo.On("UpdateCartLine", mock.Anything, existing).Return(nil)
+	mockCartRepo.On("TouchCart", mock.Anything, mock.Anything).Return(nil)
+
+	err := uc.AddProduct(context.Background(), req)
+
+	assert.NoError(t, err)
+	assert.Equal(t, uint(5), existing.Quantity)
+	mockCartRepo.AssertExpectations(t)
+	mockCartRepo.AssertNotCalled(t, "CreateCartLine", mock.Anything, mock.Anything)
+}
+
+// TestAddProduct_ExistingLine_RecalculatesPrice verifica que el precio de la
+// línea existente se recalcula con el precio actual del producto.
+func TestAddProduct_ExistingLine_RecalculatesPrice(t *testing.T) {
+	mockCartRepo := new(MockCartRepository)
+	mockProductRepo := new(mocks.ProductRepository)
+	mockValidator := new(MockValidator)
+
+	uc := usecase.NewCartUseCase(mockValidator, mockCartRepo, mockProductRepo, nil, nil, nil, nil)
+
+	req := &cartDto.AddProductRequest{CartID: "cart123", ProductID: "prod456", Quantity: 1}
+	existing := &cartEntity.CartLine{ID: "l1", CartID: "cart123", ProductID: "prod456", Quantity: 2, Price: 16}
+
+	mockValidator.On("ValidateStruct", req).Return(nil)
+	mockCartRepo.On("GetCartByID", mock.Anything, "cart123").Return(&cartEntity.Cart{ID: "cart123"}, nil)
+	mockProductRepo.On("GetProductById", mock.Anything, "prod456").Return(&productEntity.Product{ID: "prod456", Price: 10.0}, nil)
+	mockCartRepo.On("GetCartLineByProductIDAndCartID", mock.Anything, "cart123", "prod456").Return(existing, nil)
+	mockCartRepo.On("UpdateCartLine", mock.Anything, existing).Return(nil)
+	mockCartRepo.On("TouchCart", mock.Anything, mock.Anything).Return(nil)
+
+	err := uc.AddProduct(context.Background(), req)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 30.0, existing.Price)
+}
+
 // TestAddProduct_ValidationError verifica que AddProduct devuelve un error
 // cuando la validación de la petición falla.
 func TestAddProduct_ValidationError(t *testing.T) {
 	mockCartRepo := new(MockCartRepository)
-	mockProductRepo := new(MockProductRepository)
+	mockProductRepo := new(mocks.ProductRepository)
 	mockValidator := new(MockValidator)
 
-	uc := usecase.NewCartUseCase(mockValidator, mockCartRepo, mockProductRepo)
+	uc := usecase.NewCartUseCase(mockValidator, mockCartRepo, mockProductRepo, nil, nil, nil, nil)
 
 	req := &cartDto.AddProductRequest{
 		CartID:    "",
@@ -140,6 +417,100 @@ func TestAddProduct_ValidationError(t *testing.T) {
 	mockValidator.AssertExpectations(t)
 }
 
+// -------------------------------------
+// Tests de BulkAddProducts
+// -------------------------------------
+
+func bulkAddRequest() *cartDto.BulkAddProductsRequest {
+	return &cartDto.BulkAddProductsRequest{CartID: "c1", Lines: []cartDto.AddProductRequest{
+		{ProductID: "p1", Quantity: 1},
+		{ProductID: "p2", Quantity: 2},
+		{ProductID: "p3", Quantity: 3},
+	}}
+}
+
+// TestBulkAddProducts_AllSuccess verifica que se añaden todas las líneas al
+// carrito de la petición.
+func TestBulkAddProducts_AllSuccess(t *testing.T) {
+	mockCartRepo := new(MockCartRepository)
+	mockProductRepo := new(mocks.ProductRepository)
+	uc := usecase.NewCartUseCase(validation.New(), mockCartRepo, mockProductRepo, nil, nil, nil, nil)
+
+	mockCartRepo.On("GetCartByID", mock.Anything, "c1").Return(&cartEntity.Cart{ID: "c1"}, nil)
+	mockProductRepo.On("GetProductById", mock.Anything, mock.Anything).Return(&productEntity.Product{Price: 2}, nil)
+	mockCartRepo.On("GetCartLineByProductIDAndCartID", mock.Anything, "c1", mock.Anything).Return(nil, gorm.ErrRecordNotFound)
+	mockCartRepo.On("CreateCartLine", mock.Anything, mock.MatchedBy(func(cl *cartEntity.CartLine) bool {
+		return cl.CartID == "c1"
+	})).Return(nil)
+	mockCartRepo.On("TouchCart", mock.Anything, mock.Anything).Return(nil)
+
+	err := uc.BulkAddProducts(context.Background(), bulkAddRequest())
+
+	assert.NoError(t, err)
+	mockCartRepo.AssertNumberOfCalls(t, "CreateCartLine", 3)
+}
+
+// TestBulkAddProducts_OneFailure verifica que una línea fallida no impide
+// añadir las demás y que el error indica su posición.
+func TestBulkAddProducts_OneFailure(t *testing.T) {
+	mockCartRepo := new(MockCartRepository)
+	mockProductRepo := new(mocks.ProductRepository)
+	uc := usecase.NewCartUseCase(validation.New(), mockCartRepo, mockProductRepo, nil, nil, nil, nil)
+
+	mockCartRepo.On("GetCartByID", mock.Anything, "c1").Return(&cartEntity.Cart{ID: "c1"}, nil)
+	mockProductRepo.On("GetProductById", mock.Anything, "p1").Return(&productEntity.Product{ID: "p1", Price: 2}, nil)
+	mockProductRepo.On("GetProductById", mock.Anything, "p2").Return((*productEntity.Product)(nil), gorm.ErrRecordNotFound)
+	mockProductRepo.On("GetProductById", mock.Anything, "p3").Return(&productEntity.Product{ID: "p3", Price: 2}, nil)
+	mockCartRepo.On("GetCartLineByProductIDAndCartID", mock.Anything, "c1", mock.Anything).Return(nil, gorm.ErrRecordNotFound)
+	mockCartRepo.On("CreateCartLine", mock.Anything, mock.Anything).Return(nil)
+	mockCartRepo.On("TouchCart", mock.Anything, mock.Anything).Return(nil)
+
+	err := uc.BulkAddProducts(context.Background(), bulkAddRequest())
+
+	var bulkErr usecase.BulkAddError
+	if assert.ErrorAs(t, err, &bulkErr) {
+		assert.Equal(t, 1, bulkErr.Index)
+		assert.ErrorIs(t, bulkErr, gorm.ErrRecordNotFound)
+	}
+	mockCartRepo.AssertNumberOfCalls(t, "CreateCartLine", 2)
+}
+
+// TestBulkAddProducts_AllFailure verifica que se devuelve un error por cada
+// línea cuando fallan todas.
+func TestBulkAddProducts_AllFailure(t *testing.T) {
+	mockCartRepo := new(MockCartRepository)
+	mockProductRepo := new(mocks.ProductRepository)
+	uc := usecase.NewCartUseCase(validation.New(), mockCartRepo, mockProductRepo, nil, nil, nil, nil)
+
+	mockCartRepo.On("GetCartByID", mock.Anything, "c1").Return(&cartEntity.Cart{ID: "c1"}, nil)
+	mockProductRepo.On("GetProductById", mock.Anything, mock.Anything).Return((*productEntity.Product)(nil), gorm.ErrRecordNotFound)
+
+	err := uc.BulkAddProducts(context.Background(), bulkAddRequest())
+
+	joined, ok := err.(interface{ Unwrap() []error })
+	if assert.True(t, ok) {
+		failures := joined.Unwrap()
+		if assert.Len(t, failures, 3) {
+			for i, failure := range failures {
+				assert.Equal(t, i, failure.(usecase.BulkAddError).Index)
+			}
+		}
+	}
+	mockCartRepo.AssertNotCalled(t, "CreateCartLine", mock.Anything, mock.Anything)
+}
+
+// TestBulkAddProducts_NoLines verifica que una petición sin líneas no pasa la
+// validación.
+func TestBulkAddProducts_NoLines(t *testing.T) {
+	mockCartRepo := new(MockCartRepository)
+	uc := usecase.NewCartUseCase(validation.New(), mockCartRepo, nil, nil, nil, nil, nil)
+
+	err := uc.BulkAddProducts(context.Background(), &cartDto.BulkAddProductsRequest{CartID: "c1"})
+
+	assert.Error(t, err)
+	mockCartRepo.AssertNotCalled(t, "GetCartByID", mock.Anything, mock.Anything)
+}
+
 // -------------------------------------
 // Tests de GetCartByUserID
 // -------------------------------------
@@ -149,10 +520,10 @@ func TestAddProduct_ValidationError(t *testing.T) {
 // 2) Devuelve el carrito esperado sin error.
 func TestGetCartByUserID_Success(t *testing.T) {
 	mockCartRepo := new(MockCartRepository)
-	mockProductRepo := new(MockProductRepository)
+	mockProductRepo := new(mocks.ProductRepository)
 	mockValidator := new(MockValidator)
 
-	uc := usecase.NewCartUseCase(mockValidator, mockCartRepo, mockProductRepo)
+	uc := usecase.NewCartUseCase(mockValidator, mockCartRepo, mockProductRepo, nil, nil, nil, nil)
 
 	expected := &cartEntity.Cart{
 		ID:     "c1",
@@ -168,14 +539,81 @@ func TestGetCartByUserID_Success(t *testing.T) {
 	mockCartRepo.AssertExpectations(t)
 }
 
+// TestGetCartByUserID_NotExpired verifica que un carrito con fecha de
+// expiración futura se devuelve normalmente.
+func TestGetCartByUserID_NotExpired(t *testing.T) {
+	mockCartRepo := new(MockCartRepository)
+	uc := usecase.NewCartUseCase(nil, mockCartRepo, nil, nil, nil, nil, nil)
+
+	expected := cartEntity.NewCart("u1", time.Hour)
+	mockCartRepo.On("GetCartByUserID", mock.Anything, "u1").Return(expected, nil)
+
+	cart, err := uc.GetCartByUserID(context.Background(), "u1")
+
+	assert.NoError(t, err)
+	assert.Equal(t, expected, cart)
+}
+
+// TestGetCartByUserID_Expired verifica que un carrito vencido se vacía, se
+// devuelve al usuario listo para seguir usándolo y el saldo de su tarjeta
+// regalo vuelve a la tarjeta.
+func TestGetCartByUserID_Expired(t *testing.T) {
+	mockCartRepo := new(MockCartRepository)
+	mockGiftCardRepo := new(MockGiftCardRepository)
+	uc := usecase.NewCartUseCase(nil, mockCartRepo, nil, nil, mockGiftCardRepo, nil, nil)
+
+	expiredAt := time.Now().Add(-time.Minute)
+	giftCardID := "g1"
+	expired := &cartEntity.Cart{
+		ID:             "c1",
+		UserID:         "u1",
+		Lines:          []*cartEntity.CartLine{{ProductID: "p1", Quantity: 1, Price: 10}},
+		GiftCardID:     &giftCardID,
+		GiftCardAmount: 10,
+		DiscountAmount: 2,
+		ExpiresAt:      &expiredAt,
+		Status:         cartEntity.CartStatusActive,
+	}
+	mockCartRepo.On("GetCartByUserID", mock.Anything, "u1").Return(expired, nil)
+	mockGiftCardRepo.On("ReleaseGiftCard", mock.Anything, "g1", 10.0).Return(nil)
+	mockCartRepo.On("ResetCart", mock.Anything, mock.MatchedBy(func(c *cartEntity.Cart) bool {
+		return c.ID == "c1" && len(c.Lines) == 0 && c.GiftCardID == nil && c.ExpiresAt == nil
+	})).Return(nil)
+
+	cart, err := uc.GetCartByUserID(context.Background(), "u1")
+
+	assert.NoError(t, err)
+	assert.Equal(t, "c1", cart.ID)
+	assert.Empty(t, cart.Lines)
+	assert.True(t, cart.IsActive())
+	mockCartRepo.AssertExpectations(t)
+	mockGiftCardRepo.AssertExpectations(t)
+}
+
+// TestGetCartByUserID_ExpiredResetError verifica que si no se puede vaciar el
+// carrito vencido se devuelve el error.
+func TestGetCartByUserID_ExpiredResetError(t *testing.T) {
+	mockCartRepo := new(MockCartRepository)
+	uc := usecase.NewCartUseCase(nil, mockCartRepo, nil, nil, nil, nil, nil)
+
+	expiredAt := time.Now().Add(-time.Minute)
+	mockCartRepo.On("GetCartByUserID", mock.Anything, "u1").Return(&cartEntity.Cart{ID: "c1", UserID: "u1", ExpiresAt: &expiredAt}, nil)
+	mockCartRepo.On("ResetCart", mock.Anything, mock.Anything).Return(errors.New("db error"))
+
+	cart, err := uc.GetCartByUserID(context.Background(), "u1")
+
+	assert.Nil(t, cart)
+	assert.EqualError(t, err, "db error")
+}
+
 // TestGetCartByUserID_RepoError verifica que GetCartByUserID devuelve un error
 // y un carrito nulo cuando el repositorio falla.
 func TestGetCartByUserID_RepoError(t *testing.T) {
 	mockCartRepo := new(MockCartRepository)
-	mockProductRepo := new(MockProductRepository)
+	mockProductRepo := new(mocks.ProductRepository)
 	mockValidator := new(MockValidator)
 
-	uc := usecase.NewCartUseCase(mockValidator, mockCartRepo, mockProductRepo)
+	uc := usecase.NewCartUseCase(mockValidator, mockCartRepo, mockProductRepo, nil, nil, nil, nil)
 
 	mockCartRepo.On("GetCartByUserID", mock.Anything, "u1").
 		Return((*cartEntity.Cart)(nil), errors.New("db error"))
@@ -198,19 +636,21 @@ func TestGetCartByUserID_RepoError(t *testing.T) {
 // 4) Llama a UpdateCartLine sin error.
 func TestUpdateCartLine_Success(t *testing.T) {
 	mockCartRepo := new(MockCartRepository)
-	mockProductRepo := new(MockProductRepository)
+	mockProductRepo := new(mocks.ProductRepository)
 	mockValidator := new(MockValidator)
 
-	uc := usecase.NewCartUseCase(mockValidator, mockCartRepo, mockProductRepo)
+	uc := usecase.NewCartUseCase(mockValidator, mockCartRepo, mockProductRepo, nil, nil, nil, nil)
 
 	req := &cartDto.UpdateCartLineRequest{CartID: "c1", ProductID: "p1", Quantity: 5}
 	original := &cartEntity.CartLine{CartID: "c1", ProductID: "p1", Quantity: 2, Price: 20.0}
 	prod := &productEntity.Product{ID: "p1", Price: 3.0}
 
 	mockValidator.On("ValidateStruct", req).Return(nil)
+	mockCartRepo.On("GetCartByID", mock.Anything, "c1").Return(&cartEntity.Cart{ID: "c1"}, nil)
 	mockProductRepo.On("GetProductById", mock.Anything, "p1").Return(prod, nil)
 	mockCartRepo.On("GetCartLineByProductIDAndCartID", mock.Anything, "c1", "p1").Return(original, nil)
 	mockCartRepo.On("UpdateCartLine", mock.Anything, original).Return(nil)
+	mockCartRepo.On("TouchCart", mock.Anything, mock.Anything).Return(nil)
 
 	err := uc.UpdateCartLine(context.Background(), req)
 
@@ -222,14 +662,69 @@ func TestUpdateCartLine_Success(t *testing.T) {
 	mockCartRepo.AssertExpectations(t)
 }
 
+// TestUpdateCartLine_ZeroQuantityRemovesLine verifica que una cantidad de 0
+// borra la línea en lugar de actualizarla.
+func TestUpdateCartLine_ZeroQuantityRemovesLine(t *testing.T) {
+	mockCartRepo := new(MockCartRepository)
+	mockProductRepo := new(mocks.ProductRepository)
+	uc := usecase.NewCartUseCase(validation.New(), mockCartRepo, mockProductRepo, nil, nil, nil, nil)
+
+	req := &cartDto.UpdateCartLineRequest{ID: "l1", CartID: "c1", ProductID: "p1", Quantity: 0}
+	line := &cartEntity.CartLine{ID: "l1", CartID: "c1", ProductID: "p1", Quantity: 2, Price: 20.0}
+
+	mockCartRepo.On("GetCartByID", mock.Anything, "c1").Return(&cartEntity.Cart{ID: "c1"}, nil)
+	mockCartRepo.On("GetCartLineByProductIDAndCartID", mock.Anything, "c1", "p1").Return(line, nil)
+	mockCartRepo.On("RemoveCartLine", mock.Anything, line).Return(nil)
+	mockCartRepo.On("TouchCart", mock.Anything, mock.Anything).Return(nil)
+
+	err := uc.UpdateCartLine(context.Background(), req)
+
+	assert.NoError(t, err)
+	mockCartRepo.AssertExpectations(t)
+	mockCartRepo.AssertNotCalled(t, "UpdateCartLine", mock.Anything, mock.Anything)
+	mockProductRepo.AssertNotCalled(t, "GetProductById", mock.Anything, mock.Anything)
+}
+
+// TestUpdateCartLine_ZeroQuantityGetLineError verifica que si la línea no se
+// encuentra el error se propaga y no se borra nada.
+func TestUpdateCartLine_ZeroQuantityGetLineError(t *testing.T) {
+	mockCartRepo := new(MockCartRepository)
+	uc := usecase.NewCartUseCase(validation.New(), mockCartRepo, new(mocks.ProductRepository), nil, nil, nil, nil)
+
+	req := &cartDto.UpdateCartLineRequest{ID: "l1", CartID: "c1", ProductID: "p1", Quantity: 0}
+
+	mockCartRepo.On("GetCartByID", mock.Anything, "c1").Return(&cartEntity.Cart{ID: "c1"}, nil)
+	mockCartRepo.On("GetCartLineByProductIDAndCartID", mock.Anything, "c1", "p1").Return(nil, gorm.ErrRecordNotFound)
+
+	err := uc.UpdateCartLine(context.Background(), req)
+
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+	mockCartRepo.AssertNotCalled(t, "RemoveCartLine", mock.Anything, mock.Anything)
+	mockCartRepo.AssertNotCalled(t, "UpdateCartLine", mock.Anything, mock.Anything)
+}
+
+// TestUpdateCartLine_NegativeQuantity verifica que una cantidad negativa no
+// pasa la validación.
+func TestUpdateCartLine_NegativeQuantity(t *testing.T) {
+	mockCartRepo := new(MockCartRepository)
+	uc := usecase.NewCartUseCase(validation.New(), mockCartRepo, new(mocks.ProductRepository), nil, nil, nil, nil)
+
+	req := &cartDto.UpdateCartLineRequest{ID: "l1", CartID: "c1", ProductID: "p1", Quantity: -1}
+
+	err := uc.UpdateCartLine(context.Background(), req)
+
+	assert.Error(t, err)
+	mockCartRepo.AssertNotCalled(t, "GetCartByID", mock.Anything, mock.Anything)
+}
+
 // TestUpdateCartLine_ValidationError verifica que UpdateCartLine devuelve un error
 // cuando la validación de la petición falla antes de cualquier otra operación.
 func TestUpdateCartLine_ValidationError(t *testing.T) {
 	mockCartRepo := new(MockCartRepository)
-	mockProductRepo := new(MockProductRepository)
+	mockProductRepo := new(mocks.ProductRepository)
 	mockValidator := new(MockValidator)
 
-	uc := usecase.NewCartUseCase(mockValidator, mockCartRepo, mockProductRepo)
+	uc := usecase.NewCartUseCase(mockValidator, mockCartRepo, mockProductRepo, nil, nil, nil, nil)
 
 	req := &cartDto.UpdateCartLineRequest{CartID: "", ProductID: "p1", Quantity: 0}
 	mockValidator.On("ValidateStruct", req).Return(errors.New("invalid"))
@@ -249,16 +744,18 @@ func TestUpdateCartLine_ValidationError(t *testing.T) {
 // 2) Llama a RemoveCartLine sin error.
 func TestRemoveProduct_Success(t *testing.T) {
 	mockCartRepo := new(MockCartRepository)
-	mockProductRepo := new(MockProductRepository)
+	mockProductRepo := new(mocks.ProductRepository)
 	mockValidator := new(MockValidator)
 
-	uc := usecase.NewCartUseCase(mockValidator, mockCartRepo, mockProductRepo)
+	uc := usecase.NewCartUseCase(mockValidator, mockCartRepo, mockProductRepo, nil, nil, nil, nil)
 
 	req := &cartDto.RemoveProductRequest{CartID: "c1", ProductID: "p1"}
 	cl := &cartEntity.CartLine{CartID: "c1", ProductID: "p1"}
 
+	mockCartRepo.On("GetCartByID", mock.Anything, "c1").Return(&cartEntity.Cart{ID: "c1"}, nil)
 	mockCartRepo.On("GetCartLineByProductIDAndCartID", mock.Anything, "c1", "p1").Return(cl, nil)
 	mockCartRepo.On("RemoveCartLine", mock.Anything, cl).Return(nil)
+	mockCartRepo.On("TouchCart", mock.Anything, mock.Anything).Return(nil)
 
 	err := uc.RemoveProduct(context.Background(), req)
 
@@ -270,12 +767,13 @@ func TestRemoveProduct_Success(t *testing.T) {
 // cuando no se puede recuperar la línea de carrito.
 func TestRemoveProduct_GetCartLineError(t *testing.T) {
 	mockCartRepo := new(MockCartRepository)
-	mockProductRepo := new(MockProductRepository)
+	mockProductRepo := new(mocks.ProductRepository)
 	mockValidator := new(MockValidator)
 
-	uc := usecase.NewCartUseCase(mockValidator, mockCartRepo, mockProductRepo)
+	uc := usecase.NewCartUseCase(mockValidator, mockCartRepo, mockProductRepo, nil, nil, nil, nil)
 
 	req := &cartDto.RemoveProductRequest{CartID: "c1", ProductID: "p1"}
+	mockCartRepo.On("GetCartByID", mock.Anything, "c1").Return(&cartEntity.Cart{ID: "c1"}, nil)
 	mockCartRepo.On("GetCartLineByProductIDAndCartID", mock.Anything, "c1", "p1").
 		Return((*cartEntity.CartLine)(nil), errors.New("not found"))
 
@@ -284,3 +782,1782 @@ func TestRemoveProduct_GetCartLineError(t *testing.T) {
 	assert.EqualError(t, err, "not found")
 	mockCartRepo.AssertExpectations(t)
 }
+
+// -------------------------------------
+// Tests de ApplyCoupon
+// -------------------------------------
+
+func couponTestCart() *cartEntity.Cart {
+	return &cartEntity.Cart{ID: "c1", UserID: "u1", Lines: []*cartEntity.CartLine{
+		{ProductID: "p1", Quantity: 2, Price: 30},
+		{ProductID: "p2", Quantity: 1, Price: 20},
+	}}
+}
+
+// TestApplyCoupon_Valid verifica que se guarda el descuento aplicado y lo que
+// descuenta sobre el subtotal, tanto porcentual como fijo.
+func TestApplyCoupon_Valid(t *testing.T) {
+	future := time.Now().Add(time.Hour)
+	cases := []struct {
+		name     string
+		discount *discountEntity.Discount
+		want     float64
+	}{
+		{"porcentaje", &discountEntity.Discount{ID: "d1", Code: "TEN", Type: discountEntity.DiscountTypePercentage, Amount: 10, ExpiresAt: &future}, 5},
+		{"fijo", &discountEntity.Discount{ID: "d1", Code: "TEN", Type: discountEntity.DiscountTypeFlat, Amount: 7.5}, 7.5},
+		{"sin tipo cuenta como fijo", &discountEntity.Discount{ID: "d1", Code: "TEN", Amount: 7.5}, 7.5},
+		{"fijo mayor que el subtotal", &discountEntity.Discount{ID: "d1", Code: "TEN", Type: discountEntity.DiscountTypeFlat, Amount: 80}, 50},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			mockCartRepo := new(MockCartRepository)
+			mockOrderRepo := new(MockOrderRepository)
+			uc := usecase.NewCartUseCase(nil, mockCartRepo, nil, mockOrderRepo, nil, nil, nil)
+
+			cart := couponTestCart()
+			mockCartRepo.On("GetCartByID", mock.Anything, "c1").Return(cart, nil)
+			mockOrderRepo.On("GetDiscountByCode", mock.Anything, "TEN").Return(c.discount, nil)
+			mockCartRepo.On("UpdateCart", mock.Anything, cart).Return(nil)
+
+			err := uc.ApplyCoupon(context.Background(), "c1", "u1", "TEN")
+
+			assert.NoError(t, err)
+			if assert.NotNil(t, cart.DiscountID) {
+				assert.Equal(t, "d1", *cart.DiscountID)
+			}
+			assert.Equal(t, c.want, cart.DiscountAmount)
+			mockCartRepo.AssertExpectations(t)
+		})
+	}
+}
+
+// TestApplyCoupon_Expired verifica que un descuento vencido se rechaza sin
+// tocar el carrito.
+func TestApplyCoupon_Expired(t *testing.T) {
+	mockCartRepo := new(MockCartRepository)
+	mockOrderRepo := new(MockOrderRepository)
+	uc := usecase.NewCartUseCase(nil, mockCartRepo, nil, mockOrderRepo, nil, nil, nil)
+
+	past := time.Now().Add(-time.Hour)
+	mockCartRepo.On("GetCartByID", mock.Anything, "c1").Return(couponTestCart(), nil)
+	mockOrderRepo.On("GetDiscountByCode", mock.Anything, "OLD").
+		Return(&discountEntity.Discount{ID: "d1", Code: "OLD", Amount: 5, ExpiresAt: &past}, nil)
+
+	err := uc.ApplyCoupon(context.Background(), "c1", "u1", "OLD")
+
+	assert.ErrorIs(t, err, usecase.ErrCouponExpired)
+	mockCartRepo.AssertNotCalled(t, "UpdateCart", mock.Anything, mock.Anything)
+}
+
+// TestApplyCoupon_NotFound verifica que un código inexistente devuelve
+// ErrCouponNotFound.
+func TestApplyCoupon_NotFound(t *testing.T) {
+	mockCartRepo := new(MockCartRepository)
+	mockOrderRepo := new(MockOrderRepository)
+	uc := usecase.NewCartUseCase(nil, mockCartRepo, nil, mockOrderRepo, nil, nil, nil)
+
+	mockCartRepo.On("GetCartByID", mock.Anything, "c1").Return(couponTestCart(), nil)
+	mockOrderRepo.On("GetDiscountByCode", mock.Anything, "NOPE").Return(nil, gorm.ErrRecordNotFound)
+
+	err := uc.ApplyCoupon(context.Background(), "c1", "u1", "NOPE")
+
+	assert.ErrorIs(t, err, usecase.ErrCouponNotFound)
+	mockCartRepo.AssertNotCalled(t, "UpdateCart", mock.Anything, mock.Anything)
+}
+
+// TestApplyCoupon_Remove verifica que un código vacío quita el descuento del
+// carrito.
+func TestApplyCoupon_Remove(t *testing.T) {
+	mockCartRepo := new(MockCartRepository)
+	mockOrderRepo := new(MockOrderRepository)
+	uc := usecase.NewCartUseCase(nil, mockCartRepo, nil, mockOrderRepo, nil, nil, nil)
+
+	discountID := "d1"
+	cart := couponTestCart()
+	cart.DiscountID = &discountID
+	cart.DiscountAmount = 5
+	mockCartRepo.On("GetCartByID", mock.Anything, "c1").Return(cart, nil)
+	mockCartRepo.On("UpdateCart", mock.Anything, cart).Return(nil)
+
+	err := uc.ApplyCoupon(context.Background(), "c1", "u1", "")
+
+	assert.NoError(t, err)
+	assert.Nil(t, cart.DiscountID)
+	assert.Zero(t, cart.DiscountAmount)
+	mockOrderRepo.AssertNotCalled(t, "GetDiscountByCode", mock.Anything, mock.Anything)
+}
+
+// TestApplyCoupon_NotOwned verifica que no se puede aplicar un cupón al
+// carrito de otro usuario.
+func TestApplyCoupon_NotOwned(t *testing.T) {
+	mockCartRepo := new(MockCartRepository)
+	mockOrderRepo := new(MockOrderRepository)
+	uc := usecase.NewCartUseCase(nil, mockCartRepo, nil, mockOrderRepo, nil, nil, nil)
+
+	mockCartRepo.On("GetCartByID", mock.Anything, "c1").Return(couponTestCart(), nil)
+
+	err := uc.ApplyCoupon(context.Background(), "c1", "u2", "TEN")
+
+	assert.ErrorIs(t, err, usecase.ErrCartNotOwned)
+	mockOrderRepo.AssertNotCalled(t, "GetDiscountByCode", mock.Anything, mock.Anything)
+	mockCartRepo.AssertNotCalled(t, "UpdateCart", mock.Anything, mock.Anything)
+}
+
+// TestAddProduct_RepricesDiscount verifica que al cambiar las líneas se
+// recalcula el descuento sobre el nuevo subtotal.
+func TestAddProduct_RepricesDiscount(t *testing.T) {
+	mockCartRepo := new(MockCartRepository)
+	mockProductRepo := new(mocks.ProductRepository)
+	mockOrderRepo := new(MockOrderRepository)
+	mockValidator := new(MockValidator)
+	uc := usecase.NewCartUseCase(mockValidator, mockCartRepo, mockProductRepo, mockOrderRepo, nil, nil, nil)
+
+	discountID := "d1"
+	cart := couponTestCart()
+	cart.DiscountID = &discountID
+	cart.DiscountAmount = 5
+	req := &cartDto.AddProductRequest{CartID: "c1", ProductID: "p3", Quantity: 1}
+
+	mockValidator.On("ValidateStruct", req).Return(nil)
+	mockCartRepo.On("GetCartByID", mock.Anything, "c1").Return(cart, nil)
+	mockProductRepo.On("GetProductById", mock.Anything, "p3").Return(&productEntity.Product{ID: "p3", Price: 50}, nil)
+	mockCartRepo.On("GetCartLineByProductIDAndCartID", mock.Anything, "c1", "p3").Return(nil, gorm.ErrRecordNotFound)
+	mockCartRepo.On("CreateCartLine", mock.Anything, mock.Anything).Return(nil)
+	mockOrderRepo.On("GetDiscount", mock.Anything, "d1").
+		Return(&discountEntity.Discount{ID: "d1", Type: discountEntity.DiscountTypePercentage, Amount: 10}, nil)
+	mockCartRepo.On("SumCartLinesPrices", mock.Anything, "c1").Return(100.0, nil)
+	mockCartRepo.On("TouchCart", mock.Anything, cart).Return(nil)
+
+	err := uc.AddProduct(context.Background(), req)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 10.0, cart.DiscountAmount)
+	mockCartRepo.AssertExpectations(t)
+}
+
+// TestRemoveProduct_DropsExpiredDiscount verifica que un descuento vencido se
+// quita del carrito la próxima vez que cambian sus líneas.
+func TestRemoveProduct_DropsExpiredDiscount(t *testing.T) {
+	mockCartRepo := new(MockCartRepository)
+	mockOrderRepo := new(MockOrderRepository)
+	uc := usecase.NewCartUseCase(nil, mockCartRepo, nil, mockOrderRepo, nil, nil, nil)
+
+	discountID := "d1"
+	past := time.Now().Add(-time.Hour)
+	cart := couponTestCart()
+	cart.DiscountID = &discountID
+	cart.DiscountAmount = 5
+	line := cart.Lines[0]
+
+	mockCartRepo.On("GetCartByID", mock.Anything, "c1").Return(cart, nil)
+	mockCartRepo.On("GetCartLineByProductIDAndCartID", mock.Anything, "c1", "p1").Return(line, nil)
+	mockCartRepo.On("RemoveCartLine", mock.Anything, line).Return(nil)
+	mockOrderRepo.On("GetDiscount", mock.Anything, "d1").
+		Return(&discountEntity.Discount{ID: "d1", Amount: 5, ExpiresAt: &past}, nil)
+	mockCartRepo.On("TouchCart", mock.Anything, cart).Return(nil)
+
+	err := uc.RemoveProduct(context.Background(), &cartDto.RemoveProductRequest{CartID: "c1", ProductID: "p1"})
+
+	assert.NoError(t, err)
+	assert.Nil(t, cart.DiscountID)
+	assert.Zero(t, cart.DiscountAmount)
+	mockCartRepo.AssertExpectations(t)
+}
+
+// -------------------------------------
+// Tests de DetectPriceDrift
+// -------------------------------------
+
+// TestDetectPriceDrift verifica que solo se devuelven las líneas cuyo precio
+// unitario difiere del precio actual del producto.
+func TestDetectPriceDrift(t *testing.T) {
+	mockCartRepo := new(MockCartRepository)
+	mockProductRepo := new(mocks.ProductRepository)
+	uc := usecase.NewCartUseCase(nil, mockCartRepo, mockProductRepo, nil, nil, nil, nil)
+
+	cart := &cartEntity.Cart{ID: "c1", Lines: []*cartEntity.CartLine{
+		{ProductID: "p1", Quantity: 2, Price: 20},
+		{ProductID: "p2", Quantity: 3, Price: 15},
+	}}
+	mockCartRepo.On("GetCartByID", mock.Anything, "c1").Return(cart, nil)
+	mockProductRepo.On("GetProductById", mock.Anything, "p1").Return(&productEntity.Product{ID: "p1", Price: 10}, nil)
+	mockProductRepo.On("GetProductById", mock.Anything, "p2").Return(&productEntity.Product{ID: "p2", Price: 6}, nil)
+
+	drifted, err := uc.DetectPriceDrift(context.Background(), "c1")
+
+	assert.NoError(t, err)
+	assert.Equal(t, []*cartDto.PriceDriftItem{{ProductID: "p2", CartPrice: 5, CurrentPrice: 6}}, drifted)
+}
+
+// TestDetectPriceDrift_ProductError verifica que el error al buscar un
+// producto se propaga.
+func TestDetectPriceDrift_ProductError(t *testing.T) {
+	mockCartRepo := new(MockCartRepository)
+	mockProductRepo := new(mocks.ProductRepository)
+	uc := usecase.NewCartUseCase(nil, mockCartRepo, mockProductRepo, nil, nil, nil, nil)
+
+	cart := &cartEntity.Cart{ID: "c1", Lines: []*cartEntity.CartLine{{ProductID: "p1", Quantity: 1, Price: 10}}}
+	mockCartRepo.On("GetCartByID", mock.Anything, "c1").Return(cart, nil)
+	mockProductRepo.On("GetProductById", mock.Anything, "p1").Return((*productEntity.Product)(nil), gorm.ErrRecordNotFound)
+
+	drifted, err := uc.DetectPriceDrift(context.Background(), "c1")
+
+	assert.Nil(t, drifted)
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+}
+
+// -------------------------------------
+// Tests de MergeCarts
+// -------------------------------------
+
+// TestMergeCarts_Success verifica que cada línea del carrito invitado se añade
+// al carrito del usuario (sumando cantidades si ya existe) y que después se
+// vacía el carrito invitado.
+func TestMergeCarts_Success(t *testing.T) {
+	mockCartRepo := new(MockCartRepository)
+	mockProductRepo := new(mocks.ProductRepository)
+	uc := usecase.NewCartUseCase(validation.New(), mockCartRepo, mockProductRepo, nil, nil, nil, nil)
+
+	guest := &cartEntity.Cart{ID: "guest", UserID: "guest-user", Lines: []*cartEntity.CartLine{
+		{ID: "g1", ProductID: "p1", Quantity: 2},
+		{ID: "g2", ProductID: "p2", Quantity: 1},
+	}}
+	existing := &cartEntity.CartLine{ID: "t1", CartID: "c1", ProductID: "p1", Quantity: 1}
+	mockCartRepo.On("GetCartByID", mock.Anything, "guest").Return(guest, nil)
+	mockCartRepo.On("GetCartByUserID", mock.Anything, "u1").Return(&cartEntity.Cart{ID: "c1", UserID: "u1"}, nil)
+	mockCartRepo.On("GetCartByID", mock.Anything, "c1").Return(&cartEntity.Cart{ID: "c1"}, nil)
+	mockProductRepo.On("GetProductById", mock.Anything, "p1").Return(&productEntity.Product{ID: "p1", Price: 10}, nil)
+	mockProductRepo.On("GetProductById", mock.Anything, "p2").Return(&productEntity.Product{ID: "p2", Price: 4}, nil)
+	mockCartRepo.On("GetCartLineByProductIDAndCartID", mock.Anything, "c1", "p1").Return(existing, nil)
+	mockCartRepo.On("GetCartLineByProductIDAndCartID", mock.Anything, "c1", "p2").Return(nil, gorm.ErrRecordNotFound)
+	mockCartRepo.On("UpdateCartLine", mock.Anything, existing).Return(nil)
+	mockCartRepo.On("TouchCart", mock.Anything, mock.Anything).Return(nil)
+	mockCartRepo.On("CreateCartLine", mock.Anything, mock.MatchedBy(func(cl *cartEntity.CartLine) bool {
+		return cl.CartID == "c1" && cl.ProductID == "p2" && cl.Quantity == 1
+	})).Return(nil)
+	mockCartRepo.On("DeleteAllCartLines", mock.Anything, "guest").Return(nil)
+
+	err := uc.MergeCarts(context.Background(), "guest", "guest-user", "u1")
+
+	assert.NoError(t, err)
+	assert.Equal(t, uint(3), existing.Quantity)
+	mockCartRepo.AssertExpectations(t)
+	mockCartRepo.AssertNotCalled(t, "DeleteAllCartLines", mock.Anything, "c1")
+}
+
+// TestMergeCarts_PartialFailure verifica que si una línea falla se devuelve el
+// error sin vaciar ninguno de los dos carritos: la transacción deshace solo lo
+// que añadió la fusión.
+func TestMergeCarts_PartialFailure(t *testing.T) {
+	mockCartRepo := new(MockCartRepository)
+	mockProductRepo := new(mocks.ProductRepository)
+	uc := usecase.NewCartUseCase(validation.New(), mockCartRepo, mockProductRepo, nil, nil, nil, nil)
+
+	guest := &cartEntity.Cart{ID: "guest", UserID: "guest-user", Lines: []*cartEntity.CartLine{
+		{ID: "g1", ProductID: "p1", Quantity: 2},
+		{ID: "g2", ProductID: "gone", Quantity: 1},
+	}}
+	mockCartRepo.On("GetCartByID", mock.Anything, "guest").Return(guest, nil)
+	mockCartRepo.On("GetCartByUserID", mock.Anything, "u1").Return(&cartEntity.Cart{ID: "c1", UserID: "u1"}, nil)
+	mockCartRepo.On("GetCartByID", mock.Anything, "c1").
+		Return(&cartEntity.Cart{ID: "c1"}, nil).Once()
+	mockProductRepo.On("GetProductById", mock.Anything, "p1").Return(&productEntity.Product{ID: "p1", Price: 10}, nil)
+	mockCartRepo.On("GetCartLineByProductIDAndCartID", mock.Anything, "c1", "p1").Return(nil, gorm.ErrRecordNotFound)
+	mockCartRepo.On("CreateCartLine", mock.Anything, mock.Anything).Return(nil)
+	mockCartRepo.On("TouchCart", mock.Anything, mock.Anything).Return(nil)
+	mockCartRepo.On("GetCartByID", mock.Anything, "c1").
+		Return(&cartEntity.Cart{ID: "c1"}, nil).Once()
+	mockProductRepo.On("GetProductById", mock.Anything, "gone").Return((*productEntity.Product)(nil), gorm.ErrRecordNotFound)
+
+	err := uc.MergeCarts(context.Background(), "guest", "guest-user", "u1")
+
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+	mockCartRepo.AssertNotCalled(t, "DeleteAllCartLines", mock.Anything, mock.Anything)
+}
+
+// TestMergeCarts_SourceNotOwned verifica que no se puede fusionar un carrito
+// que no pertenece al usuario indicado como origen.
+func TestMergeCarts_SourceNotOwned(t *testing.T) {
+	mockCartRepo := new(MockCartRepository)
+	uc := usecase.NewCartUseCase(validation.New(), mockCartRepo, nil, nil, nil, nil, nil)
+
+	mockCartRepo.On("GetCartByID", mock.Anything, "other").Return(&cartEntity.Cart{ID: "other", UserID: "u2", Lines: []*cartEntity.CartLine{
+		{ID: "o1", ProductID: "p1", Quantity: 1},
+	}}, nil)
+
+	err := uc.MergeCarts(context.Background(), "other", "guest-user", "u1")
+
+	assert.ErrorIs(t, err, usecase.ErrCartNotOwned)
+	mockCartRepo.AssertNotCalled(t, "GetCartByUserID", mock.Anything, mock.Anything)
+	mockCartRepo.AssertNotCalled(t, "DeleteAllCartLines", mock.Anything, mock.Anything)
+}
+
+// TestMergeCarts_EmptySource verifica que un carrito invitado vacío no toca el
+// carrito del usuario.
+func TestMergeCarts_EmptySource(t *testing.T) {
+	mockCartRepo := new(MockCartRepository)
+	uc := usecase.NewCartUseCase(validation.New(), mockCartRepo, nil, nil, nil, nil, nil)
+
+	mockCartRepo.On("GetCartByID", mock.Anything, "guest").Return(&cartEntity.Cart{ID: "guest", UserID: "guest-user"}, nil)
+
+	err := uc.MergeCarts(context.Background(), "guest", "guest-user", "u1")
+
+	assert.NoError(t, err)
+	mockCartRepo.AssertNotCalled(t, "GetCartByUserID", mock.Anything, mock.Anything)
+	mockCartRepo.AssertNotCalled(t, "DeleteAllCartLines", mock.Anything, mock.Anything)
+}
+
+// -------------------------------------
+// Tests de GetCartItemCount
+// -------------------------------------
+
+// TestGetCartItemCount verifica que se suman las cantidades de todas las
+// líneas del carrito.
+func TestGetCartItemCount(t *testing.T) {
+	cases := []struct {
+		name  string
+		lines []*cartEntity.CartLine
+		want  int
+	}{
+		{"sin líneas", nil, 0},
+		{"una línea", []*cartEntity.CartLine{{ProductID: "p1", Quantity: 3}}, 3},
+		{"dos líneas", []*cartEntity.CartLine{{ProductID: "p1", Quantity: 2}, {ProductID: "p2", Quantity: 5}}, 7},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			mockCartRepo := new(MockCartRepository)
+			uc := usecase.NewCartUseCase(nil, mockCartRepo, nil, nil, nil, nil, nil)
+
+			mockCartRepo.On("GetCartByUserID", mock.Anything, "u1").Return(&cartEntity.Cart{ID: "c1", UserID: "u1", Lines: c.lines}, nil)
+
+			count, err := uc.GetCartItemCount(context.Background(), "u1")
+
+			assert.NoError(t, err)
+			assert.Equal(t, c.want, count)
+		})
+	}
+}
+
+// TestGetCartItemCount_NoCart verifica que sin carrito activo se empieza uno
+// nuevo y se devuelve 0 sin error.
+func TestGetCartItemCount_NoCart(t *testing.T) {
+	mockCartRepo := new(MockCartRepository)
+	uc := usecase.NewCartUseCase(nil, mockCartRepo, nil, nil, nil, nil, nil)
+
+	mockCartRepo.On("GetCartByUserID", mock.Anything, "u1").Return((*cartEntity.Cart)(nil), gorm.ErrRecordNotFound)
+	mockCartRepo.On("CreateCart", mock.Anything, mock.Anything).Return(nil)
+
+	count, err := uc.GetCartItemCount(context.Background(), "u1")
+
+	assert.NoError(t, err)
+	assert.Zero(t, count)
+}
+
+// TestGetCartItemCount_RepoError verifica que otros errores del repositorio se
+// propagan.
+func TestGetCartItemCount_RepoError(t *testing.T) {
+	mockCartRepo := new(MockCartRepository)
+	uc := usecase.NewCartUseCase(nil, mockCartRepo, nil, nil, nil, nil, nil)
+
+	mockCartRepo.On("GetCartByUserID", mock.Anything, "u1").Return((*cartEntity.Cart)(nil), errors.New("db error"))
+
+	count, err := uc.GetCartItemCount(context.Background(), "u1")
+
+	assert.EqualError(t, err, "db error")
+	assert.Zero(t, count)
+}
+
+// -------------------------------------
+// Tests de PurgeExpiredCarts
+// -------------------------------------
+
+// TestPurgeExpiredCarts verifica que los carritos vencidos hasta ahora se
+// vacían en lugar de borrarse y se devuelve cuántos se vaciaron.
+func TestPurgeExpiredCarts(t *testing.T) {
+	mockCartRepo := new(MockCartRepository)
+	uc := usecase.NewCartUseCase(nil, mockCartRepo, nil, nil, nil, nil, nil)
+
+	past := time.Now().Add(-time.Hour)
+	carts := []*cartEntity.Cart{
+		{ID: "c1", UserID: "u1", ExpiresAt: &past},
+		{ID: "c2", UserID: "u2", ExpiresAt: &past},
+	}
+	before := time.Now()
+	mockCartRepo.On("GetExpiredCarts", mock.Anything, mock.MatchedBy(func(ts time.Time) bool {
+		return !ts.Before(before) && !ts.After(time.Now())
+	})).Return(carts, nil)
+	mockCartRepo.On("ResetCart", mock.Anything, carts[0]).Return(nil)
+	mockCartRepo.On("ResetCart", mock.Anything, carts[1]).Return(nil)
+
+	purged, err := uc.PurgeExpiredCarts(context.Background())
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), purged)
+	for _, cart := range carts {
+		assert.Nil(t, cart.ExpiresAt)
+	}
+	mockCartRepo.AssertExpectations(t)
+}
+
+// TestPurgeExpiredCarts_RepoError verifica que el error del repositorio se
+// propaga.
+func TestPurgeExpiredCarts_RepoError(t *testing.T) {
+	mockCartRepo := new(MockCartRepository)
+	uc := usecase.NewCartUseCase(nil, mockCartRepo, nil, nil, nil, nil, nil)
+
+	mockCartRepo.On("GetExpiredCarts", mock.Anything, mock.Anything).Return(nil, errors.New("db error"))
+
+	purged, err := uc.PurgeExpiredCarts(context.Background())
+
+	assert.EqualError(t, err, "db error")
+	assert.Zero(t, purged)
+}
+
+// TestPurgeExpiredCarts_ResetError verifica que si falla un carrito se
+// devuelve el error junto con los que ya se vaciaron.
+func TestPurgeExpiredCarts_ResetError(t *testing.T) {
+	mockCartRepo := new(MockCartRepository)
+	uc := usecase.NewCartUseCase(nil, mockCartRepo, nil, nil, nil, nil, nil)
+
+	carts := []*cartEntity.Cart{{ID: "c1", UserID: "u1"}, {ID: "c2", UserID: "u2"}}
+	mockCartRepo.On("GetExpiredCarts", mock.Anything, mock.Anything).Return(carts, nil)
+	mockCartRepo.On("ResetCart", mock.Anything, carts[0]).Return(nil)
+	mockCartRepo.On("ResetCart", mock.Anything, carts[1]).Return(errors.New("db error"))
+
+	purged, err := uc.PurgeExpiredCarts(context.Background())
+
+	assert.EqualError(t, err, "db error")
+	assert.Equal(t, int64(1), purged)
+}
+
+// -------------------------------------
+// Tests de estado del carrito
+// -------------------------------------
+
+// TestCartMutations_CheckedOutCart verifica que no se puede añadir, cambiar ni
+// quitar productos de un carrito ya pagado.
+func TestCartMutations_CheckedOutCart(t *testing.T) {
+	cases := []struct {
+		name   string
+		mutate func(uc *usecase.CartUseCase) error
+	}{
+		{"AddProduct", func(uc *usecase.CartUseCase) error {
+			return uc.AddProduct(context.Background(), &cartDto.AddProductRequest{CartID: "c1", ProductID: "p1", Quantity: 1})
+		}},
+		{"UpdateCartLine", func(uc *usecase.CartUseCase) error {
+			return uc.UpdateCartLine(context.Background(), &cartDto.UpdateCartLineRequest{ID: "l1", CartID: "c1", ProductID: "p1", Quantity: 2})
+		}},
+		{"RemoveProduct", func(uc *usecase.CartUseCase) error {
+			return uc.RemoveProduct(context.Background(), &cartDto.RemoveProductRequest{CartID: "c1", ProductID: "p1"})
+		}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			mockCartRepo := new(MockCartRepository)
+			mockProductRepo := new(mocks.ProductRepository)
+			uc := usecase.NewCartUseCase(validation.New(), mockCartRepo, mockProductRepo, nil, nil, nil, nil)
+
+			mockCartRepo.On("GetCartByID", mock.Anything, "c1").
+				Return(&cartEntity.Cart{ID: "c1", Status: cartEntity.CartStatusCheckedOut}, nil)
+
+			err := c.mutate(uc)
+
+			assert.ErrorIs(t, err, usecase.ErrCartNotActive)
+			mockProductRepo.AssertNotCalled(t, "GetProductById", mock.Anything, mock.Anything)
+			mockCartRepo.AssertNotCalled(t, "GetCartLineByProductIDAndCartID", mock.Anything, mock.Anything, mock.Anything)
+		})
+	}
+}
+
+// -------------------------------------
+// Tests de ClearCart
+// -------------------------------------
+
+// TestClearCart_WithLines verifica que se borran todas las líneas del carrito
+// en una sola llamada.
+func TestClearCart_WithLines(t *testing.T) {
+	mockCartRepo := new(MockCartRepository)
+	uc := usecase.NewCartUseCase(nil, mockCartRepo, nil, nil, nil, nil, nil)
+
+	cart := &cartEntity.Cart{ID: "c1", Lines: []*cartEntity.CartLine{{ID: "l1"}, {ID: "l2"}, {ID: "l3"}}}
+	mockCartRepo.On("GetCartByID", mock.Anything, "c1").Return(cart, nil)
+	mockCartRepo.On("DeleteAllCartLines", mock.Anything, "c1").Return(nil)
+	mockCartRepo.On("TouchCart", mock.Anything, cart).Return(nil)
+
+	err := uc.ClearCart(context.Background(), "c1")
+
+	assert.NoError(t, err)
+	mockCartRepo.AssertExpectations(t)
+	mockCartRepo.AssertNotCalled(t, "RemoveCartLine", mock.Anything, mock.Anything)
+}
+
+// TestClearCart_RepricesDiscountAndReleasesGiftCard verifica que al vaciar el
+// carrito el descuento queda en 0 y todo el importe de la tarjeta regalo vuelve
+// a la tarjeta.
+func TestClearCart_RepricesDiscountAndReleasesGiftCard(t *testing.T) {
+	mockCartRepo := new(MockCartRepository)
+	mockOrderRepo := new(MockOrderRepository)
+	mockGiftCardRepo := new(MockGiftCardRepository)
+	uc := usecase.NewCartUseCase(nil, mockCartRepo, nil, mockOrderRepo, mockGiftCardRepo, nil, nil)
+
+	discountID, giftCardID := "d1", "g1"
+	cart := couponTestCart()
+	cart.DiscountID = &discountID
+	cart.DiscountAmount = 5
+	cart.GiftCardID = &giftCardID
+	cart.GiftCardAmount = 30
+	mockCartRepo.On("GetCartByID", mock.Anything, "c1").Return(cart, nil)
+	mockCartRepo.On("DeleteAllCartLines", mock.Anything, "c1").Return(nil)
+	mockOrderRepo.On("GetDiscount", mock.Anything, "d1").
+		Return(&discountEntity.Discount{ID: "d1", Type: discountEntity.DiscountTypePercentage, Amount: 10}, nil)
+	mockCartRepo.On("SumCartLinesPrices", mock.Anything, "c1").Return(0.0, nil)
+	mockGiftCardRepo.On("ReleaseGiftCard", mock.Anything, "g1", 30.0).Return(nil)
+	mockCartRepo.On("UpdateCartGiftCard", mock.Anything, cart).Return(nil)
+	mockCartRepo.On("TouchCart", mock.Anything, cart).Return(nil)
+
+	err := uc.ClearCart(context.Background(), "c1")
+
+	assert.NoError(t, err)
+	if assert.NotNil(t, cart.DiscountID) {
+		assert.Equal(t, "d1", *cart.DiscountID)
+	}
+	assert.Zero(t, cart.DiscountAmount)
+	assert.Nil(t, cart.GiftCardID)
+	assert.Zero(t, cart.GiftCardAmount)
+	mockGiftCardRepo.AssertExpectations(t)
+	mockCartRepo.AssertExpectations(t)
+}
+
+// TestClearCart_Empty verifica que un carrito vacío no llega a la base de
+// datos.
+func TestClearCart_Empty(t *testing.T) {
+	mockCartRepo := new(MockCartRepository)
+	uc := usecase.NewCartUseCase(nil, mockCartRepo, nil, nil, nil, nil, nil)
+
+	mockCartRepo.On("GetCartByID", mock.Anything, "c1").Return(&cartEntity.Cart{ID: "c1"}, nil)
+
+	err := uc.ClearCart(context.Background(), "c1")
+
+	assert.NoError(t, err)
+	mockCartRepo.AssertNotCalled(t, "DeleteAllCartLines", mock.Anything, mock.Anything)
+}
+
+// TestClearCart_RepoError verifica que el error del borrado se propaga.
+func TestClearCart_RepoError(t *testing.T) {
+	mockCartRepo := new(MockCartRepository)
+	uc := usecase.NewCartUseCase(nil, mockCartRepo, nil, nil, nil, nil, nil)
+
+	cart := &cartEntity.Cart{ID: "c1", Lines: []*cartEntity.CartLine{{ID: "l1"}}}
+	mockCartRepo.On("GetCartByID", mock.Anything, "c1").Return(cart, nil)
+	mockCartRepo.On("DeleteAllCartLines", mock.Anything, "c1").Return(errors.New("db error"))
+
+	err := uc.ClearCart(context.Background(), "c1")
+
+	assert.EqualError(t, err, "db error")
+}
+
+// TestClearCart_CartNotFound verifica que si el carrito no existe se devuelve
+// el error del repositorio.
+func TestClearCart_CartNotFound(t *testing.T) {
+	mockCartRepo := new(MockCartRepository)
+	uc := usecase.NewCartUseCase(nil, mockCartRepo, nil, nil, nil, nil, nil)
+
+	mockCartRepo.On("GetCartByID", mock.Anything, "c1").Return(nil, gorm.ErrRecordNotFound)
+
+	err := uc.ClearCart(context.Background(), "c1")
+
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+}
+
+// -------------------------------------
+// Tests de GetCartByID
+// -------------------------------------
+
+// TestAddProduct_UsesGetCartByID verifica que AddProduct resuelve el carrito
+// por su ID y nunca pasa por GetCartByUserID.
+func TestAddProduct_UsesGetCartByID(t *testing.T) {
+	mockCartRepo := new(MockCartRepository)
+	mockProductRepo := new(mocks.ProductRepository)
+	mockValidator := new(MockValidator)
+
+	uc := usecase.NewCartUseCase(mockValidator, mockCartRepo, mockProductRepo, nil, nil, nil, nil)
+
+	req := &cartDto.AddProductRequest{CartID: "c1", ProductID: "p1", Quantity: 1}
+
+	mockValidator.On("ValidateStruct", req).Return(nil)
+	mockCartRepo.On("GetCartByID", mock.Anything, "c1").Return(&cartEntity.Cart{ID: "c1", UserID: "u1"}, nil)
+	mockProductRepo.On("GetProductById", mock.Anything, "p1").Return(&productEntity.Product{ID: "p1", Price: 4.0}, nil)
+	mockCartRepo.On("GetCartLineByProductIDAndCartID", mock.Anything, "c1", "p1").Return(nil, gorm.ErrRecordNotFound)
+	mockCartRepo.On("CreateCartLine", mock.Anything, mock.MatchedBy(func(cl *cartEntity.CartLine) bool {
+		return cl.CartID == "c1" && cl.Price == 4.0
+	})).Return(nil)
+	mockCartRepo.On("TouchCart", mock.Anything, mock.Anything).Return(nil)
+
+	err := uc.AddProduct(context.Background(), req)
+
+	assert.NoError(t, err)
+	mockCartRepo.AssertCalled(t, "GetCartByID", mock.Anything, "c1")
+	mockCartRepo.AssertNotCalled(t, "GetCartByUserID", mock.Anything, mock.Anything)
+	mockCartRepo.AssertExpectations(t)
+}
+
+// TestUpdateCartLine_CartNotFound verifica que UpdateCartLine devuelve
+// gorm.ErrRecordNotFound cuando el carrito no existe, sin tocar las líneas.
+func TestUpdateCartLine_CartNotFound(t *testing.T) {
+	mockCartRepo := new(MockCartRepository)
+	mockProductRepo := new(mocks.ProductRepository)
+	mockValidator := new(MockValidator)
+
+	uc := usecase.NewCartUseCase(mockValidator, mockCartRepo, mockProductRepo, nil, nil, nil, nil)
+
+	req := &cartDto.UpdateCartLineRequest{CartID: "missing", ProductID: "p1", Quantity: 1}
+
+	mockValidator.On("ValidateStruct", req).Return(nil)
+	mockCartRepo.On("GetCartByID", mock.Anything, "missing").
+		Return((*cartEntity.Cart)(nil), gorm.ErrRecordNotFound)
+
+	err := uc.UpdateCartLine(context.Background(), req)
+
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+	mockCartRepo.AssertNotCalled(t, "GetCartLineByProductIDAndCartID", mock.Anything, mock.Anything, mock.Anything)
+	mockProductRepo.AssertNotCalled(t, "GetProductById", mock.Anything, mock.Anything)
+}
+
+// TestRemoveProduct_GetCartByIDError verifica que RemoveProduct propaga el
+// error del repositorio al buscar el carrito por ID.
+func TestRemoveProduct_GetCartByIDError(t *testing.T) {
+	mockCartRepo := new(MockCartRepository)
+	mockProductRepo := new(mocks.ProductRepository)
+	mockValidator := new(MockValidator)
+
+	uc := usecase.NewCartUseCase(mockValidator, mockCartRepo, mockProductRepo, nil, nil, nil, nil)
+
+	req := &cartDto.RemoveProductRequest{CartID: "c1", ProductID: "p1"}
+	mockCartRepo.On("GetCartByID", mock.Anything, "c1").
+		Return((*cartEntity.Cart)(nil), errors.New("db error"))
+
+	err := uc.RemoveProduct(context.Background(), req)
+
+	assert.EqualError(t, err, "db error")
+	mockCartRepo.AssertNotCalled(t, "RemoveCartLine", mock.Anything, mock.Anything)
+	mockCartRepo.AssertExpectations(t)
+}
+
+// -------------------------------------
+// Tests de GetAbandonedCarts
+// -------------------------------------
+
+// TestGetAbandonedCarts_Found verifica que se consulta el repositorio con el
+// corte (ahora - idleSince) y se devuelven los carritos encontrados.
+func TestGetAbandonedCarts_Found(t *testing.T) {
+	mockCartRepo := new(MockCartRepository)
+	uc := usecase.NewCartUseCase(new(MockValidator), mockCartRepo, new(mocks.ProductRepository), nil, nil, nil, nil)
+
+	idle := 48 * time.Hour
+	expected := []*cartEntity.Cart{{ID: "c1", UserID: "u1"}, {ID: "c2", UserID: "u2"}}
+	before := time.Now()
+	mockCartRepo.On("GetAbandonedCarts", mock.Anything, mock.MatchedBy(func(cutoff time.Time) bool {
+		return !cutoff.After(before.Add(-idle).Add(time.Minute)) && cutoff.After(before.Add(-idle).Add(-time.Minute))
+	})).Return(expected, nil)
+
+	carts, err := uc.GetAbandonedCarts(context.Background(), idle, utils.RoleAdmin)
+
+	assert.NoError(t, err)
+	assert.Equal(t, expected, carts)
+	mockCartRepo.AssertExpectations(t)
+}
+
+// TestGetAbandonedCarts_NoneFound verifica que una lista vacía no es un error.
+func TestGetAbandonedCarts_NoneFound(t *testing.T) {
+	mockCartRepo := new(MockCartRepository)
+	uc := usecase.NewCartUseCase(new(MockValidator), mockCartRepo, new(mocks.ProductRepository), nil, nil, nil, nil)
+
+	mockCartRepo.On("GetAbandonedCarts", mock.Anything, mock.AnythingOfType("time.Time")).Return([]*cartEntity.Cart{}, nil)
+
+	carts, err := uc.GetAbandonedCarts(context.Background(), 2*time.Hour, utils.RoleAdmin)
+
+	assert.NoError(t, err)
+	assert.Empty(t, carts)
+	mockCartRepo.AssertExpectations(t)
+}
+
+// TestGetAbandonedCarts_Forbidden verifica que un usuario sin rol admin es
+// rechazado sin llegar al repositorio.
+func TestGetAbandonedCarts_Forbidden(t *testing.T) {
+	mockCartRepo := new(MockCartRepository)
+	uc := usecase.NewCartUseCase(new(MockValidator), mockCartRepo, new(mocks.ProductRepository), nil, nil, nil, nil)
+
+	carts, err := uc.GetAbandonedCarts(context.Background(), 24*time.Hour, utils.RoleCustomer)
+
+	assert.Nil(t, carts)
+	assert.ErrorIs(t, err, usecase.ErrForbidden)
+	mockCartRepo.AssertNotCalled(t, "GetAbandonedCarts", mock.Anything, mock.Anything)
+}
+
+// TestGetAbandonedCarts_InvalidDuration verifica que una duración menor a una
+// hora se rechaza.
+func TestGetAbandonedCarts_InvalidDuration(t *testing.T) {
+	mockCartRepo := new(MockCartRepository)
+	uc := usecase.NewCartUseCase(new(MockValidator), mockCartRepo, new(mocks.ProductRepository), nil, nil, nil, nil)
+
+	carts, err := uc.GetAbandonedCarts(context.Background(), 30*time.Minute, utils.RoleAdmin)
+
+	assert.Nil(t, carts)
+	assert.ErrorIs(t, err, usecase.ErrInvalidIdleDuration)
+	mockCartRepo.AssertNotCalled(t, "GetAbandonedCarts", mock.Anything, mock.Anything)
+}
+
+// -------------------------------------
+// Tests de GetCartValueByUserID
+// -------------------------------------
+
+// TestGetCartValueByUserID_EmptyCart verifica que un carrito vacío vale 0 sin
+// error.
+func TestGetCartValueByUserID_EmptyCart(t *testing.T) {
+	mockCartRepo := new(MockCartRepository)
+	uc := usecase.NewCartUseCase(new(MockValidator), mockCartRepo, new(mocks.ProductRepository), nil, nil, nil, nil)
+
+	mockCartRepo.On("GetCartIDByUserID", mock.Anything, "u1").Return("c1", nil)
+	mockCartRepo.On("SumCartLinesPrices", mock.Anything, "c1").Return(0.0, nil)
+
+	value, err := uc.GetCartValueByUserID(context.Background(), "u1")
+
+	assert.NoError(t, err)
+	assert.Equal(t, 0.0, value)
+	mockCartRepo.AssertExpectations(t)
+}
+
+// TestGetCartValueByUserID_SingleLine verifica el valor de un carrito con una
+// sola línea.
+func TestGetCartValueByUserID_SingleLine(t *testing.T) {
+	mockCartRepo := new(MockCartRepository)
+	uc := usecase.NewCartUseCase(new(MockValidator), mockCartRepo, new(mocks.ProductRepository), nil, nil, nil, nil)
+
+	mockCartRepo.On("GetCartIDByUserID", mock.Anything, "u1").Return("c1", nil)
+	mockCartRepo.On("SumCartLinesPrices", mock.Anything, "c1").Return(20.0, nil)
+
+	value, err := uc.GetCartValueByUserID(context.Background(), "u1")
+
+	assert.NoError(t, err)
+	assert.Equal(t, 20.0, value)
+}
+
+// TestGetCartValueByUserID_MultipleLines verifica que se devuelve la suma de
+// todas las líneas calculada por el repositorio.
+func TestGetCartValueByUserID_MultipleLines(t *testing.T) {
+	mockCartRepo := new(MockCartRepository)
+	uc := usecase.NewCartUseCase(new(MockValidator), mockCartRepo, new(mocks.ProductRepository), nil, nil, nil, nil)
+
+	mockCartRepo.On("GetCartIDByUserID", mock.Anything, "u1").Return("c1", nil)
+	mockCartRepo.On("SumCartLinesPrices", mock.Anything, "c1").Return(20.0+5.5+3.25, nil)
+
+	value, err := uc.GetCartValueByUserID(context.Background(), "u1")
+
+	assert.NoError(t, err)
+	assert.Equal(t, 28.75, value)
+	mockCartRepo.AssertNotCalled(t, "GetCartByUserID", mock.Anything, mock.Anything)
+}
+
+// TestGetCartValueByUserID_CartNotFound verifica que se propaga el error
+// cuando el usuario no tiene carrito.
+func TestGetCartValueByUserID_CartNotFound(t *testing.T) {
+	mockCartRepo := new(MockCartRepository)
+	uc := usecase.NewCartUseCase(new(MockValidator), mockCartRepo, new(mocks.ProductRepository), nil, nil, nil, nil)
+
+	mockCartRepo.On("GetCartIDByUserID", mock.Anything, "u1").Return("", gorm.ErrRecordNotFound)
+
+	value, err := uc.GetCartValueByUserID(context.Background(), "u1")
+
+	assert.Equal(t, 0.0, value)
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+	mockCartRepo.AssertNotCalled(t, "SumCartLinesPrices", mock.Anything, mock.Anything)
+}
+
+// -------------------------------------
+// Tests de ValidateCartBeforeCheckout
+// -------------------------------------
+
+func validateCart(t *testing.T, lines []*cartEntity.CartLine, products []*productEntity.Product) *cartEntity.ValidationReport {
+	mockCartRepo := new(MockCartRepository)
+	mockProductRepo := new(mocks.ProductRepository)
+	uc := usecase.NewCartUseCase(new(MockValidator), mockCartRepo, mockProductRepo, nil, nil, nil, nil)
+
+	ids := make([]string, 0, len(lines))
+	for _, line := range lines {
+		ids = append(ids, line.ProductID)
+	}
+	mockCartRepo.On("GetCartByUserID", mock.Anything, "u1").Return(&cartEntity.Cart{ID: "c1", UserID: "u1", Lines: lines}, nil)
+	mockProductRepo.On("GetProductsByIDs", mock.Anything, ids).Return(products, nil).Once()
+
+	report, err := uc.ValidateCartBeforeCheckout(context.Background(), "u1")
+	assert.NoError(t, err)
+	mockProductRepo.AssertExpectations(t)
+	return report
+}
+
+// TestValidateCartBeforeCheckout_Valid verifica que un carrito sin problemas
+// es válido y no tiene incidencias.
+func TestValidateCartBeforeCheckout_Valid(t *testing.T) {
+	report := validateCart(t,
+		[]*cartEntity.CartLine{{ProductID: "p1", Quantity: 2, Price: 20}},
+		[]*productEntity.Product{{ID: "p1", Price: 10, Stock: 5, Active: true}},
+	)
+
+	assert.True(t, report.IsValid)
+	assert.Empty(t, report.Issues)
+}
+
+// TestValidateCartBeforeCheckout_OutOfStock verifica la incidencia de stock
+// insuficiente.
+func TestValidateCartBeforeCheckout_OutOfStock(t *testing.T) {
+	report := validateCart(t,
+		[]*cartEntity.CartLine{{ProductID: "p1", Quantity: 3, Price: 30}},
+		[]*productEntity.Product{{ID: "p1", Price: 10, Stock: 2, Active: true}},
+	)
+
+	assert.False(t, report.IsValid)
+	assert.Equal(t, []cartEntity.CartIssue{{ProductID: "p1", IssueType: cartEntity.CartIssueOutOfStock}}, report.Issues)
+}
+
+// TestValidateCartBeforeCheckout_ProductInactive verifica la incidencia de
+// producto desactivado.
+func TestValidateCartBeforeCheckout_ProductInactive(t *testing.T) {
+	report := validateCart(t,
+		[]*cartEntity.CartLine{{ProductID: "p1", Quantity: 1, Price: 10}},
+		[]*productEntity.Product{{ID: "p1", Price: 10, Stock: 5, Active: false}},
+	)
+
+	assert.False(t, report.IsValid)
+	assert.Equal(t, []cartEntity.CartIssue{{ProductID: "p1", IssueType: cartEntity.CartIssueProductInactive}}, report.Issues)
+}
+
+// TestValidateCartBeforeCheckout_PriceChanged verifica la incidencia de precio
+// distinto al guardado en la línea.
+func TestValidateCartBeforeCheckout_PriceChanged(t *testing.T) {
+	report := validateCart(t,
+		[]*cartEntity.CartLine{{ProductID: "p1", Quantity: 2, Price: 20}},
+		[]*productEntity.Product{{ID: "p1", Price: 12, Stock: 5, Active: true}},
+	)
+
+	assert.False(t, report.IsValid)
+	assert.Equal(t, []cartEntity.CartIssue{{ProductID: "p1", IssueType: cartEntity.CartIssuePriceChanged}}, report.Issues)
+}
+
+// TestValidateCartBeforeCheckout_ProductNotFound verifica la incidencia de
+// producto inexistente.
+func TestValidateCartBeforeCheckout_ProductNotFound(t *testing.T) {
+	report := validateCart(t,
+		[]*cartEntity.CartLine{
+			{ProductID: "p1", Quantity: 1, Price: 10},
+			{ProductID: "gone", Quantity: 1, Price: 5},
+		},
+		[]*productEntity.Product{{ID: "p1", Price: 10, Stock: 5, Active: true}},
+	)
+
+	assert.False(t, report.IsValid)
+	assert.Equal(t, []cartEntity.CartIssue{{ProductID: "gone", IssueType: cartEntity.CartIssueProductNotFound}}, report.Issues)
+}
+
+// -------------------------------------
+// Tests de Checkout
+// -------------------------------------
+
+// TestCheckout_EmptyCart verifica que un carrito vacío devuelve ErrEmptyCart.
+func TestCheckout_EmptyCart(t *testing.T) {
+	mockCartRepo := new(MockCartRepository)
+	mockProductRepo := new(mocks.ProductRepository)
+	uc := usecase.NewCartUseCase(new(MockValidator), mockCartRepo, mockProductRepo, nil, nil, nil, nil)
+
+	mockCartRepo.On("GetCartByUserID", mock.Anything, "u1").Return(&cartEntity.Cart{ID: "c1", UserID: "u1"}, nil)
+
+	order, err := uc.Checkout(context.Background(), "u1", "ES", "")
+
+	assert.Nil(t, order)
+	assert.ErrorIs(t, err, usecase.ErrEmptyCart)
+	mockProductRepo.AssertNotCalled(t, "GetProductsByIDs", mock.Anything, mock.Anything)
+}
+
+// TestCheckout_ProductErrors verifica que el stock insuficiente y los
+// productos desactivados o inexistentes impiden el checkout.
+func TestCheckout_ProductErrors(t *testing.T) {
+	cases := []struct {
+		name     string
+		products []*productEntity.Product
+		err      error
+	}{
+		{"insufficient stock", []*productEntity.Product{{ID: "p1", Price: 10, Stock: 1, Active: true}}, usecase.ErrInsufficientStock},
+		{"inactive", []*productEntity.Product{{ID: "p1", Price: 10, Stock: 5, Active: false}}, usecase.ErrProductInactive},
+		{"not found", []*productEntity.Product{}, usecase.ErrProductInactive},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockCartRepo := new(MockCartRepository)
+			mockProductRepo := new(mocks.ProductRepository)
+			uc := usecase.NewCartUseCase(new(MockValidator), mockCartRepo, mockProductRepo, nil, nil, nil, nil)
+
+			lines := []*cartEntity.CartLine{{ID: "l1", ProductID: "p1", Quantity: 2, Price: 20}}
+			mockCartRepo.On("GetCartByUserID", mock.Anything, "u1").Return(&cartEntity.Cart{ID: "c1", UserID: "u1", Lines: lines}, nil)
+			mockProductRepo.On("GetProductsByIDs", mock.Anything, []string{"p1"}).Return(tc.products, nil)
+
+			order, err := uc.Checkout(context.Background(), "u1", "ES", "")
+
+			assert.Nil(t, order)
+			assert.ErrorIs(t, err, tc.err)
+			mockCartRepo.AssertNotCalled(t, "RemoveCartLine", mock.Anything, mock.Anything)
+		})
+	}
+}
+
+// -------------------------------------
+// Tests de ApplyGiftCard
+// -------------------------------------
+
+func giftCardCart() *cartEntity.Cart {
+	return &cartEntity.Cart{
+		ID:     "c1",
+		UserID: "u1",
+		Lines: []*cartEntity.CartLine{
+			{ProductID: "p1", Quantity: 2, Price: 60},
+			{ProductID: "p2", Quantity: 1, Price: 40},
+		},
+	}
+}
+
+// TestApplyGiftCard_PartialBalance verifica que con saldo mayor al total solo
+// se descuenta el total del carrito y el resto queda en la tarjeta.
+func TestApplyGiftCard_PartialBalance(t *testing.T) {
+	mockCartRepo := new(MockCartRepository)
+	mockGiftCardRepo := new(MockGiftCardRepository)
+	uc := usecase.NewCartUseCase(new(MockValidator), mockCartRepo, new(mocks.ProductRepository), nil, mockGiftCardRepo, nil, nil)
+
+	card := &cartEntity.GiftCard{ID: "g1", Code: "GIFT", Balance: 150, ExpiresAt: time.Now().Add(24 * time.Hour)}
+	cart := giftCardCart()
+	mockGiftCardRepo.On("GetGiftCardByCode", mock.Anything, "GIFT").Return(card, nil)
+	mockCartRepo.On("GetCartByID", mock.Anything, "c1").Return(cart, nil)
+	mockGiftCardRepo.On("ApplyGiftCard", mock.Anything, cart).Return(nil)
+
+	err := uc.ApplyGiftCard(context.Background(), "c1", "u1", "GIFT")
+
+	assert.NoError(t, err)
+	assert.Equal(t, 100.0, cart.GiftCardAmount)
+	assert.Equal(t, "g1", *cart.GiftCardID)
+	mockGiftCardRepo.AssertExpectations(t)
+}
+
+// TestApplyGiftCard_FullBalance verifica que el saldo completo se consume
+// cuando no supera el total del carrito.
+func TestApplyGiftCard_FullBalance(t *testing.T) {
+	mockCartRepo := new(MockCartRepository)
+	mockGiftCardRepo := new(MockGiftCardRepository)
+	uc := usecase.NewCartUseCase(new(MockValidator), mockCartRepo, new(mocks.ProductRepository), nil, mockGiftCardRepo, nil, nil)
+
+	card := &cartEntity.GiftCard{ID: "g1", Code: "GIFT", Balance: 100, ExpiresAt: time.Now().Add(24 * time.Hour)}
+	cart := giftCardCart()
+	mockGiftCardRepo.On("GetGiftCardByCode", mock.Anything, "GIFT").Return(card, nil)
+	mockCartRepo.On("GetCartByID", mock.Anything, "c1").Return(cart, nil)
+	mockGiftCardRepo.On("ApplyGiftCard", mock.Anything, cart).Return(nil)
+
+	err := uc.ApplyGiftCard(context.Background(), "c1", "u1", "GIFT")
+
+	assert.NoError(t, err)
+	assert.Equal(t, 100.0, cart.GiftCardAmount)
+}
+
+// TestApplyGiftCard_Rejected verifica que las tarjetas caducadas, sin saldo o
+// inexistentes no se aplican.
+func TestApplyGiftCard_Rejected(t *testing.T) {
+	cases := []struct {
+		name string
+		card *cartEntity.GiftCard
+		err  error
+		want error
+	}{
+		{"expired", &cartEntity.GiftCard{ID: "g1", Balance: 50, ExpiresAt: time.Now().Add(-time.Hour)}, nil, usecase.ErrGiftCardExpired},
+		{"zero balance", &cartEntity.GiftCard{ID: "g1", Balance: 0, ExpiresAt: time.Now().Add(time.Hour)}, nil, usecase.ErrGiftCardEmpty},
+		{"not found", nil, gorm.ErrRecordNotFound, usecase.ErrGiftCardNotFound},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockCartRepo := new(MockCartRepository)
+			mockGiftCardRepo := new(MockGiftCardRepository)
+			uc := usecase.NewCartUseCase(new(MockValidator), mockCartRepo, new(mocks.ProductRepository), nil, mockGiftCardRepo, nil, nil)
+
+			mockCartRepo.On("GetCartByID", mock.Anything, "c1").Return(giftCardCart(), nil)
+			mockGiftCardRepo.On("GetGiftCardByCode", mock.Anything, "GIFT").Return(tc.card, tc.err)
+
+			err := uc.ApplyGiftCard(context.Background(), "c1", "u1", "GIFT")
+
+			assert.ErrorIs(t, err, tc.want)
+			mockGiftCardRepo.AssertNotCalled(t, "ApplyGiftCard", mock.Anything, mock.Anything)
+		})
+	}
+}
+
+// TestApplyGiftCard_CartRejected verifica que no se aplica una tarjeta a un
+// carrito ajeno ni a uno que ya tiene otra tarjeta, y que en ambos casos no se
+// consulta la tarjeta.
+func TestApplyGiftCard_CartRejected(t *testing.T) {
+	otherCardID := "g0"
+	cases := []struct {
+		name   string
+		userID string
+		cart   func() *cartEntity.Cart
+		want   error
+	}{
+		{"not owned", "intruder", giftCardCart, usecase.ErrCartNotOwned},
+		{"already applied", "u1", func() *cartEntity.Cart {
+			cart := giftCardCart()
+			cart.GiftCardID = &otherCardID
+			cart.GiftCardAmount = 30
+			return cart
+		}, usecase.ErrGiftCardAlreadyApplied},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockCartRepo := new(MockCartRepository)
+			mockGiftCardRepo := new(MockGiftCardRepository)
+			uc := usecase.NewCartUseCase(new(MockValidator), mockCartRepo, new(mocks.ProductRepository), nil, mockGiftCardRepo, nil, nil)
+
+			mockCartRepo.On("GetCartByID", mock.Anything, "c1").Return(tc.cart(), nil)
+
+			err := uc.ApplyGiftCard(context.Background(), "c1", tc.userID, "GIFT")
+
+			assert.ErrorIs(t, err, tc.want)
+			mockGiftCardRepo.AssertNotCalled(t, "GetGiftCardByCode", mock.Anything, mock.Anything)
+			mockGiftCardRepo.AssertNotCalled(t, "ApplyGiftCard", mock.Anything, mock.Anything)
+		})
+	}
+}
+
+// TestApplyGiftCard_ConcurrentApply verifica que si otra petición aplicó una
+// tarjeta entre la lectura y la escritura se devuelve
+// ErrGiftCardAlreadyApplied.
+func TestApplyGiftCard_ConcurrentApply(t *testing.T) {
+	mockCartRepo := new(MockCartRepository)
+	mockGiftCardRepo := new(MockGiftCardRepository)
+	uc := usecase.NewCartUseCase(new(MockValidator), mockCartRepo, new(mocks.ProductRepository), nil, mockGiftCardRepo, nil, nil)
+
+	card := &cartEntity.GiftCard{ID: "g1", Code: "GIFT", Balance: 50, ExpiresAt: time.Now().Add(time.Hour)}
+	mockCartRepo.On("GetCartByID", mock.Anything, "c1").Return(giftCardCart(), nil)
+	mockGiftCardRepo.On("GetGiftCardByCode", mock.Anything, "GIFT").Return(card, nil)
+	mockGiftCardRepo.On("ApplyGiftCard", mock.Anything, mock.Anything).Return(gorm.ErrRecordNotFound)
+
+	err := uc.ApplyGiftCard(context.Background(), "c1", "u1", "GIFT")
+
+	assert.ErrorIs(t, err, usecase.ErrGiftCardAlreadyApplied)
+}
+
+// TestApplyGiftCard_ConcurrentSpend verifica que si otro carrito gastó el saldo
+// de la tarjeta entre la lectura y la escritura se devuelve ErrGiftCardEmpty.
+func TestApplyGiftCard_ConcurrentSpend(t *testing.T) {
+	mockCartRepo := new(MockCartRepository)
+	mockGiftCardRepo := new(MockGiftCardRepository)
+	uc := usecase.NewCartUseCase(new(MockValidator), mockCartRepo, new(mocks.ProductRepository), nil, mockGiftCardRepo, nil, nil)
+
+	card := &cartEntity.GiftCard{ID: "g1", Code: "GIFT", Balance: 50, ExpiresAt: time.Now().Add(time.Hour)}
+	mockCartRepo.On("GetCartByID", mock.Anything, "c1").Return(giftCardCart(), nil)
+	mockGiftCardRepo.On("GetGiftCardByCode", mock.Anything, "GIFT").Return(card, nil)
+	mockGiftCardRepo.On("ApplyGiftCard", mock.Anything, mock.Anything).Return(cartRepo.ErrGiftCardBalanceTooLow)
+
+	err := uc.ApplyGiftCard(context.Background(), "c1", "u1", "GIFT")
+
+	assert.ErrorIs(t, err, usecase.ErrGiftCardEmpty)
+}
+
+// TestApplyGiftCard_EmptyCart verifica que un carrito vacío no puede tomar una
+// tarjeta regalo.
+func TestApplyGiftCard_EmptyCart(t *testing.T) {
+	mockCartRepo := new(MockCartRepository)
+	mockGiftCardRepo := new(MockGiftCardRepository)
+	uc := usecase.NewCartUseCase(new(MockValidator), mockCartRepo, new(mocks.ProductRepository), nil, mockGiftCardRepo, nil, nil)
+
+	card := &cartEntity.GiftCard{ID: "g1", Code: "GIFT", Balance: 50, ExpiresAt: time.Now().Add(time.Hour)}
+	mockCartRepo.On("GetCartByID", mock.Anything, "c1").Return(&cartEntity.Cart{ID: "c1", UserID: "u1"}, nil)
+	mockGiftCardRepo.On("GetGiftCardByCode", mock.Anything, "GIFT").Return(card, nil)
+
+	err := uc.ApplyGiftCard(context.Background(), "c1", "u1", "GIFT")
+
+	assert.ErrorIs(t, err, usecase.ErrEmptyCart)
+	mockGiftCardRepo.AssertNotCalled(t, "ApplyGiftCard", mock.Anything, mock.Anything)
+}
+
+// TestRemoveProduct_ReleasesGiftCardExcess verifica que al quitar líneas el
+// importe de la tarjeta regalo que supera el nuevo subtotal vuelve a la
+// tarjeta.
+func TestRemoveProduct_ReleasesGiftCardExcess(t *testing.T) {
+	mockCartRepo := new(MockCartRepository)
+	mockGiftCardRepo := new(MockGiftCardRepository)
+	uc := usecase.NewCartUseCase(new(MockValidator), mockCartRepo, new(mocks.ProductRepository), nil, mockGiftCardRepo, nil, nil)
+
+	giftCardID := "g1"
+	cart := giftCardCart()
+	cart.GiftCardID = &giftCardID
+	cart.GiftCardAmount = 100
+	line := cart.Lines[0]
+	mockCartRepo.On("GetCartByID", mock.Anything, "c1").Return(cart, nil)
+	mockCartRepo.On("GetCartLineByProductIDAndCartID", mock.Anything, "c1", "p1").Return(line, nil)
+	mockCartRepo.On("RemoveCartLine", mock.Anything, line).Return(nil)
+	mockCartRepo.On("SumCartLinesPrices", mock.Anything, "c1").Return(40.0, nil)
+	mockGiftCardRepo.On("ReleaseGiftCard", mock.Anything, "g1", 60.0).Return(nil)
+	mockCartRepo.On("UpdateCartGiftCard", mock.Anything, mock.MatchedBy(func(c *cartEntity.Cart) bool {
+		return c.GiftCardAmount == 40 && *c.GiftCardID == "g1"
+	})).Return(nil)
+	mockCartRepo.On("TouchCart", mock.Anything, cart).Return(nil)
+
+	err := uc.RemoveProduct(context.Background(), &cartDto.RemoveProductRequest{CartID: "c1", ProductID: "p1"})
+
+	assert.NoError(t, err)
+	mockGiftCardRepo.AssertExpectations(t)
+	mockCartRepo.AssertExpectations(t)
+}
+
+// TestCheckout_InvalidCart verifica que un carrito que no cumple sus
+// invariantes no llega a consultar productos.
+func TestCheckout_InvalidCart(t *testing.T) {
+	mockCartRepo := new(MockCartRepository)
+	mockProductRepo := new(mocks.ProductRepository)
+	uc := usecase.NewCartUseCase(new(MockValidator), mockCartRepo, mockProductRepo, nil, nil, nil, nil)
+
+	lines := []*cartEntity.CartLine{
+		{ProductID: "p1", Quantity: 1, Price: 10},
+		{ProductID: "p1", Quantity: 2, Price: 20},
+	}
+	mockCartRepo.On("GetCartByUserID", mock.Anything, "u1").Return(&cartEntity.Cart{ID: "c1", UserID: "u1", Lines: lines}, nil)
+
+	order, err := uc.Checkout(context.Background(), "u1", "ES", "")
+
+	assert.Nil(t, order)
+	assert.ErrorIs(t, err, cartEntity.ErrDuplicateCartProduct)
+	mockProductRepo.AssertNotCalled(t, "GetProductsByIDs", mock.Anything, mock.Anything)
+}
+
+// -------------------------------------
+// Tests de GetCartLineByID
+// -------------------------------------
+
+// TestGetCartLineByID_Own verifica que se devuelve la línea de un carrito del usuario.
+func TestGetCartLineByID_Own(t *testing.T) {
+	mockCartRepo := new(MockCartRepository)
+	uc := usecase.NewCartUseCase(new(MockValidator), mockCartRepo, new(mocks.ProductRepository), nil, nil, nil, nil)
+
+	line := &cartEntity.CartLine{ID: "l1", CartID: "c1", ProductID: "p1"}
+	mockCartRepo.On("GetCartLineByID", mock.Anything, "l1").Return(line, nil)
+	mockCartRepo.On("GetCartByID", mock.Anything, "c1").Return(&cartEntity.Cart{ID: "c1", UserID: "u1"}, nil)
+
+	result, err := uc.GetCartLineByID(context.Background(), "l1", "u1")
+
+	assert.NoError(t, err)
+	assert.Equal(t, line, result)
+}
+
+// TestGetCartLineByID_Foreign verifica que la línea de otro usuario devuelve
+// ErrLineNotOwned.
+func TestGetCartLineByID_Foreign(t *testing.T) {
+	mockCartRepo := new(MockCartRepository)
+	uc := usecase.NewCartUseCase(new(MockValidator), mockCartRepo, new(mocks.ProductRepository), nil, nil, nil, nil)
+
+	mockCartRepo.On("GetCartLineByID", mock.Anything, "l1").Return(&cartEntity.CartLine{ID: "l1", CartID: "c2"}, nil)
+	mockCartRepo.On("GetCartByID", mock.Anything, "c2").Return(&cartEntity.Cart{ID: "c2", UserID: "u2"}, nil)
+
+	result, err := uc.GetCartLineByID(context.Background(), "l1", "u1")
+
+	assert.Nil(t, result)
+	assert.ErrorIs(t, err, usecase.ErrLineNotOwned)
+}
+
+// TestGetCartLineByID_NotFound verifica que una línea inexistente devuelve el
+// error del repositorio.
+func TestGetCartLineByID_NotFound(t *testing.T) {
+	mockCartRepo := new(MockCartRepository)
+	uc := usecase.NewCartUseCase(new(MockValidator), mockCartRepo, new(mocks.ProductRepository), nil, nil, nil, nil)
+
+	mockCartRepo.On("GetCartLineByID", mock.Anything, "missing").Return(nil, gorm.ErrRecordNotFound)
+
+	result, err := uc.GetCartLineByID(context.Background(), "missing", "u1")
+
+	assert.Nil(t, result)
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+	mockCartRepo.AssertNotCalled(t, "GetCartByID", mock.Anything, mock.Anything)
+}
+
+// TestGetCartLineByID_RepoError verifica que un fallo al leer el carrito se propaga.
+func TestGetCartLineByID_RepoError(t *testing.T) {
+	mockCartRepo := new(MockCartRepository)
+	uc := usecase.NewCartUseCase(new(MockValidator), mockCartRepo, new(mocks.ProductRepository), nil, nil, nil, nil)
+
+	dbErr := errors.New("db down")
+	mockCartRepo.On("GetCartLineByID", mock.Anything, "l1").Return(&cartEntity.CartLine{ID: "l1", CartID: "c1"}, nil)
+	mockCartRepo.On("GetCartByID", mock.Anything, "c1").Return(nil, dbErr)
+
+	result, err := uc.GetCartLineByID(context.Background(), "l1", "u1")
+
+	assert.Nil(t, result)
+	assert.ErrorIs(t, err, dbErr)
+}
+
+// -------------------------------------
+// Tests de GetCrossSellSuggestions
+// -------------------------------------
+
+func productIDs(products []*productEntity.Product) []string {
+	ids := make([]string, 0, len(products))
+	for _, product := range products {
+		ids = append(ids, product.ID)
+	}
+	return ids
+}
+
+// TestGetCrossSellSuggestions_SingleItem verifica que con un único producto en
+// el carrito se devuelven sus productos comprados juntos.
+func TestGetCrossSellSuggestions_SingleItem(t *testing.T) {
+	mockCartRepo := new(MockCartRepository)
+	mockProductRepo := new(mocks.ProductRepository)
+	uc := usecase.NewCartUseCase(new(MockValidator), mockCartRepo, mockProductRepo, nil, nil, nil, nil)
+
+	mockCartRepo.On("GetCartByUserID", mock.Anything, "u1").Return(&cartEntity.Cart{
+		Lines: []*cartEntity.CartLine{{ProductID: "p1"}},
+	}, nil)
+	mockProductRepo.On("GetFrequentlyBoughtTogether", mock.Anything, "p1", 6).Return([]*productEntity.Product{
+		{ID: "p2"}, {ID: "p3"},
+	}, nil)
+
+	result, err := uc.GetCrossSellSuggestions(context.Background(), "u1", 5)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"p2", "p3"}, productIDs(result))
+}
+
+// TestGetCrossSellSuggestions_MultipleItems verifica que las sugerencias de
+// varios productos se combinan sin duplicados y sin incluir lo que ya está en
+// el carrito.
+func TestGetCrossSellSuggestions_MultipleItems(t *testing.T) {
+	mockCartRepo := new(MockCartRepository)
+	mockProductRepo := new(mocks.ProductRepository)
+	uc := usecase.NewCartUseCase(new(MockValidator), mockCartRepo, mockProductRepo, nil, nil, nil, nil)
+
+	mockCartRepo.On("GetCartByUserID", mock.Anything, "u1").Return(&cartEntity.Cart{
+		Lines: []*cartEntity.CartLine{{ProductID: "p1"}, {ProductID: "p2"}},
+	}, nil)
+	mockProductRepo.On("GetFrequentlyBoughtTogether", mock.Anything, "p1", 7).Return([]*productEntity.Product{
+		{ID: "p2"}, {ID: "p3"}, {ID: "p4"},
+	}, nil)
+	mockProductRepo.On("GetFrequentlyBoughtTogether", mock.Anything, "p2", 7).Return([]*productEntity.Product{
+		{ID: "p1"}, {ID: "p4"}, {ID: "p5"},
+	}, nil)
+
+	result, err := uc.GetCrossSellSuggestions(context.Background(), "u1", 5)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"p3", "p4", "p5"}, productIDs(result))
+}
+
+// TestGetCrossSellSuggestions_EmptyCart verifica que un carrito vacío devuelve
+// una lista vacía sin consultar productos.
+func TestGetCrossSellSuggestions_EmptyCart(t *testing.T) {
+	mockCartRepo := new(MockCartRepository)
+	mockProductRepo := new(mocks.ProductRepository)
+	uc := usecase.NewCartUseCase(new(MockValidator), mockCartRepo, mockProductRepo, nil, nil, nil, nil)
+
+	mockCartRepo.On("GetCartByUserID", mock.Anything, "u1").Return(&cartEntity.Cart{}, nil)
+
+	result, err := uc.GetCrossSellSuggestions(context.Background(), "u1", 5)
+
+	assert.NoError(t, err)
+	assert.NotNil(t, result)
+	assert.Empty(t, result)
+	mockProductRepo.AssertNotCalled(t, "GetFrequentlyBoughtTogether", mock.Anything, mock.Anything, mock.Anything)
+}
+
+// TestGetCrossSellSuggestions_Limit verifica que nunca se devuelven más de
+// limit sugerencias y que un límite fuera de rango se rechaza.
+func TestGetCrossSellSuggestions_Limit(t *testing.T) {
+	mockCartRepo := new(MockCartRepository)
+	mockProductRepo := new(mocks.ProductRepository)
+	uc := usecase.NewCartUseCase(new(MockValidator), mockCartRepo, mockProductRepo, nil, nil, nil, nil)
+
+	mockCartRepo.On("GetCartByUserID", mock.Anything, "u1").Return(&cartEntity.Cart{
+		Lines: []*cartEntity.CartLine{{ProductID: "p1"}, {ProductID: "p9"}},
+	}, nil)
+	mockProductRepo.On("GetFrequentlyBoughtTogether", mock.Anything, "p1", 4).Return([]*productEntity.Product{
+		{ID: "p2"}, {ID: "p3"}, {ID: "p4"},
+	}, nil)
+
+	result, err := uc.GetCrossSellSuggestions(context.Background(), "u1", 2)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"p2", "p3"}, productIDs(result))
+	mockProductRepo.AssertNotCalled(t, "GetFrequentlyBoughtTogether", mock.Anything, "p9", mock.Anything)
+
+	for _, limit := range []int{0, 21} {
+		_, err := uc.GetCrossSellSuggestions(context.Background(), "u1", limit)
+		assert.ErrorIs(t, err, usecase.ErrInvalidSuggestionLimit)
+	}
+}
+
+// -------------------------------------
+// Tests de ComputeCartCheckoutSummary
+// -------------------------------------
+
+// summaryCart devuelve un carrito de 3 unidades (2.5 kg, subtotal 30) con un
+// descuento y 10 de tarjeta regalo aplicados.
+func summaryCart() *cartEntity.Cart {
+	discountID := "d1"
+	return &cartEntity.Cart{
+		ID:             "c1",
+		UserID:         "u1",
+		DiscountID:     &discountID,
+		GiftCardAmount: 10,
+		Lines: []*cartEntity.CartLine{
+			{ProductID: "p1", Quantity: 2, Price: 20, Product: &productEntity.Product{ID: "p1", Weight: 1}},
+			{ProductID: "p2", Quantity: 1, Price: 10, Product: &productEntity.Product{ID: "p2", Weight: 0.5}},
+		},
+	}
+}
+
+// TestComputeCartCheckoutSummary_Totals verifica la suma completa: el impuesto
+// se aplica tras el descuento y la tarjeta regalo se descuenta al final.
+func TestComputeCartCheckoutSummary_Totals(t *testing.T) {
+	mockCartRepo := new(MockCartRepository)
+	mockOrderRepo := new(MockOrderRepository)
+	mockShipping := new(MockShippingCalculator)
+	mockTax := new(MockTaxProvider)
+	uc := usecase.NewCartUseCase(new(MockValidator), mockCartRepo, new(mocks.ProductRepository), mockOrderRepo, nil, mockShipping, mockTax)
+
+	mockCartRepo.On("GetCartByUserID", mock.Anything, "u1").Return(summaryCart(), nil)
+	mockShipping.On("Calculate", mock.Anything, 2.5, mock.MatchedBy(func(a addressEntity.Address) bool {
+		return a.Country == "ES"
+	})).Return(&orderEntity.ShippingQuote{Cost: 7.5, EstimatedDays: 3}, nil)
+	mockTax.On("GetTaxRate", mock.Anything, "ES").Return(0.1, nil)
+	mockOrderRepo.On("GetDiscount", mock.Anything, "d1").Return(&discountEntity.Discount{ID: "d1", Amount: 5}, nil)
+
+	summary, err := uc.ComputeCartCheckoutSummary(context.Background(), "u1", " es ")
+
+	assert.NoError(t, err)
+	assert.Equal(t, 30.0, summary.Subtotal)
+	assert.Equal(t, 3, summary.ItemCount)
+	assert.Equal(t, 5.0, summary.DiscountAmount)
+	assert.Equal(t, 2.5, summary.TaxAmount)
+	assert.Equal(t, 7.5, summary.ShippingCost)
+	assert.Equal(t, 10.0, summary.GiftCardAmount)
+	assert.Equal(t, 25.0, summary.GrandTotal)
+	assert.WithinDuration(t, time.Now().AddDate(0, 0, 3), summary.EstimatedDelivery, time.Minute)
+}
+
+// TestComputeCartCheckoutSummary_GiftCardCapped verifica que la tarjeta regalo
+// nunca deja el total en negativo.
+func TestComputeCartCheckoutSummary_GiftCardCapped(t *testing.T) {
+	mockCartRepo := new(MockCartRepository)
+	mockShipping := new(MockShippingCalculator)
+	mockTax := new(MockTaxProvider)
+	uc := usecase.NewCartUseCase(new(MockValidator), mockCartRepo, new(mocks.ProductRepository), nil, nil, mockShipping, mockTax)
+
+	cart := summaryCart()
+	cart.DiscountID = nil
+	cart.GiftCardAmount = 100
+	mockCartRepo.On("GetCartByUserID", mock.Anything, "u1").Return(cart, nil)
+	mockShipping.On("Calculate", mock.Anything, 2.5, mock.Anything).Return(&orderEntity.ShippingQuote{Cost: 5}, nil)
+	mockTax.On("GetTaxRate", mock.Anything, "ES").Return(0.1, nil)
+
+	summary, err := uc.ComputeCartCheckoutSummary(context.Background(), "u1", "ES")
+
+	assert.NoError(t, err)
+	assert.Equal(t, 38.0, summary.GiftCardAmount)
+	assert.Equal(t, 0.0, summary.GrandTotal)
+}
+
+// TestComputeCartCheckoutSummary_ShippingUnavailable verifica que si el
+// cálculo de envío falla el coste de envío es 0 y no se devuelve error.
+func TestComputeCartCheckoutSummary_ShippingUnavailable(t *testing.T) {
+	mockCartRepo := new(MockCartRepository)
+	mockOrderRepo := new(MockOrderRepository)
+	mockShipping := new(MockShippingCalculator)
+	mockTax := new(MockTaxProvider)
+	uc := usecase.NewCartUseCase(new(MockValidator), mockCartRepo, new(mocks.ProductRepository), mockOrderRepo, nil, mockShipping, mockTax)
+
+	mockCartRepo.On("GetCartByUserID", mock.Anything, "u1").Return(summaryCart(), nil)
+	mockShipping.On("Calculate", mock.Anything, mock.Anything, mock.Anything).Return(nil, errors.New("carrier down"))
+	mockTax.On("GetTaxRate", mock.Anything, "ES").Return(0.1, nil)
+	mockOrderRepo.On("GetDiscount", mock.Anything, "d1").Return(&discountEntity.Discount{ID: "d1", Amount: 5}, nil)
+
+	summary, err := uc.ComputeCartCheckoutSummary(context.Background(), "u1", "ES")
+
+	assert.NoError(t, err)
+	assert.Equal(t, 0.0, summary.ShippingCost)
+	assert.True(t, summary.EstimatedDelivery.IsZero())
+	assert.Equal(t, 17.5, summary.GrandTotal)
+}
+
+// TestComputeCartCheckoutSummary_DiscountUnavailable verifica que si no se
+// puede leer el descuento se calcula sin él.
+func TestComputeCartCheckoutSummary_DiscountUnavailable(t *testing.T) {
+	mockCartRepo := new(MockCartRepository)
+	mockOrderRepo := new(MockOrderRepository)
+	mockShipping := new(MockShippingCalculator)
+	mockTax := new(MockTaxProvider)
+	uc := usecase.NewCartUseCase(new(MockValidator), mockCartRepo, new(mocks.ProductRepository), mockOrderRepo, nil, mockShipping, mockTax)
+
+	mockCartRepo.On("GetCartByUserID", mock.Anything, "u1").Return(summaryCart(), nil)
+	mockShipping.On("Calculate", mock.Anything, 2.5, mock.Anything).Return(&orderEntity.ShippingQuote{Cost: 0}, nil)
+	mockTax.On("GetTaxRate", mock.Anything, "ES").Return(0.1, nil)
+	mockOrderRepo.On("GetDiscount", mock.Anything, "d1").Return(nil, errors.New("db error"))
+
+	summary, err := uc.ComputeCartCheckoutSummary(context.Background(), "u1", "ES")
+
+	assert.NoError(t, err)
+	assert.Equal(t, 0.0, summary.DiscountAmount)
+	assert.Equal(t, 3.0, summary.TaxAmount)
+	assert.Equal(t, 23.0, summary.GrandTotal)
+}
+
+// TestComputeCartCheckoutSummary_TaxUnavailable verifica que sin impuesto no se
+// devuelve un resumen, porque el total no sería fiable.
+func TestComputeCartCheckoutSummary_TaxUnavailable(t *testing.T) {
+	mockCartRepo := new(MockCartRepository)
+	mockOrderRepo := new(MockOrderRepository)
+	mockShipping := new(MockShippingCalculator)
+	mockTax := new(MockTaxProvider)
+	uc := usecase.NewCartUseCase(new(MockValidator), mockCartRepo, new(mocks.ProductRepository), mockOrderRepo, nil, mockShipping, mockTax)
+
+	taxErr := errors.New("tax service down")
+	mockCartRepo.On("GetCartByUserID", mock.Anything, "u1").Return(summaryCart(), nil)
+	mockShipping.On("Calculate", mock.Anything, mock.Anything, mock.Anything).Return(&orderEntity.ShippingQuote{Cost: 5}, nil)
+	mockTax.On("GetTaxRate", mock.Anything, "ES").Return(0.0, taxErr)
+	mockOrderRepo.On("GetDiscount", mock.Anything, "d1").Return(&discountEntity.Discount{ID: "d1", Amount: 5}, nil)
+
+	summary, err := uc.ComputeCartCheckoutSummary(context.Background(), "u1", "ES")
+
+	assert.Nil(t, summary)
+	assert.ErrorIs(t, err, taxErr)
+}
+
+// TestComputeCartCheckoutSummary_EmptyCart verifica que un carrito vacío
+// devuelve ErrEmptyCart.
+func TestComputeCartCheckoutSummary_EmptyCart(t *testing.T) {
+	mockCartRepo := new(MockCartRepository)
+	uc := usecase.NewCartUseCase(new(MockValidator), mockCartRepo, new(mocks.ProductRepository), nil, nil, nil, nil)
+
+	mockCartRepo.On("GetCartByUserID", mock.Anything, "u1").Return(&cartEntity.Cart{UserID: "u1"}, nil)
+
+	summary, err := uc.ComputeCartCheckoutSummary(context.Background(), "u1", "ES")
+
+	assert.Nil(t, summary)
+	assert.ErrorIs(t, err, usecase.ErrEmptyCart)
+}
+
+// TestComputeCartCheckoutSummary_UnsupportedCountry verifica que un país sin
+// impuesto conocido se rechaza sin leer el carrito.
+func TestComputeCartCheckoutSummary_UnsupportedCountry(t *testing.T) {
+	mockCartRepo := new(MockCartRepository)
+	mockTax := new(MockTaxProvider)
+	uc := usecase.NewCartUseCase(new(MockValidator), mockCartRepo, new(mocks.ProductRepository), nil, nil, nil, mockTax)
+
+	for _, country := range []string{"", "XX"} {
+		summary, err := uc.ComputeCartCheckoutSummary(context.Background(), "u1", country)
+		assert.Nil(t, summary)
+		assert.ErrorIs(t, err, usecase.ErrUnsupportedCountry)
+	}
+	mockCartRepo.AssertNotCalled(t, "GetCartByUserID", mock.Anything, mock.Anything)
+	mockTax.AssertNotCalled(t, "GetTaxRate", mock.Anything, mock.Anything)
+}
+
+// -------------------------------------
+// Tests de GetCartLinePriceChange
+// -------------------------------------
+
+func cartPriceChanges(t *testing.T, lines []*cartEntity.CartLine, products []*productEntity.Product) []*cartEntity.PriceChangedLine {
+	mockCartRepo := new(MockCartRepository)
+	mockProductRepo := new(mocks.ProductRepository)
+	uc := usecase.NewCartUseCase(new(MockValidator), mockCartRepo, mockProductRepo, nil, nil, nil, nil)
+
+	ids := make([]string, 0, len(lines))
+	for _, line := range lines {
+		ids = append(ids, line.ProductID)
+	}
+	mockCartRepo.On("GetCartByUserID", mock.Anything, "u1").Return(&cartEntity.Cart{ID: "c1", UserID: "u1", Lines: lines}, nil)
+	mockProductRepo.On("GetProductsByIDs", mock.Anything, ids).Return(products, nil).Once()
+
+	changed, err := uc.GetCartLinePriceChange(context.Background(), "u1")
+	assert.NoError(t, err)
+	return changed
+}
+
+// TestGetCartLinePriceChange_NoChanges verifica que si los precios no cambian
+// el resultado es vacío.
+func TestGetCartLinePriceChange_NoChanges(t *testing.T) {
+	changed := cartPriceChanges(t,
+		[]*cartEntity.CartLine{
+			{ProductID: "p1", Quantity: 2, Price: 20},
+			{ProductID: "p2", Quantity: 3, Price: 4.5},
+		},
+		[]*productEntity.Product{
+			{ID: "p1", Price: 10, Active: true},
+			{ID: "p2", Price: 1.5, Active: true},
+		},
+	)
+
+	assert.NotNil(t, changed)
+	assert.Empty(t, changed)
+}
+
+// TestGetCartLinePriceChange_Increase verifica la subida de precio unitario y
+// su porcentaje.
+func TestGetCartLinePriceChange_Increase(t *testing.T) {
+	changed := cartPriceChanges(t,
+		[]*cartEntity.CartLine{
+			{ProductID: "p1", Quantity: 2, Price: 20},
+			{ProductID: "p2", Quantity: 1, Price: 5},
+		},
+		[]*productEntity.Product{
+			{ID: "p1", Price: 12.5, Active: true},
+			{ID: "p2", Price: 5, Active: true},
+		},
+	)
+
+	assert.Len(t, changed, 1)
+	assert.Equal(t, "p1", changed[0].ProductID)
+	assert.Equal(t, 10.0, changed[0].OldPrice)
+	assert.Equal(t, 12.5, changed[0].NewPrice)
+	assert.Equal(t, 25.0, changed[0].PercentChange)
+}
+
+// TestGetCartLinePriceChange_Decrease verifica la bajada de precio unitario con
+// porcentaje negativo.
+func TestGetCartLinePriceChange_Decrease(t *testing.T) {
+	changed := cartPriceChanges(t,
+		[]*cartEntity.CartLine{{ProductID: "p1", Quantity: 4, Price: 40}},
+		[]*productEntity.Product{{ID: "p1", Price: 7, Active: true}},
+	)
+
+	assert.Len(t, changed, 1)
+	assert.Equal(t, 10.0, changed[0].OldPrice)
+	assert.Equal(t, 7.0, changed[0].NewPrice)
+	assert.Equal(t, -30.0, changed[0].PercentChange)
+}
+
+// TestGetCartLinePriceChange_InactiveProduct verifica que un producto inactivo
+// se reporta como cambio a precio 0.
+func TestGetCartLinePriceChange_InactiveProduct(t *testing.T) {
+	changed := cartPriceChanges(t,
+		[]*cartEntity.CartLine{{ProductID: "p1", Quantity: 1, Price: 10}},
+		[]*productEntity.Product{{ID: "p1", Price: 10, Active: false}},
+	)
+
+	assert.Len(t, changed, 1)
+	assert.Equal(t, 10.0, changed[0].OldPrice)
+	assert.Equal(t, 0.0, changed[0].NewPrice)
+	assert.Equal(t, -100.0, changed[0].PercentChange)
+}
+
+// -------------------------------------
+// Tests de EstimateCartTax
+// -------------------------------------
+
+func estimateTaxCart() *cartEntity.Cart {
+	return &cartEntity.Cart{ID: "c1", UserID: "u1", Lines: []*cartEntity.CartLine{
+		{ProductID: "p1", Quantity: 2, Price: 20},
+		{ProductID: "p2", Quantity: 1, Price: 30.5},
+	}}
+}
+
+// TestEstimateCartTax_ValidCountry verifica que se aplica la tasa del país al
+// total del carrito, aceptando el código en minúsculas.
+func TestEstimateCartTax_ValidCountry(t *testing.T) {
+	mockCartRepo := new(MockCartRepository)
+	taxProvider := new(MockTaxProvider)
+	uc := usecase.NewCartUseCase(new(MockValidator), mockCartRepo, new(mocks.ProductRepository), nil, nil, nil, taxProvider)
+
+	mockCartRepo.On("GetCartByUserID", mock.Anything, "u1").Return(estimateTaxCart(), nil)
+	taxProvider.On("GetTaxRate", mock.Anything, "ES").Return(0.21, nil)
+
+	tax, err := uc.EstimateCartTax(context.Background(), "u1", " es ")
+
+	assert.NoError(t, err)
+	assert.Equal(t, 10.61, tax)
+}
+
+// TestEstimateCartTax_InvalidCountry verifica que un código desconocido o mal
+// formado es rechazado sin consultar el carrito.
+func TestEstimateCartTax_InvalidCountry(t *testing.T) {
+	mockCartRepo := new(MockCartRepository)
+	taxProvider := new(MockTaxProvider)
+	uc := usecase.NewCartUseCase(new(MockValidator), mockCartRepo, new(mocks.ProductRepository), nil, nil, nil, taxProvider)
+
+	for _, code := range []string{"XX", "ESP", "E1"} {
+		tax, err := uc.EstimateCartTax(context.Background(), "u1", code)
+		assert.Zero(t, tax)
+		assert.ErrorIs(t, err, usecase.ErrUnsupportedCountry, code)
+	}
+	mockCartRepo.AssertNotCalled(t, "GetCartByUserID", mock.Anything, mock.Anything)
+	taxProvider.AssertNotCalled(t, "GetTaxRate", mock.Anything, mock.Anything)
+}
+
+// TestEstimateCartTax_EmptyCountry verifica que un código vacío es rechazado.
+func TestEstimateCartTax_EmptyCountry(t *testing.T) {
+	mockCartRepo := new(MockCartRepository)
+	uc := usecase.NewCartUseCase(new(MockValidator), mockCartRepo, new(mocks.ProductRepository), nil, nil, nil, new(MockTaxProvider))
+
+	tax, err := uc.EstimateCartTax(context.Background(), "u1", "")
+
+	assert.Zero(t, tax)
+	assert.ErrorIs(t, err, usecase.ErrUnsupportedCountry)
+}
+
+// TestEstimateCartTax_ProviderError verifica que el error del proveedor de
+// impuestos se propaga.
+func TestEstimateCartTax_ProviderError(t *testing.T) {
+	mockCartRepo := new(MockCartRepository)
+	taxProvider := new(MockTaxProvider)
+	uc := usecase.NewCartUseCase(new(MockValidator), mockCartRepo, new(mocks.ProductRepository), nil, nil, nil, taxProvider)
+
+	mockCartRepo.On("GetCartByUserID", mock.Anything, "u1").Return(estimateTaxCart(), nil)
+	taxProvider.On("GetTaxRate", mock.Anything, "US").Return(0.0, errors.New("provider down"))
+
+	tax, err := uc.EstimateCartTax(context.Background(), "u1", "US")
+
+	assert.Zero(t, tax)
+	assert.EqualError(t, err, "provider down")
+}
+
+// TestCountryTaxProvider_GetTaxRate verifica que cada país usa su propia tasa
+// y que un país sin tasa conocida usa la tasa por defecto.
+func TestCountryTaxProvider_GetTaxRate(t *testing.T) {
+	provider := usecase.NewCountryTaxProvider(0.1)
+
+	for country, want := range map[string]float64{"ES": 0.21, "DE": 0.19, "JP": 0.10, "US": 0.1} {
+		rate, err := provider.GetTaxRate(context.Background(), country)
+		assert.NoError(t, err)
+		assert.Equal(t, want, rate, country)
+	}
+}
+
+// -------------------------------------
+// Tests de GetCartItemLastViewedAt
+// -------------------------------------
+
+// TestGetCartItemLastViewedAt_WithTimestamp verifica que se devuelve la fecha
+// de la última visita del producto.
+func TestGetCartItemLastViewedAt_WithTimestamp(t *testing.T) {
+	mockCartRepo := new(MockCartRepository)
+	uc := usecase.NewCartUseCase(new(MockValidator), mockCartRepo, new(mocks.ProductRepository), nil, nil, nil, nil)
+
+	viewedAt := time.Now().AddDate(0, 0, -3)
+	mockCartRepo.On("GetCartIDByUserID", mock.Anything, "u1").Return("c1", nil)
+	mockCartRepo.On("GetCartLineByProductIDAndCartID", mock.Anything, "c1", "p1").
+		Return(&cartEntity.CartLine{CartID: "c1", ProductID: "p1", LastViewedAt: &viewedAt}, nil)
+
+	lastViewed, err := uc.GetCartItemLastViewedAt(context.Background(), "u1", "p1")
+
+	assert.NoError(t, err)
+	assert.Equal(t, viewedAt, *lastViewed)
+}
+
+// TestGetCartItemLastViewedAt_NeverViewed verifica que una línea sin visitas
+// devuelve nil sin error.
+func TestGetCartItemLastViewedAt_NeverViewed(t *testing.T) {
+	mockCartRepo := new(MockCartRepository)
+	uc := usecase.NewCartUseCase(new(MockValidator), mockCartRepo, new(mocks.ProductRepository), nil, nil, nil, nil)
+
+	mockCartRepo.On("GetCartIDByUserID", mock.Anything, "u1").Return("c1", nil)
+	mockCartRepo.On("GetCartLineByProductIDAndCartID", mock.Anything, "c1", "p1").
+		Return(&cartEntity.CartLine{CartID: "c1", ProductID: "p1"}, nil)
+
+	lastViewed, err := uc.GetCartItemLastViewedAt(context.Background(), "u1", "p1")
+
+	assert.NoError(t, err)
+	assert.Nil(t, lastViewed)
+}
+
+// TestGetCartItemLastViewedAt_LineNotFound verifica que un producto que no
+// está en el carrito devuelve ErrLineNotInCart.
+func TestGetCartItemLastViewedAt_LineNotFound(t *testing.T) {
+	mockCartRepo := new(MockCartRepository)
+	uc := usecase.NewCartUseCase(new(MockValidator), mockCartRepo, new(mocks.ProductRepository), nil, nil, nil, nil)
+
+	mockCartRepo.On("GetCartIDByUserID", mock.Anything, "u1").Return("c1", nil)
+	mockCartRepo.On("GetCartLineByProductIDAndCartID", mock.Anything, "c1", "p9").Return(nil, gorm.ErrRecordNotFound)
+
+	lastViewed, err := uc.GetCartItemLastViewedAt(context.Background(), "u1", "p9")
+
+	assert.Nil(t, lastViewed)
+	assert.ErrorIs(t, err, usecase.ErrLineNotInCart)
+}
+
+// -------------------------------------
+// Tests de GetCartsByProductID
+// -------------------------------------
+
+// TestGetCartsByProductID_MultipleCarts verifica que se devuelven todos los
+// carritos que contienen el producto junto con la paginación.
+func TestGetCartsByProductID_MultipleCarts(t *testing.T) {
+	mockCartRepo := new(MockCartRepository)
+	uc := usecase.NewCartUseCase(nil, mockCartRepo, nil, nil, nil, nil, nil)
+
+	carts := []*cartEntity.Cart{
+		{ID: "c1", Lines: []*cartEntity.CartLine{{ProductID: "p1"}}},
+		{ID: "c2", Lines: []*cartEntity.CartLine{{ProductID: "p1"}, {ProductID: "p2"}}},
+	}
+	pagination := paging.NewPagination(1, 20, 2)
+	mockCartRepo.On("GetCartsByProductID", mock.Anything, "p1", (*paging.Pagination)(nil)).Return(carts, pagination, nil)
+
+	result, page, err := uc.GetCartsByProductID(context.Background(), "p1", utils.RoleAdmin, nil)
+
+	assert.NoError(t, err)
+	assert.Equal(t, carts, result)
+	assert.Equal(t, int64(2), page.TotalCount)
+}
+
+// TestGetCartsByProductID_NoCarts verifica que un producto que no está en
+// ningún carrito devuelve una lista vacía sin error.
+func TestGetCartsByProductID_NoCarts(t *testing.T) {
+	mockCartRepo := new(MockCartRepository)
+	uc := usecase.NewCartUseCase(nil, mockCartRepo, nil, nil, nil, nil, nil)
+
+	mockCartRepo.On("GetCartsByProductID", mock.Anything, "p1", mock.Anything).
+		Return([]*cartEntity.Cart{}, paging.NewPagination(1, 20, 0), nil)
+
+	result, page, err := uc.GetCartsByProductID(context.Background(), "p1", utils.RoleAdmin, nil)
+
+	assert.NoError(t, err)
+	assert.Empty(t, result)
+	assert.Zero(t, page.TotalCount)
+}
+
+// TestGetCartsByProductID_Forbidden verifica que un usuario que no es admin
+// recibe ErrForbidden sin consultar el repositorio.
+func TestGetCartsByProductID_Forbidden(t *testing.T) {
+	mockCartRepo := new(MockCartRepository)
+	uc := usecase.NewCartUseCase(nil, mockCartRepo, nil, nil, nil, nil, nil)
+
+	result, page, err := uc.GetCartsByProductID(context.Background(), "p1", utils.RoleCustomer, nil)
+
+	assert.Nil(t, result)
+	assert.Nil(t, page)
+	assert.ErrorIs(t, err, usecase.ErrForbidden)
+	mockCartRepo.AssertNotCalled(t, "GetCartsByProductID", mock.Anything, mock.Anything, mock.Anything)
+}
+
+// TestGetCartsByProductID_Paging verifica que la petición de paginación llega
+// al repositorio y que se devuelve la página que este calcula.
+func TestGetCartsByProductID_Paging(t *testing.T) {
+	mockCartRepo := new(MockCartRepository)
+	uc := usecase.NewCartUseCase(nil, mockCartRepo, nil, nil, nil, nil, nil)
+
+	req := &paging.Pagination{Page: 2, Size: 1}
+	carts := []*cartEntity.Cart{{ID: "c2"}}
+	mockCartRepo.On("GetCartsByProductID", mock.Anything, "p1", req).Return(carts, paging.NewPagination(2, 1, 3), nil)
+
+	result, page, err := uc.GetCartsByProductID(context.Background(), "p1", utils.RoleAdmin, req)
+
+	assert.NoError(t, err)
+	assert.Equal(t, carts, result)
+	assert.Equal(t, int64(2), page.Page)
+	assert.Equal(t, int64(3), page.TotalPages)
+	assert.True(t, page.HasPrevious)
+	assert.True(t, page.HasNext)
+}
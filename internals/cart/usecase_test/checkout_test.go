@@ -0,0 +1,359 @@
+package usecase_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	cartDto "ecommerce_clean/internals/cart/controller/dto"
+	cartEntity "ecommerce_clean/internals/cart/entity"
+	"ecommerce_clean/internals/cart/usecase"
+	orderEntity "ecommerce_clean/internals/order/entity"
+	productEntity "ecommerce_clean/internals/product/entity"
+	"ecommerce_clean/internals/product/repository/mocks"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"gorm.io/gorm"
+)
+
+func checkoutTestCart() *cartEntity.Cart {
+	discountID, giftCardID := "d1", "g1"
+	return &cartEntity.Cart{
+		ID:             "c1",
+		UserID:         "u1",
+		DiscountID:     &discountID,
+		GiftCardID:     &giftCardID,
+		DiscountAmount: 5,
+		GiftCardAmount: 15,
+		UpdatedAt:      time.Date(2026, 1, 2, 3, 4, 5, 6000, time.UTC),
+		Lines: []*cartEntity.CartLine{
+			{ID: "l1", ProductID: "p1", Quantity: 2, Price: 20},
+			{ID: "l2", ProductID: "p2", Quantity: 1, Price: 5},
+		},
+	}
+}
+
+func checkoutTestProducts() []*productEntity.Product {
+	return []*productEntity.Product{
+		{ID: "p1", Active: true, Stock: 5, Price: 10},
+		{ID: "p2", Active: true, Stock: 1, Price: 5},
+	}
+}
+
+func checkoutKey(cart *cartEntity.Cart) string {
+	return fmt.Sprintf("cart:%s:%d", cart.ID, cart.UpdatedAt.UnixMicro())
+}
+
+// -------------------------------------
+// Tests de Checkout con orden creada
+// -------------------------------------
+
+// TestCheckout_Success verifica que se crea la orden con las líneas del
+// carrito a precio actual, con su descuento, su tarjeta regalo y una clave de
+// idempotencia propia del carrito, que guarda el descuento y el impuesto del
+// país cobrados, que se descuenta el stock y que el carrito queda marcado como
+// pagado.
+func TestCheckout_Success(t *testing.T) {
+	mockCartRepo := new(MockCartRepository)
+	mockProductRepo := new(mocks.ProductRepository)
+	mockOrderRepo := new(MockOrderRepository)
+	mockGiftCardRepo := new(MockGiftCardRepository)
+	uc := usecase.NewCartUseCase(new(MockValidator), mockCartRepo, mockProductRepo, mockOrderRepo, mockGiftCardRepo, nil, usecase.NewCountryTaxProvider(0.1))
+
+	cart := checkoutTestCart()
+	key := checkoutKey(cart)
+	mockCartRepo.On("GetCartByUserID", mock.Anything, "u1").Return(cart, nil)
+	mockProductRepo.On("GetProductsByIDs", mock.Anything, []string{"p1", "p2"}).Return(checkoutTestProducts(), nil)
+	mockOrderRepo.On("CreateOrder", mock.Anything, mock.MatchedBy(func(order *orderEntity.Order) bool {
+		return order.UserID == "u1" &&
+			order.DiscountID != nil && *order.DiscountID == "d1" &&
+			order.GiftCardID != nil && *order.GiftCardID == "g1" &&
+			order.GiftCardAmount == 15 &&
+			order.DiscountAmount == 5 && order.TaxAmount == 4.2 &&
+			order.IdempotencyKey != nil && *order.IdempotencyKey == key
+	}), mock.MatchedBy(func(lines []*orderEntity.OrderLine) bool {
+		return len(lines) == 2 &&
+			lines[0].ProductID == "p1" && lines[0].Quantity == 2 && lines[0].Price == 20 &&
+			lines[1].ProductID == "p2" && lines[1].Quantity == 1 && lines[1].Price == 5
+	})).Return(nil)
+	mockProductRepo.On("GetProductStockForUpdate", mock.Anything, "p1").Return(5, nil)
+	mockProductRepo.On("UpdateProductStock", mock.Anything, "p1", 3).Return(nil)
+	mockProductRepo.On("GetProductStockForUpdate", mock.Anything, "p2").Return(1, nil)
+	mockProductRepo.On("UpdateProductStock", mock.Anything, "p2", 0).Return(nil)
+	mockCartRepo.On("UpdateCartStatus", mock.Anything, "c1", cartEntity.CartStatusCheckedOut).Return(nil)
+
+	order, err := uc.Checkout(context.Background(), "u1", "ES", "")
+
+	assert.NoError(t, err)
+	assert.Equal(t, "o1", order.ID)
+	assert.Equal(t, "p1", order.Lines[0].Product.ID)
+	mockOrderRepo.AssertExpectations(t)
+	mockProductRepo.AssertExpectations(t)
+	mockCartRepo.AssertExpectations(t)
+	mockCartRepo.AssertNotCalled(t, "RemoveCartLine", mock.Anything, mock.Anything)
+	mockGiftCardRepo.AssertNotCalled(t, "ReleaseGiftCard", mock.Anything, mock.Anything, mock.Anything)
+	assert.False(t, cart.IsActive())
+}
+
+// TestCheckout_ManyLines verifica que un carrito con más de cinco líneas se
+// puede pagar.
+func TestCheckout_ManyLines(t *testing.T) {
+	mockCartRepo := new(MockCartRepository)
+	mockProductRepo := new(mocks.ProductRepository)
+	mockOrderRepo := new(MockOrderRepository)
+	uc := usecase.NewCartUseCase(new(MockValidator), mockCartRepo, mockProductRepo, mockOrderRepo, nil, nil, usecase.NewCountryTaxProvider(0.1))
+
+	cart := &cartEntity.Cart{ID: "c1", UserID: "u1"}
+	var ids []string
+	var products []*productEntity.Product
+	for i := 0; i < 8; i++ {
+		id := fmt.Sprintf("p%d", i)
+		ids = append(ids, id)
+		cart.Lines = append(cart.Lines, &cartEntity.CartLine{ID: "l" + id, ProductID: id, Quantity: 1, Price: 3})
+		products = append(products, &productEntity.Product{ID: id, Active: true, Stock: 1, Price: 3})
+	}
+	mockCartRepo.On("GetCartByUserID", mock.Anything, "u1").Return(cart, nil)
+	mockProductRepo.On("GetProductsByIDs", mock.Anything, ids).Return(products, nil)
+	mockOrderRepo.On("CreateOrder", mock.Anything, mock.Anything, mock.MatchedBy(func(lines []*orderEntity.OrderLine) bool {
+		return len(lines) == 8
+	})).Return(nil)
+	mockProductRepo.On("GetProductStockForUpdate", mock.Anything, mock.Anything).Return(1, nil)
+	mockProductRepo.On("UpdateProductStock", mock.Anything, mock.Anything, 0).Return(nil)
+	mockCartRepo.On("UpdateCartStatus", mock.Anything, "c1", cartEntity.CartStatusCheckedOut).Return(nil)
+
+	order, err := uc.Checkout(context.Background(), "u1", "ES", "")
+
+	assert.NoError(t, err)
+	assert.Len(t, order.Lines, 8)
+}
+
+// TestCheckout_Retry verifica que repetir el checkout con la misma clave de
+// idempotencia devuelve la orden ya creada, aunque el carrito ya se vació,
+// sin crear otra ni tocar el carrito.
+func TestCheckout_Retry(t *testing.T) {
+	mockCartRepo := new(MockCartRepository)
+	mockProductRepo := new(mocks.ProductRepository)
+	mockOrderRepo := new(MockOrderRepository)
+	uc := usecase.NewCartUseCase(new(MockValidator), mockCartRepo, mockProductRepo, mockOrderRepo, nil, nil, usecase.NewCountryTaxProvider(0.1))
+
+	placed := &orderEntity.Order{ID: "o1", UserID: "u1"}
+	mockOrderRepo.On("FindOrderByIdempotencyKey", mock.Anything, "k1").Return(placed, nil)
+
+	order, err := uc.Checkout(context.Background(), "u1", "ES", "k1")
+
+	assert.NoError(t, err)
+	assert.Same(t, placed, order)
+	mockCartRepo.AssertNotCalled(t, "GetCartByUserID", mock.Anything, mock.Anything)
+	mockOrderRepo.AssertNotCalled(t, "CreateOrder", mock.Anything, mock.Anything, mock.Anything)
+	mockProductRepo.AssertNotCalled(t, "UpdateProductStock", mock.Anything, mock.Anything, mock.Anything)
+	mockCartRepo.AssertNotCalled(t, "UpdateCartStatus", mock.Anything, mock.Anything, mock.Anything)
+}
+
+// TestCheckout_KeyOfOtherUser verifica que una clave usada por otro usuario se
+// rechaza sin devolver su orden.
+func TestCheckout_KeyOfOtherUser(t *testing.T) {
+	mockCartRepo := new(MockCartRepository)
+	mockOrderRepo := new(MockOrderRepository)
+	uc := usecase.NewCartUseCase(new(MockValidator), mockCartRepo, new(mocks.ProductRepository), mockOrderRepo, nil, nil, usecase.NewCountryTaxProvider(0.1))
+
+	mockOrderRepo.On("FindOrderByIdempotencyKey", mock.Anything, "k1").Return(&orderEntity.Order{ID: "o1", UserID: "u2"}, nil)
+
+	order, err := uc.Checkout(context.Background(), "u1", "ES", "k1")
+
+	assert.Nil(t, order)
+	assert.ErrorIs(t, err, usecase.ErrIdempotencyKeyConflict)
+	mockCartRepo.AssertNotCalled(t, "GetCartByUserID", mock.Anything, mock.Anything)
+}
+
+// TestCheckout_ConcurrentSameKey verifica que si otro checkout con la misma
+// clave crea la orden primero, el índice único hace fallar este y se devuelve
+// la orden del otro en lugar de un error.
+func TestCheckout_ConcurrentSameKey(t *testing.T) {
+	mockCartRepo := new(MockCartRepository)
+	mockProductRepo := new(mocks.ProductRepository)
+	mockOrderRepo := new(MockOrderRepository)
+	uc := usecase.NewCartUseCase(new(MockValidator), mockCartRepo, mockProductRepo, mockOrderRepo, nil, nil, usecase.NewCountryTaxProvider(0.1))
+
+	placed := &orderEntity.Order{ID: "o1", UserID: "u1"}
+	mockOrderRepo.On("FindOrderByIdempotencyKey", mock.Anything, "k1").Return(nil, gorm.ErrRecordNotFound).Once()
+	mockCartRepo.On("GetCartByUserID", mock.Anything, "u1").Return(checkoutTestCart(), nil)
+	mockProductRepo.On("GetProductsByIDs", mock.Anything, mock.Anything).Return(checkoutTestProducts(), nil)
+	mockProductRepo.On("GetProductStockForUpdate", mock.Anything, mock.Anything).Return(5, nil)
+	mockProductRepo.On("UpdateProductStock", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	mockOrderRepo.On("CreateOrder", mock.Anything, mock.Anything, mock.Anything).
+		Return(errors.New(`ERROR: duplicate key value violates unique constraint "idx_orders_idempotency_key" (SQLSTATE 23505)`))
+	mockOrderRepo.On("FindOrderByIdempotencyKey", mock.Anything, "k1").Return(placed, nil).Once()
+
+	order, err := uc.Checkout(context.Background(), "u1", "ES", "k1")
+
+	assert.NoError(t, err)
+	assert.Same(t, placed, order)
+	mockCartRepo.AssertNotCalled(t, "UpdateCartStatus", mock.Anything, mock.Anything, mock.Anything)
+}
+
+// TestCheckout_CreateOrderFails verifica que si no se puede crear la orden el
+// error se devuelve y el carrito queda intacto; el stock ya descontado se
+// deshace con la transacción.
+func TestCheckout_CreateOrderFails(t *testing.T) {
+	mockCartRepo := new(MockCartRepository)
+	mockProductRepo := new(mocks.ProductRepository)
+	mockOrderRepo := new(MockOrderRepository)
+	uc := usecase.NewCartUseCase(new(MockValidator), mockCartRepo, mockProductRepo, mockOrderRepo, nil, nil, usecase.NewCountryTaxProvider(0.1))
+
+	createErr := errors.New("create order failed")
+	mockCartRepo.On("GetCartByUserID", mock.Anything, "u1").Return(checkoutTestCart(), nil)
+	mockProductRepo.On("GetProductsByIDs", mock.Anything, mock.Anything).Return(checkoutTestProducts(), nil)
+	mockOrderRepo.On("CreateOrder", mock.Anything, mock.Anything, mock.Anything).Return(createErr)
+	mockProductRepo.On("GetProductStockForUpdate", mock.Anything, mock.Anything).Return(5, nil)
+	mockProductRepo.On("UpdateProductStock", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
+	order, err := uc.Checkout(context.Background(), "u1", "ES", "")
+
+	assert.Nil(t, order)
+	assert.Equal(t, createErr, err)
+	mockCartRepo.AssertNotCalled(t, "UpdateCartStatus", mock.Anything, mock.Anything, mock.Anything)
+}
+
+// TestCheckout_StockTakenMeanwhile verifica que si al bloquear el producto ya
+// no queda stock suficiente se devuelve ErrInsufficientStock sin crear la orden.
+func TestCheckout_StockTakenMeanwhile(t *testing.T) {
+	mockCartRepo := new(MockCartRepository)
+	mockProductRepo := new(mocks.ProductRepository)
+	mockOrderRepo := new(MockOrderRepository)
+	uc := usecase.NewCartUseCase(new(MockValidator), mockCartRepo, mockProductRepo, mockOrderRepo, nil, nil, usecase.NewCountryTaxProvider(0.1))
+
+	mockCartRepo.On("GetCartByUserID", mock.Anything, "u1").Return(checkoutTestCart(), nil)
+	mockProductRepo.On("GetProductsByIDs", mock.Anything, mock.Anything).Return(checkoutTestProducts(), nil)
+	mockProductRepo.On("GetProductStockForUpdate", mock.Anything, "p1").Return(1, nil)
+
+	order, err := uc.Checkout(context.Background(), "u1", "ES", "")
+
+	assert.Nil(t, order)
+	assert.ErrorIs(t, err, usecase.ErrInsufficientStock)
+	mockProductRepo.AssertNotCalled(t, "UpdateProductStock", mock.Anything, mock.Anything, mock.Anything)
+	mockOrderRepo.AssertNotCalled(t, "CreateOrder", mock.Anything, mock.Anything, mock.Anything)
+	mockCartRepo.AssertNotCalled(t, "UpdateCartStatus", mock.Anything, mock.Anything, mock.Anything)
+}
+
+// TestCheckout_PriceDrift verifica que si algún precio cambió no se crea la
+// orden y el error lleva las líneas afectadas.
+func TestCheckout_PriceDrift(t *testing.T) {
+	mockCartRepo := new(MockCartRepository)
+	mockProductRepo := new(mocks.ProductRepository)
+	mockOrderRepo := new(MockOrderRepository)
+	uc := usecase.NewCartUseCase(new(MockValidator), mockCartRepo, mockProductRepo, mockOrderRepo, nil, nil, usecase.NewCountryTaxProvider(0.1))
+
+	products := checkoutTestProducts()
+	products[0].Price = 12
+	mockCartRepo.On("GetCartByUserID", mock.Anything, "u1").Return(checkoutTestCart(), nil)
+	mockProductRepo.On("GetProductsByIDs", mock.Anything, mock.Anything).Return(products, nil)
+
+	order, err := uc.Checkout(context.Background(), "u1", "ES", "")
+
+	assert.Nil(t, order)
+	var driftErr usecase.ErrPriceDriftDetected
+	if assert.ErrorAs(t, err, &driftErr) {
+		assert.Equal(t, []*cartDto.PriceDriftItem{{ProductID: "p1", CartPrice: 10, CurrentPrice: 12}}, driftErr.Items)
+	}
+	mockOrderRepo.AssertNotCalled(t, "CreateOrder", mock.Anything, mock.Anything, mock.Anything)
+}
+
+// TestCheckout_ThenAddProduct verifica que después del checkout el carrito
+// pagado ya no admite productos y que el usuario recibe un carrito nuevo.
+func TestCheckout_ThenAddProduct(t *testing.T) {
+	mockCartRepo := new(MockCartRepository)
+	mockProductRepo := new(mocks.ProductRepository)
+	mockOrderRepo := new(MockOrderRepository)
+	mockValidator := new(MockValidator)
+	uc := usecase.NewCartUseCase(mockValidator, mockCartRepo, mockProductRepo, mockOrderRepo, nil, nil, usecase.NewCountryTaxProvider(0.1))
+
+	cart := checkoutTestCart()
+	mockCartRepo.On("GetCartByUserID", mock.Anything, "u1").Return(cart, nil).Once()
+	mockProductRepo.On("GetProductsByIDs", mock.Anything, mock.Anything).Return(checkoutTestProducts(), nil)
+	mockOrderRepo.On("CreateOrder", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	mockProductRepo.On("GetProductStockForUpdate", mock.Anything, mock.Anything).Return(5, nil)
+	mockProductRepo.On("UpdateProductStock", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	mockCartRepo.On("UpdateCartStatus", mock.Anything, "c1", cartEntity.CartStatusCheckedOut).Return(nil)
+
+	_, err := uc.Checkout(context.Background(), "u1", "ES", "")
+	assert.NoError(t, err)
+
+	req := &cartDto.AddProductRequest{CartID: "c1", ProductID: "p1", Quantity: 1}
+	mockValidator.On("ValidateStruct", req).Return(nil)
+	mockCartRepo.On("GetCartByID", mock.Anything, "c1").Return(cart, nil)
+
+	err = uc.AddProduct(context.Background(), req)
+
+	assert.ErrorIs(t, err, usecase.ErrCartNotActive)
+	mockCartRepo.AssertNotCalled(t, "CreateCartLine", mock.Anything, mock.Anything)
+
+	mockCartRepo.On("GetCartByUserID", mock.Anything, "u1").Return((*cartEntity.Cart)(nil), gorm.ErrRecordNotFound).Once()
+	mockCartRepo.On("CreateCart", mock.Anything, mock.MatchedBy(func(c *cartEntity.Cart) bool {
+		return c.UserID == "u1" && c.IsActive()
+	})).Return(nil)
+
+	next, err := uc.GetCartByUserID(context.Background(), "u1")
+
+	assert.NoError(t, err)
+	assert.NotEqual(t, "c1", next.ID)
+	assert.Empty(t, next.Lines)
+}
+
+// TestCheckout_CheckedOut verifica que un carrito ya pagado no se puede volver
+// a pagar.
+func TestCheckout_CheckedOut(t *testing.T) {
+	mockCartRepo := new(MockCartRepository)
+	mockProductRepo := new(mocks.ProductRepository)
+	uc := usecase.NewCartUseCase(new(MockValidator), mockCartRepo, mockProductRepo, nil, nil, nil, usecase.NewCountryTaxProvider(0.1))
+
+	cart := checkoutTestCart()
+	cart.Status = cartEntity.CartStatusCheckedOut
+	mockCartRepo.On("GetCartByUserID", mock.Anything, "u1").Return(cart, nil)
+
+	_, err := uc.Checkout(context.Background(), "u1", "ES", "")
+
+	assert.ErrorIs(t, err, usecase.ErrCartNotActive)
+	mockProductRepo.AssertNotCalled(t, "GetProductsByIDs", mock.Anything, mock.Anything)
+}
+
+// TestCheckout_ExpiredCart verifica que un carrito vencido se vacía y se paga
+// como vacío, devolviendo el saldo de su tarjeta regalo.
+func TestCheckout_ExpiredCart(t *testing.T) {
+	mockCartRepo := new(MockCartRepository)
+	mockGiftCardRepo := new(MockGiftCardRepository)
+	mockProductRepo := new(mocks.ProductRepository)
+	uc := usecase.NewCartUseCase(new(MockValidator), mockCartRepo, mockProductRepo, nil, mockGiftCardRepo, nil, usecase.NewCountryTaxProvider(0.1))
+
+	cart := checkoutTestCart()
+	expiredAt := time.Now().Add(-time.Hour)
+	cart.ExpiresAt = &expiredAt
+	mockCartRepo.On("GetCartByUserID", mock.Anything, "u1").Return(cart, nil)
+	mockGiftCardRepo.On("ReleaseGiftCard", mock.Anything, "g1", 15.0).Return(nil)
+	mockCartRepo.On("ResetCart", mock.Anything, cart).Return(nil)
+
+	order, err := uc.Checkout(context.Background(), "u1", "ES", "")
+
+	assert.Nil(t, order)
+	assert.ErrorIs(t, err, usecase.ErrEmptyCart)
+	mockGiftCardRepo.AssertExpectations(t)
+	mockProductRepo.AssertNotCalled(t, "GetProductsByIDs", mock.Anything, mock.Anything)
+}
+
+// TestCheckout_UnsupportedCountry verifica que sin un país soportado no se
+// lee el carrito ni se crea la orden.
+func TestCheckout_UnsupportedCountry(t *testing.T) {
+	mockCartRepo := new(MockCartRepository)
+	mockOrderRepo := new(MockOrderRepository)
+	uc := usecase.NewCartUseCase(new(MockValidator), mockCartRepo, new(mocks.ProductRepository), mockOrderRepo, nil, nil, usecase.NewCountryTaxProvider(0.1))
+
+	for _, country := range []string{"", "XX"} {
+		order, err := uc.Checkout(context.Background(), "u1", country, "")
+		assert.Nil(t, order)
+		assert.ErrorIs(t, err, usecase.ErrUnsupportedCountry)
+	}
+	mockCartRepo.AssertNotCalled(t, "GetCartByUserID", mock.Anything, mock.Anything)
+	mockOrderRepo.AssertNotCalled(t, "CreateOrder", mock.Anything, mock.Anything, mock.Anything)
+}
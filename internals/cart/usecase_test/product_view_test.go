@@ -0,0 +1,79 @@
+package usecase_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"ecommerce_clean/internals/cart/usecase"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"gorm.io/gorm"
+)
+
+// -------------------------------------
+// Tests de RecordProductView
+// -------------------------------------
+
+// TestRecordProductView_InCart verifica que se marca la línea del carrito del
+// usuario con la hora actual.
+func TestRecordProductView_InCart(t *testing.T) {
+	mockCartRepo := new(MockCartRepository)
+	recorder := usecase.NewProductViewRecorder(mockCartRepo)
+
+	before := time.Now()
+	mockCartRepo.On("GetCartIDByUserID", mock.Anything, "u1").Return("c1", nil)
+	mockCartRepo.On("UpdateLastViewed", mock.Anything, "c1", "p1", mock.MatchedBy(func(viewedAt time.Time) bool {
+		return !viewedAt.Before(before)
+	})).Return(nil)
+
+	err := recorder.RecordProductView(context.Background(), "u1", "p1")
+
+	assert.NoError(t, err)
+	mockCartRepo.AssertExpectations(t)
+}
+
+// TestRecordProductView_NotTracked verifica que ver un producto que no está en
+// el carrito, o sin tener carrito, no es un error.
+func TestRecordProductView_NotTracked(t *testing.T) {
+	t.Run("sin carrito", func(t *testing.T) {
+		mockCartRepo := new(MockCartRepository)
+		recorder := usecase.NewProductViewRecorder(mockCartRepo)
+
+		mockCartRepo.On("GetCartIDByUserID", mock.Anything, "u1").Return("", gorm.ErrRecordNotFound)
+
+		err := recorder.RecordProductView(context.Background(), "u1", "p1")
+
+		assert.NoError(t, err)
+		mockCartRepo.AssertNotCalled(t, "UpdateLastViewed", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("producto fuera del carrito", func(t *testing.T) {
+		mockCartRepo := new(MockCartRepository)
+		recorder := usecase.NewProductViewRecorder(mockCartRepo)
+
+		mockCartRepo.On("GetCartIDByUserID", mock.Anything, "u1").Return("c1", nil)
+		mockCartRepo.On("UpdateLastViewed", mock.Anything, "c1", "p1", mock.Anything).Return(gorm.ErrRecordNotFound)
+
+		err := recorder.RecordProductView(context.Background(), "u1", "p1")
+
+		assert.NoError(t, err)
+	})
+}
+
+// TestRecordProductView_RepoError verifica que los demás errores del
+// repositorio se devuelven.
+func TestRecordProductView_RepoError(t *testing.T) {
+	mockCartRepo := new(MockCartRepository)
+	recorder := usecase.NewProductViewRecorder(mockCartRepo)
+
+	repoErr := errors.New("db down")
+	mockCartRepo.On("GetCartIDByUserID", mock.Anything, "u1").Return("c1", nil)
+	mockCartRepo.On("UpdateLastViewed", mock.Anything, "c1", "p1", mock.Anything).Return(repoErr)
+
+	err := recorder.RecordProductView(context.Background(), "u1", "p1")
+
+	assert.Equal(t, repoErr, err)
+}
@@ -0,0 +1,22 @@
+package dto
+
+import (
+	"time"
+
+	"ecommerce_clean/utils"
+)
+
+const (
+	ExportFormatCSV  = "csv"
+	ExportFormatJSON = "json"
+)
+
+// OrderExportRequest selects the user's orders to export. Status, From and To
+// are optional; From and To are both inclusive.
+type OrderExportRequest struct {
+	UserID string             `json:"-" validate:"required"`
+	Format string             `json:"format" form:"format" validate:"required,oneof=csv json"`
+	Status *utils.OrderStatus `json:"status,omitempty" form:"status"`
+	From   *time.Time         `json:"from,omitempty" form:"from"`
+	To     *time.Time         `json:"to,omitempty" form:"to"`
+}
@@ -1,11 +1,45 @@
 package dto
 
 import (
+	"time"
+
 	"ecommerce_clean/pkgs/paging"
+	"ecommerce_clean/utils"
+)
+
+const (
+	SortByCreatedAt  = "created_at"
+	SortByTotalPrice = "total_price"
+	SortDirAsc       = "asc"
+	SortDirDesc      = "desc"
 )
 
+// ListOrdersRequest lists the user's own orders. From and To bound the
+// creation date and are both inclusive. Results are sorted by created_at desc
+// unless SortBy / SortDir say otherwise. A non-nil Cursor replaces Page and
+// Limit and always sorts by created_at desc.
 type ListOrdersRequest struct {
-	UserID    string `json:"-"`
+	UserID  string                   `json:"-"`
+	Code    string                   `json:"code,omitempty" form:"code"`
+	Status  *utils.OrderStatus       `json:"status,omitempty" form:"status"`
+	From    *time.Time               `json:"from,omitempty" form:"from"`
+	To      *time.Time               `json:"to,omitempty" form:"to"`
+	Page    int64                    `json:"-" form:"page"`
+	Limit   int64                    `json:"-" form:"limit"`
+	SortBy  string                   `json:"-" form:"sort_by"`
+	SortDir string                   `json:"-" form:"sort_dir"`
+	Cursor  *paging.CursorPagination `json:"-"`
+}
+
+type ListOrdersResponse struct {
+	Orders     []*Order           `json:"items"`
+	Pagination *paging.Pagination `json:"metadata"`
+}
+
+// AdminListOrdersRequest lists orders across all users. UserID is optional and
+// narrows the listing to one user.
+type AdminListOrdersRequest struct {
+	UserID    string `json:"user_id,omitempty" form:"user_id"`
 	Code      string `json:"code,omitempty" form:"code"`
 	Status    string `json:"status,omitempty" form:"status"`
 	Page      int64  `json:"-" form:"page"`
@@ -13,8 +47,3 @@ type ListOrdersRequest struct {
 	OrderBy   string `json:"-" form:"order_by"`
 	OrderDesc bool   `json:"-" form:"order_desc"`
 }
-
-type ListOrdersResponse struct {
-	Orders     []*Order           `json:"items"`
-	Pagination *paging.Pagination `json:"metadata"`
-}
@@ -3,12 +3,13 @@ package dto
 import "time"
 
 type Order struct {
-	ID         string       `json:"id"`
-	Code       string       `json:"code"`
-	Lines      []*OrderLine `json:"lines"`
-	TotalPrice float64      `json:"total_price"`
-	Status     string       `json:"status"`
-	UpdatedAt  time.Time    `json:"updated_at"`
+	ID            string       `json:"id"`
+	Code          string       `json:"code"`
+	Lines         []*OrderLine `json:"lines"`
+	TotalPrice    float64      `json:"total_price"`
+	Status        string       `json:"status"`
+	PaymentStatus string       `json:"payment_status"`
+	UpdatedAt     time.Time    `json:"updated_at"`
 }
 
 type OrderLine struct {
@@ -18,10 +19,10 @@ type OrderLine struct {
 }
 
 type Product struct {
-	ID          string  `json:"id"`
-	Code        string  `json:"code"`
-	Name        string  `json:"name"`
-	ImageUrl    string  `json:"image_url"`
-	Description string  `json:"description"`
-	Price       float64 `json:"price"`
+	ID          string   `json:"id"`
+	Code        string   `json:"code"`
+	Name        string   `json:"name"`
+	Images      []string `json:"images"`
+	Description string   `json:"description"`
+	Price       float64  `json:"price"`
 }
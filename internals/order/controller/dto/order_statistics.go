@@ -0,0 +1,9 @@
+package dto
+
+// OrderStatistics summarizes orders for dashboards. TotalRevenue only counts
+// done orders; CountByStatus is keyed by order status.
+type OrderStatistics struct {
+	TotalOrders   int            `json:"total_orders"`
+	TotalRevenue  float64        `json:"total_revenue"`
+	CountByStatus map[string]int `json:"count_by_status"`
+}
@@ -1,8 +1,14 @@
 package dto
 
+import "ecommerce_clean/internals/order/entity"
+
 type PlaceOrderRequest struct {
-	UserID string                  `json:"user_id" validate:"required"`
-	Lines  []PlaceOrderLineRequest `json:"lines,omitempty" validate:"required,gt=0,lte=5,dive"`
+	UserID          string                  `json:"user_id" validate:"required"`
+	IdempotencyKey  string                  `json:"idempotency_key" validate:"required,max=100"`
+	Lines           []PlaceOrderLineRequest `json:"lines,omitempty" validate:"required,gt=0,lte=5,dive"`
+	Notes           string                  `json:"notes,omitempty" validate:"max=1000"`
+	DeliveryAddress entity.Address          `json:"delivery_address"`
+	ReceiptEmail    *string                 `json:"receipt_email,omitempty" validate:"omitempty,email"`
 }
 
 type PlaceOrderLineRequest struct {
@@ -0,0 +1,5 @@
+package dto
+
+type AttachReceiptEmailRequest struct {
+	Email string `json:"email" validate:"required,email"`
+}
@@ -32,6 +32,7 @@ func NewOrderHandler(usecase usecase.IOrderUseCase) *OrderHandler {
 // @Failure			400	{object}	response.Response	"Bad Request - Invalid parameters"
 // @Failure			401	{object}	response.Response	"Unauthorized - User not authenticated"
 // @Failure			403	{object}	response.Response	"Forbidden - User does not have the required permissions"
+// @Failure			409	{object}	response.Response	"Conflict - Insufficient stock, or the idempotency key belongs to another user"
 // @Failure			500	{object}	response.Response	"Internal Server Error - An error occurred while processing the request"
 // @Router			/orders [post]
 // @Security		ApiKeyAuth
@@ -52,7 +53,14 @@ func (a *OrderHandler) PlaceOrder(c *gin.Context) {
 	order, err := a.usecase.PlaceOrder(c, &req)
 	if err != nil {
 		logger.Error("Failed to create OrderHandler: ", err.Error())
-		response.Error(c, http.StatusInternalServerError, err, "Something went wrong")
+		switch {
+		case errors.Is(err, usecase.ErrInsufficientStock):
+			response.Error(c, http.StatusConflict, err, "Insufficient stock")
+		case errors.Is(err, usecase.ErrIdempotencyKeyConflict):
+			response.Error(c, http.StatusConflict, err, "Idempotency key already used")
+		default:
+			response.Error(c, http.StatusInternalServerError, err, "Something went wrong")
+		}
 		return
 	}
 
@@ -68,10 +76,12 @@ func (a *OrderHandler) PlaceOrder(c *gin.Context) {
 // @Security		ApiKeyAuth
 // @Param			code		query	string	false	"Filter by order code"
 // @Param			status		query	string	false	"Filter by order status"
+// @Param			from		query	string	false	"Only orders created at or after this RFC 3339 time"
+// @Param			to			query	string	false	"Only orders created at or before this RFC 3339 time"
 // @Param			page		query	int		false	"Page number for pagination (default: 1)"
 // @Param			limit		query	int		false	"Number of records per page (default: 10)"
-// @Param			order_by	query	string	false	"Field to order by (e.g., created_at)"
-// @Param			order_desc	query	bool	false	"Sort order: true for descending, false for ascending"
+// @Param			sort_by		query	string	false	"Field to sort by: created_at (default) or total_price"
+// @Param			sort_dir	query	string	false	"Sort direction: asc or desc (default)"
 // @Success			200	{object}	dto.ListOrdersResponse	"Orders retrieved successfully"
 // @Failure			400	{object}	response.Response		"Bad Request - Invalid parameters"
 // @Failure			401	{object}	response.Response		"Unauthorized - User not authenticated"
@@ -95,6 +105,10 @@ func (a *OrderHandler) GetOrders(c *gin.Context) {
 	orders, pagination, err := a.usecase.ListMyOrders(c, &req)
 	if err != nil {
 		logger.Error("Failed to get orders: ", err)
+		if errors.Is(err, usecase.ErrInvalidSortParam) {
+			response.Error(c, http.StatusBadRequest, err, "Invalid parameters")
+			return
+		}
 		response.Error(c, http.StatusInternalServerError, err, "Something went wrong")
 		return
 	}
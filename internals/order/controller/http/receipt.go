@@ -0,0 +1,59 @@
+package http
+
+import (
+	"ecommerce_clean/internals/order/usecase"
+	"ecommerce_clean/pkgs/logger"
+	"ecommerce_clean/pkgs/response"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type ReceiptController struct {
+	usecase usecase.IOrderUseCase
+}
+
+func NewReceiptController(usecase usecase.IOrderUseCase) *ReceiptController {
+	return &ReceiptController{
+		usecase: usecase,
+	}
+}
+
+// @Summary			Download order receipt
+// @Description		Returns a plain text receipt for an order owned by the authenticated user.
+// @Tags			Orders
+// @Produce			plain
+// @Security		ApiKeyAuth
+// @Param			id	path		string	true	"Order ID"
+// @Success			200	{string}	string				"Receipt"
+// @Failure			401	{object}	response.Response	"Unauthorized - User not authenticated"
+// @Failure			403	{object}	response.Response	"Forbidden - Order belongs to another user"
+// @Failure			404	{object}	response.Response	"Not Found - Order does not exist"
+// @Failure			500	{object}	response.Response	"Internal Server Error - An error occurred while processing the request"
+// @Router			/orders/{id}/receipt [get]
+func (a *ReceiptController) GetReceipt(c *gin.Context) {
+	userId := c.GetString("userId")
+	if userId == "" {
+		response.Error(c, http.StatusUnauthorized, errors.New("unauthorized"), "Unauthorized")
+		return
+	}
+
+	orderId := c.Param("id")
+	receipt, err := a.usecase.GetOrderReceipt(c, orderId, userId)
+	if err != nil {
+		logger.Errorf("Failed to get receipt, order id: %s, error: %s", orderId, err)
+		switch {
+		case errors.Is(err, usecase.ErrPermissionDenied):
+			response.Error(c, http.StatusForbidden, err, "Permission denied")
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			response.Error(c, http.StatusNotFound, err, "Not found")
+		default:
+			response.Error(c, http.StatusInternalServerError, err, "Something went wrong")
+		}
+		return
+	}
+
+	c.String(http.StatusOK, receipt.FormatAsText())
+}
@@ -1,16 +1,21 @@
 package http
 
 import (
+	"ecommerce_clean/configs"
 	"ecommerce_clean/db"
+	addressRepo "ecommerce_clean/internals/address/repository"
 	"ecommerce_clean/internals/order/repository"
 	"ecommerce_clean/internals/order/usecase"
 	productRepo "ecommerce_clean/internals/product/repository"
+	userRepo "ecommerce_clean/internals/user/repository"
+	"ecommerce_clean/pkgs/mail"
 	"ecommerce_clean/pkgs/middlewares"
 	"ecommerce_clean/pkgs/redis"
 	"ecommerce_clean/pkgs/token"
 	"ecommerce_clean/pkgs/validation"
 
 	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
 )
 
 func Routes(
@@ -18,12 +23,23 @@ func Routes(
 	sqlDB db.IDatabase,
 	validator validation.Validation,
 	cache redis.IRedis,
+	mailer mail.IMailer,
 	token token.IMarker,
 ) {
 	productRepository := productRepo.NewProductRepository(sqlDB)
 	orderRepository := repository.NewOrderRepository(sqlDB)
-	orderUsecase := usecase.NewOrderUseCase(validator, orderRepository, productRepository)
+	shippingCalculator := usecase.NewFlatRateShippingCalculator(configs.ShippingBaseCost, configs.ShippingCostPerKg)
+	orderNoteRepository := repository.NewOrderNoteRepository(sqlDB)
+	refundRepository := repository.NewRefundRepository(sqlDB)
+	addressRepository := addressRepo.NewAddressRepository(sqlDB)
+	notifier := usecase.NewMailNotifier(mailer, userRepo.NewUserRepository(sqlDB))
+	orderUsecase := usecase.WithMiddleware(
+		usecase.NewOrderUseCase(validator, orderRepository, productRepository, shippingCalculator, orderNoteRepository, refundRepository, addressRepository, notifier),
+		usecase.LoggingMiddleware,
+		usecase.MetricsMiddleware(prometheus.DefaultRegisterer),
+	)
 	orderHandler := NewOrderHandler(orderUsecase)
+	receiptController := NewReceiptController(orderUsecase)
 
 	authMiddleware := middlewares.NewAuthMiddleware(token, cache).TokenAuth()
 
@@ -32,6 +48,7 @@ func Routes(
 		orderRoute.POST("", orderHandler.PlaceOrder)
 		orderRoute.GET("", orderHandler.GetOrders)
 		orderRoute.GET("/:id", orderHandler.GetOrderByID)
+		orderRoute.GET("/:id/receipt", receiptController.GetReceipt)
 		orderRoute.PUT("/:id/:status", orderHandler.UpdateOrder)
 	}
 }
@@ -0,0 +1,12 @@
+package entity
+
+// Address is the delivery address captured with an order. It is a snapshot
+// kept on the order itself, so later edits to the user's saved addresses do
+// not change where a placed order ships.
+type Address struct {
+	Street     string `json:"street"`
+	City       string `json:"city"`
+	State      string `json:"state"`
+	PostalCode string `json:"postal_code"`
+	Country    string `json:"country"`
+}
@@ -0,0 +1,25 @@
+package entity
+
+import "time"
+
+type LifetimeValue struct {
+	UserID             string    `json:"user_id"`
+	FirstOrderAt       time.Time `json:"first_order_at"`
+	TotalOrders        int       `json:"total_orders"`
+	CompletedOrders    int       `json:"completed_orders"`
+	CanceledOrders     int       `json:"canceled_orders"`
+	TotalSpend         float64   `json:"total_spend"`
+	AverageOrderValue  float64   `json:"average_order_value"`
+	DaysSinceLastOrder int       `json:"days_since_last_order"`
+}
+
+// UserOrderStats is the per-user aggregation behind LifetimeValue. The order
+// dates are nil when the user has no orders.
+type UserOrderStats struct {
+	TotalOrders     int
+	CompletedOrders int
+	CanceledOrders  int
+	TotalSpend      float64
+	FirstOrderAt    *time.Time
+	LastOrderAt     *time.Time
+}
@@ -0,0 +1,22 @@
+package entity
+
+import "ecommerce_clean/utils"
+
+type MonthlySummary struct {
+	Month             int     `json:"month"`
+	Year              int     `json:"year"`
+	TotalOrders       int     `json:"total_orders"`
+	CompletedOrders   int     `json:"completed_orders"`
+	CanceledOrders    int     `json:"canceled_orders"`
+	TotalRevenue      float64 `json:"total_revenue"`
+	AverageOrderValue float64 `json:"average_order_value"`
+	TotalRefunds      float64 `json:"total_refunds"`
+}
+
+// StatusTotals is one row of the per-status aggregation behind MonthlySummary.
+type StatusTotals struct {
+	Status    utils.OrderStatus
+	Orders    int
+	Total     float64
+	PaidTotal float64
+}
@@ -11,16 +11,35 @@ import (
 )
 
 type Order struct {
-	ID         string `json:"id" gorm:"unique;not null;index;primary_key"`
-	Code       string `json:"code"`
-	UserID     string `json:"user_id"`
-	User       *userEntity.User
-	Lines      []*OrderLine      `json:"lines"`
-	TotalPrice float64           `json:"total_price"`
-	Status     utils.OrderStatus `json:"status"`
-	CreatedAt  time.Time         `json:"created_at"`
-	UpdatedAt  time.Time         `json:"updated_at"`
-	DeletedAt  *gorm.DeletedAt   `json:"deleted_at" gorm:"index"`
+	ID                string `json:"id" gorm:"unique;not null;index;primary_key"`
+	Code              string `json:"code"`
+	UserID            string `json:"user_id"`
+	User              *userEntity.User
+	Lines             []*OrderLine        `json:"lines"`
+	Notes             []*OrderNote        `json:"notes,omitempty"`
+	TotalPrice        float64             `json:"total_price"`
+	Status            utils.OrderStatus   `json:"status"`
+	PaymentStatus     utils.PaymentStatus `json:"payment_status"`
+	ShippingAddressID *string             `json:"shipping_address_id" gorm:"index"`
+	DiscountID        *string             `json:"discount_id"`
+	GiftCardID        *string             `json:"gift_card_id,omitempty"`
+	GiftCardAmount    float64             `json:"gift_card_amount"`
+	DiscountAmount    float64             `json:"discount_amount"`
+	TaxAmount         float64             `json:"tax_amount"`
+	PaymentID         *string             `json:"payment_id" gorm:"index"`
+	PaidAt            *time.Time          `json:"paid_at"`
+	IsPaid            bool                `json:"is_paid" gorm:"-"`
+	RefundID          *string             `json:"refund_id"`
+	ReceiptEmail      *string             `json:"receipt_email,omitempty"`
+	Tags              []string            `json:"tags,omitempty" gorm:"serializer:json"`
+	ExternalRef       *string             `json:"external_ref,omitempty" gorm:"size:100;index"`
+	CancelReason      string              `json:"cancel_reason,omitempty"`
+	CustomerNotes     string              `json:"customer_notes,omitempty"`
+	IdempotencyKey    *string             `json:"-" gorm:"size:100;uniqueIndex"`
+	DeliveryAddress   Address             `json:"delivery_address" gorm:"type:jsonb;serializer:json"`
+	CreatedAt         time.Time           `json:"created_at"`
+	UpdatedAt         time.Time           `json:"updated_at"`
+	DeletedAt         *gorm.DeletedAt     `json:"deleted_at" gorm:"index"`
 }
 
 func (order *Order) BeforeCreate(tx *gorm.DB) error {
@@ -31,6 +50,26 @@ func (order *Order) BeforeCreate(tx *gorm.DB) error {
 		order.Status = utils.OrderStatusNew
 	}
 
+	if order.PaymentStatus == "" {
+		order.PaymentStatus = utils.PaymentStatusPending
+	}
+
+	return nil
+}
+
+// HasTag reports whether tag is one of the order's tags.
+func (order *Order) HasTag(tag string) bool {
+	for _, t := range order.Tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// AfterFind derives IsPaid, which is not stored, from PaymentID.
+func (order *Order) AfterFind(tx *gorm.DB) error {
+	order.IsPaid = order.PaymentID != nil
 	return nil
 }
 
@@ -0,0 +1,32 @@
+package entity
+
+import (
+	"bytes"
+	"encoding/gob"
+	"time"
+)
+
+// orderBinary has the same layout as Order without its methods, so gob does
+// not call back into Order.MarshalBinary while encoding.
+type orderBinary Order
+
+func init() {
+	gob.Register(time.Time{})
+	gob.Register(orderBinary{})
+	gob.Register(OrderLine{})
+}
+
+// MarshalBinary encodes the order with gob for cache storage.
+func (order *Order) MarshalBinary() ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode((*orderBinary)(order)); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// UnmarshalBinary decodes an order previously encoded by MarshalBinary.
+func (order *Order) UnmarshalBinary(data []byte) error {
+	return gob.NewDecoder(bytes.NewReader(data)).Decode((*orderBinary)(order))
+}
@@ -0,0 +1,66 @@
+package entity_test
+
+import (
+	"testing"
+	"time"
+
+	orderEntity "ecommerce_clean/internals/order/entity"
+	productEntity "ecommerce_clean/internals/product/entity"
+	"ecommerce_clean/utils"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestOrderBinary_Roundtrip verifica que una orden con varias líneas conserva
+// todos sus campos tras codificar y decodificar con gob.
+func TestOrderBinary_Roundtrip(t *testing.T) {
+	createdAt := time.Date(2025, 5, 6, 7, 8, 9, 0, time.UTC)
+	paidAt := createdAt.Add(time.Hour)
+	paymentID := "pay_1"
+	discountID := "d1"
+
+	order := &orderEntity.Order{
+		ID:         "o1",
+		Code:       "SO123",
+		UserID:     "u1",
+		TotalPrice: 25.5,
+		Status:     utils.OrderStatusInProgress,
+		DiscountID: &discountID,
+		PaymentID:  &paymentID,
+		PaidAt:     &paidAt,
+		CreatedAt:  createdAt,
+		UpdatedAt:  createdAt,
+		Lines: []*orderEntity.OrderLine{
+			{ID: "l1", OrderID: "o1", ProductID: "p1", Quantity: 2, Price: 20, CreatedAt: createdAt,
+				Product: &productEntity.Product{ID: "p1", Name: "Mango", Price: 10, Tags: []string{"fruta"}}},
+			{ID: "l2", OrderID: "o1", ProductID: "p2", Quantity: 1, Price: 5.5, CreatedAt: createdAt},
+		},
+	}
+
+	data, err := order.MarshalBinary()
+	assert.NoError(t, err)
+	assert.NotEmpty(t, data)
+
+	var decoded orderEntity.Order
+	assert.NoError(t, decoded.UnmarshalBinary(data))
+
+	assert.Equal(t, order.ID, decoded.ID)
+	assert.Equal(t, order.Code, decoded.Code)
+	assert.Equal(t, order.UserID, decoded.UserID)
+	assert.Equal(t, order.TotalPrice, decoded.TotalPrice)
+	assert.Equal(t, order.Status, decoded.Status)
+	assert.Equal(t, *order.DiscountID, *decoded.DiscountID)
+	assert.Equal(t, *order.PaymentID, *decoded.PaymentID)
+	assert.True(t, order.PaidAt.Equal(*decoded.PaidAt))
+	assert.True(t, order.CreatedAt.Equal(decoded.CreatedAt))
+	assert.Nil(t, decoded.ShippingAddressID)
+	if assert.Len(t, decoded.Lines, 2) {
+		assert.Equal(t, "l1", decoded.Lines[0].ID)
+		assert.Equal(t, uint(2), decoded.Lines[0].Quantity)
+		assert.Equal(t, 20.0, decoded.Lines[0].Price)
+		assert.Equal(t, "Mango", decoded.Lines[0].Product.Name)
+		assert.Equal(t, []string{"fruta"}, decoded.Lines[0].Product.Tags)
+		assert.Equal(t, "p2", decoded.Lines[1].ProductID)
+		assert.Nil(t, decoded.Lines[1].Product)
+	}
+}
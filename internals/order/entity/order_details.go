@@ -0,0 +1,16 @@
+package entity
+
+import (
+	addressEntity "ecommerce_clean/internals/address/entity"
+	discountEntity "ecommerce_clean/internals/discount/entity"
+)
+
+// OrderDetails is an order together with the related records that live in
+// other tables. Any of the related fields may be empty when they could not be
+// loaded.
+type OrderDetails struct {
+	*Order
+	ShippingAddress *addressEntity.Address   `json:"shipping_address"`
+	AppliedDiscount *discountEntity.Discount `json:"applied_discount"`
+	StatusHistory   []OrderStatusHistory     `json:"status_history"`
+}
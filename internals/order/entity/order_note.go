@@ -0,0 +1,26 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type OrderNote struct {
+	ID        string    `json:"id" gorm:"unique;not null;index;primary_key"`
+	OrderID   string    `json:"order_id" gorm:"not null;index"`
+	Content   string    `json:"content" gorm:"not null"`
+	CreatedBy string    `json:"created_by"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (note *OrderNote) BeforeCreate(tx *gorm.DB) error {
+	note.ID = uuid.New().String()
+
+	return nil
+}
+
+func (note *OrderNote) TableName() string {
+	return "order_notes"
+}
@@ -0,0 +1,7 @@
+package entity
+
+// OrderStats is the per-status aggregation behind the order statistics. It
+// has one entry per status that has at least one order.
+type OrderStats struct {
+	ByStatus []*StatusTotals
+}
@@ -0,0 +1,33 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"ecommerce_clean/utils"
+)
+
+type OrderStatusHistory struct {
+	ID         string            `json:"id" gorm:"unique;not null;index;primary_key"`
+	OrderID    string            `json:"order_id" gorm:"not null;index"`
+	FromStatus utils.OrderStatus `json:"from_status"`
+	ToStatus   utils.OrderStatus `json:"to_status"`
+	ChangedBy  string            `json:"changed_by"`
+	ChangedAt  time.Time         `json:"changed_at"`
+}
+
+// OrderAuditLog is the record written for every order status change. It is
+// stored as the order's status history.
+type OrderAuditLog = OrderStatusHistory
+
+func (history *OrderStatusHistory) BeforeCreate(tx *gorm.DB) error {
+	history.ID = uuid.New().String()
+
+	return nil
+}
+
+func (history *OrderStatusHistory) TableName() string {
+	return "order_status_histories"
+}
@@ -0,0 +1,10 @@
+package entity
+
+import "ecommerce_clean/pkgs/paging"
+
+// PaymentStatusMeta is the paging metadata of a payment status listing plus
+// the sum of TotalPrice over every matching order, not just the current page.
+type PaymentStatusMeta struct {
+	*paging.Pagination
+	TotalAmount float64 `json:"total_amount"`
+}
@@ -0,0 +1,8 @@
+package entity
+
+type ProductRevenue struct {
+	ProductID    string  `json:"product_id"`
+	ProductName  string  `json:"product_name"`
+	UnitsSold    int     `json:"units_sold"`
+	TotalRevenue float64 `json:"total_revenue"`
+}
@@ -0,0 +1,51 @@
+package entity
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+type ReceiptLine struct {
+	ProductID string  `json:"product_id"`
+	Name      string  `json:"name"`
+	Quantity  uint    `json:"quantity"`
+	UnitPrice float64 `json:"unit_price"`
+	Total     float64 `json:"total"`
+}
+
+type Receipt struct {
+	OrderID   string        `json:"order_id"`
+	UserID    string        `json:"user_id"`
+	IssueDate time.Time     `json:"issue_date"`
+	Lines     []ReceiptLine `json:"lines"`
+	Subtotal  float64       `json:"subtotal"`
+	Tax       float64       `json:"tax"`
+	Discount  float64       `json:"discount"`
+	GiftCard  float64       `json:"gift_card"`
+	Total     float64       `json:"total"`
+}
+
+// FormatAsText renders the receipt as a plain text document.
+func (r *Receipt) FormatAsText() string {
+	var sb strings.Builder
+
+	sb.WriteString("RECEIPT\n")
+	sb.WriteString(fmt.Sprintf("Order: %s\n", r.OrderID))
+	sb.WriteString(fmt.Sprintf("Customer: %s\n", r.UserID))
+	sb.WriteString(fmt.Sprintf("Date: %s\n", r.IssueDate.Format("2006-01-02 15:04")))
+	sb.WriteString(strings.Repeat("-", 40) + "\n")
+	for _, line := range r.Lines {
+		sb.WriteString(fmt.Sprintf("%s x%d @ %.2f = %.2f\n", line.Name, line.Quantity, line.UnitPrice, line.Total))
+	}
+	sb.WriteString(strings.Repeat("-", 40) + "\n")
+	sb.WriteString(fmt.Sprintf("Subtotal: %.2f\n", r.Subtotal))
+	sb.WriteString(fmt.Sprintf("Discount: -%.2f\n", r.Discount))
+	sb.WriteString(fmt.Sprintf("Tax: %.2f\n", r.Tax))
+	if r.GiftCard > 0 {
+		sb.WriteString(fmt.Sprintf("Gift card: -%.2f\n", r.GiftCard))
+	}
+	sb.WriteString(fmt.Sprintf("Total: %.2f\n", r.Total))
+
+	return sb.String()
+}
@@ -0,0 +1,29 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const RefundStatusProcessed = "processed"
+
+type Refund struct {
+	ID          string    `json:"id" gorm:"unique;not null;index;primary_key"`
+	OrderID     string    `json:"order_id" gorm:"not null;uniqueIndex"`
+	Amount      float64   `json:"amount"`
+	Reason      string    `json:"reason"`
+	ProcessedAt time.Time `json:"processed_at"`
+	Status      string    `json:"status"`
+}
+
+func (refund *Refund) BeforeCreate(tx *gorm.DB) error {
+	refund.ID = uuid.New().String()
+
+	return nil
+}
+
+func (refund *Refund) TableName() string {
+	return "refunds"
+}
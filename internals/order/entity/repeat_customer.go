@@ -0,0 +1,11 @@
+package entity
+
+import "time"
+
+type RepeatCustomer struct {
+	UserID       string    `json:"user_id"`
+	OrderCount   int       `json:"order_count"`
+	TotalSpend   float64   `json:"total_spend"`
+	FirstOrderAt time.Time `json:"first_order_at"`
+	LastOrderAt  time.Time `json:"last_order_at"`
+}
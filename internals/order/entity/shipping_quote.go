@@ -0,0 +1,8 @@
+package entity
+
+type ShippingQuote struct {
+	Carrier       string  `json:"carrier"`
+	Weight        float64 `json:"weight"`
+	Cost          float64 `json:"cost"`
+	EstimatedDays int     `json:"estimated_days"`
+}
@@ -0,0 +1,16 @@
+package entity
+
+import "time"
+
+const (
+	TimelineEventStatusChange = "status_change"
+	TimelineEventNote         = "note"
+	TimelineEventRefund       = "refund"
+)
+
+type TimelineEvent struct {
+	EventType   string    `json:"event_type"`
+	Description string    `json:"description"`
+	ActorID     string    `json:"actor_id"`
+	OccurredAt  time.Time `json:"occurred_at"`
+}
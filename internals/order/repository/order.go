@@ -2,18 +2,53 @@ package repository
 
 import (
 	"context"
+	"ecommerce_clean/configs"
 	"ecommerce_clean/db"
+	addressEntity "ecommerce_clean/internals/address/entity"
+	discountEntity "ecommerce_clean/internals/discount/entity"
 	"ecommerce_clean/internals/order/controller/dto"
 	"ecommerce_clean/internals/order/entity"
 	"ecommerce_clean/pkgs/paging"
 	"ecommerce_clean/utils"
+	"encoding/json"
+	"strings"
+	"time"
 )
 
+var tagLikeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
 type IOrderRepository interface {
-	CreateOrder(ctx context.Context, userID string, lines []*entity.OrderLine) (*entity.Order, error)
+	CreateOrder(ctx context.Context, order *entity.Order, lines []*entity.OrderLine) (*entity.Order, error)
 	GetOrderByID(ctx context.Context, id string, preload bool) (*entity.Order, error)
+	GetOrdersByIDs(ctx context.Context, ids []string, preload bool) ([]*entity.Order, error)
+	FindOrderByIdempotencyKey(ctx context.Context, key string) (*entity.Order, error)
 	GetMyOrders(ctx context.Context, req *dto.ListOrdersRequest) ([]*entity.Order, *paging.Pagination, error)
+	ListAllOrders(ctx context.Context, req *dto.AdminListOrdersRequest) ([]*entity.Order, *paging.Pagination, error)
+	StreamOrders(ctx context.Context, req *dto.OrderExportRequest, fn func(*entity.Order) error) error
 	UpdateOrder(ctx context.Context, order *entity.Order) error
+	SoftDeleteOrder(ctx context.Context, order *entity.Order) error
+	GetShippingAddress(ctx context.Context, addressID string) (*addressEntity.Address, error)
+	GetDiscount(ctx context.Context, discountID string) (*discountEntity.Discount, error)
+	GetDiscountByCode(ctx context.Context, code string) (*discountEntity.Discount, error)
+	GetStatusHistory(ctx context.Context, orderID string) ([]entity.OrderStatusHistory, error)
+	CreateAuditLog(ctx context.Context, log *entity.OrderAuditLog) error
+	SplitOrder(ctx context.Context, original *entity.Order, split *entity.Order) error
+	GetOpenOrdersContainingProduct(ctx context.Context, productID string) ([]*entity.Order, error)
+	AverageOrderValue(ctx context.Context, since time.Time) (float64, error)
+	GetOrderTotalsByStatus(ctx context.Context, from, to time.Time) ([]*entity.StatusTotals, error)
+	GetOrdersByShippingAddress(ctx context.Context, addressID string, req *paging.Pagination) ([]*entity.Order, *paging.Pagination, error)
+	UpdateOrderWithLines(ctx context.Context, order *entity.Order) error
+	GetOrdersByPaymentStatus(ctx context.Context, isPaid bool, req *paging.Pagination) ([]*entity.Order, *paging.Pagination, error)
+	SumOrdersByPaymentStatus(ctx context.Context, isPaid bool) (float64, error)
+	GetOrdersByTag(ctx context.Context, tag string, req *paging.Pagination) ([]*entity.Order, *paging.Pagination, error)
+	GetOrdersByExternalRef(ctx context.Context, ref string) ([]*entity.Order, error)
+	GetRevenueByProduct(ctx context.Context, since time.Time, limit int) ([]*entity.ProductRevenue, error)
+	GetRepeatCustomers(ctx context.Context, minOrders int, since time.Time, limit int) ([]*entity.RepeatCustomer, error)
+	GetUserOrderStats(ctx context.Context, userID string) (*entity.UserOrderStats, error)
+	AggregateOrderStats(ctx context.Context, userID string) (*entity.OrderStats, error)
+	CountOrdersByStatusSince(ctx context.Context, status utils.OrderStatus, since time.Time) (int64, error)
+	CountNonCanceledOrdersSince(ctx context.Context, since time.Time) (int64, error)
+	WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error
 }
 
 type OrderRepo struct {
@@ -24,21 +59,20 @@ func NewOrderRepository(db db.IDatabase) *OrderRepo {
 	return &OrderRepo{db: db}
 }
 
-func (r *OrderRepo) CreateOrder(ctx context.Context, userID string, lines []*entity.OrderLine) (*entity.Order, error) {
-	order := new(entity.Order)
-
+// CreateOrder saves order together with its lines. The total is computed from
+// the lines; every other field is taken from order as given.
+func (r *OrderRepo) CreateOrder(ctx context.Context, order *entity.Order, lines []*entity.OrderLine) (*entity.Order, error) {
 	var totalPrice float64
 	for _, line := range lines {
 		totalPrice += line.Price
 	}
 	order.TotalPrice = totalPrice
-	order.UserID = userID
 
-	handler := func() error {
+	handler := func(ctx context.Context) error {
 		return r.createOrder(ctx, order, lines)
 	}
 
-	err := r.db.WithTransaction(handler)
+	err := r.db.WithTransaction(ctx, handler)
 	if err != nil {
 		return nil, err
 	}
@@ -80,6 +114,38 @@ func (r *OrderRepo) GetOrderByID(ctx context.Context, id string, preload bool) (
 	return &order, nil
 }
 
+func (r *OrderRepo) FindOrderByIdempotencyKey(ctx context.Context, key string) (*entity.Order, error) {
+	var order entity.Order
+	opts := []db.FindOption{
+		db.WithQuery(db.NewQuery("idempotency_key = ?", key)),
+		db.WithPreload([]string{"Lines", "Lines.Product"}),
+	}
+
+	if err := r.db.FindOne(ctx, &order, opts...); err != nil {
+		return nil, err
+	}
+
+	return &order, nil
+}
+
+// GetOrdersByIDs returns the orders among ids that exist, in no particular
+// order. Missing IDs are simply absent from the result.
+func (r *OrderRepo) GetOrdersByIDs(ctx context.Context, ids []string, preload bool) ([]*entity.Order, error) {
+	opts := []db.FindOption{
+		db.WithQuery(db.NewQuery("id IN ?", ids)),
+	}
+	if preload {
+		opts = append(opts, db.WithPreload([]string{"Lines", "Lines.Product"}))
+	}
+
+	var orders []*entity.Order
+	if err := r.db.Find(ctx, &orders, opts...); err != nil {
+		return nil, err
+	}
+
+	return orders, nil
+}
+
 func (r *OrderRepo) GetMyOrders(ctx context.Context, req *dto.ListOrdersRequest) ([]*entity.Order, *paging.Pagination, error) {
 	query := []db.Query{
 		db.NewQuery("user_id = ?", req.UserID),
@@ -87,6 +153,104 @@ func (r *OrderRepo) GetMyOrders(ctx context.Context, req *dto.ListOrdersRequest)
 	if req.Code != "" {
 		query = append(query, db.NewQuery("code = ?", req.Code))
 	}
+	if req.Status != nil {
+		query = append(query, db.NewQuery("status = ?", *req.Status))
+	}
+	if req.From != nil {
+		query = append(query, db.NewQuery("created_at >= ?", *req.From))
+	}
+	if req.To != nil {
+		query = append(query, db.NewQuery("created_at <= ?", *req.To))
+	}
+
+	if req.Cursor != nil {
+		return r.listOrdersByCursor(ctx, query, req.Cursor)
+	}
+
+	sortBy := dto.SortByCreatedAt
+	if req.SortBy != "" {
+		sortBy = req.SortBy
+	}
+	sortDir := "DESC"
+	if req.SortDir == dto.SortDirAsc {
+		sortDir = "ASC"
+	}
+
+	return r.listOrders(ctx, query, req.Page, req.Limit, sortBy+" "+sortDir)
+}
+
+// listOrdersByCursor returns the page of orders right after (older than) or
+// right before (newer than) the cursor, newest first. It fetches one extra row
+// to tell whether another page follows; no total count is taken. The returned
+// pagination's NextCursor is empty on the last page.
+func (r *OrderRepo) listOrdersByCursor(ctx context.Context, query []db.Query, cursor *paging.CursorPagination) ([]*entity.Order, *paging.Pagination, error) {
+	order := "created_at DESC, id DESC"
+	backward := false
+	switch {
+	case cursor.After != "":
+		id, ts, err := paging.DecodeCursor(cursor.After)
+		if err != nil {
+			return nil, nil, err
+		}
+		query = append(query, db.NewQuery("(created_at < ? OR (created_at = ? AND id < ?))", ts, ts, id))
+	case cursor.Before != "":
+		id, ts, err := paging.DecodeCursor(cursor.Before)
+		if err != nil {
+			return nil, nil, err
+		}
+		query = append(query, db.NewQuery("(created_at > ? OR (created_at = ? AND id > ?))", ts, ts, id))
+		order = "created_at ASC, id ASC"
+		backward = true
+	}
+
+	var orders []*entity.Order
+	if err := r.db.Find(
+		ctx,
+		&orders,
+		db.WithPreload([]string{"Lines", "Lines.Product"}),
+		db.WithQuery(query...),
+		db.WithLimit(cursor.Limit+1),
+		db.WithOrder(order),
+	); err != nil {
+		return nil, nil, err
+	}
+
+	more := len(orders) > cursor.Limit
+	if more {
+		orders = orders[:cursor.Limit]
+	}
+
+	pagination := &paging.Pagination{Size: int64(cursor.Limit)}
+	if backward {
+		for i, j := 0, len(orders)-1; i < j; i, j = i+1, j-1 {
+			orders[i], orders[j] = orders[j], orders[i]
+		}
+		pagination.HasPrevious = more
+		pagination.HasNext = true
+	} else {
+		pagination.HasPrevious = cursor.After != ""
+		pagination.HasNext = more
+	}
+
+	if pagination.HasNext && len(orders) > 0 {
+		last := orders[len(orders)-1]
+		pagination.NextCursor = paging.EncodeCursor(last.ID, last.CreatedAt)
+	}
+
+	return orders, pagination, nil
+}
+
+// ListAllOrders is GetMyOrders without the per-user filter. A non-empty
+// req.UserID still narrows the listing to that user. req.OrderBy goes into the
+// SQL as is and must be checked by the caller.
+func (r *OrderRepo) ListAllOrders(ctx context.Context, req *dto.AdminListOrdersRequest) ([]*entity.Order, *paging.Pagination, error) {
+	var query []db.Query
+	if req.UserID != "" {
+		query = append(query, db.NewQuery("user_id = ?", req.UserID))
+	}
+	if req.Code != "" {
+		query = append(query, db.NewQuery("code = ?", req.Code))
+	}
 	if req.Status != "" {
 		query = append(query, db.NewQuery("status = ?", req.Status))
 	}
@@ -99,12 +263,52 @@ func (r *OrderRepo) GetMyOrders(ctx context.Context, req *dto.ListOrdersRequest)
 		}
 	}
 
+	return r.listOrders(ctx, query, req.Page, req.Limit, order)
+}
+
+// StreamOrders calls fn for each of the user's orders matching req, oldest
+// first. Rows are scanned one at a time rather than loaded up front; an error
+// from fn stops the stream and is returned. Lines are not loaded.
+func (r *OrderRepo) StreamOrders(ctx context.Context, req *dto.OrderExportRequest, fn func(*entity.Order) error) error {
+	query := r.db.Conn(ctx).
+		Model(&entity.Order{}).
+		Where("user_id = ?", req.UserID)
+	if req.Status != nil {
+		query = query.Where("status = ?", *req.Status)
+	}
+	if req.From != nil {
+		query = query.Where("created_at >= ?", *req.From)
+	}
+	if req.To != nil {
+		query = query.Where("created_at <= ?", *req.To)
+	}
+
+	rows, err := query.Order("created_at ASC").Rows()
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var order entity.Order
+		if err := query.ScanRows(rows, &order); err != nil {
+			return err
+		}
+		if err := fn(&order); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+func (r *OrderRepo) listOrders(ctx context.Context, query []db.Query, page, limit int64, order string) ([]*entity.Order, *paging.Pagination, error) {
 	var total int64
 	if err := r.db.Count(ctx, &entity.Order{}, &total, db.WithQuery(query...)); err != nil {
 		return nil, nil, err
 	}
 
-	pagination := paging.NewPagination(req.Page, req.Limit, total)
+	pagination := paging.NewPagination(page, limit, total)
 
 	var orders []*entity.Order
 	if err := r.db.Find(
@@ -122,6 +326,390 @@ func (r *OrderRepo) GetMyOrders(ctx context.Context, req *dto.ListOrdersRequest)
 	return orders, pagination, nil
 }
 
+// SoftDeleteOrder stamps the order's DeletedAt. The row stays in the table but
+// GORM leaves it out of every later query.
+func (r *OrderRepo) SoftDeleteOrder(ctx context.Context, order *entity.Order) error {
+	return r.db.Delete(ctx, order)
+}
+
 func (r *OrderRepo) UpdateOrder(ctx context.Context, order *entity.Order) error {
 	return r.db.Update(ctx, order)
 }
+
+// WithinTransaction runs fn in a database transaction. Repository calls made
+// with the ctx passed to fn join that transaction, including those of other
+// repositories.
+func (r *OrderRepo) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	return r.db.WithTransaction(ctx, fn)
+}
+
+func (r *OrderRepo) GetShippingAddress(ctx context.Context, addressID string) (*addressEntity.Address, error) {
+	var address addressEntity.Address
+	if err := r.db.FindById(ctx, addressID, &address); err != nil {
+		return nil, err
+	}
+
+	return &address, nil
+}
+
+func (r *OrderRepo) GetDiscount(ctx context.Context, discountID string) (*discountEntity.Discount, error) {
+	var discount discountEntity.Discount
+	if err := r.db.FindById(ctx, discountID, &discount); err != nil {
+		return nil, err
+	}
+
+	return &discount, nil
+}
+
+func (r *OrderRepo) GetDiscountByCode(ctx context.Context, code string) (*discountEntity.Discount, error) {
+	var discount discountEntity.Discount
+	if err := r.db.FindOne(ctx, &discount, db.WithQuery(db.NewQuery("code = ?", code))); err != nil {
+		return nil, err
+	}
+
+	return &discount, nil
+}
+
+func (r *OrderRepo) GetStatusHistory(ctx context.Context, orderID string) ([]entity.OrderStatusHistory, error) {
+	var history []entity.OrderStatusHistory
+	if err := r.db.Find(
+		ctx,
+		&history,
+		db.WithQuery(db.NewQuery("order_id = ?", orderID)),
+		db.WithOrder("changed_at ASC"),
+	); err != nil {
+		return nil, err
+	}
+
+	return history, nil
+}
+
+func (r *OrderRepo) CreateAuditLog(ctx context.Context, log *entity.OrderAuditLog) error {
+	return r.db.Create(ctx, log)
+}
+
+// SplitOrder creates split, moves its lines over from original and saves the
+// original's new total, all in one transaction.
+func (r *OrderRepo) SplitOrder(ctx context.Context, original *entity.Order, split *entity.Order) error {
+	handler := func(ctx context.Context) error {
+		lines := split.Lines
+		split.Lines = nil
+		if err := r.db.Create(ctx, split); err != nil {
+			return err
+		}
+		split.Lines = lines
+
+		for _, line := range lines {
+			line.OrderID = split.ID
+			if err := r.db.Update(ctx, line); err != nil {
+				return err
+			}
+		}
+
+		return r.db.Update(ctx, original)
+	}
+
+	return r.db.WithTransaction(ctx, handler)
+}
+
+// UpdateOrderWithLines saves every line of order and then the order itself in
+// one transaction.
+func (r *OrderRepo) UpdateOrderWithLines(ctx context.Context, order *entity.Order) error {
+	handler := func(ctx context.Context) error {
+		for _, line := range order.Lines {
+			if err := r.db.Update(ctx, line); err != nil {
+				return err
+			}
+		}
+
+		return r.db.Update(ctx, order)
+	}
+
+	return r.db.WithTransaction(ctx, handler)
+}
+
+func (r *OrderRepo) GetOpenOrdersContainingProduct(ctx context.Context, productID string) ([]*entity.Order, error) {
+	ctx, cancel := context.WithTimeout(ctx, configs.DatabaseTimeout)
+	defer cancel()
+
+	var orders []*entity.Order
+	err := r.db.Conn(ctx).
+		Preload("Lines").
+		Joins("JOIN order_lines ON order_lines.order_id = orders.id AND order_lines.deleted_at IS NULL").
+		Where("order_lines.product_id = ?", productID).
+		Where("orders.status IN ?", []utils.OrderStatus{utils.OrderStatusNew, utils.OrderStatusInProgress}).
+		Distinct("orders.*").
+		Order("orders.created_at ASC").
+		Find(&orders).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return orders, nil
+}
+
+// AverageOrderValue returns the mean total of done orders created since the
+// given time, or 0 when there are none.
+func (r *OrderRepo) AverageOrderValue(ctx context.Context, since time.Time) (float64, error) {
+	ctx, cancel := context.WithTimeout(ctx, configs.DatabaseTimeout)
+	defer cancel()
+
+	var avg *float64
+	err := r.db.Conn(ctx).
+		Model(&entity.Order{}).
+		Select("AVG(total_price)").
+		Where("status = ? AND created_at >= ?", utils.OrderStatusDone, since).
+		Scan(&avg).Error
+	if err != nil {
+		return 0, err
+	}
+
+	if avg == nil {
+		return 0, nil
+	}
+
+	return *avg, nil
+}
+
+// GetOrderTotalsByStatus aggregates orders created in [from, to) per status.
+// PaidTotal only counts orders that have been paid.
+func (r *OrderRepo) GetOrderTotalsByStatus(ctx context.Context, from, to time.Time) ([]*entity.StatusTotals, error) {
+	ctx, cancel := context.WithTimeout(ctx, configs.DatabaseTimeout)
+	defer cancel()
+
+	var totals []*entity.StatusTotals
+	err := r.db.Conn(ctx).
+		Model(&entity.Order{}).
+		Select("status, COUNT(*) AS orders, COALESCE(SUM(total_price), 0) AS total, "+
+			"COALESCE(SUM(CASE WHEN paid_at IS NOT NULL THEN total_price ELSE 0 END), 0) AS paid_total").
+		Where("created_at >= ? AND created_at < ?", from, to).
+		Group("status").
+		Scan(&totals).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return totals, nil
+}
+
+// GetRevenueByProduct ranks products by the revenue of their lines in done
+// orders created since the given time.
+func (r *OrderRepo) GetRevenueByProduct(ctx context.Context, since time.Time, limit int) ([]*entity.ProductRevenue, error) {
+	ctx, cancel := context.WithTimeout(ctx, configs.DatabaseTimeout)
+	defer cancel()
+
+	var revenue []*entity.ProductRevenue
+	err := r.db.Conn(ctx).
+		Table("order_lines AS ol").
+		Select("p.id AS product_id, p.name AS product_name, "+
+			"SUM(ol.quantity) AS units_sold, SUM(ol.price) AS total_revenue").
+		Joins("JOIN products AS p ON p.id = ol.product_id").
+		Joins("JOIN orders AS o ON o.id = ol.order_id AND o.deleted_at IS NULL").
+		Where("ol.deleted_at IS NULL").
+		Where("o.status = ? AND o.created_at >= ?", utils.OrderStatusDone, since).
+		Group("p.id, p.name").
+		Order("total_revenue DESC").
+		Limit(limit).
+		Scan(&revenue).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return revenue, nil
+}
+
+// GetRepeatCustomers returns the users with at least minOrders orders whose
+// first order was placed since the given time, most orders first.
+func (r *OrderRepo) GetRepeatCustomers(ctx context.Context, minOrders int, since time.Time, limit int) ([]*entity.RepeatCustomer, error) {
+	ctx, cancel := context.WithTimeout(ctx, configs.DatabaseTimeout)
+	defer cancel()
+
+	var customers []*entity.RepeatCustomer
+	err := r.db.Conn(ctx).
+		Model(&entity.Order{}).
+		Select("user_id, COUNT(*) AS order_count, COALESCE(SUM(total_price), 0) AS total_spend, "+
+			"MIN(created_at) AS first_order_at, MAX(created_at) AS last_order_at").
+		Group("user_id").
+		Having("COUNT(*) >= ? AND MIN(created_at) >= ?", minOrders, since).
+		Order("order_count DESC, total_spend DESC").
+		Limit(limit).
+		Scan(&customers).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return customers, nil
+}
+
+// GetUserOrderStats aggregates all of the user's orders in one query. Spend
+// only counts done orders.
+func (r *OrderRepo) GetUserOrderStats(ctx context.Context, userID string) (*entity.UserOrderStats, error) {
+	ctx, cancel := context.WithTimeout(ctx, configs.DatabaseTimeout)
+	defer cancel()
+
+	var stats entity.UserOrderStats
+	err := r.db.Conn(ctx).
+		Model(&entity.Order{}).
+		Select("COUNT(*) AS total_orders, "+
+			"COALESCE(SUM(CASE WHEN status = ? THEN 1 ELSE 0 END), 0) AS completed_orders, "+
+			"COALESCE(SUM(CASE WHEN status = ? THEN 1 ELSE 0 END), 0) AS canceled_orders, "+
+			"COALESCE(SUM(CASE WHEN status = ? THEN total_price ELSE 0 END), 0) AS total_spend, "+
+			"MIN(created_at) AS first_order_at, MAX(created_at) AS last_order_at",
+			utils.OrderStatusDone, utils.OrderStatusCanceled, utils.OrderStatusDone).
+		Where("user_id = ?", userID).
+		Scan(&stats).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return &stats, nil
+}
+
+// AggregateOrderStats counts and sums the user's orders per status. An empty
+// userID aggregates over every user.
+func (r *OrderRepo) AggregateOrderStats(ctx context.Context, userID string) (*entity.OrderStats, error) {
+	ctx, cancel := context.WithTimeout(ctx, configs.DatabaseTimeout)
+	defer cancel()
+
+	query := r.db.Conn(ctx).
+		Model(&entity.Order{}).
+		Select("status, COUNT(*) AS orders, COALESCE(SUM(total_price), 0) AS total")
+	if userID != "" {
+		query = query.Where("user_id = ?", userID)
+	}
+
+	var totals []*entity.StatusTotals
+	if err := query.Group("status").Scan(&totals).Error; err != nil {
+		return nil, err
+	}
+
+	return &entity.OrderStats{ByStatus: totals}, nil
+}
+
+func (r *OrderRepo) CountOrdersByStatusSince(ctx context.Context, status utils.OrderStatus, since time.Time) (int64, error) {
+	var total int64
+	err := r.db.Count(ctx, &entity.Order{}, &total, db.WithQuery(
+		db.NewQuery("status = ?", status),
+		db.NewQuery("created_at >= ?", since),
+	))
+	if err != nil {
+		return 0, err
+	}
+
+	return total, nil
+}
+
+func (r *OrderRepo) CountNonCanceledOrdersSince(ctx context.Context, since time.Time) (int64, error) {
+	var total int64
+	err := r.db.Count(ctx, &entity.Order{}, &total, db.WithQuery(
+		db.NewQuery("status <> ?", utils.OrderStatusCanceled),
+		db.NewQuery("created_at >= ?", since),
+	))
+	if err != nil {
+		return 0, err
+	}
+
+	return total, nil
+}
+
+func (r *OrderRepo) GetOrdersByShippingAddress(ctx context.Context, addressID string, req *paging.Pagination) ([]*entity.Order, *paging.Pagination, error) {
+	query := db.NewQuery("shipping_address_id = ?", addressID)
+
+	var orders []*entity.Order
+	pagination, err := db.FindPage(
+		ctx,
+		r.db,
+		&entity.Order{},
+		&orders,
+		req,
+		db.WithPreload([]string{"Lines", "Lines.Product"}),
+		db.WithQuery(query),
+		db.WithOrder("created_at DESC"),
+	)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return orders, pagination, nil
+}
+
+func (r *OrderRepo) GetOrdersByPaymentStatus(ctx context.Context, isPaid bool, req *paging.Pagination) ([]*entity.Order, *paging.Pagination, error) {
+	query := db.NewQuery("(payment_id IS NOT NULL) = ?", isPaid)
+
+	var orders []*entity.Order
+	pagination, err := db.FindPage(
+		ctx,
+		r.db,
+		&entity.Order{},
+		&orders,
+		req,
+		db.WithQuery(query),
+		db.WithOrder("created_at DESC"),
+	)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return orders, pagination, nil
+}
+
+func (r *OrderRepo) SumOrdersByPaymentStatus(ctx context.Context, isPaid bool) (float64, error) {
+	ctx, cancel := context.WithTimeout(ctx, configs.DatabaseTimeout)
+	defer cancel()
+
+	var total float64
+	err := r.db.Conn(ctx).
+		Model(&entity.Order{}).
+		Select("COALESCE(SUM(total_price), 0)").
+		Where("(payment_id IS NOT NULL) = ?", isPaid).
+		Scan(&total).Error
+	if err != nil {
+		return 0, err
+	}
+
+	return total, nil
+}
+
+// GetOrdersByTag pages through the orders tagged with tag. Tags are stored as
+// a JSON array in a text column, so the match is on the quoted element, which
+// keeps "sale" from matching "sales".
+func (r *OrderRepo) GetOrdersByTag(ctx context.Context, tag string, req *paging.Pagination) ([]*entity.Order, *paging.Pagination, error) {
+	element, err := json.Marshal(tag)
+	if err != nil {
+		return nil, nil, err
+	}
+	query := db.NewQuery(`tags LIKE ? ESCAPE '\'`, "%"+tagLikeEscaper.Replace(string(element))+"%")
+
+	var orders []*entity.Order
+	pagination, err := db.FindPage(
+		ctx,
+		r.db,
+		&entity.Order{},
+		&orders,
+		req,
+		db.WithQuery(query),
+		db.WithOrder("created_at DESC"),
+	)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return orders, pagination, nil
+}
+
+// GetOrdersByExternalRef returns the orders placed through a third-party
+// platform under ref, oldest first, with their lines.
+func (r *OrderRepo) GetOrdersByExternalRef(ctx context.Context, ref string) ([]*entity.Order, error) {
+	var orders []*entity.Order
+	opts := []db.FindOption{
+		db.WithQuery(db.NewQuery("external_ref = ?", ref)),
+		db.WithPreload([]string{"Lines"}),
+		db.WithOrder("created_at ASC"),
+	}
+
+	if err := r.db.Find(ctx, &orders, opts...); err != nil {
+		return nil, err
+	}
+
+	return orders, nil
+}
@@ -0,0 +1,38 @@
+package repository
+
+import (
+	"context"
+	"ecommerce_clean/db"
+	"ecommerce_clean/internals/order/entity"
+)
+
+type IOrderNoteRepository interface {
+	CreateNote(ctx context.Context, note *entity.OrderNote) error
+	ListNotes(ctx context.Context, orderID string) ([]*entity.OrderNote, error)
+}
+
+type OrderNoteRepository struct {
+	db db.IDatabase
+}
+
+func NewOrderNoteRepository(db db.IDatabase) *OrderNoteRepository {
+	return &OrderNoteRepository{db: db}
+}
+
+func (r *OrderNoteRepository) CreateNote(ctx context.Context, note *entity.OrderNote) error {
+	return r.db.Create(ctx, note)
+}
+
+func (r *OrderNoteRepository) ListNotes(ctx context.Context, orderID string) ([]*entity.OrderNote, error) {
+	var notes []*entity.OrderNote
+	opts := []db.FindOption{
+		db.WithQuery(db.NewQuery("order_id = ?", orderID)),
+		db.WithOrder("created_at ASC"),
+	}
+
+	if err := r.db.Find(ctx, &notes, opts...); err != nil {
+		return nil, err
+	}
+
+	return notes, nil
+}
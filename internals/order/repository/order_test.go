@@ -0,0 +1,659 @@
+package repository_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"ecommerce_clean/db"
+	"ecommerce_clean/db/dbtest"
+	"ecommerce_clean/internals/order/controller/dto"
+	orderEntity "ecommerce_clean/internals/order/entity"
+	"ecommerce_clean/internals/order/repository"
+	productEntity "ecommerce_clean/internals/product/entity"
+	"ecommerce_clean/pkgs/paging"
+	"ecommerce_clean/utils"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func newTestDatabase(t *testing.T) *db.Database {
+	return dbtest.NewDatabase(t,
+		&productEntity.Category{},
+		&productEntity.Product{},
+		&orderEntity.Order{},
+		&orderEntity.OrderLine{},
+		&orderEntity.OrderStatusHistory{},
+	)
+}
+
+func seedOrder(t *testing.T, database *db.Database, status utils.OrderStatus, productIDs ...string) *orderEntity.Order {
+	order := &orderEntity.Order{UserID: "u1", Status: status}
+	require.NoError(t, database.Create(context.Background(), order))
+
+	for _, productID := range productIDs {
+		line := &orderEntity.OrderLine{OrderID: order.ID, ProductID: productID, Quantity: 1, Price: 10}
+		require.NoError(t, database.Create(context.Background(), line))
+	}
+	return order
+}
+
+// TestGetOpenOrdersContainingProduct verifica que solo se devuelven las órdenes
+// abiertas (new / progress) que contienen el producto, con sus líneas.
+func TestGetOpenOrdersContainingProduct(t *testing.T) {
+	database := newTestDatabase(t)
+	repo := repository.NewOrderRepository(database)
+
+	newOrder := seedOrder(t, database, utils.OrderStatusNew, "p1", "p2")
+	inProgress := seedOrder(t, database, utils.OrderStatusInProgress, "p1")
+	seedOrder(t, database, utils.OrderStatusDone, "p1")
+	seedOrder(t, database, utils.OrderStatusNew, "p3")
+
+	orders, err := repo.GetOpenOrdersContainingProduct(context.Background(), "p1")
+
+	require.NoError(t, err)
+	ids := make([]string, 0, len(orders))
+	for _, order := range orders {
+		ids = append(ids, order.ID)
+	}
+	assert.ElementsMatch(t, []string{newOrder.ID, inProgress.ID}, ids)
+	for _, order := range orders {
+		if order.ID == newOrder.ID {
+			assert.Len(t, order.Lines, 2)
+		} else {
+			assert.Len(t, order.Lines, 1)
+		}
+	}
+}
+
+func seedOrderWithTotal(t *testing.T, database *db.Database, status utils.OrderStatus, total float64, createdAt time.Time) {
+	order := &orderEntity.Order{UserID: "u1", Status: status, TotalPrice: total, CreatedAt: createdAt}
+	require.NoError(t, database.Create(context.Background(), order))
+}
+
+// TestAverageOrderValue_NoOrders verifica que sin pedidos completados se
+// devuelve 0 sin error.
+func TestAverageOrderValue_NoOrders(t *testing.T) {
+	database := newTestDatabase(t)
+	repo := repository.NewOrderRepository(database)
+
+	seedOrderWithTotal(t, database, utils.OrderStatusNew, 100, time.Now())
+
+	avg, err := repo.AverageOrderValue(context.Background(), time.Now().AddDate(0, -1, 0))
+
+	require.NoError(t, err)
+	assert.Equal(t, 0.0, avg)
+}
+
+// TestAverageOrderValue_InRange verifica que solo se promedian los pedidos
+// completados dentro del periodo.
+func TestAverageOrderValue_InRange(t *testing.T) {
+	database := newTestDatabase(t)
+	repo := repository.NewOrderRepository(database)
+
+	now := time.Now()
+	seedOrderWithTotal(t, database, utils.OrderStatusDone, 10, now.Add(-time.Hour))
+	seedOrderWithTotal(t, database, utils.OrderStatusDone, 30, now.Add(-2*time.Hour))
+	seedOrderWithTotal(t, database, utils.OrderStatusDone, 1000, now.AddDate(0, -2, 0))
+	seedOrderWithTotal(t, database, utils.OrderStatusCanceled, 500, now.Add(-time.Hour))
+
+	avg, err := repo.AverageOrderValue(context.Background(), now.AddDate(0, -1, 0))
+
+	require.NoError(t, err)
+	assert.InDelta(t, 20.0, avg, 0.001)
+}
+
+// TestAverageOrderValue_OutOfRange verifica que los pedidos anteriores al
+// periodo no cuentan.
+func TestAverageOrderValue_OutOfRange(t *testing.T) {
+	database := newTestDatabase(t)
+	repo := repository.NewOrderRepository(database)
+
+	seedOrderWithTotal(t, database, utils.OrderStatusDone, 50, time.Now().AddDate(0, -3, 0))
+
+	avg, err := repo.AverageOrderValue(context.Background(), time.Now().AddDate(0, -1, 0))
+
+	require.NoError(t, err)
+	assert.Equal(t, 0.0, avg)
+}
+
+// TestGetOrderTotalsByStatus verifica la agregación por estado dentro del
+// periodo, contando en PaidTotal solo los pedidos pagados.
+func TestGetOrderTotalsByStatus(t *testing.T) {
+	database := newTestDatabase(t)
+	repo := repository.NewOrderRepository(database)
+
+	from := time.Date(2024, time.March, 1, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 1, 0)
+	seedOrderWithTotal(t, database, utils.OrderStatusDone, 10, from.Add(time.Hour))
+	seedOrderWithTotal(t, database, utils.OrderStatusDone, 20, from.Add(2*time.Hour))
+	seedOrderWithTotal(t, database, utils.OrderStatusDone, 999, to.Add(time.Hour))
+
+	paidAt := from.Add(time.Hour)
+	paid := &orderEntity.Order{UserID: "u1", Status: utils.OrderStatusCanceled, TotalPrice: 15, PaidAt: &paidAt, CreatedAt: from.Add(time.Hour)}
+	require.NoError(t, database.Create(context.Background(), paid))
+	seedOrderWithTotal(t, database, utils.OrderStatusCanceled, 5, from.Add(time.Hour))
+
+	totals, err := repo.GetOrderTotalsByStatus(context.Background(), from, to)
+
+	require.NoError(t, err)
+	byStatus := make(map[utils.OrderStatus]*orderEntity.StatusTotals, len(totals))
+	for _, row := range totals {
+		byStatus[row.Status] = row
+	}
+	assert.Len(t, byStatus, 2)
+	assert.Equal(t, 2, byStatus[utils.OrderStatusDone].Orders)
+	assert.InDelta(t, 30.0, byStatus[utils.OrderStatusDone].Total, 0.001)
+	assert.Equal(t, 2, byStatus[utils.OrderStatusCanceled].Orders)
+	assert.InDelta(t, 20.0, byStatus[utils.OrderStatusCanceled].Total, 0.001)
+	assert.InDelta(t, 15.0, byStatus[utils.OrderStatusCanceled].PaidTotal, 0.001)
+}
+
+// TestGetOrdersByPaymentStatus verifica que se filtra por payment_id, que
+// IsPaid se deriva al leer y que la suma cubre todos los pedidos del filtro.
+func TestGetOrdersByPaymentStatus(t *testing.T) {
+	database := newTestDatabase(t)
+	repo := repository.NewOrderRepository(database)
+
+	now := time.Now()
+	paymentID := "pay-1"
+	paid := &orderEntity.Order{UserID: "u1", Status: utils.OrderStatusDone, TotalPrice: 40, PaymentID: &paymentID}
+	require.NoError(t, database.Create(context.Background(), paid))
+	seedOrderWithTotal(t, database, utils.OrderStatusNew, 15, now)
+	seedOrderWithTotal(t, database, utils.OrderStatusNew, 5, now)
+
+	orders, pagination, err := repo.GetOrdersByPaymentStatus(context.Background(), true, nil)
+	require.NoError(t, err)
+	require.Len(t, orders, 1)
+	assert.Equal(t, paid.ID, orders[0].ID)
+	assert.True(t, orders[0].IsPaid)
+	assert.Equal(t, int64(1), pagination.TotalCount)
+
+	orders, pagination, err = repo.GetOrdersByPaymentStatus(context.Background(), false, nil)
+	require.NoError(t, err)
+	assert.Len(t, orders, 2)
+	assert.False(t, orders[0].IsPaid)
+	assert.Equal(t, int64(2), pagination.TotalCount)
+
+	total, err := repo.SumOrdersByPaymentStatus(context.Background(), false)
+	require.NoError(t, err)
+	assert.InDelta(t, 20.0, total, 0.001)
+}
+
+// TestGetOrdersByTag verifica que solo coinciden los pedidos con la etiqueta
+// exacta, no los que tienen una etiqueta que la contiene.
+func TestGetOrdersByTag(t *testing.T) {
+	database := newTestDatabase(t)
+	repo := repository.NewOrderRepository(database)
+
+	tagged := &orderEntity.Order{UserID: "u1", Tags: []string{"vip", "sale"}}
+	require.NoError(t, database.Create(context.Background(), tagged))
+	require.NoError(t, database.Create(context.Background(), &orderEntity.Order{UserID: "u1", Tags: []string{"sales"}}))
+	require.NoError(t, database.Create(context.Background(), &orderEntity.Order{UserID: "u1", Tags: []string{"sa_e"}}))
+	require.NoError(t, database.Create(context.Background(), &orderEntity.Order{UserID: "u1"}))
+
+	orders, pagination, err := repo.GetOrdersByTag(context.Background(), "sale", nil)
+
+	require.NoError(t, err)
+	require.Len(t, orders, 1)
+	assert.Equal(t, tagged.ID, orders[0].ID)
+	assert.Equal(t, []string{"vip", "sale"}, orders[0].Tags)
+	assert.Equal(t, int64(1), pagination.TotalCount)
+
+	orders, _, err = repo.GetOrdersByTag(context.Background(), "s_le", nil)
+
+	require.NoError(t, err)
+	assert.Empty(t, orders)
+}
+
+// TestGetRevenueByProduct verifica que solo cuentan las líneas de pedidos done
+// creados desde la fecha, que se suman unidades e importes por producto y que
+// el resultado va ordenado por ingresos.
+func TestGetRevenueByProduct(t *testing.T) {
+	database := newTestDatabase(t)
+	repo := repository.NewOrderRepository(database)
+	ctx := context.Background()
+
+	keyboard := &productEntity.Product{Name: "Teclado", Price: 30}
+	mouse := &productEntity.Product{Name: "Ratón", Price: 10}
+	cable := &productEntity.Product{Name: "Cable", Price: 5}
+	for _, p := range []*productEntity.Product{keyboard, mouse, cable} {
+		require.NoError(t, database.Create(ctx, p))
+	}
+
+	since := time.Now().Add(-time.Hour)
+	addOrder := func(status utils.OrderStatus, createdAt time.Time, lines ...*orderEntity.OrderLine) {
+		order := &orderEntity.Order{UserID: "u1", Status: status, CreatedAt: createdAt}
+		require.NoError(t, database.Create(ctx, order))
+		for _, line := range lines {
+			line.OrderID = order.ID
+			require.NoError(t, database.Create(ctx, line))
+		}
+	}
+
+	addOrder(utils.OrderStatusDone, time.Now(),
+		&orderEntity.OrderLine{ProductID: keyboard.ID, Quantity: 1, Price: 30},
+		&orderEntity.OrderLine{ProductID: mouse.ID, Quantity: 4, Price: 40},
+	)
+	addOrder(utils.OrderStatusDone, time.Now(),
+		&orderEntity.OrderLine{ProductID: keyboard.ID, Quantity: 2, Price: 60},
+		&orderEntity.OrderLine{ProductID: cable.ID, Quantity: 1, Price: 5},
+	)
+	addOrder(utils.OrderStatusCanceled, time.Now(),
+		&orderEntity.OrderLine{ProductID: cable.ID, Quantity: 100, Price: 500},
+	)
+	addOrder(utils.OrderStatusDone, since.Add(-time.Hour),
+		&orderEntity.OrderLine{ProductID: mouse.ID, Quantity: 100, Price: 1000},
+	)
+
+	revenue, err := repo.GetRevenueByProduct(ctx, since, 10)
+
+	require.NoError(t, err)
+	require.Len(t, revenue, 3)
+	assert.Equal(t, orderEntity.ProductRevenue{ProductID: keyboard.ID, ProductName: "Teclado", UnitsSold: 3, TotalRevenue: 90}, *revenue[0])
+	assert.Equal(t, orderEntity.ProductRevenue{ProductID: mouse.ID, ProductName: "Ratón", UnitsSold: 4, TotalRevenue: 40}, *revenue[1])
+	assert.Equal(t, orderEntity.ProductRevenue{ProductID: cable.ID, ProductName: "Cable", UnitsSold: 1, TotalRevenue: 5}, *revenue[2])
+
+	top, err := repo.GetRevenueByProduct(ctx, since, 1)
+	require.NoError(t, err)
+	require.Len(t, top, 1)
+	assert.Equal(t, keyboard.ID, top[0].ProductID)
+}
+
+// TestCountOrdersSince verifica los dos conteos de la tasa de cumplimiento:
+// pedidos completados y pedidos no cancelados desde la fecha dada.
+func TestCountOrdersSince(t *testing.T) {
+	database := newTestDatabase(t)
+	repo := repository.NewOrderRepository(database)
+	ctx := context.Background()
+
+	since := time.Now().Add(-24 * time.Hour)
+	seedOrderWithTotal(t, database, utils.OrderStatusDone, 10, since.Add(time.Hour))
+	seedOrderWithTotal(t, database, utils.OrderStatusDone, 10, since.Add(2*time.Hour))
+	seedOrderWithTotal(t, database, utils.OrderStatusNew, 10, since.Add(time.Hour))
+	seedOrderWithTotal(t, database, utils.OrderStatusCanceled, 10, since.Add(time.Hour))
+	seedOrderWithTotal(t, database, utils.OrderStatusDone, 10, since.Add(-time.Hour))
+
+	done, err := repo.CountOrdersByStatusSince(ctx, utils.OrderStatusDone, since)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), done)
+
+	nonCanceled, err := repo.CountNonCanceledOrdersSince(ctx, since)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), nonCanceled)
+}
+
+// TestListAllOrders verifica que sin filtro se listan los pedidos de todos los
+// usuarios y que el filtro de usuario los restringe.
+func TestListAllOrders(t *testing.T) {
+	database := newTestDatabase(t)
+	repo := repository.NewOrderRepository(database)
+	ctx := context.Background()
+
+	for _, userID := range []string{"u1", "u2", "u2"} {
+		require.NoError(t, database.Create(ctx, &orderEntity.Order{UserID: userID}))
+	}
+
+	orders, pagination, err := repo.ListAllOrders(ctx, &dto.AdminListOrdersRequest{})
+	require.NoError(t, err)
+	assert.Len(t, orders, 3)
+	assert.Equal(t, int64(3), pagination.TotalCount)
+
+	orders, pagination, err = repo.ListAllOrders(ctx, &dto.AdminListOrdersRequest{UserID: "u2"})
+	require.NoError(t, err)
+	assert.Len(t, orders, 2)
+	assert.Equal(t, int64(2), pagination.TotalCount)
+	for _, order := range orders {
+		assert.Equal(t, "u2", order.UserID)
+	}
+}
+
+// TestGetMyOrders_Filters verifica que el estado y el rango de fechas se
+// aplican solo cuando vienen informados.
+func TestGetMyOrders_Filters(t *testing.T) {
+	database := newTestDatabase(t)
+	repo := repository.NewOrderRepository(database)
+	ctx := context.Background()
+
+	now := time.Now()
+	seedOrderWithTotal(t, database, utils.OrderStatusNew, 10, now.Add(-48*time.Hour))
+	seedOrderWithTotal(t, database, utils.OrderStatusDone, 10, now.Add(-24*time.Hour))
+	seedOrderWithTotal(t, database, utils.OrderStatusNew, 10, now)
+
+	orders, _, err := repo.GetMyOrders(ctx, &dto.ListOrdersRequest{UserID: "u1"})
+	require.NoError(t, err)
+	assert.Len(t, orders, 3)
+
+	status := utils.OrderStatusNew
+	orders, _, err = repo.GetMyOrders(ctx, &dto.ListOrdersRequest{UserID: "u1", Status: &status})
+	require.NoError(t, err)
+	assert.Len(t, orders, 2)
+
+	from, to := now.Add(-36*time.Hour), now.Add(-time.Hour)
+	orders, _, err = repo.GetMyOrders(ctx, &dto.ListOrdersRequest{UserID: "u1", From: &from, To: &to})
+	require.NoError(t, err)
+	if assert.Len(t, orders, 1) {
+		assert.Equal(t, utils.OrderStatusDone, orders[0].Status)
+	}
+}
+
+// TestGetMyOrders_Sort verifica que por defecto se ordena por fecha
+// descendente y que se puede ordenar por importe ascendente.
+func TestGetMyOrders_Sort(t *testing.T) {
+	database := newTestDatabase(t)
+	repo := repository.NewOrderRepository(database)
+	ctx := context.Background()
+
+	now := time.Now()
+	seedOrderWithTotal(t, database, utils.OrderStatusNew, 30, now.Add(-2*time.Hour))
+	seedOrderWithTotal(t, database, utils.OrderStatusNew, 10, now.Add(-time.Hour))
+	seedOrderWithTotal(t, database, utils.OrderStatusNew, 20, now)
+
+	totals := func(orders []*orderEntity.Order) []float64 {
+		res := make([]float64, 0, len(orders))
+		for _, order := range orders {
+			res = append(res, order.TotalPrice)
+		}
+		return res
+	}
+
+	orders, _, err := repo.GetMyOrders(ctx, &dto.ListOrdersRequest{UserID: "u1"})
+	require.NoError(t, err)
+	assert.Equal(t, []float64{20, 10, 30}, totals(orders))
+
+	orders, _, err = repo.GetMyOrders(ctx, &dto.ListOrdersRequest{UserID: "u1", SortBy: dto.SortByTotalPrice, SortDir: dto.SortDirAsc})
+	require.NoError(t, err)
+	assert.Equal(t, []float64{10, 20, 30}, totals(orders))
+}
+
+// TestGetOrdersByIDs verifica que solo se devuelven las órdenes existentes de
+// la lista, con sus líneas si se pide precarga.
+func TestGetOrdersByIDs(t *testing.T) {
+	database := newTestDatabase(t)
+	repo := repository.NewOrderRepository(database)
+
+	first := seedOrder(t, database, utils.OrderStatusNew, "p1")
+	second := seedOrder(t, database, utils.OrderStatusDone)
+	seedOrder(t, database, utils.OrderStatusNew)
+
+	orders, err := repo.GetOrdersByIDs(context.Background(), []string{first.ID, second.ID, "missing"}, true)
+
+	require.NoError(t, err)
+	ids := make([]string, 0, len(orders))
+	for _, order := range orders {
+		ids = append(ids, order.ID)
+		if order.ID == first.ID {
+			assert.Len(t, order.Lines, 1)
+		}
+	}
+	assert.ElementsMatch(t, []string{first.ID, second.ID}, ids)
+}
+
+// TestCreateAuditLog verifica que las entradas de auditoría se leen como
+// historial de estados, de la más antigua a la más reciente.
+func TestCreateAuditLog(t *testing.T) {
+	database := newTestDatabase(t)
+	repo := repository.NewOrderRepository(database)
+	ctx := context.Background()
+
+	now := time.Now()
+	require.NoError(t, repo.CreateAuditLog(ctx, &orderEntity.OrderAuditLog{
+		OrderID: "o1", FromStatus: utils.OrderStatusInProgress, ToStatus: utils.OrderStatusDone, ChangedBy: "u1", ChangedAt: now,
+	}))
+	require.NoError(t, repo.CreateAuditLog(ctx, &orderEntity.OrderAuditLog{
+		OrderID: "o1", FromStatus: utils.OrderStatusNew, ToStatus: utils.OrderStatusInProgress, ChangedBy: "u1", ChangedAt: now.Add(-time.Hour),
+	}))
+	require.NoError(t, repo.CreateAuditLog(ctx, &orderEntity.OrderAuditLog{
+		OrderID: "o2", FromStatus: utils.OrderStatusNew, ToStatus: utils.OrderStatusCanceled, ChangedBy: "u2", ChangedAt: now,
+	}))
+
+	history, err := repo.GetStatusHistory(ctx, "o1")
+
+	require.NoError(t, err)
+	if assert.Len(t, history, 2) {
+		assert.Equal(t, utils.OrderStatusInProgress, history[0].ToStatus)
+		assert.Equal(t, utils.OrderStatusDone, history[1].ToStatus)
+		assert.NotEmpty(t, history[0].ID)
+	}
+}
+
+// TestOrder_DeliveryAddressRoundTrip verifica que la dirección de entrega y
+// las notas del cliente se guardan y se leen sin cambios.
+func TestOrder_DeliveryAddressRoundTrip(t *testing.T) {
+	database := newTestDatabase(t)
+	repo := repository.NewOrderRepository(database)
+	ctx := context.Background()
+
+	address := orderEntity.Address{Street: "Calle Mayor 1", City: "Madrid", PostalCode: "28013", Country: "ES"}
+	order := &orderEntity.Order{UserID: "u1", CustomerNotes: "Leave at the door", DeliveryAddress: address}
+	require.NoError(t, database.Create(ctx, order))
+
+	stored, err := repo.GetOrderByID(ctx, order.ID, false)
+
+	require.NoError(t, err)
+	assert.Equal(t, address, stored.DeliveryAddress)
+	assert.Equal(t, "Leave at the door", stored.CustomerNotes)
+}
+
+// TestFindOrderByIdempotencyKey verifica que se encuentra el pedido por su
+// clave y que una clave desconocida devuelve gorm.ErrRecordNotFound.
+func TestFindOrderByIdempotencyKey(t *testing.T) {
+	database := newTestDatabase(t)
+	repo := repository.NewOrderRepository(database)
+	ctx := context.Background()
+
+	key := "k1"
+	order := &orderEntity.Order{UserID: "u1", IdempotencyKey: &key}
+	require.NoError(t, database.Create(ctx, order))
+	seedOrder(t, database, utils.OrderStatusNew)
+
+	found, err := repo.FindOrderByIdempotencyKey(ctx, "k1")
+	require.NoError(t, err)
+	assert.Equal(t, order.ID, found.ID)
+
+	_, err = repo.FindOrderByIdempotencyKey(ctx, "unknown")
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+}
+
+// TestSoftDeleteOrder verifica que una orden borrada desaparece del listado y
+// de la búsqueda por ID, pero sigue en la tabla.
+func TestSoftDeleteOrder(t *testing.T) {
+	database := newTestDatabase(t)
+	repo := repository.NewOrderRepository(database)
+	ctx := context.Background()
+
+	kept := seedOrder(t, database, utils.OrderStatusDone)
+	deleted := seedOrder(t, database, utils.OrderStatusDone)
+
+	require.NoError(t, repo.SoftDeleteOrder(ctx, deleted))
+
+	orders, pagination, err := repo.GetMyOrders(ctx, &dto.ListOrdersRequest{UserID: "u1"})
+	require.NoError(t, err)
+	if assert.Len(t, orders, 1) {
+		assert.Equal(t, kept.ID, orders[0].ID)
+	}
+	assert.Equal(t, int64(1), pagination.TotalCount)
+
+	_, err = repo.GetOrderByID(ctx, deleted.ID, true)
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+
+	var stored orderEntity.Order
+	require.NoError(t, database.GetDB().Unscoped().First(&stored, "id = ?", deleted.ID).Error)
+	assert.NotNil(t, stored.DeletedAt)
+}
+
+// TestStreamOrders verifica que se recorren las órdenes del usuario que pasan
+// los filtros, de la más antigua a la más reciente, y que un error del
+// callback corta el recorrido.
+func TestStreamOrders(t *testing.T) {
+	database := newTestDatabase(t)
+	repo := repository.NewOrderRepository(database)
+	ctx := context.Background()
+
+	now := time.Now()
+	seedOrderWithTotal(t, database, utils.OrderStatusNew, 3, now)
+	seedOrderWithTotal(t, database, utils.OrderStatusDone, 1, now.Add(-48*time.Hour))
+	seedOrderWithTotal(t, database, utils.OrderStatusNew, 2, now.Add(-24*time.Hour))
+	require.NoError(t, database.Create(ctx, &orderEntity.Order{UserID: "u2", Status: utils.OrderStatusNew}))
+
+	var totals []float64
+	err := repo.StreamOrders(ctx, &dto.OrderExportRequest{UserID: "u1"}, func(o *orderEntity.Order) error {
+		totals = append(totals, o.TotalPrice)
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []float64{1, 2, 3}, totals)
+
+	status := utils.OrderStatusNew
+	from := now.Add(-36 * time.Hour)
+	totals = nil
+	err = repo.StreamOrders(ctx, &dto.OrderExportRequest{UserID: "u1", Status: &status, From: &from}, func(o *orderEntity.Order) error {
+		totals = append(totals, o.TotalPrice)
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []float64{2, 3}, totals)
+
+	stop := errors.New("stop")
+	calls := 0
+	err = repo.StreamOrders(ctx, &dto.OrderExportRequest{UserID: "u1"}, func(o *orderEntity.Order) error {
+		calls++
+		return stop
+	})
+	assert.ErrorIs(t, err, stop)
+	assert.Equal(t, 1, calls)
+}
+
+// TestAggregateOrderStats verifica el conteo y la suma por estado, tanto para
+// un usuario como para todos.
+func TestAggregateOrderStats(t *testing.T) {
+	database := newTestDatabase(t)
+	repo := repository.NewOrderRepository(database)
+	ctx := context.Background()
+
+	now := time.Now()
+	seedOrderWithTotal(t, database, utils.OrderStatusDone, 10, now)
+	seedOrderWithTotal(t, database, utils.OrderStatusDone, 15.5, now)
+	seedOrderWithTotal(t, database, utils.OrderStatusNew, 7, now)
+	require.NoError(t, database.Create(ctx, &orderEntity.Order{UserID: "u2", Status: utils.OrderStatusDone, TotalPrice: 100}))
+
+	byStatus := func(stats *orderEntity.OrderStats) map[utils.OrderStatus]orderEntity.StatusTotals {
+		out := make(map[utils.OrderStatus]orderEntity.StatusTotals)
+		for _, row := range stats.ByStatus {
+			out[row.Status] = *row
+		}
+		return out
+	}
+
+	stats, err := repo.AggregateOrderStats(ctx, "u1")
+	require.NoError(t, err)
+	rows := byStatus(stats)
+	assert.Len(t, rows, 2)
+	assert.Equal(t, 2, rows[utils.OrderStatusDone].Orders)
+	assert.Equal(t, 25.5, rows[utils.OrderStatusDone].Total)
+	assert.Equal(t, 1, rows[utils.OrderStatusNew].Orders)
+
+	stats, err = repo.AggregateOrderStats(ctx, "")
+	require.NoError(t, err)
+	rows = byStatus(stats)
+	assert.Equal(t, 3, rows[utils.OrderStatusDone].Orders)
+	assert.Equal(t, 125.5, rows[utils.OrderStatusDone].Total)
+}
+
+// TestGetMyOrders_Cursor verifica el recorrido hacia delante con cursores,
+// que la última página no trae NextCursor y que Before devuelve las órdenes
+// más recientes que el cursor.
+func TestGetMyOrders_Cursor(t *testing.T) {
+	database := newTestDatabase(t)
+	repo := repository.NewOrderRepository(database)
+	ctx := context.Background()
+
+	now := time.Now().UTC()
+	for i := 1; i <= 5; i++ {
+		seedOrderWithTotal(t, database, utils.OrderStatusNew, float64(i), now.Add(time.Duration(i)*time.Minute))
+	}
+
+	var totals []float64
+	var cursors []string
+	after := ""
+	for page := 0; page < 5; page++ {
+		orders, pagination, err := repo.GetMyOrders(ctx, &dto.ListOrdersRequest{
+			UserID: "u1",
+			Cursor: paging.NewCursorPagination(2, after, ""),
+		})
+		require.NoError(t, err)
+		for _, o := range orders {
+			totals = append(totals, o.TotalPrice)
+		}
+		cursors = append(cursors, pagination.NextCursor)
+		if pagination.NextCursor == "" {
+			assert.False(t, pagination.HasNext)
+			break
+		}
+		after = pagination.NextCursor
+	}
+	assert.Equal(t, []float64{5, 4, 3, 2, 1}, totals)
+	assert.Len(t, cursors, 3)
+
+	orders, pagination, err := repo.GetMyOrders(ctx, &dto.ListOrdersRequest{
+		UserID: "u1",
+		Cursor: paging.NewCursorPagination(2, "", cursors[1]),
+	})
+	require.NoError(t, err)
+	if assert.Len(t, orders, 2) {
+		assert.Equal(t, 4.0, orders[0].TotalPrice)
+		assert.Equal(t, 3.0, orders[1].TotalPrice)
+	}
+	assert.True(t, pagination.HasPrevious)
+	assert.True(t, pagination.HasNext)
+}
+
+// TestGetMyOrders_CorruptedCursor verifica que un cursor corrupto devuelve
+// ErrInvalidCursor.
+func TestGetMyOrders_CorruptedCursor(t *testing.T) {
+	database := newTestDatabase(t)
+	repo := repository.NewOrderRepository(database)
+
+	_, _, err := repo.GetMyOrders(context.Background(), &dto.ListOrdersRequest{
+		UserID: "u1",
+		Cursor: paging.NewCursorPagination(2, "not-a-cursor", ""),
+	})
+
+	assert.ErrorIs(t, err, paging.ErrInvalidCursor)
+}
+
+// TestSplitOrder_RollsBackOnFailure verifica que si falla una escritura
+// posterior a la creación de la orden nueva, SplitOrder deshace todo: la orden
+// nueva no queda guardada y las líneas siguen en la original.
+func TestSplitOrder_RollsBackOnFailure(t *testing.T) {
+	database := newTestDatabase(t)
+	repo := repository.NewOrderRepository(database)
+	ctx := context.Background()
+
+	original, err := repo.GetOrderByID(ctx, seedOrder(t, database, utils.OrderStatusNew, "p1", "p2").ID, true)
+	require.NoError(t, err)
+
+	errFail := errors.New("update failed")
+	require.NoError(t, database.GetDB().Callback().Update().Before("gorm:update").Register("test:fail", func(tx *gorm.DB) {
+		if tx.Statement.Table == "orders" {
+			_ = tx.AddError(errFail)
+		}
+	}))
+
+	split := &orderEntity.Order{UserID: original.UserID, Lines: original.Lines[:1]}
+	original.Lines = original.Lines[1:]
+
+	err = repo.SplitOrder(ctx, original, split)
+	assert.ErrorIs(t, err, errFail)
+
+	var orders int64
+	require.NoError(t, database.GetDB().Model(&orderEntity.Order{}).Count(&orders).Error)
+	assert.Equal(t, int64(1), orders)
+
+	var moved int64
+	require.NoError(t, database.GetDB().Model(&orderEntity.OrderLine{}).Where("order_id <> ?", original.ID).Count(&moved).Error)
+	assert.Zero(t, moved)
+}
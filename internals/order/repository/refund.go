@@ -0,0 +1,33 @@
+package repository
+
+import (
+	"context"
+	"ecommerce_clean/db"
+	"ecommerce_clean/internals/order/entity"
+)
+
+type IRefundRepository interface {
+	Create(ctx context.Context, refund *entity.Refund) error
+	GetByID(ctx context.Context, id string) (*entity.Refund, error)
+}
+
+type RefundRepository struct {
+	db db.IDatabase
+}
+
+func NewRefundRepository(db db.IDatabase) *RefundRepository {
+	return &RefundRepository{db: db}
+}
+
+func (r *RefundRepository) Create(ctx context.Context, refund *entity.Refund) error {
+	return r.db.Create(ctx, refund)
+}
+
+func (r *RefundRepository) GetByID(ctx context.Context, id string) (*entity.Refund, error) {
+	var refund entity.Refund
+	if err := r.db.FindById(ctx, id, &refund); err != nil {
+		return nil, err
+	}
+
+	return &refund, nil
+}
@@ -0,0 +1,67 @@
+package usecase
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"ecommerce_clean/utils"
+)
+
+var (
+	ErrPermissionDenied       = errors.New("permission denied")
+	ErrForbidden              = errors.New("forbidden")
+	ErrInvalidOrderStatus     = errors.New("invalid order status")
+	ErrAlreadyPaid            = errors.New("order already paid")
+	ErrOrderEmpty             = errors.New("order has no lines")
+	ErrCalculatorUnavailable  = errors.New("shipping calculator unavailable")
+	ErrEmptyNote              = errors.New("note content is empty")
+	ErrCategoryUnresolved     = errors.New("product category unresolved")
+	ErrInvalidSplit           = errors.New("split lines must be a non-empty proper subset of the order lines")
+	ErrInvalidSince           = errors.New("since must not be more than 5 years in the past")
+	ErrInvalidPeriod          = errors.New("month must be 1-12 and year between 2020 and the current year")
+	ErrAlreadyRefunded        = errors.New("order already refunded")
+	ErrOrderNotPaid           = errors.New("order has not been paid")
+	ErrAddressNotOwned        = errors.New("address does not belong to user")
+	ErrInvalidEmail           = errors.New("invalid email format")
+	ErrOrderFinalized         = errors.New("order is already done or canceled")
+	ErrInvalidTransition      = errors.New("invalid order status transition")
+	ErrInvalidTag             = errors.New("tag must be 1-50 characters of letters, digits, - or _")
+	ErrInvalidMerge           = errors.New("merge needs at least two distinct orders")
+	ErrInvalidRef             = errors.New("external reference must be 1-100 characters")
+	ErrInvalidLimit           = errors.New("limit must be between 1 and 100")
+	ErrInvalidMinOrders       = errors.New("min orders must be at least 2")
+	ErrInvalidSortParam       = errors.New("invalid sort parameter")
+	ErrTooManyOrderIDs        = errors.New("too many order ids, maximum is 500")
+	ErrIdempotencyKeyConflict = errors.New("idempotency key already used by another user")
+	ErrInvalidExportFormat    = errors.New("export format must be csv or json")
+	ErrInvalidPaymentStatus   = errors.New("invalid payment status")
+	ErrPaymentRefunded        = errors.New("order payment was refunded")
+	ErrPaymentStatusNotSet    = errors.New("paid and refunded are set by paying or refunding the order")
+	ErrInsufficientStock      = errors.New("insufficient stock")
+)
+
+// ErrOrderTransitionFailed reports a status change the order lifecycle does
+// not allow. It unwraps to ErrInvalidTransition.
+type ErrOrderTransitionFailed struct {
+	From utils.OrderStatus
+	To   utils.OrderStatus
+}
+
+func (e ErrOrderTransitionFailed) Error() string {
+	return fmt.Sprintf("cannot change order status from %s to %s", e.From, e.To)
+}
+
+func (e ErrOrderTransitionFailed) Unwrap() error {
+	return ErrInvalidTransition
+}
+
+// ErrPartialResult reports the requested orders that could not be found. The
+// orders that were found are returned alongside it.
+type ErrPartialResult struct {
+	MissingIDs []string
+}
+
+func (e ErrPartialResult) Error() string {
+	return fmt.Sprintf("%d orders not found: %s", len(e.MissingIDs), strings.Join(e.MissingIDs, ", "))
+}
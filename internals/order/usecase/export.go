@@ -0,0 +1,107 @@
+package usecase
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"strconv"
+	"time"
+
+	"ecommerce_clean/internals/order/controller/dto"
+	"ecommerce_clean/internals/order/entity"
+)
+
+var exportCSVHeader = []string{"id", "code", "status", "total_price", "is_paid", "created_at"}
+
+// orderExportRow is the shape of one exported order, shared by both formats.
+type orderExportRow struct {
+	ID         string    `json:"id"`
+	Code       string    `json:"code"`
+	Status     string    `json:"status"`
+	TotalPrice float64   `json:"total_price"`
+	IsPaid     bool      `json:"is_paid"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+func newOrderExportRow(order *entity.Order) orderExportRow {
+	return orderExportRow{
+		ID:         order.ID,
+		Code:       order.Code,
+		Status:     string(order.Status),
+		TotalPrice: order.TotalPrice,
+		IsPaid:     order.PaymentID != nil,
+		CreatedAt:  order.CreatedAt,
+	}
+}
+
+// ExportOrders writes the user's orders matching req to w, either as CSV with
+// a header row or as a JSON array. Orders are written as the repository
+// streams them, so the full result is never held in memory.
+func (ou *OrderUseCase) ExportOrders(ctx context.Context, req *dto.OrderExportRequest, w io.Writer) error {
+	if err := ou.validator.ValidateStruct(req); err != nil {
+		return err
+	}
+
+	switch req.Format {
+	case dto.ExportFormatCSV:
+		return ou.exportOrdersCSV(ctx, req, w)
+	case dto.ExportFormatJSON:
+		return ou.exportOrdersJSON(ctx, req, w)
+	default:
+		return ErrInvalidExportFormat
+	}
+}
+
+func (ou *OrderUseCase) exportOrdersCSV(ctx context.Context, req *dto.OrderExportRequest, w io.Writer) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(exportCSVHeader); err != nil {
+		return err
+	}
+
+	err := ou.orderRepo.StreamOrders(ctx, req, func(order *entity.Order) error {
+		row := newOrderExportRow(order)
+		return cw.Write([]string{
+			row.ID,
+			row.Code,
+			row.Status,
+			strconv.FormatFloat(row.TotalPrice, 'f', 2, 64),
+			strconv.FormatBool(row.IsPaid),
+			row.CreatedAt.UTC().Format(time.RFC3339),
+		})
+	})
+	if err != nil {
+		return err
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+func (ou *OrderUseCase) exportOrdersJSON(ctx context.Context, req *dto.OrderExportRequest, w io.Writer) error {
+	if _, err := io.WriteString(w, "["); err != nil {
+		return err
+	}
+
+	first := true
+	err := ou.orderRepo.StreamOrders(ctx, req, func(order *entity.Order) error {
+		data, err := json.Marshal(newOrderExportRow(order))
+		if err != nil {
+			return err
+		}
+		if !first {
+			if _, err := io.WriteString(w, ","); err != nil {
+				return err
+			}
+		}
+		first = false
+		_, err = w.Write(data)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = io.WriteString(w, "]")
+	return err
+}
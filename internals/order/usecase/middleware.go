@@ -0,0 +1,389 @@
+package usecase
+
+import (
+	"context"
+	addressEntity "ecommerce_clean/internals/address/entity"
+	"ecommerce_clean/internals/order/controller/dto"
+	"ecommerce_clean/internals/order/entity"
+	"ecommerce_clean/pkgs/logger"
+	"ecommerce_clean/pkgs/paging"
+	"ecommerce_clean/utils"
+	"io"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// MiddlewareFunc wraps a single use case call. It must call next to run the
+// call and may act before and after it, or return without calling next to
+// short-circuit it.
+type MiddlewareFunc func(ctx context.Context, method string, next func() error) error
+
+type middlewareUseCase struct {
+	next IOrderUseCase
+	run  func(ctx context.Context, method string, call func() error) error
+}
+
+// WithMiddleware decorates uc so every call goes through mw. The first
+// middleware is the outermost one.
+func WithMiddleware(uc IOrderUseCase, mw ...MiddlewareFunc) IOrderUseCase {
+	chain := func(ctx context.Context, method string, call func() error) error {
+		return call()
+	}
+	for i := len(mw) - 1; i >= 0; i-- {
+		inner, m := chain, mw[i]
+		chain = func(ctx context.Context, method string, call func() error) error {
+			return m(ctx, method, func() error {
+				return inner(ctx, method, call)
+			})
+		}
+	}
+
+	return &middlewareUseCase{next: uc, run: chain}
+}
+
+// LoggingMiddleware logs the duration and outcome of every call.
+func LoggingMiddleware(ctx context.Context, method string, next func() error) error {
+	start := time.Now()
+	err := next()
+	if err != nil {
+		logger.Errorf("OrderUseCase.%s failed after %s: %s", method, time.Since(start), err)
+		return err
+	}
+
+	logger.Debugf("OrderUseCase.%s done in %s", method, time.Since(start))
+	return nil
+}
+
+// MetricsMiddleware counts calls per method and outcome and records their
+// latency in reg.
+func MetricsMiddleware(reg prometheus.Registerer) MiddlewareFunc {
+	calls := prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "order_usecase_calls_total",
+			Help: "Total order use case calls",
+		},
+		[]string{"method", "status"},
+	)
+
+	latency := prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "order_usecase_duration_seconds",
+			Help:    "Histogram of order use case call duration",
+			Buckets: prometheus.DefBuckets,
+		},
+		[]string{"method"},
+	)
+
+	reg.MustRegister(calls, latency)
+
+	return func(ctx context.Context, method string, next func() error) error {
+		timer := prometheus.NewTimer(latency.WithLabelValues(method))
+		err := next()
+		timer.ObserveDuration()
+
+		status := "ok"
+		if err != nil {
+			status = "error"
+		}
+		calls.WithLabelValues(method, status).Inc()
+
+		return err
+	}
+}
+
+func (d *middlewareUseCase) PlaceOrder(ctx context.Context, req *dto.PlaceOrderRequest) (res *entity.Order, err error) {
+	err = d.run(ctx, "PlaceOrder", func() error {
+		res, err = d.next.PlaceOrder(ctx, req)
+		return err
+	})
+	return res, err
+}
+
+func (d *middlewareUseCase) ListMyOrders(ctx context.Context, req *dto.ListOrdersRequest) (res []*entity.Order, page *paging.Pagination, err error) {
+	err = d.run(ctx, "ListMyOrders", func() error {
+		res, page, err = d.next.ListMyOrders(ctx, req)
+		return err
+	})
+	return res, page, err
+}
+
+func (d *middlewareUseCase) GetOrderByID(ctx context.Context, id string) (res *entity.Order, err error) {
+	err = d.run(ctx, "GetOrderByID", func() error {
+		res, err = d.next.GetOrderByID(ctx, id)
+		return err
+	})
+	return res, err
+}
+
+func (d *middlewareUseCase) UpdateOrder(ctx context.Context, orderID, userID string, status string) (res *entity.Order, err error) {
+	err = d.run(ctx, "UpdateOrder", func() error {
+		res, err = d.next.UpdateOrder(ctx, orderID, userID, status)
+		return err
+	})
+	return res, err
+}
+
+func (d *middlewareUseCase) GetOrderWithFullDetails(ctx context.Context, orderID, requesterID, role string) (res *entity.OrderDetails, err error) {
+	err = d.run(ctx, "GetOrderWithFullDetails", func() error {
+		res, err = d.next.GetOrderWithFullDetails(ctx, orderID, requesterID, role)
+		return err
+	})
+	return res, err
+}
+
+func (d *middlewareUseCase) MarkOrderAsPaid(ctx context.Context, orderID, role, paymentID string) error {
+	return d.run(ctx, "MarkOrderAsPaid", func() error {
+		return d.next.MarkOrderAsPaid(ctx, orderID, role, paymentID)
+	})
+}
+
+func (d *middlewareUseCase) GetOrderReceipt(ctx context.Context, orderID, userID string) (res *entity.Receipt, err error) {
+	err = d.run(ctx, "GetOrderReceipt", func() error {
+		res, err = d.next.GetOrderReceipt(ctx, orderID, userID)
+		return err
+	})
+	return res, err
+}
+
+func (d *middlewareUseCase) CalculateShipping(ctx context.Context, orderID, userID, role string, destination addressEntity.Address) (res *entity.ShippingQuote, err error) {
+	err = d.run(ctx, "CalculateShipping", func() error {
+		res, err = d.next.CalculateShipping(ctx, orderID, userID, role, destination)
+		return err
+	})
+	return res, err
+}
+
+func (d *middlewareUseCase) GetOrdersForUser(ctx context.Context, targetUserID, requesterID, requesterRole string, req *paging.Pagination) (res []*entity.Order, page *paging.Pagination, err error) {
+	err = d.run(ctx, "GetOrdersForUser", func() error {
+		res, page, err = d.next.GetOrdersForUser(ctx, targetUserID, requesterID, requesterRole, req)
+		return err
+	})
+	return res, page, err
+}
+
+func (d *middlewareUseCase) AddOrderNote(ctx context.Context, orderID, note, requesterID, requesterRole string) error {
+	return d.run(ctx, "AddOrderNote", func() error {
+		return d.next.AddOrderNote(ctx, orderID, note, requesterID, requesterRole)
+	})
+}
+
+func (d *middlewareUseCase) GetOrderNotes(ctx context.Context, orderID, requesterRole string) (res []*entity.OrderNote, err error) {
+	err = d.run(ctx, "GetOrderNotes", func() error {
+		res, err = d.next.GetOrderNotes(ctx, orderID, requesterRole)
+		return err
+	})
+	return res, err
+}
+
+func (d *middlewareUseCase) GroupOrderLinesByCategory(ctx context.Context, orderID, requesterID, role string) (res map[string]float64, err error) {
+	err = d.run(ctx, "GroupOrderLinesByCategory", func() error {
+		res, err = d.next.GroupOrderLinesByCategory(ctx, orderID, requesterID, role)
+		return err
+	})
+	return res, err
+}
+
+func (d *middlewareUseCase) SplitOrder(ctx context.Context, orderID, userID string, splitLines []string) (res []*entity.Order, err error) {
+	err = d.run(ctx, "SplitOrder", func() error {
+		res, err = d.next.SplitOrder(ctx, orderID, userID, splitLines)
+		return err
+	})
+	return res, err
+}
+
+func (d *middlewareUseCase) GetAverageOrderValue(ctx context.Context, since time.Time, role string) (res float64, err error) {
+	err = d.run(ctx, "GetAverageOrderValue", func() error {
+		res, err = d.next.GetAverageOrderValue(ctx, since, role)
+		return err
+	})
+	return res, err
+}
+
+func (d *middlewareUseCase) GenerateOrderSummaryReport(ctx context.Context, month time.Month, year int, role string) (res *entity.MonthlySummary, err error) {
+	err = d.run(ctx, "GenerateOrderSummaryReport", func() error {
+		res, err = d.next.GenerateOrderSummaryReport(ctx, month, year, role)
+		return err
+	})
+	return res, err
+}
+
+func (d *middlewareUseCase) RefundOrder(ctx context.Context, orderID, userID, reason string) (res *entity.Refund, err error) {
+	err = d.run(ctx, "RefundOrder", func() error {
+		res, err = d.next.RefundOrder(ctx, orderID, userID, reason)
+		return err
+	})
+	return res, err
+}
+
+func (d *middlewareUseCase) ListOrdersByShippingAddress(ctx context.Context, addressID, userID string, req *paging.Pagination) (res []*entity.Order, page *paging.Pagination, err error) {
+	err = d.run(ctx, "ListOrdersByShippingAddress", func() error {
+		res, page, err = d.next.ListOrdersByShippingAddress(ctx, addressID, userID, req)
+		return err
+	})
+	return res, page, err
+}
+
+func (d *middlewareUseCase) AttachReceiptEmail(ctx context.Context, orderID, userID, role, email string) error {
+	return d.run(ctx, "AttachReceiptEmail", func() error {
+		return d.next.AttachReceiptEmail(ctx, orderID, userID, role, email)
+	})
+}
+
+func (d *middlewareUseCase) RecalculateOrderTotal(ctx context.Context, orderID, role string) error {
+	return d.run(ctx, "RecalculateOrderTotal", func() error {
+		return d.next.RecalculateOrderTotal(ctx, orderID, role)
+	})
+}
+
+func (d *middlewareUseCase) GetOrdersByPaymentStatus(ctx context.Context, isPaid bool, role string, req *paging.Pagination) (res []*entity.Order, meta *entity.PaymentStatusMeta, err error) {
+	err = d.run(ctx, "GetOrdersByPaymentStatus", func() error {
+		res, meta, err = d.next.GetOrdersByPaymentStatus(ctx, isPaid, role, req)
+		return err
+	})
+	return res, meta, err
+}
+
+func (d *middlewareUseCase) AddTagToOrder(ctx context.Context, orderID, tag, role string) error {
+	return d.run(ctx, "AddTagToOrder", func() error {
+		return d.next.AddTagToOrder(ctx, orderID, tag, role)
+	})
+}
+
+func (d *middlewareUseCase) RemoveTagFromOrder(ctx context.Context, orderID, tag, role string) error {
+	return d.run(ctx, "RemoveTagFromOrder", func() error {
+		return d.next.RemoveTagFromOrder(ctx, orderID, tag, role)
+	})
+}
+
+func (d *middlewareUseCase) ListOrdersByTag(ctx context.Context, tag, role string, req *paging.Pagination) (res []*entity.Order, page *paging.Pagination, err error) {
+	err = d.run(ctx, "ListOrdersByTag", func() error {
+		res, page, err = d.next.ListOrdersByTag(ctx, tag, role, req)
+		return err
+	})
+	return res, page, err
+}
+
+func (d *middlewareUseCase) MergeOrders(ctx context.Context, orderIDs []string, userID string) (res *entity.Order, err error) {
+	err = d.run(ctx, "MergeOrders", func() error {
+		res, err = d.next.MergeOrders(ctx, orderIDs, userID)
+		return err
+	})
+	return res, err
+}
+
+func (d *middlewareUseCase) GetOrderTimeline(ctx context.Context, orderID, requesterID, role string) (res []entity.TimelineEvent, err error) {
+	err = d.run(ctx, "GetOrderTimeline", func() error {
+		res, err = d.next.GetOrderTimeline(ctx, orderID, requesterID, role)
+		return err
+	})
+	return res, err
+}
+
+func (d *middlewareUseCase) GetOrdersByExternalReference(ctx context.Context, externalRef, role string) (res []*entity.Order, err error) {
+	err = d.run(ctx, "GetOrdersByExternalReference", func() error {
+		res, err = d.next.GetOrdersByExternalReference(ctx, externalRef, role)
+		return err
+	})
+	return res, err
+}
+
+func (d *middlewareUseCase) GetRevenueByProduct(ctx context.Context, since time.Time, limit int, role string) (res []*entity.ProductRevenue, err error) {
+	err = d.run(ctx, "GetRevenueByProduct", func() error {
+		res, err = d.next.GetRevenueByProduct(ctx, since, limit, role)
+		return err
+	})
+	return res, err
+}
+
+func (d *middlewareUseCase) GetRepeatCustomers(ctx context.Context, minOrders int, since time.Time, role string) (res []*entity.RepeatCustomer, err error) {
+	err = d.run(ctx, "GetRepeatCustomers", func() error {
+		res, err = d.next.GetRepeatCustomers(ctx, minOrders, since, role)
+		return err
+	})
+	return res, err
+}
+
+func (d *middlewareUseCase) GetUserLifetimeValue(ctx context.Context, userID string) (res *entity.LifetimeValue, err error) {
+	err = d.run(ctx, "GetUserLifetimeValue", func() error {
+		res, err = d.next.GetUserLifetimeValue(ctx, userID)
+		return err
+	})
+	return res, err
+}
+
+func (d *middlewareUseCase) GetOrderFulfillmentRate(ctx context.Context, since time.Time, role string) (res float64, err error) {
+	err = d.run(ctx, "GetOrderFulfillmentRate", func() error {
+		res, err = d.next.GetOrderFulfillmentRate(ctx, since, role)
+		return err
+	})
+	return res, err
+}
+
+func (d *middlewareUseCase) CancelOrder(ctx context.Context, orderID, userID, reason string) (res *entity.Order, err error) {
+	err = d.run(ctx, "CancelOrder", func() error {
+		res, err = d.next.CancelOrder(ctx, orderID, userID, reason)
+		return err
+	})
+	return res, err
+}
+
+func (d *middlewareUseCase) ListAllOrders(ctx context.Context, role string, req *dto.AdminListOrdersRequest) (res []*entity.Order, page *paging.Pagination, err error) {
+	err = d.run(ctx, "ListAllOrders", func() error {
+		res, page, err = d.next.ListAllOrders(ctx, role, req)
+		return err
+	})
+	return res, page, err
+}
+
+func (d *middlewareUseCase) GetOrdersByIDs(ctx context.Context, ids []string) (res []*entity.Order, err error) {
+	err = d.run(ctx, "GetOrdersByIDs", func() error {
+		res, err = d.next.GetOrdersByIDs(ctx, ids)
+		return err
+	})
+	return res, err
+}
+
+func (d *middlewareUseCase) GetAuditLogsForOrder(ctx context.Context, orderID string) (res []*entity.OrderAuditLog, err error) {
+	err = d.run(ctx, "GetAuditLogsForOrder", func() error {
+		res, err = d.next.GetAuditLogsForOrder(ctx, orderID)
+		return err
+	})
+	return res, err
+}
+
+func (d *middlewareUseCase) DeleteOrder(ctx context.Context, orderID, userID string) error {
+	return d.run(ctx, "DeleteOrder", func() error {
+		return d.next.DeleteOrder(ctx, orderID, userID)
+	})
+}
+
+func (d *middlewareUseCase) ReOrder(ctx context.Context, orderID, userID string) (res *entity.Order, err error) {
+	err = d.run(ctx, "ReOrder", func() error {
+		res, err = d.next.ReOrder(ctx, orderID, userID)
+		return err
+	})
+	return res, err
+}
+
+func (d *middlewareUseCase) ExportOrders(ctx context.Context, req *dto.OrderExportRequest, w io.Writer) error {
+	return d.run(ctx, "ExportOrders", func() error {
+		return d.next.ExportOrders(ctx, req, w)
+	})
+}
+
+func (d *middlewareUseCase) GetOrderStatistics(ctx context.Context, userID, requesterID, role string) (res *dto.OrderStatistics, err error) {
+	err = d.run(ctx, "GetOrderStatistics", func() error {
+		res, err = d.next.GetOrderStatistics(ctx, userID, requesterID, role)
+		return err
+	})
+	return res, err
+}
+
+func (d *middlewareUseCase) UpdatePaymentStatus(ctx context.Context, orderID, role string, status utils.PaymentStatus) (res *entity.Order, err error) {
+	err = d.run(ctx, "UpdatePaymentStatus", func() error {
+		res, err = d.next.UpdatePaymentStatus(ctx, orderID, role, status)
+		return err
+	})
+	return res, err
+}
@@ -0,0 +1,50 @@
+package usecase
+
+import (
+	"context"
+	"ecommerce_clean/internals/order/entity"
+	userRepo "ecommerce_clean/internals/user/repository"
+	"ecommerce_clean/pkgs/mail"
+	"fmt"
+)
+
+// Notifier tells customers about their orders.
+type Notifier interface {
+	OrderCreated(ctx context.Context, order *entity.Order) error
+}
+
+// MailNotifier emails order events to the order's receipt address, or to its
+// owner's account address when no receipt address was given.
+type MailNotifier struct {
+	mailer mail.IMailer
+	users  userRepo.IUserRepository
+}
+
+func NewMailNotifier(mailer mail.IMailer, users userRepo.IUserRepository) *MailNotifier {
+	return &MailNotifier{
+		mailer: mailer,
+		users:  users,
+	}
+}
+
+func (n *MailNotifier) OrderCreated(ctx context.Context, order *entity.Order) error {
+	to, err := n.recipient(ctx, order)
+	if err != nil {
+		return err
+	}
+
+	body := fmt.Sprintf("<h1>Order received</h1><p>Your order %s has been placed.</p>", order.ID)
+	return n.mailer.Send(to, "Your order has been placed", body, true)
+}
+
+func (n *MailNotifier) recipient(ctx context.Context, order *entity.Order) (string, error) {
+	if order.ReceiptEmail != nil {
+		return *order.ReceiptEmail, nil
+	}
+
+	user, err := n.users.GetUserById(ctx, order.UserID)
+	if err != nil {
+		return "", err
+	}
+	return user.Email, nil
+}
@@ -2,47 +2,130 @@ package usecase
 
 import (
 	"context"
+	addressEntity "ecommerce_clean/internals/address/entity"
+	addressRepo "ecommerce_clean/internals/address/repository"
 	"ecommerce_clean/internals/order/controller/dto"
 	"ecommerce_clean/internals/order/entity"
 	"ecommerce_clean/internals/order/repository"
 	productEntity "ecommerce_clean/internals/product/entity"
 	productRepo "ecommerce_clean/internals/product/repository"
+	"ecommerce_clean/pkgs/logger"
 	"ecommerce_clean/pkgs/paging"
 	"ecommerce_clean/pkgs/validation"
 	"ecommerce_clean/utils"
 	"errors"
+	"fmt"
+	"io"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"golang.org/x/sync/errgroup"
+)
+
+var tagPattern = regexp.MustCompile(`^[a-z0-9_-]{1,50}$`)
+
+const (
+	maxExternalRefLength = 100
+	maxRepeatCustomers   = 500
+	maxBulkOrderIDs      = 500
 )
 
 type IOrderUseCase interface {
 	PlaceOrder(ctx context.Context, req *dto.PlaceOrderRequest) (*entity.Order, error)
 	ListMyOrders(ctx context.Context, req *dto.ListOrdersRequest) ([]*entity.Order, *paging.Pagination, error)
 	GetOrderByID(ctx context.Context, id string) (*entity.Order, error)
+	GetOrdersByIDs(ctx context.Context, ids []string) ([]*entity.Order, error)
+	GetAuditLogsForOrder(ctx context.Context, orderID string) ([]*entity.OrderAuditLog, error)
 	UpdateOrder(ctx context.Context, orderID, userID string, status string) (*entity.Order, error)
+	GetOrderWithFullDetails(ctx context.Context, orderID, requesterID, role string) (*entity.OrderDetails, error)
+	MarkOrderAsPaid(ctx context.Context, orderID, role, paymentID string) error
+	GetOrderReceipt(ctx context.Context, orderID, userID string) (*entity.Receipt, error)
+	CalculateShipping(ctx context.Context, orderID, userID, role string, destination addressEntity.Address) (*entity.ShippingQuote, error)
+	GetOrdersForUser(ctx context.Context, targetUserID, requesterID, requesterRole string, req *paging.Pagination) ([]*entity.Order, *paging.Pagination, error)
+	AddOrderNote(ctx context.Context, orderID, note, requesterID, requesterRole string) error
+	GetOrderNotes(ctx context.Context, orderID, requesterRole string) ([]*entity.OrderNote, error)
+	GroupOrderLinesByCategory(ctx context.Context, orderID, requesterID, role string) (map[string]float64, error)
+	SplitOrder(ctx context.Context, orderID, userID string, splitLines []string) ([]*entity.Order, error)
+	GetAverageOrderValue(ctx context.Context, since time.Time, role string) (float64, error)
+	GenerateOrderSummaryReport(ctx context.Context, month time.Month, year int, role string) (*entity.MonthlySummary, error)
+	RefundOrder(ctx context.Context, orderID, userID, reason string) (*entity.Refund, error)
+	ListOrdersByShippingAddress(ctx context.Context, addressID, userID string, req *paging.Pagination) ([]*entity.Order, *paging.Pagination, error)
+	AttachReceiptEmail(ctx context.Context, orderID, userID, role, email string) error
+	RecalculateOrderTotal(ctx context.Context, orderID, role string) error
+	GetOrdersByPaymentStatus(ctx context.Context, isPaid bool, role string, req *paging.Pagination) ([]*entity.Order, *entity.PaymentStatusMeta, error)
+	AddTagToOrder(ctx context.Context, orderID, tag, role string) error
+	RemoveTagFromOrder(ctx context.Context, orderID, tag, role string) error
+	ListOrdersByTag(ctx context.Context, tag, role string, req *paging.Pagination) ([]*entity.Order, *paging.Pagination, error)
+	MergeOrders(ctx context.Context, orderIDs []string, userID string) (*entity.Order, error)
+	GetOrderTimeline(ctx context.Context, orderID, requesterID, role string) ([]entity.TimelineEvent, error)
+	GetOrdersByExternalReference(ctx context.Context, externalRef, role string) ([]*entity.Order, error)
+	GetRevenueByProduct(ctx context.Context, since time.Time, limit int, role string) ([]*entity.ProductRevenue, error)
+	GetRepeatCustomers(ctx context.Context, minOrders int, since time.Time, role string) ([]*entity.RepeatCustomer, error)
+	GetUserLifetimeValue(ctx context.Context, userID string) (*entity.LifetimeValue, error)
+	GetOrderStatistics(ctx context.Context, userID, requesterID, role string) (*dto.OrderStatistics, error)
+	GetOrderFulfillmentRate(ctx context.Context, since time.Time, role string) (float64, error)
+	CancelOrder(ctx context.Context, orderID, userID, reason string) (*entity.Order, error)
+	DeleteOrder(ctx context.Context, orderID, userID string) error
+	ReOrder(ctx context.Context, orderID, userID string) (*entity.Order, error)
+	UpdatePaymentStatus(ctx context.Context, orderID, role string, status utils.PaymentStatus) (*entity.Order, error)
+	ExportOrders(ctx context.Context, req *dto.OrderExportRequest, w io.Writer) error
+	ListAllOrders(ctx context.Context, role string, req *dto.AdminListOrdersRequest) ([]*entity.Order, *paging.Pagination, error)
 }
 
 type OrderUseCase struct {
-	validator   validation.Validation
-	orderRepo   repository.IOrderRepository
-	productRepo productRepo.IProductRepository
+	validator          validation.Validation
+	orderRepo          repository.IOrderRepository
+	productRepo        productRepo.IProductRepository
+	shippingCalculator ShippingCalculator
+	noteRepo           repository.IOrderNoteRepository
+	refundRepo         repository.IRefundRepository
+	addressRepo        addressRepo.IAddressRepository
+	notifier           Notifier
 }
 
 func NewOrderUseCase(
 	validator validation.Validation,
 	orderRepo repository.IOrderRepository,
 	productRepo productRepo.IProductRepository,
+	shippingCalculator ShippingCalculator,
+	noteRepo repository.IOrderNoteRepository,
+	refundRepo repository.IRefundRepository,
+	addressRepo addressRepo.IAddressRepository,
+	notifier Notifier,
 ) *OrderUseCase {
 	return &OrderUseCase{
-		validator:   validator,
-		orderRepo:   orderRepo,
-		productRepo: productRepo,
+		validator:          validator,
+		orderRepo:          orderRepo,
+		productRepo:        productRepo,
+		shippingCalculator: shippingCalculator,
+		noteRepo:           noteRepo,
+		refundRepo:         refundRepo,
+		addressRepo:        addressRepo,
+		notifier:           notifier,
 	}
 }
 
+// PlaceOrder creates the order once per idempotency key and takes its lines
+// from stock. A retry with a key the user already used, even one racing the
+// first request, returns the order created the first time.
+//
+// The notifier is told about each new order, at its receipt email if one was
+// given. Failing to notify does not fail the order, which is already placed.
 func (ou *OrderUseCase) PlaceOrder(ctx context.Context, req *dto.PlaceOrderRequest) (*entity.Order, error) {
 	if err := ou.validator.ValidateStruct(req); err != nil {
 		return nil, err
 	}
 
+	existing, err := FindIdempotentOrder(ctx, ou.orderRepo, req.IdempotencyKey, req.UserID)
+	if err != nil || existing != nil {
+		return existing, err
+	}
+
 	var lines []*entity.OrderLine
 	utils.MapStruct(&lines, &req.Lines)
 
@@ -56,8 +139,26 @@ func (ou *OrderUseCase) PlaceOrder(ctx context.Context, req *dto.PlaceOrderReque
 		productMap[line.ProductID] = product
 	}
 
-	order, err := ou.orderRepo.CreateOrder(ctx, req.UserID, lines)
+	var order *entity.Order
+	err = ou.orderRepo.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := TakeStock(ctx, ou.productRepo, lines); err != nil {
+			return err
+		}
+
+		created, err := ou.orderRepo.CreateOrder(ctx, &entity.Order{
+			UserID:          req.UserID,
+			CustomerNotes:   req.Notes,
+			DeliveryAddress: req.DeliveryAddress,
+			ReceiptEmail:    req.ReceiptEmail,
+			IdempotencyKey:  &req.IdempotencyKey,
+		}, lines)
+		order = created
+		return err
+	})
 	if err != nil {
+		if IsIdempotencyKeyTaken(err) {
+			return ou.placedMeanwhile(ctx, req.IdempotencyKey, req.UserID, err)
+		}
 		return nil, err
 	}
 
@@ -65,10 +166,69 @@ func (ou *OrderUseCase) PlaceOrder(ctx context.Context, req *dto.PlaceOrderReque
 		line.Product = productMap[line.ProductID]
 	}
 
+	if err := ou.notifier.OrderCreated(ctx, order); err != nil {
+		logger.Errorf("Failed to notify order created, order id: %s, error: %s", order.ID, err)
+	}
+
 	return order, nil
 }
 
+// placedMeanwhile returns the order a concurrent request placed with key, now
+// that creating another one hit the key's unique index. createErr is returned
+// when that order cannot be read back.
+func (ou *OrderUseCase) placedMeanwhile(ctx context.Context, key, userID string, createErr error) (*entity.Order, error) {
+	existing, err := FindIdempotentOrder(ctx, ou.orderRepo, key, userID)
+	if err != nil {
+		return nil, err
+	}
+	if existing == nil {
+		return nil, createErr
+	}
+	return existing, nil
+}
+
+// ReOrder places a new order with the same products and quantities as one of
+// the user's past orders, at current prices. Each call places a new order.
+func (ou *OrderUseCase) ReOrder(ctx context.Context, orderID, userID string) (*entity.Order, error) {
+	original, err := ou.orderRepo.GetOrderByID(ctx, orderID, true)
+	if err != nil {
+		return nil, err
+	}
+
+	if userID != original.UserID {
+		return nil, ErrPermissionDenied
+	}
+
+	req := &dto.PlaceOrderRequest{
+		UserID:          userID,
+		IdempotencyKey:  uuid.New().String(),
+		Lines:           make([]dto.PlaceOrderLineRequest, 0, len(original.Lines)),
+		DeliveryAddress: original.DeliveryAddress,
+		ReceiptEmail:    original.ReceiptEmail,
+	}
+	for _, line := range original.Lines {
+		req.Lines = append(req.Lines, dto.PlaceOrderLineRequest{
+			ProductID: line.ProductID,
+			Quantity:  line.Quantity,
+		})
+	}
+
+	return ou.PlaceOrder(ctx, req)
+}
+
 func (ou *OrderUseCase) ListMyOrders(ctx context.Context, req *dto.ListOrdersRequest) ([]*entity.Order, *paging.Pagination, error) {
+	switch req.SortBy {
+	case "", dto.SortByCreatedAt, dto.SortByTotalPrice:
+	default:
+		return nil, nil, ErrInvalidSortParam
+	}
+
+	switch req.SortDir {
+	case "", dto.SortDirAsc, dto.SortDirDesc:
+	default:
+		return nil, nil, ErrInvalidSortParam
+	}
+
 	orders, pagination, err := ou.orderRepo.GetMyOrders(ctx, req)
 	if err != nil {
 		return nil, nil, err
@@ -77,6 +237,27 @@ func (ou *OrderUseCase) ListMyOrders(ctx context.Context, req *dto.ListOrdersReq
 	return orders, pagination, err
 }
 
+// ListAllOrders lists orders across users. Only administrators may call it.
+// OrderBy takes the same columns as ListMyOrders' SortBy.
+func (ou *OrderUseCase) ListAllOrders(ctx context.Context, role string, req *dto.AdminListOrdersRequest) ([]*entity.Order, *paging.Pagination, error) {
+	if role != utils.RoleAdmin {
+		return nil, nil, ErrForbidden
+	}
+
+	switch req.OrderBy {
+	case "", dto.SortByCreatedAt, dto.SortByTotalPrice:
+	default:
+		return nil, nil, ErrInvalidSortParam
+	}
+
+	orders, pagination, err := ou.orderRepo.ListAllOrders(ctx, req)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return orders, pagination, nil
+}
+
 func (ou *OrderUseCase) GetOrderByID(ctx context.Context, id string) (*entity.Order, error) {
 	order, err := ou.orderRepo.GetOrderByID(ctx, id, true)
 	if err != nil {
@@ -86,6 +267,56 @@ func (ou *OrderUseCase) GetOrderByID(ctx context.Context, id string) (*entity.Or
 	return order, nil
 }
 
+// GetOrdersByIDs loads the given orders with their lines, in the order the IDs
+// were given. When some IDs do not exist the found orders are still returned,
+// together with an ErrPartialResult listing the missing ones.
+func (ou *OrderUseCase) GetOrdersByIDs(ctx context.Context, ids []string) ([]*entity.Order, error) {
+	unique := make([]string, 0, len(ids))
+	seen := make(map[string]struct{}, len(ids))
+	for _, id := range ids {
+		if _, ok := seen[id]; ok {
+			continue
+		}
+		seen[id] = struct{}{}
+		unique = append(unique, id)
+	}
+
+	if len(unique) > maxBulkOrderIDs {
+		return nil, ErrTooManyOrderIDs
+	}
+
+	if len(unique) == 0 {
+		return []*entity.Order{}, nil
+	}
+
+	found, err := ou.orderRepo.GetOrdersByIDs(ctx, unique, true)
+	if err != nil {
+		return nil, err
+	}
+
+	byID := make(map[string]*entity.Order, len(found))
+	for _, order := range found {
+		byID[order.ID] = order
+	}
+
+	orders := make([]*entity.Order, 0, len(found))
+	var missing []string
+	for _, id := range unique {
+		order, ok := byID[id]
+		if !ok {
+			missing = append(missing, id)
+			continue
+		}
+		orders = append(orders, order)
+	}
+
+	if len(missing) > 0 {
+		return orders, ErrPartialResult{MissingIDs: missing}
+	}
+
+	return orders, nil
+}
+
 func (ou *OrderUseCase) UpdateOrder(ctx context.Context, orderID, userID string, status string) (*entity.Order, error) {
 	order, err := ou.orderRepo.GetOrderByID(ctx, orderID, false)
 	if err != nil {
@@ -93,11 +324,7 @@ func (ou *OrderUseCase) UpdateOrder(ctx context.Context, orderID, userID string,
 	}
 
 	if userID != order.UserID {
-		return nil, errors.New("permission denied")
-	}
-
-	if order.Status == utils.OrderStatusDone || order.Status == utils.OrderStatusCanceled {
-		return nil, errors.New("invalid order status")
+		return nil, ErrPermissionDenied
 	}
 
 	statusValue, err := utils.ToOrderStatus(status)
@@ -105,11 +332,1044 @@ func (ou *OrderUseCase) UpdateOrder(ctx context.Context, orderID, userID string,
 		return nil, errors.New("invalid status")
 	}
 
-	order.Status = statusValue
-	err = ou.orderRepo.UpdateOrder(ctx, order)
+	if !order.Status.CanTransitionTo(statusValue) {
+		return nil, ErrOrderTransitionFailed{From: order.Status, To: statusValue}
+	}
+
+	if err := ou.changeStatus(ctx, order, statusValue, userID); err != nil {
+		return nil, err
+	}
+
+	return order, nil
+}
+
+// UpdatePaymentStatus records a pending or failed payment reported by the
+// gateway. Only the gateway itself and admins may set it. Paid and refunded
+// are only set by MarkOrderAsPaid and RefundOrder, which also record the
+// payment and the refund, and an order in either state keeps it.
+func (ou *OrderUseCase) UpdatePaymentStatus(ctx context.Context, orderID, role string, status utils.PaymentStatus) (*entity.Order, error) {
+	if role != utils.RoleAdmin && role != utils.RolePaymentGateway {
+		return nil, ErrForbidden
+	}
+
+	if !status.IsValid() {
+		return nil, ErrInvalidPaymentStatus
+	}
+
+	if status != utils.PaymentStatusPending && status != utils.PaymentStatusFailed {
+		return nil, ErrPaymentStatusNotSet
+	}
+
+	order, err := ou.orderRepo.GetOrderByID(ctx, orderID, false)
+	if err != nil {
+		return nil, err
+	}
+
+	switch order.PaymentStatus {
+	case utils.PaymentStatusPaid:
+		return nil, ErrAlreadyPaid
+	case utils.PaymentStatusRefunded:
+		return nil, ErrPaymentRefunded
+	}
+
+	order.PaymentStatus = status
+	if err := ou.orderRepo.UpdateOrder(ctx, order); err != nil {
+		return nil, err
+	}
+
+	return order, nil
+}
+
+// changeStatus saves order with its new status and records the change in the
+// audit log, in one transaction. Every status change goes through it.
+func (ou *OrderUseCase) changeStatus(ctx context.Context, order *entity.Order, status utils.OrderStatus, changedBy string) error {
+	from := order.Status
+	order.Status = status
+
+	return ou.orderRepo.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := ou.orderRepo.UpdateOrder(ctx, order); err != nil {
+			return err
+		}
+
+		return ou.orderRepo.CreateAuditLog(ctx, &entity.OrderAuditLog{
+			OrderID:    order.ID,
+			FromStatus: from,
+			ToStatus:   status,
+			ChangedBy:  changedBy,
+			ChangedAt:  time.Now(),
+		})
+	})
+}
+
+// GetAuditLogsForOrder returns the order's status changes, oldest first.
+func (ou *OrderUseCase) GetAuditLogsForOrder(ctx context.Context, orderID string) ([]*entity.OrderAuditLog, error) {
+	history, err := ou.orderRepo.GetStatusHistory(ctx, orderID)
+	if err != nil {
+		return nil, err
+	}
+
+	logs := make([]*entity.OrderAuditLog, 0, len(history))
+	for i := range history {
+		logs = append(logs, &history[i])
+	}
+
+	return logs, nil
+}
+
+// CancelOrder cancels one of the user's own orders while it is still new or in
+// progress, recording why.
+func (ou *OrderUseCase) CancelOrder(ctx context.Context, orderID, userID, reason string) (*entity.Order, error) {
+	order, err := ou.orderRepo.GetOrderByID(ctx, orderID, false)
 	if err != nil {
 		return nil, err
 	}
 
+	if userID != order.UserID {
+		return nil, ErrPermissionDenied
+	}
+
+	if !order.Status.CanTransitionTo(utils.OrderStatusCanceled) {
+		return nil, ErrOrderTransitionFailed{From: order.Status, To: utils.OrderStatusCanceled}
+	}
+
+	order.CancelReason = strings.TrimSpace(reason)
+	if err := ou.changeStatus(ctx, order, utils.OrderStatusCanceled, userID); err != nil {
+		return nil, err
+	}
+
 	return order, nil
 }
+
+// DeleteOrder removes one of the user's orders from their history. The order
+// is only soft deleted, so it is kept for auditing.
+func (ou *OrderUseCase) DeleteOrder(ctx context.Context, orderID, userID string) error {
+	order, err := ou.orderRepo.GetOrderByID(ctx, orderID, false)
+	if err != nil {
+		return err
+	}
+
+	if userID != order.UserID {
+		return ErrPermissionDenied
+	}
+
+	return ou.orderRepo.SoftDeleteOrder(ctx, order)
+}
+
+// GetOrderWithFullDetails returns the order with its shipping address, applied
+// discount and status history. Those are loaded in parallel and a failure in
+// any of them is logged and leaves that part out rather than failing the call.
+func (ou *OrderUseCase) GetOrderWithFullDetails(ctx context.Context, orderID, requesterID, role string) (*entity.OrderDetails, error) {
+	order, err := ou.orderRepo.GetOrderByID(ctx, orderID, true)
+	if err != nil {
+		return nil, err
+	}
+
+	if role != utils.RoleAdmin && requesterID != order.UserID {
+		return nil, ErrPermissionDenied
+	}
+
+	details := &entity.OrderDetails{Order: order}
+
+	g, gCtx := errgroup.WithContext(ctx)
+
+	if order.ShippingAddressID != nil {
+		g.Go(func() error {
+			address, err := ou.orderRepo.GetShippingAddress(gCtx, *order.ShippingAddressID)
+			if err != nil {
+				logger.Errorf("Failed to get shipping address, order id: %s, error: %s", orderID, err)
+				return nil
+			}
+			details.ShippingAddress = address
+			return nil
+		})
+	}
+
+	if order.DiscountID != nil {
+		g.Go(func() error {
+			discount, err := ou.orderRepo.GetDiscount(gCtx, *order.DiscountID)
+			if err != nil {
+				logger.Errorf("Failed to get discount, order id: %s, error: %s", orderID, err)
+				return nil
+			}
+			details.AppliedDiscount = discount
+			return nil
+		})
+	}
+
+	g.Go(func() error {
+		history, err := ou.orderRepo.GetStatusHistory(gCtx, orderID)
+		if err != nil {
+			logger.Errorf("Failed to get status history, order id: %s, error: %s", orderID, err)
+			return nil
+		}
+		details.StatusHistory = history
+		return nil
+	})
+
+	// Every fetch logs its own failure and leaves its field empty, so the
+	// order is returned with whatever could be loaded.
+	_ = g.Wait()
+
+	return details, nil
+}
+
+// MarkOrderAsPaid records the gateway's payment and moves a new order to in
+// progress. Repeating it with the same paymentID is a no-op. Only the gateway
+// itself and admins may mark an order as paid.
+func (ou *OrderUseCase) MarkOrderAsPaid(ctx context.Context, orderID, role, paymentID string) error {
+	if role != utils.RoleAdmin && role != utils.RolePaymentGateway {
+		return ErrForbidden
+	}
+
+	order, err := ou.orderRepo.GetOrderByID(ctx, orderID, false)
+	if err != nil {
+		return err
+	}
+
+	if order.PaymentID != nil {
+		if *order.PaymentID == paymentID {
+			return nil
+		}
+		return ErrAlreadyPaid
+	}
+
+	if order.Status != utils.OrderStatusNew && order.Status != utils.OrderStatusInProgress {
+		return ErrInvalidOrderStatus
+	}
+
+	paidAt := time.Now()
+	order.PaymentID = &paymentID
+	order.PaidAt = &paidAt
+	order.PaymentStatus = utils.PaymentStatusPaid
+
+	if order.Status == utils.OrderStatusNew {
+		return ou.changeStatus(ctx, order, utils.OrderStatusInProgress, role)
+	}
+
+	return ou.orderRepo.UpdateOrder(ctx, order)
+}
+
+func (ou *OrderUseCase) GetOrderReceipt(ctx context.Context, orderID, userID string) (*entity.Receipt, error) {
+	order, err := ou.orderRepo.GetOrderByID(ctx, orderID, true)
+	if err != nil {
+		return nil, err
+	}
+
+	if order.UserID != userID {
+		return nil, ErrPermissionDenied
+	}
+
+	receipt := &entity.Receipt{
+		OrderID:   order.ID,
+		UserID:    order.UserID,
+		IssueDate: time.Now(),
+		Lines:     make([]entity.ReceiptLine, 0, len(order.Lines)),
+	}
+
+	for _, line := range order.Lines {
+		receiptLine := entity.ReceiptLine{
+			ProductID: line.ProductID,
+			Quantity:  line.Quantity,
+			Total:     line.Price,
+		}
+		if line.Product != nil {
+			receiptLine.Name = line.Product.Name
+		}
+		if line.Quantity > 0 {
+			receiptLine.UnitPrice = roundMoney(line.Price / float64(line.Quantity))
+		}
+		receipt.Lines = append(receipt.Lines, receiptLine)
+		receipt.Subtotal += line.Price
+	}
+	receipt.Subtotal = roundMoney(receipt.Subtotal)
+
+	// Discount and tax are the amounts charged at checkout, not recomputed
+	// from the discount or tax rate as they are today.
+	receipt.Discount = order.DiscountAmount
+	receipt.Tax = order.TaxAmount
+	total := receipt.Subtotal - receipt.Discount + receipt.Tax
+	receipt.GiftCard = roundMoney(math.Min(order.GiftCardAmount, total))
+	receipt.Total = roundMoney(total - receipt.GiftCard)
+
+	return receipt, nil
+}
+
+func roundMoney(v float64) float64 {
+	return math.Round(v*100) / 100
+}
+
+// CalculateShipping quotes shipping the order's weight to destination. Only
+// the order's owner and admins may ask.
+func (ou *OrderUseCase) CalculateShipping(ctx context.Context, orderID, userID, role string, destination addressEntity.Address) (*entity.ShippingQuote, error) {
+	order, err := ou.orderRepo.GetOrderByID(ctx, orderID, true)
+	if err != nil {
+		return nil, err
+	}
+
+	if role != utils.RoleAdmin && userID != order.UserID {
+		return nil, ErrPermissionDenied
+	}
+
+	if len(order.Lines) == 0 {
+		return nil, ErrOrderEmpty
+	}
+
+	var weight float64
+	for _, line := range order.Lines {
+		if line.Product == nil {
+			continue
+		}
+		weight += line.Product.Weight * float64(line.Quantity)
+	}
+
+	if ou.shippingCalculator == nil {
+		return nil, ErrCalculatorUnavailable
+	}
+
+	quote, err := ou.shippingCalculator.Calculate(ctx, weight, destination)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrCalculatorUnavailable, err)
+	}
+
+	return quote, nil
+}
+
+// GetOrdersForUser lists targetUserID's orders. Admins may list any user's
+// orders; other users only their own.
+func (ou *OrderUseCase) GetOrdersForUser(ctx context.Context, targetUserID, requesterID, requesterRole string, req *paging.Pagination) ([]*entity.Order, *paging.Pagination, error) {
+	if requesterRole != utils.RoleAdmin && targetUserID != requesterID {
+		return nil, nil, ErrForbidden
+	}
+
+	listReq := &dto.ListOrdersRequest{UserID: targetUserID}
+	if req != nil {
+		listReq.Page = req.Page
+		listReq.Limit = req.Size
+	}
+
+	orders, pagination, err := ou.orderRepo.GetMyOrders(ctx, listReq)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return orders, pagination, nil
+}
+
+func canManageOrderNotes(role string) bool {
+	return role == utils.RoleAdmin || role == utils.RoleSupport
+}
+
+// AddOrderNote attaches an internal note to the order. Only admins and support
+// may write notes, and the order must exist.
+func (ou *OrderUseCase) AddOrderNote(ctx context.Context, orderID, note, requesterID, requesterRole string) error {
+	if !canManageOrderNotes(requesterRole) {
+		return ErrForbidden
+	}
+
+	content := strings.TrimSpace(note)
+	if content == "" {
+		return ErrEmptyNote
+	}
+
+	if _, err := ou.orderRepo.GetOrderByID(ctx, orderID, false); err != nil {
+		return err
+	}
+
+	return ou.noteRepo.CreateNote(ctx, &entity.OrderNote{
+		OrderID:   orderID,
+		Content:   content,
+		CreatedBy: requesterID,
+	})
+}
+
+func (ou *OrderUseCase) GetOrderNotes(ctx context.Context, orderID, requesterRole string) ([]*entity.OrderNote, error) {
+	if !canManageOrderNotes(requesterRole) {
+		return nil, ErrForbidden
+	}
+
+	return ou.noteRepo.ListNotes(ctx, orderID)
+}
+
+// GroupOrderLinesByCategory returns the order spend keyed by product CategoryID.
+// Only the order's owner and admins may see it.
+func (ou *OrderUseCase) GroupOrderLinesByCategory(ctx context.Context, orderID, requesterID, role string) (map[string]float64, error) {
+	order, err := ou.orderRepo.GetOrderByID(ctx, orderID, true)
+	if err != nil {
+		return nil, err
+	}
+
+	if role != utils.RoleAdmin && requesterID != order.UserID {
+		return nil, ErrPermissionDenied
+	}
+
+	totals := make(map[string]float64)
+	for _, line := range order.Lines {
+		if line.Product == nil || line.Product.CategoryID == nil {
+			return nil, ErrCategoryUnresolved
+		}
+		totals[*line.Product.CategoryID] += line.Price
+	}
+
+	return totals, nil
+}
+
+// SplitOrder moves the lines listed in splitLines to a new order that inherits
+// the shipping address and discount of the original.
+func (ou *OrderUseCase) SplitOrder(ctx context.Context, orderID, userID string, splitLines []string) ([]*entity.Order, error) {
+	order, err := ou.orderRepo.GetOrderByID(ctx, orderID, true)
+	if err != nil {
+		return nil, err
+	}
+
+	if order.UserID != userID {
+		return nil, ErrPermissionDenied
+	}
+
+	if order.Status != utils.OrderStatusNew {
+		return nil, ErrInvalidOrderStatus
+	}
+
+	if len(splitLines) == 0 || len(splitLines) >= len(order.Lines) {
+		return nil, ErrInvalidSplit
+	}
+
+	toSplit := make(map[string]struct{}, len(splitLines))
+	for _, id := range splitLines {
+		toSplit[id] = struct{}{}
+	}
+
+	split := &entity.Order{
+		UserID:            order.UserID,
+		ShippingAddressID: order.ShippingAddressID,
+		DiscountID:        order.DiscountID,
+	}
+	remaining := make([]*entity.OrderLine, 0, len(order.Lines))
+	for _, line := range order.Lines {
+		if _, ok := toSplit[line.ID]; ok {
+			split.Lines = append(split.Lines, line)
+			split.TotalPrice += line.Price
+			continue
+		}
+		remaining = append(remaining, line)
+	}
+
+	if len(split.Lines) != len(toSplit) {
+		return nil, ErrInvalidSplit
+	}
+
+	order.Lines = remaining
+	order.TotalPrice -= split.TotalPrice
+
+	if err := ou.orderRepo.SplitOrder(ctx, order, split); err != nil {
+		return nil, err
+	}
+
+	return []*entity.Order{order, split}, nil
+}
+
+// MergeOrders consolidates several new orders of userID into one, summing the
+// lines of repeated products, and cancels the source orders. Paid orders cannot
+// be merged, since canceling them would drop their payment. The merged order
+// and the cancellations are saved in one transaction.
+func (ou *OrderUseCase) MergeOrders(ctx context.Context, orderIDs []string, userID string) (*entity.Order, error) {
+	ids := make([]string, 0, len(orderIDs))
+	seen := make(map[string]struct{}, len(orderIDs))
+	for _, id := range orderIDs {
+		if _, ok := seen[id]; ok {
+			continue
+		}
+		seen[id] = struct{}{}
+		ids = append(ids, id)
+	}
+
+	if len(ids) < 2 {
+		return nil, ErrInvalidMerge
+	}
+
+	sources := make([]*entity.Order, 0, len(ids))
+	for _, id := range ids {
+		order, err := ou.orderRepo.GetOrderByID(ctx, id, true)
+		if err != nil {
+			return nil, err
+		}
+
+		if order.UserID != userID {
+			return nil, ErrPermissionDenied
+		}
+
+		if order.Status != utils.OrderStatusNew {
+			return nil, ErrInvalidOrderStatus
+		}
+
+		if order.PaymentID != nil {
+			return nil, ErrAlreadyPaid
+		}
+
+		sources = append(sources, order)
+	}
+
+	merged := &entity.Order{
+		UserID:            userID,
+		ShippingAddressID: sources[0].ShippingAddressID,
+	}
+	var lines []*entity.OrderLine
+	byProduct := make(map[string]*entity.OrderLine)
+	for _, source := range sources {
+		for _, line := range source.Lines {
+			merged.TotalPrice += line.Price
+
+			if existing, ok := byProduct[line.ProductID]; ok {
+				existing.Quantity += line.Quantity
+				existing.Price += line.Price
+				continue
+			}
+
+			mergedLine := &entity.OrderLine{
+				ProductID: line.ProductID,
+				Product:   line.Product,
+				Quantity:  line.Quantity,
+				Price:     line.Price,
+			}
+			byProduct[line.ProductID] = mergedLine
+			lines = append(lines, mergedLine)
+		}
+	}
+
+	err := ou.orderRepo.WithinTransaction(ctx, func(ctx context.Context) error {
+		if _, err := ou.orderRepo.CreateOrder(ctx, merged, lines); err != nil {
+			return err
+		}
+
+		for _, source := range sources {
+			if err := ou.changeStatus(ctx, source, utils.OrderStatusCanceled, userID); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	merged.Lines = lines
+	return merged, nil
+}
+
+// GetOrderTimeline gathers the order's status changes, notes and refund into a
+// single list, oldest first. Only the order's owner and admins may see it.
+func (ou *OrderUseCase) GetOrderTimeline(ctx context.Context, orderID, requesterID, role string) ([]entity.TimelineEvent, error) {
+	order, err := ou.orderRepo.GetOrderByID(ctx, orderID, false)
+	if err != nil {
+		return nil, err
+	}
+
+	if role != utils.RoleAdmin && requesterID != order.UserID {
+		return nil, ErrPermissionDenied
+	}
+
+	var (
+		history []entity.OrderStatusHistory
+		notes   []*entity.OrderNote
+		refund  *entity.Refund
+	)
+
+	g, gCtx := errgroup.WithContext(ctx)
+	g.Go(func() error {
+		var err error
+		history, err = ou.orderRepo.GetStatusHistory(gCtx, orderID)
+		return err
+	})
+	g.Go(func() error {
+		var err error
+		notes, err = ou.noteRepo.ListNotes(gCtx, orderID)
+		return err
+	})
+	if order.RefundID != nil {
+		g.Go(func() error {
+			var err error
+			refund, err = ou.refundRepo.GetByID(gCtx, *order.RefundID)
+			return err
+		})
+	}
+
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+
+	events := make([]entity.TimelineEvent, 0, len(history)+len(notes)+1)
+	for _, h := range history {
+		events = append(events, entity.TimelineEvent{
+			EventType:   entity.TimelineEventStatusChange,
+			Description: fmt.Sprintf("status changed from %s to %s", h.FromStatus, h.ToStatus),
+			ActorID:     h.ChangedBy,
+			OccurredAt:  h.ChangedAt,
+		})
+	}
+	for _, note := range notes {
+		events = append(events, entity.TimelineEvent{
+			EventType:   entity.TimelineEventNote,
+			Description: note.Content,
+			ActorID:     note.CreatedBy,
+			OccurredAt:  note.CreatedAt,
+		})
+	}
+	if refund != nil {
+		events = append(events, entity.TimelineEvent{
+			EventType:   entity.TimelineEventRefund,
+			Description: fmt.Sprintf("refund of %.2f: %s", refund.Amount, refund.Reason),
+			ActorID:     order.UserID,
+			OccurredAt:  refund.ProcessedAt,
+		})
+	}
+
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].OccurredAt.Before(events[j].OccurredAt)
+	})
+
+	return events, nil
+}
+
+// GetOrdersByExternalReference returns the orders a third-party platform
+// placed under externalRef. Only admins and support may look them up.
+func (ou *OrderUseCase) GetOrdersByExternalReference(ctx context.Context, externalRef, role string) ([]*entity.Order, error) {
+	if role != utils.RoleAdmin && role != utils.RoleSupport {
+		return nil, ErrForbidden
+	}
+
+	externalRef = strings.TrimSpace(externalRef)
+	if externalRef == "" || utf8.RuneCountInString(externalRef) > maxExternalRefLength {
+		return nil, ErrInvalidRef
+	}
+
+	orders, err := ou.orderRepo.GetOrdersByExternalRef(ctx, externalRef)
+	if err != nil {
+		return nil, err
+	}
+
+	if orders == nil {
+		orders = []*entity.Order{}
+	}
+
+	return orders, nil
+}
+
+// GetAverageOrderValue returns the mean total of the orders created since the
+// given time. It is a store-wide figure, so only admins may see it.
+func (ou *OrderUseCase) GetAverageOrderValue(ctx context.Context, since time.Time, role string) (float64, error) {
+	if role != utils.RoleAdmin {
+		return 0, ErrForbidden
+	}
+
+	if since.Before(time.Now().AddDate(-5, 0, 0)) {
+		return 0, ErrInvalidSince
+	}
+
+	return ou.orderRepo.AverageOrderValue(ctx, since)
+}
+
+// GetOrderFulfillmentRate returns the share of non-canceled orders created
+// since the given time that are done, between 0 and 1. It is 0 when there are
+// no non-canceled orders.
+func (ou *OrderUseCase) GetOrderFulfillmentRate(ctx context.Context, since time.Time, role string) (float64, error) {
+	if role != utils.RoleAdmin {
+		return 0, ErrForbidden
+	}
+
+	if since.Before(time.Now().AddDate(-5, 0, 0)) {
+		return 0, ErrInvalidSince
+	}
+
+	total, err := ou.orderRepo.CountNonCanceledOrdersSince(ctx, since)
+	if err != nil {
+		return 0, err
+	}
+
+	if total == 0 {
+		return 0, nil
+	}
+
+	done, err := ou.orderRepo.CountOrdersByStatusSince(ctx, utils.OrderStatusDone, since)
+	if err != nil {
+		return 0, err
+	}
+
+	return float64(done) / float64(total), nil
+}
+
+// GetRevenueByProduct returns the best selling products by revenue from done
+// orders created since the given time.
+func (ou *OrderUseCase) GetRevenueByProduct(ctx context.Context, since time.Time, limit int, role string) ([]*entity.ProductRevenue, error) {
+	if role != utils.RoleAdmin {
+		return nil, ErrForbidden
+	}
+
+	if since.Before(time.Now().AddDate(-5, 0, 0)) {
+		return nil, ErrInvalidSince
+	}
+
+	if limit < 1 || limit > 100 {
+		return nil, ErrInvalidLimit
+	}
+
+	revenue, err := ou.orderRepo.GetRevenueByProduct(ctx, since, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	if revenue == nil {
+		revenue = []*entity.ProductRevenue{}
+	}
+
+	return revenue, nil
+}
+
+// GetRepeatCustomers lists the users who became customers since the given
+// time and have placed at least minOrders orders, most orders first.
+func (ou *OrderUseCase) GetRepeatCustomers(ctx context.Context, minOrders int, since time.Time, role string) ([]*entity.RepeatCustomer, error) {
+	if role != utils.RoleAdmin {
+		return nil, ErrForbidden
+	}
+
+	if minOrders < 2 {
+		return nil, ErrInvalidMinOrders
+	}
+
+	customers, err := ou.orderRepo.GetRepeatCustomers(ctx, minOrders, since, maxRepeatCustomers)
+	if err != nil {
+		return nil, err
+	}
+
+	if customers == nil {
+		customers = []*entity.RepeatCustomer{}
+	}
+
+	return customers, nil
+}
+
+// GetUserLifetimeValue summarizes everything the user has ordered. A user with
+// no orders gets zero values.
+func (ou *OrderUseCase) GetUserLifetimeValue(ctx context.Context, userID string) (*entity.LifetimeValue, error) {
+	stats, err := ou.orderRepo.GetUserOrderStats(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	value := &entity.LifetimeValue{
+		UserID:          userID,
+		TotalOrders:     stats.TotalOrders,
+		CompletedOrders: stats.CompletedOrders,
+		CanceledOrders:  stats.CanceledOrders,
+		TotalSpend:      stats.TotalSpend,
+	}
+
+	if stats.CompletedOrders > 0 {
+		value.AverageOrderValue = math.Round(stats.TotalSpend/float64(stats.CompletedOrders)*100) / 100
+	}
+
+	if stats.FirstOrderAt != nil {
+		value.FirstOrderAt = *stats.FirstOrderAt
+	}
+
+	if stats.LastOrderAt != nil {
+		value.DaysSinceLastOrder = int(time.Since(*stats.LastOrderAt).Hours() / 24)
+	}
+
+	return value, nil
+}
+
+// GetOrderStatistics counts the user's orders, overall and per status, and
+// sums the revenue of the done ones. An empty userID covers every user and is
+// only allowed for admins; other callers may only ask about themselves.
+func (ou *OrderUseCase) GetOrderStatistics(ctx context.Context, userID, requesterID, role string) (*dto.OrderStatistics, error) {
+	if role != utils.RoleAdmin {
+		if userID == "" {
+			return nil, ErrForbidden
+		}
+		if userID != requesterID {
+			return nil, ErrPermissionDenied
+		}
+	}
+
+	stats, err := ou.orderRepo.AggregateOrderStats(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	statistics := &dto.OrderStatistics{CountByStatus: make(map[string]int, len(stats.ByStatus))}
+	for _, row := range stats.ByStatus {
+		statistics.TotalOrders += row.Orders
+		statistics.CountByStatus[string(row.Status)] = row.Orders
+		if row.Status == utils.OrderStatusDone {
+			statistics.TotalRevenue += row.Total
+		}
+	}
+
+	return statistics, nil
+}
+
+// GenerateOrderSummaryReport rolls up the orders created in the given month.
+// Revenue counts done orders; refunds are paid orders that were canceled.
+func (ou *OrderUseCase) GenerateOrderSummaryReport(ctx context.Context, month time.Month, year int, role string) (*entity.MonthlySummary, error) {
+	if role != utils.RoleAdmin {
+		return nil, ErrForbidden
+	}
+
+	if month < time.January || month > time.December || year < 2020 || year > time.Now().Year() {
+		return nil, ErrInvalidPeriod
+	}
+
+	from := time.Date(year, month, 1, 0, 0, 0, 0, time.UTC)
+	totals, err := ou.orderRepo.GetOrderTotalsByStatus(ctx, from, from.AddDate(0, 1, 0))
+	if err != nil {
+		return nil, err
+	}
+
+	summary := &entity.MonthlySummary{Month: int(month), Year: year}
+	for _, row := range totals {
+		summary.TotalOrders += row.Orders
+		switch row.Status {
+		case utils.OrderStatusDone:
+			summary.CompletedOrders += row.Orders
+			summary.TotalRevenue += row.Total
+		case utils.OrderStatusCanceled:
+			summary.CanceledOrders += row.Orders
+			summary.TotalRefunds += row.PaidTotal
+		}
+	}
+
+	if summary.CompletedOrders > 0 {
+		summary.AverageOrderValue = roundMoney(summary.TotalRevenue / float64(summary.CompletedOrders))
+	}
+	summary.TotalRevenue = roundMoney(summary.TotalRevenue)
+	summary.TotalRefunds = roundMoney(summary.TotalRefunds)
+
+	return summary, nil
+}
+
+// RefundOrder records a full refund for a canceled, paid order of the user.
+// The refund and the order's link to it are saved in one transaction.
+func (ou *OrderUseCase) RefundOrder(ctx context.Context, orderID, userID, reason string) (*entity.Refund, error) {
+	order, err := ou.orderRepo.GetOrderByID(ctx, orderID, false)
+	if err != nil {
+		return nil, err
+	}
+
+	if order.UserID != userID {
+		return nil, ErrPermissionDenied
+	}
+
+	if order.RefundID != nil {
+		return nil, ErrAlreadyRefunded
+	}
+
+	if order.Status != utils.OrderStatusCanceled {
+		return nil, ErrInvalidOrderStatus
+	}
+
+	if order.PaymentID == nil || order.PaidAt == nil {
+		return nil, ErrOrderNotPaid
+	}
+
+	refund := &entity.Refund{
+		OrderID:     order.ID,
+		Amount:      order.TotalPrice,
+		Reason:      reason,
+		ProcessedAt: time.Now(),
+		Status:      entity.RefundStatusProcessed,
+	}
+	err = ou.orderRepo.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := ou.refundRepo.Create(ctx, refund); err != nil {
+			return err
+		}
+
+		order.RefundID = &refund.ID
+		order.PaymentStatus = utils.PaymentStatusRefunded
+		return ou.orderRepo.UpdateOrder(ctx, order)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return refund, nil
+}
+
+func (ou *OrderUseCase) ListOrdersByShippingAddress(ctx context.Context, addressID, userID string, req *paging.Pagination) ([]*entity.Order, *paging.Pagination, error) {
+	address, err := ou.addressRepo.GetAddressByID(ctx, addressID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if address.UserID != userID {
+		return nil, nil, ErrAddressNotOwned
+	}
+
+	return ou.orderRepo.GetOrdersByShippingAddress(ctx, addressID, req)
+}
+
+// AttachReceiptEmail sets the address the receipt of an open order is sent to.
+// Only the order's owner or an admin may set it.
+func (ou *OrderUseCase) AttachReceiptEmail(ctx context.Context, orderID, userID, role, email string) error {
+	if err := ou.validator.ValidateStruct(&dto.AttachReceiptEmailRequest{Email: email}); err != nil {
+		return ErrInvalidEmail
+	}
+
+	order, err := ou.orderRepo.GetOrderByID(ctx, orderID, false)
+	if err != nil {
+		return err
+	}
+
+	if order.UserID != userID && role != utils.RoleAdmin {
+		return ErrPermissionDenied
+	}
+
+	if order.Status != utils.OrderStatusNew && order.Status != utils.OrderStatusInProgress {
+		return ErrInvalidOrderStatus
+	}
+
+	order.ReceiptEmail = &email
+	return ou.orderRepo.UpdateOrder(ctx, order)
+}
+
+// RecalculateOrderTotal reprices every line of an open order from the current
+// product prices, for admins fixing a retroactive price correction.
+func (ou *OrderUseCase) RecalculateOrderTotal(ctx context.Context, orderID, role string) error {
+	if role != utils.RoleAdmin {
+		return ErrForbidden
+	}
+
+	order, err := ou.orderRepo.GetOrderByID(ctx, orderID, true)
+	if err != nil {
+		return err
+	}
+
+	if order.Status == utils.OrderStatusDone || order.Status == utils.OrderStatusCanceled {
+		return ErrOrderFinalized
+	}
+
+	var total float64
+	for _, line := range order.Lines {
+		product, err := ou.productRepo.GetProductById(ctx, line.ProductID)
+		if err != nil {
+			return err
+		}
+		line.Price = product.Price * float64(line.Quantity)
+		total += line.Price
+	}
+
+	oldTotal := order.TotalPrice
+	order.TotalPrice = total
+	if err := ou.orderRepo.UpdateOrderWithLines(ctx, order); err != nil {
+		return err
+	}
+
+	logger.Infof("Order %s total recalculated: %.2f -> %.2f", order.ID, oldTotal, total)
+	return nil
+}
+
+// GetOrdersByPaymentStatus lists paid or unpaid orders for finance
+// reconciliation. The page and the overall total are queried concurrently.
+func (ou *OrderUseCase) GetOrdersByPaymentStatus(ctx context.Context, isPaid bool, role string, req *paging.Pagination) ([]*entity.Order, *entity.PaymentStatusMeta, error) {
+	if role != utils.RoleAdmin {
+		return nil, nil, ErrForbidden
+	}
+
+	var (
+		orders []*entity.Order
+		meta   = &entity.PaymentStatusMeta{}
+	)
+
+	g, gCtx := errgroup.WithContext(ctx)
+
+	g.Go(func() error {
+		var err error
+		orders, meta.Pagination, err = ou.orderRepo.GetOrdersByPaymentStatus(gCtx, isPaid, req)
+		return err
+	})
+
+	g.Go(func() error {
+		var err error
+		meta.TotalAmount, err = ou.orderRepo.SumOrdersByPaymentStatus(gCtx, isPaid)
+		return err
+	})
+
+	if err := g.Wait(); err != nil {
+		return nil, nil, err
+	}
+
+	return orders, meta, nil
+}
+
+// normalizeTag lowercases tag and checks it only uses letters, digits, "-"
+// and "_".
+func normalizeTag(tag string) (string, error) {
+	tag = strings.ToLower(strings.TrimSpace(tag))
+	if !tagPattern.MatchString(tag) {
+		return "", ErrInvalidTag
+	}
+	return tag, nil
+}
+
+func (ou *OrderUseCase) AddTagToOrder(ctx context.Context, orderID, tag, role string) error {
+	if role != utils.RoleAdmin {
+		return ErrForbidden
+	}
+
+	tag, err := normalizeTag(tag)
+	if err != nil {
+		return err
+	}
+
+	order, err := ou.orderRepo.GetOrderByID(ctx, orderID, false)
+	if err != nil {
+		return err
+	}
+
+	if order.HasTag(tag) {
+		return nil
+	}
+
+	order.Tags = append(order.Tags, tag)
+	return ou.orderRepo.UpdateOrder(ctx, order)
+}
+
+func (ou *OrderUseCase) RemoveTagFromOrder(ctx context.Context, orderID, tag, role string) error {
+	if role != utils.RoleAdmin {
+		return ErrForbidden
+	}
+
+	tag, err := normalizeTag(tag)
+	if err != nil {
+		return err
+	}
+
+	order, err := ou.orderRepo.GetOrderByID(ctx, orderID, false)
+	if err != nil {
+		return err
+	}
+
+	if !order.HasTag(tag) {
+		return nil
+	}
+
+	tags := make([]string, 0, len(order.Tags)-1)
+	for _, t := range order.Tags {
+		if t != tag {
+			tags = append(tags, t)
+		}
+	}
+	order.Tags = tags
+	return ou.orderRepo.UpdateOrder(ctx, order)
+}
+
+func (ou *OrderUseCase) ListOrdersByTag(ctx context.Context, tag, role string, req *paging.Pagination) ([]*entity.Order, *paging.Pagination, error) {
+	if role != utils.RoleAdmin {
+		return nil, nil, ErrForbidden
+	}
+
+	tag, err := normalizeTag(tag)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return ou.orderRepo.GetOrdersByTag(ctx, tag, req)
+}
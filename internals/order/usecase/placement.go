@@ -0,0 +1,65 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"sort"
+
+	"ecommerce_clean/internals/order/entity"
+	"ecommerce_clean/internals/order/repository"
+	productRepo "ecommerce_clean/internals/product/repository"
+	"ecommerce_clean/utils"
+
+	"gorm.io/gorm"
+)
+
+// idempotencyKeyConstraint is the unique index GORM names for
+// Order.IdempotencyKey.
+const idempotencyKeyConstraint = "idx_orders_idempotency_key"
+
+// FindIdempotentOrder returns the order placed earlier with key by userID, or
+// nil if none was. A key used by another user gives ErrIdempotencyKeyConflict.
+func FindIdempotentOrder(ctx context.Context, orders repository.IOrderRepository, key, userID string) (*entity.Order, error) {
+	existing, err := orders.FindOrderByIdempotencyKey(ctx, key)
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return nil, nil
+	case err != nil:
+		return nil, err
+	case existing.UserID != userID:
+		return nil, ErrIdempotencyKeyConflict
+	}
+	return existing, nil
+}
+
+// IsIdempotencyKeyTaken reports whether err is CreateOrder losing the race for
+// an idempotency key to a concurrent request.
+func IsIdempotencyKeyTaken(err error) bool {
+	return utils.ExtractConstraintName(err) == idempotencyKeyConstraint
+}
+
+// TakeStock takes each line's quantity from its product's stock. Rows are
+// locked in product ID order so concurrent orders cannot deadlock. It must run
+// inside a transaction.
+func TakeStock(ctx context.Context, products productRepo.IProductRepository, lines []*entity.OrderLine) error {
+	sorted := make([]*entity.OrderLine, len(lines))
+	copy(sorted, lines)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].ProductID < sorted[j].ProductID })
+
+	for _, line := range sorted {
+		stock, err := products.GetProductStockForUpdate(ctx, line.ProductID, productRepo.WithPessimisticLock())
+		if err != nil {
+			return err
+		}
+
+		if stock < int(line.Quantity) {
+			return ErrInsufficientStock
+		}
+
+		if err := products.UpdateProductStock(ctx, line.ProductID, stock-int(line.Quantity)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,34 @@
+package usecase
+
+import (
+	"context"
+	addressEntity "ecommerce_clean/internals/address/entity"
+	"ecommerce_clean/internals/order/entity"
+)
+
+type ShippingCalculator interface {
+	Calculate(ctx context.Context, weight float64, destination addressEntity.Address) (*entity.ShippingQuote, error)
+}
+
+// FlatRateShippingCalculator charges a base cost plus a fixed amount per kg,
+// regardless of destination.
+type FlatRateShippingCalculator struct {
+	baseCost  float64
+	costPerKg float64
+}
+
+func NewFlatRateShippingCalculator(baseCost, costPerKg float64) *FlatRateShippingCalculator {
+	return &FlatRateShippingCalculator{
+		baseCost:  baseCost,
+		costPerKg: costPerKg,
+	}
+}
+
+func (c *FlatRateShippingCalculator) Calculate(ctx context.Context, weight float64, destination addressEntity.Address) (*entity.ShippingQuote, error) {
+	return &entity.ShippingQuote{
+		Carrier:       "flat_rate",
+		Weight:        weight,
+		Cost:          roundMoney(c.baseCost + weight*c.costPerKg),
+		EstimatedDays: 5,
+	}, nil
+}
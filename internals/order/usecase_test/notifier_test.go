@@ -0,0 +1,113 @@
+package usecase_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	orderEntity "ecommerce_clean/internals/order/entity"
+	"ecommerce_clean/internals/order/usecase"
+	userDto "ecommerce_clean/internals/user/controller/dto"
+	userEntity "ecommerce_clean/internals/user/entity"
+	"ecommerce_clean/pkgs/paging"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockMailer struct {
+	mock.Mock
+}
+
+func (m *MockMailer) Send(to string, subject string, body string, isHTML bool) error {
+	args := m.Called(to, subject, body, isHTML)
+	return args.Error(0)
+}
+
+type MockUserRepository struct {
+	mock.Mock
+}
+
+func (m *MockUserRepository) ListUsers(ctx context.Context, req *userDto.ListUserRequest) ([]*userEntity.User, *paging.Pagination, error) {
+	args := m.Called(ctx, req)
+	return args.Get(0).([]*userEntity.User), args.Get(1).(*paging.Pagination), args.Error(2)
+}
+
+func (m *MockUserRepository) GetUserById(ctx context.Context, id string) (*userEntity.User, error) {
+	args := m.Called(ctx, id)
+	var user *userEntity.User
+	if v := args.Get(0); v != nil {
+		user = v.(*userEntity.User)
+	}
+	return user, args.Error(1)
+}
+
+func (m *MockUserRepository) GetUserByEmail(ctx context.Context, email string) (*userEntity.User, error) {
+	args := m.Called(ctx, email)
+	var user *userEntity.User
+	if v := args.Get(0); v != nil {
+		user = v.(*userEntity.User)
+	}
+	return user, args.Error(1)
+}
+
+func (m *MockUserRepository) CreateUser(ctx context.Context, user *userEntity.User) error {
+	return m.Called(ctx, user).Error(0)
+}
+
+func (m *MockUserRepository) UpdateUser(ctx context.Context, user *userEntity.User) error {
+	return m.Called(ctx, user).Error(0)
+}
+
+func (m *MockUserRepository) DeleteUser(ctx context.Context, user *userEntity.User) error {
+	return m.Called(ctx, user).Error(0)
+}
+
+// TestMailNotifier_OrderCreated_ReceiptEmail verifica que el aviso de pedido
+// creado va al correo del recibo sin consultar al dueño del pedido.
+func TestMailNotifier_OrderCreated_ReceiptEmail(t *testing.T) {
+	mockMailer := new(MockMailer)
+	mockUserRepo := new(MockUserRepository)
+	notifier := usecase.NewMailNotifier(mockMailer, mockUserRepo)
+
+	email := "gift@example.com"
+	mockMailer.On("Send", email, mock.Anything, mock.Anything, true).Return(nil)
+
+	err := notifier.OrderCreated(context.Background(), &orderEntity.Order{ID: "o1", UserID: "u1", ReceiptEmail: &email})
+
+	assert.NoError(t, err)
+	mockMailer.AssertExpectations(t)
+	mockUserRepo.AssertNotCalled(t, "GetUserById", mock.Anything, mock.Anything)
+}
+
+// TestMailNotifier_OrderCreated_OwnerEmail verifica que sin correo de recibo
+// el aviso va al correo de la cuenta del dueño del pedido.
+func TestMailNotifier_OrderCreated_OwnerEmail(t *testing.T) {
+	mockMailer := new(MockMailer)
+	mockUserRepo := new(MockUserRepository)
+	notifier := usecase.NewMailNotifier(mockMailer, mockUserRepo)
+
+	mockUserRepo.On("GetUserById", mock.Anything, "u1").Return(&userEntity.User{ID: "u1", Email: "owner@example.com"}, nil)
+	mockMailer.On("Send", "owner@example.com", mock.Anything, mock.Anything, true).Return(nil)
+
+	err := notifier.OrderCreated(context.Background(), &orderEntity.Order{ID: "o1", UserID: "u1"})
+
+	assert.NoError(t, err)
+	mockMailer.AssertExpectations(t)
+}
+
+// TestMailNotifier_OrderCreated_OwnerNotFound verifica que si no se puede leer
+// al dueño del pedido se devuelve el error sin enviar correo.
+func TestMailNotifier_OrderCreated_OwnerNotFound(t *testing.T) {
+	mockMailer := new(MockMailer)
+	mockUserRepo := new(MockUserRepository)
+	notifier := usecase.NewMailNotifier(mockMailer, mockUserRepo)
+
+	repoErr := errors.New("db down")
+	mockUserRepo.On("GetUserById", mock.Anything, "u1").Return(nil, repoErr)
+
+	err := notifier.OrderCreated(context.Background(), &orderEntity.Order{ID: "o1", UserID: "u1"})
+
+	assert.ErrorIs(t, err, repoErr)
+	mockMailer.AssertNotCalled(t, "Send", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
@@ -1,20 +1,33 @@
 package usecase_test
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
+	addressEntity "ecommerce_clean/internals/address/entity"
+	discountEntity "ecommerce_clean/internals/discount/entity"
 	orderDto "ecommerce_clean/internals/order/controller/dto"
 	orderEntity "ecommerce_clean/internals/order/entity"
 	"ecommerce_clean/internals/order/usecase"
-	prodDto "ecommerce_clean/internals/product/controller/dto"
 	productEntity "ecommerce_clean/internals/product/entity"
+	"ecommerce_clean/internals/product/repository/mocks"
+	"ecommerce_clean/pkgs/logger"
 	"ecommerce_clean/pkgs/paging"
+	"ecommerce_clean/pkgs/validation"
 	"ecommerce_clean/utils"
 
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"gorm.io/gorm"
 )
 
 // -------------------
@@ -25,9 +38,14 @@ type MockOrderRepository struct {
 	mock.Mock
 }
 
-func (m *MockOrderRepository) CreateOrder(ctx context.Context, userID string, lines []*orderEntity.OrderLine) (*orderEntity.Order, error) {
-	args := m.Called(ctx, userID, lines)
-	return args.Get(0).(*orderEntity.Order), args.Error(1)
+// CreateOrder devuelve el pedido configurado en el mock o, si es nil, el mismo
+// pedido recibido, como hace el repositorio real.
+func (m *MockOrderRepository) CreateOrder(ctx context.Context, order *orderEntity.Order, lines []*orderEntity.OrderLine) (*orderEntity.Order, error) {
+	args := m.Called(ctx, order, lines)
+	if v := args.Get(0); v != nil {
+		return v.(*orderEntity.Order), args.Error(1)
+	}
+	return order, args.Error(1)
 }
 
 func (m *MockOrderRepository) GetOrderByID(ctx context.Context, id string, preload bool) (*orderEntity.Order, error) {
@@ -35,6 +53,64 @@ func (m *MockOrderRepository) GetOrderByID(ctx context.Context, id string, prelo
 	return args.Get(0).(*orderEntity.Order), args.Error(1)
 }
 
+func (m *MockOrderRepository) SoftDeleteOrder(ctx context.Context, order *orderEntity.Order) error {
+	args := m.Called(ctx, order)
+	return args.Error(0)
+}
+
+func (m *MockOrderRepository) FindOrderByIdempotencyKey(ctx context.Context, key string) (*orderEntity.Order, error) {
+	args := m.Called(ctx, key)
+	var order *orderEntity.Order
+	if v := args.Get(0); v != nil {
+		order = v.(*orderEntity.Order)
+	}
+	return order, args.Error(1)
+}
+
+func (m *MockOrderRepository) GetOrdersByIDs(ctx context.Context, ids []string, preload bool) ([]*orderEntity.Order, error) {
+	args := m.Called(ctx, ids, preload)
+	var orders []*orderEntity.Order
+	if v := args.Get(0); v != nil {
+		orders = v.([]*orderEntity.Order)
+	}
+	return orders, args.Error(1)
+}
+
+// StreamOrders feeds the orders given as the first return value to fn, then
+// returns the second return value.
+func (m *MockOrderRepository) StreamOrders(ctx context.Context, req *orderDto.OrderExportRequest, fn func(*orderEntity.Order) error) error {
+	args := m.Called(ctx, req)
+	if v := args.Get(0); v != nil {
+		for _, order := range v.([]*orderEntity.Order) {
+			if err := fn(order); err != nil {
+				return err
+			}
+		}
+	}
+	return args.Error(1)
+}
+
+func (m *MockOrderRepository) AggregateOrderStats(ctx context.Context, userID string) (*orderEntity.OrderStats, error) {
+	args := m.Called(ctx, userID)
+	if v := args.Get(0); v != nil {
+		return v.(*orderEntity.OrderStats), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockOrderRepository) ListAllOrders(ctx context.Context, req *orderDto.AdminListOrdersRequest) ([]*orderEntity.Order, *paging.Pagination, error) {
+	args := m.Called(ctx, req)
+	var orders []*orderEntity.Order
+	if v := args.Get(0); v != nil {
+		orders = v.([]*orderEntity.Order)
+	}
+	var page *paging.Pagination
+	if v := args.Get(1); v != nil {
+		page = v.(*paging.Pagination)
+	}
+	return orders, page, args.Error(2)
+}
+
 func (m *MockOrderRepository) GetMyOrders(ctx context.Context, req *orderDto.ListOrdersRequest) ([]*orderEntity.Order, *paging.Pagination, error) {
 	args := m.Called(ctx, req)
 	var orders []*orderEntity.Order
@@ -53,33 +129,248 @@ func (m *MockOrderRepository) UpdateOrder(ctx context.Context, order *orderEntit
 	return args.Error(0)
 }
 
-type MockProductRepository struct {
+func (m *MockOrderRepository) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+func (m *MockOrderRepository) GetShippingAddress(ctx context.Context, addressID string) (*addressEntity.Address, error) {
+	args := m.Called(ctx, addressID)
+	if v := args.Get(0); v != nil {
+		return v.(*addressEntity.Address), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockOrderRepository) GetDiscount(ctx context.Context, discountID string) (*discountEntity.Discount, error) {
+	args := m.Called(ctx, discountID)
+	if v := args.Get(0); v != nil {
+		return v.(*discountEntity.Discount), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockOrderRepository) GetDiscountByCode(ctx context.Context, code string) (*discountEntity.Discount, error) {
+	args := m.Called(ctx, code)
+	if v := args.Get(0); v != nil {
+		return v.(*discountEntity.Discount), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockOrderRepository) CreateAuditLog(ctx context.Context, log *orderEntity.OrderAuditLog) error {
+	args := m.Called(ctx, log)
+	return args.Error(0)
+}
+
+func (m *MockOrderRepository) GetStatusHistory(ctx context.Context, orderID string) ([]orderEntity.OrderStatusHistory, error) {
+	args := m.Called(ctx, orderID)
+	var history []orderEntity.OrderStatusHistory
+	if v := args.Get(0); v != nil {
+		history = v.([]orderEntity.OrderStatusHistory)
+	}
+	return history, args.Error(1)
+}
+
+func (m *MockOrderRepository) UpdateOrderWithLines(ctx context.Context, order *orderEntity.Order) error {
+	args := m.Called(ctx, order)
+	return args.Error(0)
+}
+
+func (m *MockOrderRepository) GetOrdersByExternalRef(ctx context.Context, ref string) ([]*orderEntity.Order, error) {
+	args := m.Called(ctx, ref)
+	var orders []*orderEntity.Order
+	if v := args.Get(0); v != nil {
+		orders = v.([]*orderEntity.Order)
+	}
+	return orders, args.Error(1)
+}
+
+func (m *MockOrderRepository) GetRevenueByProduct(ctx context.Context, since time.Time, limit int) ([]*orderEntity.ProductRevenue, error) {
+	args := m.Called(ctx, since, limit)
+	var revenue []*orderEntity.ProductRevenue
+	if v := args.Get(0); v != nil {
+		revenue = v.([]*orderEntity.ProductRevenue)
+	}
+	return revenue, args.Error(1)
+}
+
+func (m *MockOrderRepository) GetRepeatCustomers(ctx context.Context, minOrders int, since time.Time, limit int) ([]*orderEntity.RepeatCustomer, error) {
+	args := m.Called(ctx, minOrders, since, limit)
+	var customers []*orderEntity.RepeatCustomer
+	if v := args.Get(0); v != nil {
+		customers = v.([]*orderEntity.RepeatCustomer)
+	}
+	return customers, args.Error(1)
+}
+
+func (m *MockOrderRepository) CountOrdersByStatusSince(ctx context.Context, status utils.OrderStatus, since time.Time) (int64, error) {
+	args := m.Called(ctx, status, since)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockOrderRepository) CountNonCanceledOrdersSince(ctx context.Context, since time.Time) (int64, error) {
+	args := m.Called(ctx, since)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockOrderRepository) GetUserOrderStats(ctx context.Context, userID string) (*orderEntity.UserOrderStats, error) {
+	args := m.Called(ctx, userID)
+	var stats *orderEntity.UserOrderStats
+	if v := args.Get(0); v != nil {
+		stats = v.(*orderEntity.UserOrderStats)
+	}
+	return stats, args.Error(1)
+}
+
+func (m *MockOrderRepository) SplitOrder(ctx context.Context, original *orderEntity.Order, split *orderEntity.Order) error {
+	args := m.Called(ctx, original, split)
+	return args.Error(0)
+}
+
+func (m *MockOrderRepository) AverageOrderValue(ctx context.Context, since time.Time) (float64, error) {
+	args := m.Called(ctx, since)
+	return args.Get(0).(float64), args.Error(1)
+}
+
+func (m *MockOrderRepository) GetOrderTotalsByStatus(ctx context.Context, from, to time.Time) ([]*orderEntity.StatusTotals, error) {
+	args := m.Called(ctx, from, to)
+	if v := args.Get(0); v != nil {
+		return v.([]*orderEntity.StatusTotals), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockOrderRepository) GetOrdersByShippingAddress(ctx context.Context, addressID string, req *paging.Pagination) ([]*orderEntity.Order, *paging.Pagination, error) {
+	args := m.Called(ctx, addressID, req)
+	var orders []*orderEntity.Order
+	if v := args.Get(0); v != nil {
+		orders = v.([]*orderEntity.Order)
+	}
+	var pagination *paging.Pagination
+	if v := args.Get(1); v != nil {
+		pagination = v.(*paging.Pagination)
+	}
+	return orders, pagination, args.Error(2)
+}
+
+func (m *MockOrderRepository) GetOrdersByPaymentStatus(ctx context.Context, isPaid bool, req *paging.Pagination) ([]*orderEntity.Order, *paging.Pagination, error) {
+	args := m.Called(ctx, isPaid, req)
+	var orders []*orderEntity.Order
+	if v := args.Get(0); v != nil {
+		orders = v.([]*orderEntity.Order)
+	}
+	var pagination *paging.Pagination
+	if v := args.Get(1); v != nil {
+		pagination = v.(*paging.Pagination)
+	}
+	return orders, pagination, args.Error(2)
+}
+
+func (m *MockOrderRepository) SumOrdersByPaymentStatus(ctx context.Context, isPaid bool) (float64, error) {
+	args := m.Called(ctx, isPaid)
+	return args.Get(0).(float64), args.Error(1)
+}
+
+func (m *MockOrderRepository) GetOrdersByTag(ctx context.Context, tag string, req *paging.Pagination) ([]*orderEntity.Order, *paging.Pagination, error) {
+	args := m.Called(ctx, tag, req)
+	var orders []*orderEntity.Order
+	if v := args.Get(0); v != nil {
+		orders = v.([]*orderEntity.Order)
+	}
+	var pagination *paging.Pagination
+	if v := args.Get(1); v != nil {
+		pagination = v.(*paging.Pagination)
+	}
+	return orders, pagination, args.Error(2)
+}
+
+func (m *MockOrderRepository) GetOpenOrdersContainingProduct(ctx context.Context, productID string) ([]*orderEntity.Order, error) {
+	args := m.Called(ctx, productID)
+	var orders []*orderEntity.Order
+	if v := args.Get(0); v != nil {
+		orders = v.([]*orderEntity.Order)
+	}
+	return orders, args.Error(1)
+}
+
+type MockOrderNoteRepository struct {
+	mock.Mock
+}
+
+func (m *MockOrderNoteRepository) CreateNote(ctx context.Context, note *orderEntity.OrderNote) error {
+	args := m.Called(ctx, note)
+	return args.Error(0)
+}
+
+func (m *MockOrderNoteRepository) ListNotes(ctx context.Context, orderID string) ([]*orderEntity.OrderNote, error) {
+	args := m.Called(ctx, orderID)
+	var notes []*orderEntity.OrderNote
+	if v := args.Get(0); v != nil {
+		notes = v.([]*orderEntity.OrderNote)
+	}
+	return notes, args.Error(1)
+}
+
+type MockRefundRepository struct {
 	mock.Mock
 }
 
-func (m *MockProductRepository) ListProducts(ctx context.Context, req *prodDto.ListProductRequest) ([]*productEntity.Product, *paging.Pagination, error) {
-	return nil, nil, nil
+func (m *MockRefundRepository) Create(ctx context.Context, refund *orderEntity.Refund) error {
+	args := m.Called(ctx, refund)
+	if args.Error(0) == nil {
+		refund.ID = "r1"
+	}
+	return args.Error(0)
+}
+
+func (m *MockRefundRepository) GetByID(ctx context.Context, id string) (*orderEntity.Refund, error) {
+	args := m.Called(ctx, id)
+	var refund *orderEntity.Refund
+	if v := args.Get(0); v != nil {
+		refund = v.(*orderEntity.Refund)
+	}
+	return refund, args.Error(1)
+}
+
+type MockAddressRepository struct {
+	mock.Mock
 }
 
-// GetProductById ahora maneja return nil sin panic.
-func (m *MockProductRepository) GetProductById(ctx context.Context, id string) (*productEntity.Product, error) {
+func (m *MockAddressRepository) GetAddressByID(ctx context.Context, id string) (*addressEntity.Address, error) {
 	args := m.Called(ctx, id)
 	if v := args.Get(0); v != nil {
-		return v.(*productEntity.Product), args.Error(1)
+		return v.(*addressEntity.Address), args.Error(1)
 	}
 	return nil, args.Error(1)
 }
 
-func (m *MockProductRepository) CreatedProduct(ctx context.Context, p *productEntity.Product) error {
-	return nil
+type MockShippingCalculator struct {
+	mock.Mock
+}
+
+func (m *MockShippingCalculator) Calculate(ctx context.Context, weight float64, destination addressEntity.Address) (*orderEntity.ShippingQuote, error) {
+	args := m.Called(ctx, weight, destination)
+	var quote *orderEntity.ShippingQuote
+	if v := args.Get(0); v != nil {
+		quote = v.(*orderEntity.ShippingQuote)
+	}
+	return quote, args.Error(1)
+}
+
+type MockNotifier struct {
+	mock.Mock
 }
 
-func (m *MockProductRepository) UpdateProduct(ctx context.Context, p *productEntity.Product) error {
-	return nil
+func (m *MockNotifier) OrderCreated(ctx context.Context, order *orderEntity.Order) error {
+	args := m.Called(ctx, order)
+	return args.Error(0)
 }
 
-func (m *MockProductRepository) DeleteProduct(ctx context.Context, p *productEntity.Product) error {
-	return nil
+// newMockNotifier returns a notifier that accepts every order.
+func newMockNotifier() *MockNotifier {
+	notifier := new(MockNotifier)
+	notifier.On("OrderCreated", mock.Anything, mock.Anything).Return(nil)
+	return notifier
 }
 
 type MockValidator struct {
@@ -90,6 +381,13 @@ func (m *MockValidator) ValidateStruct(i interface{}) error {
 	return m.Called(i).Error(0)
 }
 
+// TestMain inicializa el logger global, necesario para los casos de uso
+// que registran errores no críticos.
+func TestMain(m *testing.M) {
+	logger.Initialize("test")
+	os.Exit(m.Run())
+}
+
 // -------------------------------------
 // Tests de PlaceOrder
 // -------------------------------------
@@ -98,16 +396,18 @@ func (m *MockValidator) ValidateStruct(i interface{}) error {
 // 1) Valida la entrada.
 // 2) Recupera el producto.
 // 3) Calcula precio de líneas.
-// 4) Crea el pedido con líneas y total correctos.
+// 4) Descuenta el stock de cada línea.
+// 5) Crea el pedido con líneas y total correctos.
 func TestPlaceOrder_Success(t *testing.T) {
 	mockOrderRepo := new(MockOrderRepository)
-	mockProductRepo := new(MockProductRepository)
+	mockProductRepo := new(mocks.ProductRepository)
 	mockValidator := new(MockValidator)
 
-	uc := usecase.NewOrderUseCase(mockValidator, mockOrderRepo, mockProductRepo)
+	uc := usecase.NewOrderUseCase(mockValidator, mockOrderRepo, mockProductRepo, nil, nil, nil, nil, newMockNotifier())
 
 	req := &orderDto.PlaceOrderRequest{
-		UserID: "u1",
+		UserID:         "u1",
+		IdempotencyKey: "k1",
 		Lines: []orderDto.PlaceOrderLineRequest{
 			{ProductID: "p1", Quantity: 2},
 		},
@@ -115,9 +415,12 @@ func TestPlaceOrder_Success(t *testing.T) {
 	prod := &productEntity.Product{ID: "p1", Price: 50.0}
 
 	mockValidator.On("ValidateStruct", req).Return(nil)
+	mockOrderRepo.On("FindOrderByIdempotencyKey", mock.Anything, "k1").Return(nil, gorm.ErrRecordNotFound)
 	mockProductRepo.On("GetProductById", mock.Anything, "p1").Return(prod, nil)
+	mockProductRepo.On("GetProductStockForUpdate", mock.Anything, "p1").Return(5, nil)
+	mockProductRepo.On("UpdateProductStock", mock.Anything, "p1", 3).Return(nil)
 	mockOrderRepo.
-		On("CreateOrder", mock.Anything, "u1", mock.Anything).
+		On("CreateOrder", mock.Anything, mock.MatchedBy(func(o *orderEntity.Order) bool { return o.UserID == "u1" }), mock.Anything).
 		Return(&orderEntity.Order{
 			UserID:     "u1",
 			Lines:      []*orderEntity.OrderLine{{ProductID: "p1", Quantity: 2, Price: 100.0}},
@@ -131,139 +434,569 @@ func TestPlaceOrder_Success(t *testing.T) {
 		assert.Equal(t, prod, order.Lines[0].Product)
 		assert.Equal(t, 100.0, order.Lines[0].Price)
 	}
+	mockProductRepo.AssertExpectations(t)
 }
 
-// TestPlaceOrder_ValidationError verifica que PlaceOrder devuelve error
-// cuando la validación de la petición falla.
-func TestPlaceOrder_ValidationError(t *testing.T) {
+// TestPlaceOrder_NotesAndDeliveryAddress verifica que las notas y la
+// dirección de entrega de la petición llegan sin cambios al pedido creado.
+func TestPlaceOrder_NotesAndDeliveryAddress(t *testing.T) {
 	mockOrderRepo := new(MockOrderRepository)
-	mockProductRepo := new(MockProductRepository)
+	mockProductRepo := new(mocks.ProductRepository)
 	mockValidator := new(MockValidator)
 
-	uc := usecase.NewOrderUseCase(mockValidator, mockOrderRepo, mockProductRepo)
+	uc := usecase.NewOrderUseCase(mockValidator, mockOrderRepo, mockProductRepo, nil, nil, nil, nil, newMockNotifier())
 
-	req := &orderDto.PlaceOrderRequest{UserID: "", Lines: nil}
-	mockValidator.On("ValidateStruct", req).Return(errors.New("invalid input"))
+	address := orderEntity.Address{
+		Street:     "Calle Mayor 1",
+		City:       "Madrid",
+		State:      "Madrid",
+		PostalCode: "28013",
+		Country:    "ES",
+	}
+	req := &orderDto.PlaceOrderRequest{
+		UserID:          "u1",
+		IdempotencyKey:  "k1",
+		Lines:           []orderDto.PlaceOrderLineRequest{{ProductID: "p1", Quantity: 1}},
+		Notes:           "Leave at the door",
+		DeliveryAddress: address,
+	}
+
+	mockValidator.On("ValidateStruct", req).Return(nil)
+	mockOrderRepo.On("FindOrderByIdempotencyKey", mock.Anything, "k1").Return(nil, gorm.ErrRecordNotFound)
+	mockProductRepo.On("GetProductById", mock.Anything, "p1").Return(&productEntity.Product{ID: "p1", Price: 10.0}, nil)
+	mockProductRepo.On("GetProductStockForUpdate", mock.Anything, "p1").Return(5, nil)
+	mockProductRepo.On("UpdateProductStock", mock.Anything, "p1", 4).Return(nil)
+	mockOrderRepo.On("CreateOrder", mock.Anything, mock.Anything, mock.Anything).Return(nil, nil)
 
 	order, err := uc.PlaceOrder(context.Background(), req)
 
-	assert.Nil(t, order)
-	assert.EqualError(t, err, "invalid input")
+	assert.NoError(t, err)
+	assert.Equal(t, "u1", order.UserID)
+	assert.Equal(t, "Leave at the door", order.CustomerNotes)
+	assert.Equal(t, address, order.DeliveryAddress)
 }
 
-// TestPlaceOrder_ProductRepoError verifica que PlaceOrder propaga el error
-// cuando GetProductById falla.
-func TestPlaceOrder_ProductRepoError(t *testing.T) {
+// TestPlaceOrder_NotifiesReceiptEmail verifica que el correo del recibo de la
+// petición se guarda en el pedido y que el notificador recibe el pedido creado.
+func TestPlaceOrder_NotifiesReceiptEmail(t *testing.T) {
 	mockOrderRepo := new(MockOrderRepository)
-	mockProductRepo := new(MockProductRepository)
-	mockValidator := new(MockValidator)
-
-	uc := usecase.NewOrderUseCase(mockValidator, mockOrderRepo, mockProductRepo)
+	mockProductRepo := new(mocks.ProductRepository)
+	mockNotifier := new(MockNotifier)
+	uc := usecase.NewOrderUseCase(validation.New(), mockOrderRepo, mockProductRepo, nil, nil, nil, nil, mockNotifier)
 
+	email := "gift@example.com"
 	req := &orderDto.PlaceOrderRequest{
-		UserID: "u1",
-		Lines:  []orderDto.PlaceOrderLineRequest{{ProductID: "p1", Quantity: 1}},
+		UserID:         "u1",
+		IdempotencyKey: "k1",
+		Lines:          []orderDto.PlaceOrderLineRequest{{ProductID: "p1", Quantity: 1}},
+		ReceiptEmail:   &email,
 	}
-	mockValidator.On("ValidateStruct", req).Return(nil)
-	mockProductRepo.On("GetProductById", mock.Anything, "p1").Return(nil, errors.New("not found"))
+	mockOrderRepo.On("FindOrderByIdempotencyKey", mock.Anything, "k1").Return(nil, gorm.ErrRecordNotFound)
+	mockProductRepo.On("GetProductById", mock.Anything, "p1").Return(&productEntity.Product{ID: "p1", Price: 10.0}, nil)
+	mockProductRepo.On("GetProductStockForUpdate", mock.Anything, "p1").Return(5, nil)
+	mockProductRepo.On("UpdateProductStock", mock.Anything, "p1", 4).Return(nil)
+	mockOrderRepo.On("CreateOrder", mock.Anything, mock.Anything, mock.Anything).Return(nil, nil)
+	mockNotifier.On("OrderCreated", mock.Anything, mock.MatchedBy(func(o *orderEntity.Order) bool {
+		return o.ReceiptEmail != nil && *o.ReceiptEmail == email
+	})).Return(nil)
 
 	order, err := uc.PlaceOrder(context.Background(), req)
 
-	assert.Nil(t, order)
-	assert.EqualError(t, err, "not found")
+	assert.NoError(t, err)
+	assert.Equal(t, &email, order.ReceiptEmail)
+	mockNotifier.AssertExpectations(t)
 }
 
-// TestPlaceOrder_MultipleLines verifica que PlaceOrder maneja varias líneas
-// y suma correctamente todos los precios.
-func TestPlaceOrder_MultipleLines(t *testing.T) {
+// TestPlaceOrder_InvalidReceiptEmail verifica que un correo de recibo mal
+// formado rechaza la petición antes de crear el pedido.
+func TestPlaceOrder_InvalidReceiptEmail(t *testing.T) {
 	mockOrderRepo := new(MockOrderRepository)
-	mockProductRepo := new(MockProductRepository)
-	mockValidator := new(MockValidator)
+	mockNotifier := new(MockNotifier)
+	uc := usecase.NewOrderUseCase(validation.New(), mockOrderRepo, new(mocks.ProductRepository), nil, nil, nil, nil, mockNotifier)
+
+	email := "not-an-email"
+	order, err := uc.PlaceOrder(context.Background(), &orderDto.PlaceOrderRequest{
+		UserID:         "u1",
+		IdempotencyKey: "k1",
+		Lines:          []orderDto.PlaceOrderLineRequest{{ProductID: "p1", Quantity: 1}},
+		ReceiptEmail:   &email,
+	})
+
+	assert.Error(t, err)
+	assert.Nil(t, order)
+	mockOrderRepo.AssertNotCalled(t, "CreateOrder", mock.Anything, mock.Anything, mock.Anything)
+	mockNotifier.AssertNotCalled(t, "OrderCreated", mock.Anything, mock.Anything)
+}
 
-	uc := usecase.NewOrderUseCase(mockValidator, mockOrderRepo, mockProductRepo)
+// TestPlaceOrder_NotifyError verifica que un fallo del notificador no hace
+// fallar un pedido ya creado.
+func TestPlaceOrder_NotifyError(t *testing.T) {
+	mockOrderRepo := new(MockOrderRepository)
+	mockProductRepo := new(mocks.ProductRepository)
+	mockNotifier := new(MockNotifier)
+	uc := usecase.NewOrderUseCase(validation.New(), mockOrderRepo, mockProductRepo, nil, nil, nil, nil, mockNotifier)
 
 	req := &orderDto.PlaceOrderRequest{
-		UserID: "u1",
-		Lines: []orderDto.PlaceOrderLineRequest{
-			{ProductID: "p1", Quantity: 1},
-			{ProductID: "p2", Quantity: 3},
-		},
+		UserID:         "u1",
+		IdempotencyKey: "k1",
+		Lines:          []orderDto.PlaceOrderLineRequest{{ProductID: "p1", Quantity: 1}},
 	}
-	p1 := &productEntity.Product{ID: "p1", Price: 10.0}
-	p2 := &productEntity.Product{ID: "p2", Price: 20.0}
-
-	mockValidator.On("ValidateStruct", req).Return(nil)
-	mockProductRepo.On("GetProductById", mock.Anything, "p1").Return(p1, nil)
-	mockProductRepo.On("GetProductById", mock.Anything, "p2").Return(p2, nil)
-	mockOrderRepo.
-		On("CreateOrder", mock.Anything, "u1", mock.Anything).
-		Return(&orderEntity.Order{
-			UserID: "u1",
-			Lines: []*orderEntity.OrderLine{
-				{ProductID: "p1", Quantity: 1, Price: 10.0},
-				{ProductID: "p2", Quantity: 3, Price: 60.0},
-			},
-			TotalPrice: 70.0,
-		}, nil)
+	mockOrderRepo.On("FindOrderByIdempotencyKey", mock.Anything, "k1").Return(nil, gorm.ErrRecordNotFound)
+	mockProductRepo.On("GetProductById", mock.Anything, "p1").Return(&productEntity.Product{ID: "p1", Price: 10.0}, nil)
+	mockProductRepo.On("GetProductStockForUpdate", mock.Anything, "p1").Return(5, nil)
+	mockProductRepo.On("UpdateProductStock", mock.Anything, "p1", 4).Return(nil)
+	mockOrderRepo.On("CreateOrder", mock.Anything, mock.Anything, mock.Anything).Return(nil, nil)
+	mockNotifier.On("OrderCreated", mock.Anything, mock.Anything).Return(errors.New("smtp down"))
 
 	order, err := uc.PlaceOrder(context.Background(), req)
 
 	assert.NoError(t, err)
-	assert.Equal(t, 70.0, order.TotalPrice)
-	assert.Equal(t, p1, order.Lines[0].Product)
-	assert.Equal(t, p2, order.Lines[1].Product)
+	assert.NotNil(t, order)
+	mockNotifier.AssertExpectations(t)
 }
 
-// -------------------------------------
-// Tests de ListMyOrders
-// -------------------------------------
-
-// TestListMyOrders_Success verifica que ListMyOrders devuelve lista no vacía
-// y una paginación correcta.
-func TestListMyOrders_Success(t *testing.T) {
+// TestPlaceOrder_NewIdempotencyKey verifica que una clave no vista crea el
+// pedido y la guarda en él.
+func TestPlaceOrder_NewIdempotencyKey(t *testing.T) {
 	mockOrderRepo := new(MockOrderRepository)
-	uc := usecase.NewOrderUseCase(new(MockValidator), mockOrderRepo, new(MockProductRepository))
-
-	req := &orderDto.ListOrdersRequest{UserID: "u1", Page: 1, Limit: 10}
-	expectedOrders := []*orderEntity.Order{{ID: "o1"}, {ID: "o2"}}
-	expectedPage := paging.NewPagination(1, 10, 2)
+	mockProductRepo := new(mocks.ProductRepository)
+	uc := usecase.NewOrderUseCase(validation.New(), mockOrderRepo, mockProductRepo, nil, nil, nil, nil, newMockNotifier())
 
-	mockOrderRepo.
-		On("GetMyOrders", mock.Anything, req).
-		Return(expectedOrders, expectedPage, nil)
+	req := &orderDto.PlaceOrderRequest{
+		UserID:         "u1",
+		IdempotencyKey: "k1",
+		Lines:          []orderDto.PlaceOrderLineRequest{{ProductID: "p1", Quantity: 1}},
+	}
+	mockOrderRepo.On("FindOrderByIdempotencyKey", mock.Anything, "k1").Return(nil, gorm.ErrRecordNotFound)
+	mockProductRepo.On("GetProductById", mock.Anything, "p1").Return(&productEntity.Product{ID: "p1", Price: 10.0}, nil)
+	mockProductRepo.On("GetProductStockForUpdate", mock.Anything, "p1").Return(5, nil)
+	mockProductRepo.On("UpdateProductStock", mock.Anything, "p1", 4).Return(nil)
+	mockOrderRepo.On("CreateOrder", mock.Anything, mock.MatchedBy(func(o *orderEntity.Order) bool {
+		return o.IdempotencyKey != nil && *o.IdempotencyKey == "k1"
+	}), mock.Anything).Return(nil, nil)
 
-	orders, page, err := uc.ListMyOrders(context.Background(), req)
+	order, err := uc.PlaceOrder(context.Background(), req)
 
 	assert.NoError(t, err)
-	assert.Equal(t, expectedOrders, orders)
-	assert.Equal(t, expectedPage, page)
+	assert.Equal(t, "u1", order.UserID)
+	mockOrderRepo.AssertExpectations(t)
 }
 
-// TestListMyOrders_Empty verifica que ListMyOrders devuelve lista vacía
-// cuando no hay pedidos y la paginación refleja cero elementos.
-func TestListMyOrders_Empty(t *testing.T) {
+// TestPlaceOrder_SeenIdempotencyKey verifica que una clave ya usada devuelve
+// el pedido existente sin crear otro ni volver a notificarlo.
+func TestPlaceOrder_SeenIdempotencyKey(t *testing.T) {
 	mockOrderRepo := new(MockOrderRepository)
-	uc := usecase.NewOrderUseCase(new(MockValidator), mockOrderRepo, new(MockProductRepository))
-
-	req := &orderDto.ListOrdersRequest{UserID: "u1", Page: 2, Limit: 5}
-	expectedPage := paging.NewPagination(2, 5, 0)
+	mockProductRepo := new(mocks.ProductRepository)
+	mockNotifier := new(MockNotifier)
+	uc := usecase.NewOrderUseCase(validation.New(), mockOrderRepo, mockProductRepo, nil, nil, nil, nil, mockNotifier)
 
-	mockOrderRepo.
-		On("GetMyOrders", mock.Anything, req).
-		Return(nil, expectedPage, nil)
+	req := &orderDto.PlaceOrderRequest{
+		UserID:         "u1",
+		IdempotencyKey: "k1",
+		Lines:          []orderDto.PlaceOrderLineRequest{{ProductID: "p1", Quantity: 1}},
+	}
+	existing := &orderEntity.Order{ID: "o1", UserID: "u1"}
+	mockOrderRepo.On("FindOrderByIdempotencyKey", mock.Anything, "k1").Return(existing, nil)
 
-	orders, page, err := uc.ListMyOrders(context.Background(), req)
+	order, err := uc.PlaceOrder(context.Background(), req)
 
 	assert.NoError(t, err)
-	assert.Empty(t, orders)
-	assert.Equal(t, expectedPage, page)
+	assert.Same(t, existing, order)
+	mockOrderRepo.AssertNotCalled(t, "CreateOrder", mock.Anything, mock.Anything, mock.Anything)
+	mockProductRepo.AssertNotCalled(t, "GetProductById", mock.Anything, mock.Anything)
+	mockNotifier.AssertNotCalled(t, "OrderCreated", mock.Anything, mock.Anything)
 }
 
-// TestListMyOrders_RepoError verifica que ListMyOrders propaga error
-// cuando el repositorio falla.
+// TestPlaceOrder_IdempotencyKeyOtherUser verifica que una clave usada por otro
+// usuario se rechaza sin devolver su pedido.
+func TestPlaceOrder_IdempotencyKeyOtherUser(t *testing.T) {
+	mockOrderRepo := new(MockOrderRepository)
+	uc := usecase.NewOrderUseCase(validation.New(), mockOrderRepo, new(mocks.ProductRepository), nil, nil, nil, nil, nil)
+
+	req := &orderDto.PlaceOrderRequest{
+		UserID:         "u1",
+		IdempotencyKey: "k1",
+		Lines:          []orderDto.PlaceOrderLineRequest{{ProductID: "p1", Quantity: 1}},
+	}
+	mockOrderRepo.On("FindOrderByIdempotencyKey", mock.Anything, "k1").Return(&orderEntity.Order{ID: "o1", UserID: "u2"}, nil)
+
+	order, err := uc.PlaceOrder(context.Background(), req)
+
+	assert.Nil(t, order)
+	assert.ErrorIs(t, err, usecase.ErrIdempotencyKeyConflict)
+	mockOrderRepo.AssertNotCalled(t, "CreateOrder", mock.Anything, mock.Anything, mock.Anything)
+}
+
+// TestPlaceOrder_InsufficientStock verifica que sin stock suficiente no se
+// crea el pedido ni se toca el stock.
+func TestPlaceOrder_InsufficientStock(t *testing.T) {
+	mockOrderRepo := new(MockOrderRepository)
+	mockProductRepo := new(mocks.ProductRepository)
+	uc := usecase.NewOrderUseCase(validation.New(), mockOrderRepo, mockProductRepo, nil, nil, nil, nil, nil)
+
+	req := &orderDto.PlaceOrderRequest{
+		UserID:         "u1",
+		IdempotencyKey: "k1",
+		Lines:          []orderDto.PlaceOrderLineRequest{{ProductID: "p1", Quantity: 3}},
+	}
+	mockOrderRepo.On("FindOrderByIdempotencyKey", mock.Anything, "k1").Return(nil, gorm.ErrRecordNotFound)
+	mockProductRepo.On("GetProductById", mock.Anything, "p1").Return(&productEntity.Product{ID: "p1", Price: 10.0}, nil)
+	mockProductRepo.On("GetProductStockForUpdate", mock.Anything, "p1").Return(2, nil)
+
+	order, err := uc.PlaceOrder(context.Background(), req)
+
+	assert.Nil(t, order)
+	assert.ErrorIs(t, err, usecase.ErrInsufficientStock)
+	mockProductRepo.AssertNotCalled(t, "UpdateProductStock", mock.Anything, mock.Anything, mock.Anything)
+	mockOrderRepo.AssertNotCalled(t, "CreateOrder", mock.Anything, mock.Anything, mock.Anything)
+}
+
+// TestPlaceOrder_ConcurrentSameKey verifica que si una petición concurrente
+// con la misma clave crea el pedido primero, el índice único hace fallar esta
+// y se devuelve el pedido de la otra en lugar de un error.
+func TestPlaceOrder_ConcurrentSameKey(t *testing.T) {
+	mockOrderRepo := new(MockOrderRepository)
+	mockProductRepo := new(mocks.ProductRepository)
+	uc := usecase.NewOrderUseCase(validation.New(), mockOrderRepo, mockProductRepo, nil, nil, nil, nil, newMockNotifier())
+
+	req := &orderDto.PlaceOrderRequest{
+		UserID:         "u1",
+		IdempotencyKey: "k1",
+		Lines:          []orderDto.PlaceOrderLineRequest{{ProductID: "p1", Quantity: 1}},
+	}
+	placed := &orderEntity.Order{ID: "o1", UserID: "u1"}
+	mockOrderRepo.On("FindOrderByIdempotencyKey", mock.Anything, "k1").Return(nil, gorm.ErrRecordNotFound).Once()
+	mockProductRepo.On("GetProductById", mock.Anything, "p1").Return(&productEntity.Product{ID: "p1", Price: 10.0}, nil)
+	mockProductRepo.On("GetProductStockForUpdate", mock.Anything, "p1").Return(5, nil)
+	mockProductRepo.On("UpdateProductStock", mock.Anything, "p1", 4).Return(nil)
+	mockOrderRepo.On("CreateOrder", mock.Anything, mock.Anything, mock.Anything).
+		Return(nil, errors.New(`ERROR: duplicate key value violates unique constraint "idx_orders_idempotency_key" (SQLSTATE 23505)`))
+	mockOrderRepo.On("FindOrderByIdempotencyKey", mock.Anything, "k1").Return(placed, nil).Once()
+
+	order, err := uc.PlaceOrder(context.Background(), req)
+
+	assert.NoError(t, err)
+	assert.Same(t, placed, order)
+}
+
+// TestPlaceOrder_EmptyIdempotencyKey verifica que la validación rechaza una
+// petición sin clave de idempotencia.
+func TestPlaceOrder_EmptyIdempotencyKey(t *testing.T) {
+	mockOrderRepo := new(MockOrderRepository)
+	uc := usecase.NewOrderUseCase(validation.New(), mockOrderRepo, new(mocks.ProductRepository), nil, nil, nil, nil, nil)
+
+	req := &orderDto.PlaceOrderRequest{
+		UserID: "u1",
+		Lines:  []orderDto.PlaceOrderLineRequest{{ProductID: "p1", Quantity: 1}},
+	}
+
+	order, err := uc.PlaceOrder(context.Background(), req)
+
+	assert.Nil(t, order)
+	assert.Error(t, err)
+	mockOrderRepo.AssertNotCalled(t, "FindOrderByIdempotencyKey", mock.Anything, mock.Anything)
+}
+
+// TestPlaceOrder_ValidationError verifica que PlaceOrder devuelve error
+// cuando la validación de la petición falla.
+func TestPlaceOrder_ValidationError(t *testing.T) {
+	mockOrderRepo := new(MockOrderRepository)
+	mockProductRepo := new(mocks.ProductRepository)
+	mockValidator := new(MockValidator)
+
+	uc := usecase.NewOrderUseCase(mockValidator, mockOrderRepo, mockProductRepo, nil, nil, nil, nil, nil)
+
+	req := &orderDto.PlaceOrderRequest{UserID: "", Lines: nil}
+	mockValidator.On("ValidateStruct", req).Return(errors.New("invalid input"))
+
+	order, err := uc.PlaceOrder(context.Background(), req)
+
+	assert.Nil(t, order)
+	assert.EqualError(t, err, "invalid input")
+}
+
+// TestPlaceOrder_ProductRepoError verifica que PlaceOrder propaga el error
+// cuando GetProductById falla.
+func TestPlaceOrder_ProductRepoError(t *testing.T) {
+	mockOrderRepo := new(MockOrderRepository)
+	mockProductRepo := new(mocks.ProductRepository)
+	mockValidator := new(MockValidator)
+
+	uc := usecase.NewOrderUseCase(mockValidator, mockOrderRepo, mockProductRepo, nil, nil, nil, nil, nil)
+
+	req := &orderDto.PlaceOrderRequest{
+		UserID:         "u1",
+		IdempotencyKey: "k1",
+		Lines:          []orderDto.PlaceOrderLineRequest{{ProductID: "p1", Quantity: 1}},
+	}
+	mockValidator.On("ValidateStruct", req).Return(nil)
+	mockOrderRepo.On("FindOrderByIdempotencyKey", mock.Anything, "k1").Return(nil, gorm.ErrRecordNotFound)
+	mockProductRepo.On("GetProductById", mock.Anything, "p1").Return(nil, errors.New("not found"))
+
+	order, err := uc.PlaceOrder(context.Background(), req)
+
+	assert.Nil(t, order)
+	assert.EqualError(t, err, "not found")
+}
+
+// TestPlaceOrder_MultipleLines verifica que PlaceOrder maneja varias líneas
+// y suma correctamente todos los precios.
+func TestPlaceOrder_MultipleLines(t *testing.T) {
+	mockOrderRepo := new(MockOrderRepository)
+	mockProductRepo := new(mocks.ProductRepository)
+	mockValidator := new(MockValidator)
+
+	uc := usecase.NewOrderUseCase(mockValidator, mockOrderRepo, mockProductRepo, nil, nil, nil, nil, newMockNotifier())
+
+	req := &orderDto.PlaceOrderRequest{
+		UserID:         "u1",
+		IdempotencyKey: "k1",
+		Lines: []orderDto.PlaceOrderLineRequest{
+			{ProductID: "p1", Quantity: 1},
+			{ProductID: "p2", Quantity: 3},
+		},
+	}
+	p1 := &productEntity.Product{ID: "p1", Price: 10.0}
+	p2 := &productEntity.Product{ID: "p2", Price: 20.0}
+
+	mockValidator.On("ValidateStruct", req).Return(nil)
+	mockOrderRepo.On("FindOrderByIdempotencyKey", mock.Anything, "k1").Return(nil, gorm.ErrRecordNotFound)
+	mockProductRepo.On("GetProductById", mock.Anything, "p1").Return(p1, nil)
+	mockProductRepo.On("GetProductById", mock.Anything, "p2").Return(p2, nil)
+	mockProductRepo.On("GetProductStockForUpdate", mock.Anything, "p1").Return(1, nil)
+	mockProductRepo.On("UpdateProductStock", mock.Anything, "p1", 0).Return(nil)
+	mockProductRepo.On("GetProductStockForUpdate", mock.Anything, "p2").Return(10, nil)
+	mockProductRepo.On("UpdateProductStock", mock.Anything, "p2", 7).Return(nil)
+	mockOrderRepo.
+		On("CreateOrder", mock.Anything, mock.MatchedBy(func(o *orderEntity.Order) bool { return o.UserID == "u1" }), mock.Anything).
+		Return(&orderEntity.Order{
+			UserID: "u1",
+			Lines: []*orderEntity.OrderLine{
+				{ProductID: "p1", Quantity: 1, Price: 10.0},
+				{ProductID: "p2", Quantity: 3, Price: 60.0},
+			},
+			TotalPrice: 70.0,
+		}, nil)
+
+	order, err := uc.PlaceOrder(context.Background(), req)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 70.0, order.TotalPrice)
+	assert.Equal(t, p1, order.Lines[0].Product)
+	assert.Equal(t, p2, order.Lines[1].Product)
+}
+
+// -------------------------------------
+// Tests de ListMyOrders
+// -------------------------------------
+
+// TestListMyOrders_Success verifica que ListMyOrders devuelve lista no vacía
+// y una paginación correcta.
+func TestListMyOrders_Success(t *testing.T) {
+	mockOrderRepo := new(MockOrderRepository)
+	uc := usecase.NewOrderUseCase(new(MockValidator), mockOrderRepo, new(mocks.ProductRepository), nil, nil, nil, nil, nil)
+
+	req := &orderDto.ListOrdersRequest{UserID: "u1", Page: 1, Limit: 10}
+	expectedOrders := []*orderEntity.Order{{ID: "o1"}, {ID: "o2"}}
+	expectedPage := paging.NewPagination(1, 10, 2)
+
+	mockOrderRepo.
+		On("GetMyOrders", mock.Anything, req).
+		Return(expectedOrders, expectedPage, nil)
+
+	orders, page, err := uc.ListMyOrders(context.Background(), req)
+
+	assert.NoError(t, err)
+	assert.Equal(t, expectedOrders, orders)
+	assert.Equal(t, expectedPage, page)
+}
+
+// TestListMyOrders_Empty verifica que ListMyOrders devuelve lista vacía
+// cuando no hay pedidos y la paginación refleja cero elementos.
+func TestListMyOrders_Empty(t *testing.T) {
+	mockOrderRepo := new(MockOrderRepository)
+	uc := usecase.NewOrderUseCase(new(MockValidator), mockOrderRepo, new(mocks.ProductRepository), nil, nil, nil, nil, nil)
+
+	req := &orderDto.ListOrdersRequest{UserID: "u1", Page: 2, Limit: 5}
+	expectedPage := paging.NewPagination(2, 5, 0)
+
+	mockOrderRepo.
+		On("GetMyOrders", mock.Anything, req).
+		Return(nil, expectedPage, nil)
+
+	orders, page, err := uc.ListMyOrders(context.Background(), req)
+
+	assert.NoError(t, err)
+	assert.Empty(t, orders)
+	assert.Equal(t, expectedPage, page)
+}
+
+// -------------------------------------
+// Tests de ListAllOrders
+// -------------------------------------
+
+// TestListAllOrders_NoFilter verifica que sin filtros se devuelven los pedidos
+// de todos los usuarios.
+func TestListAllOrders_NoFilter(t *testing.T) {
+	mockOrderRepo := new(MockOrderRepository)
+	uc := usecase.NewOrderUseCase(new(MockValidator), mockOrderRepo, new(mocks.ProductRepository), nil, nil, nil, nil, nil)
+
+	req := &orderDto.AdminListOrdersRequest{Page: 1, Limit: 10}
+	expectedOrders := []*orderEntity.Order{{ID: "o1", UserID: "u1"}, {ID: "o2", UserID: "u2"}}
+	expectedPage := paging.NewPagination(1, 10, 2)
+	mockOrderRepo.On("ListAllOrders", mock.Anything, req).Return(expectedOrders, expectedPage, nil)
+
+	orders, page, err := uc.ListAllOrders(context.Background(), utils.RoleAdmin, req)
+
+	assert.NoError(t, err)
+	assert.Equal(t, expectedOrders, orders)
+	assert.Equal(t, expectedPage, page)
+}
+
+// TestListAllOrders_UserFilter verifica que el filtro de usuario llega al
+// repositorio.
+func TestListAllOrders_UserFilter(t *testing.T) {
+	mockOrderRepo := new(MockOrderRepository)
+	uc := usecase.NewOrderUseCase(new(MockValidator), mockOrderRepo, new(mocks.ProductRepository), nil, nil, nil, nil, nil)
+
+	req := &orderDto.AdminListOrdersRequest{UserID: "u2", Status: string(utils.OrderStatusNew)}
+	expectedOrders := []*orderEntity.Order{{ID: "o2", UserID: "u2"}}
+	mockOrderRepo.On("ListAllOrders", mock.Anything, mock.MatchedBy(func(r *orderDto.AdminListOrdersRequest) bool {
+		return r.UserID == "u2" && r.Status == string(utils.OrderStatusNew)
+	})).Return(expectedOrders, paging.NewPagination(1, 20, 1), nil)
+
+	orders, _, err := uc.ListAllOrders(context.Background(), utils.RoleAdmin, req)
+
+	assert.NoError(t, err)
+	assert.Equal(t, expectedOrders, orders)
+}
+
+// TestListAllOrders_RepoError verifica que el error del repositorio se propaga.
+func TestListAllOrders_RepoError(t *testing.T) {
+	mockOrderRepo := new(MockOrderRepository)
+	uc := usecase.NewOrderUseCase(new(MockValidator), mockOrderRepo, new(mocks.ProductRepository), nil, nil, nil, nil, nil)
+
+	req := &orderDto.AdminListOrdersRequest{}
+	mockOrderRepo.On("ListAllOrders", mock.Anything, req).Return(nil, nil, errors.New("db error"))
+
+	orders, page, err := uc.ListAllOrders(context.Background(), utils.RoleAdmin, req)
+
+	assert.Nil(t, orders)
+	assert.Nil(t, page)
+	assert.EqualError(t, err, "db error")
+}
+
+// TestListAllOrders_Forbidden verifica que solo un administrador puede listar
+// los pedidos de todos los usuarios.
+func TestListAllOrders_Forbidden(t *testing.T) {
+	mockOrderRepo := new(MockOrderRepository)
+	uc := usecase.NewOrderUseCase(new(MockValidator), mockOrderRepo, new(mocks.ProductRepository), nil, nil, nil, nil, nil)
+
+	orders, page, err := uc.ListAllOrders(context.Background(), utils.RoleCustomer, &orderDto.AdminListOrdersRequest{})
+
+	assert.Nil(t, orders)
+	assert.Nil(t, page)
+	assert.ErrorIs(t, err, usecase.ErrForbidden)
+	mockOrderRepo.AssertNotCalled(t, "ListAllOrders", mock.Anything, mock.Anything)
+}
+
+// TestListAllOrders_InvalidOrderBy verifica que OrderBy solo acepta las
+// columnas permitidas y nunca llega al SQL con otro valor.
+func TestListAllOrders_InvalidOrderBy(t *testing.T) {
+	for _, orderBy := range []string{"id", "created_at; DROP TABLE orders", "total_price DESC"} {
+		mockOrderRepo := new(MockOrderRepository)
+		uc := usecase.NewOrderUseCase(new(MockValidator), mockOrderRepo, new(mocks.ProductRepository), nil, nil, nil, nil, nil)
+
+		_, _, err := uc.ListAllOrders(context.Background(), utils.RoleAdmin, &orderDto.AdminListOrdersRequest{OrderBy: orderBy})
+
+		assert.ErrorIs(t, err, usecase.ErrInvalidSortParam)
+		mockOrderRepo.AssertNotCalled(t, "ListAllOrders", mock.Anything, mock.Anything)
+	}
+}
+
+// TestListMyOrders_FilterByStatus verifica que el filtro de estado llega al
+// repositorio.
+func TestListMyOrders_FilterByStatus(t *testing.T) {
+	mockOrderRepo := new(MockOrderRepository)
+	uc := usecase.NewOrderUseCase(new(MockValidator), mockOrderRepo, new(mocks.ProductRepository), nil, nil, nil, nil, nil)
+
+	status := utils.OrderStatusInProgress
+	req := &orderDto.ListOrdersRequest{UserID: "u1", Status: &status}
+	expectedOrders := []*orderEntity.Order{{ID: "o1", Status: utils.OrderStatusInProgress}}
+	mockOrderRepo.On("GetMyOrders", mock.Anything, mock.MatchedBy(func(r *orderDto.ListOrdersRequest) bool {
+		return r.UserID == "u1" && r.Status != nil && *r.Status == utils.OrderStatusInProgress
+	})).Return(expectedOrders, paging.NewPagination(1, 20, 1), nil)
+
+	orders, _, err := uc.ListMyOrders(context.Background(), req)
+
+	assert.NoError(t, err)
+	assert.Equal(t, expectedOrders, orders)
+	mockOrderRepo.AssertExpectations(t)
+}
+
+// TestListMyOrders_FilterByDateRange verifica que el rango de fechas llega al
+// repositorio.
+func TestListMyOrders_FilterByDateRange(t *testing.T) {
+	mockOrderRepo := new(MockOrderRepository)
+	uc := usecase.NewOrderUseCase(new(MockValidator), mockOrderRepo, new(mocks.ProductRepository), nil, nil, nil, nil, nil)
+
+	from := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2026, 1, 31, 23, 59, 59, 0, time.UTC)
+	req := &orderDto.ListOrdersRequest{UserID: "u1", From: &from, To: &to}
+	mockOrderRepo.On("GetMyOrders", mock.Anything, mock.MatchedBy(func(r *orderDto.ListOrdersRequest) bool {
+		return r.Status == nil && r.From != nil && r.From.Equal(from) && r.To != nil && r.To.Equal(to)
+	})).Return([]*orderEntity.Order{{ID: "o1"}}, paging.NewPagination(1, 20, 1), nil)
+
+	orders, _, err := uc.ListMyOrders(context.Background(), req)
+
+	assert.NoError(t, err)
+	assert.Len(t, orders, 1)
+	mockOrderRepo.AssertExpectations(t)
+}
+
+// TestListMyOrders_Sort verifica que la ordenación por defecto y las válidas
+// llegan al repositorio y que los valores desconocidos se rechazan antes.
+func TestListMyOrders_Sort(t *testing.T) {
+	cases := []struct {
+		name    string
+		sortBy  string
+		sortDir string
+		err     error
+	}{
+		{"por defecto", "", "", nil},
+		{"total ascendente", orderDto.SortByTotalPrice, orderDto.SortDirAsc, nil},
+		{"fecha descendente", orderDto.SortByCreatedAt, orderDto.SortDirDesc, nil},
+		{"campo inválido", "user_id; DROP TABLE orders", "", usecase.ErrInvalidSortParam},
+		{"dirección inválida", orderDto.SortByCreatedAt, "up", usecase.ErrInvalidSortParam},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockOrderRepo := new(MockOrderRepository)
+			uc := usecase.NewOrderUseCase(new(MockValidator), mockOrderRepo, new(mocks.ProductRepository), nil, nil, nil, nil, nil)
+
+			req := &orderDto.ListOrdersRequest{UserID: "u1", SortBy: tc.sortBy, SortDir: tc.sortDir}
+			mockOrderRepo.On("GetMyOrders", mock.Anything, req).Return([]*orderEntity.Order{{ID: "o1"}}, paging.NewPagination(1, 20, 1), nil)
+
+			orders, _, err := uc.ListMyOrders(context.Background(), req)
+
+			if tc.err != nil {
+				assert.ErrorIs(t, err, tc.err)
+				assert.Nil(t, orders)
+				mockOrderRepo.AssertNotCalled(t, "GetMyOrders", mock.Anything, mock.Anything)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Len(t, orders, 1)
+		})
+	}
+}
+
+// TestListMyOrders_RepoError verifica que ListMyOrders propaga error
+// cuando el repositorio falla.
 func TestListMyOrders_RepoError(t *testing.T) {
 	mockOrderRepo := new(MockOrderRepository)
-	uc := usecase.NewOrderUseCase(new(MockValidator), mockOrderRepo, new(MockProductRepository))
+	uc := usecase.NewOrderUseCase(new(MockValidator), mockOrderRepo, new(mocks.ProductRepository), nil, nil, nil, nil, nil)
 
 	req := &orderDto.ListOrdersRequest{UserID: "u1"}
 	mockOrderRepo.
@@ -284,7 +1017,7 @@ func TestListMyOrders_RepoError(t *testing.T) {
 // TestGetOrderByID_Success verifica que GetOrderByID devuelve una orden válida.
 func TestGetOrderByID_Success(t *testing.T) {
 	mockOrderRepo := new(MockOrderRepository)
-	uc := usecase.NewOrderUseCase(new(MockValidator), mockOrderRepo, new(MockProductRepository))
+	uc := usecase.NewOrderUseCase(new(MockValidator), mockOrderRepo, new(mocks.ProductRepository), nil, nil, nil, nil, nil)
 
 	expected := &orderEntity.Order{ID: "o123"}
 	mockOrderRepo.
@@ -301,7 +1034,7 @@ func TestGetOrderByID_Success(t *testing.T) {
 // cuando el repositorio no encuentra la orden.
 func TestGetOrderByID_RepoError(t *testing.T) {
 	mockOrderRepo := new(MockOrderRepository)
-	uc := usecase.NewOrderUseCase(new(MockValidator), mockOrderRepo, new(MockProductRepository))
+	uc := usecase.NewOrderUseCase(new(MockValidator), mockOrderRepo, new(mocks.ProductRepository), nil, nil, nil, nil, nil)
 
 	mockOrderRepo.
 		On("GetOrderByID", mock.Anything, "o123", true).
@@ -321,11 +1054,12 @@ func TestGetOrderByID_RepoError(t *testing.T) {
 // el estado de la orden cuando el usuario coincide y el estado es válido.
 func TestUpdateOrder_Success(t *testing.T) {
 	mockOrderRepo := new(MockOrderRepository)
-	uc := usecase.NewOrderUseCase(new(MockValidator), mockOrderRepo, new(MockProductRepository))
+	uc := usecase.NewOrderUseCase(new(MockValidator), mockOrderRepo, new(mocks.ProductRepository), nil, nil, nil, nil, nil)
 
-	existing := &orderEntity.Order{ID: "o1", UserID: "u1", Status: utils.OrderStatusNew}
+	existing := &orderEntity.Order{ID: "o1", UserID: "u1", Status: utils.OrderStatusInProgress}
 	mockOrderRepo.On("GetOrderByID", mock.Anything, "o1", false).Return(existing, nil)
 	mockOrderRepo.On("UpdateOrder", mock.Anything, existing).Return(nil)
+	mockOrderRepo.On("CreateAuditLog", mock.Anything, mock.Anything).Return(nil)
 
 	updated, err := uc.UpdateOrder(context.Background(), "o1", "u1", string(utils.OrderStatusDone))
 
@@ -337,7 +1071,7 @@ func TestUpdateOrder_Success(t *testing.T) {
 // cuando el userID no coincide con el de la orden.
 func TestUpdateOrder_PermissionDenied(t *testing.T) {
 	mockOrderRepo := new(MockOrderRepository)
-	uc := usecase.NewOrderUseCase(new(MockValidator), mockOrderRepo, new(MockProductRepository))
+	uc := usecase.NewOrderUseCase(new(MockValidator), mockOrderRepo, new(mocks.ProductRepository), nil, nil, nil, nil, nil)
 
 	existing := &orderEntity.Order{ID: "o1", UserID: "u1", Status: utils.OrderStatusNew}
 	mockOrderRepo.On("GetOrderByID", mock.Anything, "o1", false).Return(existing, nil)
@@ -350,23 +1084,44 @@ func TestUpdateOrder_PermissionDenied(t *testing.T) {
 // cuando la orden ya está en estado 'done' o 'canceled'.
 func TestUpdateOrder_InvalidState(t *testing.T) {
 	mockOrderRepo := new(MockOrderRepository)
-	uc := usecase.NewOrderUseCase(new(MockValidator), mockOrderRepo, new(MockProductRepository))
+	uc := usecase.NewOrderUseCase(new(MockValidator), mockOrderRepo, new(mocks.ProductRepository), nil, nil, nil, nil, nil)
 
 	for _, s := range []utils.OrderStatus{utils.OrderStatusDone, utils.OrderStatusCanceled} {
 		existing := &orderEntity.Order{ID: "o1", UserID: "u1", Status: s}
 		mockOrderRepo.On("GetOrderByID", mock.Anything, "o1", false).Return(existing, nil)
 
 		_, err := uc.UpdateOrder(context.Background(), "o1", "u1", string(utils.OrderStatusInProgress))
-		assert.EqualError(t, err, "invalid order status")
+		assert.True(t, errors.As(err, &usecase.ErrOrderTransitionFailed{}))
 		mockOrderRepo.ExpectedCalls = nil
 	}
 }
 
+// TestUpdateOrder_TransitionError verifica que el error de transición se puede
+// extraer con errors.As con From/To rellenos y que envuelve ErrInvalidTransition.
+func TestUpdateOrder_TransitionError(t *testing.T) {
+	mockOrderRepo := new(MockOrderRepository)
+	uc := usecase.NewOrderUseCase(new(MockValidator), mockOrderRepo, new(mocks.ProductRepository), nil, nil, nil, nil, nil)
+
+	existing := &orderEntity.Order{ID: "o1", UserID: "u1", Status: utils.OrderStatusCanceled}
+	mockOrderRepo.On("GetOrderByID", mock.Anything, "o1", false).Return(existing, nil)
+
+	_, err := uc.UpdateOrder(context.Background(), "o1", "u1", string(utils.OrderStatusDone))
+
+	var transitionErr usecase.ErrOrderTransitionFailed
+	if assert.True(t, errors.As(err, &transitionErr)) {
+		assert.Equal(t, utils.OrderStatusCanceled, transitionErr.From)
+		assert.Equal(t, utils.OrderStatusDone, transitionErr.To)
+	}
+	assert.ErrorIs(t, err, usecase.ErrInvalidTransition)
+	assert.EqualError(t, err, "cannot change order status from canceled to done")
+	mockOrderRepo.AssertNotCalled(t, "UpdateOrder", mock.Anything, mock.Anything)
+}
+
 // TestUpdateOrder_InvalidStatusParam verifica que UpdateOrder devuelve error
 // cuando se pasa un estado no válido en el parámetro.
 func TestUpdateOrder_InvalidStatusParam(t *testing.T) {
 	mockOrderRepo := new(MockOrderRepository)
-	uc := usecase.NewOrderUseCase(new(MockValidator), mockOrderRepo, new(MockProductRepository))
+	uc := usecase.NewOrderUseCase(new(MockValidator), mockOrderRepo, new(mocks.ProductRepository), nil, nil, nil, nil, nil)
 
 	existing := &orderEntity.Order{ID: "o1", UserID: "u1", Status: utils.OrderStatusNew}
 	mockOrderRepo.On("GetOrderByID", mock.Anything, "o1", false).Return(existing, nil)
@@ -375,16 +1130,2770 @@ func TestUpdateOrder_InvalidStatusParam(t *testing.T) {
 	assert.EqualError(t, err, "invalid status")
 }
 
-// TestUpdateOrder_UpdateError verifica que UpdateOrder propaga el error
-// cuando el repositorio falla al actualizar la orden.
-func TestUpdateOrder_UpdateError(t *testing.T) {
+// TestUpdatePaymentStatus_InvalidStatusParam verifica que un estado de pago
+// desconocido se rechaza sin guardar la orden.
+func TestUpdatePaymentStatus_InvalidStatusParam(t *testing.T) {
 	mockOrderRepo := new(MockOrderRepository)
-	uc := usecase.NewOrderUseCase(new(MockValidator), mockOrderRepo, new(MockProductRepository))
+	uc := usecase.NewOrderUseCase(new(MockValidator), mockOrderRepo, new(mocks.ProductRepository), nil, nil, nil, nil, nil)
 
-	existing := &orderEntity.Order{ID: "o1", UserID: "u1", Status: utils.OrderStatusNew}
+	_, err := uc.UpdatePaymentStatus(context.Background(), "o1", utils.RolePaymentGateway, "badstatus")
+	assert.ErrorIs(t, err, usecase.ErrInvalidPaymentStatus)
+	mockOrderRepo.AssertNotCalled(t, "UpdateOrder", mock.Anything, mock.Anything)
+}
+
+// TestUpdatePaymentStatus_PaidOrRefunded verifica que pagada y reembolsada no
+// se pueden fijar a mano: solo MarkOrderAsPaid y RefundOrder las ponen.
+func TestUpdatePaymentStatus_PaidOrRefunded(t *testing.T) {
+	for _, status := range []utils.PaymentStatus{utils.PaymentStatusPaid, utils.PaymentStatusRefunded} {
+		mockOrderRepo := new(MockOrderRepository)
+		uc := usecase.NewOrderUseCase(new(MockValidator), mockOrderRepo, new(mocks.ProductRepository), nil, nil, nil, nil, nil)
+
+		_, err := uc.UpdatePaymentStatus(context.Background(), "o1", utils.RoleAdmin, status)
+		assert.ErrorIs(t, err, usecase.ErrPaymentStatusNotSet)
+		mockOrderRepo.AssertNotCalled(t, "UpdateOrder", mock.Anything, mock.Anything)
+	}
+}
+
+// TestUpdatePaymentStatus_SettledOrder verifica que una orden pagada o
+// reembolsada no vuelve a pendiente ni a fallida.
+func TestUpdatePaymentStatus_SettledOrder(t *testing.T) {
+	cases := []struct {
+		current utils.PaymentStatus
+		err     error
+	}{
+		{utils.PaymentStatusPaid, usecase.ErrAlreadyPaid},
+		{utils.PaymentStatusRefunded, usecase.ErrPaymentRefunded},
+	}
+
+	for _, tc := range cases {
+		mockOrderRepo := new(MockOrderRepository)
+		uc := usecase.NewOrderUseCase(new(MockValidator), mockOrderRepo, new(mocks.ProductRepository), nil, nil, nil, nil, nil)
+
+		existing := &orderEntity.Order{ID: "o1", UserID: "u1", PaymentStatus: tc.current}
+		mockOrderRepo.On("GetOrderByID", mock.Anything, "o1", false).Return(existing, nil)
+
+		_, err := uc.UpdatePaymentStatus(context.Background(), "o1", utils.RoleAdmin, utils.PaymentStatusFailed)
+		assert.ErrorIs(t, err, tc.err)
+		mockOrderRepo.AssertNotCalled(t, "UpdateOrder", mock.Anything, mock.Anything)
+	}
+}
+
+// TestUpdatePaymentStatus_Success verifica que se guarda el nuevo estado de
+// pago.
+func TestUpdatePaymentStatus_Success(t *testing.T) {
+	mockOrderRepo := new(MockOrderRepository)
+	uc := usecase.NewOrderUseCase(new(MockValidator), mockOrderRepo, new(mocks.ProductRepository), nil, nil, nil, nil, nil)
+
+	existing := &orderEntity.Order{ID: "o1", UserID: "u1", PaymentStatus: utils.PaymentStatusPending}
 	mockOrderRepo.On("GetOrderByID", mock.Anything, "o1", false).Return(existing, nil)
-	mockOrderRepo.On("UpdateOrder", mock.Anything, existing).Return(errors.New("update failed"))
+	mockOrderRepo.On("UpdateOrder", mock.Anything, existing).Return(nil)
 
-	_, err := uc.UpdateOrder(context.Background(), "o1", "u1", string(utils.OrderStatusInProgress))
-	assert.EqualError(t, err, "update failed")
+	order, err := uc.UpdatePaymentStatus(context.Background(), "o1", utils.RolePaymentGateway, utils.PaymentStatusFailed)
+	assert.NoError(t, err)
+	assert.Equal(t, utils.PaymentStatusFailed, order.PaymentStatus)
+}
+
+// TestUpdatePaymentStatus_Forbidden verifica que solo la pasarela de pago y los
+// administradores pueden cambiar el estado de pago, ni siquiera el dueño de la
+// orden.
+func TestUpdatePaymentStatus_Forbidden(t *testing.T) {
+	for _, role := range []string{utils.RoleCustomer, utils.RoleSupport, ""} {
+		mockOrderRepo := new(MockOrderRepository)
+		uc := usecase.NewOrderUseCase(new(MockValidator), mockOrderRepo, new(mocks.ProductRepository), nil, nil, nil, nil, nil)
+
+		_, err := uc.UpdatePaymentStatus(context.Background(), "o1", role, utils.PaymentStatusPaid)
+		assert.ErrorIs(t, err, usecase.ErrForbidden)
+		mockOrderRepo.AssertNotCalled(t, "GetOrderByID", mock.Anything, mock.Anything, mock.Anything)
+		mockOrderRepo.AssertNotCalled(t, "UpdateOrder", mock.Anything, mock.Anything)
+	}
+}
+
+// TestUpdateOrder_AllowedTransitions verifica que UpdateOrder solo acepta los
+// cambios de estado de utils.AllowedTransitions.
+func TestUpdateOrder_AllowedTransitions(t *testing.T) {
+	cases := []struct {
+		from    utils.OrderStatus
+		to      utils.OrderStatus
+		allowed bool
+	}{
+		{utils.OrderStatusNew, utils.OrderStatusInProgress, true},
+		{utils.OrderStatusInProgress, utils.OrderStatusDone, true},
+		{utils.OrderStatusNew, utils.OrderStatusCanceled, true},
+		{utils.OrderStatusNew, utils.OrderStatusDone, false},
+		{utils.OrderStatusInProgress, utils.OrderStatusNew, false},
+		{utils.OrderStatusNew, utils.OrderStatusNew, false},
+		{utils.OrderStatusDone, utils.OrderStatusCanceled, false},
+	}
+
+	for _, tc := range cases {
+		t.Run(string(tc.from)+"->"+string(tc.to), func(t *testing.T) {
+			mockOrderRepo := new(MockOrderRepository)
+			uc := usecase.NewOrderUseCase(new(MockValidator), mockOrderRepo, new(mocks.ProductRepository), nil, nil, nil, nil, nil)
+
+			existing := &orderEntity.Order{ID: "o1", UserID: "u1", Status: tc.from}
+			mockOrderRepo.On("GetOrderByID", mock.Anything, "o1", false).Return(existing, nil)
+			mockOrderRepo.On("UpdateOrder", mock.Anything, existing).Return(nil)
+			mockOrderRepo.On("CreateAuditLog", mock.Anything, mock.Anything).Return(nil)
+
+			_, err := uc.UpdateOrder(context.Background(), "o1", "u1", string(tc.to))
+
+			if tc.allowed {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorIs(t, err, usecase.ErrInvalidTransition)
+			assert.EqualError(t, errors.Unwrap(err), "invalid order status transition")
+			mockOrderRepo.AssertNotCalled(t, "UpdateOrder", mock.Anything, mock.Anything)
+		})
+	}
+}
+
+// TestUpdateOrder_UpdateError verifica que UpdateOrder propaga el error
+// cuando el repositorio falla al actualizar la orden.
+func TestUpdateOrder_UpdateError(t *testing.T) {
+	mockOrderRepo := new(MockOrderRepository)
+	uc := usecase.NewOrderUseCase(new(MockValidator), mockOrderRepo, new(mocks.ProductRepository), nil, nil, nil, nil, nil)
+
+	existing := &orderEntity.Order{ID: "o1", UserID: "u1", Status: utils.OrderStatusNew}
+	mockOrderRepo.On("GetOrderByID", mock.Anything, "o1", false).Return(existing, nil)
+	mockOrderRepo.On("UpdateOrder", mock.Anything, existing).Return(errors.New("update failed"))
+
+	_, err := uc.UpdateOrder(context.Background(), "o1", "u1", string(utils.OrderStatusInProgress))
+	assert.EqualError(t, err, "update failed")
+}
+
+// -------------------------------------
+// Tests de GetOrderWithFullDetails
+// -------------------------------------
+
+func strPtr(s string) *string {
+	return &s
+}
+
+// TestGetOrderWithFullDetails_ParallelFetch verifica que las tres sub-consultas
+// (dirección, descuento e historial) se lanzan en paralelo: cada mock espera a
+// que las otras dos hayan empezado antes de responder.
+func TestGetOrderWithFullDetails_ParallelFetch(t *testing.T) {
+	mockOrderRepo := new(MockOrderRepository)
+	uc := usecase.NewOrderUseCase(new(MockValidator), mockOrderRepo, new(mocks.ProductRepository), nil, nil, nil, nil, nil)
+
+	order := &orderEntity.Order{
+		ID:                "o1",
+		UserID:            "u1",
+		ShippingAddressID: strPtr("a1"),
+		DiscountID:        strPtr("d1"),
+	}
+	address := &addressEntity.Address{ID: "a1", City: "San José"}
+	discount := &discountEntity.Discount{ID: "d1", Code: "PROMO", Amount: 5}
+	history := []orderEntity.OrderStatusHistory{{OrderID: "o1", FromStatus: utils.OrderStatusNew, ToStatus: utils.OrderStatusInProgress}}
+
+	var started sync.WaitGroup
+	started.Add(3)
+	allStarted := make(chan struct{})
+	go func() {
+		started.Wait()
+		close(allStarted)
+	}()
+	barrier := func(mock.Arguments) {
+		started.Done()
+		select {
+		case <-allStarted:
+		case <-time.After(time.Second):
+			t.Error("sub-queries were not executed in parallel")
+		}
+	}
+
+	mockOrderRepo.On("GetOrderByID", mock.Anything, "o1", true).Return(order, nil)
+	mockOrderRepo.On("GetShippingAddress", mock.Anything, "a1").Run(barrier).Return(address, nil)
+	mockOrderRepo.On("GetDiscount", mock.Anything, "d1").Run(barrier).Return(discount, nil)
+	mockOrderRepo.On("GetStatusHistory", mock.Anything, "o1").Run(barrier).Return(history, nil)
+
+	details, err := uc.GetOrderWithFullDetails(context.Background(), "o1", "u1", utils.RoleCustomer)
+
+	assert.NoError(t, err)
+	assert.Equal(t, order, details.Order)
+	assert.Equal(t, address, details.ShippingAddress)
+	assert.Equal(t, discount, details.AppliedDiscount)
+	assert.Equal(t, history, details.StatusHistory)
+	mockOrderRepo.AssertExpectations(t)
+}
+
+// TestGetOrderWithFullDetails_PartialFailure verifica que un fallo al cargar la
+// dirección o el descuento no aborta la operación: se devuelve la orden con
+// esos campos vacíos.
+func TestGetOrderWithFullDetails_PartialFailure(t *testing.T) {
+	mockOrderRepo := new(MockOrderRepository)
+	uc := usecase.NewOrderUseCase(new(MockValidator), mockOrderRepo, new(mocks.ProductRepository), nil, nil, nil, nil, nil)
+
+	order := &orderEntity.Order{
+		ID:                "o1",
+		UserID:            "u1",
+		ShippingAddressID: strPtr("a1"),
+		DiscountID:        strPtr("d1"),
+	}
+	history := []orderEntity.OrderStatusHistory{{OrderID: "o1"}}
+
+	mockOrderRepo.On("GetOrderByID", mock.Anything, "o1", true).Return(order, nil)
+	mockOrderRepo.On("GetShippingAddress", mock.Anything, "a1").Return(nil, errors.New("address db down"))
+	mockOrderRepo.On("GetDiscount", mock.Anything, "d1").Return(nil, errors.New("discount db down"))
+	mockOrderRepo.On("GetStatusHistory", mock.Anything, "o1").Return(history, nil)
+
+	details, err := uc.GetOrderWithFullDetails(context.Background(), "o1", "u1", utils.RoleCustomer)
+
+	assert.NoError(t, err)
+	assert.Equal(t, order, details.Order)
+	assert.Nil(t, details.ShippingAddress)
+	assert.Nil(t, details.AppliedDiscount)
+	assert.Equal(t, history, details.StatusHistory)
+}
+
+// TestGetOrderWithFullDetails_HistoryError verifica que un fallo en el
+// historial de estados tampoco aborta la operación: se devuelve la orden con
+// la dirección y el descuento y sin historial.
+func TestGetOrderWithFullDetails_HistoryError(t *testing.T) {
+	mockOrderRepo := new(MockOrderRepository)
+	uc := usecase.NewOrderUseCase(new(MockValidator), mockOrderRepo, new(mocks.ProductRepository), nil, nil, nil, nil, nil)
+
+	order := &orderEntity.Order{
+		ID:                "o1",
+		UserID:            "u1",
+		ShippingAddressID: strPtr("a1"),
+		DiscountID:        strPtr("d1"),
+	}
+	address := &addressEntity.Address{ID: "a1"}
+	discount := &discountEntity.Discount{ID: "d1"}
+
+	mockOrderRepo.On("GetOrderByID", mock.Anything, "o1", true).Return(order, nil)
+	mockOrderRepo.On("GetShippingAddress", mock.Anything, "a1").Return(address, nil)
+	mockOrderRepo.On("GetDiscount", mock.Anything, "d1").Return(discount, nil)
+	mockOrderRepo.On("GetStatusHistory", mock.Anything, "o1").Return(nil, errors.New("history db down"))
+
+	details, err := uc.GetOrderWithFullDetails(context.Background(), "o1", "u1", utils.RoleCustomer)
+
+	assert.NoError(t, err)
+	assert.Equal(t, order, details.Order)
+	assert.Equal(t, address, details.ShippingAddress)
+	assert.Equal(t, discount, details.AppliedDiscount)
+	assert.Nil(t, details.StatusHistory)
+}
+
+// TestGetOrderWithFullDetails_PermissionDenied verifica que un usuario que no es
+// dueño de la orden ni admin no puede consultarla, y que un admin sí puede.
+func TestGetOrderWithFullDetails_PermissionDenied(t *testing.T) {
+	mockOrderRepo := new(MockOrderRepository)
+	uc := usecase.NewOrderUseCase(new(MockValidator), mockOrderRepo, new(mocks.ProductRepository), nil, nil, nil, nil, nil)
+
+	order := &orderEntity.Order{ID: "o1", UserID: "u1"}
+	mockOrderRepo.On("GetOrderByID", mock.Anything, "o1", true).Return(order, nil)
+	mockOrderRepo.On("GetStatusHistory", mock.Anything, "o1").Return(nil, nil)
+
+	_, err := uc.GetOrderWithFullDetails(context.Background(), "o1", "u2", utils.RoleCustomer)
+	assert.ErrorIs(t, err, usecase.ErrPermissionDenied)
+
+	details, err := uc.GetOrderWithFullDetails(context.Background(), "o1", "u2", utils.RoleAdmin)
+	assert.NoError(t, err)
+	assert.Equal(t, "o1", details.ID)
+}
+
+// -------------------------------------
+// Tests de MarkOrderAsPaid
+// -------------------------------------
+
+// TestMarkOrderAsPaid_FirstPayment verifica que el primer pago guarda el
+// PaymentID, la fecha de pago, el estado de pago 'paid' y pasa la orden a
+// 'progress', dejando el cambio en el registro de auditoría.
+func TestMarkOrderAsPaid_FirstPayment(t *testing.T) {
+	mockOrderRepo := new(MockOrderRepository)
+	uc := usecase.NewOrderUseCase(new(MockValidator), mockOrderRepo, new(mocks.ProductRepository), nil, nil, nil, nil, nil)
+
+	existing := &orderEntity.Order{ID: "o1", UserID: "u1", Status: utils.OrderStatusNew}
+	mockOrderRepo.On("GetOrderByID", mock.Anything, "o1", false).Return(existing, nil)
+	mockOrderRepo.On("UpdateOrder", mock.Anything, existing).Return(nil)
+	mockOrderRepo.On("CreateAuditLog", mock.Anything, mock.MatchedBy(func(log *orderEntity.OrderAuditLog) bool {
+		return log.OrderID == "o1" && log.FromStatus == utils.OrderStatusNew && log.ToStatus == utils.OrderStatusInProgress &&
+			log.ChangedBy == utils.RolePaymentGateway
+	})).Return(nil)
+
+	err := uc.MarkOrderAsPaid(context.Background(), "o1", utils.RolePaymentGateway, "pay_123")
+
+	assert.NoError(t, err)
+	if assert.NotNil(t, existing.PaymentID) {
+		assert.Equal(t, "pay_123", *existing.PaymentID)
+	}
+	assert.NotNil(t, existing.PaidAt)
+	assert.Equal(t, utils.PaymentStatusPaid, existing.PaymentStatus)
+	assert.Equal(t, utils.OrderStatusInProgress, existing.Status)
+	mockOrderRepo.AssertExpectations(t)
+}
+
+// TestMarkOrderAsPaid_SamePaymentID verifica que repetir el callback con el
+// mismo PaymentID no hace nada (idempotente).
+func TestMarkOrderAsPaid_SamePaymentID(t *testing.T) {
+	mockOrderRepo := new(MockOrderRepository)
+	uc := usecase.NewOrderUseCase(new(MockValidator), mockOrderRepo, new(mocks.ProductRepository), nil, nil, nil, nil, nil)
+
+	paidAt := time.Now().Add(-time.Hour)
+	existing := &orderEntity.Order{
+		ID:        "o1",
+		Status:    utils.OrderStatusInProgress,
+		PaymentID: strPtr("pay_123"),
+		PaidAt:    &paidAt,
+	}
+	mockOrderRepo.On("GetOrderByID", mock.Anything, "o1", false).Return(existing, nil)
+
+	err := uc.MarkOrderAsPaid(context.Background(), "o1", utils.RolePaymentGateway, "pay_123")
+
+	assert.NoError(t, err)
+	assert.Equal(t, paidAt, *existing.PaidAt)
+	mockOrderRepo.AssertNotCalled(t, "UpdateOrder", mock.Anything, mock.Anything)
+}
+
+// TestMarkOrderAsPaid_DifferentPaymentID verifica que un segundo pago con otro
+// PaymentID se rechaza con ErrAlreadyPaid.
+func TestMarkOrderAsPaid_DifferentPaymentID(t *testing.T) {
+	mockOrderRepo := new(MockOrderRepository)
+	uc := usecase.NewOrderUseCase(new(MockValidator), mockOrderRepo, new(mocks.ProductRepository), nil, nil, nil, nil, nil)
+
+	existing := &orderEntity.Order{ID: "o1", Status: utils.OrderStatusInProgress, PaymentID: strPtr("pay_123")}
+	mockOrderRepo.On("GetOrderByID", mock.Anything, "o1", false).Return(existing, nil)
+
+	err := uc.MarkOrderAsPaid(context.Background(), "o1", utils.RolePaymentGateway, "pay_999")
+
+	assert.ErrorIs(t, err, usecase.ErrAlreadyPaid)
+	assert.Equal(t, "pay_123", *existing.PaymentID)
+	mockOrderRepo.AssertNotCalled(t, "UpdateOrder", mock.Anything, mock.Anything)
+}
+
+// TestMarkOrderAsPaid_InvalidStatus verifica que no se puede pagar una orden
+// terminada o cancelada.
+func TestMarkOrderAsPaid_InvalidStatus(t *testing.T) {
+	mockOrderRepo := new(MockOrderRepository)
+	uc := usecase.NewOrderUseCase(new(MockValidator), mockOrderRepo, new(mocks.ProductRepository), nil, nil, nil, nil, nil)
+
+	existing := &orderEntity.Order{ID: "o1", Status: utils.OrderStatusCanceled}
+	mockOrderRepo.On("GetOrderByID", mock.Anything, "o1", false).Return(existing, nil)
+
+	err := uc.MarkOrderAsPaid(context.Background(), "o1", utils.RolePaymentGateway, "pay_123")
+
+	assert.ErrorIs(t, err, usecase.ErrInvalidOrderStatus)
+	mockOrderRepo.AssertNotCalled(t, "UpdateOrder", mock.Anything, mock.Anything)
+}
+
+// TestMarkOrderAsPaid_Forbidden verifica que un cliente no puede marcar una
+// orden como pagada.
+func TestMarkOrderAsPaid_Forbidden(t *testing.T) {
+	mockOrderRepo := new(MockOrderRepository)
+	uc := usecase.NewOrderUseCase(new(MockValidator), mockOrderRepo, new(mocks.ProductRepository), nil, nil, nil, nil, nil)
+
+	err := uc.MarkOrderAsPaid(context.Background(), "o1", utils.RoleCustomer, "pay_123")
+
+	assert.ErrorIs(t, err, usecase.ErrForbidden)
+	mockOrderRepo.AssertNotCalled(t, "GetOrderByID", mock.Anything, mock.Anything, mock.Anything)
+	mockOrderRepo.AssertNotCalled(t, "UpdateOrder", mock.Anything, mock.Anything)
+}
+
+// -------------------------------------
+// Tests de GetOrderReceipt
+// -------------------------------------
+
+func receiptOrder() *orderEntity.Order {
+	return &orderEntity.Order{
+		ID:             "o1",
+		UserID:         "u1",
+		DiscountID:     strPtr("d1"),
+		DiscountAmount: 5.5,
+		TaxAmount:      2,
+		Lines: []*orderEntity.OrderLine{
+			{ProductID: "p1", Quantity: 2, Price: 20, Product: &productEntity.Product{ID: "p1", Name: "Mango"}},
+			{ProductID: "p2", Quantity: 1, Price: 5.5, Product: &productEntity.Product{ID: "p2", Name: "Pepino"}},
+		},
+	}
+}
+
+// TestGetOrderReceipt_PermissionDenied verifica que un usuario no puede
+// obtener el recibo de una orden ajena.
+func TestGetOrderReceipt_PermissionDenied(t *testing.T) {
+	mockOrderRepo := new(MockOrderRepository)
+	uc := usecase.NewOrderUseCase(new(MockValidator), mockOrderRepo, new(mocks.ProductRepository), nil, nil, nil, nil, nil)
+
+	mockOrderRepo.On("GetOrderByID", mock.Anything, "o1", true).Return(receiptOrder(), nil)
+
+	receipt, err := uc.GetOrderReceipt(context.Background(), "o1", "intruder")
+
+	assert.Nil(t, receipt)
+	assert.ErrorIs(t, err, usecase.ErrPermissionDenied)
+	mockOrderRepo.AssertNotCalled(t, "GetDiscount", mock.Anything, mock.Anything)
+}
+
+// TestGetOrderReceipt_Totals verifica el subtotal, el descuento y el impuesto
+// guardados en la orden, el total y el precio unitario de cada línea.
+func TestGetOrderReceipt_Totals(t *testing.T) {
+	mockOrderRepo := new(MockOrderRepository)
+	uc := usecase.NewOrderUseCase(new(MockValidator), mockOrderRepo, new(mocks.ProductRepository), nil, nil, nil, nil, nil)
+
+	mockOrderRepo.On("GetOrderByID", mock.Anything, "o1", true).Return(receiptOrder(), nil)
+
+	receipt, err := uc.GetOrderReceipt(context.Background(), "o1", "u1")
+
+	assert.NoError(t, err)
+	assert.Equal(t, "o1", receipt.OrderID)
+	assert.Equal(t, "u1", receipt.UserID)
+	assert.Len(t, receipt.Lines, 2)
+	assert.Equal(t, 10.0, receipt.Lines[0].UnitPrice)
+	assert.Equal(t, 20.0, receipt.Lines[0].Total)
+	assert.Equal(t, 25.5, receipt.Subtotal)
+	assert.Equal(t, 5.5, receipt.Discount)
+	assert.Equal(t, 2.0, receipt.Tax)
+	assert.Equal(t, 22.0, receipt.Total)
+	mockOrderRepo.AssertExpectations(t)
+}
+
+// TestGetOrderReceipt_StoredAmounts verifica que el recibo usa el descuento y
+// el impuesto cobrados en el checkout sin volver a leer el descuento, que pudo
+// cambiar desde entonces.
+func TestGetOrderReceipt_StoredAmounts(t *testing.T) {
+	mockOrderRepo := new(MockOrderRepository)
+	uc := usecase.NewOrderUseCase(new(MockValidator), mockOrderRepo, new(mocks.ProductRepository), nil, nil, nil, nil, nil)
+
+	order := receiptOrder()
+	order.DiscountAmount = 2.55
+	order.TaxAmount = 4.82
+	mockOrderRepo.On("GetOrderByID", mock.Anything, "o1", true).Return(order, nil)
+
+	receipt, err := uc.GetOrderReceipt(context.Background(), "o1", "u1")
+
+	assert.NoError(t, err)
+	assert.Equal(t, 2.55, receipt.Discount)
+	assert.Equal(t, 4.82, receipt.Tax)
+	assert.Equal(t, 27.77, receipt.Total)
+	mockOrderRepo.AssertNotCalled(t, "GetDiscount", mock.Anything, mock.Anything)
+}
+
+// TestGetOrderReceipt_GiftCard verifica que lo pagado con tarjeta regalo se
+// descuenta del total, nunca por encima de él.
+func TestGetOrderReceipt_GiftCard(t *testing.T) {
+	cases := []struct {
+		name     string
+		amount   float64
+		giftCard float64
+	}{
+		{"parcial", 10, 10},
+		{"mayor que el total", 1000, 22},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockOrderRepo := new(MockOrderRepository)
+			uc := usecase.NewOrderUseCase(new(MockValidator), mockOrderRepo, new(mocks.ProductRepository), nil, nil, nil, nil, nil)
+
+			order := receiptOrder()
+			order.GiftCardAmount = tc.amount
+			mockOrderRepo.On("GetOrderByID", mock.Anything, "o1", true).Return(order, nil)
+
+			receipt, err := uc.GetOrderReceipt(context.Background(), "o1", "u1")
+
+			assert.NoError(t, err)
+			assert.InDelta(t, tc.giftCard, receipt.GiftCard, 0.001)
+			assert.InDelta(t, 22-tc.giftCard, receipt.Total, 0.001)
+		})
+	}
+}
+
+// TestGetOrderReceipt_FormatAsText verifica que el texto del recibo incluye
+// las líneas y los importes formateados con dos decimales.
+func TestGetOrderReceipt_FormatAsText(t *testing.T) {
+	receipt := &orderEntity.Receipt{
+		OrderID:   "o1",
+		UserID:    "u1",
+		IssueDate: time.Date(2025, 3, 4, 10, 30, 0, 0, time.UTC),
+		Lines: []orderEntity.ReceiptLine{
+			{ProductID: "p1", Name: "Mango", Quantity: 2, UnitPrice: 10, Total: 20},
+		},
+		Subtotal: 20,
+		Discount: 5,
+		Tax:      1.5,
+		Total:    16.5,
+	}
+
+	text := receipt.FormatAsText()
+
+	assert.Contains(t, text, "Order: o1\n")
+	assert.Contains(t, text, "Customer: u1\n")
+	assert.Contains(t, text, "Date: 2025-03-04 10:30\n")
+	assert.Contains(t, text, "Mango x2 @ 10.00 = 20.00\n")
+	assert.Contains(t, text, "Subtotal: 20.00\n")
+	assert.Contains(t, text, "Discount: -5.00\n")
+	assert.Contains(t, text, "Tax: 1.50\n")
+	assert.Contains(t, text, "Total: 16.50\n")
+}
+
+// -------------------------------------
+// Tests de CalculateShipping
+// -------------------------------------
+
+// TestCalculateShipping_Success verifica que el peso enviado a la calculadora
+// es la suma de peso * cantidad de cada línea.
+func TestCalculateShipping_Success(t *testing.T) {
+	mockOrderRepo := new(MockOrderRepository)
+	calculator := new(MockShippingCalculator)
+	uc := usecase.NewOrderUseCase(new(MockValidator), mockOrderRepo, new(mocks.ProductRepository), calculator, nil, nil, nil, nil)
+
+	order := &orderEntity.Order{
+		ID:     "o1",
+		UserID: "u1",
+		Lines: []*orderEntity.OrderLine{
+			{ProductID: "p1", Quantity: 2, Product: &productEntity.Product{ID: "p1", Weight: 1.5}},
+			{ProductID: "p2", Quantity: 1, Product: &productEntity.Product{ID: "p2", Weight: 0.5}},
+		},
+	}
+	destination := addressEntity.Address{City: "Lima", Country: "PE"}
+	expected := &orderEntity.ShippingQuote{Carrier: "acme", Weight: 3.5, Cost: 12}
+	mockOrderRepo.On("GetOrderByID", mock.Anything, "o1", true).Return(order, nil)
+	calculator.On("Calculate", mock.Anything, 3.5, destination).Return(expected, nil)
+
+	quote, err := uc.CalculateShipping(context.Background(), "o1", "u1", utils.RoleCustomer, destination)
+
+	assert.NoError(t, err)
+	assert.Equal(t, expected, quote)
+	calculator.AssertExpectations(t)
+}
+
+// TestCalculateShipping_EmptyOrder verifica que una orden sin líneas devuelve
+// ErrOrderEmpty sin llamar a la calculadora.
+func TestCalculateShipping_EmptyOrder(t *testing.T) {
+	mockOrderRepo := new(MockOrderRepository)
+	calculator := new(MockShippingCalculator)
+	uc := usecase.NewOrderUseCase(new(MockValidator), mockOrderRepo, new(mocks.ProductRepository), calculator, nil, nil, nil, nil)
+
+	mockOrderRepo.On("GetOrderByID", mock.Anything, "o1", true).Return(&orderEntity.Order{ID: "o1", UserID: "u1"}, nil)
+
+	quote, err := uc.CalculateShipping(context.Background(), "o1", "u1", utils.RoleCustomer, addressEntity.Address{})
+
+	assert.Nil(t, quote)
+	assert.ErrorIs(t, err, usecase.ErrOrderEmpty)
+	calculator.AssertNotCalled(t, "Calculate", mock.Anything, mock.Anything, mock.Anything)
+}
+
+// TestCalculateShipping_CalculatorError verifica que un fallo de la
+// calculadora se reporta como ErrCalculatorUnavailable.
+func TestCalculateShipping_CalculatorError(t *testing.T) {
+	mockOrderRepo := new(MockOrderRepository)
+	calculator := new(MockShippingCalculator)
+	uc := usecase.NewOrderUseCase(new(MockValidator), mockOrderRepo, new(mocks.ProductRepository), calculator, nil, nil, nil, nil)
+
+	order := &orderEntity.Order{
+		ID:     "o1",
+		UserID: "u1",
+		Lines:  []*orderEntity.OrderLine{{ProductID: "p1", Quantity: 1, Product: &productEntity.Product{ID: "p1", Weight: 1}}},
+	}
+	mockOrderRepo.On("GetOrderByID", mock.Anything, "o1", true).Return(order, nil)
+	calculator.On("Calculate", mock.Anything, 1.0, mock.Anything).Return(nil, errors.New("timeout"))
+
+	quote, err := uc.CalculateShipping(context.Background(), "o1", "u1", utils.RoleCustomer, addressEntity.Address{})
+
+	assert.Nil(t, quote)
+	assert.ErrorIs(t, err, usecase.ErrCalculatorUnavailable)
+}
+
+// TestCalculateShipping_NotOwner verifica que otro cliente no puede cotizar el
+// envío del pedido y que no se llama a la calculadora.
+func TestCalculateShipping_NotOwner(t *testing.T) {
+	mockOrderRepo := new(MockOrderRepository)
+	calculator := new(MockShippingCalculator)
+	uc := usecase.NewOrderUseCase(new(MockValidator), mockOrderRepo, new(mocks.ProductRepository), calculator, nil, nil, nil, nil)
+
+	order := &orderEntity.Order{
+		ID:     "o1",
+		UserID: "u1",
+		Lines:  []*orderEntity.OrderLine{{ProductID: "p1", Quantity: 1, Product: &productEntity.Product{ID: "p1", Weight: 1}}},
+	}
+	mockOrderRepo.On("GetOrderByID", mock.Anything, "o1", true).Return(order, nil)
+
+	quote, err := uc.CalculateShipping(context.Background(), "o1", "u2", utils.RoleCustomer, addressEntity.Address{})
+
+	assert.Nil(t, quote)
+	assert.ErrorIs(t, err, usecase.ErrPermissionDenied)
+	calculator.AssertNotCalled(t, "Calculate", mock.Anything, mock.Anything, mock.Anything)
+}
+
+// -------------------------------------
+// Tests de GetOrdersForUser
+// -------------------------------------
+
+func expectOrdersForUser(repo *MockOrderRepository, userID string, orders []*orderEntity.Order) *paging.Pagination {
+	pagination := paging.NewPagination(1, 10, int64(len(orders)))
+	repo.On("GetMyOrders", mock.Anything, &orderDto.ListOrdersRequest{UserID: userID, Page: 1, Limit: 10}).
+		Return(orders, pagination, nil)
+	return pagination
+}
+
+// TestGetOrdersForUser_AdminOwn verifica que un admin puede listar sus propias
+// órdenes.
+func TestGetOrdersForUser_AdminOwn(t *testing.T) {
+	mockOrderRepo := new(MockOrderRepository)
+	uc := usecase.NewOrderUseCase(new(MockValidator), mockOrderRepo, new(mocks.ProductRepository), nil, nil, nil, nil, nil)
+
+	expected := []*orderEntity.Order{{ID: "o1", UserID: "admin1"}}
+	pagination := expectOrdersForUser(mockOrderRepo, "admin1", expected)
+
+	orders, page, err := uc.GetOrdersForUser(context.Background(), "admin1", "admin1", utils.RoleAdmin, paging.NewPagination(1, 10, 0))
+
+	assert.NoError(t, err)
+	assert.Equal(t, expected, orders)
+	assert.Equal(t, pagination, page)
+}
+
+// TestGetOrdersForUser_AdminOther verifica que un admin puede listar las
+// órdenes de otro usuario.
+func TestGetOrdersForUser_AdminOther(t *testing.T) {
+	mockOrderRepo := new(MockOrderRepository)
+	uc := usecase.NewOrderUseCase(new(MockValidator), mockOrderRepo, new(mocks.ProductRepository), nil, nil, nil, nil, nil)
+
+	expected := []*orderEntity.Order{{ID: "o2", UserID: "u2"}}
+	expectOrdersForUser(mockOrderRepo, "u2", expected)
+
+	orders, _, err := uc.GetOrdersForUser(context.Background(), "u2", "admin1", utils.RoleAdmin, paging.NewPagination(1, 10, 0))
+
+	assert.NoError(t, err)
+	assert.Equal(t, expected, orders)
+	mockOrderRepo.AssertExpectations(t)
+}
+
+// TestGetOrdersForUser_UserOwn verifica que un usuario puede listar sus
+// propias órdenes.
+func TestGetOrdersForUser_UserOwn(t *testing.T) {
+	mockOrderRepo := new(MockOrderRepository)
+	uc := usecase.NewOrderUseCase(new(MockValidator), mockOrderRepo, new(mocks.ProductRepository), nil, nil, nil, nil, nil)
+
+	expected := []*orderEntity.Order{{ID: "o3", UserID: "u1"}}
+	expectOrdersForUser(mockOrderRepo, "u1", expected)
+
+	orders, _, err := uc.GetOrdersForUser(context.Background(), "u1", "u1", utils.RoleCustomer, paging.NewPagination(1, 10, 0))
+
+	assert.NoError(t, err)
+	assert.Equal(t, expected, orders)
+}
+
+// TestGetOrdersForUser_UserOther verifica que un usuario no puede listar las
+// órdenes de otro.
+func TestGetOrdersForUser_UserOther(t *testing.T) {
+	mockOrderRepo := new(MockOrderRepository)
+	uc := usecase.NewOrderUseCase(new(MockValidator), mockOrderRepo, new(mocks.ProductRepository), nil, nil, nil, nil, nil)
+
+	orders, page, err := uc.GetOrdersForUser(context.Background(), "u2", "u1", utils.RoleCustomer, paging.NewPagination(1, 10, 0))
+
+	assert.Nil(t, orders)
+	assert.Nil(t, page)
+	assert.ErrorIs(t, err, usecase.ErrForbidden)
+	mockOrderRepo.AssertNotCalled(t, "GetMyOrders", mock.Anything, mock.Anything)
+}
+
+// TestGetOrdersForUser_Empty verifica que un usuario sin órdenes recibe una
+// lista vacía sin error.
+func TestGetOrdersForUser_Empty(t *testing.T) {
+	mockOrderRepo := new(MockOrderRepository)
+	uc := usecase.NewOrderUseCase(new(MockValidator), mockOrderRepo, new(mocks.ProductRepository), nil, nil, nil, nil, nil)
+
+	expectOrdersForUser(mockOrderRepo, "u1", []*orderEntity.Order{})
+
+	orders, page, err := uc.GetOrdersForUser(context.Background(), "u1", "u1", utils.RoleCustomer, paging.NewPagination(1, 10, 0))
+
+	assert.NoError(t, err)
+	assert.Empty(t, orders)
+	assert.Equal(t, int64(0), page.TotalCount)
+}
+
+// -------------------------------------
+// Tests de AddOrderNote / GetOrderNotes
+// -------------------------------------
+
+// TestAddOrderNote_Success verifica que un agente de soporte puede crear una
+// nota y que se guarda con autor y contenido sin espacios sobrantes.
+func TestAddOrderNote_Success(t *testing.T) {
+	noteRepo := new(MockOrderNoteRepository)
+	mockOrderRepo := new(MockOrderRepository)
+	uc := usecase.NewOrderUseCase(new(MockValidator), mockOrderRepo, new(mocks.ProductRepository), nil, noteRepo, nil, nil, nil)
+
+	mockOrderRepo.On("GetOrderByID", mock.Anything, "o1", false).Return(&orderEntity.Order{ID: "o1"}, nil)
+	noteRepo.On("CreateNote", mock.Anything, mock.MatchedBy(func(n *orderEntity.OrderNote) bool {
+		return n.OrderID == "o1" && n.Content == "Cliente reporta paquete dañado" && n.CreatedBy == "agent1"
+	})).Return(nil)
+
+	err := uc.AddOrderNote(context.Background(), "o1", "  Cliente reporta paquete dañado ", "agent1", utils.RoleSupport)
+
+	assert.NoError(t, err)
+	noteRepo.AssertExpectations(t)
+}
+
+// TestAddOrderNote_RoleGuard verifica que un cliente no puede crear ni leer
+// notas internas.
+func TestAddOrderNote_RoleGuard(t *testing.T) {
+	noteRepo := new(MockOrderNoteRepository)
+	uc := usecase.NewOrderUseCase(new(MockValidator), new(MockOrderRepository), new(mocks.ProductRepository), nil, noteRepo, nil, nil, nil)
+
+	err := uc.AddOrderNote(context.Background(), "o1", "nota", "u1", utils.RoleCustomer)
+	assert.ErrorIs(t, err, usecase.ErrForbidden)
+
+	notes, err := uc.GetOrderNotes(context.Background(), "o1", utils.RoleCustomer)
+	assert.Nil(t, notes)
+	assert.ErrorIs(t, err, usecase.ErrForbidden)
+
+	noteRepo.AssertNotCalled(t, "CreateNote", mock.Anything, mock.Anything)
+	noteRepo.AssertNotCalled(t, "ListNotes", mock.Anything, mock.Anything)
+}
+
+// TestAddOrderNote_EmptyNote verifica que una nota vacía o solo con espacios
+// devuelve ErrEmptyNote.
+func TestAddOrderNote_EmptyNote(t *testing.T) {
+	noteRepo := new(MockOrderNoteRepository)
+	uc := usecase.NewOrderUseCase(new(MockValidator), new(MockOrderRepository), new(mocks.ProductRepository), nil, noteRepo, nil, nil, nil)
+
+	err := uc.AddOrderNote(context.Background(), "o1", "   ", "admin1", utils.RoleAdmin)
+
+	assert.ErrorIs(t, err, usecase.ErrEmptyNote)
+	noteRepo.AssertNotCalled(t, "CreateNote", mock.Anything, mock.Anything)
+}
+
+// TestAddOrderNote_OrderNotFound verifica que no se crea una nota para una
+// orden que no existe.
+func TestAddOrderNote_OrderNotFound(t *testing.T) {
+	noteRepo := new(MockOrderNoteRepository)
+	mockOrderRepo := new(MockOrderRepository)
+	uc := usecase.NewOrderUseCase(new(MockValidator), mockOrderRepo, new(mocks.ProductRepository), nil, noteRepo, nil, nil, nil)
+
+	mockOrderRepo.On("GetOrderByID", mock.Anything, "missing", false).Return((*orderEntity.Order)(nil), gorm.ErrRecordNotFound)
+
+	err := uc.AddOrderNote(context.Background(), "missing", "nota", "admin1", utils.RoleAdmin)
+
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+	noteRepo.AssertNotCalled(t, "CreateNote", mock.Anything, mock.Anything)
+}
+
+// TestGetOrderNotes_Success verifica que un admin obtiene las notas de la
+// orden.
+func TestGetOrderNotes_Success(t *testing.T) {
+	noteRepo := new(MockOrderNoteRepository)
+	uc := usecase.NewOrderUseCase(new(MockValidator), new(MockOrderRepository), new(mocks.ProductRepository), nil, noteRepo, nil, nil, nil)
+
+	expected := []*orderEntity.OrderNote{{ID: "n1", OrderID: "o1", Content: "revisar"}}
+	noteRepo.On("ListNotes", mock.Anything, "o1").Return(expected, nil)
+
+	notes, err := uc.GetOrderNotes(context.Background(), "o1", utils.RoleAdmin)
+
+	assert.NoError(t, err)
+	assert.Equal(t, expected, notes)
+}
+
+// -------------------------------------
+// Tests de GroupOrderLinesByCategory
+// -------------------------------------
+
+// TestGroupOrderLinesByCategory_SameCategory verifica que todas las líneas de
+// una misma categoría se suman en una sola entrada.
+func TestGroupOrderLinesByCategory_SameCategory(t *testing.T) {
+	mockOrderRepo := new(MockOrderRepository)
+	uc := usecase.NewOrderUseCase(new(MockValidator), mockOrderRepo, new(mocks.ProductRepository), nil, nil, nil, nil, nil)
+
+	order := &orderEntity.Order{ID: "o1", UserID: "u1", Lines: []*orderEntity.OrderLine{
+		{ProductID: "p1", Price: 20, Product: &productEntity.Product{ID: "p1", CategoryID: strPtr("fruits")}},
+		{ProductID: "p2", Price: 5.5, Product: &productEntity.Product{ID: "p2", CategoryID: strPtr("fruits")}},
+	}}
+	mockOrderRepo.On("GetOrderByID", mock.Anything, "o1", true).Return(order, nil)
+
+	totals, err := uc.GroupOrderLinesByCategory(context.Background(), "o1", "u1", utils.RoleCustomer)
+
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]float64{"fruits": 25.5}, totals)
+}
+
+// TestGroupOrderLinesByCategory_MultipleCategories verifica que el gasto se
+// reparte por categoría.
+func TestGroupOrderLinesByCategory_MultipleCategories(t *testing.T) {
+	mockOrderRepo := new(MockOrderRepository)
+	uc := usecase.NewOrderUseCase(new(MockValidator), mockOrderRepo, new(mocks.ProductRepository), nil, nil, nil, nil, nil)
+
+	order := &orderEntity.Order{ID: "o1", UserID: "u1", Lines: []*orderEntity.OrderLine{
+		{ProductID: "p1", Price: 20, Product: &productEntity.Product{ID: "p1", CategoryID: strPtr("fruits")}},
+		{ProductID: "p2", Price: 7, Product: &productEntity.Product{ID: "p2", CategoryID: strPtr("dairy")}},
+		{ProductID: "p3", Price: 3, Product: &productEntity.Product{ID: "p3", CategoryID: strPtr("fruits")}},
+	}}
+	mockOrderRepo.On("GetOrderByID", mock.Anything, "o1", true).Return(order, nil)
+
+	totals, err := uc.GroupOrderLinesByCategory(context.Background(), "o1", "u1", utils.RoleCustomer)
+
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]float64{"fruits": 23, "dairy": 7}, totals)
+}
+
+// TestGroupOrderLinesByCategory_Uncategorized verifica que un producto sin
+// categoría devuelve ErrCategoryUnresolved.
+func TestGroupOrderLinesByCategory_Uncategorized(t *testing.T) {
+	mockOrderRepo := new(MockOrderRepository)
+	uc := usecase.NewOrderUseCase(new(MockValidator), mockOrderRepo, new(mocks.ProductRepository), nil, nil, nil, nil, nil)
+
+	order := &orderEntity.Order{ID: "o1", UserID: "u1", Lines: []*orderEntity.OrderLine{
+		{ProductID: "p1", Price: 20, Product: &productEntity.Product{ID: "p1", CategoryID: strPtr("fruits")}},
+		{ProductID: "p2", Price: 7, Product: &productEntity.Product{ID: "p2"}},
+	}}
+	mockOrderRepo.On("GetOrderByID", mock.Anything, "o1", true).Return(order, nil)
+
+	totals, err := uc.GroupOrderLinesByCategory(context.Background(), "o1", "u1", utils.RoleCustomer)
+
+	assert.Nil(t, totals)
+	assert.ErrorIs(t, err, usecase.ErrCategoryUnresolved)
+}
+
+// TestGroupOrderLinesByCategory_OrderNotFound verifica que se propaga el error
+// cuando la orden no existe.
+func TestGroupOrderLinesByCategory_OrderNotFound(t *testing.T) {
+	mockOrderRepo := new(MockOrderRepository)
+	uc := usecase.NewOrderUseCase(new(MockValidator), mockOrderRepo, new(mocks.ProductRepository), nil, nil, nil, nil, nil)
+
+	mockOrderRepo.On("GetOrderByID", mock.Anything, "missing", true).Return((*orderEntity.Order)(nil), errors.New("record not found"))
+
+	totals, err := uc.GroupOrderLinesByCategory(context.Background(), "missing", "u1", utils.RoleCustomer)
+
+	assert.Nil(t, totals)
+	assert.EqualError(t, err, "record not found")
+}
+
+// TestGroupOrderLinesByCategory_NotOwner verifica que otro cliente no ve el
+// gasto por categoría del pedido y que un administrador sí.
+func TestGroupOrderLinesByCategory_NotOwner(t *testing.T) {
+	mockOrderRepo := new(MockOrderRepository)
+	uc := usecase.NewOrderUseCase(new(MockValidator), mockOrderRepo, new(mocks.ProductRepository), nil, nil, nil, nil, nil)
+
+	order := &orderEntity.Order{ID: "o1", UserID: "u1", Lines: []*orderEntity.OrderLine{
+		{ProductID: "p1", Price: 20, Product: &productEntity.Product{ID: "p1", CategoryID: strPtr("fruits")}},
+	}}
+	mockOrderRepo.On("GetOrderByID", mock.Anything, "o1", true).Return(order, nil)
+
+	totals, err := uc.GroupOrderLinesByCategory(context.Background(), "o1", "u2", utils.RoleCustomer)
+
+	assert.Nil(t, totals)
+	assert.ErrorIs(t, err, usecase.ErrPermissionDenied)
+
+	totals, err = uc.GroupOrderLinesByCategory(context.Background(), "o1", "admin1", utils.RoleAdmin)
+
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]float64{"fruits": 20}, totals)
+}
+
+// -------------------------------------
+// Tests de SplitOrder
+// -------------------------------------
+
+func splittableOrder() *orderEntity.Order {
+	return &orderEntity.Order{
+		ID:                "o1",
+		UserID:            "u1",
+		Status:            utils.OrderStatusNew,
+		TotalPrice:        35,
+		ShippingAddressID: strPtr("a1"),
+		DiscountID:        strPtr("d1"),
+		Lines: []*orderEntity.OrderLine{
+			{ID: "l1", OrderID: "o1", Price: 20},
+			{ID: "l2", OrderID: "o1", Price: 10},
+			{ID: "l3", OrderID: "o1", Price: 5},
+		},
+	}
+}
+
+// TestSplitOrder_Success verifica que las líneas indicadas pasan a una nueva
+// orden que hereda dirección y descuento, y que se recalculan los totales.
+func TestSplitOrder_Success(t *testing.T) {
+	mockOrderRepo := new(MockOrderRepository)
+	uc := usecase.NewOrderUseCase(new(MockValidator), mockOrderRepo, new(mocks.ProductRepository), nil, nil, nil, nil, nil)
+
+	order := splittableOrder()
+	mockOrderRepo.On("GetOrderByID", mock.Anything, "o1", true).Return(order, nil)
+	mockOrderRepo.On("SplitOrder", mock.Anything, order, mock.Anything).Return(nil)
+
+	orders, err := uc.SplitOrder(context.Background(), "o1", "u1", []string{"l2", "l3"})
+
+	assert.NoError(t, err)
+	if assert.Len(t, orders, 2) {
+		original, split := orders[0], orders[1]
+		assert.Equal(t, 20.0, original.TotalPrice)
+		assert.Len(t, original.Lines, 1)
+		assert.Equal(t, "l1", original.Lines[0].ID)
+		assert.Equal(t, 15.0, split.TotalPrice)
+		assert.Len(t, split.Lines, 2)
+		assert.Equal(t, "u1", split.UserID)
+		assert.Equal(t, "a1", *split.ShippingAddressID)
+		assert.Equal(t, "d1", *split.DiscountID)
+	}
+	mockOrderRepo.AssertExpectations(t)
+}
+
+// TestSplitOrder_PermissionDenied verifica que no se puede dividir una orden
+// ajena.
+func TestSplitOrder_PermissionDenied(t *testing.T) {
+	mockOrderRepo := new(MockOrderRepository)
+	uc := usecase.NewOrderUseCase(new(MockValidator), mockOrderRepo, new(mocks.ProductRepository), nil, nil, nil, nil, nil)
+
+	mockOrderRepo.On("GetOrderByID", mock.Anything, "o1", true).Return(splittableOrder(), nil)
+
+	orders, err := uc.SplitOrder(context.Background(), "o1", "intruder", []string{"l1"})
+
+	assert.Nil(t, orders)
+	assert.ErrorIs(t, err, usecase.ErrPermissionDenied)
+}
+
+// TestSplitOrder_InvalidStatus verifica que solo se dividen órdenes en estado
+// 'new'.
+func TestSplitOrder_InvalidStatus(t *testing.T) {
+	mockOrderRepo := new(MockOrderRepository)
+	uc := usecase.NewOrderUseCase(new(MockValidator), mockOrderRepo, new(mocks.ProductRepository), nil, nil, nil, nil, nil)
+
+	order := splittableOrder()
+	order.Status = utils.OrderStatusInProgress
+	mockOrderRepo.On("GetOrderByID", mock.Anything, "o1", true).Return(order, nil)
+
+	orders, err := uc.SplitOrder(context.Background(), "o1", "u1", []string{"l1"})
+
+	assert.Nil(t, orders)
+	assert.ErrorIs(t, err, usecase.ErrInvalidOrderStatus)
+}
+
+// TestSplitOrder_InvalidSplitLines verifica que se rechazan una lista vacía,
+// todas las líneas o IDs que no pertenecen a la orden.
+func TestSplitOrder_InvalidSplitLines(t *testing.T) {
+	cases := map[string][]string{
+		"empty":   {},
+		"all":     {"l1", "l2", "l3"},
+		"unknown": {"l1", "l9"},
+	}
+
+	for name, splitLines := range cases {
+		t.Run(name, func(t *testing.T) {
+			mockOrderRepo := new(MockOrderRepository)
+			uc := usecase.NewOrderUseCase(new(MockValidator), mockOrderRepo, new(mocks.ProductRepository), nil, nil, nil, nil, nil)
+
+			mockOrderRepo.On("GetOrderByID", mock.Anything, "o1", true).Return(splittableOrder(), nil)
+
+			orders, err := uc.SplitOrder(context.Background(), "o1", "u1", splitLines)
+
+			assert.Nil(t, orders)
+			assert.ErrorIs(t, err, usecase.ErrInvalidSplit)
+			mockOrderRepo.AssertNotCalled(t, "SplitOrder", mock.Anything, mock.Anything, mock.Anything)
+		})
+	}
+}
+
+// -------------------------------------
+// Tests de GetAverageOrderValue
+// -------------------------------------
+
+// TestGetAverageOrderValue_ReturnsAverage verifica que se devuelve el valor
+// calculado por el repositorio.
+func TestGetAverageOrderValue_ReturnsAverage(t *testing.T) {
+	mockOrderRepo := new(MockOrderRepository)
+	uc := usecase.NewOrderUseCase(nil, mockOrderRepo, nil, nil, nil, nil, nil, nil)
+
+	since := time.Now().AddDate(0, -1, 0)
+	mockOrderRepo.On("AverageOrderValue", mock.Anything, since).Return(42.5, nil)
+
+	avg, err := uc.GetAverageOrderValue(context.Background(), since, utils.RoleAdmin)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 42.5, avg)
+	mockOrderRepo.AssertExpectations(t)
+}
+
+// TestGetAverageOrderValue_NoOrders verifica que sin pedidos se devuelve 0 sin error.
+func TestGetAverageOrderValue_NoOrders(t *testing.T) {
+	mockOrderRepo := new(MockOrderRepository)
+	uc := usecase.NewOrderUseCase(nil, mockOrderRepo, nil, nil, nil, nil, nil, nil)
+
+	since := time.Now().AddDate(0, -1, 0)
+	mockOrderRepo.On("AverageOrderValue", mock.Anything, since).Return(0.0, nil)
+
+	avg, err := uc.GetAverageOrderValue(context.Background(), since, utils.RoleAdmin)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 0.0, avg)
+}
+
+// TestGetAverageOrderValue_InvalidSince verifica que una fecha de más de 5 años
+// atrás es rechazada.
+func TestGetAverageOrderValue_InvalidSince(t *testing.T) {
+	mockOrderRepo := new(MockOrderRepository)
+	uc := usecase.NewOrderUseCase(nil, mockOrderRepo, nil, nil, nil, nil, nil, nil)
+
+	avg, err := uc.GetAverageOrderValue(context.Background(), time.Now().AddDate(-6, 0, 0), utils.RoleAdmin)
+
+	assert.Equal(t, 0.0, avg)
+	assert.ErrorIs(t, err, usecase.ErrInvalidSince)
+	mockOrderRepo.AssertNotCalled(t, "AverageOrderValue", mock.Anything, mock.Anything)
+}
+
+// TestGetAverageOrderValue_Forbidden verifica que solo un admin puede ver el
+// valor medio de los pedidos.
+func TestGetAverageOrderValue_Forbidden(t *testing.T) {
+	mockOrderRepo := new(MockOrderRepository)
+	uc := usecase.NewOrderUseCase(nil, mockOrderRepo, nil, nil, nil, nil, nil, nil)
+
+	avg, err := uc.GetAverageOrderValue(context.Background(), time.Now().AddDate(0, -1, 0), utils.RoleCustomer)
+
+	assert.Equal(t, 0.0, avg)
+	assert.ErrorIs(t, err, usecase.ErrForbidden)
+	mockOrderRepo.AssertNotCalled(t, "AverageOrderValue", mock.Anything, mock.Anything)
+}
+
+// -------------------------------------
+// Tests de GenerateOrderSummaryReport
+// -------------------------------------
+
+// TestGenerateOrderSummaryReport_Aggregates verifica los totales por estado y
+// el cálculo del valor medio a partir de los pedidos completados.
+func TestGenerateOrderSummaryReport_Aggregates(t *testing.T) {
+	mockOrderRepo := new(MockOrderRepository)
+	uc := usecase.NewOrderUseCase(nil, mockOrderRepo, nil, nil, nil, nil, nil, nil)
+
+	from := time.Date(2024, time.March, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2024, time.April, 1, 0, 0, 0, 0, time.UTC)
+	mockOrderRepo.On("GetOrderTotalsByStatus", mock.Anything, from, to).Return([]*orderEntity.StatusTotals{
+		{Status: utils.OrderStatusDone, Orders: 3, Total: 100, PaidTotal: 100},
+		{Status: utils.OrderStatusCanceled, Orders: 2, Total: 80, PaidTotal: 30},
+		{Status: utils.OrderStatusNew, Orders: 4, Total: 200},
+	}, nil)
+
+	summary, err := uc.GenerateOrderSummaryReport(context.Background(), time.March, 2024, utils.RoleAdmin)
+
+	assert.NoError(t, err)
+	assert.Equal(t, &orderEntity.MonthlySummary{
+		Month:             3,
+		Year:              2024,
+		TotalOrders:       9,
+		CompletedOrders:   3,
+		CanceledOrders:    2,
+		TotalRevenue:      100,
+		AverageOrderValue: 33.33,
+		TotalRefunds:      30,
+	}, summary)
+	mockOrderRepo.AssertExpectations(t)
+}
+
+// TestGenerateOrderSummaryReport_NoCompletedOrders verifica que sin pedidos
+// completados el valor medio es 0 (sin división por cero).
+func TestGenerateOrderSummaryReport_NoCompletedOrders(t *testing.T) {
+	mockOrderRepo := new(MockOrderRepository)
+	uc := usecase.NewOrderUseCase(nil, mockOrderRepo, nil, nil, nil, nil, nil, nil)
+
+	mockOrderRepo.On("GetOrderTotalsByStatus", mock.Anything, mock.Anything, mock.Anything).Return([]*orderEntity.StatusTotals{
+		{Status: utils.OrderStatusNew, Orders: 2, Total: 50},
+	}, nil)
+
+	summary, err := uc.GenerateOrderSummaryReport(context.Background(), time.January, 2024, utils.RoleAdmin)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 2, summary.TotalOrders)
+	assert.Equal(t, 0, summary.CompletedOrders)
+	assert.Equal(t, 0.0, summary.AverageOrderValue)
+}
+
+// TestGenerateOrderSummaryReport_NonAdmin verifica que solo un admin puede
+// generar el informe.
+func TestGenerateOrderSummaryReport_NonAdmin(t *testing.T) {
+	mockOrderRepo := new(MockOrderRepository)
+	uc := usecase.NewOrderUseCase(nil, mockOrderRepo, nil, nil, nil, nil, nil, nil)
+
+	summary, err := uc.GenerateOrderSummaryReport(context.Background(), time.January, 2024, utils.RoleCustomer)
+
+	assert.Nil(t, summary)
+	assert.ErrorIs(t, err, usecase.ErrForbidden)
+}
+
+// TestGenerateOrderSummaryReport_InvalidPeriod verifica la validación del mes
+// y del año.
+func TestGenerateOrderSummaryReport_InvalidPeriod(t *testing.T) {
+	mockOrderRepo := new(MockOrderRepository)
+	uc := usecase.NewOrderUseCase(nil, mockOrderRepo, nil, nil, nil, nil, nil, nil)
+
+	cases := []struct {
+		month time.Month
+		year  int
+	}{
+		{0, 2024},
+		{13, 2024},
+		{time.June, 2019},
+		{time.June, time.Now().Year() + 1},
+	}
+	for _, tc := range cases {
+		summary, err := uc.GenerateOrderSummaryReport(context.Background(), tc.month, tc.year, utils.RoleAdmin)
+		assert.Nil(t, summary)
+		assert.ErrorIs(t, err, usecase.ErrInvalidPeriod)
+	}
+	mockOrderRepo.AssertNotCalled(t, "GetOrderTotalsByStatus", mock.Anything, mock.Anything, mock.Anything)
+}
+
+// -------------------------------------
+// Tests de RefundOrder
+// -------------------------------------
+
+func paidCanceledOrder() *orderEntity.Order {
+	paymentID, paidAt := "pay1", time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	return &orderEntity.Order{
+		ID:         "o1",
+		UserID:     "u1",
+		Status:     utils.OrderStatusCanceled,
+		TotalPrice: 45.5,
+		PaymentID:  &paymentID,
+		PaidAt:     &paidAt,
+	}
+}
+
+// TestRefundOrder_Success verifica que se crea el reembolso por el total del
+// pedido cancelado y se enlaza al pedido.
+func TestRefundOrder_Success(t *testing.T) {
+	mockOrderRepo := new(MockOrderRepository)
+	mockRefundRepo := new(MockRefundRepository)
+	uc := usecase.NewOrderUseCase(nil, mockOrderRepo, nil, nil, nil, mockRefundRepo, nil, nil)
+
+	order := paidCanceledOrder()
+	mockOrderRepo.On("GetOrderByID", mock.Anything, "o1", false).Return(order, nil)
+	mockRefundRepo.On("Create", mock.Anything, mock.AnythingOfType("*entity.Refund")).Return(nil)
+	mockOrderRepo.On("UpdateOrder", mock.Anything, order).Return(nil)
+
+	refund, err := uc.RefundOrder(context.Background(), "o1", "u1", "changed my mind")
+
+	assert.NoError(t, err)
+	assert.Equal(t, "o1", refund.OrderID)
+	assert.Equal(t, 45.5, refund.Amount)
+	assert.Equal(t, "changed my mind", refund.Reason)
+	assert.Equal(t, orderEntity.RefundStatusProcessed, refund.Status)
+	assert.Equal(t, "r1", *order.RefundID)
+	assert.Equal(t, utils.PaymentStatusRefunded, order.PaymentStatus)
+	mockOrderRepo.AssertExpectations(t)
+	mockRefundRepo.AssertExpectations(t)
+}
+
+// TestRefundOrder_NotPaid verifica que un pedido cancelado que nunca se pagó no
+// se puede reembolsar.
+func TestRefundOrder_NotPaid(t *testing.T) {
+	mockOrderRepo := new(MockOrderRepository)
+	mockRefundRepo := new(MockRefundRepository)
+	uc := usecase.NewOrderUseCase(nil, mockOrderRepo, nil, nil, nil, mockRefundRepo, nil, nil)
+
+	order := &orderEntity.Order{ID: "o1", UserID: "u1", Status: utils.OrderStatusCanceled, TotalPrice: 45.5}
+	mockOrderRepo.On("GetOrderByID", mock.Anything, "o1", false).Return(order, nil)
+
+	refund, err := uc.RefundOrder(context.Background(), "o1", "u1", "")
+
+	assert.Nil(t, refund)
+	assert.ErrorIs(t, err, usecase.ErrOrderNotPaid)
+	mockRefundRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+// TestRefundOrder_UpdateFails verifica que si no se puede enlazar el reembolso
+// al pedido se devuelve el error, y la transacción descarta el reembolso.
+func TestRefundOrder_UpdateFails(t *testing.T) {
+	mockOrderRepo := new(MockOrderRepository)
+	mockRefundRepo := new(MockRefundRepository)
+	uc := usecase.NewOrderUseCase(nil, mockOrderRepo, nil, nil, nil, mockRefundRepo, nil, nil)
+
+	updateErr := errors.New("update failed")
+	order := paidCanceledOrder()
+	mockOrderRepo.On("GetOrderByID", mock.Anything, "o1", false).Return(order, nil)
+	mockRefundRepo.On("Create", mock.Anything, mock.AnythingOfType("*entity.Refund")).Return(nil)
+	mockOrderRepo.On("UpdateOrder", mock.Anything, order).Return(updateErr)
+
+	refund, err := uc.RefundOrder(context.Background(), "o1", "u1", "")
+
+	assert.Nil(t, refund)
+	assert.Equal(t, updateErr, err)
+}
+
+// TestRefundOrder_NotOwner verifica que otro usuario no puede reembolsar el pedido.
+func TestRefundOrder_NotOwner(t *testing.T) {
+	mockOrderRepo := new(MockOrderRepository)
+	mockRefundRepo := new(MockRefundRepository)
+	uc := usecase.NewOrderUseCase(nil, mockOrderRepo, nil, nil, nil, mockRefundRepo, nil, nil)
+
+	order := &orderEntity.Order{ID: "o1", UserID: "u1", Status: utils.OrderStatusCanceled}
+	mockOrderRepo.On("GetOrderByID", mock.Anything, "o1", false).Return(order, nil)
+
+	refund, err := uc.RefundOrder(context.Background(), "o1", "u2", "")
+
+	assert.Nil(t, refund)
+	assert.ErrorIs(t, err, usecase.ErrPermissionDenied)
+	mockRefundRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+// TestRefundOrder_NotCanceled verifica que solo se reembolsan pedidos cancelados.
+func TestRefundOrder_NotCanceled(t *testing.T) {
+	mockOrderRepo := new(MockOrderRepository)
+	mockRefundRepo := new(MockRefundRepository)
+	uc := usecase.NewOrderUseCase(nil, mockOrderRepo, nil, nil, nil, mockRefundRepo, nil, nil)
+
+	order := &orderEntity.Order{ID: "o1", UserID: "u1", Status: utils.OrderStatusDone}
+	mockOrderRepo.On("GetOrderByID", mock.Anything, "o1", false).Return(order, nil)
+
+	refund, err := uc.RefundOrder(context.Background(), "o1", "u1", "")
+
+	assert.Nil(t, refund)
+	assert.ErrorIs(t, err, usecase.ErrInvalidOrderStatus)
+	mockRefundRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+// TestRefundOrder_Duplicate verifica que un pedido ya reembolsado devuelve
+// ErrAlreadyRefunded.
+func TestRefundOrder_Duplicate(t *testing.T) {
+	mockOrderRepo := new(MockOrderRepository)
+	mockRefundRepo := new(MockRefundRepository)
+	uc := usecase.NewOrderUseCase(nil, mockOrderRepo, nil, nil, nil, mockRefundRepo, nil, nil)
+
+	refundID := "r0"
+	order := &orderEntity.Order{ID: "o1", UserID: "u1", Status: utils.OrderStatusCanceled, RefundID: &refundID}
+	mockOrderRepo.On("GetOrderByID", mock.Anything, "o1", false).Return(order, nil)
+
+	refund, err := uc.RefundOrder(context.Background(), "o1", "u1", "")
+
+	assert.Nil(t, refund)
+	assert.ErrorIs(t, err, usecase.ErrAlreadyRefunded)
+	mockRefundRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+// -------------------------------------
+// Tests de ListOrdersByShippingAddress
+// -------------------------------------
+
+// TestListOrdersByShippingAddress_OwnedWithOrders verifica que se devuelven los
+// pedidos de una dirección del usuario.
+func TestListOrdersByShippingAddress_OwnedWithOrders(t *testing.T) {
+	mockOrderRepo := new(MockOrderRepository)
+	mockAddressRepo := new(MockAddressRepository)
+	uc := usecase.NewOrderUseCase(nil, mockOrderRepo, nil, nil, nil, nil, mockAddressRepo, nil)
+
+	req := &paging.Pagination{Page: 1, Size: 10}
+	orders := []*orderEntity.Order{{ID: "o1"}, {ID: "o2"}}
+	pagination := paging.NewPagination(1, 10, 2)
+	mockAddressRepo.On("GetAddressByID", mock.Anything, "a1").Return(&addressEntity.Address{ID: "a1", UserID: "u1"}, nil)
+	mockOrderRepo.On("GetOrdersByShippingAddress", mock.Anything, "a1", req).Return(orders, pagination, nil)
+
+	result, page, err := uc.ListOrdersByShippingAddress(context.Background(), "a1", "u1", req)
+
+	assert.NoError(t, err)
+	assert.Equal(t, orders, result)
+	assert.Equal(t, int64(2), page.TotalCount)
+	mockOrderRepo.AssertExpectations(t)
+}
+
+// TestListOrdersByShippingAddress_OwnedNoOrders verifica que una dirección sin
+// pedidos devuelve una lista vacía sin error.
+func TestListOrdersByShippingAddress_OwnedNoOrders(t *testing.T) {
+	mockOrderRepo := new(MockOrderRepository)
+	mockAddressRepo := new(MockAddressRepository)
+	uc := usecase.NewOrderUseCase(nil, mockOrderRepo, nil, nil, nil, nil, mockAddressRepo, nil)
+
+	mockAddressRepo.On("GetAddressByID", mock.Anything, "a1").Return(&addressEntity.Address{ID: "a1", UserID: "u1"}, nil)
+	mockOrderRepo.On("GetOrdersByShippingAddress", mock.Anything, "a1", (*paging.Pagination)(nil)).
+		Return([]*orderEntity.Order{}, paging.NewPagination(1, 0, 0), nil)
+
+	result, page, err := uc.ListOrdersByShippingAddress(context.Background(), "a1", "u1", nil)
+
+	assert.NoError(t, err)
+	assert.Empty(t, result)
+	assert.Equal(t, int64(0), page.TotalCount)
+}
+
+// TestListOrdersByShippingAddress_NotOwned verifica que la dirección de otro
+// usuario devuelve ErrAddressNotOwned.
+func TestListOrdersByShippingAddress_NotOwned(t *testing.T) {
+	mockOrderRepo := new(MockOrderRepository)
+	mockAddressRepo := new(MockAddressRepository)
+	uc := usecase.NewOrderUseCase(nil, mockOrderRepo, nil, nil, nil, nil, mockAddressRepo, nil)
+
+	mockAddressRepo.On("GetAddressByID", mock.Anything, "a1").Return(&addressEntity.Address{ID: "a1", UserID: "u2"}, nil)
+
+	result, page, err := uc.ListOrdersByShippingAddress(context.Background(), "a1", "u1", nil)
+
+	assert.Nil(t, result)
+	assert.Nil(t, page)
+	assert.ErrorIs(t, err, usecase.ErrAddressNotOwned)
+	mockOrderRepo.AssertNotCalled(t, "GetOrdersByShippingAddress", mock.Anything, mock.Anything, mock.Anything)
+}
+
+// -------------------------------------
+// Tests de WithMiddleware
+// -------------------------------------
+
+// TestWithMiddleware_ChainOrder verifica que los middlewares se ejecutan en
+// orden (el primero es el más externo) alrededor de la llamada real.
+func TestWithMiddleware_ChainOrder(t *testing.T) {
+	mockOrderRepo := new(MockOrderRepository)
+	mockOrderRepo.On("GetOrderByID", mock.Anything, "o1", true).Return(&orderEntity.Order{ID: "o1"}, nil)
+
+	var calls []string
+	trace := func(name string) usecase.MiddlewareFunc {
+		return func(ctx context.Context, method string, next func() error) error {
+			calls = append(calls, name+":before:"+method)
+			err := next()
+			calls = append(calls, name+":after:"+method)
+			return err
+		}
+	}
+
+	uc := usecase.WithMiddleware(
+		usecase.NewOrderUseCase(nil, mockOrderRepo, nil, nil, nil, nil, nil, nil),
+		trace("outer"),
+		trace("inner"),
+	)
+
+	order, err := uc.GetOrderByID(context.Background(), "o1")
+
+	assert.NoError(t, err)
+	assert.Equal(t, "o1", order.ID)
+	assert.Equal(t, []string{
+		"outer:before:GetOrderByID",
+		"inner:before:GetOrderByID",
+		"inner:after:GetOrderByID",
+		"outer:after:GetOrderByID",
+	}, calls)
+	mockOrderRepo.AssertExpectations(t)
+}
+
+// TestWithMiddleware_ShortCircuit verifica que un middleware puede cortar la
+// llamada sin invocar next.
+func TestWithMiddleware_ShortCircuit(t *testing.T) {
+	mockOrderRepo := new(MockOrderRepository)
+	errBlocked := errors.New("blocked")
+
+	innerCalled := false
+	uc := usecase.WithMiddleware(
+		usecase.NewOrderUseCase(nil, mockOrderRepo, nil, nil, nil, nil, nil, nil),
+		func(ctx context.Context, method string, next func() error) error {
+			return errBlocked
+		},
+		func(ctx context.Context, method string, next func() error) error {
+			innerCalled = true
+			return next()
+		},
+	)
+
+	order, err := uc.GetOrderByID(context.Background(), "o1")
+
+	assert.Nil(t, order)
+	assert.ErrorIs(t, err, errBlocked)
+	assert.False(t, innerCalled)
+	mockOrderRepo.AssertNotCalled(t, "GetOrderByID", mock.Anything, mock.Anything, mock.Anything)
+}
+
+// TestWithMiddleware_Builtins verifica que LoggingMiddleware y
+// MetricsMiddleware dejan pasar el resultado y el error de la llamada.
+func TestWithMiddleware_Builtins(t *testing.T) {
+	mockOrderRepo := new(MockOrderRepository)
+	mockOrderRepo.On("GetOrderByID", mock.Anything, "missing", true).Return((*orderEntity.Order)(nil), errors.New("not found"))
+
+	uc := usecase.WithMiddleware(
+		usecase.NewOrderUseCase(nil, mockOrderRepo, nil, nil, nil, nil, nil, nil),
+		usecase.LoggingMiddleware,
+		usecase.MetricsMiddleware(prometheus.NewRegistry()),
+	)
+
+	order, err := uc.GetOrderByID(context.Background(), "missing")
+
+	assert.Nil(t, order)
+	assert.EqualError(t, err, "not found")
+}
+
+// -------------------------------------
+// Tests de AttachReceiptEmail
+// -------------------------------------
+
+// TestAttachReceiptEmail_Valid verifica que un email válido se guarda en el pedido.
+func TestAttachReceiptEmail_Valid(t *testing.T) {
+	mockOrderRepo := new(MockOrderRepository)
+	uc := usecase.NewOrderUseCase(validation.New(), mockOrderRepo, new(mocks.ProductRepository), nil, nil, nil, nil, nil)
+
+	order := &orderEntity.Order{ID: "o1", UserID: "u1", Status: utils.OrderStatusNew}
+	mockOrderRepo.On("GetOrderByID", mock.Anything, "o1", false).Return(order, nil)
+	mockOrderRepo.On("UpdateOrder", mock.Anything, order).Return(nil)
+
+	err := uc.AttachReceiptEmail(context.Background(), "o1", "u1", utils.RoleCustomer, "gift@example.com")
+
+	assert.NoError(t, err)
+	if assert.NotNil(t, order.ReceiptEmail) {
+		assert.Equal(t, "gift@example.com", *order.ReceiptEmail)
+	}
+	mockOrderRepo.AssertExpectations(t)
+}
+
+// TestAttachReceiptEmail_InvalidFormat verifica que un email mal formado devuelve
+// ErrInvalidEmail sin consultar el repositorio.
+func TestAttachReceiptEmail_InvalidFormat(t *testing.T) {
+	mockOrderRepo := new(MockOrderRepository)
+	uc := usecase.NewOrderUseCase(validation.New(), mockOrderRepo, new(mocks.ProductRepository), nil, nil, nil, nil, nil)
+
+	err := uc.AttachReceiptEmail(context.Background(), "o1", "u1", utils.RoleCustomer, "not-an-email")
+
+	assert.ErrorIs(t, err, usecase.ErrInvalidEmail)
+	mockOrderRepo.AssertNotCalled(t, "GetOrderByID", mock.Anything, mock.Anything, mock.Anything)
+}
+
+// TestAttachReceiptEmail_DoneOrder verifica que no se puede adjuntar un email a
+// un pedido ya finalizado.
+func TestAttachReceiptEmail_DoneOrder(t *testing.T) {
+	mockOrderRepo := new(MockOrderRepository)
+	uc := usecase.NewOrderUseCase(validation.New(), mockOrderRepo, new(mocks.ProductRepository), nil, nil, nil, nil, nil)
+
+	mockOrderRepo.On("GetOrderByID", mock.Anything, "o1", false).
+		Return(&orderEntity.Order{ID: "o1", UserID: "u1", Status: utils.OrderStatusDone}, nil)
+
+	err := uc.AttachReceiptEmail(context.Background(), "o1", "u1", utils.RoleCustomer, "gift@example.com")
+
+	assert.ErrorIs(t, err, usecase.ErrInvalidOrderStatus)
+	mockOrderRepo.AssertNotCalled(t, "UpdateOrder", mock.Anything, mock.Anything)
+}
+
+// TestAttachReceiptEmail_NotOwner verifica que otro cliente no puede cambiar el
+// email del pedido, pero un administrador sí.
+func TestAttachReceiptEmail_NotOwner(t *testing.T) {
+	mockOrderRepo := new(MockOrderRepository)
+	uc := usecase.NewOrderUseCase(validation.New(), mockOrderRepo, new(mocks.ProductRepository), nil, nil, nil, nil, nil)
+
+	order := &orderEntity.Order{ID: "o1", UserID: "u1", Status: utils.OrderStatusNew}
+	mockOrderRepo.On("GetOrderByID", mock.Anything, "o1", false).Return(order, nil)
+
+	err := uc.AttachReceiptEmail(context.Background(), "o1", "u2", utils.RoleCustomer, "gift@example.com")
+
+	assert.ErrorIs(t, err, usecase.ErrPermissionDenied)
+	assert.Nil(t, order.ReceiptEmail)
+	mockOrderRepo.AssertNotCalled(t, "UpdateOrder", mock.Anything, mock.Anything)
+
+	mockOrderRepo.On("UpdateOrder", mock.Anything, order).Return(nil)
+
+	err = uc.AttachReceiptEmail(context.Background(), "o1", "admin1", utils.RoleAdmin, "gift@example.com")
+
+	assert.NoError(t, err)
+}
+
+// -------------------------------------
+// Tests de RecalculateOrderTotal
+// -------------------------------------
+
+// TestRecalculateOrderTotal_Success verifica que cada línea se recalcula con el
+// precio actual del producto y que el total se guarda junto con las líneas.
+func TestRecalculateOrderTotal_Success(t *testing.T) {
+	mockOrderRepo := new(MockOrderRepository)
+	mockProductRepo := new(mocks.ProductRepository)
+	uc := usecase.NewOrderUseCase(nil, mockOrderRepo, mockProductRepo, nil, nil, nil, nil, nil)
+
+	order := &orderEntity.Order{
+		ID:         "o1",
+		Status:     utils.OrderStatusInProgress,
+		TotalPrice: 50,
+		Lines: []*orderEntity.OrderLine{
+			{ID: "l1", ProductID: "p1", Quantity: 2, Price: 20},
+			{ID: "l2", ProductID: "p2", Quantity: 1, Price: 30},
+		},
+	}
+	mockOrderRepo.On("GetOrderByID", mock.Anything, "o1", true).Return(order, nil)
+	mockProductRepo.On("GetProductById", mock.Anything, "p1").Return(&productEntity.Product{ID: "p1", Price: 15}, nil)
+	mockProductRepo.On("GetProductById", mock.Anything, "p2").Return(&productEntity.Product{ID: "p2", Price: 25}, nil)
+	mockOrderRepo.On("UpdateOrderWithLines", mock.Anything, order).Return(nil)
+
+	err := uc.RecalculateOrderTotal(context.Background(), "o1", utils.RoleAdmin)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 30.0, order.Lines[0].Price)
+	assert.Equal(t, 25.0, order.Lines[1].Price)
+	assert.Equal(t, 55.0, order.TotalPrice)
+	mockOrderRepo.AssertExpectations(t)
+}
+
+// TestRecalculateOrderTotal_Finalized verifica que un pedido terminado o
+// cancelado devuelve ErrOrderFinalized sin tocar los precios.
+func TestRecalculateOrderTotal_Finalized(t *testing.T) {
+	for _, status := range []utils.OrderStatus{utils.OrderStatusDone, utils.OrderStatusCanceled} {
+		mockOrderRepo := new(MockOrderRepository)
+		mockProductRepo := new(mocks.ProductRepository)
+		uc := usecase.NewOrderUseCase(nil, mockOrderRepo, mockProductRepo, nil, nil, nil, nil, nil)
+
+		mockOrderRepo.On("GetOrderByID", mock.Anything, "o1", true).Return(&orderEntity.Order{
+			ID:     "o1",
+			Status: status,
+			Lines:  []*orderEntity.OrderLine{{ProductID: "p1", Quantity: 1, Price: 10}},
+		}, nil)
+
+		err := uc.RecalculateOrderTotal(context.Background(), "o1", utils.RoleAdmin)
+
+		assert.ErrorIs(t, err, usecase.ErrOrderFinalized, status)
+		mockProductRepo.AssertNotCalled(t, "GetProductById", mock.Anything, mock.Anything)
+		mockOrderRepo.AssertNotCalled(t, "UpdateOrderWithLines", mock.Anything, mock.Anything)
+	}
+}
+
+// TestRecalculateOrderTotal_ProductNotFound verifica que si un producto ya no
+// existe a mitad del cálculo se devuelve el error y no se persiste nada.
+func TestRecalculateOrderTotal_ProductNotFound(t *testing.T) {
+	mockOrderRepo := new(MockOrderRepository)
+	mockProductRepo := new(mocks.ProductRepository)
+	uc := usecase.NewOrderUseCase(nil, mockOrderRepo, mockProductRepo, nil, nil, nil, nil, nil)
+
+	mockOrderRepo.On("GetOrderByID", mock.Anything, "o1", true).Return(&orderEntity.Order{
+		ID:     "o1",
+		Status: utils.OrderStatusNew,
+		Lines: []*orderEntity.OrderLine{
+			{ProductID: "p1", Quantity: 1, Price: 10},
+			{ProductID: "gone", Quantity: 1, Price: 10},
+		},
+	}, nil)
+	mockProductRepo.On("GetProductById", mock.Anything, "p1").Return(&productEntity.Product{ID: "p1", Price: 12}, nil)
+	mockProductRepo.On("GetProductById", mock.Anything, "gone").Return(nil, gorm.ErrRecordNotFound)
+
+	err := uc.RecalculateOrderTotal(context.Background(), "o1", utils.RoleAdmin)
+
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+	mockOrderRepo.AssertNotCalled(t, "UpdateOrderWithLines", mock.Anything, mock.Anything)
+}
+
+// -------------------------------------
+// Tests de GetOrdersByPaymentStatus
+// -------------------------------------
+
+// TestGetOrdersByPaymentStatus_Paid verifica que se devuelven los pedidos
+// pagados con la paginación y el importe total del filtro.
+func TestGetOrdersByPaymentStatus_Paid(t *testing.T) {
+	mockOrderRepo := new(MockOrderRepository)
+	uc := usecase.NewOrderUseCase(nil, mockOrderRepo, new(mocks.ProductRepository), nil, nil, nil, nil, nil)
+
+	req := &paging.Pagination{Page: 1, Size: 10}
+	paymentID := "pay-1"
+	expected := []*orderEntity.Order{{ID: "o1", PaymentID: &paymentID, IsPaid: true}}
+	mockOrderRepo.On("GetOrdersByPaymentStatus", mock.Anything, true, req).Return(expected, &paging.Pagination{TotalCount: 1}, nil)
+	mockOrderRepo.On("SumOrdersByPaymentStatus", mock.Anything, true).Return(120.5, nil)
+
+	orders, meta, err := uc.GetOrdersByPaymentStatus(context.Background(), true, utils.RoleAdmin, req)
+
+	assert.NoError(t, err)
+	assert.Equal(t, expected, orders)
+	assert.Equal(t, int64(1), meta.TotalCount)
+	assert.Equal(t, 120.5, meta.TotalAmount)
+}
+
+// TestGetOrdersByPaymentStatus_Unpaid verifica que el filtro de no pagados se
+// pasa al repositorio y que un fallo en la suma hace fallar la consulta.
+func TestGetOrdersByPaymentStatus_Unpaid(t *testing.T) {
+	mockOrderRepo := new(MockOrderRepository)
+	uc := usecase.NewOrderUseCase(nil, mockOrderRepo, new(mocks.ProductRepository), nil, nil, nil, nil, nil)
+
+	expected := []*orderEntity.Order{{ID: "o2"}, {ID: "o3"}}
+	mockOrderRepo.On("GetOrdersByPaymentStatus", mock.Anything, false, (*paging.Pagination)(nil)).Return(expected, &paging.Pagination{TotalCount: 2}, nil)
+	mockOrderRepo.On("SumOrdersByPaymentStatus", mock.Anything, false).Return(45.0, nil)
+
+	orders, meta, err := uc.GetOrdersByPaymentStatus(context.Background(), false, utils.RoleAdmin, nil)
+
+	assert.NoError(t, err)
+	assert.Equal(t, expected, orders)
+	assert.Equal(t, 45.0, meta.TotalAmount)
+
+	failingRepo := new(MockOrderRepository)
+	uc = usecase.NewOrderUseCase(nil, failingRepo, new(mocks.ProductRepository), nil, nil, nil, nil, nil)
+	failingRepo.On("GetOrdersByPaymentStatus", mock.Anything, false, (*paging.Pagination)(nil)).Return(expected, &paging.Pagination{TotalCount: 2}, nil)
+	failingRepo.On("SumOrdersByPaymentStatus", mock.Anything, false).Return(0.0, errors.New("db error"))
+
+	orders, meta, err = uc.GetOrdersByPaymentStatus(context.Background(), false, utils.RoleAdmin, nil)
+
+	assert.Nil(t, orders)
+	assert.Nil(t, meta)
+	assert.EqualError(t, err, "db error")
+}
+
+// TestGetOrdersByPaymentStatus_Forbidden verifica que solo un admin puede
+// consultar la conciliación.
+func TestGetOrdersByPaymentStatus_Forbidden(t *testing.T) {
+	mockOrderRepo := new(MockOrderRepository)
+	uc := usecase.NewOrderUseCase(nil, mockOrderRepo, new(mocks.ProductRepository), nil, nil, nil, nil, nil)
+
+	for _, role := range []string{utils.RoleCustomer, utils.RoleSupport} {
+		orders, meta, err := uc.GetOrdersByPaymentStatus(context.Background(), true, role, nil)
+
+		assert.Nil(t, orders)
+		assert.Nil(t, meta)
+		assert.ErrorIs(t, err, usecase.ErrForbidden)
+	}
+	mockOrderRepo.AssertNotCalled(t, "GetOrdersByPaymentStatus", mock.Anything, mock.Anything, mock.Anything)
+}
+
+// -------------------------------------
+// Tests de etiquetas de pedidos
+// -------------------------------------
+
+// TestAddTagToOrder_Success verifica que la etiqueta se normaliza y se añade una
+// sola vez.
+func TestAddTagToOrder_Success(t *testing.T) {
+	mockOrderRepo := new(MockOrderRepository)
+	uc := usecase.NewOrderUseCase(nil, mockOrderRepo, new(mocks.ProductRepository), nil, nil, nil, nil, nil)
+
+	order := &orderEntity.Order{ID: "o1", Tags: []string{"vip"}}
+	mockOrderRepo.On("GetOrderByID", mock.Anything, "o1", false).Return(order, nil)
+	mockOrderRepo.On("UpdateOrder", mock.Anything, order).Return(nil).Once()
+
+	err := uc.AddTagToOrder(context.Background(), "o1", " Black-Friday ", utils.RoleAdmin)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"vip", "black-friday"}, order.Tags)
+
+	err = uc.AddTagToOrder(context.Background(), "o1", "black-friday", utils.RoleAdmin)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"vip", "black-friday"}, order.Tags)
+	mockOrderRepo.AssertNumberOfCalls(t, "UpdateOrder", 1)
+
+	err = uc.AddTagToOrder(context.Background(), "o1", "no spaces", utils.RoleAdmin)
+	assert.ErrorIs(t, err, usecase.ErrInvalidTag)
+}
+
+// TestRemoveTagFromOrder_Success verifica que se quita la etiqueta y que quitar
+// una que no existe no escribe nada.
+func TestRemoveTagFromOrder_Success(t *testing.T) {
+	mockOrderRepo := new(MockOrderRepository)
+	uc := usecase.NewOrderUseCase(nil, mockOrderRepo, new(mocks.ProductRepository), nil, nil, nil, nil, nil)
+
+	order := &orderEntity.Order{ID: "o1", Tags: []string{"vip", "black-friday"}}
+	mockOrderRepo.On("GetOrderByID", mock.Anything, "o1", false).Return(order, nil)
+	mockOrderRepo.On("UpdateOrder", mock.Anything, order).Return(nil).Once()
+
+	err := uc.RemoveTagFromOrder(context.Background(), "o1", "vip", utils.RoleAdmin)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"black-friday"}, order.Tags)
+
+	err = uc.RemoveTagFromOrder(context.Background(), "o1", "vip", utils.RoleAdmin)
+	assert.NoError(t, err)
+	mockOrderRepo.AssertNumberOfCalls(t, "UpdateOrder", 1)
+}
+
+// TestListOrdersByTag_Success verifica que se devuelven los pedidos con la
+// etiqueta y su paginación.
+func TestListOrdersByTag_Success(t *testing.T) {
+	mockOrderRepo := new(MockOrderRepository)
+	uc := usecase.NewOrderUseCase(nil, mockOrderRepo, new(mocks.ProductRepository), nil, nil, nil, nil, nil)
+
+	req := &paging.Pagination{Page: 1, Size: 10}
+	expected := []*orderEntity.Order{{ID: "o1", Tags: []string{"vip"}}}
+	mockOrderRepo.On("GetOrdersByTag", mock.Anything, "vip", req).Return(expected, &paging.Pagination{TotalCount: 1}, nil)
+
+	orders, pagination, err := uc.ListOrdersByTag(context.Background(), "VIP", utils.RoleAdmin, req)
+
+	assert.NoError(t, err)
+	assert.Equal(t, expected, orders)
+	assert.Equal(t, int64(1), pagination.TotalCount)
+}
+
+// TestListOrdersByTag_NoMatches verifica que sin pedidos etiquetados se
+// devuelve una lista vacía sin error.
+func TestListOrdersByTag_NoMatches(t *testing.T) {
+	mockOrderRepo := new(MockOrderRepository)
+	uc := usecase.NewOrderUseCase(nil, mockOrderRepo, new(mocks.ProductRepository), nil, nil, nil, nil, nil)
+
+	mockOrderRepo.On("GetOrdersByTag", mock.Anything, "unused", (*paging.Pagination)(nil)).Return([]*orderEntity.Order{}, &paging.Pagination{}, nil)
+
+	orders, _, err := uc.ListOrdersByTag(context.Background(), "unused", utils.RoleAdmin, nil)
+
+	assert.NoError(t, err)
+	assert.Empty(t, orders)
+}
+
+// TestOrderTags_NonAdmin verifica que ninguna operación de etiquetas está
+// permitida a quien no es admin.
+func TestOrderTags_NonAdmin(t *testing.T) {
+	mockOrderRepo := new(MockOrderRepository)
+	uc := usecase.NewOrderUseCase(nil, mockOrderRepo, new(mocks.ProductRepository), nil, nil, nil, nil, nil)
+
+	assert.ErrorIs(t, uc.AddTagToOrder(context.Background(), "o1", "vip", utils.RoleSupport), usecase.ErrForbidden)
+	assert.ErrorIs(t, uc.RemoveTagFromOrder(context.Background(), "o1", "vip", utils.RoleCustomer), usecase.ErrForbidden)
+	_, _, err := uc.ListOrdersByTag(context.Background(), "vip", utils.RoleCustomer, nil)
+	assert.ErrorIs(t, err, usecase.ErrForbidden)
+	mockOrderRepo.AssertNotCalled(t, "GetOrderByID", mock.Anything, mock.Anything, mock.Anything)
+	mockOrderRepo.AssertNotCalled(t, "GetOrdersByTag", mock.Anything, mock.Anything, mock.Anything)
+}
+
+// -------------------------------------
+// Tests de MergeOrders
+// -------------------------------------
+
+func newMergeSource(id, userID string, status utils.OrderStatus, lines ...*orderEntity.OrderLine) *orderEntity.Order {
+	order := &orderEntity.Order{ID: id, UserID: userID, Status: status, Lines: lines}
+	for _, line := range lines {
+		order.TotalPrice += line.Price
+	}
+	return order
+}
+
+func linesByProduct(order *orderEntity.Order) map[string]*orderEntity.OrderLine {
+	lines := make(map[string]*orderEntity.OrderLine, len(order.Lines))
+	for _, line := range order.Lines {
+		lines[line.ProductID] = line
+	}
+	return lines
+}
+
+// TestMergeOrders_TwoOrders verifica que dos pedidos se combinan en uno nuevo y
+// que los originales quedan cancelados.
+func TestMergeOrders_TwoOrders(t *testing.T) {
+	mockOrderRepo := new(MockOrderRepository)
+	uc := usecase.NewOrderUseCase(nil, mockOrderRepo, new(mocks.ProductRepository), nil, nil, nil, nil, nil)
+
+	o1 := newMergeSource("o1", "u1", utils.OrderStatusNew, &orderEntity.OrderLine{ProductID: "p1", Quantity: 1, Price: 10})
+	o2 := newMergeSource("o2", "u1", utils.OrderStatusNew, &orderEntity.OrderLine{ProductID: "p2", Quantity: 2, Price: 8})
+	mockOrderRepo.On("GetOrderByID", mock.Anything, "o1", true).Return(o1, nil)
+	mockOrderRepo.On("GetOrderByID", mock.Anything, "o2", true).Return(o2, nil)
+	mockOrderRepo.On("CreateOrder", mock.Anything, mock.Anything, mock.Anything).Return(nil, nil)
+	mockOrderRepo.On("UpdateOrder", mock.Anything, mock.Anything).Return(nil)
+	mockOrderRepo.On("CreateAuditLog", mock.Anything, mock.Anything).Return(nil)
+
+	merged, err := uc.MergeOrders(context.Background(), []string{"o1", "o2"}, "u1")
+
+	assert.NoError(t, err)
+	assert.Equal(t, "u1", merged.UserID)
+	assert.Len(t, merged.Lines, 2)
+	assert.Equal(t, 18.0, merged.TotalPrice)
+	assert.Equal(t, utils.OrderStatusCanceled, o1.Status)
+	assert.Equal(t, utils.OrderStatusCanceled, o2.Status)
+	for _, id := range []string{"o1", "o2"} {
+		mockOrderRepo.AssertCalled(t, "CreateAuditLog", mock.Anything, mock.MatchedBy(func(log *orderEntity.OrderAuditLog) bool {
+			return log.OrderID == id && log.FromStatus == utils.OrderStatusNew && log.ToStatus == utils.OrderStatusCanceled && log.ChangedBy == "u1"
+		}))
+	}
+}
+
+// TestMergeOrders_AuditLogFails verifica que si no se puede registrar la
+// cancelación de un pedido origen la fusión devuelve el error.
+func TestMergeOrders_AuditLogFails(t *testing.T) {
+	mockOrderRepo := new(MockOrderRepository)
+	uc := usecase.NewOrderUseCase(nil, mockOrderRepo, new(mocks.ProductRepository), nil, nil, nil, nil, nil)
+
+	auditErr := errors.New("audit failed")
+	o1 := newMergeSource("o1", "u1", utils.OrderStatusNew, &orderEntity.OrderLine{ProductID: "p1", Quantity: 1, Price: 10})
+	o2 := newMergeSource("o2", "u1", utils.OrderStatusNew, &orderEntity.OrderLine{ProductID: "p2", Quantity: 2, Price: 8})
+	mockOrderRepo.On("GetOrderByID", mock.Anything, "o1", true).Return(o1, nil)
+	mockOrderRepo.On("GetOrderByID", mock.Anything, "o2", true).Return(o2, nil)
+	mockOrderRepo.On("CreateOrder", mock.Anything, mock.Anything, mock.Anything).Return(nil, nil)
+	mockOrderRepo.On("UpdateOrder", mock.Anything, o1).Return(nil)
+	mockOrderRepo.On("CreateAuditLog", mock.Anything, mock.Anything).Return(auditErr)
+
+	merged, err := uc.MergeOrders(context.Background(), []string{"o1", "o2"}, "u1")
+
+	assert.Nil(t, merged)
+	assert.Equal(t, auditErr, err)
+	mockOrderRepo.AssertNotCalled(t, "UpdateOrder", mock.Anything, o2)
+}
+
+// TestMergeOrders_NotOwner verifica que si cualquiera de los pedidos es de otro
+// usuario no se fusiona nada.
+func TestMergeOrders_NotOwner(t *testing.T) {
+	mockOrderRepo := new(MockOrderRepository)
+	uc := usecase.NewOrderUseCase(nil, mockOrderRepo, new(mocks.ProductRepository), nil, nil, nil, nil, nil)
+
+	o1 := newMergeSource("o1", "u1", utils.OrderStatusNew, &orderEntity.OrderLine{ProductID: "p1", Quantity: 1, Price: 10})
+	o2 := newMergeSource("o2", "u2", utils.OrderStatusNew, &orderEntity.OrderLine{ProductID: "p2", Quantity: 1, Price: 5})
+	mockOrderRepo.On("GetOrderByID", mock.Anything, "o1", true).Return(o1, nil)
+	mockOrderRepo.On("GetOrderByID", mock.Anything, "o2", true).Return(o2, nil)
+
+	merged, err := uc.MergeOrders(context.Background(), []string{"o1", "o2"}, "u1")
+
+	assert.Nil(t, merged)
+	assert.ErrorIs(t, err, usecase.ErrPermissionDenied)
+	assert.Equal(t, utils.OrderStatusNew, o1.Status)
+	mockOrderRepo.AssertNotCalled(t, "CreateOrder", mock.Anything, mock.Anything, mock.Anything)
+}
+
+// TestMergeOrders_NotNew verifica que solo se pueden fusionar pedidos en
+// estado new.
+func TestMergeOrders_NotNew(t *testing.T) {
+	mockOrderRepo := new(MockOrderRepository)
+	uc := usecase.NewOrderUseCase(nil, mockOrderRepo, new(mocks.ProductRepository), nil, nil, nil, nil, nil)
+
+	o1 := newMergeSource("o1", "u1", utils.OrderStatusNew, &orderEntity.OrderLine{ProductID: "p1", Quantity: 1, Price: 10})
+	o2 := newMergeSource("o2", "u1", utils.OrderStatusInProgress, &orderEntity.OrderLine{ProductID: "p2", Quantity: 1, Price: 5})
+	mockOrderRepo.On("GetOrderByID", mock.Anything, "o1", true).Return(o1, nil)
+	mockOrderRepo.On("GetOrderByID", mock.Anything, "o2", true).Return(o2, nil)
+
+	merged, err := uc.MergeOrders(context.Background(), []string{"o1", "o2"}, "u1")
+
+	assert.Nil(t, merged)
+	assert.ErrorIs(t, err, usecase.ErrInvalidOrderStatus)
+	mockOrderRepo.AssertNotCalled(t, "CreateOrder", mock.Anything, mock.Anything, mock.Anything)
+}
+
+// TestMergeOrders_PaidSource verifica que un pedido ya pagado no se puede
+// fusionar.
+func TestMergeOrders_PaidSource(t *testing.T) {
+	mockOrderRepo := new(MockOrderRepository)
+	uc := usecase.NewOrderUseCase(nil, mockOrderRepo, new(mocks.ProductRepository), nil, nil, nil, nil, nil)
+
+	paymentID := "pay1"
+	o1 := newMergeSource("o1", "u1", utils.OrderStatusNew, &orderEntity.OrderLine{ProductID: "p1", Quantity: 1, Price: 10})
+	o2 := newMergeSource("o2", "u1", utils.OrderStatusNew, &orderEntity.OrderLine{ProductID: "p2", Quantity: 1, Price: 5})
+	o2.PaymentID = &paymentID
+	mockOrderRepo.On("GetOrderByID", mock.Anything, "o1", true).Return(o1, nil)
+	mockOrderRepo.On("GetOrderByID", mock.Anything, "o2", true).Return(o2, nil)
+
+	merged, err := uc.MergeOrders(context.Background(), []string{"o1", "o2"}, "u1")
+
+	assert.Nil(t, merged)
+	assert.ErrorIs(t, err, usecase.ErrAlreadyPaid)
+	assert.Equal(t, utils.OrderStatusNew, o1.Status)
+	mockOrderRepo.AssertNotCalled(t, "CreateOrder", mock.Anything, mock.Anything, mock.Anything)
+}
+
+// TestMergeOrders_DuplicateProducts verifica que las líneas del mismo producto
+// se suman en una sola línea.
+func TestMergeOrders_DuplicateProducts(t *testing.T) {
+	mockOrderRepo := new(MockOrderRepository)
+	uc := usecase.NewOrderUseCase(nil, mockOrderRepo, new(mocks.ProductRepository), nil, nil, nil, nil, nil)
+
+	o1 := newMergeSource("o1", "u1", utils.OrderStatusNew,
+		&orderEntity.OrderLine{ProductID: "p1", Quantity: 1, Price: 10},
+		&orderEntity.OrderLine{ProductID: "p2", Quantity: 1, Price: 4},
+	)
+	o2 := newMergeSource("o2", "u1", utils.OrderStatusNew, &orderEntity.OrderLine{ProductID: "p1", Quantity: 3, Price: 30})
+	mockOrderRepo.On("GetOrderByID", mock.Anything, "o1", true).Return(o1, nil)
+	mockOrderRepo.On("GetOrderByID", mock.Anything, "o2", true).Return(o2, nil)
+	mockOrderRepo.On("CreateOrder", mock.Anything, mock.Anything, mock.Anything).Return(nil, nil)
+	mockOrderRepo.On("UpdateOrder", mock.Anything, mock.Anything).Return(nil)
+	mockOrderRepo.On("CreateAuditLog", mock.Anything, mock.Anything).Return(nil)
+
+	merged, err := uc.MergeOrders(context.Background(), []string{"o1", "o2", "o1"}, "u1")
+
+	assert.NoError(t, err)
+	lines := linesByProduct(merged)
+	assert.Len(t, lines, 2)
+	assert.Equal(t, uint(4), lines["p1"].Quantity)
+	assert.Equal(t, 40.0, lines["p1"].Price)
+	assert.Equal(t, uint(1), lines["p2"].Quantity)
+	assert.Equal(t, 44.0, merged.TotalPrice)
+
+	_, err = uc.MergeOrders(context.Background(), []string{"o1", "o1"}, "u1")
+	assert.ErrorIs(t, err, usecase.ErrInvalidMerge)
+}
+
+// TestMergeOrders_ThreeOrders verifica la fusión de tres pedidos.
+func TestMergeOrders_ThreeOrders(t *testing.T) {
+	mockOrderRepo := new(MockOrderRepository)
+	uc := usecase.NewOrderUseCase(nil, mockOrderRepo, new(mocks.ProductRepository), nil, nil, nil, nil, nil)
+
+	o1 := newMergeSource("o1", "u1", utils.OrderStatusNew, &orderEntity.OrderLine{ProductID: "p1", Quantity: 1, Price: 10})
+	o2 := newMergeSource("o2", "u1", utils.OrderStatusNew, &orderEntity.OrderLine{ProductID: "p2", Quantity: 1, Price: 5})
+	o3 := newMergeSource("o3", "u1", utils.OrderStatusNew,
+		&orderEntity.OrderLine{ProductID: "p3", Quantity: 2, Price: 6},
+		&orderEntity.OrderLine{ProductID: "p2", Quantity: 1, Price: 5},
+	)
+	for _, o := range []*orderEntity.Order{o1, o2, o3} {
+		mockOrderRepo.On("GetOrderByID", mock.Anything, o.ID, true).Return(o, nil)
+	}
+	mockOrderRepo.On("CreateOrder", mock.Anything, mock.Anything, mock.Anything).Return(nil, nil)
+	mockOrderRepo.On("UpdateOrder", mock.Anything, mock.Anything).Return(nil)
+	mockOrderRepo.On("CreateAuditLog", mock.Anything, mock.Anything).Return(nil)
+
+	merged, err := uc.MergeOrders(context.Background(), []string{"o1", "o2", "o3"}, "u1")
+
+	assert.NoError(t, err)
+	lines := linesByProduct(merged)
+	assert.Len(t, lines, 3)
+	assert.Equal(t, uint(2), lines["p2"].Quantity)
+	assert.Equal(t, 26.0, merged.TotalPrice)
+	for _, o := range []*orderEntity.Order{o1, o2, o3} {
+		assert.Equal(t, utils.OrderStatusCanceled, o.Status)
+	}
+}
+
+// -------------------------------------
+// Tests de GetOrderTimeline
+// -------------------------------------
+
+// TestGetOrderTimeline_SortedMixedEvents verifica que los eventos de historial,
+// notas y reembolso se mezclan ordenados por fecha ascendente.
+func TestGetOrderTimeline_SortedMixedEvents(t *testing.T) {
+	mockOrderRepo := new(MockOrderRepository)
+	noteRepo := new(MockOrderNoteRepository)
+	refundRepo := new(MockRefundRepository)
+	uc := usecase.NewOrderUseCase(nil, mockOrderRepo, nil, nil, noteRepo, refundRepo, nil, nil)
+
+	base := time.Date(2024, time.March, 1, 10, 0, 0, 0, time.UTC)
+	refundID := "r1"
+	mockOrderRepo.On("GetOrderByID", mock.Anything, "o1", false).
+		Return(&orderEntity.Order{ID: "o1", UserID: "u1", RefundID: &refundID}, nil)
+	mockOrderRepo.On("GetStatusHistory", mock.Anything, "o1").Return([]orderEntity.OrderStatusHistory{
+		{FromStatus: utils.OrderStatusNew, ToStatus: utils.OrderStatusInProgress, ChangedBy: "admin1", ChangedAt: base.Add(time.Hour)},
+		{FromStatus: utils.OrderStatusInProgress, ToStatus: utils.OrderStatusCanceled, ChangedBy: "u1", ChangedAt: base.Add(4 * time.Hour)},
+	}, nil)
+	noteRepo.On("ListNotes", mock.Anything, "o1").Return([]*orderEntity.OrderNote{
+		{Content: "Cliente llama por el envío", CreatedBy: "agent1", CreatedAt: base.Add(2 * time.Hour)},
+		{Content: "Primer contacto", CreatedBy: "agent1", CreatedAt: base},
+	}, nil)
+	refundRepo.On("GetByID", mock.Anything, "r1").
+		Return(&orderEntity.Refund{ID: "r1", Amount: 30, Reason: "cancelado", ProcessedAt: base.Add(5 * time.Hour)}, nil)
+
+	events, err := uc.GetOrderTimeline(context.Background(), "o1", "u1", utils.RoleCustomer)
+
+	assert.NoError(t, err)
+	assert.Len(t, events, 5)
+	for i := 1; i < len(events); i++ {
+		assert.False(t, events[i].OccurredAt.Before(events[i-1].OccurredAt))
+	}
+	types := make([]string, 0, len(events))
+	for _, e := range events {
+		types = append(types, e.EventType)
+	}
+	assert.Equal(t, []string{
+		orderEntity.TimelineEventNote,
+		orderEntity.TimelineEventStatusChange,
+		orderEntity.TimelineEventNote,
+		orderEntity.TimelineEventStatusChange,
+		orderEntity.TimelineEventRefund,
+	}, types)
+	assert.Equal(t, "admin1", events[1].ActorID)
+	assert.Equal(t, "u1", events[4].ActorID)
+}
+
+// TestGetOrderTimeline_NoRefund verifica que sin reembolso no se consulta el
+// repositorio de reembolsos.
+func TestGetOrderTimeline_NoRefund(t *testing.T) {
+	mockOrderRepo := new(MockOrderRepository)
+	noteRepo := new(MockOrderNoteRepository)
+	refundRepo := new(MockRefundRepository)
+	uc := usecase.NewOrderUseCase(nil, mockOrderRepo, nil, nil, noteRepo, refundRepo, nil, nil)
+
+	mockOrderRepo.On("GetOrderByID", mock.Anything, "o1", false).Return(&orderEntity.Order{ID: "o1"}, nil)
+	mockOrderRepo.On("GetStatusHistory", mock.Anything, "o1").Return(nil, nil)
+	noteRepo.On("ListNotes", mock.Anything, "o1").Return(nil, nil)
+
+	events, err := uc.GetOrderTimeline(context.Background(), "o1", "admin1", utils.RoleAdmin)
+
+	assert.NoError(t, err)
+	assert.Empty(t, events)
+	refundRepo.AssertNotCalled(t, "GetByID", mock.Anything, mock.Anything)
+}
+
+// TestGetOrderTimeline_SourceError verifica que el fallo de cualquier fuente
+// se propaga.
+func TestGetOrderTimeline_SourceError(t *testing.T) {
+	mockOrderRepo := new(MockOrderRepository)
+	noteRepo := new(MockOrderNoteRepository)
+	uc := usecase.NewOrderUseCase(nil, mockOrderRepo, nil, nil, noteRepo, nil, nil, nil)
+
+	mockOrderRepo.On("GetOrderByID", mock.Anything, "o1", false).Return(&orderEntity.Order{ID: "o1"}, nil)
+	mockOrderRepo.On("GetStatusHistory", mock.Anything, "o1").Return(nil, nil)
+	noteRepo.On("ListNotes", mock.Anything, "o1").Return(nil, errors.New("db error"))
+
+	events, err := uc.GetOrderTimeline(context.Background(), "o1", "admin1", utils.RoleAdmin)
+
+	assert.Nil(t, events)
+	assert.EqualError(t, err, "db error")
+}
+
+// TestGetOrderTimeline_NotOwner verifica que otro cliente no ve la línea de
+// tiempo del pedido y que no se consulta ninguna fuente.
+func TestGetOrderTimeline_NotOwner(t *testing.T) {
+	mockOrderRepo := new(MockOrderRepository)
+	noteRepo := new(MockOrderNoteRepository)
+	uc := usecase.NewOrderUseCase(nil, mockOrderRepo, nil, nil, noteRepo, nil, nil, nil)
+
+	mockOrderRepo.On("GetOrderByID", mock.Anything, "o1", false).Return(&orderEntity.Order{ID: "o1", UserID: "u1"}, nil)
+
+	events, err := uc.GetOrderTimeline(context.Background(), "o1", "u2", utils.RoleCustomer)
+
+	assert.Nil(t, events)
+	assert.ErrorIs(t, err, usecase.ErrPermissionDenied)
+	mockOrderRepo.AssertNotCalled(t, "GetStatusHistory", mock.Anything, mock.Anything)
+	noteRepo.AssertNotCalled(t, "ListNotes", mock.Anything, mock.Anything)
+}
+
+// -------------------------------------
+// Tests de GetOrdersByExternalReference
+// -------------------------------------
+
+// TestGetOrdersByExternalReference_Found verifica que se devuelven los pedidos
+// de la referencia, ya sin espacios alrededor.
+func TestGetOrdersByExternalReference_Found(t *testing.T) {
+	mockOrderRepo := new(MockOrderRepository)
+	uc := usecase.NewOrderUseCase(nil, mockOrderRepo, nil, nil, nil, nil, nil, nil)
+
+	ref := "AMZ-123"
+	orders := []*orderEntity.Order{{ID: "o1", ExternalRef: &ref}, {ID: "o2", ExternalRef: &ref}}
+	mockOrderRepo.On("GetOrdersByExternalRef", mock.Anything, "AMZ-123").Return(orders, nil)
+
+	result, err := uc.GetOrdersByExternalReference(context.Background(), "  AMZ-123 ", utils.RoleAdmin)
+
+	assert.NoError(t, err)
+	assert.Equal(t, orders, result)
+}
+
+// TestGetOrdersByExternalReference_NotFound verifica que sin pedidos se
+// devuelve un slice vacío.
+func TestGetOrdersByExternalReference_NotFound(t *testing.T) {
+	mockOrderRepo := new(MockOrderRepository)
+	uc := usecase.NewOrderUseCase(nil, mockOrderRepo, nil, nil, nil, nil, nil, nil)
+
+	mockOrderRepo.On("GetOrdersByExternalRef", mock.Anything, "AMZ-404").Return(nil, nil)
+
+	result, err := uc.GetOrdersByExternalReference(context.Background(), "AMZ-404", utils.RoleSupport)
+
+	assert.NoError(t, err)
+	assert.NotNil(t, result)
+	assert.Empty(t, result)
+}
+
+// TestGetOrdersByExternalReference_EmptyRef verifica que una referencia vacía
+// es rechazada.
+func TestGetOrdersByExternalReference_EmptyRef(t *testing.T) {
+	mockOrderRepo := new(MockOrderRepository)
+	uc := usecase.NewOrderUseCase(nil, mockOrderRepo, nil, nil, nil, nil, nil, nil)
+
+	for _, ref := range []string{"", "   "} {
+		result, err := uc.GetOrdersByExternalReference(context.Background(), ref, utils.RoleAdmin)
+		assert.Nil(t, result)
+		assert.ErrorIs(t, err, usecase.ErrInvalidRef)
+	}
+	mockOrderRepo.AssertNotCalled(t, "GetOrdersByExternalRef", mock.Anything, mock.Anything)
+}
+
+// TestGetOrdersByExternalReference_TooLong verifica el límite de 100
+// caracteres.
+func TestGetOrdersByExternalReference_TooLong(t *testing.T) {
+	mockOrderRepo := new(MockOrderRepository)
+	uc := usecase.NewOrderUseCase(nil, mockOrderRepo, nil, nil, nil, nil, nil, nil)
+
+	maxRef := strings.Repeat("a", 100)
+	mockOrderRepo.On("GetOrdersByExternalRef", mock.Anything, maxRef).Return(nil, nil)
+
+	_, err := uc.GetOrdersByExternalReference(context.Background(), maxRef, utils.RoleSupport)
+	assert.NoError(t, err)
+
+	result, err := uc.GetOrdersByExternalReference(context.Background(), maxRef+"a", utils.RoleAdmin)
+	assert.Nil(t, result)
+	assert.ErrorIs(t, err, usecase.ErrInvalidRef)
+}
+
+// TestGetOrdersByExternalReference_Forbidden verifica que un cliente no puede
+// buscar pedidos por referencia externa.
+func TestGetOrdersByExternalReference_Forbidden(t *testing.T) {
+	mockOrderRepo := new(MockOrderRepository)
+	uc := usecase.NewOrderUseCase(nil, mockOrderRepo, nil, nil, nil, nil, nil, nil)
+
+	result, err := uc.GetOrdersByExternalReference(context.Background(), "AMZ-123", utils.RoleCustomer)
+
+	assert.Nil(t, result)
+	assert.ErrorIs(t, err, usecase.ErrForbidden)
+	mockOrderRepo.AssertNotCalled(t, "GetOrdersByExternalRef", mock.Anything, mock.Anything)
+}
+
+// -------------------------------------
+// Tests de GetRevenueByProduct
+// -------------------------------------
+
+// TestGetRevenueByProduct_Success verifica que se devuelve el ranking del
+// repositorio para un administrador.
+func TestGetRevenueByProduct_Success(t *testing.T) {
+	mockOrderRepo := new(MockOrderRepository)
+	uc := usecase.NewOrderUseCase(nil, mockOrderRepo, nil, nil, nil, nil, nil, nil)
+
+	since := time.Now().AddDate(0, -1, 0)
+	revenue := []*orderEntity.ProductRevenue{
+		{ProductID: "p1", ProductName: "Teclado", UnitsSold: 3, TotalRevenue: 90},
+		{ProductID: "p2", ProductName: "Ratón", UnitsSold: 5, TotalRevenue: 50},
+	}
+	mockOrderRepo.On("GetRevenueByProduct", mock.Anything, since, 10).Return(revenue, nil)
+
+	result, err := uc.GetRevenueByProduct(context.Background(), since, 10, utils.RoleAdmin)
+
+	assert.NoError(t, err)
+	assert.Equal(t, revenue, result)
+}
+
+// TestGetRevenueByProduct_Forbidden verifica que solo un administrador puede
+// consultar los ingresos.
+func TestGetRevenueByProduct_Forbidden(t *testing.T) {
+	mockOrderRepo := new(MockOrderRepository)
+	uc := usecase.NewOrderUseCase(nil, mockOrderRepo, nil, nil, nil, nil, nil, nil)
+
+	result, err := uc.GetRevenueByProduct(context.Background(), time.Now(), 10, utils.RoleCustomer)
+
+	assert.Nil(t, result)
+	assert.ErrorIs(t, err, usecase.ErrForbidden)
+	mockOrderRepo.AssertNotCalled(t, "GetRevenueByProduct", mock.Anything, mock.Anything, mock.Anything)
+}
+
+// TestGetRevenueByProduct_InvalidInput verifica la validación del límite y de
+// la fecha de inicio.
+func TestGetRevenueByProduct_InvalidInput(t *testing.T) {
+	mockOrderRepo := new(MockOrderRepository)
+	uc := usecase.NewOrderUseCase(nil, mockOrderRepo, nil, nil, nil, nil, nil, nil)
+
+	for _, limit := range []int{0, 101} {
+		_, err := uc.GetRevenueByProduct(context.Background(), time.Now(), limit, utils.RoleAdmin)
+		assert.ErrorIs(t, err, usecase.ErrInvalidLimit)
+	}
+
+	_, err := uc.GetRevenueByProduct(context.Background(), time.Now().AddDate(-6, 0, 0), 10, utils.RoleAdmin)
+	assert.ErrorIs(t, err, usecase.ErrInvalidSince)
+}
+
+// -------------------------------------
+// Tests de GetRepeatCustomers
+// -------------------------------------
+
+// TestGetRepeatCustomers_Success verifica que se piden como máximo 500
+// clientes con el mínimo de pedidos indicado y se devuelven en orden.
+func TestGetRepeatCustomers_Success(t *testing.T) {
+	mockOrderRepo := new(MockOrderRepository)
+	uc := usecase.NewOrderUseCase(nil, mockOrderRepo, nil, nil, nil, nil, nil, nil)
+
+	since := time.Now().AddDate(0, -3, 0)
+	customers := []*orderEntity.RepeatCustomer{
+		{UserID: "u2", OrderCount: 5, TotalSpend: 300},
+		{UserID: "u1", OrderCount: 3, TotalSpend: 120},
+	}
+	mockOrderRepo.On("GetRepeatCustomers", mock.Anything, 3, since, 500).Return(customers, nil)
+
+	result, err := uc.GetRepeatCustomers(context.Background(), 3, since, utils.RoleAdmin)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"u2", "u1"}, []string{result[0].UserID, result[1].UserID})
+}
+
+// TestGetRepeatCustomers_Empty verifica que sin resultados se devuelve un
+// slice vacío.
+func TestGetRepeatCustomers_Empty(t *testing.T) {
+	mockOrderRepo := new(MockOrderRepository)
+	uc := usecase.NewOrderUseCase(nil, mockOrderRepo, nil, nil, nil, nil, nil, nil)
+
+	mockOrderRepo.On("GetRepeatCustomers", mock.Anything, 2, mock.Anything, 500).Return(nil, nil)
+
+	result, err := uc.GetRepeatCustomers(context.Background(), 2, time.Now(), utils.RoleAdmin)
+
+	assert.NoError(t, err)
+	assert.NotNil(t, result)
+	assert.Empty(t, result)
+}
+
+// TestGetRepeatCustomers_InvalidInput verifica el rol de administrador y el
+// mínimo de pedidos.
+func TestGetRepeatCustomers_InvalidInput(t *testing.T) {
+	mockOrderRepo := new(MockOrderRepository)
+	uc := usecase.NewOrderUseCase(nil, mockOrderRepo, nil, nil, nil, nil, nil, nil)
+
+	_, err := uc.GetRepeatCustomers(context.Background(), 2, time.Now(), utils.RoleSupport)
+	assert.ErrorIs(t, err, usecase.ErrForbidden)
+
+	_, err = uc.GetRepeatCustomers(context.Background(), 1, time.Now(), utils.RoleAdmin)
+	assert.ErrorIs(t, err, usecase.ErrInvalidMinOrders)
+
+	mockOrderRepo.AssertNotCalled(t, "GetRepeatCustomers", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+// -------------------------------------
+// Tests de GetUserLifetimeValue
+// -------------------------------------
+
+// TestGetUserLifetimeValue_Success verifica el ticket medio y los días desde
+// el último pedido.
+func TestGetUserLifetimeValue_Success(t *testing.T) {
+	mockOrderRepo := new(MockOrderRepository)
+	uc := usecase.NewOrderUseCase(nil, mockOrderRepo, nil, nil, nil, nil, nil, nil)
+
+	first := time.Now().AddDate(0, -6, 0)
+	last := time.Now().Add(-(3*24 + 5) * time.Hour)
+	mockOrderRepo.On("GetUserOrderStats", mock.Anything, "u1").Return(&orderEntity.UserOrderStats{
+		TotalOrders:     5,
+		CompletedOrders: 3,
+		CanceledOrders:  1,
+		TotalSpend:      100,
+		FirstOrderAt:    &first,
+		LastOrderAt:     &last,
+	}, nil)
+
+	value, err := uc.GetUserLifetimeValue(context.Background(), "u1")
+
+	assert.NoError(t, err)
+	assert.Equal(t, "u1", value.UserID)
+	assert.Equal(t, first, value.FirstOrderAt)
+	assert.Equal(t, 5, value.TotalOrders)
+	assert.Equal(t, 3, value.CompletedOrders)
+	assert.Equal(t, 1, value.CanceledOrders)
+	assert.Equal(t, 33.33, value.AverageOrderValue)
+	assert.Equal(t, 3, value.DaysSinceLastOrder)
+}
+
+// TestGetUserLifetimeValue_NoCompletedOrders verifica que sin pedidos
+// completados el ticket medio es 0 y no se divide por cero.
+func TestGetUserLifetimeValue_NoCompletedOrders(t *testing.T) {
+	mockOrderRepo := new(MockOrderRepository)
+	uc := usecase.NewOrderUseCase(nil, mockOrderRepo, nil, nil, nil, nil, nil, nil)
+
+	at := time.Now()
+	mockOrderRepo.On("GetUserOrderStats", mock.Anything, "u1").Return(&orderEntity.UserOrderStats{
+		TotalOrders:    2,
+		CanceledOrders: 2,
+		FirstOrderAt:   &at,
+		LastOrderAt:    &at,
+	}, nil)
+
+	value, err := uc.GetUserLifetimeValue(context.Background(), "u1")
+
+	assert.NoError(t, err)
+	assert.Zero(t, value.AverageOrderValue)
+	assert.Zero(t, value.DaysSinceLastOrder)
+}
+
+// TestGetUserLifetimeValue_NoOrders verifica que un usuario sin pedidos
+// obtiene valores a cero sin error.
+func TestGetUserLifetimeValue_NoOrders(t *testing.T) {
+	mockOrderRepo := new(MockOrderRepository)
+	uc := usecase.NewOrderUseCase(nil, mockOrderRepo, nil, nil, nil, nil, nil, nil)
+
+	mockOrderRepo.On("GetUserOrderStats", mock.Anything, "u1").Return(&orderEntity.UserOrderStats{}, nil)
+
+	value, err := uc.GetUserLifetimeValue(context.Background(), "u1")
+
+	assert.NoError(t, err)
+	assert.Equal(t, &orderEntity.LifetimeValue{UserID: "u1"}, value)
+}
+
+// TestGetUserLifetimeValue_RepoError verifica que el error del repositorio se
+// propaga.
+func TestGetUserLifetimeValue_RepoError(t *testing.T) {
+	mockOrderRepo := new(MockOrderRepository)
+	uc := usecase.NewOrderUseCase(nil, mockOrderRepo, nil, nil, nil, nil, nil, nil)
+
+	mockOrderRepo.On("GetUserOrderStats", mock.Anything, "u1").Return(nil, errors.New("db error"))
+
+	value, err := uc.GetUserLifetimeValue(context.Background(), "u1")
+
+	assert.Nil(t, value)
+	assert.EqualError(t, err, "db error")
+}
+
+// -------------------------------------
+// Tests de GetOrderFulfillmentRate
+// -------------------------------------
+
+// TestGetOrderFulfillmentRate verifica la proporción de pedidos completados
+// sobre los no cancelados, y que sin pedidos no cancelados se devuelve 0.
+func TestGetOrderFulfillmentRate(t *testing.T) {
+	cases := []struct {
+		name        string
+		nonCanceled int64
+		done        int64
+		expected    float64
+	}{
+		{"todos completados", 4, 4, 1.0},
+		{"mitad completados", 4, 2, 0.5},
+		{"sin pedidos", 0, 0, 0.0},
+		{"todos cancelados", 0, 0, 0.0},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockOrderRepo := new(MockOrderRepository)
+			uc := usecase.NewOrderUseCase(nil, mockOrderRepo, nil, nil, nil, nil, nil, nil)
+
+			since := time.Now().AddDate(0, -1, 0)
+			mockOrderRepo.On("CountNonCanceledOrdersSince", mock.Anything, since).Return(tc.nonCanceled, nil)
+			mockOrderRepo.On("CountOrdersByStatusSince", mock.Anything, utils.OrderStatusDone, since).Return(tc.done, nil)
+
+			rate, err := uc.GetOrderFulfillmentRate(context.Background(), since, utils.RoleAdmin)
+
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, rate)
+			if tc.nonCanceled == 0 {
+				mockOrderRepo.AssertNotCalled(t, "CountOrdersByStatusSince", mock.Anything, mock.Anything, mock.Anything)
+			}
+		})
+	}
+}
+
+// TestGetOrderFulfillmentRate_Forbidden verifica que solo un admin puede
+// consultar la tasa.
+func TestGetOrderFulfillmentRate_Forbidden(t *testing.T) {
+	mockOrderRepo := new(MockOrderRepository)
+	uc := usecase.NewOrderUseCase(nil, mockOrderRepo, nil, nil, nil, nil, nil, nil)
+
+	rate, err := uc.GetOrderFulfillmentRate(context.Background(), time.Now(), utils.RoleCustomer)
+
+	assert.Zero(t, rate)
+	assert.ErrorIs(t, err, usecase.ErrForbidden)
+	mockOrderRepo.AssertNotCalled(t, "CountNonCanceledOrdersSince", mock.Anything, mock.Anything)
+}
+
+// TestGetOrderFulfillmentRate_InvalidSince verifica que se rechaza una fecha
+// de más de 5 años atrás.
+func TestGetOrderFulfillmentRate_InvalidSince(t *testing.T) {
+	uc := usecase.NewOrderUseCase(nil, new(MockOrderRepository), nil, nil, nil, nil, nil, nil)
+
+	_, err := uc.GetOrderFulfillmentRate(context.Background(), time.Now().AddDate(-6, 0, 0), utils.RoleAdmin)
+
+	assert.ErrorIs(t, err, usecase.ErrInvalidSince)
+}
+
+// -------------------------------------
+// Tests de CancelOrder
+// -------------------------------------
+
+// TestCancelOrder_Success verifica que una orden nueva o en curso se cancela y
+// guarda el motivo.
+func TestCancelOrder_Success(t *testing.T) {
+	for _, s := range []utils.OrderStatus{utils.OrderStatusNew, utils.OrderStatusInProgress} {
+		mockOrderRepo := new(MockOrderRepository)
+		uc := usecase.NewOrderUseCase(new(MockValidator), mockOrderRepo, new(mocks.ProductRepository), nil, nil, nil, nil, nil)
+
+		existing := &orderEntity.Order{ID: "o1", UserID: "u1", Status: s}
+		mockOrderRepo.On("GetOrderByID", mock.Anything, "o1", false).Return(existing, nil)
+		mockOrderRepo.On("UpdateOrder", mock.Anything, mock.MatchedBy(func(o *orderEntity.Order) bool {
+			return o.Status == utils.OrderStatusCanceled && o.CancelReason == "changed my mind"
+		})).Return(nil)
+		mockOrderRepo.On("CreateAuditLog", mock.Anything, mock.MatchedBy(func(l *orderEntity.OrderAuditLog) bool {
+			return l.FromStatus == s && l.ToStatus == utils.OrderStatusCanceled && l.ChangedBy == "u1"
+		})).Return(nil)
+
+		order, err := uc.CancelOrder(context.Background(), "o1", "u1", "  changed my mind ")
+
+		assert.NoError(t, err)
+		assert.Equal(t, utils.OrderStatusCanceled, order.Status)
+		assert.Equal(t, "changed my mind", order.CancelReason)
+		mockOrderRepo.AssertExpectations(t)
+	}
+}
+
+// TestCancelOrder_InvalidState verifica que CancelOrder rechaza órdenes que
+// ya están en estado 'done' o 'canceled'.
+func TestCancelOrder_InvalidState(t *testing.T) {
+	mockOrderRepo := new(MockOrderRepository)
+	uc := usecase.NewOrderUseCase(new(MockValidator), mockOrderRepo, new(mocks.ProductRepository), nil, nil, nil, nil, nil)
+
+	for _, s := range []utils.OrderStatus{utils.OrderStatusDone, utils.OrderStatusCanceled} {
+		existing := &orderEntity.Order{ID: "o1", UserID: "u1", Status: s}
+		mockOrderRepo.On("GetOrderByID", mock.Anything, "o1", false).Return(existing, nil)
+
+		_, err := uc.CancelOrder(context.Background(), "o1", "u1", "reason")
+
+		var transitionErr usecase.ErrOrderTransitionFailed
+		if assert.True(t, errors.As(err, &transitionErr)) {
+			assert.Equal(t, s, transitionErr.From)
+			assert.Equal(t, utils.OrderStatusCanceled, transitionErr.To)
+		}
+		mockOrderRepo.ExpectedCalls = nil
+	}
+	mockOrderRepo.AssertNotCalled(t, "UpdateOrder", mock.Anything, mock.Anything)
+}
+
+// TestCancelOrder_NotOwner verifica que un usuario no puede cancelar la orden
+// de otro.
+func TestCancelOrder_NotOwner(t *testing.T) {
+	mockOrderRepo := new(MockOrderRepository)
+	uc := usecase.NewOrderUseCase(new(MockValidator), mockOrderRepo, new(mocks.ProductRepository), nil, nil, nil, nil, nil)
+
+	existing := &orderEntity.Order{ID: "o1", UserID: "u1", Status: utils.OrderStatusNew}
+	mockOrderRepo.On("GetOrderByID", mock.Anything, "o1", false).Return(existing, nil)
+
+	order, err := uc.CancelOrder(context.Background(), "o1", "u2", "reason")
+
+	assert.Nil(t, order)
+	assert.ErrorIs(t, err, usecase.ErrPermissionDenied)
+	mockOrderRepo.AssertNotCalled(t, "UpdateOrder", mock.Anything, mock.Anything)
+}
+
+// -------------------------------------
+// Tests de GetOrdersByIDs
+// -------------------------------------
+
+// TestGetOrdersByIDs_AllFound verifica que se devuelven todas las órdenes en
+// el orden pedido, sin duplicados.
+func TestGetOrdersByIDs_AllFound(t *testing.T) {
+	mockOrderRepo := new(MockOrderRepository)
+	uc := usecase.NewOrderUseCase(nil, mockOrderRepo, nil, nil, nil, nil, nil, nil)
+
+	mockOrderRepo.On("GetOrdersByIDs", mock.Anything, []string{"o1", "o2"}, true).
+		Return([]*orderEntity.Order{{ID: "o2"}, {ID: "o1"}}, nil)
+
+	orders, err := uc.GetOrdersByIDs(context.Background(), []string{"o1", "o2", "o1"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []*orderEntity.Order{{ID: "o1"}, {ID: "o2"}}, orders)
+}
+
+// TestGetOrdersByIDs_PartialFound verifica que se devuelven las órdenes
+// encontradas junto con un ErrPartialResult con los IDs que faltan.
+func TestGetOrdersByIDs_PartialFound(t *testing.T) {
+	mockOrderRepo := new(MockOrderRepository)
+	uc := usecase.NewOrderUseCase(nil, mockOrderRepo, nil, nil, nil, nil, nil, nil)
+
+	mockOrderRepo.On("GetOrdersByIDs", mock.Anything, []string{"o1", "o2", "o3"}, true).
+		Return([]*orderEntity.Order{{ID: "o2"}}, nil)
+
+	orders, err := uc.GetOrdersByIDs(context.Background(), []string{"o1", "o2", "o3"})
+
+	assert.Equal(t, []*orderEntity.Order{{ID: "o2"}}, orders)
+	var partial usecase.ErrPartialResult
+	if assert.True(t, errors.As(err, &partial)) {
+		assert.Equal(t, []string{"o1", "o3"}, partial.MissingIDs)
+	}
+}
+
+// TestGetOrdersByIDs_AllMissing verifica que si no existe ninguna se devuelve
+// una lista vacía y todos los IDs como ausentes.
+func TestGetOrdersByIDs_AllMissing(t *testing.T) {
+	mockOrderRepo := new(MockOrderRepository)
+	uc := usecase.NewOrderUseCase(nil, mockOrderRepo, nil, nil, nil, nil, nil, nil)
+
+	mockOrderRepo.On("GetOrdersByIDs", mock.Anything, []string{"o1", "o2"}, true).Return(nil, nil)
+
+	orders, err := uc.GetOrdersByIDs(context.Background(), []string{"o1", "o2"})
+
+	assert.Empty(t, orders)
+	var partial usecase.ErrPartialResult
+	if assert.True(t, errors.As(err, &partial)) {
+		assert.Equal(t, []string{"o1", "o2"}, partial.MissingIDs)
+	}
+}
+
+// TestGetOrdersByIDs_TooMany verifica que se rechazan más de 500 IDs sin
+// consultar el repositorio.
+func TestGetOrdersByIDs_TooMany(t *testing.T) {
+	mockOrderRepo := new(MockOrderRepository)
+	uc := usecase.NewOrderUseCase(nil, mockOrderRepo, nil, nil, nil, nil, nil, nil)
+
+	ids := make([]string, 501)
+	for i := range ids {
+		ids[i] = fmt.Sprintf("o%d", i)
+	}
+
+	_, err := uc.GetOrdersByIDs(context.Background(), ids)
+
+	assert.ErrorIs(t, err, usecase.ErrTooManyOrderIDs)
+	mockOrderRepo.AssertNotCalled(t, "GetOrdersByIDs", mock.Anything, mock.Anything, mock.Anything)
+}
+
+// -------------------------------------
+// Tests de OrderAuditLog
+// -------------------------------------
+
+// TestUpdateOrder_AuditLogTwoSteps verifica que new→progress→done deja dos
+// entradas de auditoría con los pares from/to correctos.
+func TestUpdateOrder_AuditLogTwoSteps(t *testing.T) {
+	mockOrderRepo := new(MockOrderRepository)
+	uc := usecase.NewOrderUseCase(new(MockValidator), mockOrderRepo, new(mocks.ProductRepository), nil, nil, nil, nil, nil)
+
+	existing := &orderEntity.Order{ID: "o1", UserID: "u1", Status: utils.OrderStatusNew}
+	mockOrderRepo.On("GetOrderByID", mock.Anything, "o1", false).Return(existing, nil)
+	mockOrderRepo.On("UpdateOrder", mock.Anything, existing).Return(nil)
+	var logs []*orderEntity.OrderAuditLog
+	mockOrderRepo.On("CreateAuditLog", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		logs = append(logs, args.Get(1).(*orderEntity.OrderAuditLog))
+	}).Return(nil)
+
+	_, err := uc.UpdateOrder(context.Background(), "o1", "u1", string(utils.OrderStatusInProgress))
+	assert.NoError(t, err)
+	_, err = uc.UpdateOrder(context.Background(), "o1", "u1", string(utils.OrderStatusDone))
+	assert.NoError(t, err)
+
+	if assert.Len(t, logs, 2) {
+		assert.Equal(t, utils.OrderStatusNew, logs[0].FromStatus)
+		assert.Equal(t, utils.OrderStatusInProgress, logs[0].ToStatus)
+		assert.Equal(t, utils.OrderStatusInProgress, logs[1].FromStatus)
+		assert.Equal(t, utils.OrderStatusDone, logs[1].ToStatus)
+		for _, log := range logs {
+			assert.Equal(t, "o1", log.OrderID)
+			assert.Equal(t, "u1", log.ChangedBy)
+			assert.False(t, log.ChangedAt.IsZero())
+		}
+	}
+}
+
+// TestUpdateOrder_AuditLogError verifica que el fallo al guardar la auditoría
+// se propaga.
+func TestUpdateOrder_AuditLogError(t *testing.T) {
+	mockOrderRepo := new(MockOrderRepository)
+	uc := usecase.NewOrderUseCase(new(MockValidator), mockOrderRepo, new(mocks.ProductRepository), nil, nil, nil, nil, nil)
+
+	existing := &orderEntity.Order{ID: "o1", UserID: "u1", Status: utils.OrderStatusNew}
+	mockOrderRepo.On("GetOrderByID", mock.Anything, "o1", false).Return(existing, nil)
+	mockOrderRepo.On("UpdateOrder", mock.Anything, existing).Return(nil)
+	mockOrderRepo.On("CreateAuditLog", mock.Anything, mock.Anything).Return(errors.New("audit failed"))
+
+	order, err := uc.UpdateOrder(context.Background(), "o1", "u1", string(utils.OrderStatusInProgress))
+
+	assert.Nil(t, order)
+	assert.EqualError(t, err, "audit failed")
+}
+
+// TestGetAuditLogsForOrder verifica que se devuelve el historial de estados
+// como entradas de auditoría.
+func TestGetAuditLogsForOrder(t *testing.T) {
+	mockOrderRepo := new(MockOrderRepository)
+	uc := usecase.NewOrderUseCase(nil, mockOrderRepo, nil, nil, nil, nil, nil, nil)
+
+	history := []orderEntity.OrderStatusHistory{
+		{OrderID: "o1", FromStatus: utils.OrderStatusNew, ToStatus: utils.OrderStatusInProgress},
+		{OrderID: "o1", FromStatus: utils.OrderStatusInProgress, ToStatus: utils.OrderStatusDone},
+	}
+	mockOrderRepo.On("GetStatusHistory", mock.Anything, "o1").Return(history, nil)
+
+	logs, err := uc.GetAuditLogsForOrder(context.Background(), "o1")
+
+	assert.NoError(t, err)
+	if assert.Len(t, logs, 2) {
+		assert.Equal(t, utils.OrderStatusInProgress, logs[0].ToStatus)
+		assert.Equal(t, utils.OrderStatusDone, logs[1].ToStatus)
+	}
+}
+
+// -------------------------------------
+// Tests de DeleteOrder
+// -------------------------------------
+
+// TestDeleteOrder_Success verifica que el propietario puede borrar su orden.
+func TestDeleteOrder_Success(t *testing.T) {
+	mockOrderRepo := new(MockOrderRepository)
+	uc := usecase.NewOrderUseCase(nil, mockOrderRepo, nil, nil, nil, nil, nil, nil)
+
+	existing := &orderEntity.Order{ID: "o1", UserID: "u1", Status: utils.OrderStatusDone}
+	mockOrderRepo.On("GetOrderByID", mock.Anything, "o1", false).Return(existing, nil)
+	mockOrderRepo.On("SoftDeleteOrder", mock.Anything, existing).Return(nil)
+
+	err := uc.DeleteOrder(context.Background(), "o1", "u1")
+
+	assert.NoError(t, err)
+	mockOrderRepo.AssertExpectations(t)
+}
+
+// TestDeleteOrder_NotOwner verifica que un usuario no puede borrar la orden de
+// otro.
+func TestDeleteOrder_NotOwner(t *testing.T) {
+	mockOrderRepo := new(MockOrderRepository)
+	uc := usecase.NewOrderUseCase(nil, mockOrderRepo, nil, nil, nil, nil, nil, nil)
+
+	existing := &orderEntity.Order{ID: "o1", UserID: "u1"}
+	mockOrderRepo.On("GetOrderByID", mock.Anything, "o1", false).Return(existing, nil)
+
+	err := uc.DeleteOrder(context.Background(), "o1", "u2")
+
+	assert.ErrorIs(t, err, usecase.ErrPermissionDenied)
+	mockOrderRepo.AssertNotCalled(t, "SoftDeleteOrder", mock.Anything, mock.Anything)
+}
+
+// -------------------------------------
+// Tests de ReOrder
+// -------------------------------------
+
+// TestReOrder_Success verifica que se crea un pedido nuevo con los mismos
+// productos y cantidades, a precio actual.
+func TestReOrder_Success(t *testing.T) {
+	mockOrderRepo := new(MockOrderRepository)
+	mockProductRepo := new(mocks.ProductRepository)
+	uc := usecase.NewOrderUseCase(validation.New(), mockOrderRepo, mockProductRepo, nil, nil, nil, nil, newMockNotifier())
+
+	original := &orderEntity.Order{ID: "o1", UserID: "u1", Lines: []*orderEntity.OrderLine{
+		{ProductID: "p1", Quantity: 2, Price: 20},
+		{ProductID: "p2", Quantity: 1, Price: 5},
+	}}
+	mockOrderRepo.On("GetOrderByID", mock.Anything, "o1", true).Return(original, nil)
+	mockOrderRepo.On("FindOrderByIdempotencyKey", mock.Anything, mock.Anything).Return(nil, gorm.ErrRecordNotFound)
+	mockProductRepo.On("GetProductById", mock.Anything, "p1").Return(&productEntity.Product{ID: "p1", Price: 15}, nil)
+	mockProductRepo.On("GetProductById", mock.Anything, "p2").Return(&productEntity.Product{ID: "p2", Price: 7}, nil)
+	mockProductRepo.On("GetProductStockForUpdate", mock.Anything, mock.Anything).Return(5, nil)
+	mockProductRepo.On("UpdateProductStock", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	var lines []*orderEntity.OrderLine
+	mockOrderRepo.On("CreateOrder", mock.Anything, mock.MatchedBy(func(o *orderEntity.Order) bool {
+		return o.UserID == "u1"
+	}), mock.Anything).Run(func(args mock.Arguments) {
+		lines = args.Get(2).([]*orderEntity.OrderLine)
+	}).Return(nil, nil)
+
+	order, err := uc.ReOrder(context.Background(), "o1", "u1")
+
+	assert.NoError(t, err)
+	assert.NotNil(t, order)
+	if assert.Len(t, lines, 2) {
+		assert.Equal(t, uint(2), lines[0].Quantity)
+		assert.Equal(t, 30.0, lines[0].Price)
+		assert.Equal(t, 7.0, lines[1].Price)
+	}
+}
+
+// TestReOrder_ProductDeleted verifica que si un producto ya no existe se
+// devuelve el error de PlaceOrder sin cambios y no se crea el pedido.
+func TestReOrder_ProductDeleted(t *testing.T) {
+	mockOrderRepo := new(MockOrderRepository)
+	mockProductRepo := new(mocks.ProductRepository)
+	uc := usecase.NewOrderUseCase(validation.New(), mockOrderRepo, mockProductRepo, nil, nil, nil, nil, nil)
+
+	original := &orderEntity.Order{ID: "o1", UserID: "u1", Lines: []*orderEntity.OrderLine{
+		{ProductID: "p1", Quantity: 1},
+	}}
+	mockOrderRepo.On("GetOrderByID", mock.Anything, "o1", true).Return(original, nil)
+	mockOrderRepo.On("FindOrderByIdempotencyKey", mock.Anything, mock.Anything).Return(nil, gorm.ErrRecordNotFound)
+	mockProductRepo.On("GetProductById", mock.Anything, "p1").Return(nil, gorm.ErrRecordNotFound)
+
+	order, err := uc.ReOrder(context.Background(), "o1", "u1")
+
+	assert.Nil(t, order)
+	assert.Equal(t, gorm.ErrRecordNotFound, err)
+	mockOrderRepo.AssertNotCalled(t, "CreateOrder", mock.Anything, mock.Anything, mock.Anything)
+}
+
+// TestReOrder_NotOwner verifica que no se puede repetir la orden de otro
+// usuario.
+func TestReOrder_NotOwner(t *testing.T) {
+	mockOrderRepo := new(MockOrderRepository)
+	uc := usecase.NewOrderUseCase(validation.New(), mockOrderRepo, new(mocks.ProductRepository), nil, nil, nil, nil, nil)
+
+	mockOrderRepo.On("GetOrderByID", mock.Anything, "o1", true).Return(&orderEntity.Order{ID: "o1", UserID: "u1"}, nil)
+
+	order, err := uc.ReOrder(context.Background(), "o1", "u2")
+
+	assert.Nil(t, order)
+	assert.ErrorIs(t, err, usecase.ErrPermissionDenied)
+}
+
+// -------------------------------------
+// Tests de ExportOrders
+// -------------------------------------
+
+func exportTestOrders() []*orderEntity.Order {
+	paymentID := "pay1"
+	return []*orderEntity.Order{
+		{ID: "o1", Code: "SO1", Status: utils.OrderStatusDone, TotalPrice: 12.5, PaymentID: &paymentID,
+			CreatedAt: time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)},
+		{ID: "o2", Code: "SO2", Status: utils.OrderStatusNew, TotalPrice: 3,
+			CreatedAt: time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC)},
+	}
+}
+
+// TestExportOrders_CSV verifica que la salida CSV incluye la cabecera y una
+// fila por orden.
+func TestExportOrders_CSV(t *testing.T) {
+	mockOrderRepo := new(MockOrderRepository)
+	uc := usecase.NewOrderUseCase(validation.New(), mockOrderRepo, nil, nil, nil, nil, nil, nil)
+
+	req := &orderDto.OrderExportRequest{UserID: "u1", Format: orderDto.ExportFormatCSV}
+	mockOrderRepo.On("StreamOrders", mock.Anything, req).Return(exportTestOrders(), nil)
+
+	var buf bytes.Buffer
+	err := uc.ExportOrders(context.Background(), req, &buf)
+
+	assert.NoError(t, err)
+	rows := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if assert.Len(t, rows, 3) {
+		assert.Equal(t, "id,code,status,total_price,is_paid,created_at", rows[0])
+		assert.Equal(t, "o1,SO1,done,12.50,true,2026-01-02T03:04:05Z", rows[1])
+		assert.Equal(t, "o2,SO2,new,3.00,false,2026-02-01T00:00:00Z", rows[2])
+	}
+}
+
+// TestExportOrders_JSON verifica que la salida JSON es un arreglo válido.
+func TestExportOrders_JSON(t *testing.T) {
+	mockOrderRepo := new(MockOrderRepository)
+	uc := usecase.NewOrderUseCase(validation.New(), mockOrderRepo, nil, nil, nil, nil, nil, nil)
+
+	req := &orderDto.OrderExportRequest{UserID: "u1", Format: orderDto.ExportFormatJSON}
+	mockOrderRepo.On("StreamOrders", mock.Anything, req).Return(exportTestOrders(), nil)
+
+	var buf bytes.Buffer
+	err := uc.ExportOrders(context.Background(), req, &buf)
+
+	assert.NoError(t, err)
+	var rows []map[string]any
+	if assert.NoError(t, json.Unmarshal(buf.Bytes(), &rows)) && assert.Len(t, rows, 2) {
+		assert.Equal(t, "o1", rows[0]["id"])
+		assert.Equal(t, true, rows[0]["is_paid"])
+		assert.Equal(t, "o2", rows[1]["id"])
+	}
+}
+
+// TestExportOrders_JSONEmpty verifica que sin órdenes se escribe un arreglo
+// vacío.
+func TestExportOrders_JSONEmpty(t *testing.T) {
+	mockOrderRepo := new(MockOrderRepository)
+	uc := usecase.NewOrderUseCase(validation.New(), mockOrderRepo, nil, nil, nil, nil, nil, nil)
+
+	req := &orderDto.OrderExportRequest{UserID: "u1", Format: orderDto.ExportFormatJSON}
+	mockOrderRepo.On("StreamOrders", mock.Anything, req).Return(nil, nil)
+
+	var buf bytes.Buffer
+	err := uc.ExportOrders(context.Background(), req, &buf)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "[]", buf.String())
+}
+
+// TestExportOrders_InvalidFormat verifica que un formato desconocido se
+// rechaza sin consultar el repositorio.
+func TestExportOrders_InvalidFormat(t *testing.T) {
+	mockOrderRepo := new(MockOrderRepository)
+	uc := usecase.NewOrderUseCase(validation.New(), mockOrderRepo, nil, nil, nil, nil, nil, nil)
+
+	err := uc.ExportOrders(context.Background(), &orderDto.OrderExportRequest{UserID: "u1", Format: "xml"}, &bytes.Buffer{})
+
+	assert.Error(t, err)
+	mockOrderRepo.AssertNotCalled(t, "StreamOrders", mock.Anything, mock.Anything)
+}
+
+// -------------------------------------
+// Tests de GetOrderStatistics
+// -------------------------------------
+
+// TestGetOrderStatistics_Success verifica que se suman las órdenes de todos
+// los estados y que los ingresos solo cuentan las completadas.
+func TestGetOrderStatistics_Success(t *testing.T) {
+	mockOrderRepo := new(MockOrderRepository)
+	uc := usecase.NewOrderUseCase(nil, mockOrderRepo, nil, nil, nil, nil, nil, nil)
+
+	mockOrderRepo.On("AggregateOrderStats", mock.Anything, "u1").Return(&orderEntity.OrderStats{
+		ByStatus: []*orderEntity.StatusTotals{
+			{Status: utils.OrderStatusNew, Orders: 2, Total: 40},
+			{Status: utils.OrderStatusDone, Orders: 3, Total: 125.5},
+			{Status: utils.OrderStatusCanceled, Orders: 1, Total: 30},
+		},
+	}, nil)
+
+	stats, err := uc.GetOrderStatistics(context.Background(), "u1", "u1", utils.RoleCustomer)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 6, stats.TotalOrders)
+	assert.Equal(t, 125.5, stats.TotalRevenue)
+	assert.Equal(t, map[string]int{"new": 2, "done": 3, "canceled": 1}, stats.CountByStatus)
+}
+
+// TestGetOrderStatistics_NoOrders verifica que sin órdenes se devuelven ceros
+// y un mapa vacío.
+func TestGetOrderStatistics_NoOrders(t *testing.T) {
+	mockOrderRepo := new(MockOrderRepository)
+	uc := usecase.NewOrderUseCase(nil, mockOrderRepo, nil, nil, nil, nil, nil, nil)
+
+	mockOrderRepo.On("AggregateOrderStats", mock.Anything, "").Return(&orderEntity.OrderStats{}, nil)
+
+	stats, err := uc.GetOrderStatistics(context.Background(), "", "admin1", utils.RoleAdmin)
+
+	assert.NoError(t, err)
+	assert.Zero(t, stats.TotalOrders)
+	assert.Zero(t, stats.TotalRevenue)
+	assert.Empty(t, stats.CountByStatus)
+}
+
+// TestGetOrderStatistics_AllUsersNotAdmin verifica que solo un admin puede
+// pedir las estadísticas de todos los usuarios.
+func TestGetOrderStatistics_AllUsersNotAdmin(t *testing.T) {
+	mockOrderRepo := new(MockOrderRepository)
+	uc := usecase.NewOrderUseCase(nil, mockOrderRepo, nil, nil, nil, nil, nil, nil)
+
+	stats, err := uc.GetOrderStatistics(context.Background(), "", "u1", utils.RoleCustomer)
+
+	assert.Nil(t, stats)
+	assert.ErrorIs(t, err, usecase.ErrForbidden)
+	mockOrderRepo.AssertNotCalled(t, "AggregateOrderStats", mock.Anything, mock.Anything)
+}
+
+// TestGetOrderStatistics_OtherUser verifica que un cliente no puede ver las
+// estadísticas de otro usuario.
+func TestGetOrderStatistics_OtherUser(t *testing.T) {
+	mockOrderRepo := new(MockOrderRepository)
+	uc := usecase.NewOrderUseCase(nil, mockOrderRepo, nil, nil, nil, nil, nil, nil)
+
+	stats, err := uc.GetOrderStatistics(context.Background(), "u2", "u1", utils.RoleCustomer)
+
+	assert.Nil(t, stats)
+	assert.ErrorIs(t, err, usecase.ErrPermissionDenied)
+	mockOrderRepo.AssertNotCalled(t, "AggregateOrderStats", mock.Anything, mock.Anything)
 }
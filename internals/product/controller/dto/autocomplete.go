@@ -0,0 +1,6 @@
+package dto
+
+type AutocompleteRequest struct {
+	Query string `form:"q"`
+	Limit int    `form:"limit"`
+}
@@ -0,0 +1,14 @@
+package dto
+
+type CreateCategoryRequest struct {
+	Name     string  `json:"name" validate:"required,max=100"`
+	Slug     string  `json:"slug" validate:"required,max=100"`
+	ParentID *string `json:"parent_id,omitempty"`
+}
+
+type UpdateCategoryRequest struct {
+	ID       string  `json:"-" validate:"required"`
+	Name     string  `json:"name" validate:"required,max=100"`
+	Slug     string  `json:"slug" validate:"required,max=100"`
+	ParentID *string `json:"parent_id,omitempty"`
+}
@@ -4,15 +4,18 @@ import (
 	"ecommerce_clean/pkgs/paging"
 )
 
+// ListProductRequest lists products. Search matches part of the product name,
+// ignoring case.
 type ListProductRequest struct {
-	Search    string `json:"search,omitempty" form:"search"`
-	Page      int64  `json:"-" form:"page"`
-	Limit     int64  `json:"-" form:"size"`
-	OrderBy   string `json:"-" form:"order_by"`
-	OrderDesc bool   `json:"-" form:"order_desc"`
-	TakeAll   bool   `json:"-" form:"take_all"`
+	Search     string `json:"search,omitempty" form:"search"`
+	CategoryID string `json:"category_id,omitempty" form:"category_id"`
+	Page       int64  `json:"-" form:"page"`
+	Limit      int64  `json:"-" form:"size"`
+	OrderBy    string `json:"-" form:"order_by"`
+	OrderDesc  bool   `json:"-" form:"order_desc"`
+	TakeAll    bool   `json:"-" form:"take_all"`
 }
 type ListProductResponse struct {
-	Products   []*Product         `json:"items"`
+	Products   []*ProductResponse `json:"items"`
 	Pagination *paging.Pagination `json:"metadata"`
 }
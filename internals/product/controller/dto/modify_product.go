@@ -3,10 +3,12 @@ package dto
 import "mime/multipart"
 
 type CreateProductRequest struct {
-	Name        string                `form:"name" binding:"required"`
-	Description string                `form:"description" binding:"required"`
-	Image       *multipart.FileHeader `form:"image" binding:"required" swaggerignore:"true"`
-	Price       float64               `form:"price" binding:"gt=0"`
+	Name        string                `json:"name" form:"name" binding:"required" validate:"required"`
+	Description string                `json:"description" form:"description" binding:"required" validate:"required"`
+	Image       *multipart.FileHeader `json:"-" form:"image" binding:"required" swaggerignore:"true"`
+	Price       float64               `json:"price" form:"price" binding:"gt=0" validate:"gt=0"`
+	CategoryID  *string               `json:"category_id,omitempty" form:"category_id"`
+	Tags        []string              `json:"tags,omitempty" form:"tags"`
 }
 
 type UpdateProductRequest struct {
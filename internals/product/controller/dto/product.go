@@ -1,15 +0,0 @@
-package dto
-
-import "time"
-
-type Product struct {
-	ID          string    `json:"id"`
-	Code        string    `json:"code"`
-	Name        string    `json:"name"`
-	ImageUrl    string    `json:"image_url"`
-	Description string    `json:"description"`
-	Price       float64   `json:"price"`
-	Active      bool      `json:"active"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
-}
@@ -0,0 +1,58 @@
+package dto
+
+import (
+	"time"
+
+	"ecommerce_clean/internals/product/entity"
+)
+
+type ProductResponse struct {
+	ID           string    `json:"id"`
+	Name         string    `json:"name"`
+	Price        float64   `json:"price"`
+	Stock        int       `json:"stock"`
+	ImageURL     string    `json:"image_url"`
+	Images       []string  `json:"images"`
+	CategoryID   *string   `json:"category_id"`
+	CategoryName string    `json:"category_name"`
+	Tags         []string  `json:"tags"`
+	IsActive     bool      `json:"is_active"`
+	IsFeatured   bool      `json:"is_featured"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// FromProduct builds the API representation of a product. cat may be nil when
+// the product has no category or it could not be loaded.
+func FromProduct(p *entity.Product, cat *entity.Category) *ProductResponse {
+	if p == nil {
+		return nil
+	}
+
+	res := &ProductResponse{
+		ID:         p.ID,
+		Name:       p.Name,
+		Price:      p.Price,
+		Stock:      p.Stock,
+		ImageURL:   p.GetPrimaryImage(),
+		Images:     p.Images,
+		CategoryID: p.CategoryID,
+		Tags:       p.Tags,
+		IsActive:   p.Active,
+		IsFeatured: p.Featured,
+		CreatedAt:  p.CreatedAt,
+	}
+	if cat != nil {
+		res.CategoryName = cat.Name
+	}
+
+	return res
+}
+
+func FromProducts(products []*entity.Product) []*ProductResponse {
+	res := make([]*ProductResponse, 0, len(products))
+	for _, p := range products {
+		res = append(res, FromProduct(p, p.Category))
+	}
+
+	return res
+}
@@ -0,0 +1,60 @@
+package dto_test
+
+import (
+	"testing"
+	"time"
+
+	"ecommerce_clean/internals/product/controller/dto"
+	productEntity "ecommerce_clean/internals/product/entity"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// -------------------------------------
+// Tests de FromProduct
+// -------------------------------------
+
+// TestFromProduct_NilCategory verifica que sin categoría el CategoryName
+// queda vacío y el resto de campos se copian del producto.
+func TestFromProduct_NilCategory(t *testing.T) {
+	createdAt := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	p := &productEntity.Product{
+		ID:        "p1",
+		Name:      "Mango",
+		Price:     2.5,
+		Stock:     7,
+		Images:    []string{"https://cdn/mango.png", "https://cdn/mango-2.png"},
+		Tags:      []string{"fruta"},
+		Active:    true,
+		CreatedAt: createdAt,
+	}
+
+	res := dto.FromProduct(p, nil)
+
+	assert.Equal(t, "p1", res.ID)
+	assert.Equal(t, "Mango", res.Name)
+	assert.Equal(t, 2.5, res.Price)
+	assert.Equal(t, 7, res.Stock)
+	assert.Equal(t, "https://cdn/mango.png", res.ImageURL)
+	assert.Len(t, res.Images, 2)
+	assert.Nil(t, res.CategoryID)
+	assert.Empty(t, res.CategoryName)
+	assert.Equal(t, []string{"fruta"}, res.Tags)
+	assert.True(t, res.IsActive)
+	assert.False(t, res.IsFeatured)
+	assert.Equal(t, createdAt, res.CreatedAt)
+}
+
+// TestFromProduct_WithCategory verifica que el nombre de la categoría se
+// incluye en la respuesta.
+func TestFromProduct_WithCategory(t *testing.T) {
+	catID := "c1"
+	p := &productEntity.Product{ID: "p1", Name: "Mango", CategoryID: &catID, Featured: true}
+	cat := &productEntity.Category{ID: "c1", Name: "Frutas", Slug: "frutas"}
+
+	res := dto.FromProduct(p, cat)
+
+	assert.Equal(t, &catID, res.CategoryID)
+	assert.Equal(t, "Frutas", res.CategoryName)
+	assert.True(t, res.IsFeatured)
+}
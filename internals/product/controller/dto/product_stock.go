@@ -0,0 +1,6 @@
+package dto
+
+type ProductStockResponse struct {
+	ProductID string `json:"productID"`
+	Stock     int    `json:"stock"`
+}
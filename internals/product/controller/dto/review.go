@@ -0,0 +1,8 @@
+package dto
+
+type SubmitReviewRequest struct {
+	ProductID string `json:"-" validate:"required"`
+	UserID    string `json:"-" validate:"required"`
+	Rating    int    `json:"rating" binding:"min=1,max=5" validate:"min=1,max=5"`
+	Comment   string `json:"comment" binding:"max=1000" validate:"max=1000"`
+}
@@ -1,6 +1,7 @@
 package http
 
 import (
+	"context"
 	"ecommerce_clean/configs"
 	"ecommerce_clean/internals/product/controller/dto"
 	"ecommerce_clean/internals/product/entity"
@@ -9,25 +10,35 @@ import (
 	"ecommerce_clean/pkgs/redis"
 	"ecommerce_clean/pkgs/response"
 	"ecommerce_clean/utils"
+	"errors"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
+// ProductViewRecorder is told whenever a user views a product's details.
+type ProductViewRecorder interface {
+	RecordProductView(ctx context.Context, userID, productID string) error
+}
+
 type ProductHandler struct {
 	usecase usecase.IProductUseCase
 	cache   redis.IRedis
+	views   ProductViewRecorder
 }
 
-func NewProductHandler(usecase usecase.IProductUseCase, cache redis.IRedis) *ProductHandler {
-	return &ProductHandler{usecase: usecase, cache: cache}
+// NewProductHandler builds the product handler. views may be nil, in which
+// case product views are not recorded.
+func NewProductHandler(usecase usecase.IProductUseCase, cache redis.IRedis, views ProductViewRecorder) *ProductHandler {
+	return &ProductHandler{usecase: usecase, cache: cache, views: views}
 }
 
 // @Summary			Retrieve a list of products
 // @Description		Fetches a paginated list of products based on the provided filter parameters.
 // @Tags			Products
 // @Produce			json
-// @Param			search		query	string	false	"Search keyword for products"
+// @Param			search		query	string	false	"Case-insensitive partial name match (at least 2 characters)"
 // @Param			page		query	int		false	"Page number (default: 1)"
 // @Param			size		query	int		false	"Number of items per page (default: 10)"
 // @Param			order_by	query	string	false	"Field to sort by"
@@ -58,16 +69,57 @@ func (h *ProductHandler) GetProducts(c *gin.Context) {
 	products, pagination, err := h.usecase.ListProducts(c, &req)
 	if err != nil {
 		logger.Error("Failed to get products", err)
+		if errors.Is(err, usecase.ErrQueryTooShort) {
+			response.Error(c, http.StatusBadRequest, err, "Invalid parameters")
+			return
+		}
 		response.Error(c, http.StatusInternalServerError, err, "Failed to get products")
 		return
 	}
 
-	utils.MapStruct(&res.Products, products)
+	res.Products = dto.FromProducts(products)
 	res.Pagination = pagination
 	response.JSON(c, http.StatusOK, res)
 	_ = h.cache.SetWithExpiration(cacheKey, res, configs.ProductCachingTime)
 }
 
+// @Summary			Autocomplete products by name
+// @Description		Returns a few products whose name starts with the query, for type-ahead search.
+// @Tags			Products
+// @Produce			json
+// @Param			q		query	string	true	"Name prefix"
+// @Param			limit	query	int		false	"Maximum number of results (default: 5, max: 20)"
+// @Success			200		{object}	response.Response	"Matching products, possibly empty"
+// @Failure			400		{object}	response.Response	"Bad Request - Invalid query parameters"
+// @Failure			500		{object}	response.Response	"Internal Server Error - An error occurred while processing the request"
+// @Router			/products/search [get]
+// @Security		ApiKeyAuth
+func (h *ProductHandler) Autocomplete(c *gin.Context) {
+	var req dto.AutocompleteRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		logger.Error("Failed to get query", err)
+		response.Error(c, http.StatusBadRequest, err, "Invalid parameters")
+		return
+	}
+
+	var res []*entity.AutocompleteResult
+	cacheKey := c.Request.URL.RequestURI()
+	if err := h.cache.Get(cacheKey, &res); err == nil {
+		response.JSON(c, http.StatusOK, res)
+		return
+	}
+
+	res, err := h.usecase.Autocomplete(c, req.Query, req.Limit)
+	if err != nil {
+		logger.Error("Failed to autocomplete products", err)
+		response.Error(c, http.StatusInternalServerError, err, "Something went wrong")
+		return
+	}
+
+	response.JSON(c, http.StatusOK, res)
+	_ = h.cache.SetWithExpiration(cacheKey, res, configs.ProductCachingTime)
+}
+
 // @Summary			Retrieve a product by its ID
 // @Description		Fetches the details of a specific product based on the provided product ID.
 // @Tags			Products
@@ -82,7 +134,10 @@ func (h *ProductHandler) GetProducts(c *gin.Context) {
 // @Router			/products/{id} [get]
 // @Security		ApiKeyAuth
 func (h *ProductHandler) GetProduct(c *gin.Context) {
-	var res entity.Product
+	var res dto.ProductResponse
+
+	productId := c.Param("id")
+	h.recordView(c, productId)
 
 	cacheKey := c.Request.URL.RequestURI()
 	err := h.cache.Get(cacheKey, &res)
@@ -91,8 +146,6 @@ func (h *ProductHandler) GetProduct(c *gin.Context) {
 		return
 	}
 
-	productId := c.Param("id")
-
 	product, err := h.usecase.GetProductById(c, productId)
 	if err != nil {
 		logger.Error("Failed to get product detail: ", err)
@@ -107,11 +160,52 @@ func (h *ProductHandler) GetProduct(c *gin.Context) {
 		return
 	}
 
-	utils.MapStruct(&res, product)
+	res = *dto.FromProduct(product, product.Category)
 	response.JSON(c, http.StatusOK, res)
 	_ = h.cache.SetWithExpiration(cacheKey, res, configs.ProductCachingTime)
 }
 
+// recordView records the view for the signed in user. It is best effort: a
+// failure is logged and does not affect the response.
+func (h *ProductHandler) recordView(c *gin.Context, productID string) {
+	userID := c.GetString("userId")
+	if h.views == nil || userID == "" {
+		return
+	}
+
+	if err := h.views.RecordProductView(c, userID, productID); err != nil {
+		logger.Errorf("Failed to record product view, product: %s, error: %s", productID, err)
+	}
+}
+
+// @Summary			Retrieve product stock
+// @Description		Returns only the stock of a product, for clients that do not need the full product.
+// @Tags			Products
+// @Produce			json
+// @Param			id	path	string	true	"Product ID"
+// @Success			200	{object}	dto.ProductStockResponse	"Successfully retrieved the product stock"
+// @Failure			401	{object}	response.Response	"Unauthorized - User not authenticated"
+// @Failure			404	{object}	response.Response	"Not Found - Product with the specified ID not found"
+// @Failure			500	{object}	response.Response	"Internal Server Error - An error occurred while processing the request"
+// @Router			/products/{id}/stock [get]
+// @Security		ApiKeyAuth
+func (h *ProductHandler) GetProductStock(c *gin.Context) {
+	productId := c.Param("id")
+
+	stock, err := h.usecase.GetProductStock(c, productId)
+	if err != nil {
+		logger.Error("Failed to get product stock: ", err)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			response.Error(c, http.StatusNotFound, err, "Not found")
+			return
+		}
+		response.Error(c, http.StatusInternalServerError, err, "Something went wrong")
+		return
+	}
+
+	response.JSON(c, http.StatusOK, dto.ProductStockResponse{ProductID: productId, Stock: stock})
+}
+
 // @Summary			Create a new product
 // @Description		Creates a new product based on the provided details.
 // @Tags			Products
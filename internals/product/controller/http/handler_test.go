@@ -0,0 +1,251 @@
+package http_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	productHttp "ecommerce_clean/internals/product/controller/http"
+	productEntity "ecommerce_clean/internals/product/entity"
+	"ecommerce_clean/internals/product/usecase"
+	"ecommerce_clean/pkgs/logger"
+	"ecommerce_clean/pkgs/redis"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"gorm.io/gorm"
+)
+
+// -------------------
+// Mocks
+// -------------------
+
+// MockProductUseCase solo implementa los métodos usados en estos tests; el
+// resto queda cubierto por la interfaz embebida.
+type MockProductUseCase struct {
+	usecase.IProductUseCase
+	mock.Mock
+}
+
+func (m *MockProductUseCase) GetProductStock(ctx context.Context, productID string) (int, error) {
+	args := m.Called(productID)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockProductUseCase) Autocomplete(ctx context.Context, query string, limit int) ([]*productEntity.AutocompleteResult, error) {
+	args := m.Called(query, limit)
+	if v := args.Get(0); v != nil {
+		return v.([]*productEntity.AutocompleteResult), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+// memoryCache guarda los valores serializados en memoria, igual que Redis.
+type memoryCache struct {
+	redis.IRedis
+	values map[string][]byte
+}
+
+func newMemoryCache() *memoryCache {
+	return &memoryCache{values: make(map[string][]byte)}
+}
+
+func (c *memoryCache) Get(key string, value interface{}) error {
+	data, ok := c.values[key]
+	if !ok {
+		return errors.New("cache miss")
+	}
+	return json.Unmarshal(data, value)
+}
+
+func (c *memoryCache) SetWithExpiration(key string, value interface{}, expiration time.Duration) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	c.values[key] = data
+	return nil
+}
+
+// MockProductViewRecorder registra las vistas de producto recibidas.
+type MockProductViewRecorder struct {
+	mock.Mock
+}
+
+func (m *MockProductViewRecorder) RecordProductView(ctx context.Context, userID, productID string) error {
+	args := m.Called(userID, productID)
+	return args.Error(0)
+}
+
+func TestMain(m *testing.M) {
+	logger.Initialize("test")
+	gin.SetMode(gin.TestMode)
+	os.Exit(m.Run())
+}
+
+func performStockRequest(uc usecase.IProductUseCase, productID string) *httptest.ResponseRecorder {
+	handler := productHttp.NewProductHandler(uc, nil, nil)
+	router := gin.New()
+	router.GET("/products/:id/stock", handler.GetProductStock)
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/products/"+productID+"/stock", nil)
+	router.ServeHTTP(w, req)
+	return w
+}
+
+// -------------------------------------
+// Tests de GetProductStock
+// -------------------------------------
+
+// TestGetProductStock_Found verifica que se devuelve el stock del producto.
+func TestGetProductStock_Found(t *testing.T) {
+	uc := new(MockProductUseCase)
+	uc.On("GetProductStock", "p1").Return(5, nil)
+
+	w := performStockRequest(uc, "p1")
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"data":{"productID":"p1","stock":5},"error":null}`, w.Body.String())
+}
+
+// TestGetProductStock_ZeroStock verifica que un stock de cero se devuelve como
+// 200 y no como not found.
+func TestGetProductStock_ZeroStock(t *testing.T) {
+	uc := new(MockProductUseCase)
+	uc.On("GetProductStock", "p1").Return(0, nil)
+
+	w := performStockRequest(uc, "p1")
+
+	var body struct {
+		Data struct {
+			ProductID string `json:"productID"`
+			Stock     int    `json:"stock"`
+		} `json:"data"`
+	}
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	assert.Equal(t, "p1", body.Data.ProductID)
+	assert.Equal(t, 0, body.Data.Stock)
+}
+
+// TestGetProductStock_NotFound verifica que un producto inexistente devuelve
+// 404.
+func TestGetProductStock_NotFound(t *testing.T) {
+	uc := new(MockProductUseCase)
+	uc.On("GetProductStock", "missing").Return(0, gorm.ErrRecordNotFound)
+
+	w := performStockRequest(uc, "missing")
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func performSearchRequest(uc usecase.IProductUseCase, cache redis.IRedis, query string) *httptest.ResponseRecorder {
+	handler := productHttp.NewProductHandler(uc, cache, nil)
+	router := gin.New()
+	router.GET("/products/search", handler.Autocomplete)
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/products/search?"+query, nil)
+	router.ServeHTTP(w, req)
+	return w
+}
+
+// -------------------------------------
+// Tests de Autocomplete
+// -------------------------------------
+
+// TestAutocomplete_Matches verifica que se devuelven las sugerencias con los
+// parámetros q y limit de la query.
+func TestAutocomplete_Matches(t *testing.T) {
+	uc := new(MockProductUseCase)
+	uc.On("Autocomplete", "man", 3).Return([]*productEntity.AutocompleteResult{
+		{ID: "p1", Name: "Mango", Price: 2.5, ImageURL: "https://cdn.example.com/mango.png"},
+	}, nil)
+
+	w := performSearchRequest(uc, newMemoryCache(), "q=man&limit=3")
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"data":[{"id":"p1","name":"Mango","price":2.5,"image_url":"https://cdn.example.com/mango.png"}],"error":null}`, w.Body.String())
+}
+
+// TestAutocomplete_NoMatches verifica que sin coincidencias se responde 200 con
+// un array vacío y no 404.
+func TestAutocomplete_NoMatches(t *testing.T) {
+	uc := new(MockProductUseCase)
+	uc.On("Autocomplete", "zzz", 0).Return([]*productEntity.AutocompleteResult{}, nil)
+
+	w := performSearchRequest(uc, newMemoryCache(), "q=zzz")
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"data":[],"error":null}`, w.Body.String())
+}
+
+// TestAutocomplete_Cached verifica que una segunda petición igual se sirve
+// desde la caché sin volver a llamar al caso de uso.
+func TestAutocomplete_Cached(t *testing.T) {
+	uc := new(MockProductUseCase)
+	uc.On("Autocomplete", "man", 0).Return([]*productEntity.AutocompleteResult{{ID: "p1", Name: "Mango"}}, nil).Once()
+	cache := newMemoryCache()
+
+	first := performSearchRequest(uc, cache, "q=man")
+	second := performSearchRequest(uc, cache, "q=man")
+
+	assert.Equal(t, http.StatusOK, second.Code)
+	assert.JSONEq(t, first.Body.String(), second.Body.String())
+	uc.AssertNumberOfCalls(t, "Autocomplete", 1)
+}
+
+// TestAutocomplete_BadLimit verifica que un limit no numérico devuelve 400.
+func TestAutocomplete_BadLimit(t *testing.T) {
+	uc := new(MockProductUseCase)
+
+	w := performSearchRequest(uc, newMemoryCache(), "q=man&limit=abc")
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	uc.AssertNotCalled(t, "Autocomplete", mock.Anything, mock.Anything)
+}
+
+// TestAutocomplete_Error verifica que un fallo del caso de uso devuelve 500 y
+// no se guarda en caché.
+func TestAutocomplete_Error(t *testing.T) {
+	uc := new(MockProductUseCase)
+	uc.On("Autocomplete", "man", 0).Return(nil, errors.New("db down"))
+	cache := newMemoryCache()
+
+	w := performSearchRequest(uc, cache, "q=man")
+
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.Empty(t, cache.values)
+}
+
+// -------------------------------------
+// Tests de GetProduct
+// -------------------------------------
+
+// TestGetProduct_RecordsView verifica que ver un producto lo registra para el
+// usuario, también cuando la respuesta sale de la caché, y que un fallo al
+// registrarlo no afecta a la respuesta.
+func TestGetProduct_RecordsView(t *testing.T) {
+	uc := new(MockProductUseCase)
+	views := new(MockProductViewRecorder)
+	views.On("RecordProductView", "u1", "p1").Return(errors.New("db down"))
+	cache := newMemoryCache()
+	cache.values["/products/p1"] = []byte(`{"id":"p1","name":"Mango"}`)
+
+	handler := productHttp.NewProductHandler(uc, cache, views)
+	router := gin.New()
+	router.GET("/products/:id", func(c *gin.Context) { c.Set("userId", "u1") }, handler.GetProduct)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/products/p1", nil))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	views.AssertExpectations(t)
+}
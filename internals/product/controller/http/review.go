@@ -0,0 +1,94 @@
+package http
+
+import (
+	"ecommerce_clean/internals/product/controller/dto"
+	"ecommerce_clean/internals/product/usecase"
+	"ecommerce_clean/pkgs/logger"
+	"ecommerce_clean/pkgs/response"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type ReviewController struct {
+	usecase usecase.IReviewUseCase
+}
+
+func NewReviewController(usecase usecase.IReviewUseCase) *ReviewController {
+	return &ReviewController{
+		usecase: usecase,
+	}
+}
+
+// @Summary			Review a product
+// @Description		Stores the authenticated user's rating of a product. A user can review a product only once.
+// @Tags			Products
+// @Accept			json
+// @Produce			json
+// @Security		ApiKeyAuth
+// @Param			id		path		string					true	"Product ID"
+// @Param			_		body		dto.SubmitReviewRequest	true	"Rating (1-5) and optional comment"
+// @Success			201		{object}	entity.Review			"Review created"
+// @Failure			400		{object}	response.Response		"Bad Request - Invalid parameters"
+// @Failure			401		{object}	response.Response		"Unauthorized - User not authenticated"
+// @Failure			404		{object}	response.Response		"Not Found - Product does not exist"
+// @Failure			409		{object}	response.Response		"Conflict - Product already reviewed by the user"
+// @Failure			500		{object}	response.Response		"Internal Server Error - An error occurred while processing the request"
+// @Router			/products/{id}/reviews [post]
+func (a *ReviewController) SubmitReview(c *gin.Context) {
+	userId := c.GetString("userId")
+	if userId == "" {
+		response.Error(c, http.StatusUnauthorized, errors.New("unauthorized"), "Unauthorized")
+		return
+	}
+
+	var req dto.SubmitReviewRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.Error("Failed to get body", err)
+		response.Error(c, http.StatusBadRequest, err, "Invalid parameters")
+		return
+	}
+	req.ProductID = c.Param("id")
+	req.UserID = userId
+
+	review, err := a.usecase.SubmitReview(c, &req)
+	if err != nil {
+		logger.Errorf("Failed to submit review, product id: %s, error: %s", req.ProductID, err)
+		switch {
+		case errors.Is(err, usecase.ErrAlreadyReviewed):
+			response.Error(c, http.StatusConflict, err, "Product already reviewed")
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			response.Error(c, http.StatusNotFound, err, "Not found")
+		default:
+			response.Error(c, http.StatusInternalServerError, err, "Something went wrong")
+		}
+		return
+	}
+
+	response.JSON(c, http.StatusCreated, review)
+}
+
+// @Summary			Product review summary
+// @Description		Returns the average rating, review count and rating distribution of a product.
+// @Tags			Products
+// @Produce			json
+// @Security		ApiKeyAuth
+// @Param			id	path		string					true	"Product ID"
+// @Success			200	{object}	entity.ReviewSummary	"Review summary"
+// @Failure			401	{object}	response.Response		"Unauthorized - User not authenticated"
+// @Failure			500	{object}	response.Response		"Internal Server Error - An error occurred while processing the request"
+// @Router			/products/{id}/reviews/summary [get]
+func (a *ReviewController) GetReviewSummary(c *gin.Context) {
+	productId := c.Param("id")
+
+	summary, err := a.usecase.GetProductReviewSummary(c, productId)
+	if err != nil {
+		logger.Errorf("Failed to get review summary, product id: %s, error: %s", productId, err)
+		response.Error(c, http.StatusInternalServerError, err, "Something went wrong")
+		return
+	}
+
+	response.JSON(c, http.StatusOK, summary)
+}
@@ -11,6 +11,9 @@ import (
 	"ecommerce_clean/pkgs/validation"
 
 	"github.com/gin-gonic/gin"
+
+	cartRepo "ecommerce_clean/internals/cart/repository"
+	cartUseCase "ecommerce_clean/internals/cart/usecase"
 )
 
 func Routes(
@@ -22,15 +25,24 @@ func Routes(
 	token token.IMarker,
 ) {
 	productRepository := repository.NewProductRepository(sqlDB)
-	productUseCase := usecase.NewProductUseCase(validator, productRepository, minioClient)
-	productHandler := NewProductHandler(productUseCase, cache)
+	productTemplateRepository := repository.NewProductTemplateRepository(sqlDB)
+	categoryRepository := repository.NewCategoryRepository(sqlDB)
+	productUseCase := usecase.NewProductUseCase(validator, productRepository, minioClient, productTemplateRepository, categoryRepository)
+	productViewRecorder := cartUseCase.NewProductViewRecorder(cartRepo.NewCartRepository(sqlDB))
+	productHandler := NewProductHandler(productUseCase, cache, productViewRecorder)
+	reviewUseCase := usecase.NewReviewUseCase(validator, repository.NewReviewRepository(sqlDB), productRepository)
+	reviewController := NewReviewController(reviewUseCase)
 
 	authMiddleware := middlewares.NewAuthMiddleware(token, cache).TokenAuth()
 
 	productRoute := r.Group("/products").Use(authMiddleware)
 	{
 		productRoute.GET("", productHandler.GetProducts)
+		productRoute.GET("/search", productHandler.Autocomplete)
 		productRoute.GET("/:id", productHandler.GetProduct)
+		productRoute.GET("/:id/stock", productHandler.GetProductStock)
+		productRoute.POST("/:id/reviews", reviewController.SubmitReview)
+		productRoute.GET("/:id/reviews/summary", reviewController.GetReviewSummary)
 		productRoute.POST("", middlewares.AuthorizePolicy("products", "write"), productHandler.CreateProduct)
 		productRoute.PUT("/:id", middlewares.AuthorizePolicy("products", "write"), productHandler.UpdateProduct)
 		productRoute.DELETE("/:id", middlewares.AuthorizePolicy("products", "delete"), productHandler.DeleteProduct)
@@ -0,0 +1,8 @@
+package entity
+
+type AutocompleteResult struct {
+	ID       string  `json:"id"`
+	Name     string  `json:"name"`
+	Price    float64 `json:"price"`
+	ImageURL string  `json:"image_url"`
+}
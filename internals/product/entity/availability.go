@@ -0,0 +1,8 @@
+package entity
+
+type AvailabilityResult struct {
+	ProductID    string `json:"product_id"`
+	RequestedQty int    `json:"requested_qty"`
+	AvailableQty int    `json:"available_qty"`
+	IsAvailable  bool   `json:"is_available"`
+}
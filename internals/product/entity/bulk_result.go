@@ -0,0 +1,6 @@
+package entity
+
+type BulkResult struct {
+	Requested int   `json:"requested"`
+	Affected  int64 `json:"affected"`
+}
@@ -0,0 +1,9 @@
+package entity
+
+type BundlePrice struct {
+	Products        []*Product `json:"products"`
+	IndividualTotal float64    `json:"individual_total"`
+	BundleDiscount  float64    `json:"bundle_discount"`
+	BundleTotal     float64    `json:"bundle_total"`
+	DiscountPercent float64    `json:"discount_percent"`
+}
@@ -0,0 +1,12 @@
+package entity
+
+type CatalogCloneResult struct {
+	Cloned  int          `json:"cloned"`
+	Skipped int          `json:"skipped"`
+	Errors  []CloneError `json:"errors"`
+}
+
+type CloneError struct {
+	ProductID string `json:"product_id"`
+	Message   string `json:"message"`
+}
@@ -0,0 +1,27 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type Category struct {
+	ID        string          `json:"id" gorm:"unique;not null;index;primary_key"`
+	Name      string          `json:"name" gorm:"not null"`
+	Slug      string          `json:"slug" gorm:"uniqueIndex:unique_category_slug;not null"`
+	ParentID  *string         `json:"parent_id" gorm:"index"`
+	CreatedAt time.Time       `json:"created_at"`
+	UpdatedAt time.Time       `json:"updated_at"`
+	DeletedAt *gorm.DeletedAt `json:"deleted_at" gorm:"index"`
+}
+
+func (m *Category) BeforeCreate(tx *gorm.DB) error {
+	m.ID = uuid.New().String()
+	return nil
+}
+
+func (m *Category) TableName() string {
+	return "categories"
+}
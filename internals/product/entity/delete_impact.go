@@ -0,0 +1,7 @@
+package entity
+
+type DeleteImpact struct {
+	ActiveCartLines int  `json:"active_cart_lines"`
+	OpenOrders      int  `json:"open_orders"`
+	CanDelete       bool `json:"can_delete"`
+}
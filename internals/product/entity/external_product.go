@@ -0,0 +1,18 @@
+package entity
+
+// ExternalProduct is a product as provided by a third-party supplier catalog.
+type ExternalProduct struct {
+	ExternalID  string
+	Name        string
+	Description string
+	ImageUrl    string
+	Price       float64
+	Stock       int
+}
+
+type SyncReport struct {
+	Created int `json:"created"`
+	Updated int `json:"updated"`
+	Deleted int `json:"deleted"`
+	Errors  int `json:"errors"`
+}
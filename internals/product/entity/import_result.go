@@ -0,0 +1,12 @@
+package entity
+
+type ImportResult struct {
+	Total    int           `json:"total"`
+	Imported int           `json:"imported"`
+	Errors   []ImportError `json:"errors"`
+}
+
+type ImportError struct {
+	Index   int    `json:"index"`
+	Message string `json:"message"`
+}
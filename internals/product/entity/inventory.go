@@ -0,0 +1,10 @@
+package entity
+
+type InventoryItem struct {
+	ProductID      string `json:"product_id"`
+	Name           string `json:"name"`
+	Stock          int    `json:"stock"`
+	ReservedStock  int    `json:"reserved_stock"`
+	AvailableStock int    `json:"available_stock"`
+	CategoryName   string `json:"category_name"`
+}
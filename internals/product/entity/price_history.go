@@ -0,0 +1,25 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type PriceHistory struct {
+	ID        string    `json:"id" gorm:"unique;not null;index;primary_key"`
+	ProductID string    `json:"product_id" gorm:"not null;index"`
+	OldPrice  float64   `json:"old_price"`
+	NewPrice  float64   `json:"new_price"`
+	ChangedAt time.Time `json:"changed_at" gorm:"index"`
+}
+
+func (m *PriceHistory) BeforeCreate(tx *gorm.DB) error {
+	m.ID = uuid.New().String()
+	return nil
+}
+
+func (m *PriceHistory) TableName() string {
+	return "product_price_histories"
+}
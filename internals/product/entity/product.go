@@ -10,25 +10,50 @@ import (
 )
 
 type Product struct {
-	ID          string          `json:"id" gorm:"unique;not null;index;primary_key"`
-	Code        string          `json:"code" gorm:"uniqueIndex:unique_product_code,not null"`
-	Name        string          `json:"name" gorm:"uniqueIndex:unique_product_name,not null"`
-	ImageUrl    string          `json:"image_url" gorm:"unique:unique_product_image,not null"`
-	Description string          `json:"description"`
-	Price       float64         `json:"price"`
-	Active      bool            `json:"active" gorm:"default:true"`
-	CreatedAt   time.Time       `json:"created_at"`
-	UpdatedAt   time.Time       `json:"updated_at"`
-	DeletedAt   *gorm.DeletedAt `json:"deleted_at" gorm:"index"`
+	ID          string     `json:"id" gorm:"unique;not null;index;primary_key"`
+	Code        string     `json:"code" gorm:"uniqueIndex:unique_product_code,not null"`
+	Name        string     `json:"name" gorm:"uniqueIndex:unique_product_name,not null"`
+	Images      []string   `json:"images" gorm:"serializer:json"`
+	Description string     `json:"description"`
+	Price       float64    `json:"price"`
+	SalePrice   *float64   `json:"sale_price"`
+	Stock       int        `json:"stock" gorm:"default:0"`
+	Weight      float64    `json:"weight" gorm:"default:0"`
+	CategoryID  *string    `json:"category_id" gorm:"index"`
+	SupplierID  *string    `json:"supplier_id" gorm:"index"`
+	Category    *Category  `json:"category,omitempty"`
+	Tags        []string   `json:"tags" gorm:"serializer:json"`
+	Active      bool       `json:"active" gorm:"default:true"`
+	Featured    bool       `json:"featured" gorm:"default:false"`
+	ExternalID  string     `json:"external_id" gorm:"index"`
+	Barcode     string     `json:"barcode" gorm:"size:50;uniqueIndex:unique_product_barcode,where:barcode <> ''"`
+	ExpiresAt   *time.Time `json:"expires_at" gorm:"index"`
+	// StockLastUpdatedAt is stamped on create and on every stock change.
+	StockLastUpdatedAt time.Time `json:"stock_last_updated_at"`
+	// DaysOutOfStock is only filled in by out of stock listings.
+	DaysOutOfStock int             `json:"days_out_of_stock,omitempty" gorm:"-"`
+	CreatedAt      time.Time       `json:"created_at"`
+	UpdatedAt      time.Time       `json:"updated_at"`
+	DeletedAt      *gorm.DeletedAt `json:"deleted_at" gorm:"index"`
 }
 
 func (m *Product) BeforeCreate(tx *gorm.DB) error {
 	m.ID = uuid.New().String()
 	m.Code = utils.GenerateCode("P")
 	m.Active = true
+	m.StockLastUpdatedAt = time.Now()
 	return nil
 }
 
+// GetPrimaryImage returns the first image URL, or "" when the product has none.
+func (m *Product) GetPrimaryImage() string {
+	if len(m.Images) == 0 {
+		return ""
+	}
+
+	return m.Images[0]
+}
+
 func (m *Product) TableName() string {
 	return "products"
 }
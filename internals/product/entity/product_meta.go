@@ -0,0 +1,8 @@
+package entity
+
+type ProductMeta struct {
+	TotalSold     int     `json:"total_sold"`
+	TotalRevenue  float64 `json:"total_revenue"`
+	AverageRating float64 `json:"average_rating"`
+	ReviewCount   int     `json:"review_count"`
+}
@@ -0,0 +1,28 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type ProductTemplate struct {
+	ID           string          `json:"id" gorm:"unique;not null;index;primary_key"`
+	Name         string          `json:"name" gorm:"not null"`
+	CategoryID   *string         `json:"category_id" gorm:"index"`
+	DefaultPrice float64         `json:"default_price"`
+	DefaultTags  []string        `json:"default_tags" gorm:"serializer:json"`
+	CreatedAt    time.Time       `json:"created_at"`
+	UpdatedAt    time.Time       `json:"updated_at"`
+	DeletedAt    *gorm.DeletedAt `json:"deleted_at" gorm:"index"`
+}
+
+func (m *ProductTemplate) BeforeCreate(tx *gorm.DB) error {
+	m.ID = uuid.New().String()
+	return nil
+}
+
+func (m *ProductTemplate) TableName() string {
+	return "product_templates"
+}
@@ -0,0 +1,39 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Review is a user's rating of a product. A user has at most one review per
+// product that is not deleted, so a deleted review can be written again.
+type Review struct {
+	ID        string          `json:"id" gorm:"unique;not null;index;primary_key"`
+	ProductID string          `json:"product_id" gorm:"not null;uniqueIndex:unique_product_review_user,where:deleted_at IS NULL"`
+	UserID    string          `json:"user_id" gorm:"not null;uniqueIndex:unique_product_review_user,where:deleted_at IS NULL"`
+	Rating    int             `json:"rating"`
+	Comment   string          `json:"comment"`
+	CreatedAt time.Time       `json:"created_at"`
+	UpdatedAt time.Time       `json:"updated_at"`
+	DeletedAt *gorm.DeletedAt `json:"deleted_at" gorm:"index"`
+}
+
+func (m *Review) BeforeCreate(tx *gorm.DB) error {
+	m.ID = uuid.New().String()
+	return nil
+}
+
+func (m *Review) TableName() string {
+	return "product_reviews"
+}
+
+// ReviewSummary aggregates the reviews of a product. RatingDistribution has an
+// entry for every rating from 1 to 5.
+type ReviewSummary struct {
+	ProductID          string      `json:"product_id"`
+	AverageRating      float64     `json:"average_rating"`
+	TotalReviews       int         `json:"total_reviews"`
+	RatingDistribution map[int]int `json:"rating_distribution"`
+}
@@ -0,0 +1,7 @@
+package entity
+
+type ProductWithSalePrice struct {
+	*Product
+	SavingsAmount  float64 `json:"savings_amount"`
+	SavingsPercent float64 `json:"savings_percent"`
+}
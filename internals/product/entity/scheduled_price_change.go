@@ -0,0 +1,27 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type ScheduledPriceChange struct {
+	ID          string     `json:"id" gorm:"unique;not null;index;primary_key"`
+	ProductID   string     `json:"product_id" gorm:"not null;index"`
+	NewPrice    float64    `json:"new_price"`
+	EffectiveAt time.Time  `json:"effective_at" gorm:"index"`
+	IsApplied   bool       `json:"is_applied" gorm:"default:false;index"`
+	AppliedAt   *time.Time `json:"applied_at"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+func (m *ScheduledPriceChange) BeforeCreate(tx *gorm.DB) error {
+	m.ID = uuid.New().String()
+	return nil
+}
+
+func (m *ScheduledPriceChange) TableName() string {
+	return "product_scheduled_price_changes"
+}
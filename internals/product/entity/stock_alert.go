@@ -0,0 +1,16 @@
+package entity
+
+const (
+	StockAlertOK       = "ok"
+	StockAlertLow      = "low"
+	StockAlertCritical = "critical"
+	StockAlertOut      = "out"
+)
+
+type StockAlert struct {
+	ProductID      string `json:"product_id"`
+	CurrentStock   int    `json:"current_stock"`
+	ReservedStock  int    `json:"reserved_stock"`
+	AvailableStock int    `json:"available_stock"`
+	AlertLevel     string `json:"alert_level"`
+}
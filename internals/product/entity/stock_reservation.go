@@ -0,0 +1,26 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type StockReservation struct {
+	ID        string          `json:"id" gorm:"unique;not null;index;primary_key"`
+	ProductID string          `json:"product_id" gorm:"not null;index"`
+	Quantity  int             `json:"quantity"`
+	CreatedAt time.Time       `json:"created_at"`
+	UpdatedAt time.Time       `json:"updated_at"`
+	DeletedAt *gorm.DeletedAt `json:"deleted_at" gorm:"index"`
+}
+
+func (m *StockReservation) BeforeCreate(tx *gorm.DB) error {
+	m.ID = uuid.New().String()
+	return nil
+}
+
+func (m *StockReservation) TableName() string {
+	return "stock_reservations"
+}
@@ -0,0 +1,27 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type Supplier struct {
+	ID           string          `json:"id" gorm:"unique;not null;index;primary_key"`
+	Name         string          `json:"name" gorm:"not null"`
+	ContactEmail string          `json:"contact_email"`
+	IsActive     bool            `json:"is_active" gorm:"default:true"`
+	CreatedAt    time.Time       `json:"created_at"`
+	UpdatedAt    time.Time       `json:"updated_at"`
+	DeletedAt    *gorm.DeletedAt `json:"deleted_at" gorm:"index"`
+}
+
+func (m *Supplier) BeforeCreate(tx *gorm.DB) error {
+	m.ID = uuid.New().String()
+	return nil
+}
+
+func (m *Supplier) TableName() string {
+	return "suppliers"
+}
@@ -0,0 +1,81 @@
+package repository
+
+import (
+	"context"
+	"ecommerce_clean/db"
+	"ecommerce_clean/internals/product/entity"
+)
+
+type ICategoryRepository interface {
+	GetBySlug(ctx context.Context, slug string) (*entity.Category, error)
+	GetByID(ctx context.Context, id string) (*entity.Category, error)
+	CreateCategory(ctx context.Context, category *entity.Category) error
+	ListCategories(ctx context.Context) ([]*entity.Category, error)
+	UpdateCategory(ctx context.Context, category *entity.Category) error
+	DeleteCategory(ctx context.Context, category *entity.Category) error
+	HasChildren(ctx context.Context, categoryID string) (bool, error)
+}
+
+type CategoryRepository struct {
+	db db.IDatabase
+}
+
+func NewCategoryRepository(db db.IDatabase) *CategoryRepository {
+	return &CategoryRepository{db: db}
+}
+
+func (r *CategoryRepository) GetBySlug(ctx context.Context, slug string) (*entity.Category, error) {
+	var category entity.Category
+	if err := r.db.FindOne(ctx, &category, db.WithQuery(db.NewQuery("slug = ?", slug))); err != nil {
+		return nil, err
+	}
+
+	return &category, nil
+}
+
+func (r *CategoryRepository) GetByID(ctx context.Context, id string) (*entity.Category, error) {
+	var category entity.Category
+	if err := r.db.FindById(ctx, id, &category); err != nil {
+		return nil, err
+	}
+
+	return &category, nil
+}
+
+func (r *CategoryRepository) CreateCategory(ctx context.Context, category *entity.Category) error {
+	return r.db.Create(ctx, category)
+}
+
+func (r *CategoryRepository) ListCategories(ctx context.Context) ([]*entity.Category, error) {
+	var categories []*entity.Category
+	if err := r.db.Find(ctx, &categories, db.WithOrder("name ASC")); err != nil {
+		return nil, err
+	}
+
+	return categories, nil
+}
+
+func (r *CategoryRepository) UpdateCategory(ctx context.Context, category *entity.Category) error {
+	return r.db.Update(ctx, category)
+}
+
+func (r *CategoryRepository) DeleteCategory(ctx context.Context, category *entity.Category) error {
+	return r.db.Delete(ctx, category)
+}
+
+// HasChildren reports whether the category has subcategories or products.
+func (r *CategoryRepository) HasChildren(ctx context.Context, categoryID string) (bool, error) {
+	var total int64
+	if err := r.db.Count(ctx, &entity.Category{}, &total, db.WithQuery(db.NewQuery("parent_id = ?", categoryID))); err != nil {
+		return false, err
+	}
+	if total > 0 {
+		return true, nil
+	}
+
+	if err := r.db.Count(ctx, &entity.Product{}, &total, db.WithQuery(db.NewQuery("category_id = ?", categoryID))); err != nil {
+		return false, err
+	}
+
+	return total > 0, nil
+}
@@ -0,0 +1,185 @@
+// Package mocks holds a testify mock of the product repository for the use
+// case tests of the packages that depend on it.
+package mocks
+
+import (
+	"context"
+	"time"
+
+	prodDto "ecommerce_clean/internals/product/controller/dto"
+	productEntity "ecommerce_clean/internals/product/entity"
+	productRepo "ecommerce_clean/internals/product/repository"
+	"ecommerce_clean/pkgs/paging"
+
+	"github.com/stretchr/testify/mock"
+)
+
+// ProductRepository mocks the calls the order and cart use cases make to
+// products: reads by ID, stock locking and updates, and the lookups whose
+// results they use. Every other method is a stub returning zero values.
+type ProductRepository struct {
+	mock.Mock
+}
+
+func (m *ProductRepository) ListProducts(ctx context.Context, req *prodDto.ListProductRequest) ([]*productEntity.Product, *paging.Pagination, error) {
+	return nil, nil, nil
+}
+
+func (m *ProductRepository) GetProductById(ctx context.Context, id string) (*productEntity.Product, error) {
+	args := m.Called(ctx, id)
+	if v := args.Get(0); v != nil {
+		return v.(*productEntity.Product), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *ProductRepository) CreatedProduct(ctx context.Context, p *productEntity.Product) error {
+	return nil
+}
+
+func (m *ProductRepository) UpdateProduct(ctx context.Context, p *productEntity.Product) error {
+	return nil
+}
+
+func (m *ProductRepository) DeleteProduct(ctx context.Context, p *productEntity.Product) error {
+	return nil
+}
+
+func (m *ProductRepository) GetInventoryReport(ctx context.Context) ([]*productEntity.InventoryItem, error) {
+	return nil, nil
+}
+
+func (m *ProductRepository) GetExternalProducts(ctx context.Context) ([]*productEntity.Product, error) {
+	return nil, nil
+}
+
+func (m *ProductRepository) GetNewArrivals(ctx context.Context, since time.Time, limit int) ([]*productEntity.Product, error) {
+	return nil, nil
+}
+
+func (m *ProductRepository) GetProductByBarcode(ctx context.Context, barcode string) (*productEntity.Product, error) {
+	return nil, nil
+}
+
+func (m *ProductRepository) GetProductStock(ctx context.Context, productID string) (int, error) {
+	return 0, nil
+}
+
+func (m *ProductRepository) GetProductsByIDs(ctx context.Context, ids []string) ([]*productEntity.Product, error) {
+	args := m.Called(ctx, ids)
+	var products []*productEntity.Product
+	if v := args.Get(0); v != nil {
+		products = v.([]*productEntity.Product)
+	}
+	return products, args.Error(1)
+}
+
+func (m *ProductRepository) GetProductsOnSale(ctx context.Context, limit int) ([]*productEntity.Product, error) {
+	return nil, nil
+}
+
+func (m *ProductRepository) CountCartLinesByProduct(ctx context.Context, productID string) (int, error) {
+	return 0, nil
+}
+
+func (m *ProductRepository) CountOpenOrdersByProduct(ctx context.Context, productID string) (int, error) {
+	return 0, nil
+}
+
+func (m *ProductRepository) UpdateProductsActiveStatus(ctx context.Context, ids []string, isActive bool) (int64, error) {
+	return 0, nil
+}
+
+func (m *ProductRepository) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+func (m *ProductRepository) GetProductStockForUpdate(ctx context.Context, productID string, opts ...productRepo.RepoOption) (int, error) {
+	args := m.Called(ctx, productID)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *ProductRepository) UpdateProductStock(ctx context.Context, productID string, stock int) error {
+	args := m.Called(ctx, productID, stock)
+	return args.Error(0)
+}
+
+func (m *ProductRepository) CreateProducts(ctx context.Context, products []*productEntity.Product) error {
+	return nil
+}
+
+func (m *ProductRepository) GetPriceHistory(ctx context.Context, productID string, limit int) ([]*productEntity.PriceHistory, error) {
+	return nil, nil
+}
+
+func (m *ProductRepository) GetProductsUpdatedSince(ctx context.Context, since time.Time, limit int) ([]*productEntity.Product, error) {
+	return nil, nil
+}
+
+func (m *ProductRepository) GetProductUnitsSold(ctx context.Context, productID string) (int, error) {
+	return 0, nil
+}
+
+func (m *ProductRepository) GetProductRevenue(ctx context.Context, productID string) (float64, error) {
+	return 0, nil
+}
+
+func (m *ProductRepository) GetProductRatingStats(ctx context.Context, productID string) (float64, int, error) {
+	return 0, 0, nil
+}
+
+func (m *ProductRepository) GetFrequentlyBoughtTogether(ctx context.Context, productID string, limit int) ([]*productEntity.Product, error) {
+	args := m.Called(ctx, productID, limit)
+	if v := args.Get(0); v != nil {
+		return v.([]*productEntity.Product), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *ProductRepository) GetProductsBySupplier(ctx context.Context, supplierID string, req *paging.Pagination) ([]*productEntity.Product, *paging.Pagination, error) {
+	return nil, nil, nil
+}
+
+func (m *ProductRepository) SearchProductsByNamePrefix(ctx context.Context, prefix string, limit int) ([]*productEntity.Product, error) {
+	return nil, nil
+}
+
+func (m *ProductRepository) GetProductsByCategoryID(ctx context.Context, categoryID string, req *paging.Pagination) ([]*productEntity.Product, *paging.Pagination, error) {
+	return nil, nil, nil
+}
+
+func (m *ProductRepository) GetReservedStock(ctx context.Context, productID string) (int, error) {
+	return 0, nil
+}
+
+func (m *ProductRepository) CreateStockReservation(ctx context.Context, reservation *productEntity.StockReservation) error {
+	return nil
+}
+
+func (m *ProductRepository) CreateScheduledPriceChange(ctx context.Context, change *productEntity.ScheduledPriceChange) error {
+	return nil
+}
+
+func (m *ProductRepository) GetDueScheduledPriceChanges(ctx context.Context, now time.Time, limit int) ([]*productEntity.ScheduledPriceChange, error) {
+	return nil, nil
+}
+
+func (m *ProductRepository) ApplyScheduledPriceChange(ctx context.Context, change *productEntity.ScheduledPriceChange) error {
+	return nil
+}
+
+func (m *ProductRepository) GetAllProductsByCategoryID(ctx context.Context, categoryID string) ([]*productEntity.Product, error) {
+	return nil, nil
+}
+
+func (m *ProductRepository) GetProductNamesWithPrefixes(ctx context.Context, prefixes []string) ([]string, error) {
+	return nil, nil
+}
+
+func (m *ProductRepository) GetProductsExpiringBetween(ctx context.Context, from, to time.Time, limit int) ([]*productEntity.Product, error) {
+	return nil, nil
+}
+
+func (m *ProductRepository) GetOutOfStockProducts(ctx context.Context, req *paging.Pagination) ([]*productEntity.Product, *paging.Pagination, error) {
+	return nil, nil, nil
+}
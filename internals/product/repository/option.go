@@ -0,0 +1,24 @@
+package repository
+
+type RepoOption func(*repoOption)
+
+type repoOption struct {
+	pessimisticLock bool
+}
+
+// WithPessimisticLock makes the read take a row-level lock (SELECT ... FOR
+// UPDATE) held until the surrounding transaction ends.
+func WithPessimisticLock() RepoOption {
+	return func(opt *repoOption) {
+		opt.pessimisticLock = true
+	}
+}
+
+func getRepoOption(opts ...RepoOption) repoOption {
+	var opt repoOption
+	for _, o := range opts {
+		o(&opt)
+	}
+
+	return opt
+}
@@ -7,14 +7,56 @@ import (
 	"ecommerce_clean/internals/product/controller/dto"
 	"ecommerce_clean/internals/product/entity"
 	"ecommerce_clean/pkgs/paging"
+	"ecommerce_clean/utils"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+const createProductsBatchSize = 100
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
 type IProductRepository interface {
 	ListProducts(ctx context.Context, req *dto.ListProductRequest) ([]*entity.Product, *paging.Pagination, error)
 	GetProductById(ctx context.Context, id string) (*entity.Product, error)
 	CreatedProduct(ctx context.Context, product *entity.Product) error
+	CreateProducts(ctx context.Context, products []*entity.Product) error
 	UpdateProduct(ctx context.Context, product *entity.Product) error
 	DeleteProduct(ctx context.Context, product *entity.Product) error
+	GetInventoryReport(ctx context.Context) ([]*entity.InventoryItem, error)
+	GetExternalProducts(ctx context.Context) ([]*entity.Product, error)
+	GetNewArrivals(ctx context.Context, since time.Time, limit int) ([]*entity.Product, error)
+	GetProductByBarcode(ctx context.Context, barcode string) (*entity.Product, error)
+	GetProductStock(ctx context.Context, productID string) (int, error)
+	GetProductsByIDs(ctx context.Context, ids []string) ([]*entity.Product, error)
+	GetProductsOnSale(ctx context.Context, limit int) ([]*entity.Product, error)
+	CountCartLinesByProduct(ctx context.Context, productID string) (int, error)
+	CountOpenOrdersByProduct(ctx context.Context, productID string) (int, error)
+	UpdateProductsActiveStatus(ctx context.Context, ids []string, isActive bool) (int64, error)
+	WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error
+	GetProductStockForUpdate(ctx context.Context, productID string, opts ...RepoOption) (int, error)
+	UpdateProductStock(ctx context.Context, productID string, stock int) error
+	GetPriceHistory(ctx context.Context, productID string, limit int) ([]*entity.PriceHistory, error)
+	GetProductsUpdatedSince(ctx context.Context, since time.Time, limit int) ([]*entity.Product, error)
+	GetProductUnitsSold(ctx context.Context, productID string) (int, error)
+	GetProductRevenue(ctx context.Context, productID string) (float64, error)
+	GetProductRatingStats(ctx context.Context, productID string) (float64, int, error)
+	GetFrequentlyBoughtTogether(ctx context.Context, productID string, limit int) ([]*entity.Product, error)
+	GetProductsBySupplier(ctx context.Context, supplierID string, req *paging.Pagination) ([]*entity.Product, *paging.Pagination, error)
+	SearchProductsByNamePrefix(ctx context.Context, prefix string, limit int) ([]*entity.Product, error)
+	GetProductsByCategoryID(ctx context.Context, categoryID string, req *paging.Pagination) ([]*entity.Product, *paging.Pagination, error)
+	GetReservedStock(ctx context.Context, productID string) (int, error)
+	CreateStockReservation(ctx context.Context, reservation *entity.StockReservation) error
+	CreateScheduledPriceChange(ctx context.Context, change *entity.ScheduledPriceChange) error
+	GetDueScheduledPriceChanges(ctx context.Context, now time.Time, limit int) ([]*entity.ScheduledPriceChange, error)
+	ApplyScheduledPriceChange(ctx context.Context, change *entity.ScheduledPriceChange) error
+	GetAllProductsByCategoryID(ctx context.Context, categoryID string) ([]*entity.Product, error)
+	GetProductNamesWithPrefixes(ctx context.Context, prefixes []string) ([]string, error)
+	GetProductsExpiringBetween(ctx context.Context, from, to time.Time, limit int) ([]*entity.Product, error)
+	GetOutOfStockProducts(ctx context.Context, req *paging.Pagination) ([]*entity.Product, *paging.Pagination, error)
 }
 
 type ProductRepository struct {
@@ -32,7 +74,12 @@ func (pr *ProductRepository) ListProducts(ctx context.Context, req *dto.ListProd
 	query := make([]db.Query, 0)
 
 	if req.Search != "" {
-		query = append(query, db.NewQuery("name ILIKE ?", "%"+req.Search+"%"))
+		pattern := "%" + likeEscaper.Replace(strings.ToLower(req.Search)) + "%"
+		query = append(query, db.NewQuery(`LOWER(name) LIKE ? ESCAPE '\'`, pattern))
+	}
+
+	if req.CategoryID != "" {
+		query = append(query, db.NewQuery("category_id = ?", req.CategoryID))
 	}
 
 	order := "created_at DESC"
@@ -58,6 +105,7 @@ func (pr *ProductRepository) ListProducts(ctx context.Context, req *dto.ListProd
 		db.WithLimit(int(pagination.Size)),
 		db.WithOffset(int(pagination.Skip)),
 		db.WithOrder(order),
+		db.WithPreload([]string{"Category"}),
 	); err != nil {
 		return nil, nil, err
 	}
@@ -67,7 +115,11 @@ func (pr *ProductRepository) ListProducts(ctx context.Context, req *dto.ListProd
 
 func (pr *ProductRepository) GetProductById(ctx context.Context, id string) (*entity.Product, error) {
 	var product entity.Product
-	if err := pr.db.FindById(ctx, id, &product); err != nil {
+	opts := []db.FindOption{
+		db.WithQuery(db.NewQuery("id = ?", id)),
+		db.WithPreload([]string{"Category"}),
+	}
+	if err := pr.db.FindOne(ctx, &product, opts...); err != nil {
 		return nil, err
 	}
 	return &product, nil
@@ -77,6 +129,10 @@ func (pr *ProductRepository) CreatedProduct(ctx context.Context, product *entity
 	return pr.db.Create(ctx, product)
 }
 
+func (pr *ProductRepository) CreateProducts(ctx context.Context, products []*entity.Product) error {
+	return pr.db.CreateInBatches(ctx, &products, createProductsBatchSize)
+}
+
 func (pr *ProductRepository) UpdateProduct(ctx context.Context, product *entity.Product) error {
 	return pr.db.Update(ctx, product)
 }
@@ -84,3 +140,551 @@ func (pr *ProductRepository) UpdateProduct(ctx context.Context, product *entity.
 func (pr *ProductRepository) DeleteProduct(ctx context.Context, product *entity.Product) error {
 	return pr.db.Delete(ctx, product)
 }
+
+func (pr *ProductRepository) GetInventoryReport(ctx context.Context) ([]*entity.InventoryItem, error) {
+	ctx, cancel := context.WithTimeout(ctx, configs.DatabaseTimeout)
+	defer cancel()
+
+	var items []*entity.InventoryItem
+	err := pr.db.Conn(ctx).
+		Table("products AS p").
+		Select(`p.id AS product_id, p.name, p.stock,
+			COALESCE(SUM(r.quantity), 0) AS reserved_stock,
+			p.stock - COALESCE(SUM(r.quantity), 0) AS available_stock,
+			COALESCE(c.name, '') AS category_name`).
+		Joins("LEFT JOIN categories AS c ON c.id = p.category_id").
+		Joins("LEFT JOIN stock_reservations AS r ON r.product_id = p.id AND r.deleted_at IS NULL").
+		Where("p.deleted_at IS NULL").
+		Group("p.id, p.name, p.stock, c.name").
+		Order("available_stock ASC").
+		Scan(&items).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return items, nil
+}
+
+func (pr *ProductRepository) GetExternalProducts(ctx context.Context) ([]*entity.Product, error) {
+	var products []*entity.Product
+	if err := pr.db.Find(ctx, &products, db.WithQuery(db.NewQuery("external_id <> ''"))); err != nil {
+		return nil, err
+	}
+
+	return products, nil
+}
+
+func (pr *ProductRepository) GetNewArrivals(ctx context.Context, since time.Time, limit int) ([]*entity.Product, error) {
+	var products []*entity.Product
+	opts := []db.FindOption{
+		db.WithQuery(
+			db.NewQuery("created_at >= ?", since),
+			db.NewQuery("active = ?", true),
+		),
+		db.WithOrder("created_at DESC"),
+		db.WithLimit(limit),
+	}
+
+	if err := pr.db.Find(ctx, &products, opts...); err != nil {
+		return nil, err
+	}
+
+	return products, nil
+}
+
+// GetProductsExpiringBetween returns products whose expiry falls inside
+// [from, to], soonest first. Products without an expiry date never match the
+// range comparisons.
+func (pr *ProductRepository) GetProductsExpiringBetween(ctx context.Context, from, to time.Time, limit int) ([]*entity.Product, error) {
+	var products []*entity.Product
+	opts := []db.FindOption{
+		db.WithQuery(
+			db.NewQuery("expires_at >= ?", from),
+			db.NewQuery("expires_at <= ?", to),
+		),
+		db.WithOrder("expires_at ASC"),
+		db.WithLimit(limit),
+	}
+
+	if err := pr.db.Find(ctx, &products, opts...); err != nil {
+		return nil, err
+	}
+
+	return products, nil
+}
+
+func (pr *ProductRepository) GetProductByBarcode(ctx context.Context, barcode string) (*entity.Product, error) {
+	var product entity.Product
+	if err := pr.db.FindOne(ctx, &product, db.WithQuery(db.NewQuery("barcode = ?", barcode))); err != nil {
+		return nil, err
+	}
+	return &product, nil
+}
+
+func (pr *ProductRepository) GetProductStock(ctx context.Context, productID string) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, configs.DatabaseTimeout)
+	defer cancel()
+
+	var row struct {
+		Stock int
+	}
+	err := pr.db.Conn(ctx).
+		Model(&entity.Product{}).
+		Select("stock").
+		Where("id = ?", productID).
+		Take(&row).Error
+	if err != nil {
+		return 0, err
+	}
+
+	return row.Stock, nil
+}
+
+func (pr *ProductRepository) GetProductsByIDs(ctx context.Context, ids []string) ([]*entity.Product, error) {
+	var products []*entity.Product
+	if err := pr.db.Find(ctx, &products, db.WithQuery(db.NewQuery("id IN ?", ids))); err != nil {
+		return nil, err
+	}
+
+	return products, nil
+}
+
+func (pr *ProductRepository) GetProductsOnSale(ctx context.Context, limit int) ([]*entity.Product, error) {
+	var products []*entity.Product
+	opts := []db.FindOption{
+		db.WithQuery(
+			db.NewQuery("sale_price IS NOT NULL"),
+			db.NewQuery("sale_price < price"),
+			db.NewQuery("active = ?", true),
+		),
+		db.WithOrder("(price - sale_price) DESC"),
+		db.WithLimit(limit),
+	}
+
+	if err := pr.db.Find(ctx, &products, opts...); err != nil {
+		return nil, err
+	}
+
+	return products, nil
+}
+
+func (pr *ProductRepository) CountCartLinesByProduct(ctx context.Context, productID string) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, configs.DatabaseTimeout)
+	defer cancel()
+
+	var total int64
+	err := pr.db.Conn(ctx).
+		Table("cart_lines").
+		Where("product_id = ? AND deleted_at IS NULL", productID).
+		Count(&total).Error
+	if err != nil {
+		return 0, err
+	}
+
+	return int(total), nil
+}
+
+func (pr *ProductRepository) CountOpenOrdersByProduct(ctx context.Context, productID string) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, configs.DatabaseTimeout)
+	defer cancel()
+
+	var total int64
+	err := pr.db.Conn(ctx).
+		Table("orders AS o").
+		Joins("JOIN order_lines AS l ON l.order_id = o.id AND l.deleted_at IS NULL").
+		Where("l.product_id = ? AND o.deleted_at IS NULL", productID).
+		Where("o.status IN ?", []utils.OrderStatus{utils.OrderStatusNew, utils.OrderStatusInProgress}).
+		Distinct("o.id").
+		Count(&total).Error
+	if err != nil {
+		return 0, err
+	}
+
+	return int(total), nil
+}
+
+func (pr *ProductRepository) UpdateProductsActiveStatus(ctx context.Context, ids []string, isActive bool) (int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, configs.DatabaseTimeout)
+	defer cancel()
+
+	result := pr.db.Conn(ctx).
+		Model(&entity.Product{}).
+		Where("id IN ?", ids).
+		Update("active", isActive)
+	if result.Error != nil {
+		return 0, result.Error
+	}
+
+	return result.RowsAffected, nil
+}
+
+// WithinTransaction runs fn in a database transaction. Repository calls made
+// with the ctx passed to fn join that transaction.
+func (pr *ProductRepository) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	return pr.db.WithTransaction(ctx, fn)
+}
+
+func (pr *ProductRepository) GetProductStockForUpdate(ctx context.Context, productID string, opts ...RepoOption) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, configs.DatabaseTimeout)
+	defer cancel()
+
+	query := pr.db.Conn(ctx).
+		Model(&entity.Product{}).
+		Select("stock").
+		Where("id = ?", productID)
+	if getRepoOption(opts...).pessimisticLock {
+		query = query.Clauses(clause.Locking{Strength: "UPDATE"})
+	}
+
+	var row struct {
+		Stock int
+	}
+	if err := query.Take(&row).Error; err != nil {
+		return 0, err
+	}
+
+	return row.Stock, nil
+}
+
+func (pr *ProductRepository) UpdateProductStock(ctx context.Context, productID string, stock int) error {
+	ctx, cancel := context.WithTimeout(ctx, configs.DatabaseTimeout)
+	defer cancel()
+
+	return pr.db.Conn(ctx).
+		Model(&entity.Product{}).
+		Where("id = ?", productID).
+		Updates(map[string]any{"stock": stock, "stock_last_updated_at": time.Now()}).Error
+}
+
+func (pr *ProductRepository) CreateScheduledPriceChange(ctx context.Context, change *entity.ScheduledPriceChange) error {
+	return pr.db.Create(ctx, change)
+}
+
+// GetDueScheduledPriceChanges returns the pending changes whose effective date
+// has been reached, oldest first.
+func (pr *ProductRepository) GetDueScheduledPriceChanges(ctx context.Context, now time.Time, limit int) ([]*entity.ScheduledPriceChange, error) {
+	var changes []*entity.ScheduledPriceChange
+	opts := []db.FindOption{
+		db.WithQuery(
+			db.NewQuery("is_applied = ?", false),
+			db.NewQuery("effective_at <= ?", now),
+		),
+		db.WithOrder("effective_at ASC"),
+		db.WithLimit(limit),
+	}
+
+	if err := pr.db.Find(ctx, &changes, opts...); err != nil {
+		return nil, err
+	}
+
+	return changes, nil
+}
+
+// ApplyScheduledPriceChange sets the product's new price, records it in the
+// price history and marks the change as applied, all in one transaction.
+func (pr *ProductRepository) ApplyScheduledPriceChange(ctx context.Context, change *entity.ScheduledPriceChange) error {
+	return pr.WithinTransaction(ctx, func(ctx context.Context) error {
+		var product entity.Product
+		if err := pr.db.Conn(ctx).Select("id", "price").Where("id = ?", change.ProductID).Take(&product).Error; err != nil {
+			return err
+		}
+
+		now := time.Now()
+		history := &entity.PriceHistory{
+			ProductID: change.ProductID,
+			OldPrice:  product.Price,
+			NewPrice:  change.NewPrice,
+			ChangedAt: now,
+		}
+		if err := pr.db.Conn(ctx).Create(history).Error; err != nil {
+			return err
+		}
+
+		err := pr.db.Conn(ctx).
+			Model(&entity.Product{}).
+			Where("id = ?", change.ProductID).
+			Update("price", change.NewPrice).Error
+		if err != nil {
+			return err
+		}
+
+		change.IsApplied = true
+		change.AppliedAt = &now
+		return pr.db.Conn(ctx).
+			Model(change).
+			Updates(map[string]interface{}{"is_applied": true, "applied_at": now}).Error
+	})
+}
+
+func (pr *ProductRepository) GetPriceHistory(ctx context.Context, productID string, limit int) ([]*entity.PriceHistory, error) {
+	var history []*entity.PriceHistory
+	opts := []db.FindOption{
+		db.WithQuery(db.NewQuery("product_id = ?", productID)),
+		db.WithOrder("changed_at DESC"),
+		db.WithLimit(limit),
+	}
+
+	if err := pr.db.Find(ctx, &history, opts...); err != nil {
+		return nil, err
+	}
+
+	return history, nil
+}
+
+// GetProductsUpdatedSince includes products soft-deleted after since so
+// callers can see deletions. A soft delete only sets deleted_at, so that column
+// counts as the change time of deleted products.
+func (pr *ProductRepository) GetProductsUpdatedSince(ctx context.Context, since time.Time, limit int) ([]*entity.Product, error) {
+	ctx, cancel := context.WithTimeout(ctx, configs.DatabaseTimeout)
+	defer cancel()
+
+	var products []*entity.Product
+	err := pr.db.Conn(ctx).
+		Unscoped().
+		Where("updated_at > ? OR deleted_at > ?", since, since).
+		Order("COALESCE(deleted_at, updated_at) ASC").
+		Limit(limit).
+		Find(&products).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return products, nil
+}
+
+// soldLines scopes order_lines of productID to orders that were not canceled.
+func (pr *ProductRepository) soldLines(ctx context.Context, productID string) *gorm.DB {
+	return pr.db.Conn(ctx).
+		Table("order_lines AS l").
+		Joins("JOIN orders AS o ON o.id = l.order_id AND o.deleted_at IS NULL").
+		Where("l.product_id = ? AND l.deleted_at IS NULL", productID).
+		Where("o.status <> ?", utils.OrderStatusCanceled)
+}
+
+func (pr *ProductRepository) GetProductUnitsSold(ctx context.Context, productID string) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, configs.DatabaseTimeout)
+	defer cancel()
+
+	var total int64
+	if err := pr.soldLines(ctx, productID).Select("COALESCE(SUM(l.quantity), 0)").Scan(&total).Error; err != nil {
+		return 0, err
+	}
+
+	return int(total), nil
+}
+
+// GetProductRevenue sums the product's order lines. A line's price is already
+// its total for the quantity ordered.
+func (pr *ProductRepository) GetProductRevenue(ctx context.Context, productID string) (float64, error) {
+	ctx, cancel := context.WithTimeout(ctx, configs.DatabaseTimeout)
+	defer cancel()
+
+	var total float64
+	if err := pr.soldLines(ctx, productID).Select("COALESCE(SUM(l.price), 0)").Scan(&total).Error; err != nil {
+		return 0, err
+	}
+
+	return total, nil
+}
+
+func (pr *ProductRepository) GetProductRatingStats(ctx context.Context, productID string) (float64, int, error) {
+	ctx, cancel := context.WithTimeout(ctx, configs.DatabaseTimeout)
+	defer cancel()
+
+	var stats struct {
+		Average float64
+		Count   int64
+	}
+	err := pr.db.Conn(ctx).
+		Table("product_reviews").
+		Select("COALESCE(AVG(rating), 0) AS average, COUNT(*) AS count").
+		Where("product_id = ? AND deleted_at IS NULL", productID).
+		Scan(&stats).Error
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return stats.Average, int(stats.Count), nil
+}
+
+// GetReservedStock sums the quantities of the product's active stock
+// reservations.
+func (pr *ProductRepository) GetReservedStock(ctx context.Context, productID string) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, configs.DatabaseTimeout)
+	defer cancel()
+
+	var reserved int
+	err := pr.db.Conn(ctx).
+		Model(&entity.StockReservation{}).
+		Select("COALESCE(SUM(quantity), 0)").
+		Where("product_id = ? AND deleted_at IS NULL", productID).
+		Scan(&reserved).Error
+	if err != nil {
+		return 0, err
+	}
+
+	return reserved, nil
+}
+
+func (pr *ProductRepository) CreateStockReservation(ctx context.Context, reservation *entity.StockReservation) error {
+	return pr.db.Create(ctx, reservation)
+}
+
+// GetFrequentlyBoughtTogether returns the active products that share the most
+// orders with productID, most frequent first.
+func (pr *ProductRepository) GetFrequentlyBoughtTogether(ctx context.Context, productID string, limit int) ([]*entity.Product, error) {
+	ctx, cancel := context.WithTimeout(ctx, configs.DatabaseTimeout)
+	defer cancel()
+
+	conn := pr.db.Conn(ctx)
+	together := conn.
+		Table("order_lines AS a").
+		Select("b.product_id, COUNT(DISTINCT a.order_id) AS times").
+		Joins("JOIN order_lines AS b ON b.order_id = a.order_id AND b.product_id <> a.product_id AND b.deleted_at IS NULL").
+		Where("a.product_id = ? AND a.deleted_at IS NULL", productID).
+		Group("b.product_id")
+
+	var products []*entity.Product
+	err := conn.
+		Joins("JOIN (?) AS t ON t.product_id = products.id", together).
+		Where("products.active = ?", true).
+		Order("t.times DESC, products.id ASC").
+		Limit(limit).
+		Find(&products).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return products, nil
+}
+
+func (pr *ProductRepository) GetProductsBySupplier(ctx context.Context, supplierID string, req *paging.Pagination) ([]*entity.Product, *paging.Pagination, error) {
+	query := db.NewQuery("supplier_id = ?", supplierID)
+
+	var products []*entity.Product
+	pagination, err := db.FindPage(
+		ctx,
+		pr.db,
+		&entity.Product{},
+		&products,
+		req,
+		db.WithQuery(query),
+		db.WithOrder("created_at DESC"),
+	)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return products, pagination, nil
+}
+
+// GetOutOfStockProducts pages through the active products with no stock left,
+// by name.
+func (pr *ProductRepository) GetOutOfStockProducts(ctx context.Context, req *paging.Pagination) ([]*entity.Product, *paging.Pagination, error) {
+	query := []db.Query{
+		db.NewQuery("stock = ?", 0),
+		db.NewQuery("active = ?", true),
+	}
+
+	var products []*entity.Product
+	pagination, err := db.FindPage(
+		ctx,
+		pr.db,
+		&entity.Product{},
+		&products,
+		req,
+		db.WithQuery(query...),
+		db.WithOrder("name ASC"),
+	)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return products, pagination, nil
+}
+
+// SearchProductsByNamePrefix returns active products whose name starts with
+// prefix, loading only the columns autocomplete needs.
+func (pr *ProductRepository) SearchProductsByNamePrefix(ctx context.Context, prefix string, limit int) ([]*entity.Product, error) {
+	ctx, cancel := context.WithTimeout(ctx, configs.DatabaseTimeout)
+	defer cancel()
+
+	var products []*entity.Product
+	err := pr.db.Conn(ctx).
+		Select("id", "name", "price", "images").
+		Where(`name LIKE ? ESCAPE '\'`, likeEscaper.Replace(prefix)+"%").
+		Where("active = ?", true).
+		Order("name ASC").
+		Limit(limit).
+		Find(&products).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return products, nil
+}
+
+func (pr *ProductRepository) GetAllProductsByCategoryID(ctx context.Context, categoryID string) ([]*entity.Product, error) {
+	var products []*entity.Product
+	opts := []db.FindOption{
+		db.WithQuery(db.NewQuery("category_id = ?", categoryID)),
+		db.WithOrder("name ASC"),
+	}
+
+	if err := pr.db.Find(ctx, &products, opts...); err != nil {
+		return nil, err
+	}
+
+	return products, nil
+}
+
+// GetProductNamesWithPrefixes returns every product name starting with one of
+// the prefixes, deleted products included since their names still hold the
+// unique index.
+func (pr *ProductRepository) GetProductNamesWithPrefixes(ctx context.Context, prefixes []string) ([]string, error) {
+	if len(prefixes) == 0 {
+		return nil, nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, configs.DatabaseTimeout)
+	defer cancel()
+
+	conditions := make([]string, 0, len(prefixes))
+	args := make([]any, 0, len(prefixes))
+	for _, prefix := range prefixes {
+		conditions = append(conditions, `name LIKE ? ESCAPE '\'`)
+		args = append(args, likeEscaper.Replace(prefix)+"%")
+	}
+
+	var names []string
+	err := pr.db.Conn(ctx).
+		Unscoped().
+		Model(&entity.Product{}).
+		Where(strings.Join(conditions, " OR "), args...).
+		Pluck("name", &names).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return names, nil
+}
+
+func (pr *ProductRepository) GetProductsByCategoryID(ctx context.Context, categoryID string, req *paging.Pagination) ([]*entity.Product, *paging.Pagination, error) {
+	query := db.NewQuery("category_id = ?", categoryID)
+
+	var products []*entity.Product
+	pagination, err := db.FindPage(
+		ctx,
+		pr.db,
+		&entity.Product{},
+		&products,
+		req,
+		db.WithQuery(query),
+		db.WithOrder("created_at DESC"),
+	)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return products, pagination, nil
+}
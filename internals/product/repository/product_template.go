@@ -0,0 +1,28 @@
+package repository
+
+import (
+	"context"
+	"ecommerce_clean/db"
+	"ecommerce_clean/internals/product/entity"
+)
+
+type IProductTemplateRepository interface {
+	GetTemplateByID(ctx context.Context, id string) (*entity.ProductTemplate, error)
+}
+
+type ProductTemplateRepository struct {
+	db db.IDatabase
+}
+
+func NewProductTemplateRepository(db db.IDatabase) *ProductTemplateRepository {
+	return &ProductTemplateRepository{db: db}
+}
+
+func (r *ProductTemplateRepository) GetTemplateByID(ctx context.Context, id string) (*entity.ProductTemplate, error) {
+	var template entity.ProductTemplate
+	if err := r.db.FindById(ctx, id, &template); err != nil {
+		return nil, err
+	}
+
+	return &template, nil
+}
@@ -0,0 +1,506 @@
+package repository_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"ecommerce_clean/db"
+	"ecommerce_clean/db/dbtest"
+	orderEntity "ecommerce_clean/internals/order/entity"
+	"ecommerce_clean/internals/product/controller/dto"
+	productEntity "ecommerce_clean/internals/product/entity"
+	"ecommerce_clean/internals/product/repository"
+	"ecommerce_clean/pkgs/paging"
+	"ecommerce_clean/utils"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func newTestDatabase(t *testing.T) *db.Database {
+	return dbtest.NewDatabase(t,
+		&productEntity.Category{},
+		&productEntity.Product{},
+		&productEntity.PriceHistory{},
+		&productEntity.ScheduledPriceChange{},
+	)
+}
+
+func seedProduct(t *testing.T, database *db.Database, name string, updatedAt time.Time) *productEntity.Product {
+	product := &productEntity.Product{Name: name, Price: 1, CreatedAt: updatedAt, UpdatedAt: updatedAt}
+	require.NoError(t, database.Create(context.Background(), product))
+	return product
+}
+
+func productNames(products []*productEntity.Product) []string {
+	names := make([]string, 0, len(products))
+	for _, product := range products {
+		names = append(names, product.Name)
+	}
+	return names
+}
+
+// TestGetProductsUpdatedSince_AfterTimestamp verifica que solo se devuelven los
+// productos modificados después de la marca, del más antiguo al más reciente.
+func TestGetProductsUpdatedSince_AfterTimestamp(t *testing.T) {
+	database := newTestDatabase(t)
+	repo := repository.NewProductRepository(database)
+
+	since := time.Now().Add(-time.Hour)
+	seedProduct(t, database, "old", since.Add(-time.Minute))
+	seedProduct(t, database, "second", since.Add(2*time.Minute))
+	seedProduct(t, database, "first", since.Add(time.Minute))
+
+	products, err := repo.GetProductsUpdatedSince(context.Background(), since, 10)
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{"first", "second"}, productNames(products))
+}
+
+// TestGetProductsUpdatedSince_IncludesDeleted verifica que los productos
+// borrados después de la marca se devuelven con DeletedAt informado.
+func TestGetProductsUpdatedSince_IncludesDeleted(t *testing.T) {
+	database := newTestDatabase(t)
+	repo := repository.NewProductRepository(database)
+
+	since := time.Now().Add(-time.Hour)
+	deleted := seedProduct(t, database, "deleted", since.Add(-time.Minute))
+	require.NoError(t, database.Delete(context.Background(), deleted))
+
+	products, err := repo.GetProductsUpdatedSince(context.Background(), since, 10)
+
+	require.NoError(t, err)
+	require.Len(t, products, 1)
+	assert.Equal(t, "deleted", products[0].Name)
+	assert.NotNil(t, products[0].DeletedAt)
+}
+
+// TestGetProductsUpdatedSince_Limit verifica que el resultado respeta el límite
+// quedándose con los cambios más antiguos.
+func TestGetProductsUpdatedSince_Limit(t *testing.T) {
+	database := newTestDatabase(t)
+	repo := repository.NewProductRepository(database)
+
+	since := time.Now().Add(-time.Hour)
+	seedProduct(t, database, "a", since.Add(time.Minute))
+	seedProduct(t, database, "b", since.Add(2*time.Minute))
+	seedProduct(t, database, "c", since.Add(3*time.Minute))
+
+	products, err := repo.GetProductsUpdatedSince(context.Background(), since, 2)
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a", "b"}, productNames(products))
+}
+
+// TestGetFrequentlyBoughtTogether_RankedByOrders verifica que se devuelven los
+// productos que más pedidos comparten con el dado, sin incluirlo a él mismo.
+func TestGetFrequentlyBoughtTogether_RankedByOrders(t *testing.T) {
+	database := newTestDatabase(t)
+	require.NoError(t, database.AutoMigrate(&orderEntity.Order{}, &orderEntity.OrderLine{}))
+	repo := repository.NewProductRepository(database)
+
+	now := time.Now()
+	base := seedProduct(t, database, "base", now)
+	often := seedProduct(t, database, "often", now)
+	once := seedProduct(t, database, "once", now)
+	seedProduct(t, database, "never", now)
+
+	for _, ids := range [][]string{
+		{base.ID, often.ID},
+		{base.ID, often.ID, once.ID},
+		{often.ID},
+	} {
+		order := &orderEntity.Order{UserID: "u1"}
+		require.NoError(t, database.Create(context.Background(), order))
+		for _, id := range ids {
+			require.NoError(t, database.Create(context.Background(), &orderEntity.OrderLine{OrderID: order.ID, ProductID: id, Quantity: 1}))
+		}
+	}
+
+	products, err := repo.GetFrequentlyBoughtTogether(context.Background(), base.ID, 10)
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{"often", "once"}, productNames(products))
+}
+
+// TestGetProductRevenue_SumsLineTotals verifica que los ingresos suman el
+// precio de cada línea, que ya es el total de su cantidad, sin contar pedidos
+// cancelados.
+func TestGetProductRevenue_SumsLineTotals(t *testing.T) {
+	database := newTestDatabase(t)
+	require.NoError(t, database.AutoMigrate(&orderEntity.Order{}, &orderEntity.OrderLine{}))
+	repo := repository.NewProductRepository(database)
+
+	product := seedProduct(t, database, "p", time.Now())
+	for _, order := range []struct {
+		status   utils.OrderStatus
+		quantity uint
+		price    float64
+	}{
+		{utils.OrderStatusNew, 3, 30},
+		{utils.OrderStatusDone, 2, 20},
+		{utils.OrderStatusCanceled, 5, 50},
+	} {
+		o := &orderEntity.Order{UserID: "u1", Status: order.status}
+		require.NoError(t, database.Create(context.Background(), o))
+		require.NoError(t, database.Create(context.Background(), &orderEntity.OrderLine{
+			OrderID: o.ID, ProductID: product.ID, Quantity: order.quantity, Price: order.price,
+		}))
+	}
+
+	revenue, err := repo.GetProductRevenue(context.Background(), product.ID)
+
+	require.NoError(t, err)
+	assert.Equal(t, 50.0, revenue)
+}
+
+// TestSearchProductsByNamePrefix_QueryShape verifica que la búsqueda es por
+// prefijo con LIKE y LIMIT, que escapa los comodines y que solo devuelve
+// productos activos.
+func TestSearchProductsByNamePrefix_QueryShape(t *testing.T) {
+	database := newTestDatabase(t)
+	repo := repository.NewProductRepository(database)
+
+	now := time.Now()
+	seedProduct(t, database, "Mango", now)
+	seedProduct(t, database, "Mandarina", now)
+	seedProduct(t, database, "Green mango", now)
+	seedProduct(t, database, "Man_go", now)
+	inactive := seedProduct(t, database, "Manzana", now)
+	require.NoError(t, database.GetDB().Model(inactive).Update("active", false).Error)
+
+	var statements []string
+	require.NoError(t, database.GetDB().Callback().Query().After("gorm:query").Register("test:capture", func(tx *gorm.DB) {
+		statements = append(statements, tx.Statement.SQL.String())
+	}))
+
+	products, err := repo.SearchProductsByNamePrefix(context.Background(), "Man", 2)
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Man_go", "Mandarina"}, productNames(products))
+	require.Len(t, statements, 1)
+	assert.Contains(t, statements[0], "name LIKE ?")
+	assert.Contains(t, statements[0], "LIMIT")
+
+	products, err = repo.SearchProductsByNamePrefix(context.Background(), "Man_", 10)
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Man_go"}, productNames(products))
+}
+
+// TestScheduledPriceChanges_ApplyDue verifica que solo se devuelven los cambios
+// vencidos y que al aplicarlos se actualiza el precio, se registra el historial
+// y se marcan como aplicados.
+func TestScheduledPriceChanges_ApplyDue(t *testing.T) {
+	database := newTestDatabase(t)
+	repo := repository.NewProductRepository(database)
+	ctx := context.Background()
+
+	product := seedProduct(t, database, "flash", time.Now())
+	now := time.Now()
+	due := &productEntity.ScheduledPriceChange{ProductID: product.ID, NewPrice: 0.5, EffectiveAt: now.Add(-time.Minute)}
+	future := &productEntity.ScheduledPriceChange{ProductID: product.ID, NewPrice: 2, EffectiveAt: now.Add(time.Hour)}
+	require.NoError(t, repo.CreateScheduledPriceChange(ctx, due))
+	require.NoError(t, repo.CreateScheduledPriceChange(ctx, future))
+
+	changes, err := repo.GetDueScheduledPriceChanges(ctx, now, 10)
+	require.NoError(t, err)
+	require.Len(t, changes, 1)
+	assert.Equal(t, due.ID, changes[0].ID)
+
+	require.NoError(t, repo.ApplyScheduledPriceChange(ctx, changes[0]))
+
+	stored, err := repo.GetProductById(ctx, product.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 0.5, stored.Price)
+
+	history, err := repo.GetPriceHistory(ctx, product.ID, 10)
+	require.NoError(t, err)
+	require.Len(t, history, 1)
+	assert.Equal(t, 1.0, history[0].OldPrice)
+	assert.Equal(t, 0.5, history[0].NewPrice)
+
+	changes, err = repo.GetDueScheduledPriceChanges(ctx, now, 10)
+	require.NoError(t, err)
+	assert.Empty(t, changes)
+}
+
+// TestGetProductNamesWithPrefixes verifica que se devuelven los nombres con
+// alguno de los prefijos literales, incluidos los de productos borrados.
+func TestGetProductNamesWithPrefixes(t *testing.T) {
+	database := newTestDatabase(t)
+	repo := repository.NewProductRepository(database)
+	ctx := context.Background()
+
+	seedProduct(t, database, "Pera-clone", time.Now())
+	deleted := seedProduct(t, database, "Pera-clone-2", time.Now())
+	seedProduct(t, database, "Pera_clone", time.Now())
+	seedProduct(t, database, "Manzana-clone", time.Now())
+	seedProduct(t, database, "Kiwi-clone", time.Now())
+	require.NoError(t, database.GetDB().Delete(deleted).Error)
+
+	names, err := repo.GetProductNamesWithPrefixes(ctx, []string{"Pera-clone", "Manzana-clone"})
+
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"Pera-clone", "Pera-clone-2", "Manzana-clone"}, names)
+}
+
+// TestGetProductsExpiringBetween verifica que solo se devuelven los productos
+// que caducan dentro de la ventana, límites incluidos, del más próximo al más
+// lejano. Los caducados y los que no tienen caducidad quedan fuera.
+func TestGetProductsExpiringBetween(t *testing.T) {
+	database := newTestDatabase(t)
+	repo := repository.NewProductRepository(database)
+	ctx := context.Background()
+
+	from := time.Now().UTC().Truncate(time.Second)
+	to := from.Add(24 * time.Hour)
+	seedExpiring := func(name string, expiresAt *time.Time) {
+		product := &productEntity.Product{Name: name, Price: 1, ExpiresAt: expiresAt}
+		require.NoError(t, database.Create(ctx, product))
+	}
+	at := func(d time.Duration) *time.Time {
+		v := from.Add(d)
+		return &v
+	}
+
+	seedExpiring("expired", at(-time.Hour))
+	seedExpiring("no-expiry", nil)
+	seedExpiring("later", at(12*time.Hour))
+	seedExpiring("soon", at(time.Hour))
+	seedExpiring("upper-bound", at(24*time.Hour))
+	seedExpiring("lower-bound", at(0))
+	seedExpiring("outside", at(25*time.Hour))
+
+	products, err := repo.GetProductsExpiringBetween(ctx, from, to, 10)
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{"lower-bound", "soon", "later", "upper-bound"}, productNames(products))
+}
+
+// TestGetOutOfStockProducts verifica que solo se devuelven los productos
+// activos sin stock, por nombre y paginados.
+func TestGetOutOfStockProducts(t *testing.T) {
+	database := newTestDatabase(t)
+	repo := repository.NewProductRepository(database)
+	ctx := context.Background()
+
+	now := time.Now()
+	seedProduct(t, database, "c-empty", now)
+	seedProduct(t, database, "a-empty", now)
+	seedProduct(t, database, "b-empty", now)
+	stocked := seedProduct(t, database, "stocked", now)
+	require.NoError(t, repo.UpdateProductStock(ctx, stocked.ID, 5))
+	inactive := seedProduct(t, database, "inactive", now)
+	_, err := repo.UpdateProductsActiveStatus(ctx, []string{inactive.ID}, false)
+	require.NoError(t, err)
+
+	products, pagination, err := repo.GetOutOfStockProducts(ctx, &paging.Pagination{Page: 1, Size: 2})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a-empty", "b-empty"}, productNames(products))
+	assert.Equal(t, int64(3), pagination.TotalCount)
+
+	products, _, err = repo.GetOutOfStockProducts(ctx, &paging.Pagination{Page: 2, Size: 2})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"c-empty"}, productNames(products))
+}
+
+// TestUpdateProductStock_StampsStockLastUpdatedAt verifica que cambiar el
+// stock actualiza la fecha del último cambio.
+func TestUpdateProductStock_StampsStockLastUpdatedAt(t *testing.T) {
+	database := newTestDatabase(t)
+	repo := repository.NewProductRepository(database)
+	ctx := context.Background()
+
+	product := seedProduct(t, database, "p", time.Now())
+	require.NoError(t, database.GetDB().Model(product).Update("stock_last_updated_at", time.Now().Add(-48*time.Hour)).Error)
+
+	require.NoError(t, repo.UpdateProductStock(ctx, product.ID, 3))
+
+	updated, err := repo.GetProductById(ctx, product.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 3, updated.Stock)
+	assert.WithinDuration(t, time.Now(), updated.StockLastUpdatedAt, time.Minute)
+}
+
+// TestListProducts_SearchFilter verifica que el filtro Search de ListProducts
+// encuentra coincidencias exactas, parciales y sin distinguir mayúsculas, y
+// que una consulta vacía no aplica ningún filtro.
+func TestListProducts_SearchFilter(t *testing.T) {
+	database := newTestDatabase(t)
+	repo := repository.NewProductRepository(database)
+
+	now := time.Now()
+	seedProduct(t, database, "Red Shirt", now.Add(-3*time.Hour))
+	seedProduct(t, database, "Blue Shirt", now.Add(-2*time.Hour))
+	seedProduct(t, database, "Green Hat", now.Add(-time.Hour))
+	seedProduct(t, database, "100% Cotton", now)
+
+	cases := []struct {
+		name     string
+		query    string
+		expected []string
+	}{
+		{"exact", "Green Hat", []string{"Green Hat"}},
+		{"partial", "shirt", []string{"Blue Shirt", "Red Shirt"}},
+		{"case insensitive", "rED sHiRt", []string{"Red Shirt"}},
+		{"wildcards escaped", "0%", []string{"100% Cotton"}},
+		{"empty", "", []string{"100% Cotton", "Green Hat", "Blue Shirt", "Red Shirt"}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			products, page, err := repo.ListProducts(context.Background(), &dto.ListProductRequest{Search: tc.query, Page: 1, Limit: 10})
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, productNames(products))
+			assert.Equal(t, int64(len(tc.expected)), page.TotalCount)
+		})
+	}
+}
+
+// TestCreateCategory_Nested verifica que se pueden crear categorías planas y
+// anidadas y que ListProducts filtra por CategoryID.
+func TestCreateCategory_Nested(t *testing.T) {
+	database := newTestDatabase(t)
+	categories := repository.NewCategoryRepository(database)
+	repo := repository.NewProductRepository(database)
+	ctx := context.Background()
+
+	clothing := &productEntity.Category{Name: "Clothing", Slug: "clothing"}
+	require.NoError(t, categories.CreateCategory(ctx, clothing))
+	shirts := &productEntity.Category{Name: "Shirts", Slug: "shirts", ParentID: &clothing.ID}
+	require.NoError(t, categories.CreateCategory(ctx, shirts))
+
+	stored, err := categories.GetByID(ctx, shirts.ID)
+	require.NoError(t, err)
+	assert.Equal(t, &clothing.ID, stored.ParentID)
+
+	all, err := categories.ListCategories(ctx)
+	require.NoError(t, err)
+	assert.Len(t, all, 2)
+
+	now := time.Now()
+	redShirt := seedProduct(t, database, "Red Shirt", now)
+	redShirt.CategoryID = &shirts.ID
+	require.NoError(t, repo.UpdateProduct(ctx, redShirt))
+	seedProduct(t, database, "Green Hat", now)
+
+	products, _, err := repo.ListProducts(ctx, &dto.ListProductRequest{CategoryID: shirts.ID, Page: 1, Limit: 10})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Red Shirt"}, productNames(products))
+}
+
+// TestGetInventoryReport_SortedByAvailableStock verifica que el informe resta
+// las reservas activas del stock y ordena por stock disponible ascendente,
+// para ver primero lo que está por agotarse.
+func TestGetInventoryReport_SortedByAvailableStock(t *testing.T) {
+	database := newTestDatabase(t)
+	require.NoError(t, database.AutoMigrate(&productEntity.StockReservation{}))
+	repo := repository.NewProductRepository(database)
+	ctx := context.Background()
+
+	category := &productEntity.Category{Name: "Fruta"}
+	require.NoError(t, database.Create(ctx, category))
+
+	now := time.Now()
+	for _, p := range []struct {
+		name     string
+		stock    int
+		reserved []int
+	}{
+		{"plenty", 50, nil},
+		{"reserved", 20, []int{8, 7}},
+		{"low", 3, nil},
+		{"mid", 10, []int{1}},
+	} {
+		product := seedProduct(t, database, p.name, now)
+		product.Stock = p.stock
+		product.CategoryID = &category.ID
+		require.NoError(t, database.Update(ctx, product))
+		for _, quantity := range p.reserved {
+			require.NoError(t, database.Create(ctx, &productEntity.StockReservation{ProductID: product.ID, Quantity: quantity}))
+		}
+	}
+
+	released := &productEntity.StockReservation{ProductID: "none", Quantity: 100}
+	require.NoError(t, database.Create(ctx, released))
+	require.NoError(t, database.Delete(ctx, released))
+
+	items, err := repo.GetInventoryReport(ctx)
+
+	require.NoError(t, err)
+	var names []string
+	var available []int
+	for _, item := range items {
+		names = append(names, item.Name)
+		available = append(available, item.AvailableStock)
+	}
+	assert.Equal(t, []string{"low", "reserved", "mid", "plenty"}, names)
+	assert.Equal(t, []int{3, 5, 9, 50}, available)
+	assert.Equal(t, 15, items[1].ReservedStock)
+	assert.Equal(t, "Fruta", items[0].CategoryName)
+}
+
+// TestCreateStockReservation_CountsAsReserved verifica que las reservas creadas
+// por el repositorio suman en GetReservedStock igual que en el informe de
+// inventario, y que las liberadas no cuentan en ninguno de los dos.
+func TestCreateStockReservation_CountsAsReserved(t *testing.T) {
+	database := newTestDatabase(t)
+	require.NoError(t, database.AutoMigrate(&productEntity.StockReservation{}))
+	repo := repository.NewProductRepository(database)
+	ctx := context.Background()
+
+	product := seedProduct(t, database, "reserved", time.Now())
+	product.Stock = 10
+	require.NoError(t, database.Update(ctx, product))
+
+	require.NoError(t, repo.CreateStockReservation(ctx, &productEntity.StockReservation{ProductID: product.ID, Quantity: 2}))
+	require.NoError(t, repo.CreateStockReservation(ctx, &productEntity.StockReservation{ProductID: product.ID, Quantity: 3}))
+	released := &productEntity.StockReservation{ProductID: product.ID, Quantity: 4}
+	require.NoError(t, repo.CreateStockReservation(ctx, released))
+	require.NoError(t, database.Delete(ctx, released))
+
+	reserved, err := repo.GetReservedStock(ctx, product.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 5, reserved)
+
+	items, err := repo.GetInventoryReport(ctx)
+	require.NoError(t, err)
+	require.Len(t, items, 1)
+	assert.Equal(t, reserved, items[0].ReservedStock)
+	assert.Equal(t, 5, items[0].AvailableStock)
+}
+
+// TestCategoryHasChildren verifica que una categoría con subcategorías o con
+// productos no está vacía, y que los borrados no cuentan.
+func TestCategoryHasChildren(t *testing.T) {
+	database := newTestDatabase(t)
+	repo := repository.NewCategoryRepository(database)
+	ctx := context.Background()
+
+	parent := &productEntity.Category{Name: "Clothing", Slug: "clothing"}
+	require.NoError(t, database.Create(ctx, parent))
+	child := &productEntity.Category{Name: "Shirts", Slug: "shirts", ParentID: &parent.ID}
+	require.NoError(t, database.Create(ctx, child))
+
+	hasChildren, err := repo.HasChildren(ctx, parent.ID)
+	require.NoError(t, err)
+	assert.True(t, hasChildren)
+
+	hasChildren, err = repo.HasChildren(ctx, child.ID)
+	require.NoError(t, err)
+	assert.False(t, hasChildren)
+
+	product := &productEntity.Product{Name: "Polo", Price: 1, CategoryID: &child.ID}
+	require.NoError(t, database.Create(ctx, product))
+	hasChildren, err = repo.HasChildren(ctx, child.ID)
+	require.NoError(t, err)
+	assert.True(t, hasChildren)
+
+	require.NoError(t, database.Delete(ctx, product))
+	hasChildren, err = repo.HasChildren(ctx, child.ID)
+	require.NoError(t, err)
+	assert.False(t, hasChildren)
+}
@@ -0,0 +1,82 @@
+package repository
+
+import (
+	"context"
+	"ecommerce_clean/configs"
+	"ecommerce_clean/db"
+	"ecommerce_clean/internals/product/entity"
+)
+
+type IReviewRepository interface {
+	CreateReview(ctx context.Context, review *entity.Review) error
+	GetReviewsByProductID(ctx context.Context, productID string) ([]*entity.Review, error)
+	GetReviewSummary(ctx context.Context, productID string) (map[int]int, error)
+	HasUserReviewed(ctx context.Context, productID, userID string) (bool, error)
+}
+
+type ReviewRepository struct {
+	db db.IDatabase
+}
+
+func NewReviewRepository(db db.IDatabase) *ReviewRepository {
+	return &ReviewRepository{db: db}
+}
+
+func (r *ReviewRepository) CreateReview(ctx context.Context, review *entity.Review) error {
+	return r.db.Create(ctx, review)
+}
+
+func (r *ReviewRepository) GetReviewsByProductID(ctx context.Context, productID string) ([]*entity.Review, error) {
+	var reviews []*entity.Review
+	opts := []db.FindOption{
+		db.WithQuery(db.NewQuery("product_id = ?", productID)),
+		db.WithOrder("created_at DESC"),
+	}
+
+	if err := r.db.Find(ctx, &reviews, opts...); err != nil {
+		return nil, err
+	}
+
+	return reviews, nil
+}
+
+// GetReviewSummary returns how many reviews the product has for each rating.
+// Ratings nobody gave are left out.
+func (r *ReviewRepository) GetReviewSummary(ctx context.Context, productID string) (map[int]int, error) {
+	ctx, cancel := context.WithTimeout(ctx, configs.DatabaseTimeout)
+	defer cancel()
+
+	var rows []struct {
+		Rating int
+		Count  int
+	}
+	err := r.db.Conn(ctx).
+		Model(&entity.Review{}).
+		Select("rating, COUNT(*) AS count").
+		Where("product_id = ?", productID).
+		Group("rating").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	distribution := make(map[int]int, len(rows))
+	for _, row := range rows {
+		distribution[row.Rating] = row.Count
+	}
+
+	return distribution, nil
+}
+
+func (r *ReviewRepository) HasUserReviewed(ctx context.Context, productID, userID string) (bool, error) {
+	var total int64
+	err := r.db.Count(ctx, &entity.Review{}, &total, db.WithQuery(
+		db.NewQuery("product_id = ?", productID),
+		db.NewQuery("user_id = ?", userID),
+	))
+	if err != nil {
+		return false, err
+	}
+
+	return total > 0, nil
+}
@@ -0,0 +1,33 @@
+package repository_test
+
+import (
+	"context"
+	"testing"
+
+	productEntity "ecommerce_clean/internals/product/entity"
+	"ecommerce_clean/internals/product/repository"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestCreateReview_UniquePerUser verifica que el índice único impide una
+// segunda reseña del mismo usuario, pero no cuenta las reseñas borradas.
+func TestCreateReview_UniquePerUser(t *testing.T) {
+	database := newTestDatabase(t)
+	require.NoError(t, database.AutoMigrate(&productEntity.Review{}))
+	repo := repository.NewReviewRepository(database)
+	ctx := context.Background()
+
+	first := &productEntity.Review{ProductID: "p1", UserID: "u1", Rating: 4}
+	require.NoError(t, repo.CreateReview(ctx, first))
+	assert.Error(t, repo.CreateReview(ctx, &productEntity.Review{ProductID: "p1", UserID: "u1", Rating: 2}))
+	require.NoError(t, repo.CreateReview(ctx, &productEntity.Review{ProductID: "p1", UserID: "u2", Rating: 2}))
+
+	require.NoError(t, database.Delete(ctx, first))
+	reviewed, err := repo.HasUserReviewed(ctx, "p1", "u1")
+	require.NoError(t, err)
+	assert.False(t, reviewed)
+
+	assert.NoError(t, repo.CreateReview(ctx, &productEntity.Review{ProductID: "p1", UserID: "u1", Rating: 5}))
+}
@@ -0,0 +1,28 @@
+package repository
+
+import (
+	"context"
+	"ecommerce_clean/db"
+	"ecommerce_clean/internals/product/entity"
+)
+
+type ISupplierRepository interface {
+	GetSupplierByID(ctx context.Context, id string) (*entity.Supplier, error)
+}
+
+type SupplierRepository struct {
+	db db.IDatabase
+}
+
+func NewSupplierRepository(db db.IDatabase) *SupplierRepository {
+	return &SupplierRepository{db: db}
+}
+
+func (r *SupplierRepository) GetSupplierByID(ctx context.Context, id string) (*entity.Supplier, error) {
+	var supplier entity.Supplier
+	if err := r.db.FindById(ctx, id, &supplier); err != nil {
+		return nil, err
+	}
+
+	return &supplier, nil
+}
@@ -0,0 +1,109 @@
+package usecase
+
+import (
+	"context"
+	"ecommerce_clean/internals/product/entity"
+	"ecommerce_clean/utils"
+	"errors"
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+const cloneNameSuffix = "-clone"
+
+// CloneProductCatalog copies the active products of one category into another.
+// Product names are unique, so every copy is named "<name>-clone", or
+// "<name>-clone-N" when that is taken. Stock, barcodes and external ids are
+// not copied. Each copy is stored on its own: one that fails, e.g. because
+// another request took its name meanwhile, is reported in the result and does
+// not stop the clone.
+func (pu *ProductUseCase) CloneProductCatalog(ctx context.Context, sourceCategoryID, targetCategoryID string, role string) (*entity.CatalogCloneResult, error) {
+	if role != utils.RoleAdmin {
+		return nil, ErrForbidden
+	}
+
+	if sourceCategoryID == targetCategoryID {
+		return nil, ErrSameCategory
+	}
+
+	for _, id := range []string{sourceCategoryID, targetCategoryID} {
+		if _, err := pu.categoryRepo.GetByID(ctx, id); err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, ErrCategoryNotFound
+			}
+			return nil, err
+		}
+	}
+
+	products, err := pu.productRepo.GetAllProductsByCategoryID(ctx, sourceCategoryID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &entity.CatalogCloneResult{Errors: []entity.CloneError{}}
+	sources := make([]*entity.Product, 0, len(products))
+	bases := make([]string, 0, len(products))
+	for _, product := range products {
+		if !product.Active {
+			result.Skipped++
+			continue
+		}
+		sources = append(sources, product)
+		bases = append(bases, product.Name+cloneNameSuffix)
+	}
+
+	if len(sources) == 0 {
+		return result, nil
+	}
+
+	taken, err := pu.productRepo.GetProductNamesWithPrefixes(ctx, bases)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make(map[string]struct{}, len(taken)+len(sources))
+	for _, name := range taken {
+		names[name] = struct{}{}
+	}
+
+	for i, product := range sources {
+		name := cloneName(bases[i], names)
+		names[name] = struct{}{}
+
+		categoryID := targetCategoryID
+		clone := &entity.Product{
+			Name:        name,
+			Images:      product.Images,
+			Description: product.Description,
+			Price:       product.Price,
+			SalePrice:   product.SalePrice,
+			Weight:      product.Weight,
+			CategoryID:  &categoryID,
+			SupplierID:  product.SupplierID,
+			Tags:        product.Tags,
+		}
+		if err := pu.productRepo.CreatedProduct(ctx, clone); err != nil {
+			result.Errors = append(result.Errors, entity.CloneError{ProductID: product.ID, Message: err.Error()})
+			continue
+		}
+		result.Cloned++
+	}
+
+	return result, nil
+}
+
+// cloneName returns base, or base followed by the lowest free numeric suffix
+// starting at 2, skipping the names already taken.
+func cloneName(base string, names map[string]struct{}) string {
+	if _, ok := names[base]; !ok {
+		return base
+	}
+
+	for i := 2; ; i++ {
+		candidate := fmt.Sprintf("%s-%d", base, i)
+		if _, ok := names[candidate]; !ok {
+			return candidate
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package usecase
+
+import (
+	"context"
+	"ecommerce_clean/internals/product/entity"
+	"time"
+)
+
+type ExternalCatalogSource interface {
+	FetchProducts(ctx context.Context) ([]*entity.ExternalProduct, error)
+}
+
+// SyncProductsFromExternalCatalog upserts the supplier catalog by ExternalID and
+// archives previously synced products that are no longer offered. Failures on
+// individual products are counted in the report instead of aborting the sync.
+func (pu *ProductUseCase) SyncProductsFromExternalCatalog(ctx context.Context, source ExternalCatalogSource) (*entity.SyncReport, error) {
+	externalProducts, err := source.FetchProducts(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	existing, err := pu.productRepo.GetExternalProducts(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	existingMap := make(map[string]*entity.Product, len(existing))
+	for _, product := range existing {
+		existingMap[product.ExternalID] = product
+	}
+
+	report := &entity.SyncReport{}
+	seen := make(map[string]struct{}, len(externalProducts))
+
+	for _, ext := range externalProducts {
+		seen[ext.ExternalID] = struct{}{}
+
+		product, ok := existingMap[ext.ExternalID]
+		if !ok {
+			product = &entity.Product{ExternalID: ext.ExternalID}
+		}
+		product.Name = ext.Name
+		product.Description = ext.Description
+		if ext.ImageUrl != "" {
+			product.Images = []string{ext.ImageUrl}
+		}
+		product.Price = ext.Price
+		if ok && product.Stock != ext.Stock {
+			product.StockLastUpdatedAt = time.Now()
+		}
+		product.Stock = ext.Stock
+
+		if !ok {
+			if err := pu.productRepo.CreatedProduct(ctx, product); err != nil {
+				report.Errors++
+				continue
+			}
+			report.Created++
+			continue
+		}
+
+		product.Active = true
+		if err := pu.productRepo.UpdateProduct(ctx, product); err != nil {
+			report.Errors++
+			continue
+		}
+		report.Updated++
+	}
+
+	for _, product := range existing {
+		if _, ok := seen[product.ExternalID]; ok || !product.Active {
+			continue
+		}
+
+		product.Active = false
+		if err := pu.productRepo.UpdateProduct(ctx, product); err != nil {
+			report.Errors++
+			continue
+		}
+		report.Deleted++
+	}
+
+	return report, nil
+}
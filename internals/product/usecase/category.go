@@ -0,0 +1,159 @@
+package usecase
+
+import (
+	"context"
+	"ecommerce_clean/internals/product/controller/dto"
+	"ecommerce_clean/internals/product/entity"
+	"ecommerce_clean/internals/product/repository"
+	"ecommerce_clean/pkgs/validation"
+	"errors"
+
+	"gorm.io/gorm"
+)
+
+type IProductCategoryUseCase interface {
+	CreateCategory(ctx context.Context, req *dto.CreateCategoryRequest) (*entity.Category, error)
+	GetCategoryByID(ctx context.Context, id string) (*entity.Category, error)
+	ListCategories(ctx context.Context) ([]*entity.Category, error)
+	UpdateCategory(ctx context.Context, req *dto.UpdateCategoryRequest) (*entity.Category, error)
+	DeleteCategory(ctx context.Context, id string) error
+}
+
+type ProductCategoryUseCase struct {
+	validator    validation.Validation
+	categoryRepo repository.ICategoryRepository
+}
+
+func NewProductCategoryUseCase(
+	validator validation.Validation,
+	categoryRepo repository.ICategoryRepository,
+) *ProductCategoryUseCase {
+	return &ProductCategoryUseCase{
+		validator:    validator,
+		categoryRepo: categoryRepo,
+	}
+}
+
+// CreateCategory stores a new category. When ParentID is set the parent must
+// already exist, which is how nested categories are built.
+func (cu *ProductCategoryUseCase) CreateCategory(ctx context.Context, req *dto.CreateCategoryRequest) (*entity.Category, error) {
+	if err := cu.validator.ValidateStruct(req); err != nil {
+		return nil, err
+	}
+
+	if err := cu.ensureParentExists(ctx, req.ParentID); err != nil {
+		return nil, err
+	}
+
+	category := &entity.Category{
+		Name:     req.Name,
+		Slug:     req.Slug,
+		ParentID: req.ParentID,
+	}
+	if err := cu.categoryRepo.CreateCategory(ctx, category); err != nil {
+		return nil, err
+	}
+
+	return category, nil
+}
+
+func (cu *ProductCategoryUseCase) GetCategoryByID(ctx context.Context, id string) (*entity.Category, error) {
+	return cu.categoryRepo.GetByID(ctx, id)
+}
+
+func (cu *ProductCategoryUseCase) ListCategories(ctx context.Context) ([]*entity.Category, error) {
+	return cu.categoryRepo.ListCategories(ctx)
+}
+
+// UpdateCategory saves the category's name, slug and parent. The new parent
+// must exist and must not be the category itself or one of its subcategories,
+// which would turn the tree into a cycle.
+func (cu *ProductCategoryUseCase) UpdateCategory(ctx context.Context, req *dto.UpdateCategoryRequest) (*entity.Category, error) {
+	if err := cu.validator.ValidateStruct(req); err != nil {
+		return nil, err
+	}
+
+	if req.ParentID != nil && *req.ParentID == req.ID {
+		return nil, ErrCategoryOwnParent
+	}
+
+	category, err := cu.categoryRepo.GetByID(ctx, req.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := cu.ensureParentExists(ctx, req.ParentID); err != nil {
+		return nil, err
+	}
+
+	if err := cu.ensureNotDescendant(ctx, req.ID, req.ParentID); err != nil {
+		return nil, err
+	}
+
+	category.Name = req.Name
+	category.Slug = req.Slug
+	category.ParentID = req.ParentID
+	if err := cu.categoryRepo.UpdateCategory(ctx, category); err != nil {
+		return nil, err
+	}
+
+	return category, nil
+}
+
+// DeleteCategory deletes an empty category. Categories that still have
+// subcategories or products return ErrCategoryNotEmpty; those have to be moved
+// or deleted first.
+func (cu *ProductCategoryUseCase) DeleteCategory(ctx context.Context, id string) error {
+	category, err := cu.categoryRepo.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	hasChildren, err := cu.categoryRepo.HasChildren(ctx, id)
+	if err != nil {
+		return err
+	}
+	if hasChildren {
+		return ErrCategoryNotEmpty
+	}
+
+	return cu.categoryRepo.DeleteCategory(ctx, category)
+}
+
+func (cu *ProductCategoryUseCase) ensureParentExists(ctx context.Context, parentID *string) error {
+	if parentID == nil {
+		return nil
+	}
+
+	if _, err := cu.categoryRepo.GetByID(ctx, *parentID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrParentCategoryNotFound
+		}
+		return err
+	}
+
+	return nil
+}
+
+// ensureNotDescendant walks up from parentID to the root and fails with
+// ErrCategoryCycle if it meets categoryID on the way.
+func (cu *ProductCategoryUseCase) ensureNotDescendant(ctx context.Context, categoryID string, parentID *string) error {
+	visited := make(map[string]struct{})
+	for parentID != nil {
+		if *parentID == categoryID {
+			return ErrCategoryCycle
+		}
+		if _, ok := visited[*parentID]; ok {
+			return ErrCategoryCycle
+		}
+		visited[*parentID] = struct{}{}
+
+		parent, err := cu.categoryRepo.GetByID(ctx, *parentID)
+		if err != nil {
+			return err
+		}
+		parentID = parent.ParentID
+	}
+
+	return nil
+}
@@ -0,0 +1,34 @@
+package usecase
+
+import "errors"
+
+var (
+	ErrForbidden                = errors.New("forbidden")
+	ErrInvalidSince             = errors.New("since must not be in the future")
+	ErrInvalidLimit             = errors.New("limit must be between 1 and 100")
+	ErrNoNewArrivals            = errors.New("no new arrivals")
+	ErrInvalidBarcode           = errors.New("invalid barcode")
+	ErrEmptyProductIDs          = errors.New("product ids must not be empty")
+	ErrTooManyProductIDs        = errors.New("too many product ids, maximum is 500")
+	ErrInvalidQuantity          = errors.New("quantity must be positive")
+	ErrInsufficientStock        = errors.New("insufficient stock")
+	ErrTooManyImages            = errors.New("too many images, maximum is 10")
+	ErrInvalidImageURL          = errors.New("image url must be an absolute https url")
+	ErrInvalidImportPayload     = errors.New("import payload must be a JSON array")
+	ErrInvalidHistoryLimit      = errors.New("limit must be at least 1")
+	ErrTemplateNotFound         = errors.New("product template not found")
+	ErrCategoryNotFound         = errors.New("category not found")
+	ErrInvalidPrice             = errors.New("price must be positive")
+	ErrInvalidEffectiveAt       = errors.New("effective date must be in the future")
+	ErrEmptyBundle              = errors.New("bundle must contain at least one product")
+	ErrTooManyBundleItems       = errors.New("too many bundle items, maximum is 20")
+	ErrBundleProductUnavailable = errors.New("bundle contains unknown or inactive products")
+	ErrAlreadyReviewed          = errors.New("user has already reviewed this product")
+	ErrSameCategory             = errors.New("source and target categories must differ")
+	ErrInvalidExpiryWindow      = errors.New("expiry window must be positive")
+	ErrQueryTooShort            = errors.New("search query must be at least 2 characters")
+	ErrParentCategoryNotFound   = errors.New("parent category not found")
+	ErrCategoryOwnParent        = errors.New("category cannot be its own parent")
+	ErrCategoryCycle            = errors.New("category cannot be moved under one of its subcategories")
+	ErrCategoryNotEmpty         = errors.New("category still has subcategories or products")
+)
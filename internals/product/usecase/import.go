@@ -0,0 +1,54 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"ecommerce_clean/internals/product/controller/dto"
+	"ecommerce_clean/internals/product/entity"
+	"ecommerce_clean/utils"
+)
+
+// ImportProductsFromJSON creates products from a JSON array of
+// CreateProductRequest objects. Invalid entries are reported in the result by
+// their position in the array and do not stop the import; a payload that is
+// not a JSON array fails as a whole.
+func (pu *ProductUseCase) ImportProductsFromJSON(ctx context.Context, reader io.Reader) (*entity.ImportResult, error) {
+	var items []json.RawMessage
+	if err := json.NewDecoder(reader).Decode(&items); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidImportPayload, err)
+	}
+
+	result := &entity.ImportResult{Total: len(items), Errors: []entity.ImportError{}}
+	products := make([]*entity.Product, 0, len(items))
+
+	for i, item := range items {
+		var req dto.CreateProductRequest
+		if err := json.Unmarshal(item, &req); err != nil {
+			result.Errors = append(result.Errors, entity.ImportError{Index: i, Message: err.Error()})
+			continue
+		}
+
+		if err := pu.validator.ValidateStruct(&req); err != nil {
+			result.Errors = append(result.Errors, entity.ImportError{Index: i, Message: err.Error()})
+			continue
+		}
+
+		var product entity.Product
+		utils.MapStruct(&product, &req)
+		products = append(products, &product)
+	}
+
+	if len(products) == 0 {
+		return result, nil
+	}
+
+	if err := pu.productRepo.CreateProducts(ctx, products); err != nil {
+		return nil, err
+	}
+	result.Imported = len(products)
+
+	return result, nil
+}
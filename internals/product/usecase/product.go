@@ -2,6 +2,7 @@ package usecase
 
 import (
 	"context"
+	cartEntity "ecommerce_clean/internals/cart/entity"
 	"ecommerce_clean/internals/product/controller/dto"
 	"ecommerce_clean/internals/product/entity"
 	"ecommerce_clean/internals/product/repository"
@@ -10,6 +11,16 @@ import (
 	"ecommerce_clean/pkgs/paging"
 	"ecommerce_clean/pkgs/validation"
 	"ecommerce_clean/utils"
+	"errors"
+	"io"
+	"math"
+	"net/url"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"golang.org/x/sync/errgroup"
+	"gorm.io/gorm"
 )
 
 type IProductUseCase interface {
@@ -18,27 +29,94 @@ type IProductUseCase interface {
 	CreateProduct(ctx context.Context, req *dto.CreateProductRequest) error
 	UpdateProduct(ctx context.Context, req *dto.UpdateProductRequest) error
 	DeleteProduct(ctx context.Context, id string) error
+	GetProductInventoryReport(ctx context.Context, role string) ([]*entity.InventoryItem, error)
+	SyncProductsFromExternalCatalog(ctx context.Context, source ExternalCatalogSource) (*entity.SyncReport, error)
+	GetNewArrivals(ctx context.Context, since time.Time, limit int) ([]*entity.Product, error)
+	GetProductByBarcode(ctx context.Context, barcode string) (*entity.Product, error)
+	GetProductStock(ctx context.Context, productID string) (int, error)
+	GetProductStockAlert(ctx context.Context, productID string) (*entity.StockAlert, error)
+	ComputeCartItemAvailability(ctx context.Context, cartLines []*cartEntity.CartLine) ([]*entity.AvailabilityResult, error)
+	GetProductsOnSale(ctx context.Context, limit int) ([]*entity.ProductWithSalePrice, error)
+	PreviewProductDelete(ctx context.Context, productID string) (*entity.DeleteImpact, error)
+	GetProductMeta(ctx context.Context, productID string) (*entity.ProductMeta, error)
+	GetProductsBySupplier(ctx context.Context, supplierID string, req *paging.Pagination, requesterID, role string) ([]*entity.Product, *paging.Pagination, error)
+	Autocomplete(ctx context.Context, query string, limit int) ([]*entity.AutocompleteResult, error)
+	CreateProductFromTemplate(ctx context.Context, templateID string, overrides *dto.CreateProductRequest, role string) (*entity.Product, error)
+	GetProductsByCategorySlug(ctx context.Context, slug string, req *paging.Pagination) ([]*entity.Product, *paging.Pagination, error)
+	BulkActivateProducts(ctx context.Context, ids []string, role string) (*entity.BulkResult, error)
+	BulkDeactivateProducts(ctx context.Context, ids []string, role string) (*entity.BulkResult, error)
+	ReserveStock(ctx context.Context, productID string, quantity int) error
+	UpdateProductImages(ctx context.Context, productID string, imageURLs []string, role string) error
+	ImportProductsFromJSON(ctx context.Context, reader io.Reader) (*entity.ImportResult, error)
+	GetProductPriceHistory(ctx context.Context, productID string, limit int) ([]*entity.PriceHistory, error)
+	GetProductsUpdatedSince(ctx context.Context, lastSyncAt time.Time) ([]*entity.Product, error)
+	SchedulePriceChange(ctx context.Context, productID string, newPrice float64, effectiveAt time.Time, role string) error
+	ProcessScheduledPriceChanges(ctx context.Context) (int, error)
+	GetBundlePrice(ctx context.Context, productIDs []string) (*entity.BundlePrice, error)
+	CloneProductCatalog(ctx context.Context, sourceCategoryID, targetCategoryID string, role string) (*entity.CatalogCloneResult, error)
+	GetProductsExpiringSoon(ctx context.Context, within time.Duration, limit int, role string) ([]*entity.Product, error)
+	GetOutOfStockProducts(ctx context.Context, role string, req *paging.Pagination) ([]*entity.Product, *paging.Pagination, error)
+}
+
+// bundleDiscounts maps a minimum number of distinct products to the percentage
+// taken off the bundle, largest bundles first.
+var bundleDiscounts = []struct {
+	minItems int
+	percent  float64
+}{
+	{5, 15},
+	{3, 10},
+	{2, 5},
 }
 
+const (
+	maxBulkProductIDs = 500
+	maxProductImages  = 10
+	maxPriceHistory   = 50
+	maxSyncProducts   = 5000
+
+	scheduledPriceChangeBatchSize = 500
+	maxBundleItems                = 20
+
+	defaultAutocompleteLimit = 5
+	maxAutocompleteLimit     = 20
+
+	minQueryLength = 2
+
+	lowStockThreshold      = 10
+	criticalStockThreshold = 2
+)
+
 type ProductUseCase struct {
-	validator   validation.Validation
-	productRepo repository.IProductRepository
-	minioClient minio.IUploadService
+	validator    validation.Validation
+	productRepo  repository.IProductRepository
+	minioClient  minio.IUploadService
+	templateRepo repository.IProductTemplateRepository
+	categoryRepo repository.ICategoryRepository
 }
 
 func NewProductUseCase(
 	validator validation.Validation,
 	productRepo repository.IProductRepository,
 	minioClient minio.IUploadService,
+	templateRepo repository.IProductTemplateRepository,
+	categoryRepo repository.ICategoryRepository,
 ) *ProductUseCase {
 	return &ProductUseCase{
-		validator:   validator,
-		productRepo: productRepo,
-		minioClient: minioClient,
+		validator:    validator,
+		productRepo:  productRepo,
+		minioClient:  minioClient,
+		templateRepo: templateRepo,
+		categoryRepo: categoryRepo,
 	}
 }
 
 func (pu *ProductUseCase) ListProducts(ctx context.Context, req *dto.ListProductRequest) ([]*entity.Product, *paging.Pagination, error) {
+	req.Search = strings.TrimSpace(req.Search)
+	if req.Search != "" && utf8.RuneCountInString(req.Search) < minQueryLength {
+		return nil, nil, ErrQueryTooShort
+	}
+
 	products, pagination, err := pu.productRepo.ListProducts(ctx, req)
 	if err != nil {
 		return nil, nil, err
@@ -59,19 +137,19 @@ func (pu *ProductUseCase) CreateProduct(ctx context.Context, req *dto.CreateProd
 		return err
 	}
 
-	var imageUrlUpload = ""
+	var images []string
 	if req.Image != nil {
 		avatarURL, err := pu.minioClient.UploadFile(ctx, req.Image, "products")
 		if err != nil {
 			logger.Errorf("Failed to upload avatar: %s", err)
 			return err
 		}
-		imageUrlUpload = avatarURL
+		images = append(images, avatarURL)
 	}
 
 	var product entity.Product
 	utils.MapStruct(&product, &req)
-	product.ImageUrl = imageUrlUpload
+	product.Images = images
 
 	err := pu.productRepo.CreatedProduct(ctx, &product)
 	if err != nil {
@@ -103,9 +181,12 @@ func (pu *ProductUseCase) UpdateProduct(ctx context.Context, req *dto.UpdateProd
 			return err
 		}
 
-		pu.minioClient.DeleteFile(ctx, product.ImageUrl)
-
-		product.ImageUrl = avatarURL
+		if len(product.Images) > 0 {
+			pu.minioClient.DeleteFile(ctx, product.Images[0])
+			product.Images[0] = avatarURL
+		} else {
+			product.Images = []string{avatarURL}
+		}
 	}
 
 	err = pu.productRepo.UpdateProduct(ctx, product)
@@ -127,7 +208,630 @@ func (pu *ProductUseCase) DeleteProduct(ctx context.Context, id string) error {
 		return err
 	}
 
-	pu.minioClient.DeleteFile(ctx, product.ImageUrl)
+	for _, image := range product.Images {
+		pu.minioClient.DeleteFile(ctx, image)
+	}
 
 	return nil
 }
+
+func (pu *ProductUseCase) GetProductInventoryReport(ctx context.Context, role string) ([]*entity.InventoryItem, error) {
+	if role != utils.RoleAdmin {
+		return nil, ErrForbidden
+	}
+
+	items, err := pu.productRepo.GetInventoryReport(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return items, nil
+}
+
+func (pu *ProductUseCase) GetNewArrivals(ctx context.Context, since time.Time, limit int) ([]*entity.Product, error) {
+	if since.After(time.Now()) {
+		return nil, ErrInvalidSince
+	}
+
+	if limit < 1 || limit > 100 {
+		return nil, ErrInvalidLimit
+	}
+
+	products, err := pu.productRepo.GetNewArrivals(ctx, since, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(products) == 0 {
+		return nil, ErrNoNewArrivals
+	}
+
+	return products, nil
+}
+
+func (pu *ProductUseCase) GetProductByBarcode(ctx context.Context, barcode string) (*entity.Product, error) {
+	if barcode == "" || len(barcode) > 50 {
+		return nil, ErrInvalidBarcode
+	}
+
+	product, err := pu.productRepo.GetProductByBarcode(ctx, barcode)
+	if err != nil {
+		return nil, err
+	}
+	return product, nil
+}
+
+func (pu *ProductUseCase) GetProductStock(ctx context.Context, productID string) (int, error) {
+	return pu.productRepo.GetProductStock(ctx, productID)
+}
+
+// GetProductStockAlert reports the product's stock net of active reservations
+// and how close it is to running out.
+func (pu *ProductUseCase) GetProductStockAlert(ctx context.Context, productID string) (*entity.StockAlert, error) {
+	stock, err := pu.productRepo.GetProductStock(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+
+	reserved, err := pu.productRepo.GetReservedStock(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+
+	available := stock - reserved
+	return &entity.StockAlert{
+		ProductID:      productID,
+		CurrentStock:   stock,
+		ReservedStock:  reserved,
+		AvailableStock: available,
+		AlertLevel:     stockAlertLevel(available),
+	}, nil
+}
+
+func stockAlertLevel(available int) string {
+	switch {
+	case available <= 0:
+		return entity.StockAlertOut
+	case available <= criticalStockThreshold:
+		return entity.StockAlertCritical
+	case available <= lowStockThreshold:
+		return entity.StockAlertLow
+	default:
+		return entity.StockAlertOK
+	}
+}
+
+// ComputeCartItemAvailability checks stock for every cart line with a single
+// product lookup. Results follow the order of cartLines; unknown products are
+// reported as unavailable.
+func (pu *ProductUseCase) ComputeCartItemAvailability(ctx context.Context, cartLines []*cartEntity.CartLine) ([]*entity.AvailabilityResult, error) {
+	results := make([]*entity.AvailabilityResult, 0, len(cartLines))
+	if len(cartLines) == 0 {
+		return results, nil
+	}
+
+	ids := make([]string, 0, len(cartLines))
+	for _, line := range cartLines {
+		ids = append(ids, line.ProductID)
+	}
+
+	products, err := pu.productRepo.GetProductsByIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	stockMap := make(map[string]int, len(products))
+	for _, product := range products {
+		stockMap[product.ID] = product.Stock
+	}
+
+	for _, line := range cartLines {
+		stock, found := stockMap[line.ProductID]
+		results = append(results, &entity.AvailabilityResult{
+			ProductID:    line.ProductID,
+			RequestedQty: int(line.Quantity),
+			AvailableQty: stock,
+			IsAvailable:  found && stock >= int(line.Quantity),
+		})
+	}
+
+	return results, nil
+}
+
+func (pu *ProductUseCase) GetProductsOnSale(ctx context.Context, limit int) ([]*entity.ProductWithSalePrice, error) {
+	if limit < 1 || limit > 100 {
+		return nil, ErrInvalidLimit
+	}
+
+	products, err := pu.productRepo.GetProductsOnSale(ctx, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*entity.ProductWithSalePrice, 0, len(products))
+	for _, product := range products {
+		if product.SalePrice == nil || *product.SalePrice >= product.Price {
+			continue
+		}
+
+		savings := product.Price - *product.SalePrice
+		result = append(result, &entity.ProductWithSalePrice{
+			Product:        product,
+			SavingsAmount:  savings,
+			SavingsPercent: math.Round(savings/product.Price*10000) / 100,
+		})
+	}
+
+	return result, nil
+}
+
+func (pu *ProductUseCase) PreviewProductDelete(ctx context.Context, productID string) (*entity.DeleteImpact, error) {
+	impact := &entity.DeleteImpact{}
+
+	g, gCtx := errgroup.WithContext(ctx)
+
+	g.Go(func() error {
+		_, err := pu.productRepo.GetProductById(gCtx, productID)
+		return err
+	})
+
+	g.Go(func() error {
+		count, err := pu.productRepo.CountCartLinesByProduct(gCtx, productID)
+		if err != nil {
+			return err
+		}
+		impact.ActiveCartLines = count
+		return nil
+	})
+
+	g.Go(func() error {
+		count, err := pu.productRepo.CountOpenOrdersByProduct(gCtx, productID)
+		if err != nil {
+			return err
+		}
+		impact.OpenOrders = count
+		return nil
+	})
+
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+
+	impact.CanDelete = impact.ActiveCartLines == 0 && impact.OpenOrders == 0
+	return impact, nil
+}
+
+func (pu *ProductUseCase) BulkActivateProducts(ctx context.Context, ids []string, role string) (*entity.BulkResult, error) {
+	return pu.bulkSetActive(ctx, ids, role, true)
+}
+
+// GetProductsExpiringSoon lists products that expire between now and now+within.
+// Already expired products are left out.
+func (pu *ProductUseCase) GetProductsExpiringSoon(ctx context.Context, within time.Duration, limit int, role string) ([]*entity.Product, error) {
+	if role != utils.RoleAdmin {
+		return nil, ErrForbidden
+	}
+
+	if within <= 0 {
+		return nil, ErrInvalidExpiryWindow
+	}
+
+	if limit < 1 || limit > 100 {
+		return nil, ErrInvalidLimit
+	}
+
+	now := time.Now()
+	return pu.productRepo.GetProductsExpiringBetween(ctx, now, now.Add(within), limit)
+}
+
+// GetOutOfStockProducts lists the active products that need restocking, with
+// how many days each has been out of stock.
+func (pu *ProductUseCase) GetOutOfStockProducts(ctx context.Context, role string, req *paging.Pagination) ([]*entity.Product, *paging.Pagination, error) {
+	if role != utils.RoleAdmin {
+		return nil, nil, ErrForbidden
+	}
+
+	products, pagination, err := pu.productRepo.GetOutOfStockProducts(ctx, req)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for _, product := range products {
+		if !product.StockLastUpdatedAt.IsZero() {
+			product.DaysOutOfStock = int(time.Since(product.StockLastUpdatedAt).Hours() / 24)
+		}
+	}
+
+	return products, pagination, nil
+}
+
+func (pu *ProductUseCase) BulkDeactivateProducts(ctx context.Context, ids []string, role string) (*entity.BulkResult, error) {
+	return pu.bulkSetActive(ctx, ids, role, false)
+}
+
+func (pu *ProductUseCase) bulkSetActive(ctx context.Context, ids []string, role string, isActive bool) (*entity.BulkResult, error) {
+	if role != utils.RoleAdmin {
+		return nil, ErrForbidden
+	}
+
+	if len(ids) == 0 {
+		return nil, ErrEmptyProductIDs
+	}
+
+	if len(ids) > maxBulkProductIDs {
+		return nil, ErrTooManyProductIDs
+	}
+
+	affected, err := pu.productRepo.UpdateProductsActiveStatus(ctx, ids, isActive)
+	if err != nil {
+		return nil, err
+	}
+
+	return &entity.BulkResult{Requested: len(ids), Affected: affected}, nil
+}
+
+// ReserveStock holds quantity units of the product by recording a stock
+// reservation. The stock itself is left as is; reservations are subtracted
+// from it wherever available stock is reported. The product row stays locked
+// from the read until the reservation commits, so concurrent reservations
+// queue up instead of retrying.
+func (pu *ProductUseCase) ReserveStock(ctx context.Context, productID string, quantity int) error {
+	if quantity <= 0 {
+		return ErrInvalidQuantity
+	}
+
+	return pu.productRepo.WithinTransaction(ctx, func(ctx context.Context) error {
+		stock, err := pu.productRepo.GetProductStockForUpdate(ctx, productID, repository.WithPessimisticLock())
+		if err != nil {
+			return err
+		}
+
+		reserved, err := pu.productRepo.GetReservedStock(ctx, productID)
+		if err != nil {
+			return err
+		}
+
+		if stock-reserved < quantity {
+			return ErrInsufficientStock
+		}
+
+		return pu.productRepo.CreateStockReservation(ctx, &entity.StockReservation{
+			ProductID: productID,
+			Quantity:  quantity,
+		})
+	})
+}
+
+// UpdateProductImages replaces the product's images. An empty list clears them.
+func (pu *ProductUseCase) UpdateProductImages(ctx context.Context, productID string, imageURLs []string, role string) error {
+	if role != utils.RoleAdmin {
+		return ErrForbidden
+	}
+
+	if len(imageURLs) > maxProductImages {
+		return ErrTooManyImages
+	}
+
+	for _, imageURL := range imageURLs {
+		u, err := url.Parse(imageURL)
+		if err != nil || u.Scheme != "https" || u.Host == "" {
+			return ErrInvalidImageURL
+		}
+	}
+
+	product, err := pu.productRepo.GetProductById(ctx, productID)
+	if err != nil {
+		return err
+	}
+
+	product.Images = nil
+	if len(imageURLs) > 0 {
+		product.Images = imageURLs
+	}
+
+	return pu.productRepo.UpdateProduct(ctx, product)
+}
+
+// GetProductPriceHistory returns the most recent price changes of a product,
+// newest first. Limits above 50 are clamped to 50.
+func (pu *ProductUseCase) GetProductPriceHistory(ctx context.Context, productID string, limit int) ([]*entity.PriceHistory, error) {
+	if limit < 1 {
+		return nil, ErrInvalidHistoryLimit
+	}
+	if limit > maxPriceHistory {
+		limit = maxPriceHistory
+	}
+
+	if _, err := pu.productRepo.GetProductById(ctx, productID); err != nil {
+		return nil, err
+	}
+
+	history, err := pu.productRepo.GetPriceHistory(ctx, productID, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	if history == nil {
+		history = []*entity.PriceHistory{}
+	}
+
+	return history, nil
+}
+
+// GetProductsUpdatedSince returns products changed after lastSyncAt, oldest
+// change first and at most 5000 of them. Deleted products are included with
+// DeletedAt set. Callers fetch the rest by syncing again from the last change.
+func (pu *ProductUseCase) GetProductsUpdatedSince(ctx context.Context, lastSyncAt time.Time) ([]*entity.Product, error) {
+	return pu.productRepo.GetProductsUpdatedSince(ctx, lastSyncAt, maxSyncProducts)
+}
+
+// GetProductMeta runs every stat query concurrently. Sales figures are
+// required; ratings are best effort and left at zero when their query fails.
+func (pu *ProductUseCase) GetProductMeta(ctx context.Context, productID string) (*entity.ProductMeta, error) {
+	meta := &entity.ProductMeta{}
+
+	g, gCtx := errgroup.WithContext(ctx)
+
+	g.Go(func() error {
+		_, err := pu.productRepo.GetProductById(gCtx, productID)
+		return err
+	})
+
+	g.Go(func() error {
+		sold, err := pu.productRepo.GetProductUnitsSold(gCtx, productID)
+		if err != nil {
+			return err
+		}
+		meta.TotalSold = sold
+		return nil
+	})
+
+	g.Go(func() error {
+		revenue, err := pu.productRepo.GetProductRevenue(gCtx, productID)
+		if err != nil {
+			return err
+		}
+		meta.TotalRevenue = revenue
+		return nil
+	})
+
+	g.Go(func() error {
+		average, count, err := pu.productRepo.GetProductRatingStats(gCtx, productID)
+		if err != nil {
+			logger.Errorf("Get rating stats fail, id: %s, error: %s", productID, err)
+			return nil
+		}
+		meta.AverageRating = average
+		meta.ReviewCount = count
+		return nil
+	})
+
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+
+	return meta, nil
+}
+
+// GetProductsBySupplier lists a supplier's products. Admins may list any
+// supplier; supplier accounts, whose user ID is their supplier ID, only their own.
+func (pu *ProductUseCase) GetProductsBySupplier(ctx context.Context, supplierID string, req *paging.Pagination, requesterID, role string) ([]*entity.Product, *paging.Pagination, error) {
+	switch role {
+	case utils.RoleAdmin:
+	case utils.RoleSupplier:
+		if requesterID != supplierID {
+			return nil, nil, ErrForbidden
+		}
+	default:
+		return nil, nil, ErrForbidden
+	}
+
+	return pu.productRepo.GetProductsBySupplier(ctx, supplierID, req)
+}
+
+// Autocomplete returns up to limit products whose name starts with query. A
+// blank query yields no results rather than the whole catalog.
+func (pu *ProductUseCase) Autocomplete(ctx context.Context, query string, limit int) ([]*entity.AutocompleteResult, error) {
+	if limit < 1 {
+		limit = defaultAutocompleteLimit
+	}
+	if limit > maxAutocompleteLimit {
+		limit = maxAutocompleteLimit
+	}
+
+	results := make([]*entity.AutocompleteResult, 0, limit)
+
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return results, nil
+	}
+
+	products, err := pu.productRepo.SearchProductsByNamePrefix(ctx, query, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, product := range products {
+		results = append(results, &entity.AutocompleteResult{
+			ID:       product.ID,
+			Name:     product.Name,
+			Price:    product.Price,
+			ImageURL: product.GetPrimaryImage(),
+		})
+	}
+
+	return results, nil
+}
+
+// CreateProductFromTemplate creates a product from a template's defaults.
+// Non-empty fields of overrides replace the matching defaults.
+func (pu *ProductUseCase) CreateProductFromTemplate(ctx context.Context, templateID string, overrides *dto.CreateProductRequest, role string) (*entity.Product, error) {
+	if role != utils.RoleAdmin {
+		return nil, ErrForbidden
+	}
+
+	template, err := pu.templateRepo.GetTemplateByID(ctx, templateID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrTemplateNotFound
+		}
+		return nil, err
+	}
+
+	product := &entity.Product{
+		Name:       template.Name,
+		CategoryID: template.CategoryID,
+		Price:      template.DefaultPrice,
+		Tags:       append([]string(nil), template.DefaultTags...),
+	}
+
+	if overrides != nil {
+		if overrides.Name != "" {
+			product.Name = overrides.Name
+		}
+		if overrides.Description != "" {
+			product.Description = overrides.Description
+		}
+		if overrides.Price > 0 {
+			product.Price = overrides.Price
+		}
+		if overrides.CategoryID != nil {
+			product.CategoryID = overrides.CategoryID
+		}
+		if overrides.Tags != nil {
+			product.Tags = overrides.Tags
+		}
+		if overrides.Image != nil {
+			imageURL, err := pu.minioClient.UploadFile(ctx, overrides.Image, "products")
+			if err != nil {
+				logger.Errorf("Failed to upload avatar: %s", err)
+				return nil, err
+			}
+			product.Images = []string{imageURL}
+		}
+	}
+
+	if err := pu.productRepo.CreatedProduct(ctx, product); err != nil {
+		logger.Errorf("Create fail, error: %s", err)
+		return nil, err
+	}
+
+	return product, nil
+}
+
+// GetProductsByCategorySlug lists the products of the category identified by
+// slug. The slug may still be URL-encoded.
+func (pu *ProductUseCase) GetProductsByCategorySlug(ctx context.Context, slug string, req *paging.Pagination) ([]*entity.Product, *paging.Pagination, error) {
+	slug, err := url.PathUnescape(strings.TrimSpace(slug))
+	if err != nil || slug == "" {
+		return nil, nil, ErrCategoryNotFound
+	}
+
+	category, err := pu.categoryRepo.GetBySlug(ctx, slug)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, ErrCategoryNotFound
+		}
+		return nil, nil, err
+	}
+
+	return pu.productRepo.GetProductsByCategoryID(ctx, category.ID, req)
+}
+
+// SchedulePriceChange records a price that ProcessScheduledPriceChanges will
+// apply to the product once effectiveAt is reached.
+func (pu *ProductUseCase) SchedulePriceChange(ctx context.Context, productID string, newPrice float64, effectiveAt time.Time, role string) error {
+	if role != utils.RoleAdmin {
+		return ErrForbidden
+	}
+
+	if newPrice <= 0 {
+		return ErrInvalidPrice
+	}
+
+	if !effectiveAt.After(time.Now()) {
+		return ErrInvalidEffectiveAt
+	}
+
+	if _, err := pu.productRepo.GetProductById(ctx, productID); err != nil {
+		return err
+	}
+
+	return pu.productRepo.CreateScheduledPriceChange(ctx, &entity.ScheduledPriceChange{
+		ProductID:   productID,
+		NewPrice:    newPrice,
+		EffectiveAt: effectiveAt,
+	})
+}
+
+// ProcessScheduledPriceChanges applies every due price change and returns how
+// many were applied. A change that fails is logged and retried on the next run.
+func (pu *ProductUseCase) ProcessScheduledPriceChanges(ctx context.Context) (int, error) {
+	changes, err := pu.productRepo.GetDueScheduledPriceChanges(ctx, time.Now(), scheduledPriceChangeBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	applied := 0
+	for _, change := range changes {
+		if err := pu.productRepo.ApplyScheduledPriceChange(ctx, change); err != nil {
+			logger.Errorf("Failed to apply scheduled price change, id: %s, error: %s", change.ID, err)
+			continue
+		}
+		applied++
+	}
+
+	return applied, nil
+}
+
+// GetBundlePrice prices the given products bought together. Duplicate ids are
+// counted once and every product must exist and be active.
+func (pu *ProductUseCase) GetBundlePrice(ctx context.Context, productIDs []string) (*entity.BundlePrice, error) {
+	ids := make([]string, 0, len(productIDs))
+	seen := make(map[string]struct{}, len(productIDs))
+	for _, id := range productIDs {
+		if _, ok := seen[id]; ok {
+			continue
+		}
+		seen[id] = struct{}{}
+		ids = append(ids, id)
+	}
+
+	if len(ids) == 0 {
+		return nil, ErrEmptyBundle
+	}
+
+	if len(ids) > maxBundleItems {
+		return nil, ErrTooManyBundleItems
+	}
+
+	products, err := pu.productRepo.GetProductsByIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(products) != len(ids) {
+		return nil, ErrBundleProductUnavailable
+	}
+
+	bundle := &entity.BundlePrice{Products: products}
+	for _, product := range products {
+		if !product.Active {
+			return nil, ErrBundleProductUnavailable
+		}
+		bundle.IndividualTotal += product.Price
+	}
+
+	for _, rule := range bundleDiscounts {
+		if len(products) >= rule.minItems {
+			bundle.DiscountPercent = rule.percent
+			break
+		}
+	}
+
+	bundle.IndividualTotal = math.Round(bundle.IndividualTotal*100) / 100
+	bundle.BundleDiscount = math.Round(bundle.IndividualTotal*bundle.DiscountPercent) / 100
+	bundle.BundleTotal = math.Round((bundle.IndividualTotal-bundle.BundleDiscount)*100) / 100
+
+	return bundle, nil
+}
@@ -0,0 +1,105 @@
+package usecase
+
+import (
+	"context"
+	"ecommerce_clean/internals/product/controller/dto"
+	"ecommerce_clean/internals/product/entity"
+	"ecommerce_clean/internals/product/repository"
+	"ecommerce_clean/pkgs/validation"
+	"ecommerce_clean/utils"
+	"math"
+)
+
+const (
+	minReviewRating = 1
+	maxReviewRating = 5
+
+	uniqueReviewConstraint = "unique_product_review_user"
+)
+
+type IReviewUseCase interface {
+	SubmitReview(ctx context.Context, req *dto.SubmitReviewRequest) (*entity.Review, error)
+	GetProductReviewSummary(ctx context.Context, productID string) (*entity.ReviewSummary, error)
+}
+
+type ReviewUseCase struct {
+	validator   validation.Validation
+	reviewRepo  repository.IReviewRepository
+	productRepo repository.IProductRepository
+}
+
+func NewReviewUseCase(
+	validator validation.Validation,
+	reviewRepo repository.IReviewRepository,
+	productRepo repository.IProductRepository,
+) *ReviewUseCase {
+	return &ReviewUseCase{
+		validator:   validator,
+		reviewRepo:  reviewRepo,
+		productRepo: productRepo,
+	}
+}
+
+// SubmitReview stores the user's review of a product. Each user can review a
+// product only once; a second review, even one racing the first, gets
+// ErrAlreadyReviewed.
+func (ru *ReviewUseCase) SubmitReview(ctx context.Context, req *dto.SubmitReviewRequest) (*entity.Review, error) {
+	if err := ru.validator.ValidateStruct(req); err != nil {
+		return nil, err
+	}
+
+	if _, err := ru.productRepo.GetProductById(ctx, req.ProductID); err != nil {
+		return nil, err
+	}
+
+	reviewed, err := ru.reviewRepo.HasUserReviewed(ctx, req.ProductID, req.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if reviewed {
+		return nil, ErrAlreadyReviewed
+	}
+
+	review := &entity.Review{
+		ProductID: req.ProductID,
+		UserID:    req.UserID,
+		Rating:    req.Rating,
+		Comment:   req.Comment,
+	}
+	if err := ru.reviewRepo.CreateReview(ctx, review); err != nil {
+		if utils.ExtractConstraintName(err) == uniqueReviewConstraint {
+			return nil, ErrAlreadyReviewed
+		}
+		return nil, err
+	}
+
+	return review, nil
+}
+
+// GetProductReviewSummary returns the product's average rating, review count
+// and how the reviews spread over the 1-5 ratings.
+func (ru *ReviewUseCase) GetProductReviewSummary(ctx context.Context, productID string) (*entity.ReviewSummary, error) {
+	counts, err := ru.reviewRepo.GetReviewSummary(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+
+	summary := &entity.ReviewSummary{
+		ProductID:          productID,
+		RatingDistribution: make(map[int]int, maxReviewRating),
+	}
+
+	var ratingSum int
+	for rating := minReviewRating; rating <= maxReviewRating; rating++ {
+		count := counts[rating]
+		summary.RatingDistribution[rating] = count
+		summary.TotalReviews += count
+		ratingSum += rating * count
+	}
+
+	if summary.TotalReviews > 0 {
+		summary.AverageRating = math.Round(float64(ratingSum)/float64(summary.TotalReviews)*100) / 100
+	}
+
+	return summary, nil
+}
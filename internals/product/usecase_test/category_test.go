@@ -0,0 +1,151 @@
+package usecase_test
+
+import (
+	"context"
+	"testing"
+
+	prodDto "ecommerce_clean/internals/product/controller/dto"
+	productEntity "ecommerce_clean/internals/product/entity"
+	"ecommerce_clean/internals/product/usecase"
+	"ecommerce_clean/pkgs/validation"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"gorm.io/gorm"
+)
+
+// -------------------------------------
+// Tests de ProductCategoryUseCase
+// -------------------------------------
+
+// TestCreateCategory_Flat verifica que una categoría sin padre se crea sin
+// consultar otras categorías.
+func TestCreateCategory_Flat(t *testing.T) {
+	mockRepo := new(MockCategoryRepository)
+	uc := usecase.NewProductCategoryUseCase(validation.New(), mockRepo)
+
+	mockRepo.On("CreateCategory", mock.Anything, mock.MatchedBy(func(c *productEntity.Category) bool {
+		return c.Name == "Clothing" && c.Slug == "clothing" && c.ParentID == nil
+	})).Return(nil)
+
+	category, err := uc.CreateCategory(context.Background(), &prodDto.CreateCategoryRequest{Name: "Clothing", Slug: "clothing"})
+
+	assert.NoError(t, err)
+	assert.Nil(t, category.ParentID)
+	mockRepo.AssertNotCalled(t, "GetByID", mock.Anything, mock.Anything)
+	mockRepo.AssertExpectations(t)
+}
+
+// TestCreateCategory_Nested verifica que una subcategoría se crea cuando la
+// categoría padre existe.
+func TestCreateCategory_Nested(t *testing.T) {
+	mockRepo := new(MockCategoryRepository)
+	uc := usecase.NewProductCategoryUseCase(validation.New(), mockRepo)
+
+	parentID := "c1"
+	mockRepo.On("GetByID", mock.Anything, parentID).Return(&productEntity.Category{ID: parentID}, nil)
+	mockRepo.On("CreateCategory", mock.Anything, mock.Anything).Return(nil)
+
+	category, err := uc.CreateCategory(context.Background(), &prodDto.CreateCategoryRequest{Name: "Shirts", Slug: "shirts", ParentID: &parentID})
+
+	assert.NoError(t, err)
+	assert.Equal(t, &parentID, category.ParentID)
+	mockRepo.AssertExpectations(t)
+}
+
+// TestCreateCategory_ParentNotFound verifica que se devuelve
+// ErrParentCategoryNotFound cuando la categoría padre no existe.
+func TestCreateCategory_ParentNotFound(t *testing.T) {
+	mockRepo := new(MockCategoryRepository)
+	uc := usecase.NewProductCategoryUseCase(validation.New(), mockRepo)
+
+	parentID := "missing"
+	mockRepo.On("GetByID", mock.Anything, parentID).Return(nil, gorm.ErrRecordNotFound)
+
+	category, err := uc.CreateCategory(context.Background(), &prodDto.CreateCategoryRequest{Name: "Shirts", Slug: "shirts", ParentID: &parentID})
+
+	assert.ErrorIs(t, err, usecase.ErrParentCategoryNotFound)
+	assert.Nil(t, category)
+	mockRepo.AssertNotCalled(t, "CreateCategory", mock.Anything, mock.Anything)
+}
+
+// TestUpdateCategory_OwnParent verifica que una categoría no puede ser su
+// propio padre.
+func TestUpdateCategory_OwnParent(t *testing.T) {
+	mockRepo := new(MockCategoryRepository)
+	uc := usecase.NewProductCategoryUseCase(validation.New(), mockRepo)
+
+	id := "c1"
+	category, err := uc.UpdateCategory(context.Background(), &prodDto.UpdateCategoryRequest{ID: id, Name: "Shirts", Slug: "shirts", ParentID: &id})
+
+	assert.ErrorIs(t, err, usecase.ErrCategoryOwnParent)
+	assert.Nil(t, category)
+	mockRepo.AssertNotCalled(t, "UpdateCategory", mock.Anything, mock.Anything)
+}
+
+// TestUpdateCategory_UnderDescendant verifica que una categoría no se puede
+// mover debajo de una de sus subcategorías, aunque no sea su hija directa.
+func TestUpdateCategory_UnderDescendant(t *testing.T) {
+	mockRepo := new(MockCategoryRepository)
+	uc := usecase.NewProductCategoryUseCase(validation.New(), mockRepo)
+
+	rootID, childID, grandchildID := "c1", "c2", "c3"
+	mockRepo.On("GetByID", mock.Anything, rootID).Return(&productEntity.Category{ID: rootID}, nil)
+	mockRepo.On("GetByID", mock.Anything, childID).Return(&productEntity.Category{ID: childID, ParentID: &rootID}, nil)
+	mockRepo.On("GetByID", mock.Anything, grandchildID).Return(&productEntity.Category{ID: grandchildID, ParentID: &childID}, nil)
+
+	category, err := uc.UpdateCategory(context.Background(), &prodDto.UpdateCategoryRequest{ID: rootID, Name: "Clothing", Slug: "clothing", ParentID: &grandchildID})
+
+	assert.ErrorIs(t, err, usecase.ErrCategoryCycle)
+	assert.Nil(t, category)
+	mockRepo.AssertNotCalled(t, "UpdateCategory", mock.Anything, mock.Anything)
+}
+
+// TestUpdateCategory_MoveToOtherBranch verifica que una categoría se puede
+// mover debajo de otra rama del árbol.
+func TestUpdateCategory_MoveToOtherBranch(t *testing.T) {
+	mockRepo := new(MockCategoryRepository)
+	uc := usecase.NewProductCategoryUseCase(validation.New(), mockRepo)
+
+	rootID, shirtsID, shoesID := "c1", "c2", "c3"
+	mockRepo.On("GetByID", mock.Anything, rootID).Return(&productEntity.Category{ID: rootID}, nil)
+	mockRepo.On("GetByID", mock.Anything, shirtsID).Return(&productEntity.Category{ID: shirtsID, ParentID: &rootID}, nil)
+	mockRepo.On("GetByID", mock.Anything, shoesID).Return(&productEntity.Category{ID: shoesID, ParentID: &rootID}, nil)
+	mockRepo.On("UpdateCategory", mock.Anything, mock.Anything).Return(nil)
+
+	category, err := uc.UpdateCategory(context.Background(), &prodDto.UpdateCategoryRequest{ID: shoesID, Name: "Shoes", Slug: "shoes", ParentID: &shirtsID})
+
+	assert.NoError(t, err)
+	assert.Equal(t, &shirtsID, category.ParentID)
+	mockRepo.AssertExpectations(t)
+}
+
+// TestDeleteCategory_NotEmpty verifica que no se puede borrar una categoría con
+// subcategorías o productos.
+func TestDeleteCategory_NotEmpty(t *testing.T) {
+	mockRepo := new(MockCategoryRepository)
+	uc := usecase.NewProductCategoryUseCase(validation.New(), mockRepo)
+
+	mockRepo.On("GetByID", mock.Anything, "c1").Return(&productEntity.Category{ID: "c1"}, nil)
+	mockRepo.On("HasChildren", mock.Anything, "c1").Return(true, nil)
+
+	err := uc.DeleteCategory(context.Background(), "c1")
+
+	assert.ErrorIs(t, err, usecase.ErrCategoryNotEmpty)
+	mockRepo.AssertNotCalled(t, "DeleteCategory", mock.Anything, mock.Anything)
+}
+
+// TestDeleteCategory_Success verifica que DeleteCategory elimina la categoría
+// cargada por su ID.
+func TestDeleteCategory_Success(t *testing.T) {
+	mockRepo := new(MockCategoryRepository)
+	uc := usecase.NewProductCategoryUseCase(validation.New(), mockRepo)
+
+	existing := &productEntity.Category{ID: "c1"}
+	mockRepo.On("GetByID", mock.Anything, "c1").Return(existing, nil)
+	mockRepo.On("HasChildren", mock.Anything, "c1").Return(false, nil)
+	mockRepo.On("DeleteCategory", mock.Anything, existing).Return(nil)
+
+	assert.NoError(t, uc.DeleteCategory(context.Background(), "c1"))
+	mockRepo.AssertExpectations(t)
+}
@@ -3,15 +3,28 @@ package usecase_test
 import (
 	"context"
 	"errors"
+	"fmt"
+	"mime/multipart"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
+	cartEntity "ecommerce_clean/internals/cart/entity"
 	prodDto "ecommerce_clean/internals/product/controller/dto"
 	productEntity "ecommerce_clean/internals/product/entity"
+	productRepo "ecommerce_clean/internals/product/repository"
 	"ecommerce_clean/internals/product/usecase"
+	"ecommerce_clean/pkgs/logger"
 	"ecommerce_clean/pkgs/paging"
+	"ecommerce_clean/pkgs/validation"
+	"ecommerce_clean/utils"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"gorm.io/gorm"
 )
 
 // -------------------
@@ -40,19 +53,359 @@ func (m *MockProductRepository) ListProducts(ctx context.Context, req *prodDto.L
 
 func (m *MockProductRepository) GetProductById(ctx context.Context, id string) (*productEntity.Product, error) {
 	args := m.Called(ctx, id)
-	return args.Get(0).(*productEntity.Product), args.Error(1)
+	var product *productEntity.Product
+	if v := args.Get(0); v != nil {
+		product = v.(*productEntity.Product)
+	}
+	return product, args.Error(1)
 }
 
 func (m *MockProductRepository) CreatedProduct(ctx context.Context, p *productEntity.Product) error {
-	return nil
+	args := m.Called(ctx, p)
+	return args.Error(0)
+}
+func (m *MockProductRepository) CreateProducts(ctx context.Context, products []*productEntity.Product) error {
+	args := m.Called(ctx, products)
+	return args.Error(0)
 }
 func (m *MockProductRepository) UpdateProduct(ctx context.Context, p *productEntity.Product) error {
-	return nil
+	args := m.Called(ctx, p)
+	return args.Error(0)
 }
 func (m *MockProductRepository) DeleteProduct(ctx context.Context, p *productEntity.Product) error {
 	return nil
 }
 
+func (m *MockProductRepository) GetInventoryReport(ctx context.Context) ([]*productEntity.InventoryItem, error) {
+	args := m.Called(ctx)
+	var items []*productEntity.InventoryItem
+	if v := args.Get(0); v != nil {
+		items = v.([]*productEntity.InventoryItem)
+	}
+	return items, args.Error(1)
+}
+
+func (m *MockProductRepository) GetExternalProducts(ctx context.Context) ([]*productEntity.Product, error) {
+	args := m.Called(ctx)
+	var products []*productEntity.Product
+	if v := args.Get(0); v != nil {
+		products = v.([]*productEntity.Product)
+	}
+	return products, args.Error(1)
+}
+
+func (m *MockProductRepository) GetNewArrivals(ctx context.Context, since time.Time, limit int) ([]*productEntity.Product, error) {
+	args := m.Called(ctx, since, limit)
+	var products []*productEntity.Product
+	if v := args.Get(0); v != nil {
+		products = v.([]*productEntity.Product)
+	}
+	return products, args.Error(1)
+}
+
+func (m *MockProductRepository) GetProductByBarcode(ctx context.Context, barcode string) (*productEntity.Product, error) {
+	args := m.Called(ctx, barcode)
+	var product *productEntity.Product
+	if v := args.Get(0); v != nil {
+		product = v.(*productEntity.Product)
+	}
+	return product, args.Error(1)
+}
+
+func (m *MockProductRepository) GetProductStock(ctx context.Context, productID string) (int, error) {
+	args := m.Called(ctx, productID)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockProductRepository) GetReservedStock(ctx context.Context, productID string) (int, error) {
+	args := m.Called(ctx, productID)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockProductRepository) CreateStockReservation(ctx context.Context, reservation *productEntity.StockReservation) error {
+	args := m.Called(ctx, reservation)
+	return args.Error(0)
+}
+
+func (m *MockProductRepository) GetAllProductsByCategoryID(ctx context.Context, categoryID string) ([]*productEntity.Product, error) {
+	args := m.Called(ctx, categoryID)
+	var products []*productEntity.Product
+	if v := args.Get(0); v != nil {
+		products = v.([]*productEntity.Product)
+	}
+	return products, args.Error(1)
+}
+
+func (m *MockProductRepository) GetProductsExpiringBetween(ctx context.Context, from, to time.Time, limit int) ([]*productEntity.Product, error) {
+	args := m.Called(ctx, from, to, limit)
+	var products []*productEntity.Product
+	if v := args.Get(0); v != nil {
+		products = v.([]*productEntity.Product)
+	}
+	return products, args.Error(1)
+}
+
+func (m *MockProductRepository) GetOutOfStockProducts(ctx context.Context, req *paging.Pagination) ([]*productEntity.Product, *paging.Pagination, error) {
+	args := m.Called(ctx, req)
+	var products []*productEntity.Product
+	if v := args.Get(0); v != nil {
+		products = v.([]*productEntity.Product)
+	}
+	var pagination *paging.Pagination
+	if v := args.Get(1); v != nil {
+		pagination = v.(*paging.Pagination)
+	}
+	return products, pagination, args.Error(2)
+}
+
+func (m *MockProductRepository) GetProductNamesWithPrefixes(ctx context.Context, prefixes []string) ([]string, error) {
+	args := m.Called(ctx, prefixes)
+	var names []string
+	if v := args.Get(0); v != nil {
+		names = v.([]string)
+	}
+	return names, args.Error(1)
+}
+
+func (m *MockProductRepository) CreateScheduledPriceChange(ctx context.Context, change *productEntity.ScheduledPriceChange) error {
+	args := m.Called(ctx, change)
+	return args.Error(0)
+}
+
+func (m *MockProductRepository) GetDueScheduledPriceChanges(ctx context.Context, now time.Time, limit int) ([]*productEntity.ScheduledPriceChange, error) {
+	args := m.Called(ctx, now, limit)
+	var changes []*productEntity.ScheduledPriceChange
+	if v := args.Get(0); v != nil {
+		changes = v.([]*productEntity.ScheduledPriceChange)
+	}
+	return changes, args.Error(1)
+}
+
+func (m *MockProductRepository) ApplyScheduledPriceChange(ctx context.Context, change *productEntity.ScheduledPriceChange) error {
+	args := m.Called(ctx, change)
+	if args.Error(0) == nil {
+		change.IsApplied = true
+	}
+	return args.Error(0)
+}
+
+func (m *MockProductRepository) GetProductsByIDs(ctx context.Context, ids []string) ([]*productEntity.Product, error) {
+	args := m.Called(ctx, ids)
+	var products []*productEntity.Product
+	if v := args.Get(0); v != nil {
+		products = v.([]*productEntity.Product)
+	}
+	return products, args.Error(1)
+}
+
+func (m *MockProductRepository) GetProductsOnSale(ctx context.Context, limit int) ([]*productEntity.Product, error) {
+	args := m.Called(ctx, limit)
+	var products []*productEntity.Product
+	if v := args.Get(0); v != nil {
+		products = v.([]*productEntity.Product)
+	}
+	return products, args.Error(1)
+}
+
+func (m *MockProductRepository) CountCartLinesByProduct(ctx context.Context, productID string) (int, error) {
+	args := m.Called(ctx, productID)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockProductRepository) CountOpenOrdersByProduct(ctx context.Context, productID string) (int, error) {
+	args := m.Called(ctx, productID)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockProductRepository) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	args := m.Called(ctx)
+	if err := args.Error(0); err != nil {
+		return err
+	}
+	return fn(ctx)
+}
+
+func (m *MockProductRepository) GetProductStockForUpdate(ctx context.Context, productID string, opts ...productRepo.RepoOption) (int, error) {
+	args := m.Called(ctx, productID, len(opts))
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockProductRepository) UpdateProductStock(ctx context.Context, productID string, stock int) error {
+	args := m.Called(ctx, productID, stock)
+	return args.Error(0)
+}
+
+func (m *MockProductRepository) GetPriceHistory(ctx context.Context, productID string, limit int) ([]*productEntity.PriceHistory, error) {
+	args := m.Called(ctx, productID, limit)
+	if v := args.Get(0); v != nil {
+		return v.([]*productEntity.PriceHistory), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockProductRepository) GetProductsUpdatedSince(ctx context.Context, since time.Time, limit int) ([]*productEntity.Product, error) {
+	args := m.Called(ctx, since, limit)
+	if v := args.Get(0); v != nil {
+		return v.([]*productEntity.Product), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockProductRepository) GetProductUnitsSold(ctx context.Context, productID string) (int, error) {
+	args := m.Called(ctx, productID)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockProductRepository) GetProductRevenue(ctx context.Context, productID string) (float64, error) {
+	args := m.Called(ctx, productID)
+	return args.Get(0).(float64), args.Error(1)
+}
+
+func (m *MockProductRepository) GetProductRatingStats(ctx context.Context, productID string) (float64, int, error) {
+	args := m.Called(ctx, productID)
+	return args.Get(0).(float64), args.Int(1), args.Error(2)
+}
+
+func (m *MockProductRepository) GetFrequentlyBoughtTogether(ctx context.Context, productID string, limit int) ([]*productEntity.Product, error) {
+	args := m.Called(ctx, productID, limit)
+	if v := args.Get(0); v != nil {
+		return v.([]*productEntity.Product), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockProductRepository) GetProductsBySupplier(ctx context.Context, supplierID string, req *paging.Pagination) ([]*productEntity.Product, *paging.Pagination, error) {
+	args := m.Called(ctx, supplierID, req)
+	var products []*productEntity.Product
+	if v := args.Get(0); v != nil {
+		products = v.([]*productEntity.Product)
+	}
+	var pagination *paging.Pagination
+	if v := args.Get(1); v != nil {
+		pagination = v.(*paging.Pagination)
+	}
+	return products, pagination, args.Error(2)
+}
+
+func (m *MockProductRepository) SearchProductsByNamePrefix(ctx context.Context, prefix string, limit int) ([]*productEntity.Product, error) {
+	args := m.Called(ctx, prefix, limit)
+	if v := args.Get(0); v != nil {
+		return v.([]*productEntity.Product), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockProductRepository) GetProductsByCategoryID(ctx context.Context, categoryID string, req *paging.Pagination) ([]*productEntity.Product, *paging.Pagination, error) {
+	args := m.Called(ctx, categoryID, req)
+	var products []*productEntity.Product
+	if v := args.Get(0); v != nil {
+		products = v.([]*productEntity.Product)
+	}
+	var pagination *paging.Pagination
+	if v := args.Get(1); v != nil {
+		pagination = v.(*paging.Pagination)
+	}
+	return products, pagination, args.Error(2)
+}
+
+func (m *MockProductRepository) UpdateProductsActiveStatus(ctx context.Context, ids []string, isActive bool) (int64, error) {
+	args := m.Called(ctx, ids, isActive)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+type MockCatalogSource struct {
+	mock.Mock
+}
+
+func (m *MockCatalogSource) FetchProducts(ctx context.Context) ([]*productEntity.ExternalProduct, error) {
+	args := m.Called(ctx)
+	var products []*productEntity.ExternalProduct
+	if v := args.Get(0); v != nil {
+		products = v.([]*productEntity.ExternalProduct)
+	}
+	return products, args.Error(1)
+}
+
+type MockProductTemplateRepository struct {
+	mock.Mock
+}
+
+func (m *MockProductTemplateRepository) GetTemplateByID(ctx context.Context, id string) (*productEntity.ProductTemplate, error) {
+	args := m.Called(ctx, id)
+	if v := args.Get(0); v != nil {
+		return v.(*productEntity.ProductTemplate), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+type MockCategoryRepository struct {
+	mock.Mock
+}
+
+func (m *MockCategoryRepository) GetBySlug(ctx context.Context, slug string) (*productEntity.Category, error) {
+	args := m.Called(ctx, slug)
+	if v := args.Get(0); v != nil {
+		return v.(*productEntity.Category), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockCategoryRepository) GetByID(ctx context.Context, id string) (*productEntity.Category, error) {
+	args := m.Called(ctx, id)
+	if v := args.Get(0); v != nil {
+		return v.(*productEntity.Category), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockCategoryRepository) CreateCategory(ctx context.Context, category *productEntity.Category) error {
+	args := m.Called(ctx, category)
+	return args.Error(0)
+}
+
+func (m *MockCategoryRepository) ListCategories(ctx context.Context) ([]*productEntity.Category, error) {
+	args := m.Called(ctx)
+	if v := args.Get(0); v != nil {
+		return v.([]*productEntity.Category), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockCategoryRepository) UpdateCategory(ctx context.Context, category *productEntity.Category) error {
+	args := m.Called(ctx, category)
+	return args.Error(0)
+}
+
+func (m *MockCategoryRepository) DeleteCategory(ctx context.Context, category *productEntity.Category) error {
+	args := m.Called(ctx, category)
+	return args.Error(0)
+}
+
+func (m *MockCategoryRepository) HasChildren(ctx context.Context, categoryID string) (bool, error) {
+	args := m.Called(ctx, categoryID)
+	return args.Bool(0), args.Error(1)
+}
+
+type MockUploadService struct {
+	mock.Mock
+}
+
+func (m *MockUploadService) UploadFile(ctx context.Context, file *multipart.FileHeader, folder string) (string, error) {
+	args := m.Called(ctx, file, folder)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockUploadService) DeleteFile(ctx context.Context, fileURL string) error {
+	return m.Called(ctx, fileURL).Error(0)
+}
+
+// TestMain inicializa el logger global, necesario para los casos de uso
+// que registran errores no críticos.
+func TestMain(m *testing.M) {
+	logger.Initialize("test")
+	os.Exit(m.Run())
+}
+
 // -------------------------------------
 // Tests de ProductUseCase
 // -------------------------------------
@@ -62,7 +415,7 @@ func (m *MockProductRepository) DeleteProduct(ctx context.Context, p *productEnt
 // 2) Devuelve la lista de productos y la paginación proporcionada.
 func TestListProducts_Success(t *testing.T) {
 	mockRepo := new(MockProductRepository)
-	uc := usecase.NewProductUseCase(nil, mockRepo, nil)
+	uc := usecase.NewProductUseCase(nil, mockRepo, nil, nil, nil)
 
 	req := &prodDto.ListProductRequest{Page: 1, Limit: 2}
 	expected := []*productEntity.Product{{ID: "p1"}, {ID: "p2"}}
@@ -82,7 +435,7 @@ func TestListProducts_Success(t *testing.T) {
 // cuando el repositorio falla.
 func TestListProducts_RepoError(t *testing.T) {
 	mockRepo := new(MockProductRepository)
-	uc := usecase.NewProductUseCase(nil, mockRepo, nil)
+	uc := usecase.NewProductUseCase(nil, mockRepo, nil, nil, nil)
 
 	req := &prodDto.ListProductRequest{Page: 1, Limit: 2}
 	mockRepo.On("ListProducts", mock.Anything, req).Return(nil, nil, errors.New("db error"))
@@ -95,11 +448,45 @@ func TestListProducts_RepoError(t *testing.T) {
 	mockRepo.AssertExpectations(t)
 }
 
+// TestListProducts_TrimsSearch verifica que ListProducts recorta los espacios de
+// la búsqueda antes de enviarla al repositorio.
+func TestListProducts_TrimsSearch(t *testing.T) {
+	mockRepo := new(MockProductRepository)
+	uc := usecase.NewProductUseCase(nil, mockRepo, nil, nil, nil)
+
+	req := &prodDto.ListProductRequest{Search: "  shirt  ", Page: 1, Limit: 2}
+	mockRepo.On("ListProducts", mock.Anything, mock.MatchedBy(func(r *prodDto.ListProductRequest) bool {
+		return r.Search == "shirt"
+	})).Return([]*productEntity.Product{{ID: "p1"}}, paging.NewPagination(1, 2, 1), nil)
+
+	products, _, err := uc.ListProducts(context.Background(), req)
+
+	assert.NoError(t, err)
+	assert.Len(t, products, 1)
+	mockRepo.AssertExpectations(t)
+}
+
+// TestListProducts_SearchTooShort verifica que ListProducts rechaza búsquedas de
+// menos de 2 caracteres sin llamar al repositorio.
+func TestListProducts_SearchTooShort(t *testing.T) {
+	mockRepo := new(MockProductRepository)
+	uc := usecase.NewProductUseCase(nil, mockRepo, nil, nil, nil)
+
+	for _, q := range []string{"a", " b ", "é"} {
+		products, page, err := uc.ListProducts(context.Background(), &prodDto.ListProductRequest{Search: q})
+
+		assert.ErrorIs(t, err, usecase.ErrQueryTooShort)
+		assert.Nil(t, products)
+		assert.Nil(t, page)
+	}
+	mockRepo.AssertNotCalled(t, "ListProducts", mock.Anything, mock.Anything)
+}
+
 // TestGetProductById_Success verifica que GetProductById devuelve
 // correctamente un producto cuando existe.
 func TestGetProductById_Success(t *testing.T) {
 	mockRepo := new(MockProductRepository)
-	uc := usecase.NewProductUseCase(nil, mockRepo, nil)
+	uc := usecase.NewProductUseCase(nil, mockRepo, nil, nil, nil)
 
 	expected := &productEntity.Product{ID: "p1"}
 	mockRepo.On("GetProductById", mock.Anything, "p1").Return(expected, nil)
@@ -115,7 +502,7 @@ func TestGetProductById_Success(t *testing.T) {
 // cuando el repositorio falla.
 func TestGetProductById_RepoError(t *testing.T) {
 	mockRepo := new(MockProductRepository)
-	uc := usecase.NewProductUseCase(nil, mockRepo, nil)
+	uc := usecase.NewProductUseCase(nil, mockRepo, nil, nil, nil)
 
 	mockRepo.On("GetProductById", mock.Anything, "p1").Return((*productEntity.Product)(nil), errors.New("not found"))
 
@@ -125,3 +512,1807 @@ func TestGetProductById_RepoError(t *testing.T) {
 	assert.EqualError(t, err, "not found")
 	mockRepo.AssertExpectations(t)
 }
+
+// -------------------------------------
+// Tests de GetProductInventoryReport
+// -------------------------------------
+
+// TestGetProductInventoryReport_Success verifica que un admin recibe el
+// reporte tal cual lo ordena el repositorio (AvailableStock ascendente),
+// incluyendo productos sin stock.
+func TestGetProductInventoryReport_Success(t *testing.T) {
+	mockRepo := new(MockProductRepository)
+	uc := usecase.NewProductUseCase(nil, mockRepo, nil, nil, nil)
+
+	expected := []*productEntity.InventoryItem{
+		{ProductID: "p3", Name: "Durian", Stock: 0, ReservedStock: 0, AvailableStock: 0},
+		{ProductID: "p1", Name: "Mango", Stock: 10, ReservedStock: 8, AvailableStock: 2, CategoryName: "Frutas"},
+		{ProductID: "p2", Name: "Pepino", Stock: 30, ReservedStock: 5, AvailableStock: 25, CategoryName: "Verduras"},
+	}
+	mockRepo.On("GetInventoryReport", mock.Anything).Return(expected, nil)
+
+	items, err := uc.GetProductInventoryReport(context.Background(), utils.RoleAdmin)
+
+	assert.NoError(t, err)
+	assert.Equal(t, expected, items)
+	for i := 1; i < len(items); i++ {
+		assert.LessOrEqual(t, items[i-1].AvailableStock, items[i].AvailableStock)
+	}
+	mockRepo.AssertExpectations(t)
+}
+
+// TestGetProductInventoryReport_Forbidden verifica que un usuario sin rol
+// admin no puede consultar el reporte y que no se llega al repositorio.
+func TestGetProductInventoryReport_Forbidden(t *testing.T) {
+	mockRepo := new(MockProductRepository)
+	uc := usecase.NewProductUseCase(nil, mockRepo, nil, nil, nil)
+
+	items, err := uc.GetProductInventoryReport(context.Background(), utils.RoleCustomer)
+
+	assert.Nil(t, items)
+	assert.ErrorIs(t, err, usecase.ErrForbidden)
+	mockRepo.AssertNotCalled(t, "GetInventoryReport", mock.Anything)
+}
+
+// TestGetProductInventoryReport_RepoError verifica que se propaga el error
+// del repositorio.
+func TestGetProductInventoryReport_RepoError(t *testing.T) {
+	mockRepo := new(MockProductRepository)
+	uc := usecase.NewProductUseCase(nil, mockRepo, nil, nil, nil)
+
+	mockRepo.On("GetInventoryReport", mock.Anything).Return(nil, errors.New("db error"))
+
+	items, err := uc.GetProductInventoryReport(context.Background(), utils.RoleAdmin)
+
+	assert.Nil(t, items)
+	assert.EqualError(t, err, "db error")
+}
+
+// -------------------------------------
+// Tests de SyncProductsFromExternalCatalog
+// -------------------------------------
+
+// TestSyncProductsFromExternalCatalog_AllNew verifica que los productos que no
+// existen en la base se crean con su ExternalID.
+func TestSyncProductsFromExternalCatalog_AllNew(t *testing.T) {
+	mockRepo := new(MockProductRepository)
+	source := new(MockCatalogSource)
+	uc := usecase.NewProductUseCase(nil, mockRepo, nil, nil, nil)
+
+	source.On("FetchProducts", mock.Anything).Return([]*productEntity.ExternalProduct{
+		{ExternalID: "ext1", Name: "Mango", Price: 2},
+		{ExternalID: "ext2", Name: "Pepino", Price: 1},
+	}, nil)
+	mockRepo.On("GetExternalProducts", mock.Anything).Return([]*productEntity.Product{}, nil)
+	mockRepo.On("CreatedProduct", mock.Anything, mock.MatchedBy(func(p *productEntity.Product) bool {
+		return p.ExternalID == "ext1" || p.ExternalID == "ext2"
+	})).Return(nil).Twice()
+
+	report, err := uc.SyncProductsFromExternalCatalog(context.Background(), source)
+
+	assert.NoError(t, err)
+	assert.Equal(t, &productEntity.SyncReport{Created: 2}, report)
+	mockRepo.AssertExpectations(t)
+	mockRepo.AssertNotCalled(t, "UpdateProduct", mock.Anything, mock.Anything)
+}
+
+// TestSyncProductsFromExternalCatalog_AllUpdated verifica que los productos
+// existentes se actualizan con los datos del proveedor.
+func TestSyncProductsFromExternalCatalog_AllUpdated(t *testing.T) {
+	mockRepo := new(MockProductRepository)
+	source := new(MockCatalogSource)
+	uc := usecase.NewProductUseCase(nil, mockRepo, nil, nil, nil)
+
+	existing := []*productEntity.Product{
+		{ID: "p1", ExternalID: "ext1", Name: "Mango", Price: 2, Active: true},
+		{ID: "p2", ExternalID: "ext2", Name: "Pepino", Price: 1, Active: true},
+	}
+	source.On("FetchProducts", mock.Anything).Return([]*productEntity.ExternalProduct{
+		{ExternalID: "ext1", Name: "Mango", Price: 3, Stock: 10},
+		{ExternalID: "ext2", Name: "Pepino", Price: 1.5, Stock: 4},
+	}, nil)
+	mockRepo.On("GetExternalProducts", mock.Anything).Return(existing, nil)
+	mockRepo.On("UpdateProduct", mock.Anything, mock.Anything).Return(nil).Twice()
+
+	report, err := uc.SyncProductsFromExternalCatalog(context.Background(), source)
+
+	assert.NoError(t, err)
+	assert.Equal(t, &productEntity.SyncReport{Updated: 2}, report)
+	assert.Equal(t, 3.0, existing[0].Price)
+	assert.Equal(t, 10, existing[0].Stock)
+	assert.Equal(t, 1.5, existing[1].Price)
+	mockRepo.AssertExpectations(t)
+	mockRepo.AssertNotCalled(t, "CreatedProduct", mock.Anything, mock.Anything)
+}
+
+// TestSyncProductsFromExternalCatalog_SomeMissing verifica que los productos
+// ausentes en el catálogo externo quedan archivados (inactivos).
+func TestSyncProductsFromExternalCatalog_SomeMissing(t *testing.T) {
+	mockRepo := new(MockProductRepository)
+	source := new(MockCatalogSource)
+	uc := usecase.NewProductUseCase(nil, mockRepo, nil, nil, nil)
+
+	kept := &productEntity.Product{ID: "p1", ExternalID: "ext1", Active: true}
+	missing := &productEntity.Product{ID: "p2", ExternalID: "ext2", Active: true}
+	source.On("FetchProducts", mock.Anything).Return([]*productEntity.ExternalProduct{
+		{ExternalID: "ext1", Name: "Mango", Price: 2},
+	}, nil)
+	mockRepo.On("GetExternalProducts", mock.Anything).Return([]*productEntity.Product{kept, missing}, nil)
+	mockRepo.On("UpdateProduct", mock.Anything, kept).Return(nil).Once()
+	mockRepo.On("UpdateProduct", mock.Anything, missing).Return(nil).Once()
+
+	report, err := uc.SyncProductsFromExternalCatalog(context.Background(), source)
+
+	assert.NoError(t, err)
+	assert.Equal(t, &productEntity.SyncReport{Updated: 1, Deleted: 1}, report)
+	assert.True(t, kept.Active)
+	assert.False(t, missing.Active)
+	mockRepo.AssertExpectations(t)
+}
+
+// TestSyncProductsFromExternalCatalog_FetchError verifica que un fallo de la
+// fuente externa se propaga sin tocar la base.
+func TestSyncProductsFromExternalCatalog_FetchError(t *testing.T) {
+	mockRepo := new(MockProductRepository)
+	source := new(MockCatalogSource)
+	uc := usecase.NewProductUseCase(nil, mockRepo, nil, nil, nil)
+
+	source.On("FetchProducts", mock.Anything).Return(nil, errors.New("supplier down"))
+
+	report, err := uc.SyncProductsFromExternalCatalog(context.Background(), source)
+
+	assert.Nil(t, report)
+	assert.EqualError(t, err, "supplier down")
+	mockRepo.AssertNotCalled(t, "GetExternalProducts", mock.Anything)
+}
+
+// -------------------------------------
+// Tests de GetNewArrivals
+// -------------------------------------
+
+// TestGetNewArrivals_Success verifica que se devuelven los productos recientes
+// tal como los entrega el repositorio.
+func TestGetNewArrivals_Success(t *testing.T) {
+	mockRepo := new(MockProductRepository)
+	uc := usecase.NewProductUseCase(nil, mockRepo, nil, nil, nil)
+
+	since := time.Now().Add(-7 * 24 * time.Hour)
+	expected := []*productEntity.Product{{ID: "p2"}, {ID: "p1"}}
+	mockRepo.On("GetNewArrivals", mock.Anything, since, 10).Return(expected, nil)
+
+	products, err := uc.GetNewArrivals(context.Background(), since, 10)
+
+	assert.NoError(t, err)
+	assert.Equal(t, expected, products)
+	mockRepo.AssertExpectations(t)
+}
+
+// TestGetNewArrivals_Empty verifica que un resultado vacío devuelve
+// ErrNoNewArrivals en lugar de un slice nil.
+func TestGetNewArrivals_Empty(t *testing.T) {
+	mockRepo := new(MockProductRepository)
+	uc := usecase.NewProductUseCase(nil, mockRepo, nil, nil, nil)
+
+	since := time.Now().Add(-time.Hour)
+	mockRepo.On("GetNewArrivals", mock.Anything, since, 5).Return([]*productEntity.Product{}, nil)
+
+	products, err := uc.GetNewArrivals(context.Background(), since, 5)
+
+	assert.Nil(t, products)
+	assert.ErrorIs(t, err, usecase.ErrNoNewArrivals)
+}
+
+// TestGetNewArrivals_InvalidParams verifica que se rechazan una fecha futura y
+// límites fuera de rango sin consultar el repositorio.
+func TestGetNewArrivals_InvalidParams(t *testing.T) {
+	mockRepo := new(MockProductRepository)
+	uc := usecase.NewProductUseCase(nil, mockRepo, nil, nil, nil)
+
+	_, err := uc.GetNewArrivals(context.Background(), time.Now().Add(time.Hour), 10)
+	assert.ErrorIs(t, err, usecase.ErrInvalidSince)
+
+	_, err = uc.GetNewArrivals(context.Background(), time.Now().Add(-time.Hour), 0)
+	assert.ErrorIs(t, err, usecase.ErrInvalidLimit)
+
+	_, err = uc.GetNewArrivals(context.Background(), time.Now().Add(-time.Hour), 101)
+	assert.ErrorIs(t, err, usecase.ErrInvalidLimit)
+
+	mockRepo.AssertNotCalled(t, "GetNewArrivals", mock.Anything, mock.Anything, mock.Anything)
+}
+
+// -------------------------------------
+// Tests de GetProductByBarcode
+// -------------------------------------
+
+// TestGetProductByBarcode_Found verifica que se devuelve el producto asociado
+// al código de barras.
+func TestGetProductByBarcode_Found(t *testing.T) {
+	mockRepo := new(MockProductRepository)
+	uc := usecase.NewProductUseCase(nil, mockRepo, nil, nil, nil)
+
+	expected := &productEntity.Product{ID: "p1", Barcode: "7501234567890"}
+	mockRepo.On("GetProductByBarcode", mock.Anything, "7501234567890").Return(expected, nil)
+
+	product, err := uc.GetProductByBarcode(context.Background(), "7501234567890")
+
+	assert.NoError(t, err)
+	assert.Equal(t, expected, product)
+	mockRepo.AssertExpectations(t)
+}
+
+// TestGetProductByBarcode_NotFound verifica que se propaga el error del
+// repositorio cuando el código no existe.
+func TestGetProductByBarcode_NotFound(t *testing.T) {
+	mockRepo := new(MockProductRepository)
+	uc := usecase.NewProductUseCase(nil, mockRepo, nil, nil, nil)
+
+	mockRepo.On("GetProductByBarcode", mock.Anything, "000").Return(nil, gorm.ErrRecordNotFound)
+
+	product, err := uc.GetProductByBarcode(context.Background(), "000")
+
+	assert.Nil(t, product)
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+}
+
+// TestGetProductByBarcode_Invalid verifica que se rechazan códigos vacíos o de
+// más de 50 caracteres.
+func TestGetProductByBarcode_Invalid(t *testing.T) {
+	mockRepo := new(MockProductRepository)
+	uc := usecase.NewProductUseCase(nil, mockRepo, nil, nil, nil)
+
+	_, err := uc.GetProductByBarcode(context.Background(), "")
+	assert.ErrorIs(t, err, usecase.ErrInvalidBarcode)
+
+	_, err = uc.GetProductByBarcode(context.Background(), strings.Repeat("9", 51))
+	assert.ErrorIs(t, err, usecase.ErrInvalidBarcode)
+
+	mockRepo.AssertNotCalled(t, "GetProductByBarcode", mock.Anything, mock.Anything)
+}
+
+// -------------------------------------
+// Tests de ComputeCartItemAvailability
+// -------------------------------------
+
+// TestComputeCartItemAvailability_AllAvailable verifica que con stock
+// suficiente todas las líneas quedan disponibles y en el mismo orden.
+func TestComputeCartItemAvailability_AllAvailable(t *testing.T) {
+	mockRepo := new(MockProductRepository)
+	uc := usecase.NewProductUseCase(nil, mockRepo, nil, nil, nil)
+
+	lines := []*cartEntity.CartLine{{ProductID: "p2", Quantity: 1}, {ProductID: "p1", Quantity: 3}}
+	mockRepo.On("GetProductsByIDs", mock.Anything, []string{"p2", "p1"}).Return([]*productEntity.Product{
+		{ID: "p1", Stock: 3}, {ID: "p2", Stock: 10},
+	}, nil).Once()
+
+	results, err := uc.ComputeCartItemAvailability(context.Background(), lines)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []*productEntity.AvailabilityResult{
+		{ProductID: "p2", RequestedQty: 1, AvailableQty: 10, IsAvailable: true},
+		{ProductID: "p1", RequestedQty: 3, AvailableQty: 3, IsAvailable: true},
+	}, results)
+	mockRepo.AssertExpectations(t)
+}
+
+// TestComputeCartItemAvailability_LowStock verifica que una línea con más
+// cantidad que el stock queda como no disponible.
+func TestComputeCartItemAvailability_LowStock(t *testing.T) {
+	mockRepo := new(MockProductRepository)
+	uc := usecase.NewProductUseCase(nil, mockRepo, nil, nil, nil)
+
+	lines := []*cartEntity.CartLine{{ProductID: "p1", Quantity: 5}, {ProductID: "p2", Quantity: 1}}
+	mockRepo.On("GetProductsByIDs", mock.Anything, []string{"p1", "p2"}).Return([]*productEntity.Product{
+		{ID: "p1", Stock: 2}, {ID: "p2", Stock: 1},
+	}, nil)
+
+	results, err := uc.ComputeCartItemAvailability(context.Background(), lines)
+
+	assert.NoError(t, err)
+	assert.False(t, results[0].IsAvailable)
+	assert.Equal(t, 2, results[0].AvailableQty)
+	assert.True(t, results[1].IsAvailable)
+}
+
+// TestComputeCartItemAvailability_ProductNotFound verifica que un producto
+// inexistente se reporta como no disponible.
+func TestComputeCartItemAvailability_ProductNotFound(t *testing.T) {
+	mockRepo := new(MockProductRepository)
+	uc := usecase.NewProductUseCase(nil, mockRepo, nil, nil, nil)
+
+	lines := []*cartEntity.CartLine{{ProductID: "missing", Quantity: 1}}
+	mockRepo.On("GetProductsByIDs", mock.Anything, []string{"missing"}).Return([]*productEntity.Product{}, nil)
+
+	results, err := uc.ComputeCartItemAvailability(context.Background(), lines)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []*productEntity.AvailabilityResult{
+		{ProductID: "missing", RequestedQty: 1, AvailableQty: 0, IsAvailable: false},
+	}, results)
+}
+
+// TestComputeCartItemAvailability_EmptyCart verifica que un carrito vacío
+// devuelve una lista vacía sin consultar el repositorio.
+func TestComputeCartItemAvailability_EmptyCart(t *testing.T) {
+	mockRepo := new(MockProductRepository)
+	uc := usecase.NewProductUseCase(nil, mockRepo, nil, nil, nil)
+
+	results, err := uc.ComputeCartItemAvailability(context.Background(), nil)
+
+	assert.NoError(t, err)
+	assert.Empty(t, results)
+	mockRepo.AssertNotCalled(t, "GetProductsByIDs", mock.Anything, mock.Anything)
+}
+
+// -------------------------------------
+// Tests de GetProductsOnSale
+// -------------------------------------
+
+func floatPtr(f float64) *float64 {
+	return &f
+}
+
+// TestGetProductsOnSale_ValidSale verifica el cálculo del ahorro absoluto y
+// porcentual.
+func TestGetProductsOnSale_ValidSale(t *testing.T) {
+	mockRepo := new(MockProductRepository)
+	uc := usecase.NewProductUseCase(nil, mockRepo, nil, nil, nil)
+
+	product := &productEntity.Product{ID: "p1", Price: 80, SalePrice: floatPtr(60)}
+	mockRepo.On("GetProductsOnSale", mock.Anything, 10).Return([]*productEntity.Product{product}, nil)
+
+	result, err := uc.GetProductsOnSale(context.Background(), 10)
+
+	assert.NoError(t, err)
+	if assert.Len(t, result, 1) {
+		assert.Equal(t, product, result[0].Product)
+		assert.Equal(t, 20.0, result[0].SavingsAmount)
+		assert.Equal(t, 25.0, result[0].SavingsPercent)
+	}
+}
+
+// TestGetProductsOnSale_SalePriceNotLower verifica que un producto con
+// sale_price >= price queda excluido.
+func TestGetProductsOnSale_SalePriceNotLower(t *testing.T) {
+	mockRepo := new(MockProductRepository)
+	uc := usecase.NewProductUseCase(nil, mockRepo, nil, nil, nil)
+
+	mockRepo.On("GetProductsOnSale", mock.Anything, 10).Return([]*productEntity.Product{
+		{ID: "p1", Price: 10, SalePrice: floatPtr(10)},
+		{ID: "p2", Price: 10, SalePrice: floatPtr(12)},
+		{ID: "p3", Price: 10, SalePrice: floatPtr(9)},
+	}, nil)
+
+	result, err := uc.GetProductsOnSale(context.Background(), 10)
+
+	assert.NoError(t, err)
+	if assert.Len(t, result, 1) {
+		assert.Equal(t, "p3", result[0].ID)
+	}
+}
+
+// TestGetProductsOnSale_Limit verifica que el límite se pasa al repositorio y
+// que se rechazan límites fuera de rango.
+func TestGetProductsOnSale_Limit(t *testing.T) {
+	mockRepo := new(MockProductRepository)
+	uc := usecase.NewProductUseCase(nil, mockRepo, nil, nil, nil)
+
+	mockRepo.On("GetProductsOnSale", mock.Anything, 2).Return([]*productEntity.Product{
+		{ID: "p1", Price: 10, SalePrice: floatPtr(5)},
+		{ID: "p2", Price: 10, SalePrice: floatPtr(8)},
+	}, nil).Once()
+
+	result, err := uc.GetProductsOnSale(context.Background(), 2)
+	assert.NoError(t, err)
+	assert.Len(t, result, 2)
+
+	_, err = uc.GetProductsOnSale(context.Background(), 0)
+	assert.ErrorIs(t, err, usecase.ErrInvalidLimit)
+
+	_, err = uc.GetProductsOnSale(context.Background(), 101)
+	assert.ErrorIs(t, err, usecase.ErrInvalidLimit)
+
+	mockRepo.AssertExpectations(t)
+}
+
+// -------------------------------------
+// Tests de PreviewProductDelete
+// -------------------------------------
+
+func previewDelete(t *testing.T, cartLines, openOrders int) *productEntity.DeleteImpact {
+	mockRepo := new(MockProductRepository)
+	uc := usecase.NewProductUseCase(nil, mockRepo, nil, nil, nil)
+
+	mockRepo.On("GetProductById", mock.Anything, "p1").Return(&productEntity.Product{ID: "p1"}, nil)
+	mockRepo.On("CountCartLinesByProduct", mock.Anything, "p1").Return(cartLines, nil)
+	mockRepo.On("CountOpenOrdersByProduct", mock.Anything, "p1").Return(openOrders, nil)
+
+	impact, err := uc.PreviewProductDelete(context.Background(), "p1")
+	assert.NoError(t, err)
+	mockRepo.AssertExpectations(t)
+	return impact
+}
+
+// TestPreviewProductDelete_AllClear verifica que sin referencias el producto
+// se puede borrar.
+func TestPreviewProductDelete_AllClear(t *testing.T) {
+	impact := previewDelete(t, 0, 0)
+
+	assert.Equal(t, &productEntity.DeleteImpact{CanDelete: true}, impact)
+}
+
+// TestPreviewProductDelete_InActiveCarts verifica que un producto en carritos
+// no se puede borrar.
+func TestPreviewProductDelete_InActiveCarts(t *testing.T) {
+	impact := previewDelete(t, 3, 0)
+
+	assert.Equal(t, &productEntity.DeleteImpact{ActiveCartLines: 3}, impact)
+}
+
+// TestPreviewProductDelete_InOpenOrders verifica que un producto en órdenes
+// abiertas no se puede borrar.
+func TestPreviewProductDelete_InOpenOrders(t *testing.T) {
+	impact := previewDelete(t, 0, 2)
+
+	assert.Equal(t, &productEntity.DeleteImpact{OpenOrders: 2}, impact)
+}
+
+// TestPreviewProductDelete_InBoth verifica que se reportan ambos conteos.
+func TestPreviewProductDelete_InBoth(t *testing.T) {
+	impact := previewDelete(t, 1, 4)
+
+	assert.Equal(t, &productEntity.DeleteImpact{ActiveCartLines: 1, OpenOrders: 4}, impact)
+}
+
+// TestPreviewProductDelete_NotFound verifica que se propaga el error cuando el
+// producto no existe.
+func TestPreviewProductDelete_NotFound(t *testing.T) {
+	mockRepo := new(MockProductRepository)
+	uc := usecase.NewProductUseCase(nil, mockRepo, nil, nil, nil)
+
+	mockRepo.On("GetProductById", mock.Anything, "missing").Return(nil, gorm.ErrRecordNotFound)
+	mockRepo.On("CountCartLinesByProduct", mock.Anything, "missing").Return(0, nil).Maybe()
+	mockRepo.On("CountOpenOrdersByProduct", mock.Anything, "missing").Return(0, nil).Maybe()
+
+	impact, err := uc.PreviewProductDelete(context.Background(), "missing")
+
+	assert.Nil(t, impact)
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+}
+
+// -------------------------------------
+// Tests de BulkActivate / BulkDeactivate
+// -------------------------------------
+
+// TestBulkActivateProducts_Admin verifica que un admin activa los productos y
+// recibe el número de filas afectadas.
+func TestBulkActivateProducts_Admin(t *testing.T) {
+	mockRepo := new(MockProductRepository)
+	uc := usecase.NewProductUseCase(nil, mockRepo, nil, nil, nil)
+
+	ids := []string{"p1", "p2", "p3"}
+	mockRepo.On("UpdateProductsActiveStatus", mock.Anything, ids, true).Return(int64(2), nil)
+
+	result, err := uc.BulkActivateProducts(context.Background(), ids, utils.RoleAdmin)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 3, result.Requested)
+	assert.Equal(t, int64(2), result.Affected)
+	mockRepo.AssertExpectations(t)
+}
+
+// TestBulkDeactivateProducts_Admin verifica que la desactivación pasa false
+// al repositorio.
+func TestBulkDeactivateProducts_Admin(t *testing.T) {
+	mockRepo := new(MockProductRepository)
+	uc := usecase.NewProductUseCase(nil, mockRepo, nil, nil, nil)
+
+	ids := []string{"p1", "p2"}
+	mockRepo.On("UpdateProductsActiveStatus", mock.Anything, ids, false).Return(int64(2), nil)
+
+	result, err := uc.BulkDeactivateProducts(context.Background(), ids, utils.RoleAdmin)
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), result.Affected)
+	mockRepo.AssertExpectations(t)
+}
+
+// TestBulkActivateProducts_NonAdmin verifica que un usuario no admin es rechazado.
+func TestBulkActivateProducts_NonAdmin(t *testing.T) {
+	mockRepo := new(MockProductRepository)
+	uc := usecase.NewProductUseCase(nil, mockRepo, nil, nil, nil)
+
+	result, err := uc.BulkActivateProducts(context.Background(), []string{"p1"}, utils.RoleCustomer)
+
+	assert.Nil(t, result)
+	assert.ErrorIs(t, err, usecase.ErrForbidden)
+	mockRepo.AssertNotCalled(t, "UpdateProductsActiveStatus", mock.Anything, mock.Anything, mock.Anything)
+}
+
+// TestBulkActivateProducts_EmptyList verifica que una lista vacía es rechazada.
+func TestBulkActivateProducts_EmptyList(t *testing.T) {
+	mockRepo := new(MockProductRepository)
+	uc := usecase.NewProductUseCase(nil, mockRepo, nil, nil, nil)
+
+	result, err := uc.BulkActivateProducts(context.Background(), nil, utils.RoleAdmin)
+
+	assert.Nil(t, result)
+	assert.ErrorIs(t, err, usecase.ErrEmptyProductIDs)
+	mockRepo.AssertNotCalled(t, "UpdateProductsActiveStatus", mock.Anything, mock.Anything, mock.Anything)
+}
+
+// TestBulkDeactivateProducts_TooMany verifica que más de 500 IDs son rechazados.
+func TestBulkDeactivateProducts_TooMany(t *testing.T) {
+	mockRepo := new(MockProductRepository)
+	uc := usecase.NewProductUseCase(nil, mockRepo, nil, nil, nil)
+
+	ids := make([]string, 501)
+	for i := range ids {
+		ids[i] = fmt.Sprintf("p%d", i)
+	}
+
+	result, err := uc.BulkDeactivateProducts(context.Background(), ids, utils.RoleAdmin)
+
+	assert.Nil(t, result)
+	assert.ErrorIs(t, err, usecase.ErrTooManyProductIDs)
+	mockRepo.AssertNotCalled(t, "UpdateProductsActiveStatus", mock.Anything, mock.Anything, mock.Anything)
+}
+
+// -------------------------------------
+// Tests de ReserveStock
+// -------------------------------------
+
+// TestReserveStock_Success verifica que se lee el stock con bloqueo y se
+// guarda una reserva por la cantidad, sin tocar el stock.
+func TestReserveStock_Success(t *testing.T) {
+	mockRepo := new(MockProductRepository)
+	uc := usecase.NewProductUseCase(nil, mockRepo, nil, nil, nil)
+
+	mockRepo.On("WithinTransaction", mock.Anything).Return(nil)
+	mockRepo.On("GetProductStockForUpdate", mock.Anything, "p1", 1).Return(5, nil)
+	mockRepo.On("GetReservedStock", mock.Anything, "p1").Return(3, nil)
+	mockRepo.On("CreateStockReservation", mock.Anything, mock.MatchedBy(func(r *productEntity.StockReservation) bool {
+		return r.ProductID == "p1" && r.Quantity == 2
+	})).Return(nil)
+
+	err := uc.ReserveStock(context.Background(), "p1", 2)
+
+	assert.NoError(t, err)
+	mockRepo.AssertExpectations(t)
+	mockRepo.AssertNotCalled(t, "UpdateProductStock", mock.Anything, mock.Anything, mock.Anything)
+}
+
+// TestReserveStock_Insufficient verifica que no se reserva si el stock que
+// queda tras las reservas activas no alcanza.
+func TestReserveStock_Insufficient(t *testing.T) {
+	mockRepo := new(MockProductRepository)
+	uc := usecase.NewProductUseCase(nil, mockRepo, nil, nil, nil)
+
+	mockRepo.On("WithinTransaction", mock.Anything).Return(nil)
+	mockRepo.On("GetProductStockForUpdate", mock.Anything, "p1", 1).Return(5, nil)
+	mockRepo.On("GetReservedStock", mock.Anything, "p1").Return(4, nil)
+
+	err := uc.ReserveStock(context.Background(), "p1", 2)
+
+	assert.ErrorIs(t, err, usecase.ErrInsufficientStock)
+	mockRepo.AssertNotCalled(t, "CreateStockReservation", mock.Anything, mock.Anything)
+}
+
+// TestReserveStock_InvalidQuantity verifica que una cantidad no positiva es rechazada.
+func TestReserveStock_InvalidQuantity(t *testing.T) {
+	mockRepo := new(MockProductRepository)
+	uc := usecase.NewProductUseCase(nil, mockRepo, nil, nil, nil)
+
+	err := uc.ReserveStock(context.Background(), "p1", 0)
+
+	assert.ErrorIs(t, err, usecase.ErrInvalidQuantity)
+	mockRepo.AssertNotCalled(t, "WithinTransaction", mock.Anything)
+}
+
+type lockTxKey struct{}
+
+// lockingStockRepo simula el bloqueo de fila con un mutex: se toma al leer con
+// WithPessimisticLock y se libera al terminar la transacción.
+type lockingStockRepo struct {
+	*MockProductRepository
+	row      sync.Mutex
+	stock    int
+	reserved int
+	inside   int32
+	maxSeen  int32
+}
+
+func (r *lockingStockRepo) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	locked := new(bool)
+	err := fn(context.WithValue(ctx, lockTxKey{}, locked))
+	if *locked {
+		atomic.AddInt32(&r.inside, -1)
+		r.row.Unlock()
+	}
+	return err
+}
+
+func (r *lockingStockRepo) GetProductStockForUpdate(ctx context.Context, productID string, opts ...productRepo.RepoOption) (int, error) {
+	if len(opts) > 0 {
+		r.row.Lock()
+		*ctx.Value(lockTxKey{}).(*bool) = true
+		if n := atomic.AddInt32(&r.inside, 1); n > atomic.LoadInt32(&r.maxSeen) {
+			atomic.StoreInt32(&r.maxSeen, n)
+		}
+	}
+	stock := r.stock
+	time.Sleep(2 * time.Millisecond)
+	return stock, nil
+}
+
+func (r *lockingStockRepo) GetReservedStock(ctx context.Context, productID string) (int, error) {
+	return r.reserved, nil
+}
+
+func (r *lockingStockRepo) CreateStockReservation(ctx context.Context, reservation *productEntity.StockReservation) error {
+	r.reserved += reservation.Quantity
+	return nil
+}
+
+// TestReserveStock_ConcurrentReservations verifica que las reservas
+// concurrentes se serializan: nunca hay dos dentro de la sección crítica y no
+// se reserva más stock del que hay.
+func TestReserveStock_ConcurrentReservations(t *testing.T) {
+	repo := &lockingStockRepo{MockProductRepository: new(MockProductRepository), stock: 5}
+	uc := usecase.NewProductUseCase(nil, repo, nil, nil, nil)
+
+	var wg sync.WaitGroup
+	var succeeded, rejected int32
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			switch err := uc.ReserveStock(context.Background(), "p1", 1); {
+			case err == nil:
+				atomic.AddInt32(&succeeded, 1)
+			case errors.Is(err, usecase.ErrInsufficientStock):
+				atomic.AddInt32(&rejected, 1)
+			default:
+				t.Errorf("unexpected error: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, int32(5), succeeded)
+	assert.Equal(t, int32(5), rejected)
+	assert.Equal(t, 5, repo.reserved)
+	assert.Equal(t, 5, repo.stock)
+	assert.Equal(t, int32(1), repo.maxSeen)
+}
+
+// -------------------------------------
+// Tests de UpdateProductImages
+// -------------------------------------
+
+// TestUpdateProductImages_Valid verifica que se guardan las URLs https y que la
+// primera es la imagen principal.
+func TestUpdateProductImages_Valid(t *testing.T) {
+	mockRepo := new(MockProductRepository)
+	uc := usecase.NewProductUseCase(nil, mockRepo, nil, nil, nil)
+
+	product := &productEntity.Product{ID: "p1", Images: []string{"https://cdn/old.png"}}
+	images := []string{"https://cdn/a.png", "https://cdn/b.png"}
+	mockRepo.On("GetProductById", mock.Anything, "p1").Return(product, nil)
+	mockRepo.On("UpdateProduct", mock.Anything, product).Return(nil)
+
+	err := uc.UpdateProductImages(context.Background(), "p1", images, utils.RoleAdmin)
+
+	assert.NoError(t, err)
+	assert.Equal(t, images, product.Images)
+	assert.Equal(t, "https://cdn/a.png", product.GetPrimaryImage())
+	mockRepo.AssertExpectations(t)
+}
+
+// TestUpdateProductImages_HTTPRejected verifica que una URL http es rechazada.
+func TestUpdateProductImages_HTTPRejected(t *testing.T) {
+	mockRepo := new(MockProductRepository)
+	uc := usecase.NewProductUseCase(nil, mockRepo, nil, nil, nil)
+
+	err := uc.UpdateProductImages(context.Background(), "p1", []string{"https://cdn/a.png", "http://cdn/b.png"}, utils.RoleAdmin)
+
+	assert.ErrorIs(t, err, usecase.ErrInvalidImageURL)
+	mockRepo.AssertNotCalled(t, "UpdateProduct", mock.Anything, mock.Anything)
+}
+
+// TestUpdateProductImages_TooMany verifica que más de 10 imágenes son rechazadas.
+func TestUpdateProductImages_TooMany(t *testing.T) {
+	mockRepo := new(MockProductRepository)
+	uc := usecase.NewProductUseCase(nil, mockRepo, nil, nil, nil)
+
+	images := make([]string, 11)
+	for i := range images {
+		images[i] = fmt.Sprintf("https://cdn/%d.png", i)
+	}
+
+	err := uc.UpdateProductImages(context.Background(), "p1", images, utils.RoleAdmin)
+
+	assert.ErrorIs(t, err, usecase.ErrTooManyImages)
+	mockRepo.AssertNotCalled(t, "GetProductById", mock.Anything, mock.Anything)
+}
+
+// TestUpdateProductImages_Empty verifica que una lista vacía borra las imágenes.
+func TestUpdateProductImages_Empty(t *testing.T) {
+	mockRepo := new(MockProductRepository)
+	uc := usecase.NewProductUseCase(nil, mockRepo, nil, nil, nil)
+
+	product := &productEntity.Product{ID: "p1", Images: []string{"https://cdn/old.png"}}
+	mockRepo.On("GetProductById", mock.Anything, "p1").Return(product, nil)
+	mockRepo.On("UpdateProduct", mock.Anything, product).Return(nil)
+
+	err := uc.UpdateProductImages(context.Background(), "p1", nil, utils.RoleAdmin)
+
+	assert.NoError(t, err)
+	assert.Empty(t, product.Images)
+	assert.Equal(t, "", product.GetPrimaryImage())
+}
+
+// TestUpdateProductImages_NonAdmin verifica que solo un admin puede cambiar las imágenes.
+func TestUpdateProductImages_NonAdmin(t *testing.T) {
+	mockRepo := new(MockProductRepository)
+	uc := usecase.NewProductUseCase(nil, mockRepo, nil, nil, nil)
+
+	err := uc.UpdateProductImages(context.Background(), "p1", []string{"https://cdn/a.png"}, utils.RoleCustomer)
+
+	assert.ErrorIs(t, err, usecase.ErrForbidden)
+}
+
+// -------------------------------------
+// Tests de ImportProductsFromJSON
+// -------------------------------------
+
+// TestImportProductsFromJSON_AllValid verifica que todos los productos válidos
+// se insertan en bloque.
+func TestImportProductsFromJSON_AllValid(t *testing.T) {
+	mockRepo := new(MockProductRepository)
+	uc := usecase.NewProductUseCase(validation.New(), mockRepo, nil, nil, nil)
+
+	mockRepo.On("CreateProducts", mock.Anything, mock.MatchedBy(func(products []*productEntity.Product) bool {
+		return len(products) == 2 && products[0].Name == "Mango" && products[1].Price == 3
+	})).Return(nil)
+
+	body := `[
+		{"name": "Mango", "description": "Fruta", "price": 2.5},
+		{"name": "Piña", "description": "Fruta", "price": 3}
+	]`
+	result, err := uc.ImportProductsFromJSON(context.Background(), strings.NewReader(body))
+
+	assert.NoError(t, err)
+	assert.Equal(t, 2, result.Total)
+	assert.Equal(t, 2, result.Imported)
+	assert.Empty(t, result.Errors)
+	mockRepo.AssertExpectations(t)
+}
+
+// TestImportProductsFromJSON_PartialInvalid verifica que los registros
+// inválidos se reportan con su índice y el resto se importa.
+func TestImportProductsFromJSON_PartialInvalid(t *testing.T) {
+	mockRepo := new(MockProductRepository)
+	uc := usecase.NewProductUseCase(validation.New(), mockRepo, nil, nil, nil)
+
+	mockRepo.On("CreateProducts", mock.Anything, mock.MatchedBy(func(products []*productEntity.Product) bool {
+		return len(products) == 1 && products[0].Name == "Mango"
+	})).Return(nil)
+
+	body := `[
+		{"name": "Mango", "description": "Fruta", "price": 2.5},
+		{"name": "", "description": "Sin nombre", "price": 1},
+		{"name": "Gratis", "description": "Precio cero", "price": 0},
+		{"name": "Raro", "description": "Precio texto", "price": "uno"}
+	]`
+	result, err := uc.ImportProductsFromJSON(context.Background(), strings.NewReader(body))
+
+	assert.NoError(t, err)
+	assert.Equal(t, 4, result.Total)
+	assert.Equal(t, 1, result.Imported)
+	if assert.Len(t, result.Errors, 3) {
+		assert.Equal(t, 1, result.Errors[0].Index)
+		assert.Equal(t, 2, result.Errors[1].Index)
+		assert.Equal(t, 3, result.Errors[2].Index)
+		assert.NotEmpty(t, result.Errors[0].Message)
+	}
+	mockRepo.AssertExpectations(t)
+}
+
+// TestImportProductsFromJSON_EmptyArray verifica que un array vacío no inserta nada.
+func TestImportProductsFromJSON_EmptyArray(t *testing.T) {
+	mockRepo := new(MockProductRepository)
+	uc := usecase.NewProductUseCase(validation.New(), mockRepo, nil, nil, nil)
+
+	result, err := uc.ImportProductsFromJSON(context.Background(), strings.NewReader(`[]`))
+
+	assert.NoError(t, err)
+	assert.Equal(t, 0, result.Total)
+	assert.Equal(t, 0, result.Imported)
+	mockRepo.AssertNotCalled(t, "CreateProducts", mock.Anything, mock.Anything)
+}
+
+// TestImportProductsFromJSON_InvalidPayload verifica que un JSON mal formado o
+// un objeto en lugar de un array devuelven ErrInvalidImportPayload.
+func TestImportProductsFromJSON_InvalidPayload(t *testing.T) {
+	for name, body := range map[string]string{
+		"malformed": `[{"name": "Mango",`,
+		"object":    `{"name": "Mango", "description": "Fruta", "price": 2.5}`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			mockRepo := new(MockProductRepository)
+			uc := usecase.NewProductUseCase(validation.New(), mockRepo, nil, nil, nil)
+
+			result, err := uc.ImportProductsFromJSON(context.Background(), strings.NewReader(body))
+
+			assert.Nil(t, result)
+			assert.ErrorIs(t, err, usecase.ErrInvalidImportPayload)
+			mockRepo.AssertNotCalled(t, "CreateProducts", mock.Anything, mock.Anything)
+		})
+	}
+}
+
+// -------------------------------------
+// Tests de GetProductPriceHistory
+// -------------------------------------
+
+// TestGetProductPriceHistory_WithHistory verifica que se devuelve el historial
+// del repositorio.
+func TestGetProductPriceHistory_WithHistory(t *testing.T) {
+	mockRepo := new(MockProductRepository)
+	uc := usecase.NewProductUseCase(nil, mockRepo, nil, nil, nil)
+
+	history := []*productEntity.PriceHistory{
+		{ProductID: "p1", OldPrice: 10, NewPrice: 12},
+		{ProductID: "p1", OldPrice: 8, NewPrice: 10},
+	}
+	mockRepo.On("GetProductById", mock.Anything, "p1").Return(&productEntity.Product{ID: "p1"}, nil)
+	mockRepo.On("GetPriceHistory", mock.Anything, "p1", 10).Return(history, nil)
+
+	result, err := uc.GetProductPriceHistory(context.Background(), "p1", 10)
+
+	assert.NoError(t, err)
+	assert.Equal(t, history, result)
+	mockRepo.AssertExpectations(t)
+}
+
+// TestGetProductPriceHistory_NoHistory verifica que sin historial se devuelve
+// un slice vacío y no un error.
+func TestGetProductPriceHistory_NoHistory(t *testing.T) {
+	mockRepo := new(MockProductRepository)
+	uc := usecase.NewProductUseCase(nil, mockRepo, nil, nil, nil)
+
+	mockRepo.On("GetProductById", mock.Anything, "p1").Return(&productEntity.Product{ID: "p1"}, nil)
+	mockRepo.On("GetPriceHistory", mock.Anything, "p1", 5).Return(nil, nil)
+
+	result, err := uc.GetProductPriceHistory(context.Background(), "p1", 5)
+
+	assert.NoError(t, err)
+	assert.NotNil(t, result)
+	assert.Empty(t, result)
+}
+
+// TestGetProductPriceHistory_LimitClamping verifica que un límite mayor de 50
+// se reduce a 50 y uno menor de 1 es rechazado.
+func TestGetProductPriceHistory_LimitClamping(t *testing.T) {
+	mockRepo := new(MockProductRepository)
+	uc := usecase.NewProductUseCase(nil, mockRepo, nil, nil, nil)
+
+	mockRepo.On("GetProductById", mock.Anything, "p1").Return(&productEntity.Product{ID: "p1"}, nil)
+	mockRepo.On("GetPriceHistory", mock.Anything, "p1", 50).Return([]*productEntity.PriceHistory{}, nil)
+
+	_, err := uc.GetProductPriceHistory(context.Background(), "p1", 500)
+	assert.NoError(t, err)
+	mockRepo.AssertCalled(t, "GetPriceHistory", mock.Anything, "p1", 50)
+
+	_, err = uc.GetProductPriceHistory(context.Background(), "p1", 0)
+	assert.ErrorIs(t, err, usecase.ErrInvalidHistoryLimit)
+}
+
+// TestGetProductPriceHistory_ProductNotFound verifica que un producto
+// inexistente devuelve el error del repositorio.
+func TestGetProductPriceHistory_ProductNotFound(t *testing.T) {
+	mockRepo := new(MockProductRepository)
+	uc := usecase.NewProductUseCase(nil, mockRepo, nil, nil, nil)
+
+	mockRepo.On("GetProductById", mock.Anything, "missing").Return(nil, gorm.ErrRecordNotFound)
+
+	result, err := uc.GetProductPriceHistory(context.Background(), "missing", 10)
+
+	assert.Nil(t, result)
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+	mockRepo.AssertNotCalled(t, "GetPriceHistory", mock.Anything, mock.Anything, mock.Anything)
+}
+
+// -------------------------------------
+// Tests de GetProductsUpdatedSince
+// -------------------------------------
+
+// TestGetProductsUpdatedSince_CapsAt5000 verifica que se pide al repositorio
+// como máximo 5000 productos.
+func TestGetProductsUpdatedSince_CapsAt5000(t *testing.T) {
+	mockRepo := new(MockProductRepository)
+	uc := usecase.NewProductUseCase(nil, mockRepo, nil, nil, nil)
+
+	since := time.Now().Add(-time.Hour)
+	products := []*productEntity.Product{{ID: "p1"}, {ID: "p2"}}
+	mockRepo.On("GetProductsUpdatedSince", mock.Anything, since, 5000).Return(products, nil)
+
+	result, err := uc.GetProductsUpdatedSince(context.Background(), since)
+
+	assert.NoError(t, err)
+	assert.Equal(t, products, result)
+	mockRepo.AssertExpectations(t)
+}
+
+// -------------------------------------
+// Tests de GetProductMeta
+// -------------------------------------
+
+// TestGetProductMeta_Parallel verifica que las consultas se ejecutan en
+// paralelo: con cuatro consultas de 50ms el total debe quedar muy por debajo
+// de la suma secuencial.
+func TestGetProductMeta_Parallel(t *testing.T) {
+	mockRepo := new(MockProductRepository)
+	uc := usecase.NewProductUseCase(nil, mockRepo, nil, nil, nil)
+
+	delay := 50 * time.Millisecond
+	mockRepo.On("GetProductById", mock.Anything, "p1").Return(&productEntity.Product{ID: "p1"}, nil).After(delay)
+	mockRepo.On("GetProductUnitsSold", mock.Anything, "p1").Return(12, nil).After(delay)
+	mockRepo.On("GetProductRevenue", mock.Anything, "p1").Return(240.0, nil).After(delay)
+	mockRepo.On("GetProductRatingStats", mock.Anything, "p1").Return(4.5, 8, nil).After(delay)
+
+	start := time.Now()
+	meta, err := uc.GetProductMeta(context.Background(), "p1")
+	elapsed := time.Since(start)
+
+	assert.NoError(t, err)
+	assert.Equal(t, &productEntity.ProductMeta{
+		TotalSold:     12,
+		TotalRevenue:  240,
+		AverageRating: 4.5,
+		ReviewCount:   8,
+	}, meta)
+	assert.Less(t, elapsed, 3*delay)
+}
+
+// TestGetProductMeta_PartialFailure verifica que si fallan las consultas no
+// críticas (valoraciones) se devuelven las ventas con esos campos a cero.
+func TestGetProductMeta_PartialFailure(t *testing.T) {
+	mockRepo := new(MockProductRepository)
+	uc := usecase.NewProductUseCase(nil, mockRepo, nil, nil, nil)
+
+	mockRepo.On("GetProductById", mock.Anything, "p1").Return(&productEntity.Product{ID: "p1"}, nil)
+	mockRepo.On("GetProductUnitsSold", mock.Anything, "p1").Return(5, nil)
+	mockRepo.On("GetProductRevenue", mock.Anything, "p1").Return(50.0, nil)
+	mockRepo.On("GetProductRatingStats", mock.Anything, "p1").Return(0.0, 0, errors.New("ratings down"))
+
+	meta, err := uc.GetProductMeta(context.Background(), "p1")
+
+	assert.NoError(t, err)
+	assert.Equal(t, &productEntity.ProductMeta{TotalSold: 5, TotalRevenue: 50}, meta)
+}
+
+// TestGetProductMeta_CriticalFailure verifica que un fallo en las ventas sí
+// hace fallar la consulta completa.
+func TestGetProductMeta_CriticalFailure(t *testing.T) {
+	mockRepo := new(MockProductRepository)
+	uc := usecase.NewProductUseCase(nil, mockRepo, nil, nil, nil)
+
+	dbErr := errors.New("db error")
+	mockRepo.On("GetProductById", mock.Anything, "p1").Return(&productEntity.Product{ID: "p1"}, nil)
+	mockRepo.On("GetProductUnitsSold", mock.Anything, "p1").Return(0, dbErr)
+	mockRepo.On("GetProductRevenue", mock.Anything, "p1").Return(0.0, nil)
+	mockRepo.On("GetProductRatingStats", mock.Anything, "p1").Return(4.0, 1, nil)
+
+	meta, err := uc.GetProductMeta(context.Background(), "p1")
+
+	assert.Nil(t, meta)
+	assert.ErrorIs(t, err, dbErr)
+}
+
+// -------------------------------------
+// Tests de GetProductsBySupplier
+// -------------------------------------
+
+// TestGetProductsBySupplier_Admin verifica que un admin puede listar los
+// productos de cualquier proveedor.
+func TestGetProductsBySupplier_Admin(t *testing.T) {
+	mockRepo := new(MockProductRepository)
+	uc := usecase.NewProductUseCase(nil, mockRepo, nil, nil, nil)
+
+	req := &paging.Pagination{Page: 1, Size: 10}
+	expected := []*productEntity.Product{{ID: "p1"}, {ID: "p2"}}
+	mockRepo.On("GetProductsBySupplier", mock.Anything, "s1", req).Return(expected, &paging.Pagination{TotalCount: 2}, nil)
+
+	products, pagination, err := uc.GetProductsBySupplier(context.Background(), "s1", req, "admin-1", utils.RoleAdmin)
+
+	assert.NoError(t, err)
+	assert.Equal(t, expected, products)
+	assert.Equal(t, int64(2), pagination.TotalCount)
+}
+
+// TestGetProductsBySupplier_OwnSupplier verifica que un proveedor puede listar
+// sus propios productos.
+func TestGetProductsBySupplier_OwnSupplier(t *testing.T) {
+	mockRepo := new(MockProductRepository)
+	uc := usecase.NewProductUseCase(nil, mockRepo, nil, nil, nil)
+
+	expected := []*productEntity.Product{{ID: "p1"}}
+	mockRepo.On("GetProductsBySupplier", mock.Anything, "s1", (*paging.Pagination)(nil)).Return(expected, &paging.Pagination{TotalCount: 1}, nil)
+
+	products, _, err := uc.GetProductsBySupplier(context.Background(), "s1", nil, "s1", utils.RoleSupplier)
+
+	assert.NoError(t, err)
+	assert.Equal(t, expected, products)
+}
+
+// TestGetProductsBySupplier_OtherSupplier verifica que un proveedor no puede
+// listar los productos de otro proveedor ni un cliente los de ninguno.
+func TestGetProductsBySupplier_OtherSupplier(t *testing.T) {
+	mockRepo := new(MockProductRepository)
+	uc := usecase.NewProductUseCase(nil, mockRepo, nil, nil, nil)
+
+	products, _, err := uc.GetProductsBySupplier(context.Background(), "s1", nil, "s2", utils.RoleSupplier)
+
+	assert.Nil(t, products)
+	assert.ErrorIs(t, err, usecase.ErrForbidden)
+
+	_, _, err = uc.GetProductsBySupplier(context.Background(), "s1", nil, "s1", utils.RoleCustomer)
+
+	assert.ErrorIs(t, err, usecase.ErrForbidden)
+	mockRepo.AssertNotCalled(t, "GetProductsBySupplier", mock.Anything, mock.Anything, mock.Anything)
+}
+
+// -------------------------------------
+// Tests de Autocomplete
+// -------------------------------------
+
+// TestAutocomplete_MapsResults verifica que los productos se reducen a los
+// campos de autocompletado, usando la imagen principal.
+func TestAutocomplete_MapsResults(t *testing.T) {
+	mockRepo := new(MockProductRepository)
+	uc := usecase.NewProductUseCase(nil, mockRepo, nil, nil, nil)
+
+	mockRepo.On("SearchProductsByNamePrefix", mock.Anything, "man", 5).Return([]*productEntity.Product{
+		{ID: "p1", Name: "Mango", Price: 2.5, Images: []string{"https://cdn.example.com/a.png", "https://cdn.example.com/b.png"}},
+		{ID: "p2", Name: "Mandarina", Price: 1},
+	}, nil)
+
+	results, err := uc.Autocomplete(context.Background(), " man ", 0)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []*productEntity.AutocompleteResult{
+		{ID: "p1", Name: "Mango", Price: 2.5, ImageURL: "https://cdn.example.com/a.png"},
+		{ID: "p2", Name: "Mandarina", Price: 1},
+	}, results)
+}
+
+// TestAutocomplete_BlankQuery verifica que una consulta vacía devuelve una
+// lista vacía sin tocar el repositorio.
+func TestAutocomplete_BlankQuery(t *testing.T) {
+	mockRepo := new(MockProductRepository)
+	uc := usecase.NewProductUseCase(nil, mockRepo, nil, nil, nil)
+
+	results, err := uc.Autocomplete(context.Background(), "   ", 5)
+
+	assert.NoError(t, err)
+	assert.NotNil(t, results)
+	assert.Empty(t, results)
+	mockRepo.AssertNotCalled(t, "SearchProductsByNamePrefix", mock.Anything, mock.Anything, mock.Anything)
+}
+
+// TestAutocomplete_LimitCapped verifica que el límite se acota al máximo.
+func TestAutocomplete_LimitCapped(t *testing.T) {
+	mockRepo := new(MockProductRepository)
+	uc := usecase.NewProductUseCase(nil, mockRepo, nil, nil, nil)
+
+	mockRepo.On("SearchProductsByNamePrefix", mock.Anything, "a", 20).Return(nil, nil)
+
+	results, err := uc.Autocomplete(context.Background(), "a", 500)
+
+	assert.NoError(t, err)
+	assert.Empty(t, results)
+	mockRepo.AssertExpectations(t)
+}
+
+// -------------------------------------
+// Tests de CreateProductFromTemplate
+// -------------------------------------
+
+func newTemplate() *productEntity.ProductTemplate {
+	categoryID := "cat-fruit"
+	return &productEntity.ProductTemplate{
+		ID:           "t1",
+		Name:         "Fruta de temporada",
+		CategoryID:   &categoryID,
+		DefaultPrice: 3.5,
+		DefaultTags:  []string{"fresh", "seasonal"},
+	}
+}
+
+// TestCreateProductFromTemplate_Defaults verifica que sin overrides el producto
+// toma todos los valores de la plantilla.
+func TestCreateProductFromTemplate_Defaults(t *testing.T) {
+	mockRepo := new(MockProductRepository)
+	mockTemplates := new(MockProductTemplateRepository)
+	uc := usecase.NewProductUseCase(nil, mockRepo, nil, mockTemplates, nil)
+
+	template := newTemplate()
+	mockTemplates.On("GetTemplateByID", mock.Anything, "t1").Return(template, nil)
+	mockRepo.On("CreatedProduct", mock.Anything, mock.Anything).Return(nil)
+
+	product, err := uc.CreateProductFromTemplate(context.Background(), "t1", nil, utils.RoleAdmin)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "Fruta de temporada", product.Name)
+	assert.Equal(t, template.CategoryID, product.CategoryID)
+	assert.Equal(t, 3.5, product.Price)
+	assert.Equal(t, []string{"fresh", "seasonal"}, product.Tags)
+	mockRepo.AssertCalled(t, "CreatedProduct", mock.Anything, product)
+}
+
+// TestCreateProductFromTemplate_AllOverrides verifica que todos los campos de
+// overrides sustituyen a los de la plantilla, incluida la imagen subida.
+func TestCreateProductFromTemplate_AllOverrides(t *testing.T) {
+	mockRepo := new(MockProductRepository)
+	mockTemplates := new(MockProductTemplateRepository)
+	mockUpload := new(MockUploadService)
+	uc := usecase.NewProductUseCase(nil, mockRepo, mockUpload, mockTemplates, nil)
+
+	image := &multipart.FileHeader{Filename: "mango.png"}
+	categoryID := "cat-tropical"
+	overrides := &prodDto.CreateProductRequest{
+		Name:        "Mango",
+		Description: "Mango Ataulfo",
+		Image:       image,
+		Price:       4.2,
+		CategoryID:  &categoryID,
+		Tags:        []string{"tropical"},
+	}
+	mockTemplates.On("GetTemplateByID", mock.Anything, "t1").Return(newTemplate(), nil)
+	mockUpload.On("UploadFile", mock.Anything, image, "products").Return("https://cdn.example.com/mango.png", nil)
+	mockRepo.On("CreatedProduct", mock.Anything, mock.Anything).Return(nil)
+
+	product, err := uc.CreateProductFromTemplate(context.Background(), "t1", overrides, utils.RoleAdmin)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "Mango", product.Name)
+	assert.Equal(t, "Mango Ataulfo", product.Description)
+	assert.Equal(t, 4.2, product.Price)
+	assert.Equal(t, &categoryID, product.CategoryID)
+	assert.Equal(t, []string{"tropical"}, product.Tags)
+	assert.Equal(t, []string{"https://cdn.example.com/mango.png"}, product.Images)
+}
+
+// TestCreateProductFromTemplate_PartialOverrides verifica que solo se
+// sustituyen los campos informados y el resto se toma de la plantilla.
+func TestCreateProductFromTemplate_PartialOverrides(t *testing.T) {
+	mockRepo := new(MockProductRepository)
+	mockTemplates := new(MockProductTemplateRepository)
+	uc := usecase.NewProductUseCase(nil, mockRepo, nil, mockTemplates, nil)
+
+	template := newTemplate()
+	mockTemplates.On("GetTemplateByID", mock.Anything, "t1").Return(template, nil)
+	mockRepo.On("CreatedProduct", mock.Anything, mock.Anything).Return(nil)
+
+	product, err := uc.CreateProductFromTemplate(context.Background(), "t1", &prodDto.CreateProductRequest{Name: "Papaya"}, utils.RoleAdmin)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "Papaya", product.Name)
+	assert.Equal(t, template.CategoryID, product.CategoryID)
+	assert.Equal(t, 3.5, product.Price)
+	assert.Equal(t, []string{"fresh", "seasonal"}, product.Tags)
+	assert.Empty(t, product.Images)
+}
+
+// TestCreateProductFromTemplate_NotFound verifica que una plantilla
+// inexistente devuelve ErrTemplateNotFound sin crear nada.
+func TestCreateProductFromTemplate_NotFound(t *testing.T) {
+	mockRepo := new(MockProductRepository)
+	mockTemplates := new(MockProductTemplateRepository)
+	uc := usecase.NewProductUseCase(nil, mockRepo, nil, mockTemplates, nil)
+
+	mockTemplates.On("GetTemplateByID", mock.Anything, "missing").Return(nil, gorm.ErrRecordNotFound)
+
+	product, err := uc.CreateProductFromTemplate(context.Background(), "missing", nil, utils.RoleAdmin)
+
+	assert.Nil(t, product)
+	assert.ErrorIs(t, err, usecase.ErrTemplateNotFound)
+	mockRepo.AssertNotCalled(t, "CreatedProduct", mock.Anything, mock.Anything)
+}
+
+// -------------------------------------
+// Tests de GetProductsByCategorySlug
+// -------------------------------------
+
+// TestGetProductsByCategorySlug_WithProducts verifica que el slug se resuelve a
+// la categoría y se listan sus productos.
+func TestGetProductsByCategorySlug_WithProducts(t *testing.T) {
+	mockRepo := new(MockProductRepository)
+	mockCategories := new(MockCategoryRepository)
+	uc := usecase.NewProductUseCase(nil, mockRepo, nil, nil, mockCategories)
+
+	req := &paging.Pagination{Page: 1, Size: 10}
+	expected := []*productEntity.Product{{ID: "p1"}, {ID: "p2"}}
+	mockCategories.On("GetBySlug", mock.Anything, "frutas").Return(&productEntity.Category{ID: "cat-1", Slug: "frutas"}, nil)
+	mockRepo.On("GetProductsByCategoryID", mock.Anything, "cat-1", req).Return(expected, &paging.Pagination{TotalCount: 2}, nil)
+
+	products, pagination, err := uc.GetProductsByCategorySlug(context.Background(), "frutas", req)
+
+	assert.NoError(t, err)
+	assert.Equal(t, expected, products)
+	assert.Equal(t, int64(2), pagination.TotalCount)
+}
+
+// TestGetProductsByCategorySlug_Empty verifica que una categoría sin productos
+// devuelve una lista vacía sin error.
+func TestGetProductsByCategorySlug_Empty(t *testing.T) {
+	mockRepo := new(MockProductRepository)
+	mockCategories := new(MockCategoryRepository)
+	uc := usecase.NewProductUseCase(nil, mockRepo, nil, nil, mockCategories)
+
+	mockCategories.On("GetBySlug", mock.Anything, "verduras").Return(&productEntity.Category{ID: "cat-2", Slug: "verduras"}, nil)
+	mockRepo.On("GetProductsByCategoryID", mock.Anything, "cat-2", (*paging.Pagination)(nil)).Return([]*productEntity.Product{}, &paging.Pagination{}, nil)
+
+	products, _, err := uc.GetProductsByCategorySlug(context.Background(), "verduras", nil)
+
+	assert.NoError(t, err)
+	assert.Empty(t, products)
+}
+
+// TestGetProductsByCategorySlug_Unknown verifica que un slug desconocido
+// devuelve ErrCategoryNotFound sin consultar productos.
+func TestGetProductsByCategorySlug_Unknown(t *testing.T) {
+	mockRepo := new(MockProductRepository)
+	mockCategories := new(MockCategoryRepository)
+	uc := usecase.NewProductUseCase(nil, mockRepo, nil, nil, mockCategories)
+
+	mockCategories.On("GetBySlug", mock.Anything, "missing").Return(nil, gorm.ErrRecordNotFound)
+
+	products, _, err := uc.GetProductsByCategorySlug(context.Background(), "missing", nil)
+
+	assert.Nil(t, products)
+	assert.ErrorIs(t, err, usecase.ErrCategoryNotFound)
+	mockRepo.AssertNotCalled(t, "GetProductsByCategoryID", mock.Anything, mock.Anything, mock.Anything)
+}
+
+// TestGetProductsByCategorySlug_URLEncoded verifica que un slug con caracteres
+// especiales codificados se decodifica antes de buscarlo, y que una
+// codificación inválida se trata como categoría inexistente.
+func TestGetProductsByCategorySlug_URLEncoded(t *testing.T) {
+	mockRepo := new(MockProductRepository)
+	mockCategories := new(MockCategoryRepository)
+	uc := usecase.NewProductUseCase(nil, mockRepo, nil, nil, mockCategories)
+
+	mockCategories.On("GetBySlug", mock.Anything, "café-té").Return(&productEntity.Category{ID: "cat-3", Slug: "café-té"}, nil)
+	mockRepo.On("GetProductsByCategoryID", mock.Anything, "cat-3", (*paging.Pagination)(nil)).Return([]*productEntity.Product{{ID: "p9"}}, &paging.Pagination{TotalCount: 1}, nil)
+
+	products, _, err := uc.GetProductsByCategorySlug(context.Background(), "caf%C3%A9-t%C3%A9", nil)
+
+	assert.NoError(t, err)
+	assert.Len(t, products, 1)
+
+	_, _, err = uc.GetProductsByCategorySlug(context.Background(), "caf%zz", nil)
+
+	assert.ErrorIs(t, err, usecase.ErrCategoryNotFound)
+}
+
+// -------------------------------------
+// Tests de GetProductStockAlert
+// -------------------------------------
+
+// TestGetProductStockAlert_Levels verifica el nivel de alerta en cada límite
+// del stock disponible.
+func TestGetProductStockAlert_Levels(t *testing.T) {
+	cases := []struct {
+		available int
+		level     string
+	}{
+		{0, productEntity.StockAlertOut},
+		{1, productEntity.StockAlertCritical},
+		{2, productEntity.StockAlertCritical},
+		{3, productEntity.StockAlertLow},
+		{10, productEntity.StockAlertLow},
+		{11, productEntity.StockAlertOK},
+	}
+	for _, tc := range cases {
+		mockRepo := new(MockProductRepository)
+		uc := usecase.NewProductUseCase(nil, mockRepo, nil, nil, nil)
+
+		mockRepo.On("GetProductStock", mock.Anything, "p1").Return(tc.available+4, nil)
+		mockRepo.On("GetReservedStock", mock.Anything, "p1").Return(4, nil)
+
+		alert, err := uc.GetProductStockAlert(context.Background(), "p1")
+
+		assert.NoError(t, err)
+		assert.Equal(t, tc.available+4, alert.CurrentStock)
+		assert.Equal(t, 4, alert.ReservedStock)
+		assert.Equal(t, tc.available, alert.AvailableStock)
+		assert.Equal(t, tc.level, alert.AlertLevel, "available=%d", tc.available)
+	}
+}
+
+// TestGetProductStockAlert_OverReserved verifica que si las reservas superan
+// el stock el producto se marca como agotado.
+func TestGetProductStockAlert_OverReserved(t *testing.T) {
+	mockRepo := new(MockProductRepository)
+	uc := usecase.NewProductUseCase(nil, mockRepo, nil, nil, nil)
+
+	mockRepo.On("GetProductStock", mock.Anything, "p1").Return(3, nil)
+	mockRepo.On("GetReservedStock", mock.Anything, "p1").Return(5, nil)
+
+	alert, err := uc.GetProductStockAlert(context.Background(), "p1")
+
+	assert.NoError(t, err)
+	assert.Equal(t, -2, alert.AvailableStock)
+	assert.Equal(t, productEntity.StockAlertOut, alert.AlertLevel)
+}
+
+// TestGetProductStockAlert_NotFound verifica que el error del repositorio se
+// propaga sin consultar las reservas.
+func TestGetProductStockAlert_NotFound(t *testing.T) {
+	mockRepo := new(MockProductRepository)
+	uc := usecase.NewProductUseCase(nil, mockRepo, nil, nil, nil)
+
+	mockRepo.On("GetProductStock", mock.Anything, "p1").Return(0, gorm.ErrRecordNotFound)
+
+	alert, err := uc.GetProductStockAlert(context.Background(), "p1")
+
+	assert.Nil(t, alert)
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+	mockRepo.AssertNotCalled(t, "GetReservedStock", mock.Anything, mock.Anything)
+}
+
+// -------------------------------------
+// Tests de SchedulePriceChange
+// -------------------------------------
+
+// TestSchedulePriceChange_Success verifica que se guarda el cambio pendiente
+// con el precio y la fecha indicados.
+func TestSchedulePriceChange_Success(t *testing.T) {
+	mockRepo := new(MockProductRepository)
+	uc := usecase.NewProductUseCase(nil, mockRepo, nil, nil, nil)
+
+	effectiveAt := time.Now().Add(24 * time.Hour)
+	mockRepo.On("GetProductById", mock.Anything, "p1").Return(&productEntity.Product{ID: "p1", Price: 20}, nil)
+	mockRepo.On("CreateScheduledPriceChange", mock.Anything, mock.MatchedBy(func(c *productEntity.ScheduledPriceChange) bool {
+		return c.ProductID == "p1" && c.NewPrice == 15 && c.EffectiveAt.Equal(effectiveAt) && !c.IsApplied
+	})).Return(nil)
+
+	err := uc.SchedulePriceChange(context.Background(), "p1", 15, effectiveAt, utils.RoleAdmin)
+
+	assert.NoError(t, err)
+	mockRepo.AssertExpectations(t)
+}
+
+// TestSchedulePriceChange_PastDate verifica que una fecha pasada o actual es
+// rechazada.
+func TestSchedulePriceChange_PastDate(t *testing.T) {
+	mockRepo := new(MockProductRepository)
+	uc := usecase.NewProductUseCase(nil, mockRepo, nil, nil, nil)
+
+	err := uc.SchedulePriceChange(context.Background(), "p1", 15, time.Now().Add(-time.Minute), utils.RoleAdmin)
+
+	assert.ErrorIs(t, err, usecase.ErrInvalidEffectiveAt)
+	mockRepo.AssertNotCalled(t, "CreateScheduledPriceChange", mock.Anything, mock.Anything)
+}
+
+// TestSchedulePriceChange_InvalidInput verifica el precio no positivo y el rol.
+func TestSchedulePriceChange_InvalidInput(t *testing.T) {
+	mockRepo := new(MockProductRepository)
+	uc := usecase.NewProductUseCase(nil, mockRepo, nil, nil, nil)
+	effectiveAt := time.Now().Add(time.Hour)
+
+	err := uc.SchedulePriceChange(context.Background(), "p1", 0, effectiveAt, utils.RoleAdmin)
+	assert.ErrorIs(t, err, usecase.ErrInvalidPrice)
+
+	err = uc.SchedulePriceChange(context.Background(), "p1", 15, effectiveAt, utils.RoleCustomer)
+	assert.ErrorIs(t, err, usecase.ErrForbidden)
+
+	mockRepo.AssertNotCalled(t, "CreateScheduledPriceChange", mock.Anything, mock.Anything)
+}
+
+// -------------------------------------
+// Tests de ProcessScheduledPriceChanges
+// -------------------------------------
+
+// TestProcessScheduledPriceChanges_AppliesDue verifica que se aplican los
+// cambios vencidos y que un fallo no detiene el resto.
+func TestProcessScheduledPriceChanges_AppliesDue(t *testing.T) {
+	mockRepo := new(MockProductRepository)
+	uc := usecase.NewProductUseCase(nil, mockRepo, nil, nil, nil)
+
+	c1 := &productEntity.ScheduledPriceChange{ID: "c1", ProductID: "p1", NewPrice: 10}
+	c2 := &productEntity.ScheduledPriceChange{ID: "c2", ProductID: "p2", NewPrice: 12}
+	c3 := &productEntity.ScheduledPriceChange{ID: "c3", ProductID: "p3", NewPrice: 14}
+	mockRepo.On("GetDueScheduledPriceChanges", mock.Anything, mock.AnythingOfType("time.Time"), 500).
+		Return([]*productEntity.ScheduledPriceChange{c1, c2, c3}, nil)
+	mockRepo.On("ApplyScheduledPriceChange", mock.Anything, c1).Return(nil)
+	mockRepo.On("ApplyScheduledPriceChange", mock.Anything, c2).Return(errors.New("db error"))
+	mockRepo.On("ApplyScheduledPriceChange", mock.Anything, c3).Return(nil)
+
+	applied, err := uc.ProcessScheduledPriceChanges(context.Background())
+
+	assert.NoError(t, err)
+	assert.Equal(t, 2, applied)
+	assert.True(t, c1.IsApplied)
+	assert.False(t, c2.IsApplied)
+	assert.True(t, c3.IsApplied)
+}
+
+// TestProcessScheduledPriceChanges_FetchError verifica que el error al buscar
+// los cambios vencidos se propaga.
+func TestProcessScheduledPriceChanges_FetchError(t *testing.T) {
+	mockRepo := new(MockProductRepository)
+	uc := usecase.NewProductUseCase(nil, mockRepo, nil, nil, nil)
+
+	mockRepo.On("GetDueScheduledPriceChanges", mock.Anything, mock.Anything, mock.Anything).Return(nil, errors.New("db error"))
+
+	applied, err := uc.ProcessScheduledPriceChanges(context.Background())
+
+	assert.Equal(t, 0, applied)
+	assert.EqualError(t, err, "db error")
+}
+
+// -------------------------------------
+// Tests de GetBundlePrice
+// -------------------------------------
+
+func bundleProducts(n int) ([]string, []*productEntity.Product) {
+	ids := make([]string, 0, n)
+	products := make([]*productEntity.Product, 0, n)
+	for i := 0; i < n; i++ {
+		id := fmt.Sprintf("p%d", i+1)
+		ids = append(ids, id)
+		products = append(products, &productEntity.Product{ID: id, Price: 10, Active: true})
+	}
+	return ids, products
+}
+
+// TestGetBundlePrice_Tiers verifica el descuento aplicado en cada tramo y en
+// sus límites.
+func TestGetBundlePrice_Tiers(t *testing.T) {
+	cases := []struct {
+		items    int
+		percent  float64
+		discount float64
+	}{
+		{1, 0, 0},
+		{2, 5, 1},
+		{3, 10, 3},
+		{4, 10, 4},
+		{5, 15, 7.5},
+		{20, 15, 30},
+	}
+	for _, tc := range cases {
+		mockRepo := new(MockProductRepository)
+		uc := usecase.NewProductUseCase(nil, mockRepo, nil, nil, nil)
+
+		ids, products := bundleProducts(tc.items)
+		mockRepo.On("GetProductsByIDs", mock.Anything, ids).Return(products, nil)
+
+		bundle, err := uc.GetBundlePrice(context.Background(), ids)
+
+		assert.NoError(t, err)
+		assert.Len(t, bundle.Products, tc.items)
+		assert.Equal(t, float64(tc.items*10), bundle.IndividualTotal)
+		assert.Equal(t, tc.percent, bundle.DiscountPercent, "items=%d", tc.items)
+		assert.Equal(t, tc.discount, bundle.BundleDiscount, "items=%d", tc.items)
+		assert.Equal(t, float64(tc.items*10)-tc.discount, bundle.BundleTotal, "items=%d", tc.items)
+	}
+}
+
+// TestGetBundlePrice_DuplicateIDs verifica que los ids repetidos cuentan una
+// sola vez para el tramo.
+func TestGetBundlePrice_DuplicateIDs(t *testing.T) {
+	mockRepo := new(MockProductRepository)
+	uc := usecase.NewProductUseCase(nil, mockRepo, nil, nil, nil)
+
+	ids, products := bundleProducts(2)
+	mockRepo.On("GetProductsByIDs", mock.Anything, ids).Return(products, nil)
+
+	bundle, err := uc.GetBundlePrice(context.Background(), []string{"p1", "p2", "p1"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 5.0, bundle.DiscountPercent)
+}
+
+// TestGetBundlePrice_Empty verifica que un paquete vacío es rechazado.
+func TestGetBundlePrice_Empty(t *testing.T) {
+	mockRepo := new(MockProductRepository)
+	uc := usecase.NewProductUseCase(nil, mockRepo, nil, nil, nil)
+
+	bundle, err := uc.GetBundlePrice(context.Background(), nil)
+
+	assert.Nil(t, bundle)
+	assert.ErrorIs(t, err, usecase.ErrEmptyBundle)
+}
+
+// TestGetBundlePrice_TooMany verifica el máximo de 20 productos.
+func TestGetBundlePrice_TooMany(t *testing.T) {
+	mockRepo := new(MockProductRepository)
+	uc := usecase.NewProductUseCase(nil, mockRepo, nil, nil, nil)
+
+	ids, _ := bundleProducts(21)
+	bundle, err := uc.GetBundlePrice(context.Background(), ids)
+
+	assert.Nil(t, bundle)
+	assert.ErrorIs(t, err, usecase.ErrTooManyBundleItems)
+	mockRepo.AssertNotCalled(t, "GetProductsByIDs", mock.Anything, mock.Anything)
+}
+
+// TestGetBundlePrice_Unavailable verifica que un producto inexistente o
+// inactivo invalida el paquete.
+func TestGetBundlePrice_Unavailable(t *testing.T) {
+	mockRepo := new(MockProductRepository)
+	uc := usecase.NewProductUseCase(nil, mockRepo, nil, nil, nil)
+
+	mockRepo.On("GetProductsByIDs", mock.Anything, []string{"p1", "p2"}).
+		Return([]*productEntity.Product{{ID: "p1", Price: 10, Active: true}}, nil)
+	mockRepo.On("GetProductsByIDs", mock.Anything, []string{"p1", "p3"}).
+		Return([]*productEntity.Product{{ID: "p1", Price: 10, Active: true}, {ID: "p3", Price: 10}}, nil)
+
+	_, err := uc.GetBundlePrice(context.Background(), []string{"p1", "p2"})
+	assert.ErrorIs(t, err, usecase.ErrBundleProductUnavailable)
+
+	_, err = uc.GetBundlePrice(context.Background(), []string{"p1", "p3"})
+	assert.ErrorIs(t, err, usecase.ErrBundleProductUnavailable)
+}
+
+// -------------------------------------
+// Tests de CloneProductCatalog
+// -------------------------------------
+
+func newCloneUseCase() (usecase.IProductUseCase, *MockProductRepository, *MockCategoryRepository) {
+	mockRepo := new(MockProductRepository)
+	mockCategories := new(MockCategoryRepository)
+	mockCategories.On("GetByID", mock.Anything, "src").Return(&productEntity.Category{ID: "src"}, nil)
+	mockCategories.On("GetByID", mock.Anything, "dst").Return(&productEntity.Category{ID: "dst"}, nil)
+	return usecase.NewProductUseCase(nil, mockRepo, nil, nil, mockCategories), mockRepo, mockCategories
+}
+
+// TestCloneProductCatalog_Success verifica que se copian los productos activos
+// en la categoría destino sin stock, código de barras ni id externo.
+func TestCloneProductCatalog_Success(t *testing.T) {
+	uc, mockRepo, _ := newCloneUseCase()
+
+	mockRepo.On("GetAllProductsByCategoryID", mock.Anything, "src").Return([]*productEntity.Product{
+		{ID: "p1", Name: "Manzana", Price: 2, Stock: 40, Barcode: "123", ExternalID: "ext-1", Active: true},
+		{ID: "p2", Name: "Pera", Price: 3, Active: true},
+		{ID: "p3", Name: "Kiwi", Price: 4, Active: false},
+	}, nil)
+	mockRepo.On("GetProductNamesWithPrefixes", mock.Anything, []string{"Manzana-clone", "Pera-clone"}).Return(nil, nil).Once()
+	var created []*productEntity.Product
+	mockRepo.On("CreatedProduct", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		created = append(created, args.Get(1).(*productEntity.Product))
+	}).Return(nil)
+
+	result, err := uc.CloneProductCatalog(context.Background(), "src", "dst", utils.RoleAdmin)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 2, result.Cloned)
+	assert.Equal(t, 1, result.Skipped)
+	assert.Empty(t, result.Errors)
+	assert.Len(t, created, 2)
+	assert.Equal(t, "Manzana-clone", created[0].Name)
+	assert.Equal(t, "Pera-clone", created[1].Name)
+	assert.Equal(t, "dst", *created[0].CategoryID)
+	assert.Equal(t, 2.0, created[0].Price)
+	assert.Zero(t, created[0].Stock)
+	assert.Empty(t, created[0].Barcode)
+	assert.Empty(t, created[0].ExternalID)
+	mockRepo.AssertExpectations(t)
+}
+
+// TestCloneProductCatalog_NameConflict verifica que si el nombre clonado ya
+// existe se usa el primer sufijo numérico libre.
+func TestCloneProductCatalog_NameConflict(t *testing.T) {
+	uc, mockRepo, _ := newCloneUseCase()
+
+	mockRepo.On("GetAllProductsByCategoryID", mock.Anything, "src").Return([]*productEntity.Product{
+		{ID: "p1", Name: "Manzana", Active: true},
+		{ID: "p2", Name: "Pera", Active: true},
+	}, nil)
+	mockRepo.On("GetProductNamesWithPrefixes", mock.Anything, mock.Anything).
+		Return([]string{"Manzana-clone", "Manzana-clone-2", "Manzana-clone-roja", "Pera-clone-2"}, nil)
+	var created []*productEntity.Product
+	mockRepo.On("CreatedProduct", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		created = append(created, args.Get(1).(*productEntity.Product))
+	}).Return(nil)
+
+	result, err := uc.CloneProductCatalog(context.Background(), "src", "dst", utils.RoleAdmin)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 2, result.Cloned)
+	assert.Len(t, created, 2)
+	assert.Equal(t, "Manzana-clone-3", created[0].Name)
+	assert.Equal(t, "Pera-clone", created[1].Name)
+}
+
+// TestCloneProductCatalog_PartialFailure verifica que una copia que no se
+// puede guardar, por ejemplo porque otro alta tomó su nombre, se reporta y el
+// resto se clona.
+func TestCloneProductCatalog_PartialFailure(t *testing.T) {
+	uc, mockRepo, _ := newCloneUseCase()
+
+	mockRepo.On("GetAllProductsByCategoryID", mock.Anything, "src").Return([]*productEntity.Product{
+		{ID: "p1", Name: "Manzana", Active: true},
+		{ID: "p2", Name: "Pera", Active: true},
+	}, nil)
+	mockRepo.On("GetProductNamesWithPrefixes", mock.Anything, mock.Anything).Return(nil, nil)
+	mockRepo.On("CreatedProduct", mock.Anything, mock.MatchedBy(func(p *productEntity.Product) bool {
+		return p.Name == "Manzana-clone"
+	})).Return(errors.New("duplicate key"))
+	mockRepo.On("CreatedProduct", mock.Anything, mock.MatchedBy(func(p *productEntity.Product) bool {
+		return p.Name == "Pera-clone"
+	})).Return(nil)
+
+	result, err := uc.CloneProductCatalog(context.Background(), "src", "dst", utils.RoleAdmin)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, result.Cloned)
+	assert.Equal(t, []productEntity.CloneError{{ProductID: "p1", Message: "duplicate key"}}, result.Errors)
+	mockRepo.AssertExpectations(t)
+}
+
+// TestCloneProductCatalog_NamesLookupFails verifica que si no se pueden leer
+// los nombres ocupados no se crea ninguna copia.
+func TestCloneProductCatalog_NamesLookupFails(t *testing.T) {
+	uc, mockRepo, _ := newCloneUseCase()
+
+	mockRepo.On("GetAllProductsByCategoryID", mock.Anything, "src").Return([]*productEntity.Product{
+		{ID: "p1", Name: "Manzana", Active: true},
+	}, nil)
+	mockRepo.On("GetProductNamesWithPrefixes", mock.Anything, mock.Anything).Return(nil, errors.New("db error"))
+
+	result, err := uc.CloneProductCatalog(context.Background(), "src", "dst", utils.RoleAdmin)
+
+	assert.Nil(t, result)
+	assert.EqualError(t, err, "db error")
+	mockRepo.AssertNotCalled(t, "CreatedProduct", mock.Anything, mock.Anything)
+}
+
+// TestCloneProductCatalog_SourceNotFound verifica que una categoría origen
+// inexistente devuelve ErrCategoryNotFound.
+func TestCloneProductCatalog_SourceNotFound(t *testing.T) {
+	mockRepo := new(MockProductRepository)
+	mockCategories := new(MockCategoryRepository)
+	uc := usecase.NewProductUseCase(nil, mockRepo, nil, nil, mockCategories)
+
+	mockCategories.On("GetByID", mock.Anything, "src").Return(nil, gorm.ErrRecordNotFound)
+
+	result, err := uc.CloneProductCatalog(context.Background(), "src", "dst", utils.RoleAdmin)
+
+	assert.Nil(t, result)
+	assert.ErrorIs(t, err, usecase.ErrCategoryNotFound)
+	mockRepo.AssertNotCalled(t, "GetAllProductsByCategoryID", mock.Anything, mock.Anything)
+}
+
+// TestCloneProductCatalog_InvalidRequest verifica el rol de administrador y
+// que origen y destino sean distintos.
+func TestCloneProductCatalog_InvalidRequest(t *testing.T) {
+	uc, mockRepo, _ := newCloneUseCase()
+
+	_, err := uc.CloneProductCatalog(context.Background(), "src", "dst", utils.RoleCustomer)
+	assert.ErrorIs(t, err, usecase.ErrForbidden)
+
+	_, err = uc.CloneProductCatalog(context.Background(), "src", "src", utils.RoleAdmin)
+	assert.ErrorIs(t, err, usecase.ErrSameCategory)
+
+	mockRepo.AssertNotCalled(t, "CreatedProduct", mock.Anything, mock.Anything)
+}
+
+// -------------------------------------
+// Tests de GetProductsExpiringSoon
+// -------------------------------------
+
+// TestGetProductsExpiringSoon_Success verifica que la ventana pedida al
+// repositorio va de ahora a ahora+within.
+func TestGetProductsExpiringSoon_Success(t *testing.T) {
+	mockRepo := new(MockProductRepository)
+	uc := usecase.NewProductUseCase(nil, mockRepo, nil, nil, nil)
+
+	within := 48 * time.Hour
+	expected := []*productEntity.Product{{ID: "p1"}, {ID: "p2"}}
+	before := time.Now()
+	mockRepo.On("GetProductsExpiringBetween", mock.Anything,
+		mock.MatchedBy(func(from time.Time) bool { return !from.Before(before) && time.Since(from) < time.Minute }),
+		mock.MatchedBy(func(to time.Time) bool { return !to.Before(before.Add(within)) }),
+		10,
+	).Return(expected, nil)
+
+	products, err := uc.GetProductsExpiringSoon(context.Background(), within, 10, utils.RoleAdmin)
+
+	assert.NoError(t, err)
+	assert.Equal(t, expected, products)
+	call := mockRepo.Calls[0]
+	assert.Equal(t, within, call.Arguments.Get(2).(time.Time).Sub(call.Arguments.Get(1).(time.Time)))
+}
+
+// TestGetProductsExpiringSoon_Validation verifica el rol, la ventana y el
+// límite antes de consultar el repositorio.
+func TestGetProductsExpiringSoon_Validation(t *testing.T) {
+	cases := []struct {
+		name   string
+		within time.Duration
+		limit  int
+		role   string
+		err    error
+	}{
+		{"no admin", time.Hour, 10, utils.RoleCustomer, usecase.ErrForbidden},
+		{"ventana cero", 0, 10, utils.RoleAdmin, usecase.ErrInvalidExpiryWindow},
+		{"ventana negativa", -time.Hour, 10, utils.RoleAdmin, usecase.ErrInvalidExpiryWindow},
+		{"límite cero", time.Hour, 0, utils.RoleAdmin, usecase.ErrInvalidLimit},
+		{"límite excesivo", time.Hour, 101, utils.RoleAdmin, usecase.ErrInvalidLimit},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockRepo := new(MockProductRepository)
+			uc := usecase.NewProductUseCase(nil, mockRepo, nil, nil, nil)
+
+			products, err := uc.GetProductsExpiringSoon(context.Background(), tc.within, tc.limit, tc.role)
+
+			assert.Nil(t, products)
+			assert.ErrorIs(t, err, tc.err)
+			mockRepo.AssertNotCalled(t, "GetProductsExpiringBetween")
+		})
+	}
+}
+
+// -------------------------------------
+// Tests de GetOutOfStockProducts
+// -------------------------------------
+
+// TestGetOutOfStockProducts_Success verifica que se calculan los días sin
+// stock a partir de la última actualización del stock.
+func TestGetOutOfStockProducts_Success(t *testing.T) {
+	mockRepo := new(MockProductRepository)
+	uc := usecase.NewProductUseCase(nil, mockRepo, nil, nil, nil)
+
+	products := []*productEntity.Product{
+		{ID: "p1", StockLastUpdatedAt: time.Now().Add(-(5*24 + 3) * time.Hour)},
+		{ID: "p2", StockLastUpdatedAt: time.Now().Add(-time.Hour)},
+		{ID: "p3"},
+	}
+	pagination := paging.NewPagination(1, 20, 3)
+	mockRepo.On("GetOutOfStockProducts", mock.Anything, (*paging.Pagination)(nil)).Return(products, pagination, nil)
+
+	result, page, err := uc.GetOutOfStockProducts(context.Background(), utils.RoleAdmin, nil)
+
+	assert.NoError(t, err)
+	assert.Equal(t, pagination, page)
+	assert.Equal(t, 5, result[0].DaysOutOfStock)
+	assert.Equal(t, 0, result[1].DaysOutOfStock)
+	assert.Equal(t, 0, result[2].DaysOutOfStock)
+}
+
+// TestGetOutOfStockProducts_Paging verifica que la paginación pedida llega al
+// repositorio.
+func TestGetOutOfStockProducts_Paging(t *testing.T) {
+	mockRepo := new(MockProductRepository)
+	uc := usecase.NewProductUseCase(nil, mockRepo, nil, nil, nil)
+
+	req := &paging.Pagination{Page: 2, Size: 10}
+	mockRepo.On("GetOutOfStockProducts", mock.Anything, req).Return([]*productEntity.Product{}, paging.NewPagination(2, 10, 15), nil)
+
+	_, page, err := uc.GetOutOfStockProducts(context.Background(), utils.RoleAdmin, req)
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), page.Page)
+	mockRepo.AssertExpectations(t)
+}
+
+// TestGetOutOfStockProducts_Forbidden verifica que solo un admin puede
+// consultar los productos sin stock.
+func TestGetOutOfStockProducts_Forbidden(t *testing.T) {
+	mockRepo := new(MockProductRepository)
+	uc := usecase.NewProductUseCase(nil, mockRepo, nil, nil, nil)
+
+	products, page, err := uc.GetOutOfStockProducts(context.Background(), utils.RoleCustomer, nil)
+
+	assert.Nil(t, products)
+	assert.Nil(t, page)
+	assert.ErrorIs(t, err, usecase.ErrForbidden)
+	mockRepo.AssertNotCalled(t, "GetOutOfStockProducts", mock.Anything, mock.Anything)
+}
@@ -0,0 +1,209 @@
+package usecase_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	prodDto "ecommerce_clean/internals/product/controller/dto"
+	productEntity "ecommerce_clean/internals/product/entity"
+	"ecommerce_clean/internals/product/usecase"
+	"ecommerce_clean/pkgs/validation"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockReviewRepository struct {
+	mock.Mock
+}
+
+func (m *MockReviewRepository) CreateReview(ctx context.Context, review *productEntity.Review) error {
+	args := m.Called(ctx, review)
+	return args.Error(0)
+}
+
+func (m *MockReviewRepository) GetReviewsByProductID(ctx context.Context, productID string) ([]*productEntity.Review, error) {
+	args := m.Called(ctx, productID)
+	var reviews []*productEntity.Review
+	if v := args.Get(0); v != nil {
+		reviews = v.([]*productEntity.Review)
+	}
+	return reviews, args.Error(1)
+}
+
+func (m *MockReviewRepository) GetReviewSummary(ctx context.Context, productID string) (map[int]int, error) {
+	args := m.Called(ctx, productID)
+	var counts map[int]int
+	if v := args.Get(0); v != nil {
+		counts = v.(map[int]int)
+	}
+	return counts, args.Error(1)
+}
+
+func (m *MockReviewRepository) HasUserReviewed(ctx context.Context, productID, userID string) (bool, error) {
+	args := m.Called(ctx, productID, userID)
+	return args.Bool(0), args.Error(1)
+}
+
+// -------------------------------------
+// Tests de SubmitReview
+// -------------------------------------
+
+// TestSubmitReview_Success verifica que se guarda la reseña del usuario.
+func TestSubmitReview_Success(t *testing.T) {
+	mockRepo := new(MockProductRepository)
+	reviewRepo := new(MockReviewRepository)
+	uc := usecase.NewReviewUseCase(validation.New(), reviewRepo, mockRepo)
+
+	mockRepo.On("GetProductById", mock.Anything, "p1").Return(&productEntity.Product{ID: "p1"}, nil)
+	reviewRepo.On("HasUserReviewed", mock.Anything, "p1", "u1").Return(false, nil)
+	reviewRepo.On("CreateReview", mock.Anything, mock.MatchedBy(func(r *productEntity.Review) bool {
+		return r.ProductID == "p1" && r.UserID == "u1" && r.Rating == 4 && r.Comment == "Muy bueno"
+	})).Return(nil)
+
+	review, err := uc.SubmitReview(context.Background(), &prodDto.SubmitReviewRequest{
+		ProductID: "p1", UserID: "u1", Rating: 4, Comment: "Muy bueno",
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 4, review.Rating)
+	reviewRepo.AssertExpectations(t)
+}
+
+// TestSubmitReview_InvalidRating verifica que la puntuación debe estar entre 1
+// y 5.
+func TestSubmitReview_InvalidRating(t *testing.T) {
+	reviewRepo := new(MockReviewRepository)
+	uc := usecase.NewReviewUseCase(validation.New(), reviewRepo, new(MockProductRepository))
+
+	for _, rating := range []int{0, 6, -1} {
+		review, err := uc.SubmitReview(context.Background(), &prodDto.SubmitReviewRequest{
+			ProductID: "p1", UserID: "u1", Rating: rating,
+		})
+		assert.Nil(t, review)
+		assert.Error(t, err, "rating=%d", rating)
+	}
+	reviewRepo.AssertNotCalled(t, "CreateReview", mock.Anything, mock.Anything)
+}
+
+// TestSubmitReview_CommentTooLong verifica el límite de 1000 caracteres del
+// comentario.
+func TestSubmitReview_CommentTooLong(t *testing.T) {
+	mockRepo := new(MockProductRepository)
+	reviewRepo := new(MockReviewRepository)
+	uc := usecase.NewReviewUseCase(validation.New(), reviewRepo, mockRepo)
+
+	mockRepo.On("GetProductById", mock.Anything, "p1").Return(&productEntity.Product{ID: "p1"}, nil)
+	reviewRepo.On("HasUserReviewed", mock.Anything, "p1", "u1").Return(false, nil)
+	reviewRepo.On("CreateReview", mock.Anything, mock.Anything).Return(nil)
+
+	_, err := uc.SubmitReview(context.Background(), &prodDto.SubmitReviewRequest{
+		ProductID: "p1", UserID: "u1", Rating: 5, Comment: strings.Repeat("ñ", 1000),
+	})
+	assert.NoError(t, err)
+
+	review, err := uc.SubmitReview(context.Background(), &prodDto.SubmitReviewRequest{
+		ProductID: "p1", UserID: "u1", Rating: 5, Comment: strings.Repeat("a", 1001),
+	})
+	assert.Nil(t, review)
+	assert.Error(t, err)
+	reviewRepo.AssertNumberOfCalls(t, "CreateReview", 1)
+}
+
+// TestSubmitReview_AlreadyReviewed verifica que un usuario solo puede reseñar
+// un producto una vez.
+func TestSubmitReview_AlreadyReviewed(t *testing.T) {
+	mockRepo := new(MockProductRepository)
+	reviewRepo := new(MockReviewRepository)
+	uc := usecase.NewReviewUseCase(validation.New(), reviewRepo, mockRepo)
+
+	mockRepo.On("GetProductById", mock.Anything, "p1").Return(&productEntity.Product{ID: "p1"}, nil)
+	reviewRepo.On("HasUserReviewed", mock.Anything, "p1", "u1").Return(true, nil)
+
+	review, err := uc.SubmitReview(context.Background(), &prodDto.SubmitReviewRequest{
+		ProductID: "p1", UserID: "u1", Rating: 3,
+	})
+
+	assert.Nil(t, review)
+	assert.ErrorIs(t, err, usecase.ErrAlreadyReviewed)
+	reviewRepo.AssertNotCalled(t, "CreateReview", mock.Anything, mock.Anything)
+}
+
+// TestSubmitReview_ProductNotFound verifica que no se puede reseñar un
+// producto inexistente.
+func TestSubmitReview_ProductNotFound(t *testing.T) {
+	mockRepo := new(MockProductRepository)
+	reviewRepo := new(MockReviewRepository)
+	uc := usecase.NewReviewUseCase(validation.New(), reviewRepo, mockRepo)
+
+	mockRepo.On("GetProductById", mock.Anything, "p1").Return((*productEntity.Product)(nil), errors.New("not found"))
+
+	review, err := uc.SubmitReview(context.Background(), &prodDto.SubmitReviewRequest{
+		ProductID: "p1", UserID: "u1", Rating: 3,
+	})
+
+	assert.Nil(t, review)
+	assert.EqualError(t, err, "not found")
+	reviewRepo.AssertNotCalled(t, "HasUserReviewed", mock.Anything, mock.Anything, mock.Anything)
+}
+
+// -------------------------------------
+// Tests de GetProductReviewSummary
+// -------------------------------------
+
+// TestGetProductReviewSummary_Distribution verifica la media, el total y que
+// la distribución incluye todas las puntuaciones.
+func TestGetProductReviewSummary_Distribution(t *testing.T) {
+	reviewRepo := new(MockReviewRepository)
+	uc := usecase.NewReviewUseCase(nil, reviewRepo, nil)
+
+	reviewRepo.On("GetReviewSummary", mock.Anything, "p1").Return(map[int]int{5: 3, 4: 1, 1: 2}, nil)
+
+	summary, err := uc.GetProductReviewSummary(context.Background(), "p1")
+
+	assert.NoError(t, err)
+	assert.Equal(t, "p1", summary.ProductID)
+	assert.Equal(t, 6, summary.TotalReviews)
+	assert.Equal(t, 3.5, summary.AverageRating)
+	assert.Equal(t, map[int]int{1: 2, 2: 0, 3: 0, 4: 1, 5: 3}, summary.RatingDistribution)
+}
+
+// TestGetProductReviewSummary_NoReviews verifica que sin reseñas la media es 0
+// y la distribución está a cero.
+func TestGetProductReviewSummary_NoReviews(t *testing.T) {
+	reviewRepo := new(MockReviewRepository)
+	uc := usecase.NewReviewUseCase(nil, reviewRepo, nil)
+
+	reviewRepo.On("GetReviewSummary", mock.Anything, "p1").Return(map[int]int{}, nil)
+
+	summary, err := uc.GetProductReviewSummary(context.Background(), "p1")
+
+	assert.NoError(t, err)
+	assert.Zero(t, summary.TotalReviews)
+	assert.Zero(t, summary.AverageRating)
+	assert.Equal(t, map[int]int{1: 0, 2: 0, 3: 0, 4: 0, 5: 0}, summary.RatingDistribution)
+}
+
+// TestSubmitReview_ConcurrentDuplicate verifica que, si otra petición guarda la
+// reseña entre la comprobación y el alta, el índice único se traduce en
+// ErrAlreadyReviewed.
+func TestSubmitReview_ConcurrentDuplicate(t *testing.T) {
+	mockRepo := new(MockProductRepository)
+	reviewRepo := new(MockReviewRepository)
+	uc := usecase.NewReviewUseCase(validation.New(), reviewRepo, mockRepo)
+
+	mockRepo.On("GetProductById", mock.Anything, "p1").Return(&productEntity.Product{ID: "p1"}, nil)
+	reviewRepo.On("HasUserReviewed", mock.Anything, "p1", "u1").Return(false, nil)
+	reviewRepo.On("CreateReview", mock.Anything, mock.Anything).Return(errors.New(
+		`ERROR: duplicate key value violates unique constraint "unique_product_review_user" (SQLSTATE 23505)`,
+	))
+
+	review, err := uc.SubmitReview(context.Background(), &prodDto.SubmitReviewRequest{
+		ProductID: "p1", UserID: "u1", Rating: 3,
+	})
+
+	assert.Nil(t, review)
+	assert.Equal(t, usecase.ErrAlreadyReviewed, err)
+}
@@ -1,6 +1,7 @@
 package entity
 
 import (
+	"ecommerce_clean/configs"
 	cartEntity "ecommerce_clean/internals/cart/entity"
 	"ecommerce_clean/utils"
 	"time"
@@ -29,12 +30,9 @@ func (user *User) BeforeCreate(tx *gorm.DB) error {
 }
 
 func (user *User) AfterCreate(tx *gorm.DB) error {
-	cart := cartEntity.Cart{
-		ID:     uuid.New().String(),
-		UserID: user.ID,
-	}
+	cart := cartEntity.NewCart(user.ID, configs.CartTTL)
 
-	if err := tx.Create(&cart).Error; err != nil {
+	if err := tx.Create(cart).Error; err != nil {
 		return err
 	}
 
@@ -0,0 +1,106 @@
+package cache
+
+import (
+	"container/list"
+	"sync"
+)
+
+type entry struct {
+	key   string
+	value interface{}
+}
+
+// MapCache is an in-memory cache with least-recently-used eviction. A capacity
+// of zero or less leaves the cache unbounded.
+type MapCache struct {
+	mu       sync.Mutex
+	capacity int
+	items    map[string]*list.Element
+	order    *list.List
+	hits     int64
+	lookups  int64
+}
+
+// NewMapCache returns an unbounded MapCache
+func NewMapCache() *MapCache {
+	return NewMapCacheWithCapacity(0)
+}
+
+// NewMapCacheWithCapacity returns a MapCache holding at most capacity entries
+func NewMapCacheWithCapacity(capacity int) *MapCache {
+	return &MapCache{
+		capacity: capacity,
+		items:    make(map[string]*list.Element),
+		order:    list.New(),
+	}
+}
+
+// Get returns the value stored under key and marks it as most recently used
+func (c *MapCache) Get(key string) (interface{}, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.lookups++
+	elem, ok := c.items[key]
+	if !ok {
+		return nil, false
+	}
+
+	c.hits++
+	c.order.MoveToFront(elem)
+	return elem.Value.(*entry).value, true
+}
+
+// Set stores value under key, evicting the least recently used entry when the
+// cache is full.
+func (c *MapCache) Set(key string, value interface{}) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.items[key]; ok {
+		elem.Value.(*entry).value = value
+		c.order.MoveToFront(elem)
+		return
+	}
+
+	if c.capacity > 0 && c.order.Len() >= c.capacity {
+		if oldest := c.order.Back(); oldest != nil {
+			c.order.Remove(oldest)
+			delete(c.items, oldest.Value.(*entry).key)
+		}
+	}
+
+	c.items[key] = c.order.PushFront(&entry{key: key, value: value})
+}
+
+// Remove deletes the given keys
+func (c *MapCache) Remove(keys ...string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, key := range keys {
+		if elem, ok := c.items[key]; ok {
+			c.order.Remove(elem)
+			delete(c.items, key)
+		}
+	}
+}
+
+// Size returns the number of cached entries
+func (c *MapCache) Size() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.order.Len()
+}
+
+// HitRate returns hits divided by total lookups, or 0 before any lookup
+func (c *MapCache) HitRate() float64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.lookups == 0 {
+		return 0
+	}
+	return float64(c.hits) / float64(c.lookups)
+}
@@ -0,0 +1,102 @@
+package cache_test
+
+import (
+	"testing"
+
+	"ecommerce_clean/pkgs/cache"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// -------------------------------------
+// Tests de MapCache
+// -------------------------------------
+
+// TestMapCache_EvictsAtCapacity verifica que al superar la capacidad se
+// descarta la entrada menos usada recientemente.
+func TestMapCache_EvictsAtCapacity(t *testing.T) {
+	c := cache.NewMapCacheWithCapacity(2)
+
+	c.Set("a", 1)
+	c.Set("b", 2)
+	assert.Equal(t, 2, c.Size())
+
+	c.Set("c", 3)
+
+	assert.Equal(t, 2, c.Size())
+	_, ok := c.Get("a")
+	assert.False(t, ok)
+	value, ok := c.Get("c")
+	assert.True(t, ok)
+	assert.Equal(t, 3, value)
+}
+
+// TestMapCache_OverwriteDoesNotEvict verifica que actualizar una clave
+// existente no cuenta como entrada nueva.
+func TestMapCache_OverwriteDoesNotEvict(t *testing.T) {
+	c := cache.NewMapCacheWithCapacity(2)
+
+	c.Set("a", 1)
+	c.Set("b", 2)
+	c.Set("a", 10)
+
+	assert.Equal(t, 2, c.Size())
+	value, _ := c.Get("a")
+	assert.Equal(t, 10, value)
+	_, ok := c.Get("b")
+	assert.True(t, ok)
+}
+
+// TestMapCache_LRUOrder verifica que las lecturas renuevan la entrada y cambian
+// el orden de expulsión.
+func TestMapCache_LRUOrder(t *testing.T) {
+	c := cache.NewMapCacheWithCapacity(3)
+
+	c.Set("a", 1)
+	c.Set("b", 2)
+	c.Set("c", 3)
+
+	c.Get("a")
+	c.Get("b")
+	c.Set("d", 4) // expulsa c
+
+	_, ok := c.Get("c")
+	assert.False(t, ok)
+
+	c.Get("a")
+	c.Set("e", 5) // expulsa b
+
+	_, ok = c.Get("b")
+	assert.False(t, ok)
+	for _, key := range []string{"a", "d", "e"} {
+		_, ok := c.Get(key)
+		assert.True(t, ok, key)
+	}
+}
+
+// TestMapCache_HitRate verifica el cálculo de aciertos sobre el total de
+// lecturas.
+func TestMapCache_HitRate(t *testing.T) {
+	c := cache.NewMapCacheWithCapacity(2)
+	assert.Equal(t, 0.0, c.HitRate())
+
+	c.Set("a", 1)
+	c.Get("a")
+	c.Get("a")
+	c.Get("a")
+	c.Get("missing")
+
+	assert.InDelta(t, 0.75, c.HitRate(), 1e-9)
+}
+
+// TestMapCache_Unbounded verifica que sin capacidad no se expulsa nada.
+func TestMapCache_Unbounded(t *testing.T) {
+	c := cache.NewMapCache()
+
+	for i := 0; i < 100; i++ {
+		c.Set(string(rune('a'+i%26))+string(rune('0'+i/26)), i)
+	}
+	c.Remove("a0")
+
+	assert.Equal(t, 99, c.Size())
+}
@@ -0,0 +1,58 @@
+package database
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+)
+
+type txKey struct{}
+
+type txHolder struct {
+	tx *gorm.DB
+}
+
+// TxFromContext returns the transaction started by WithTransaction that ctx
+// belongs to, if any.
+func TxFromContext(ctx context.Context) (*gorm.DB, bool) {
+	holder, ok := ctx.Value(txKey{}).(*txHolder)
+	if !ok || holder.tx == nil {
+		return nil, false
+	}
+
+	return holder.tx, true
+}
+
+// WithTransaction runs fn inside a transaction on db. It commits when fn
+// returns nil and rolls back when fn returns an error or panics; panics are
+// re-raised after the rollback.
+//
+// The transaction travels in tx.Statement.Context, so a nested WithTransaction
+// called with that context runs fn in the same transaction instead of
+// opening a new one.
+func WithTransaction(ctx context.Context, db *gorm.DB, fn func(tx *gorm.DB) error) (err error) {
+	if tx, ok := TxFromContext(ctx); ok {
+		return fn(tx)
+	}
+
+	holder := &txHolder{}
+	tx := db.WithContext(context.WithValue(ctx, txKey{}, holder)).Begin()
+	if tx.Error != nil {
+		return tx.Error
+	}
+	holder.tx = tx
+
+	defer func() {
+		if p := recover(); p != nil {
+			tx.Rollback()
+			panic(p)
+		}
+	}()
+
+	if err := fn(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit().Error
+}
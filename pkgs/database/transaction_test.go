@@ -0,0 +1,97 @@
+package database_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"ecommerce_clean/db/dbtest"
+	"ecommerce_clean/pkgs/database"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+type item struct {
+	ID   uint
+	Name string
+}
+
+func newTestDB(t *testing.T) *gorm.DB {
+	return dbtest.NewDatabase(t, &item{}).GetDB()
+}
+
+func countItems(t *testing.T, db *gorm.DB) int64 {
+	var total int64
+	require.NoError(t, db.Model(&item{}).Count(&total).Error)
+	return total
+}
+
+// TestWithTransaction_Commit verifica que los cambios se confirman si fn no
+// devuelve error.
+func TestWithTransaction_Commit(t *testing.T) {
+	db := newTestDB(t)
+
+	err := database.WithTransaction(context.Background(), db, func(tx *gorm.DB) error {
+		return tx.Create(&item{Name: "a"}).Error
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), countItems(t, db))
+}
+
+// TestWithTransaction_RollbackOnError verifica que un error de fn deshace los
+// cambios y se devuelve tal cual.
+func TestWithTransaction_RollbackOnError(t *testing.T) {
+	db := newTestDB(t)
+	errFail := errors.New("fail")
+
+	err := database.WithTransaction(context.Background(), db, func(tx *gorm.DB) error {
+		require.NoError(t, tx.Create(&item{Name: "a"}).Error)
+		return errFail
+	})
+
+	assert.ErrorIs(t, err, errFail)
+	assert.Equal(t, int64(0), countItems(t, db))
+}
+
+// TestWithTransaction_RollbackOnPanic verifica que un panic deshace los cambios
+// y se vuelve a lanzar.
+func TestWithTransaction_RollbackOnPanic(t *testing.T) {
+	db := newTestDB(t)
+
+	assert.PanicsWithValue(t, "boom", func() {
+		_ = database.WithTransaction(context.Background(), db, func(tx *gorm.DB) error {
+			require.NoError(t, tx.Create(&item{Name: "a"}).Error)
+			panic("boom")
+		})
+	})
+
+	assert.Equal(t, int64(0), countItems(t, db))
+}
+
+// TestWithTransaction_NestedReusesTransaction verifica que una llamada anidada
+// con el contexto de la transacción reutiliza la misma transacción, de modo
+// que un error en la externa deshace también lo hecho en la interna.
+func TestWithTransaction_NestedReusesTransaction(t *testing.T) {
+	db := newTestDB(t)
+	errFail := errors.New("fail")
+
+	err := database.WithTransaction(context.Background(), db, func(outer *gorm.DB) error {
+		current, ok := database.TxFromContext(outer.Statement.Context)
+		require.True(t, ok)
+		assert.Same(t, outer, current)
+
+		err := database.WithTransaction(outer.Statement.Context, db, func(inner *gorm.DB) error {
+			assert.Same(t, outer, inner)
+			return inner.Create(&item{Name: "nested"}).Error
+		})
+		require.NoError(t, err)
+
+		return errFail
+	})
+
+	assert.ErrorIs(t, err, errFail)
+	assert.Equal(t, int64(0), countItems(t, db))
+}
@@ -0,0 +1,55 @@
+package paging
+
+import (
+	"encoding/base64"
+	"errors"
+	"strings"
+	"time"
+)
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// CursorPagination pages through rows ordered by a timestamp and id instead
+// of an offset, so rows inserted between requests do not shift the pages.
+// After and Before are cursors from EncodeCursor; After wins when both are set.
+type CursorPagination struct {
+	Limit  int    `json:"limit"`
+	After  string `json:"after,omitempty"`
+	Before string `json:"before,omitempty"`
+}
+
+func NewCursorPagination(limit int, after, before string) *CursorPagination {
+	if limit <= 0 || limit > 1000 {
+		limit = int(DefaultPageSize)
+	}
+
+	return &CursorPagination{Limit: limit, After: after, Before: before}
+}
+
+// EncodeCursor builds an opaque cursor pointing at the row with the given id
+// and timestamp.
+func EncodeCursor(id string, ts time.Time) string {
+	raw := ts.UTC().Format(time.RFC3339Nano) + "|" + id
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// DecodeCursor reverses EncodeCursor. Any malformed token yields
+// ErrInvalidCursor.
+func DecodeCursor(token string) (id string, ts time.Time, err error) {
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return "", time.Time{}, ErrInvalidCursor
+	}
+
+	stamp, id, ok := strings.Cut(string(raw), "|")
+	if !ok || id == "" {
+		return "", time.Time{}, ErrInvalidCursor
+	}
+
+	ts, err = time.Parse(time.RFC3339Nano, stamp)
+	if err != nil {
+		return "", time.Time{}, ErrInvalidCursor
+	}
+
+	return id, ts, nil
+}
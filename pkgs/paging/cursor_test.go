@@ -0,0 +1,58 @@
+package paging_test
+
+import (
+	"encoding/base64"
+	"testing"
+	"time"
+
+	"ecommerce_clean/pkgs/paging"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// -------------------------------------
+// Tests de cursores
+// -------------------------------------
+
+// TestCursor_RoundTrip verifica que DecodeCursor recupera el id y la fecha
+// codificados, con precisión de nanosegundos.
+func TestCursor_RoundTrip(t *testing.T) {
+	ts := time.Date(2026, 3, 4, 5, 6, 7, 891, time.FixedZone("X", 3600))
+
+	id, decoded, err := paging.DecodeCursor(paging.EncodeCursor("o1", ts))
+
+	assert.NoError(t, err)
+	assert.Equal(t, "o1", id)
+	assert.True(t, ts.Equal(decoded))
+}
+
+// TestDecodeCursor_Corrupted verifica que un cursor mal formado devuelve
+// ErrInvalidCursor.
+func TestDecodeCursor_Corrupted(t *testing.T) {
+	encode := func(raw string) string { return base64.RawURLEncoding.EncodeToString([]byte(raw)) }
+
+	cases := []struct {
+		name  string
+		token string
+	}{
+		{"no base64", "%%%"},
+		{"sin separador", encode("2026-01-01T00:00:00Z")},
+		{"sin id", encode("2026-01-01T00:00:00Z|")},
+		{"fecha inválida", encode("ayer|o1")},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			_, _, err := paging.DecodeCursor(c.token)
+			assert.ErrorIs(t, err, paging.ErrInvalidCursor)
+		})
+	}
+}
+
+// TestNewCursorPagination_DefaultLimit verifica que un límite inválido usa el
+// tamaño de página por defecto.
+func TestNewCursorPagination_DefaultLimit(t *testing.T) {
+	assert.Equal(t, int(paging.DefaultPageSize), paging.NewCursorPagination(0, "", "").Limit)
+	assert.Equal(t, int(paging.DefaultPageSize), paging.NewCursorPagination(5000, "", "").Limit)
+	assert.Equal(t, 5, paging.NewCursorPagination(5, "a", "b").Limit)
+}
@@ -15,6 +15,9 @@ type Pagination struct {
 	TotalPages  int64 `json:"total_pages"`
 	HasPrevious bool  `json:"has_previous"`
 	HasNext     bool  `json:"has_next"`
+	// NextCursor is only set by cursor-paginated listings and is empty on the
+	// last page.
+	NextCursor string `json:"next_cursor,omitempty"`
 }
 
 func NewPagination(page int64, size int64, total int64) *Pagination {
@@ -0,0 +1,19 @@
+package paging
+
+// PaginateSlice returns the requested page of an in-memory slice together with
+// its pagination metadata. Out-of-range pages yield an empty slice.
+func PaginateSlice[T any](items []T, page, limit int) ([]T, *Pagination) {
+	pagination := NewPagination(int64(page), int64(limit), int64(len(items)))
+
+	start := pagination.Skip
+	if start >= int64(len(items)) {
+		return []T{}, pagination
+	}
+
+	end := start + pagination.Size
+	if end > int64(len(items)) {
+		end = int64(len(items))
+	}
+
+	return items[start:end], pagination
+}
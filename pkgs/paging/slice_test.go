@@ -0,0 +1,87 @@
+package paging_test
+
+import (
+	"testing"
+
+	"ecommerce_clean/pkgs/paging"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func stringPtrs(values ...string) []*string {
+	items := make([]*string, 0, len(values))
+	for i := range values {
+		items = append(items, &values[i])
+	}
+	return items
+}
+
+// -------------------------------------
+// Tests de PaginateSlice
+// -------------------------------------
+
+// TestPaginateSlice_Empty verifica que una entrada vacía devuelve un slice
+// vacío con Total=0.
+func TestPaginateSlice_Empty(t *testing.T) {
+	page, pagination := paging.PaginateSlice([]*string{}, 1, 10)
+
+	assert.NotNil(t, page)
+	assert.Empty(t, page)
+	assert.Equal(t, int64(0), pagination.TotalCount)
+	assert.Equal(t, int64(0), pagination.TotalPages)
+}
+
+// TestPaginateSlice_FirstPage verifica el corte y los metadatos de la primera
+// página.
+func TestPaginateSlice_FirstPage(t *testing.T) {
+	items := stringPtrs("a", "b", "c", "d", "e")
+
+	page, pagination := paging.PaginateSlice(items, 1, 2)
+
+	assert.Equal(t, items[0:2], page)
+	assert.Equal(t, int64(1), pagination.Page)
+	assert.Equal(t, int64(2), pagination.Size)
+	assert.Equal(t, int64(5), pagination.TotalCount)
+	assert.Equal(t, int64(3), pagination.TotalPages)
+	assert.False(t, pagination.HasPrevious)
+	assert.True(t, pagination.HasNext)
+}
+
+// TestPaginateSlice_LastPartialPage verifica que la última página puede traer
+// menos elementos que el límite.
+func TestPaginateSlice_LastPartialPage(t *testing.T) {
+	items := stringPtrs("a", "b", "c", "d", "e")
+
+	page, pagination := paging.PaginateSlice(items, 3, 2)
+
+	assert.Len(t, page, 1)
+	assert.Equal(t, "e", *page[0])
+	assert.Equal(t, int64(4), pagination.Skip)
+	assert.True(t, pagination.HasPrevious)
+	assert.False(t, pagination.HasNext)
+}
+
+// TestPaginateSlice_OutOfRange verifica que una página fuera de rango devuelve
+// un slice vacío sin perder el total.
+func TestPaginateSlice_OutOfRange(t *testing.T) {
+	items := stringPtrs("a", "b", "c")
+
+	page, pagination := paging.PaginateSlice(items, 5, 2)
+
+	assert.Empty(t, page)
+	assert.Equal(t, int64(5), pagination.Page)
+	assert.Equal(t, int64(3), pagination.TotalCount)
+	assert.Equal(t, int64(2), pagination.TotalPages)
+}
+
+// TestPaginateSlice_DefaultLimit verifica que un límite inválido usa el tamaño
+// de página por defecto.
+func TestPaginateSlice_DefaultLimit(t *testing.T) {
+	items := stringPtrs("a", "b", "c")
+
+	page, pagination := paging.PaginateSlice(items, 0, 0)
+
+	assert.Len(t, page, 3)
+	assert.Equal(t, int64(1), pagination.Page)
+	assert.Equal(t, paging.DefaultPageSize, pagination.Size)
+}
@@ -26,3 +26,22 @@ func ToOrderStatus(status string) (OrderStatus, error) {
 	}
 	return "", fmt.Errorf("invalid order status: %s", status)
 }
+
+// AllowedTransitions lists the statuses each order status may move to. Done
+// and canceled orders are final.
+var AllowedTransitions = map[OrderStatus][]OrderStatus{
+	OrderStatusNew:        {OrderStatusInProgress, OrderStatusCanceled},
+	OrderStatusInProgress: {OrderStatusDone, OrderStatusCanceled},
+	OrderStatusDone:       {},
+	OrderStatusCanceled:   {},
+}
+
+// CanTransitionTo reports whether AllowedTransitions lets s move to next.
+func (s OrderStatus) CanTransitionTo(next OrderStatus) bool {
+	for _, allowed := range AllowedTransitions[s] {
+		if allowed == next {
+			return true
+		}
+	}
+	return false
+}
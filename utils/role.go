@@ -0,0 +1,9 @@
+package utils
+
+const (
+	RoleAdmin          = "admin"
+	RoleSupport        = "support"
+	RoleCustomer       = "customer"
+	RoleSupplier       = "supplier"
+	RolePaymentGateway = "payment_gateway"
+)